@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+)
+
+const (
+	bundleFormatPEM  = "pem"
+	bundleFormatDER  = "der"
+	bundleFormatJWKS = "jwks"
+)
+
+func NewFetchBundleCommand() cli.Command {
+	return newFetchBundleCommand(common_cli.DefaultEnv, newWorkloadClient)
+}
+
+func newFetchBundleCommand(env *common_cli.Env, clientMaker workloadClientMaker) cli.Command {
+	return adaptCommand(env, clientMaker, new(fetchBundleCommand))
+}
+
+// fetchBundleCommand fetches the trust bundles from the Workload API and
+// outputs them in formats that workloads unable to parse SPIFFE bundles can
+// consume directly: the X.509 authorities as concatenated PEM blocks or DER
+// certificates, and the JWT authorities as a JWKS document.
+type fetchBundleCommand struct {
+	formats     common_cli.CommaStringsFlag
+	trustDomain string
+	writePath   string
+}
+
+func (*fetchBundleCommand) name() string {
+	return "fetch bundle"
+}
+
+func (*fetchBundleCommand) synopsis() string {
+	return "Fetches the trust bundles from the Workload API as PEM, DER or JWKS"
+}
+
+func (c *fetchBundleCommand) appendFlags(fs *flag.FlagSet) {
+	fs.Var(&c.formats, "format", "Comma separated list of the formats to output the bundles in, among pem, der and jwks (default pem)")
+	fs.StringVar(&c.trustDomain, "trustDomain", "", "Trust domain of the bundle to fetch (optional, defaults to all bundles)")
+	fs.StringVar(&c.writePath, "write", "", "Write the bundles to the specified directory, one file per trust domain and format (optional)")
+}
+
+func (c *fetchBundleCommand) run(ctx context.Context, env *common_cli.Env, client *workloadClient) error {
+	formats := []string(c.formats)
+	if len(formats) == 0 {
+		formats = []string{bundleFormatPEM}
+	}
+	var wantX509, wantJWT bool
+	for _, format := range formats {
+		switch format {
+		case bundleFormatPEM, bundleFormatDER:
+			wantX509 = true
+		case bundleFormatJWKS:
+			wantJWT = true
+		default:
+			return fmt.Errorf("unknown format %q; must be one of %q, %q or %q", format, bundleFormatPEM, bundleFormatDER, bundleFormatJWKS)
+		}
+	}
+	if c.writePath == "" && len(formats) > 1 {
+		return errors.New("-write is required to output more than one format")
+	}
+
+	var filter spiffeid.TrustDomain
+	if c.trustDomain != "" {
+		var err error
+		filter, err = spiffeid.TrustDomainFromString(c.trustDomain)
+		if err != nil {
+			return fmt.Errorf("invalid trust domain: %w", err)
+		}
+	}
+
+	var x509Bundles, jwtBundles map[spiffeid.TrustDomain][]byte
+	if wantX509 {
+		resp, err := c.fetchX509Bundles(ctx, client)
+		if err != nil {
+			return err
+		}
+		x509Bundles, err = filterBundles(resp.Bundles, filter)
+		if err != nil {
+			return err
+		}
+	}
+	if wantJWT {
+		resp, err := c.fetchJWTBundles(ctx, client)
+		if err != nil {
+			return err
+		}
+		jwtBundles, err = filterBundles(resp.Bundles, filter)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, format := range formats {
+		bundles := x509Bundles
+		if format == bundleFormatJWKS {
+			bundles = jwtBundles
+		}
+		if len(bundles) == 0 {
+			if c.trustDomain != "" {
+				return fmt.Errorf("no bundle for trust domain %q", filter)
+			}
+			return errors.New("no bundles received")
+		}
+
+		encoded := make(map[spiffeid.TrustDomain][]byte, len(bundles))
+		for td, bundle := range bundles {
+			data, err := encodeBundle(format, bundle)
+			if err != nil {
+				return fmt.Errorf("failed to encode bundle for trust domain %q: %w", td, err)
+			}
+			encoded[td] = data
+		}
+
+		if c.writePath != "" {
+			if err := writeBundles(env, c.writePath, format, encoded); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := printBundles(env, format, encoded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *fetchBundleCommand) fetchX509Bundles(ctx context.Context, client *workloadClient) (*workload.X509BundlesResponse, error) {
+	ctx, cancel := client.prepareContext(ctx)
+	defer cancel()
+	stream, err := client.FetchX509Bundles(ctx, &workload.X509BundlesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive X.509 bundles: %w", err)
+	}
+	return stream.Recv()
+}
+
+func (c *fetchBundleCommand) fetchJWTBundles(ctx context.Context, client *workloadClient) (*workload.JWTBundlesResponse, error) {
+	ctx, cancel := client.prepareContext(ctx)
+	defer cancel()
+	stream, err := client.FetchJWTBundles(ctx, &workload.JWTBundlesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive JWT bundles: %w", err)
+	}
+	return stream.Recv()
+}
+
+// filterBundles parses the trust domains the bundles are keyed by and keeps
+// the bundle of the given trust domain, if any.
+func filterBundles(bundles map[string][]byte, filter spiffeid.TrustDomain) (map[spiffeid.TrustDomain][]byte, error) {
+	out := make(map[spiffeid.TrustDomain][]byte, len(bundles))
+	for key, bundle := range bundles {
+		td, err := spiffeid.TrustDomainFromString(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trust domain %q in response: %w", key, err)
+		}
+		if !filter.IsZero() && td != filter {
+			continue
+		}
+		out[td] = bundle
+	}
+	return out, nil
+}
+
+// encodeBundle encodes the bundle in the given format. X.509 bundles are
+// received as concatenated DER certificates and JWT bundles as JWKS
+// documents.
+func encodeBundle(format string, bundle []byte) ([]byte, error) {
+	switch format {
+	case bundleFormatPEM:
+		certs, err := x509.ParseCertificates(bundle)
+		if err != nil {
+			return nil, err
+		}
+		var data []byte
+		for _, cert := range certs {
+			data = append(data, pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: cert.Raw,
+			})...)
+		}
+		return data, nil
+	case bundleFormatDER:
+		if _, err := x509.ParseCertificates(bundle); err != nil {
+			return nil, err
+		}
+		return bundle, nil
+	default:
+		return bundle, nil
+	}
+}
+
+// writeBundles writes each bundle to <trust domain>.<format> in the given
+// directory.
+func writeBundles(env *common_cli.Env, dir, format string, bundles map[spiffeid.TrustDomain][]byte) error {
+	for _, td := range sortedTrustDomains(bundles) {
+		bundlePath := path.Join(dir, fmt.Sprintf("%s.%s", td.String(), format))
+		if err := env.Printf("Writing %s bundle for trust domain %s to file %s.\n", format, td, bundlePath); err != nil {
+			return err
+		}
+		if err := os.WriteFile(bundlePath, bundles[td], 0644); err != nil { // nolint: gosec // expected permission for bundles
+			return err
+		}
+	}
+	return nil
+}
+
+// printBundles writes the bundles to stdout. PEM bundles can be
+// concatenated, but DER and JWKS bundles cannot be told apart, so only one
+// is printed.
+func printBundles(env *common_cli.Env, format string, bundles map[spiffeid.TrustDomain][]byte) error {
+	if format != bundleFormatPEM && len(bundles) > 1 {
+		return fmt.Errorf("received %d bundles; use -trustDomain or -write to output %s bundles", len(bundles), format)
+	}
+	for _, td := range sortedTrustDomains(bundles) {
+		if _, err := env.Stdout.Write(bundles[td]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedTrustDomains(bundles map[spiffeid.TrustDomain][]byte) []spiffeid.TrustDomain {
+	tds := make([]spiffeid.TrustDomain, 0, len(bundles))
+	for td := range bundles {
+		tds = append(tds, td)
+	}
+	sort.Slice(tds, func(i, j int) bool {
+		return tds[i].String() < tds[j].String()
+	})
+	return tds
+}
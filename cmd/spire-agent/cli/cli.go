@@ -5,6 +5,7 @@ import (
 
 	"github.com/mitchellh/cli"
 	"github.com/spiffe/spire/cmd/spire-agent/cli/api"
+	"github.com/spiffe/spire/cmd/spire-agent/cli/debug"
 	"github.com/spiffe/spire/cmd/spire-agent/cli/healthcheck"
 	"github.com/spiffe/spire/cmd/spire-agent/cli/run"
 	"github.com/spiffe/spire/cmd/spire-agent/cli/validate"
@@ -24,6 +25,9 @@ func (cc *CLI) Run(args []string) int {
 		"api fetch": func() (cli.Command, error) {
 			return api.NewFetchX509Command(), nil
 		},
+		"api fetch bundle": func() (cli.Command, error) {
+			return api.NewFetchBundleCommand(), nil
+		},
 		"api fetch x509": func() (cli.Command, error) {
 			return api.NewFetchX509Command(), nil
 		},
@@ -36,6 +40,18 @@ func (cc *CLI) Run(args []string) int {
 		"api watch": func() (cli.Command, error) {
 			return &api.WatchCLI{}, nil
 		},
+		"debug attest": func() (cli.Command, error) {
+			return debug.NewAttestCommand(), nil
+		},
+		"debug entryusage": func() (cli.Command, error) {
+			return debug.NewEntryUsageCommand(), nil
+		},
+		"debug events": func() (cli.Command, error) {
+			return debug.NewEventsCommand(), nil
+		},
+		"debug loglevels": func() (cli.Command, error) {
+			return debug.NewLogLevelsCommand(), nil
+		},
 		"run": func() (cli.Command, error) {
 			return run.NewRunCommand(cc.LogOptions, cc.AllowUnknownConfig), nil
 		},
@@ -0,0 +1,27 @@
+package debug
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spiffe/spire/pkg/common/util"
+	"google.golang.org/grpc"
+)
+
+// dialAdmin dials the admin API of the agent.
+func (c *adminConfigOS) dialAdmin(ctx context.Context) (*grpc.ClientConn, error) {
+	addr, err := c.getAddr()
+	if err != nil {
+		return nil, err
+	}
+	target, err := util.GetTargetName(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := util.GRPCDialContext(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the agent: %w", err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,134 @@
+package debug
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/mitchellh/cli"
+	adminv1 "github.com/spiffe/spire/pkg/agent/api/admin/v1"
+	attestor "github.com/spiffe/spire/pkg/agent/attestor/workload"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+)
+
+const defaultTimeout = 30 * time.Second
+
+func NewAttestCommand() cli.Command {
+	return newAttestCommand(common_cli.DefaultEnv)
+}
+
+func newAttestCommand(env *common_cli.Env) *attestCommand {
+	return &attestCommand{
+		env: env,
+	}
+}
+
+type attestCommand struct {
+	adminConfigOS // os specific
+
+	env *common_cli.Env
+
+	pid     int
+	timeout time.Duration
+}
+
+func (c *attestCommand) Help() string {
+	// ignoring parsing errors since "-h" is always supported by the flags package
+	_ = c.parseFlags([]string{"-h"})
+	return ""
+}
+
+func (c *attestCommand) Synopsis() string {
+	return "Attests a process and prints its selectors and matching entries"
+}
+
+func (c *attestCommand) Run(args []string) int {
+	if err := c.parseFlags(args); err != nil {
+		return 1
+	}
+	if err := c.run(); err != nil {
+		// Ignore error since a failure to write to stderr cannot very well be
+		// reported
+		_ = c.env.ErrPrintf("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func (c *attestCommand) parseFlags(args []string) error {
+	fs := flag.NewFlagSet("debug attest", flag.ContinueOnError)
+	fs.SetOutput(c.env.Stderr)
+	fs.IntVar(&c.pid, "pid", 0, "PID of the process to attest")
+	c.addOSFlags(fs)
+	fs.DurationVar(&c.timeout, "timeout", defaultTimeout, "Time to wait for the attestation")
+	return fs.Parse(args)
+}
+
+func (c *attestCommand) run() error {
+	if c.pid <= 0 {
+		return errors.New("a positive -pid is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	conn, err := c.dialAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := adminv1.NewClient(conn).AttestWorkload(ctx, &adminv1.AttestWorkloadRequest{
+		PID: int32(c.pid),
+	})
+	if err != nil {
+		return err
+	}
+	return c.print(resp.Attestation)
+}
+
+func (c *attestCommand) print(attestation *attestor.DebugAttestation) error {
+	for _, result := range attestation.Attestors {
+		if err := c.env.Printf("Workload attestor %q:\n", result.Name); err != nil {
+			return err
+		}
+		if result.Error != "" {
+			if err := c.env.Printf("  Error: %s\n", result.Error); err != nil {
+				return err
+			}
+			continue
+		}
+		if len(result.Selectors) == 0 {
+			if err := c.env.Println("  No selectors"); err != nil {
+				return err
+			}
+		}
+		for _, selector := range result.Selectors {
+			if err := c.env.Printf("  %s\n", selector); err != nil {
+				return err
+			}
+		}
+	}
+
+	if attestation.Denied {
+		if err := c.env.Println("\nThe workload was denied by a workload attestor; it would not be issued any identity."); err != nil {
+			return err
+		}
+	}
+
+	if err := c.env.Printf("\nFound %d matching entries\n", len(attestation.Entries)); err != nil {
+		return err
+	}
+	for _, entry := range attestation.Entries {
+		if err := c.env.Printf("\nEntry ID         : %s\nSPIFFE ID        : %s\nParent ID        : %s\n", entry.EntryID, entry.SPIFFEID, entry.ParentID); err != nil {
+			return err
+		}
+		for _, selector := range entry.Selectors {
+			if err := c.env.Printf("Selector         : %s\n", selector); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
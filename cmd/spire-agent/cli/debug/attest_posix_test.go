@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package debug
+
+import (
+	"net"
+)
+
+const missingAdminAddrErr = "-adminSocketPath is required; the agent must be run with admin_socket_path"
+
+func adminAddrArgs(addr net.Addr) []string {
+	if addr == nil {
+		return []string{"-adminSocketPath", "/tmp/spire-agent/private/admin.sock"}
+	}
+	return []string{"-adminSocketPath", addr.String()}
+}
@@ -0,0 +1,108 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+
+	adminv1 "github.com/spiffe/spire/pkg/agent/api/admin/v1"
+	attestor "github.com/spiffe/spire/pkg/agent/attestor/workload"
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAttest(t *testing.T) {
+	addr := spiretest.StartGRPCServer(t, func(s *grpc.Server) {
+		adminapi.Register(s, adminapi.Service{
+			Name: adminv1.ServiceName,
+			Methods: map[string]adminapi.Handler{
+				"AttestWorkload": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+					req := new(adminv1.AttestWorkloadRequest)
+					if err := decode(req); err != nil {
+						return nil, err
+					}
+					if req.PID != 1234 {
+						return nil, status.Error(codes.PermissionDenied, "caller uid 1000 is neither root nor the uid of the agent")
+					}
+					return &adminv1.AttestWorkloadResponse{
+						Attestation: &attestor.DebugAttestation{
+							PID: 1234,
+							Attestors: []attestor.DebugPluginResult{
+								{Name: "docker", Error: "workloadattestor(docker): not a container"},
+								{Name: "unix", Selectors: []string{"unix:uid:1000", "unix:gid:1000"}},
+							},
+							Entries: []attestor.DebugEntry{
+								{
+									EntryID:   "ENTRY",
+									SPIFFEID:  "spiffe://example.org/workload",
+									ParentID:  "spiffe://example.org/agent",
+									Selectors: []string{"unix:uid:1000"},
+								},
+							},
+						},
+					}, nil
+				},
+			},
+		})
+	})
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd := newAttestCommand(&common_cli.Env{
+		Stdin:  new(bytes.Buffer),
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+
+	code := cmd.Run(append([]string{"-pid", "1234"}, adminAddrArgs(addr)...))
+	require.Equal(t, 0, code, "stderr: %s", stderr.String())
+	require.Equal(t, `Workload attestor "docker":
+  Error: workloadattestor(docker): not a container
+Workload attestor "unix":
+  unix:uid:1000
+  unix:gid:1000
+
+Found 1 matching entries
+
+Entry ID         : ENTRY
+SPIFFE ID        : spiffe://example.org/workload
+Parent ID        : spiffe://example.org/agent
+Selector         : unix:uid:1000
+`, stdout.String())
+
+	stderr.Reset()
+	code = cmd.Run(append([]string{"-pid", "4321"}, adminAddrArgs(addr)...))
+	require.Equal(t, 1, code)
+	require.Equal(t, "Error: rpc error: code = PermissionDenied desc = caller uid 1000 is neither root nor the uid of the agent\n", stderr.String())
+}
+
+func TestAttestRequiresFlags(t *testing.T) {
+	for _, tt := range []struct {
+		args      []string
+		expectErr string
+	}{
+		{
+			args:      adminAddrArgs(nil),
+			expectErr: "Error: a positive -pid is required\n",
+		},
+		{
+			args:      []string{"-pid", strconv.Itoa(1234)},
+			expectErr: "Error: " + missingAdminAddrErr + "\n",
+		},
+	} {
+		stderr := new(bytes.Buffer)
+		cmd := newAttestCommand(&common_cli.Env{
+			Stdin:  new(bytes.Buffer),
+			Stdout: new(bytes.Buffer),
+			Stderr: stderr,
+		})
+		require.Equal(t, 1, cmd.Run(tt.args))
+		require.Equal(t, tt.expectErr, stderr.String())
+	}
+}
@@ -0,0 +1,19 @@
+//go:build windows
+// +build windows
+
+package debug
+
+import (
+	"net"
+
+	"github.com/spiffe/spire/pkg/common/namedpipe"
+)
+
+const missingAdminAddrErr = "-adminNamedPipeName is required; the agent must be run with admin_named_pipe_name"
+
+func adminAddrArgs(addr net.Addr) []string {
+	if addr == nil {
+		return []string{"-adminNamedPipeName", "\\spire-agent\\private\\admin"}
+	}
+	return []string{"-adminNamedPipeName", namedpipe.GetPipeName(addr.String())}
+}
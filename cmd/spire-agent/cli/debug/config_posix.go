@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package debug
+
+import (
+	"errors"
+	"flag"
+	"net"
+
+	"github.com/spiffe/spire/pkg/common/util"
+)
+
+type adminConfigOS struct {
+	adminSocketPath string
+}
+
+func (c *adminConfigOS) addOSFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.adminSocketPath, "adminSocketPath", "", "Path to the SPIRE Agent admin API Unix domain socket (admin_socket_path in the agent configuration)")
+}
+
+func (c *adminConfigOS) getAddr() (net.Addr, error) {
+	if c.adminSocketPath == "" {
+		return nil, errors.New("-adminSocketPath is required; the agent must be run with admin_socket_path")
+	}
+	return util.GetUnixAddrWithAbsPath(c.adminSocketPath)
+}
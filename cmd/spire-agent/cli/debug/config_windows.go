@@ -0,0 +1,27 @@
+//go:build windows
+// +build windows
+
+package debug
+
+import (
+	"errors"
+	"flag"
+	"net"
+
+	"github.com/spiffe/spire/pkg/common/namedpipe"
+)
+
+type adminConfigOS struct {
+	adminNamedPipeName string
+}
+
+func (c *adminConfigOS) addOSFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.adminNamedPipeName, "adminNamedPipeName", "", "Pipe name of the SPIRE Agent admin API named pipe (admin_named_pipe_name in the agent configuration)")
+}
+
+func (c *adminConfigOS) getAddr() (net.Addr, error) {
+	if c.adminNamedPipeName == "" {
+		return nil, errors.New("-adminNamedPipeName is required; the agent must be run with admin_named_pipe_name")
+	}
+	return namedpipe.AddrFromName(c.adminNamedPipeName), nil
+}
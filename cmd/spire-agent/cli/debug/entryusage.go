@@ -0,0 +1,94 @@
+package debug
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/mitchellh/cli"
+	adminv1 "github.com/spiffe/spire/pkg/agent/api/admin/v1"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+)
+
+func NewEntryUsageCommand() cli.Command {
+	return newEntryUsageCommand(common_cli.DefaultEnv)
+}
+
+func newEntryUsageCommand(env *common_cli.Env) *entryUsageCommand {
+	return &entryUsageCommand{
+		env: env,
+	}
+}
+
+type entryUsageCommand struct {
+	adminConfigOS // os specific
+
+	env *common_cli.Env
+
+	unusedOnly bool
+	timeout    time.Duration
+}
+
+func (c *entryUsageCommand) Help() string {
+	// ignoring parsing errors since "-h" is always supported by the flags package
+	_ = c.parseFlags([]string{"-h"})
+	return ""
+}
+
+func (c *entryUsageCommand) Synopsis() string {
+	return "Prints when the SVID of each cached entry was last fetched by a workload"
+}
+
+func (c *entryUsageCommand) Run(args []string) int {
+	if err := c.parseFlags(args); err != nil {
+		return 1
+	}
+	if err := c.run(); err != nil {
+		// Ignore error since a failure to write to stderr cannot very well be
+		// reported
+		_ = c.env.ErrPrintf("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func (c *entryUsageCommand) parseFlags(args []string) error {
+	fs := flag.NewFlagSet("debug entryusage", flag.ContinueOnError)
+	fs.SetOutput(c.env.Stderr)
+	c.addOSFlags(fs)
+	fs.DurationVar(&c.timeout, "timeout", defaultTimeout, "Time to wait for the agent")
+	fs.BoolVar(&c.unusedOnly, "unused", false, "Only print the entries whose SVID was never fetched since the agent started")
+	return fs.Parse(args)
+}
+
+func (c *entryUsageCommand) run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	conn, err := c.dialAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := adminv1.NewClient(conn).ListEntryUsage(ctx, &adminv1.ListEntryUsageRequest{
+		UnusedOnly: c.unusedOnly,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := c.env.Printf("Found %d entries\n", len(resp.Entries)); err != nil {
+		return err
+	}
+	for _, entry := range resp.Entries {
+		lastUsed := "never since the agent started"
+		if entry.LastUsed != nil {
+			lastUsed = entry.LastUsed.Format(time.RFC3339)
+		}
+		if err := c.env.Printf("\nEntry ID         : %s\nSPIFFE ID        : %s\nParent ID        : %s\nLast used        : %s\n", entry.EntryID, entry.SpiffeID, entry.ParentID, lastUsed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
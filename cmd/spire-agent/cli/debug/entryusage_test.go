@@ -0,0 +1,107 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	adminv1 "github.com/spiffe/spire/pkg/agent/api/admin/v1"
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestEntryUsage(t *testing.T) {
+	lastUsed := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	used := &adminv1.EntryUsage{
+		EntryID:  "USED",
+		SpiffeID: "spiffe://example.org/used",
+		ParentID: "spiffe://example.org/agent",
+		LastUsed: &lastUsed,
+	}
+	unused := &adminv1.EntryUsage{
+		EntryID:  "UNUSED",
+		SpiffeID: "spiffe://example.org/unused",
+		ParentID: "spiffe://example.org/agent",
+	}
+
+	addr := spiretest.StartGRPCServer(t, func(s *grpc.Server) {
+		adminapi.Register(s, adminapi.Service{
+			Name: adminv1.ServiceName,
+			Methods: map[string]adminapi.Handler{
+				"ListEntryUsage": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+					req := new(adminv1.ListEntryUsageRequest)
+					if err := decode(req); err != nil {
+						return nil, err
+					}
+					if req.UnusedOnly {
+						return &adminv1.ListEntryUsageResponse{Entries: []*adminv1.EntryUsage{unused}}, nil
+					}
+					return &adminv1.ListEntryUsageResponse{Entries: []*adminv1.EntryUsage{used, unused}}, nil
+				},
+			},
+		})
+	})
+
+	for _, tt := range []struct {
+		name   string
+		args   []string
+		stdout string
+	}{
+		{
+			name: "all entries",
+			stdout: `Found 2 entries
+
+Entry ID         : USED
+SPIFFE ID        : spiffe://example.org/used
+Parent ID        : spiffe://example.org/agent
+Last used        : 2022-01-01T12:00:00Z
+
+Entry ID         : UNUSED
+SPIFFE ID        : spiffe://example.org/unused
+Parent ID        : spiffe://example.org/agent
+Last used        : never since the agent started
+`,
+		},
+		{
+			name: "unused entries",
+			args: []string{"-unused"},
+			stdout: `Found 1 entries
+
+Entry ID         : UNUSED
+SPIFFE ID        : spiffe://example.org/unused
+Parent ID        : spiffe://example.org/agent
+Last used        : never since the agent started
+`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			cmd := newEntryUsageCommand(&common_cli.Env{
+				Stdin:  new(bytes.Buffer),
+				Stdout: stdout,
+				Stderr: stderr,
+			})
+
+			code := cmd.Run(append(adminAddrArgs(addr), tt.args...))
+			require.Equal(t, 0, code, "stderr: %s", stderr.String())
+			require.Equal(t, tt.stdout, stdout.String())
+		})
+	}
+}
+
+func TestEntryUsageRequiresAdminAddr(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	cmd := newEntryUsageCommand(&common_cli.Env{
+		Stdin:  new(bytes.Buffer),
+		Stdout: new(bytes.Buffer),
+		Stderr: stderr,
+	})
+	require.Equal(t, 1, cmd.Run(nil))
+	require.Equal(t, "Error: "+missingAdminAddrErr+"\n", stderr.String())
+}
@@ -0,0 +1,82 @@
+package debug
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/mitchellh/cli"
+	adminv1 "github.com/spiffe/spire/pkg/agent/api/admin/v1"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+)
+
+func NewEventsCommand() cli.Command {
+	return newEventsCommand(common_cli.DefaultEnv)
+}
+
+func newEventsCommand(env *common_cli.Env) *eventsCommand {
+	return &eventsCommand{
+		env: env,
+	}
+}
+
+type eventsCommand struct {
+	adminConfigOS // os specific
+
+	env *common_cli.Env
+
+	timeout time.Duration
+}
+
+func (c *eventsCommand) Help() string {
+	// ignoring parsing errors since "-h" is always supported by the flags package
+	_ = c.parseFlags([]string{"-h"})
+	return ""
+}
+
+func (c *eventsCommand) Synopsis() string {
+	return "Prints the recent significant log entries of the agent"
+}
+
+func (c *eventsCommand) Run(args []string) int {
+	if err := c.parseFlags(args); err != nil {
+		return 1
+	}
+	if err := c.run(); err != nil {
+		// Ignore error since a failure to write to stderr cannot very well be
+		// reported
+		_ = c.env.ErrPrintf("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func (c *eventsCommand) parseFlags(args []string) error {
+	fs := flag.NewFlagSet("debug events", flag.ContinueOnError)
+	fs.SetOutput(c.env.Stderr)
+	c.addOSFlags(fs)
+	fs.DurationVar(&c.timeout, "timeout", defaultTimeout, "Time to wait for the agent")
+	return fs.Parse(args)
+}
+
+func (c *eventsCommand) run() error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	conn, err := c.dialAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	resp, err := adminv1.NewClient(conn).ListEvents(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range resp.Events {
+		if err := c.env.Println(event.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
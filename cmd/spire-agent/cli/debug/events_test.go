@@ -0,0 +1,69 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	adminv1 "github.com/spiffe/spire/pkg/agent/api/admin/v1"
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	"github.com/spiffe/spire/pkg/common/log"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestEvents(t *testing.T) {
+	var serverErr error
+	addr := spiretest.StartGRPCServer(t, func(s *grpc.Server) {
+		adminapi.Register(s, adminapi.Service{
+			Name: adminv1.ServiceName,
+			Methods: map[string]adminapi.Handler{
+				"ListEvents": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+					if serverErr != nil {
+						return nil, serverErr
+					}
+					return &adminv1.ListEventsResponse{
+						Events: []log.Event{
+							{
+								Time:    time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC),
+								Level:   "info",
+								Message: "Renewing X509-SVID",
+								Fields:  map[string]string{"subsystem_name": "manager"},
+							},
+							{
+								Time:    time.Date(2022, 1, 1, 12, 1, 0, 0, time.UTC),
+								Level:   "error",
+								Message: "Failed to attest workload",
+								Fields:  map[string]string{"error": "oh no"},
+							},
+						},
+					}, nil
+				},
+			},
+		})
+	})
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd := newEventsCommand(&common_cli.Env{
+		Stdin:  new(bytes.Buffer),
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+
+	code := cmd.Run(adminAddrArgs(addr))
+	require.Equal(t, 0, code, "stderr: %s", stderr.String())
+	require.Equal(t, `2022-01-01T12:00:00Z [info] Renewing X509-SVID subsystem_name=manager
+2022-01-01T12:01:00Z [error] Failed to attest workload error="oh no"
+`, stdout.String())
+
+	serverErr = status.Error(codes.FailedPrecondition, "event buffer is disabled; set event_buffer_size to enable it")
+	stderr.Reset()
+	require.Equal(t, 1, cmd.Run(adminAddrArgs(addr)))
+	require.Equal(t, "Error: rpc error: code = FailedPrecondition desc = event buffer is disabled; set event_buffer_size to enable it\n", stderr.String())
+}
@@ -0,0 +1,124 @@
+package debug
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/cli"
+	adminv1 "github.com/spiffe/spire/pkg/agent/api/admin/v1"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+)
+
+func NewLogLevelsCommand() cli.Command {
+	return newLogLevelsCommand(common_cli.DefaultEnv)
+}
+
+func newLogLevelsCommand(env *common_cli.Env) *logLevelsCommand {
+	return &logLevelsCommand{
+		env: env,
+	}
+}
+
+type logLevelsCommand struct {
+	adminConfigOS // os specific
+
+	env *common_cli.Env
+
+	subsystemLevels common_cli.StringsFlag
+	reset           bool
+	timeout         time.Duration
+}
+
+func (c *logLevelsCommand) Help() string {
+	// ignoring parsing errors since "-h" is always supported by the flags package
+	_ = c.parseFlags([]string{"-h"})
+	return ""
+}
+
+func (c *logLevelsCommand) Synopsis() string {
+	return "Prints or replaces the log level overrides of the agent subsystems"
+}
+
+func (c *logLevelsCommand) Run(args []string) int {
+	if err := c.parseFlags(args); err != nil {
+		return 1
+	}
+	if err := c.run(); err != nil {
+		// Ignore error since a failure to write to stderr cannot very well be
+		// reported
+		_ = c.env.ErrPrintf("Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func (c *logLevelsCommand) parseFlags(args []string) error {
+	fs := flag.NewFlagSet("debug loglevels", flag.ContinueOnError)
+	fs.SetOutput(c.env.Stderr)
+	c.addOSFlags(fs)
+	fs.DurationVar(&c.timeout, "timeout", defaultTimeout, "Time to wait for the agent")
+	fs.Var(&c.subsystemLevels, "subsystemLevel", "A <subsystem>=<level> log level override, e.g. workloadattestor.k8s=DEBUG. Can be used more than once. Replaces the current overrides")
+	fs.BoolVar(&c.reset, "reset", false, "Removes the log level overrides, so that every subsystem uses the log level of the agent")
+	return fs.Parse(args)
+}
+
+func (c *logLevelsCommand) run() error {
+	if c.reset && len(c.subsystemLevels) > 0 {
+		return errors.New("-reset cannot be used with -subsystemLevel")
+	}
+	levels := make(map[string]string, len(c.subsystemLevels))
+	for _, subsystemLevel := range c.subsystemLevels {
+		subsystem, level, ok := strings.Cut(subsystemLevel, "=")
+		if !ok || subsystem == "" {
+			return errors.New("log level overrides must be formatted as <subsystem>=<level>")
+		}
+		levels[subsystem] = level
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	conn, err := c.dialAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := adminv1.NewClient(conn)
+	var resp *adminv1.LogLevels
+	if c.reset || len(levels) > 0 {
+		resp, err = client.SetLogLevels(ctx, &adminv1.SetLogLevelsRequest{
+			SubsystemLevels: levels,
+		})
+	} else {
+		resp, err = client.GetLogLevels(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := c.env.Printf("Log level: %s\n", resp.Level); err != nil {
+		return err
+	}
+	if len(resp.SubsystemLevels) == 0 {
+		return nil
+	}
+	if err := c.env.Println("Subsystem log levels:"); err != nil {
+		return err
+	}
+	subsystems := make([]string, 0, len(resp.SubsystemLevels))
+	for subsystem := range resp.SubsystemLevels {
+		subsystems = append(subsystems, subsystem)
+	}
+	sort.Strings(subsystems)
+	for _, subsystem := range subsystems {
+		if err := c.env.Printf("  %s: %s\n", subsystem, resp.SubsystemLevels[subsystem]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package debug
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	adminv1 "github.com/spiffe/spire/pkg/agent/api/admin/v1"
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestLogLevels(t *testing.T) {
+	current := &adminv1.LogLevels{Level: "info"}
+	var setRequests []*adminv1.SetLogLevelsRequest
+	addr := spiretest.StartGRPCServer(t, func(s *grpc.Server) {
+		adminapi.Register(s, adminapi.Service{
+			Name: adminv1.ServiceName,
+			Methods: map[string]adminapi.Handler{
+				"GetLogLevels": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+					return current, nil
+				},
+				"SetLogLevels": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+					req := new(adminv1.SetLogLevelsRequest)
+					if err := decode(req); err != nil {
+						return nil, err
+					}
+					setRequests = append(setRequests, req)
+					current = &adminv1.LogLevels{Level: "info", SubsystemLevels: req.SubsystemLevels}
+					return current, nil
+				},
+			},
+		})
+	})
+
+	for _, tt := range []struct {
+		name              string
+		args              []string
+		expectCode        int
+		expectStdout      string
+		expectStderr      string
+		expectSetRequests []*adminv1.SetLogLevelsRequest
+	}{
+		{
+			name:         "show without overrides",
+			expectStdout: "Log level: info\n",
+		},
+		{
+			name: "replace overrides",
+			args: []string{"-subsystemLevel", "workloadattestor.k8s=DEBUG", "-subsystemLevel", "manager=WARN"},
+			expectStdout: `Log level: info
+Subsystem log levels:
+  manager: WARN
+  workloadattestor.k8s: DEBUG
+`,
+			expectSetRequests: []*adminv1.SetLogLevelsRequest{
+				{SubsystemLevels: map[string]string{"workloadattestor.k8s": "DEBUG", "manager": "WARN"}},
+			},
+		},
+		{
+			name: "show overrides",
+			expectStdout: `Log level: info
+Subsystem log levels:
+  manager: WARN
+  workloadattestor.k8s: DEBUG
+`,
+		},
+		{
+			name:         "reset overrides",
+			args:         []string{"-reset"},
+			expectStdout: "Log level: info\n",
+			expectSetRequests: []*adminv1.SetLogLevelsRequest{
+				{SubsystemLevels: map[string]string{}},
+			},
+		},
+		{
+			name:         "malformed override",
+			args:         []string{"-subsystemLevel", "manager"},
+			expectCode:   1,
+			expectStderr: "Error: log level overrides must be formatted as <subsystem>=<level>\n",
+		},
+		{
+			name:         "reset with overrides",
+			args:         []string{"-reset", "-subsystemLevel", "manager=WARN"},
+			expectCode:   1,
+			expectStderr: "Error: -reset cannot be used with -subsystemLevel\n",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			setRequests = nil
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			cmd := newLogLevelsCommand(&common_cli.Env{
+				Stdin:  new(bytes.Buffer),
+				Stdout: stdout,
+				Stderr: stderr,
+			})
+
+			code := cmd.Run(append(adminAddrArgs(addr), tt.args...))
+			require.Equal(t, tt.expectCode, code, "stderr: %s", stderr.String())
+			require.Equal(t, tt.expectStdout, stdout.String())
+			require.Equal(t, tt.expectStderr, stderr.String())
+			require.Equal(t, tt.expectSetRequests, setRequests)
+		})
+	}
+}
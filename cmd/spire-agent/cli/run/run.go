@@ -7,6 +7,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"net/url"
@@ -24,6 +25,8 @@ import (
 	"github.com/mitchellh/cli"
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/spire/pkg/agent"
+	node_attestor "github.com/spiffe/spire/pkg/agent/attestor/node"
+	"github.com/spiffe/spire/pkg/agent/client"
 	"github.com/spiffe/spire/pkg/agent/workloadkey"
 	"github.com/spiffe/spire/pkg/common/catalog"
 	common_cli "github.com/spiffe/spire/pkg/common/cli"
@@ -33,6 +36,7 @@ import (
 	"github.com/spiffe/spire/pkg/common/log"
 	"github.com/spiffe/spire/pkg/common/pemutil"
 	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/proto/spire/common"
 )
 
 const (
@@ -59,23 +63,30 @@ type Config struct {
 }
 
 type agentConfig struct {
-	DataDir                       string    `hcl:"data_dir"`
-	AdminSocketPath               string    `hcl:"admin_socket_path"`
-	InsecureBootstrap             bool      `hcl:"insecure_bootstrap"`
-	JoinToken                     string    `hcl:"join_token"`
-	LogFile                       string    `hcl:"log_file"`
-	LogFormat                     string    `hcl:"log_format"`
-	LogLevel                      string    `hcl:"log_level"`
-	SDS                           sdsConfig `hcl:"sds"`
-	ServerAddress                 string    `hcl:"server_address"`
-	ServerPort                    int       `hcl:"server_port"`
-	SocketPath                    string    `hcl:"socket_path"`
-	WorkloadX509SVIDKeyType       string    `hcl:"workload_x509_svid_key_type"`
-	TrustBundlePath               string    `hcl:"trust_bundle_path"`
-	TrustBundleURL                string    `hcl:"trust_bundle_url"`
-	TrustDomain                   string    `hcl:"trust_domain"`
-	AllowUnauthenticatedVerifiers bool      `hcl:"allow_unauthenticated_verifiers"`
-	AllowedForeignJWTClaims       []string  `hcl:"allowed_foreign_jwt_claims"`
+	AttestationRetry              *attestationRetry `hcl:"attestation_retry"`
+	DataDir                       string            `hcl:"data_dir"`
+	AdminSocketPath               string            `hcl:"admin_socket_path"`
+	InsecureBootstrap             bool              `hcl:"insecure_bootstrap"`
+	JoinToken                     string            `hcl:"join_token"`
+	LogFile                       string            `hcl:"log_file"`
+	LogFormat                     string            `hcl:"log_format"`
+	LogLevel                      string            `hcl:"log_level"`
+	LogSubsystemLevels            map[string]string `hcl:"log_subsystem_levels"`
+	SDS                           sdsConfig         `hcl:"sds"`
+	ServerAddress                 string            `hcl:"server_address"`
+	ServerPort                    int               `hcl:"server_port"`
+	SocketPath                    string            `hcl:"socket_path"`
+	AdditionalSocketPaths         []string          `hcl:"additional_socket_paths"`
+	WorkloadX509SVIDKeyType       string            `hcl:"workload_x509_svid_key_type"`
+	TrustBundlePath               string            `hcl:"trust_bundle_path"`
+	TrustBundleURL                string            `hcl:"trust_bundle_url"`
+	TrustDomain                   string            `hcl:"trust_domain"`
+	AllowUnauthenticatedVerifiers bool              `hcl:"allow_unauthenticated_verifiers"`
+	AllowLegacySecurityHeaders    bool              `hcl:"allow_legacy_security_headers"`
+	AllowedForeignJWTClaims       []string          `hcl:"allowed_foreign_jwt_claims"`
+	DenyJWTSVIDSelectors          []string          `hcl:"deny_jwt_svid_selectors"`
+
+	MaxConcurrentWorkloadAttestations int `hcl:"max_concurrent_workload_attestations"`
 
 	AuthorizedDelegates []string `hcl:"authorized_delegates"`
 
@@ -99,10 +110,47 @@ type sdsConfig struct {
 	DisableSPIFFECertValidation bool   `hcl:"disable_spiffe_cert_validation"`
 }
 
+type attestationRetry struct {
+	MaxAttempts       int      `hcl:"max_attempts"`
+	InitialBackoff    string   `hcl:"initial_backoff"`
+	MaxBackoff        string   `hcl:"max_backoff"`
+	BackoffMultiplier float64  `hcl:"backoff_multiplier"`
+	Jitter            *float64 `hcl:"jitter"`
+
+	UnusedKeys []string `hcl:",unusedKeys"`
+}
+
+type loadSheddingConfig struct {
+	MemoryThreshold      float64 `hcl:"memory_threshold"`
+	CPUPressureThreshold float64 `hcl:"cpu_pressure_threshold"`
+
+	UnusedKeys []string `hcl:",unusedKeys"`
+}
+
+type serverDiscoveryConfig struct {
+	SRVName string                 `hcl:"srv_name"`
+	Servers []serverEndpointConfig `hcl:"servers"`
+
+	UnusedKeys []string `hcl:",unusedKeys"`
+}
+
+type serverEndpointConfig struct {
+	Address  string `hcl:"address"`
+	Port     int    `hcl:"port"`
+	Weight   int    `hcl:"weight"`
+	Priority int    `hcl:"priority"`
+
+	UnusedKeys []string `hcl:",unusedKeys"`
+}
+
 type experimentalConfig struct {
-	SyncInterval       string `hcl:"sync_interval"`
-	NamedPipeName      string `hcl:"named_pipe_name"`
-	AdminNamedPipeName string `hcl:"admin_named_pipe_name"`
+	SyncInterval       string                 `hcl:"sync_interval"`
+	NamedPipeName      string                 `hcl:"named_pipe_name"`
+	AdminNamedPipeName string                 `hcl:"admin_named_pipe_name"`
+	LoadShedding       *loadSheddingConfig    `hcl:"load_shedding"`
+	ServerDiscovery    *serverDiscoveryConfig `hcl:"server_discovery"`
+	SocketActivation   bool                   `hcl:"socket_activation"`
+	EventBufferSize    int                    `hcl:"event_buffer_size"`
 
 	Flags fflag.RawConfig `hcl:"feature_flags"`
 
@@ -205,12 +253,18 @@ func (c *agentConfig) validate() error {
 		return errors.New("agent section must be configured")
 	}
 
-	if c.ServerAddress == "" {
-		return errors.New("server_address must be configured")
-	}
+	if c.Experimental.ServerDiscovery != nil {
+		if c.ServerAddress != "" || c.ServerPort != 0 {
+			return errors.New("server_address and server_port cannot be configured along with server_discovery")
+		}
+	} else {
+		if c.ServerAddress == "" {
+			return errors.New("server_address must be configured")
+		}
 
-	if c.ServerPort == 0 {
-		return errors.New("server_port must be configured")
+		if c.ServerPort == 0 {
+			return errors.New("server_port must be configured")
+		}
 	}
 
 	if c.TrustDomain == "" {
@@ -400,13 +454,46 @@ func NewAgentConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool)
 	}
 	ac.X509SVIDCacheMaxSize = c.Agent.Experimental.X509SVIDCacheMaxSize
 
-	serverHostPort := net.JoinHostPort(c.Agent.ServerAddress, strconv.Itoa(c.Agent.ServerPort))
-	ac.ServerAddress = fmt.Sprintf("dns:///%s", serverHostPort)
+	ac.SocketActivation = c.Agent.Experimental.SocketActivation
+
+	if ls := c.Agent.Experimental.LoadShedding; ls != nil {
+		if ac.X509SVIDCacheMaxSize == 0 {
+			return nil, errors.New("load_shedding requires x509_svid_cache_max_size to be set")
+		}
+		if ls.MemoryThreshold < 0 || ls.MemoryThreshold > 1 {
+			return nil, errors.New("load_shedding memory_threshold should be between 0 and 1")
+		}
+		if ls.CPUPressureThreshold < 0 || ls.CPUPressureThreshold > 100 {
+			return nil, errors.New("load_shedding cpu_pressure_threshold should be between 0 and 100")
+		}
+		ac.LoadSheddingMemoryThreshold = ls.MemoryThreshold
+		ac.LoadSheddingCPUPressureThreshold = ls.CPUPressureThreshold
+	}
+
+	if sd := c.Agent.Experimental.ServerDiscovery; sd != nil {
+		target, err := serverDiscoveryTarget(sd)
+		if err != nil {
+			return nil, fmt.Errorf("server_discovery: %w", err)
+		}
+		ac.ServerAddress = target
+	} else {
+		serverHostPort := net.JoinHostPort(c.Agent.ServerAddress, strconv.Itoa(c.Agent.ServerPort))
+		ac.ServerAddress = fmt.Sprintf("dns:///%s", serverHostPort)
+	}
 
 	logOptions = append(logOptions,
 		log.WithLevel(c.Agent.LogLevel),
 		log.WithFormat(c.Agent.LogFormat),
+		// Always enabled so that the overrides can be changed at runtime
+		log.WithSubsystemLevels(c.Agent.LogSubsystemLevels),
 	)
+	if c.Agent.Experimental.EventBufferSize < 0 {
+		return nil, errors.New("event_buffer_size should not be negative")
+	}
+	if c.Agent.Experimental.EventBufferSize > 0 {
+		ac.EventBuffer = log.NewEventBuffer(c.Agent.Experimental.EventBufferSize)
+		logOptions = append(logOptions, log.WithEventBuffer(ac.EventBuffer))
+	}
 	var reopenableFile *log.ReopenableFile
 	if c.Agent.LogFile != "" {
 		reopenableFile, err := log.NewReopenableFile(c.Agent.LogFile)
@@ -421,6 +508,7 @@ func NewAgentConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool)
 		return nil, fmt.Errorf("could not start logger: %w", err)
 	}
 	ac.Log = logger
+	ac.LogLevels = logger
 	if reopenableFile != nil {
 		ac.LogReopener = log.ReopenOnSignal(logger, reopenableFile)
 	}
@@ -437,6 +525,11 @@ func NewAgentConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool)
 	}
 	ac.BindAddress = addr
 
+	ac.AdditionalBindAddresses, err = c.Agent.getAdditionalAddrs()
+	if err != nil {
+		return nil, err
+	}
+
 	if c.Agent.hasAdminAddr() {
 		adminAddr, err := c.Agent.getAdminAddr()
 		if err != nil {
@@ -445,6 +538,14 @@ func NewAgentConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool)
 		ac.AdminBindAddress = adminAddr
 	}
 	ac.JoinToken = c.Agent.JoinToken
+
+	if c.Agent.AttestationRetry != nil {
+		ac.AttestationRetry, err = parseAttestationRetry(c.Agent.AttestationRetry)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	ac.DataDir = c.Agent.DataDir
 	ac.DefaultSVIDName = c.Agent.SDS.DefaultSVIDName
 	ac.DefaultBundleName = c.Agent.SDS.DefaultBundleName
@@ -474,6 +575,19 @@ func NewAgentConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool)
 
 	ac.AllowedForeignJWTClaims = c.Agent.AllowedForeignJWTClaims
 
+	for _, s := range c.Agent.DenyJWTSVIDSelectors {
+		selector, err := parseSelector(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deny_jwt_svid_selectors: %w", err)
+		}
+		ac.DenyJWTSVIDSelectors = append(ac.DenyJWTSVIDSelectors, selector)
+	}
+
+	if c.Agent.MaxConcurrentWorkloadAttestations < 0 {
+		return nil, errors.New("max_concurrent_workload_attestations should not be negative")
+	}
+	ac.MaxConcurrentWorkloadAttestations = c.Agent.MaxConcurrentWorkloadAttestations
+
 	ac.PluginConfigs = *c.Plugins
 	ac.Telemetry = c.Telemetry
 	ac.HealthChecks = c.HealthChecks
@@ -485,6 +599,7 @@ func NewAgentConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool)
 	}
 
 	ac.AllowUnauthenticatedVerifiers = c.Agent.AllowUnauthenticatedVerifiers
+	ac.AllowLegacySecurityHeaders = c.Agent.AllowLegacySecurityHeaders
 
 	for _, authorizedDelegate := range c.Agent.AuthorizedDelegates {
 		if _, err := idutil.MemberFromString(ac.TrustDomain, authorizedDelegate); err != nil {
@@ -505,6 +620,65 @@ func NewAgentConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool)
 	return ac, nil
 }
 
+func parseAttestationRetry(c *attestationRetry) (node_attestor.RetryConfig, error) {
+	var rc node_attestor.RetryConfig
+
+	if c.MaxAttempts < 0 {
+		return rc, errors.New("attestation_retry max_attempts cannot be negative")
+	}
+	rc.MaxAttempts = c.MaxAttempts
+
+	if c.InitialBackoff != "" {
+		initialBackoff, err := time.ParseDuration(c.InitialBackoff)
+		if err != nil {
+			return rc, fmt.Errorf("could not parse attestation_retry initial_backoff %q: %w", c.InitialBackoff, err)
+		}
+		if initialBackoff <= 0 {
+			return rc, errors.New("attestation_retry initial_backoff must be positive")
+		}
+		rc.InitialBackoff = initialBackoff
+	}
+
+	if c.MaxBackoff != "" {
+		maxBackoff, err := time.ParseDuration(c.MaxBackoff)
+		if err != nil {
+			return rc, fmt.Errorf("could not parse attestation_retry max_backoff %q: %w", c.MaxBackoff, err)
+		}
+		if maxBackoff <= 0 {
+			return rc, errors.New("attestation_retry max_backoff must be positive")
+		}
+		rc.MaxBackoff = maxBackoff
+	}
+
+	if c.BackoffMultiplier != 0 && c.BackoffMultiplier < 1 {
+		return rc, errors.New("attestation_retry backoff_multiplier cannot be less than 1")
+	}
+	rc.BackoffMultiplier = c.BackoffMultiplier
+
+	rc.Jitter = node_attestor.DefaultRetryJitter
+	if c.Jitter != nil {
+		if *c.Jitter < 0 || *c.Jitter > 1 {
+			return rc, errors.New("attestation_retry jitter must be between 0 and 1")
+		}
+		rc.Jitter = *c.Jitter
+	}
+
+	return rc, nil
+}
+
+// parseSelector parses a selector formatted as type:value. Everything to the
+// right of the first ":" is considered the selector value.
+func parseSelector(str string) (*common.Selector, error) {
+	parts := strings.SplitN(str, ":", 2)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("selector %q must be formatted as type:value", str)
+	}
+	return &common.Selector{
+		Type:  parts[0],
+		Value: parts[1],
+	}, nil
+}
+
 func validateConfig(c *Config) error {
 	if c.Plugins == nil {
 		return errors.New("plugins section must be configured")
@@ -517,6 +691,39 @@ func validateConfig(c *Config) error {
 	return nil
 }
 
+// serverDiscoveryTarget returns the target the agent dials to discover the
+// servers as configured.
+func serverDiscoveryTarget(c *serverDiscoveryConfig) (string, error) {
+	switch {
+	case c.SRVName != "" && len(c.Servers) > 0:
+		return "", errors.New("only one of srv_name or servers can be configured")
+	case c.SRVName != "":
+		return client.SRVTarget(c.SRVName), nil
+	case len(c.Servers) == 0:
+		return "", errors.New("either srv_name or servers must be configured")
+	}
+
+	servers := make([]client.ServerEndpoint, 0, len(c.Servers))
+	for _, server := range c.Servers {
+		switch {
+		case server.Address == "":
+			return "", errors.New("server address must be configured")
+		case server.Port <= 0 || server.Port > math.MaxUint16:
+			return "", fmt.Errorf("server %q port must be between 1 and %d", server.Address, math.MaxUint16)
+		case server.Weight < 0 || int64(server.Weight) > math.MaxUint32:
+			return "", fmt.Errorf("server %q weight is out of range", server.Address)
+		case server.Priority < 0 || int64(server.Priority) > math.MaxUint32:
+			return "", fmt.Errorf("server %q priority is out of range", server.Address)
+		}
+		servers = append(servers, client.ServerEndpoint{
+			Address:  net.JoinHostPort(server.Address, strconv.Itoa(server.Port)),
+			Weight:   uint32(server.Weight),
+			Priority: uint32(server.Priority),
+		})
+	}
+	return client.StaticTarget(servers), nil
+}
+
 func checkForUnknownConfig(c *Config, l logrus.FieldLogger) (err error) {
 	detectedUnknown := func(section string, keys []string) {
 		l.WithFields(logrus.Fields{
@@ -534,6 +741,25 @@ func checkForUnknownConfig(c *Config, l logrus.FieldLogger) (err error) {
 		detectedUnknown("agent", a.UnusedKeys)
 	}
 
+	if a := c.Agent; a != nil && a.AttestationRetry != nil && len(a.AttestationRetry.UnusedKeys) != 0 {
+		detectedUnknown("attestation_retry", a.AttestationRetry.UnusedKeys)
+	}
+
+	if a := c.Agent; a != nil && a.Experimental.LoadShedding != nil && len(a.Experimental.LoadShedding.UnusedKeys) != 0 {
+		detectedUnknown("load_shedding", a.Experimental.LoadShedding.UnusedKeys)
+	}
+
+	if a := c.Agent; a != nil && a.Experimental.ServerDiscovery != nil {
+		if len(a.Experimental.ServerDiscovery.UnusedKeys) != 0 {
+			detectedUnknown("server_discovery", a.Experimental.ServerDiscovery.UnusedKeys)
+		}
+		for _, server := range a.Experimental.ServerDiscovery.Servers {
+			if len(server.UnusedKeys) != 0 {
+				detectedUnknown("server_discovery servers", server.UnusedKeys)
+			}
+		}
+	}
+
 	// TODO: Re-enable unused key detection for telemetry. See
 	// https://github.com/spiffe/spire/issues/1101 for more information
 	//
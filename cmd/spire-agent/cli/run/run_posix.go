@@ -30,18 +30,42 @@ func (c *agentConfig) getAddr() (net.Addr, error) {
 	return util.GetUnixAddrWithAbsPath(c.SocketPath)
 }
 
-func (c *agentConfig) getAdminAddr() (net.Addr, error) {
+func (c *agentConfig) getAdditionalAddrs() ([]net.Addr, error) {
 	socketPathAbs, err := filepath.Abs(c.SocketPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get absolute path for socket_path: %w", err)
 	}
+
+	seen := map[string]bool{socketPathAbs: true}
+	var addrs []net.Addr
+	for _, socketPath := range c.AdditionalSocketPaths {
+		addr, err := util.GetUnixAddrWithAbsPath(socketPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path for additional_socket_paths: %w", err)
+		}
+		if seen[addr.Name] {
+			return nil, fmt.Errorf("additional_socket_paths: socket path %q is already in use", socketPath)
+		}
+		seen[addr.Name] = true
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+func (c *agentConfig) getAdminAddr() (net.Addr, error) {
 	adminSocketPathAbs, err := filepath.Abs(c.AdminSocketPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get absolute path for admin_socket_path: %w", err)
 	}
 
-	if strings.HasPrefix(adminSocketPathAbs, filepath.Dir(socketPathAbs)+"/") {
-		return nil, errors.New("admin socket cannot be in the same directory or a subdirectory as that containing the Workload API socket")
+	for _, socketPath := range append([]string{c.SocketPath}, c.AdditionalSocketPaths...) {
+		socketPathAbs, err := filepath.Abs(socketPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path for socket_path: %w", err)
+		}
+		if strings.HasPrefix(adminSocketPathAbs, filepath.Dir(socketPathAbs)+"/") {
+			return nil, errors.New("admin socket cannot be in the same directory or a subdirectory as that containing the Workload API socket")
+		}
 	}
 
 	return &net.UnixAddr{
@@ -66,12 +90,14 @@ func (c *agentConfig) validateOS() error {
 }
 
 func prepareEndpoints(c *agent.Config) error {
-	// Create uds dir and parents if not exists
-	dir := filepath.Dir(c.BindAddress.String())
-	if _, statErr := os.Stat(dir); os.IsNotExist(statErr) {
-		c.Log.WithField("dir", dir).Infof("Creating spire agent UDS directory")
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return err
+	// Create uds dirs and parents if not exists
+	for _, addr := range append([]net.Addr{c.BindAddress}, c.AdditionalBindAddresses...) {
+		dir := filepath.Dir(addr.String())
+		if _, statErr := os.Stat(dir); os.IsNotExist(statErr) {
+			c.Log.WithField("dir", dir).Infof("Creating spire agent UDS directory")
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -228,5 +228,41 @@ func newAgentConfigCasesOS() []newAgentConfigCase {
 				require.Nil(t, c.AdminBindAddress)
 			},
 		},
+		{
+			msg: "additional_socket_paths should be correctly configured",
+			input: func(c *Config) {
+				c.Agent.SocketPath = "/run/spire/sockets/agent.sock"
+				c.Agent.AdditionalSocketPaths = []string{"/tmp/spire-agent/public/api.sock"}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Equal(t, "/run/spire/sockets/agent.sock", c.BindAddress.String())
+				require.Len(t, c.AdditionalBindAddresses, 1)
+				require.Equal(t, "/tmp/spire-agent/public/api.sock", c.AdditionalBindAddresses[0].String())
+				require.Equal(t, "unix", c.AdditionalBindAddresses[0].Network())
+			},
+		},
+		{
+			msg:         "additional_socket_paths cannot repeat socket_path",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.SocketPath = "/run/spire/sockets/agent.sock"
+				c.Agent.AdditionalSocketPaths = []string{"/run/spire/sockets/agent.sock"}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "admin_socket_path same folder as additional socket path",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.SocketPath = "/run/spire/sockets/agent.sock"
+				c.Agent.AdditionalSocketPaths = []string{"/tmp/spire-agent/public/api.sock"}
+				c.Agent.AdminSocketPath = "/tmp/spire-agent/public/admin.sock"
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
 	}
 }
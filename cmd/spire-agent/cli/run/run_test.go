@@ -9,13 +9,16 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/spiffe/spire/pkg/agent"
+	node_attestor "github.com/spiffe/spire/pkg/agent/attestor/node"
 	"github.com/spiffe/spire/pkg/agent/workloadkey"
 	"github.com/spiffe/spire/pkg/common/catalog"
 	"github.com/spiffe/spire/pkg/common/log"
+	"github.com/spiffe/spire/proto/spire/common"
 	"github.com/spiffe/spire/test/spiretest"
 	"github.com/spiffe/spire/test/util"
 	"github.com/stretchr/testify/assert"
@@ -639,6 +642,33 @@ func TestNewAgentConfig(t *testing.T) {
 				require.IsType(t, &logrus.TextFormatter{}, l.Formatter)
 			},
 		},
+		{
+			msg: "log_subsystem_levels lowers the logger level",
+			input: func(c *Config) {
+				c.Agent.LogLevel = "INFO"
+				c.Agent.LogSubsystemLevels = map[string]string{
+					"workloadattestor.k8s": "DEBUG",
+				}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.NotNil(t, c.Log)
+
+				l := c.Log.(*log.Logger)
+				require.Equal(t, logrus.DebugLevel, l.Level)
+			},
+		},
+		{
+			msg:         "log_subsystem_levels with an invalid level",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.LogSubsystemLevels = map[string]string{
+					"workloadattestor.k8s": "LOUD",
+				}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
 		{
 			msg:         "trust_bundle_path and trust_bundle_url cannot both be set",
 			expectError: true,
@@ -727,6 +757,42 @@ func TestNewAgentConfig(t *testing.T) {
 				require.Nil(t, c)
 			},
 		},
+		{
+			msg: "event_buffer_size is set",
+			input: func(c *Config) {
+				c.Agent.Experimental.EventBufferSize = 100
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.NotNil(t, c.EventBuffer)
+			},
+		},
+		{
+			msg: "event_buffer_size is not set",
+			input: func(c *Config) {
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c.EventBuffer)
+			},
+		},
+		{
+			msg:         "event_buffer_size is negative",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.Experimental.EventBufferSize = -1
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "socket_activation is set",
+			input: func(c *Config) {
+				c.Agent.Experimental.SocketActivation = true
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.True(t, c.SocketActivation)
+			},
+		},
 		{
 			msg: "x509_svid_cache_max_size is set",
 			input: func(c *Config) {
@@ -763,6 +829,115 @@ func TestNewAgentConfig(t *testing.T) {
 				require.Nil(t, c)
 			},
 		},
+		{
+			msg: "load_shedding is set",
+			input: func(c *Config) {
+				c.Agent.Experimental.X509SVIDCacheMaxSize = 100
+				c.Agent.Experimental.LoadShedding = &loadSheddingConfig{
+					MemoryThreshold:      0.9,
+					CPUPressureThreshold: 50,
+				}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Equal(t, 0.9, c.LoadSheddingMemoryThreshold)
+				require.Equal(t, 50.0, c.LoadSheddingCPUPressureThreshold)
+			},
+		},
+		{
+			msg:         "load_shedding without x509_svid_cache_max_size",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.Experimental.LoadShedding = &loadSheddingConfig{
+					MemoryThreshold: 0.9,
+				}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "load_shedding memory_threshold out of range",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.Experimental.X509SVIDCacheMaxSize = 100
+				c.Agent.Experimental.LoadShedding = &loadSheddingConfig{
+					MemoryThreshold: 90,
+				}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "server_discovery with an SRV record",
+			input: func(c *Config) {
+				c.Agent.ServerAddress = ""
+				c.Agent.ServerPort = 0
+				c.Agent.Experimental.ServerDiscovery = &serverDiscoveryConfig{
+					SRVName: "_spire-server._tcp.example.org",
+				}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Equal(t, "spire-srv:///_spire-server._tcp.example.org", c.ServerAddress)
+			},
+		},
+		{
+			msg: "server_discovery with static servers",
+			input: func(c *Config) {
+				c.Agent.ServerAddress = ""
+				c.Agent.ServerPort = 0
+				c.Agent.Experimental.ServerDiscovery = &serverDiscoveryConfig{
+					Servers: []serverEndpointConfig{
+						{Address: "10.0.0.1", Port: 8081, Weight: 2},
+						{Address: "::1", Port: 8081, Priority: 1},
+					},
+				}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Equal(t, "spire-static:///10.0.0.1:8081;weight=2,[::1]:8081;priority=1", c.ServerAddress)
+			},
+		},
+		{
+			msg:         "server_discovery along with server_address",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.Experimental.ServerDiscovery = &serverDiscoveryConfig{
+					SRVName: "_spire-server._tcp.example.org",
+				}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "server_discovery with both srv_name and servers",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.ServerAddress = ""
+				c.Agent.ServerPort = 0
+				c.Agent.Experimental.ServerDiscovery = &serverDiscoveryConfig{
+					SRVName: "_spire-server._tcp.example.org",
+					Servers: []serverEndpointConfig{{Address: "10.0.0.1", Port: 8081}},
+				}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "server_discovery with a server without port",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.ServerAddress = ""
+				c.Agent.ServerPort = 0
+				c.Agent.Experimental.ServerDiscovery = &serverDiscoveryConfig{
+					Servers: []serverEndpointConfig{{Address: "10.0.0.1"}},
+				}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
 		{
 			msg: "allowed_foreign_jwt_claims provided",
 			input: func(c *Config) {
@@ -772,6 +947,139 @@ func TestNewAgentConfig(t *testing.T) {
 				require.Equal(t, []string{"c1", "c2"}, c.AllowedForeignJWTClaims)
 			},
 		},
+		{
+			msg: "allow_legacy_security_headers provided",
+			input: func(c *Config) {
+				c.Agent.AllowLegacySecurityHeaders = true
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.True(t, c.AllowLegacySecurityHeaders)
+			},
+		},
+		{
+			msg:   "attestation_retry is not set by default",
+			input: func(c *Config) {},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Equal(t, node_attestor.RetryConfig{}, c.AttestationRetry)
+			},
+		},
+		{
+			msg: "attestation_retry provided",
+			input: func(c *Config) {
+				c.Agent.AttestationRetry = &attestationRetry{
+					MaxAttempts:       10,
+					InitialBackoff:    "2s",
+					MaxBackoff:        "1m",
+					BackoffMultiplier: 1.5,
+				}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Equal(t, node_attestor.RetryConfig{
+					MaxAttempts:       10,
+					InitialBackoff:    2 * time.Second,
+					MaxBackoff:        time.Minute,
+					BackoffMultiplier: 1.5,
+					Jitter:            node_attestor.DefaultRetryJitter,
+				}, c.AttestationRetry)
+			},
+		},
+		{
+			msg: "attestation_retry without jitter",
+			input: func(c *Config) {
+				jitter := 0.0
+				c.Agent.AttestationRetry = &attestationRetry{
+					MaxAttempts: 3,
+					Jitter:      &jitter,
+				}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Equal(t, node_attestor.RetryConfig{
+					MaxAttempts: 3,
+				}, c.AttestationRetry)
+			},
+		},
+		{
+			msg:         "negative attestation_retry max_attempts returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.AttestationRetry = &attestationRetry{MaxAttempts: -1}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "unparseable attestation_retry initial_backoff returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.AttestationRetry = &attestationRetry{InitialBackoff: "a while"}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "attestation_retry backoff_multiplier less than 1 returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.AttestationRetry = &attestationRetry{BackoffMultiplier: 0.5}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "attestation_retry jitter greater than 1 returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				jitter := 1.5
+				c.Agent.AttestationRetry = &attestationRetry{Jitter: &jitter}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "deny_jwt_svid_selectors provided",
+			input: func(c *Config) {
+				c.Agent.DenyJWTSVIDSelectors = []string{"k8s:ns:untrusted", "unix:uid:1000"}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Equal(t, []*common.Selector{
+					{Type: "k8s", Value: "ns:untrusted"},
+					{Type: "unix", Value: "uid:1000"},
+				}, c.DenyJWTSVIDSelectors)
+			},
+		},
+		{
+			msg:         "deny_jwt_svid_selectors with malformed selector",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.DenyJWTSVIDSelectors = []string{"untrusted"}
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "max_concurrent_workload_attestations provided",
+			input: func(c *Config) {
+				c.Agent.MaxConcurrentWorkloadAttestations = 100
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Equal(t, 100, c.MaxConcurrentWorkloadAttestations)
+			},
+		},
+		{
+			msg:         "max_concurrent_workload_attestations is negative",
+			expectError: true,
+			input: func(c *Config) {
+				c.Agent.MaxConcurrentWorkloadAttestations = -1
+			},
+			test: func(t *testing.T, c *agent.Config) {
+				require.Nil(t, c)
+			},
+		},
 		{
 			msg: "SDS configurables are provided",
 			input: func(c *Config) {
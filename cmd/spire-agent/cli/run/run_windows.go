@@ -29,6 +29,11 @@ func (c *agentConfig) getAdminAddr() (net.Addr, error) {
 	return namedpipe.AddrFromName(c.Experimental.AdminNamedPipeName), nil
 }
 
+func (c *agentConfig) getAdditionalAddrs() ([]net.Addr, error) {
+	// Additional socket paths are not supported in this platform
+	return nil, nil
+}
+
 func (c *agentConfig) hasAdminAddr() bool {
 	return c.Experimental.AdminNamedPipeName != ""
 }
@@ -41,6 +46,12 @@ func (c *agentConfig) validateOS() error {
 	if c.AdminSocketPath != "" {
 		return errors.New("invalid configuration: admin_socket_path is not supported in this platform; please use admin_named_pipe_name instead")
 	}
+	if len(c.AdditionalSocketPaths) > 0 {
+		return errors.New("invalid configuration: additional_socket_paths is not supported in this platform")
+	}
+	if c.Experimental.SocketActivation {
+		return errors.New("invalid configuration: socket_activation is not supported in this platform")
+	}
 	return nil
 }
 
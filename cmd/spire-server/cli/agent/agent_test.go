@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"testing"
+	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -13,7 +14,9 @@ import (
 	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"github.com/spiffe/spire/cmd/spire-server/cli/agent"
 	"github.com/spiffe/spire/cmd/spire-server/cli/common"
+	"github.com/spiffe/spire/pkg/common/adminapi"
 	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
 	"github.com/spiffe/spire/test/spiretest"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
@@ -60,6 +63,241 @@ func (s *agentTest) afterTest(t *testing.T) {
 	t.Logf("STDERR:\n%s", s.stderr.String())
 }
 
+func TestApproveHelp(t *testing.T) {
+	test := setupTest(t, agent.NewApproveCommandWithEnv)
+
+	test.client.Help()
+	require.Equal(t, `Usage of agent approve:`+common.AddrUsage+
+		`  -spiffeID string
+    	The SPIFFE ID of the agent to approve (agent identity)
+`, test.stderr.String())
+}
+
+func TestApprove(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		args             []string
+		expectReturnCode int
+		expectStdout     string
+		expectStderr     string
+		expectApprovedID string
+		serverErr        error
+	}{
+		{
+			name:             "success",
+			args:             []string{"-spiffeID", "spiffe://example.org/spire/agent/agent1"},
+			expectReturnCode: 0,
+			expectStdout:     "Agent approved successfully; it is issued an SVID the next time it attests\n",
+			expectApprovedID: "spiffe://example.org/spire/agent/agent1",
+		},
+		{
+			name:             "no spiffe id",
+			expectReturnCode: 1,
+			expectStderr:     "Error: a SPIFFE ID is required\n",
+		},
+		{
+			name:             "wrong UDS path",
+			args:             []string{common.AddrArg, common.AddrValue, "-spiffeID", "spiffe://example.org/spire/agent/agent1"},
+			expectReturnCode: 1,
+			expectStderr:     common.AddrError,
+		},
+		{
+			name:             "server error",
+			args:             []string{"-spiffeID", "spiffe://example.org/spire/agent/foo"},
+			serverErr:        status.Error(codes.NotFound, "pending agent not found"),
+			expectReturnCode: 1,
+			expectStderr:     "Error: rpc error: code = NotFound desc = pending agent not found\n",
+			expectApprovedID: "spiffe://example.org/spire/agent/foo",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			test := setupTest(t, agent.NewApproveCommandWithEnv)
+			test.server.err = tt.serverErr
+
+			returnCode := test.client.Run(append(test.args, tt.args...))
+			require.Equal(t, tt.expectStdout, test.stdout.String())
+			require.Equal(t, tt.expectStderr, test.stderr.String())
+			require.Equal(t, tt.expectReturnCode, returnCode)
+			require.Equal(t, tt.expectApprovedID, test.server.gotApprovedID)
+		})
+	}
+}
+
+func TestAttestHistoryHelp(t *testing.T) {
+	test := setupTest(t, agent.NewAttestHistoryCommandWithEnv)
+
+	test.client.Help()
+	require.Equal(t, `Usage of agent attest-history:
+  -failedOnly
+    	Only list failed attestations
+  -limit int
+    	Maximum number of attestations to list, most recent first (default 100)`+common.AddrUsage+
+		`  -spiffeID string
+    	Only list the attestations of the agent with the given SPIFFE ID
+`, test.stderr.String())
+}
+
+func TestAttestHistory(t *testing.T) {
+	events := []*adminv1.AttestationEvent{
+		{
+			AttestationType: "x509pop",
+			Error:           "failed to attest: certificate is expired",
+			Selectors:       []string{"x509pop:subject:cn:agent1", "x509pop:ca:fingerprint:abc"},
+			AttestedAt:      time.Unix(1010, 0).UTC(),
+		},
+		{
+			SpiffeID:        "spiffe://example.org/spire/agent/agent1",
+			AttestationType: "x509pop",
+			SerialNumber:    "1",
+			Reattestation:   true,
+			AttestedAt:      time.Unix(1000, 0).UTC(),
+		},
+	}
+
+	for _, tt := range []struct {
+		name             string
+		args             []string
+		events           []*adminv1.AttestationEvent
+		expectReturnCode int
+		expectStdout     string
+		expectStderr     string
+		expectRequest    *adminv1.ListAttestationEventsRequest
+		serverErr        error
+	}{
+		{
+			name:   "success",
+			events: events,
+			expectStdout: `Found 2 attestations:
+
+Attestation type  : x509pop
+Attested at       : 1970-01-01 00:16:50 +0000 UTC
+Error             : failed to attest: certificate is expired
+Selectors         : x509pop:subject:cn:agent1, x509pop:ca:fingerprint:abc
+
+SPIFFE ID         : spiffe://example.org/spire/agent/agent1
+Attestation type  : x509pop
+Attested at       : 1970-01-01 00:16:40 +0000 UTC
+Serial number     : 1
+Reattestation     : true
+
+`,
+			expectRequest: &adminv1.ListAttestationEventsRequest{Limit: 100},
+		},
+		{
+			name:          "filters",
+			args:          []string{"-spiffeID", "spiffe://example.org/spire/agent/agent1", "-failedOnly", "-limit", "10"},
+			expectStdout:  "No attestations found\n",
+			expectRequest: &adminv1.ListAttestationEventsRequest{BySpiffeID: "spiffe://example.org/spire/agent/agent1", FailedOnly: true, Limit: 10},
+		},
+		{
+			name:             "wrong UDS path",
+			args:             []string{common.AddrArg, common.AddrValue},
+			expectReturnCode: 1,
+			expectStderr:     common.AddrError,
+		},
+		{
+			name:             "server error",
+			serverErr:        status.Error(codes.Internal, "internal server error"),
+			expectReturnCode: 1,
+			expectStderr:     "Error: rpc error: code = Internal desc = internal server error\n",
+			expectRequest:    &adminv1.ListAttestationEventsRequest{Limit: 100},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			test := setupTest(t, agent.NewAttestHistoryCommandWithEnv)
+			test.server.attestationEvents = tt.events
+			test.server.adminErr = tt.serverErr
+
+			returnCode := test.client.Run(append(test.args, tt.args...))
+			require.Equal(t, tt.expectStdout, test.stdout.String())
+			require.Equal(t, tt.expectStderr, test.stderr.String())
+			require.Equal(t, tt.expectReturnCode, returnCode)
+			require.Equal(t, tt.expectRequest, test.server.gotListAttestationEventsRequest)
+		})
+	}
+}
+
+func TestPendingHelp(t *testing.T) {
+	test := setupTest(t, agent.NewPendingCommandWithEnv)
+
+	test.client.Help()
+	require.Equal(t, `Usage of agent pending:
+  -attestationType string
+    	Only list the agents that attested with the given node attestor`+common.AddrUsage, test.stderr.String())
+}
+
+func TestPending(t *testing.T) {
+	requestedAt := time.Date(2022, 10, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		name             string
+		args             []string
+		pendingAgents    []*adminv1.PendingAgent
+		expectReturnCode int
+		expectStdout     string
+		expectStderr     string
+		expectRequest    *adminv1.ListPendingAgentsRequest
+		serverErr        error
+	}{
+		{
+			name: "success",
+			args: []string{"-attestationType", "x509pop"},
+			pendingAgents: []*adminv1.PendingAgent{
+				{
+					SpiffeID:        "spiffe://example.org/spire/agent/x509pop/agent1",
+					AttestationType: "x509pop",
+					RequestedAt:     requestedAt,
+					LastAttemptAt:   requestedAt.Add(time.Minute),
+				},
+			},
+			expectReturnCode: 0,
+			expectStdout: `Found 1 pending agent:
+
+SPIFFE ID         : spiffe://example.org/spire/agent/x509pop/agent1
+Attestation type  : x509pop
+Approved          : false
+Requested at      : 2022-10-01 12:00:00 +0000 UTC
+Last attempt at   : 2022-10-01 12:01:00 +0000 UTC
+
+`,
+			expectRequest: &adminv1.ListPendingAgentsRequest{ByAttestationType: "x509pop"},
+		},
+		{
+			name:             "no pending agents",
+			expectReturnCode: 0,
+			expectStdout:     "No agents pending approval found\n",
+			expectRequest:    &adminv1.ListPendingAgentsRequest{},
+		},
+		{
+			name:             "wrong UDS path",
+			args:             []string{common.AddrArg, common.AddrValue},
+			expectReturnCode: 1,
+			expectStderr:     common.AddrError,
+		},
+		{
+			name:             "server error",
+			serverErr:        status.Error(codes.Internal, "internal server error"),
+			expectReturnCode: 1,
+			expectStderr:     "Error: rpc error: code = Internal desc = internal server error\n",
+			expectRequest:    &adminv1.ListPendingAgentsRequest{},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			test := setupTest(t, agent.NewPendingCommandWithEnv)
+			test.server.err = tt.serverErr
+			test.server.pendingAgents = tt.pendingAgents
+
+			returnCode := test.client.Run(append(test.args, tt.args...))
+			require.Equal(t, tt.expectStdout, test.stdout.String())
+			require.Equal(t, tt.expectStderr, test.stderr.String())
+			require.Equal(t, tt.expectReturnCode, returnCode)
+			require.Equal(t, tt.expectRequest, test.server.gotListPendingAgentRequest)
+		})
+	}
+}
+
 func TestBanHelp(t *testing.T) {
 	test := setupTest(t, agent.NewBanCommandWithEnv)
 
@@ -402,7 +640,9 @@ func TestShowHelp(t *testing.T) {
 	test := setupTest(t, agent.NewShowCommandWithEnv)
 
 	test.client.Help()
-	require.Equal(t, `Usage of agent show:`+common.AddrUsage+
+	require.Equal(t, `Usage of agent show:
+  -attestationHistory int
+    	Number of recent attestations of the agent to show (0 to disable) (default 5)`+common.AddrUsage+
 		`  -spiffeID string
     	The SPIFFE ID of the agent to show (agent identity)
 `, test.stderr.String())
@@ -416,7 +656,10 @@ func TestShow(t *testing.T) {
 		expectedStdout     string
 		expectedStderr     string
 		existentAgents     []*types.Agent
+		attestationEvents  []*adminv1.AttestationEvent
+		adminErr           error
 		serverErr          error
+		expectedEventsReq  *adminv1.ListAttestationEventsRequest
 	}{
 		{
 			name:               "success",
@@ -424,6 +667,54 @@ func TestShow(t *testing.T) {
 			expectedReturnCode: 0,
 			existentAgents:     testAgents,
 			expectedStdout:     "Found an attested agent given its SPIFFE ID\n\nSPIFFE ID         : spiffe://example.org/spire/agent/agent1",
+			expectedEventsReq:  &adminv1.ListAttestationEventsRequest{BySpiffeID: "spiffe://example.org/spire/agent/agent1", Limit: 5},
+		},
+		{
+			name:               "show attestation history",
+			args:               []string{"-spiffeID", "spiffe://example.org/spire/agent/agent1", "-attestationHistory", "2"},
+			expectedReturnCode: 0,
+			existentAgents:     testAgents,
+			attestationEvents: []*adminv1.AttestationEvent{
+				{
+					SpiffeID:     "spiffe://example.org/spire/agent/agent1",
+					SerialNumber: "2",
+					AttestedAt:   time.Unix(1010, 0).UTC(),
+				},
+				{
+					SpiffeID:   "spiffe://example.org/spire/agent/agent1",
+					Error:      "failed to attest: certificate is expired",
+					AttestedAt: time.Unix(1000, 0).UTC(),
+				},
+			},
+			expectedStdout: "Attestation       : 1970-01-01 00:16:50 +0000 UTC succeeded, serial number 2\n" +
+				"Attestation       : 1970-01-01 00:16:40 +0000 UTC failed: failed to attest: certificate is expired\n",
+			expectedEventsReq: &adminv1.ListAttestationEventsRequest{BySpiffeID: "spiffe://example.org/spire/agent/agent1", Limit: 2},
+		},
+		{
+			name:               "attestation history disabled",
+			args:               []string{"-spiffeID", "spiffe://example.org/spire/agent/agent1", "-attestationHistory", "0"},
+			expectedReturnCode: 0,
+			existentAgents:     testAgents,
+			expectedStdout:     "SPIFFE ID         : spiffe://example.org/spire/agent/agent1",
+		},
+		{
+			name:               "attestation history unsupported by server",
+			args:               []string{"-spiffeID", "spiffe://example.org/spire/agent/agent1"},
+			expectedReturnCode: 0,
+			existentAgents:     testAgents,
+			adminErr:           status.Error(codes.Unimplemented, "unknown service"),
+			expectedStdout:     "SPIFFE ID         : spiffe://example.org/spire/agent/agent1",
+			expectedEventsReq:  &adminv1.ListAttestationEventsRequest{BySpiffeID: "spiffe://example.org/spire/agent/agent1", Limit: 5},
+		},
+		{
+			name:               "attestation history error",
+			args:               []string{"-spiffeID", "spiffe://example.org/spire/agent/agent1"},
+			expectedReturnCode: 1,
+			existentAgents:     testAgents,
+			adminErr:           status.Error(codes.Internal, "internal server error"),
+			expectedStdout:     "SPIFFE ID         : spiffe://example.org/spire/agent/agent1",
+			expectedStderr:     "Error: rpc error: code = Internal desc = internal server error\n",
+			expectedEventsReq:  &adminv1.ListAttestationEventsRequest{BySpiffeID: "spiffe://example.org/spire/agent/agent1", Limit: 5},
 		},
 		{
 			name:               "no spiffe id",
@@ -450,6 +741,7 @@ func TestShow(t *testing.T) {
 			existentAgents:     testAgentsWithSelectors,
 			expectedReturnCode: 0,
 			expectedStdout:     "Selectors         : k8s_psat:agent_ns:spire\nSelectors         : k8s_psat:agent_sa:spire-agent\nSelectors         : k8s_psat:cluster:demo-cluster",
+			expectedEventsReq:  &adminv1.ListAttestationEventsRequest{BySpiffeID: "spiffe://example.org/spire/agent/agent2", Limit: 5},
 		},
 		{
 			name:               "show banned",
@@ -457,6 +749,7 @@ func TestShow(t *testing.T) {
 			existentAgents:     testAgentsWithBanned,
 			expectedReturnCode: 0,
 			expectedStdout:     "Banned            : true",
+			expectedEventsReq:  &adminv1.ListAttestationEventsRequest{BySpiffeID: "spiffe://example.org/spire/agent/banned", Limit: 5},
 		},
 	} {
 		tt := tt
@@ -464,11 +757,14 @@ func TestShow(t *testing.T) {
 			test := setupTest(t, agent.NewShowCommandWithEnv)
 			test.server.err = tt.serverErr
 			test.server.agents = tt.existentAgents
+			test.server.attestationEvents = tt.attestationEvents
+			test.server.adminErr = tt.adminErr
 
 			returnCode := test.client.Run(append(test.args, tt.args...))
 			require.Contains(t, test.stdout.String(), tt.expectedStdout)
 			require.Equal(t, tt.expectedStderr, test.stderr.String())
 			require.Equal(t, tt.expectedReturnCode, returnCode)
+			require.Equal(t, tt.expectedEventsReq, test.server.gotListAttestationEventsRequest)
 		})
 	}
 }
@@ -478,6 +774,14 @@ func setupTest(t *testing.T, newClient func(*common_cli.Env) cli.Command) *agent
 
 	addr := spiretest.StartGRPCServer(t, func(s *grpc.Server) {
 		agentv1.RegisterAgentServer(s, server)
+		adminapi.Register(s, adminapi.Service{
+			Name: adminv1.ServiceName,
+			Methods: map[string]adminapi.Handler{
+				"ListPendingAgents":     server.listPendingAgents,
+				"ApprovePendingAgent":   server.approvePendingAgent,
+				"ListAttestationEvents": server.listAttestationEvents,
+			},
+		})
 	})
 
 	stdin := new(bytes.Buffer)
@@ -512,6 +816,14 @@ type fakeAgentServer struct {
 	agents              []*types.Agent
 	gotListAgentRequest *agentv1.ListAgentsRequest
 	err                 error
+
+	pendingAgents              []*adminv1.PendingAgent
+	gotListPendingAgentRequest *adminv1.ListPendingAgentsRequest
+	gotApprovedID              string
+
+	attestationEvents               []*adminv1.AttestationEvent
+	gotListAttestationEventsRequest *adminv1.ListAttestationEventsRequest
+	adminErr                        error
 }
 
 func (s *fakeAgentServer) BanAgent(ctx context.Context, req *agentv1.BanAgentRequest) (*emptypb.Empty, error) {
@@ -542,3 +854,39 @@ func (s *fakeAgentServer) GetAgent(ctx context.Context, req *agentv1.GetAgentReq
 
 	return nil, s.err
 }
+
+func (s *fakeAgentServer) listPendingAgents(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+	req := new(adminv1.ListPendingAgentsRequest)
+	if err := decode(req); err != nil {
+		return nil, err
+	}
+	s.gotListPendingAgentRequest = req
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &adminv1.ListPendingAgentsResponse{Agents: s.pendingAgents}, nil
+}
+
+func (s *fakeAgentServer) approvePendingAgent(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+	req := new(adminv1.ApprovePendingAgentRequest)
+	if err := decode(req); err != nil {
+		return nil, err
+	}
+	s.gotApprovedID = req.SpiffeID
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &adminv1.PendingAgent{SpiffeID: req.SpiffeID, Approved: true}, nil
+}
+
+func (s *fakeAgentServer) listAttestationEvents(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+	req := new(adminv1.ListAttestationEventsRequest)
+	if err := decode(req); err != nil {
+		return nil, err
+	}
+	s.gotListAttestationEventsRequest = req
+	if s.adminErr != nil {
+		return nil, s.adminErr
+	}
+	return &adminv1.ListAttestationEventsResponse{Events: s.attestationEvents}, nil
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"flag"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+)
+
+type approveCommand struct {
+	// SPIFFE ID of the agent being approved
+	spiffeID string
+}
+
+// NewApproveCommand creates a new "approve" subcommand for "agent" command.
+func NewApproveCommand() cli.Command {
+	return NewApproveCommandWithEnv(common_cli.DefaultEnv)
+}
+
+// NewApproveCommandWithEnv creates a new "approve" subcommand for "agent"
+// command using the environment specified
+func NewApproveCommandWithEnv(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(approveCommand))
+}
+
+func (*approveCommand) Name() string {
+	return "agent approve"
+}
+
+func (*approveCommand) Synopsis() string {
+	return "Approve an agent pending approval given its SPIFFE ID"
+}
+
+// Run approves an agent pending approval given its SPIFFE ID
+func (c *approveCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	if c.spiffeID == "" {
+		return errors.New("a SPIFFE ID is required")
+	}
+
+	id, err := spiffeid.FromString(c.spiffeID)
+	if err != nil {
+		return err
+	}
+
+	adminClient := serverClient.NewAdminClient()
+	if _, err := adminClient.ApprovePendingAgent(ctx, &adminv1.ApprovePendingAgentRequest{
+		SpiffeID: id.String(),
+	}); err != nil {
+		return err
+	}
+
+	return env.Println("Agent approved successfully; it is issued an SVID the next time it attests")
+}
+
+func (c *approveCommand) AppendFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.spiffeID, "spiffeID", "", "The SPIFFE ID of the agent to approve (agent identity)")
+}
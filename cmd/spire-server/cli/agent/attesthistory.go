@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+)
+
+type attestHistoryCommand struct {
+	// SPIFFE ID of the agent the attestations are listed for
+	spiffeID string
+
+	// Only list failed attestations
+	failedOnly bool
+
+	// Maximum number of attestations listed
+	limit int
+}
+
+// NewAttestHistoryCommand creates a new "attest-history" subcommand for
+// "agent" command.
+func NewAttestHistoryCommand() cli.Command {
+	return NewAttestHistoryCommandWithEnv(common_cli.DefaultEnv)
+}
+
+// NewAttestHistoryCommandWithEnv creates a new "attest-history" subcommand
+// for "agent" command using the environment specified
+func NewAttestHistoryCommandWithEnv(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(attestHistoryCommand))
+}
+
+func (*attestHistoryCommand) Name() string {
+	return "agent attest-history"
+}
+
+func (*attestHistoryCommand) Synopsis() string {
+	return "Lists the recent attestations of agents, successful or not"
+}
+
+// Run lists the recent attestations of agents
+func (c *attestHistoryCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	adminClient := serverClient.NewAdminClient()
+	resp, err := adminClient.ListAttestationEvents(ctx, &adminv1.ListAttestationEventsRequest{
+		BySpiffeID: c.spiffeID,
+		FailedOnly: c.failedOnly,
+		Limit:      int32(c.limit),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Events) == 0 {
+		return env.Printf("No attestations found\n")
+	}
+
+	msg := fmt.Sprintf("Found %d ", len(resp.Events))
+	msg = util.Pluralizer(msg, "attestation", "attestations", len(resp.Events))
+	env.Printf(msg + ":\n\n")
+
+	for _, event := range resp.Events {
+		if event.SpiffeID != "" {
+			if err := env.Printf("SPIFFE ID         : %s\n", event.SpiffeID); err != nil {
+				return err
+			}
+		}
+		if err := env.Printf("Attestation type  : %s\n", event.AttestationType); err != nil {
+			return err
+		}
+		if err := env.Printf("Attested at       : %s\n", event.AttestedAt); err != nil {
+			return err
+		}
+		if event.Error != "" {
+			if err := env.Printf("Error             : %s\n", event.Error); err != nil {
+				return err
+			}
+			if len(event.Selectors) > 0 {
+				if err := env.Printf("Selectors         : %s\n", strings.Join(event.Selectors, ", ")); err != nil {
+					return err
+				}
+			}
+		} else {
+			if err := env.Printf("Serial number     : %s\n", event.SerialNumber); err != nil {
+				return err
+			}
+			if err := env.Printf("Reattestation     : %t\n", event.Reattestation); err != nil {
+				return err
+			}
+		}
+		if err := env.Println(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *attestHistoryCommand) AppendFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.failedOnly, "failedOnly", false, "Only list failed attestations")
+	fs.IntVar(&c.limit, "limit", 100, "Maximum number of attestations to list, most recent first")
+	fs.StringVar(&c.spiffeID, "spiffeID", "", "Only list the attestations of the agent with the given SPIFFE ID")
+}
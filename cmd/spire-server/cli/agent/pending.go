@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+)
+
+type pendingCommand struct {
+	// Node attestor the listed agents attested with
+	attestationType string
+}
+
+// NewPendingCommand creates a new "pending" subcommand for "agent" command.
+func NewPendingCommand() cli.Command {
+	return NewPendingCommandWithEnv(common_cli.DefaultEnv)
+}
+
+// NewPendingCommandWithEnv creates a new "pending" subcommand for "agent"
+// command using the environment specified
+func NewPendingCommandWithEnv(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(pendingCommand))
+}
+
+func (*pendingCommand) Name() string {
+	return "agent pending"
+}
+
+func (*pendingCommand) Synopsis() string {
+	return "Lists agents pending approval"
+}
+
+// Run lists agents pending approval
+func (c *pendingCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	adminClient := serverClient.NewAdminClient()
+	resp, err := adminClient.ListPendingAgents(ctx, &adminv1.ListPendingAgentsRequest{
+		ByAttestationType: c.attestationType,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(resp.Agents) == 0 {
+		return env.Printf("No agents pending approval found\n")
+	}
+
+	msg := fmt.Sprintf("Found %d pending ", len(resp.Agents))
+	msg = util.Pluralizer(msg, "agent", "agents", len(resp.Agents))
+	env.Printf(msg + ":\n\n")
+
+	for _, agent := range resp.Agents {
+		if err := env.Printf("SPIFFE ID         : %s\n", agent.SpiffeID); err != nil {
+			return err
+		}
+		if err := env.Printf("Attestation type  : %s\n", agent.AttestationType); err != nil {
+			return err
+		}
+		if err := env.Printf("Approved          : %t\n", agent.Approved); err != nil {
+			return err
+		}
+		if err := env.Printf("Requested at      : %s\n", agent.RequestedAt); err != nil {
+			return err
+		}
+		if err := env.Printf("Last attempt at   : %s\n", agent.LastAttemptAt); err != nil {
+			return err
+		}
+		if err := env.Println(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *pendingCommand) AppendFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.attestationType, "attestationType", "", "Only list the agents that attested with the given node attestor")
+}
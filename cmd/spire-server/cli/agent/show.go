@@ -11,6 +11,9 @@ import (
 	"github.com/spiffe/spire/cmd/spire-server/util"
 	common_cli "github.com/spiffe/spire/pkg/common/cli"
 	"github.com/spiffe/spire/pkg/server/api"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"golang.org/x/net/context"
 )
@@ -18,6 +21,9 @@ import (
 type showCommand struct {
 	// SPIFFE ID of the agent being showed
 	spiffeID string
+
+	// Number of recent attestations of the agent to show
+	attestationHistory int
 }
 
 // NewShowCommand creates a new "show" subcommand for "agent" command.
@@ -65,9 +71,36 @@ func (c *showCommand) Run(ctx context.Context, env *common_cli.Env, serverClient
 	for _, s := range agent.Selectors {
 		env.Printf("Selectors         : %s:%s\n", s.Type, s.Value)
 	}
+
+	if c.attestationHistory <= 0 {
+		return nil
+	}
+
+	adminClient := serverClient.NewAdminClient()
+	resp, err := adminClient.ListAttestationEvents(ctx, &adminv1.ListAttestationEventsRequest{
+		BySpiffeID: id.String(),
+		Limit:      int32(c.attestationHistory),
+	})
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.Unimplemented:
+		// Servers that predate the admin API do not record attestations
+		return nil
+	default:
+		return err
+	}
+
+	for _, event := range resp.Events {
+		if event.Error != "" {
+			env.Printf("Attestation       : %s failed: %s\n", event.AttestedAt, event.Error)
+			continue
+		}
+		env.Printf("Attestation       : %s succeeded, serial number %s\n", event.AttestedAt, event.SerialNumber)
+	}
 	return nil
 }
 
 func (c *showCommand) AppendFlags(fs *flag.FlagSet) {
+	fs.IntVar(&c.attestationHistory, "attestationHistory", 5, "Number of recent attestations of the agent to show (0 to disable)")
 	fs.StringVar(&c.spiffeID, "spiffeID", "", "The SPIFFE ID of the agent to show (agent identity)")
 }
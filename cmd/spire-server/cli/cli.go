@@ -7,11 +7,14 @@ import (
 	"github.com/spiffe/spire/cmd/spire-server/cli/agent"
 	"github.com/spiffe/spire/cmd/spire-server/cli/bundle"
 	"github.com/spiffe/spire/cmd/spire-server/cli/entry"
+	"github.com/spiffe/spire/cmd/spire-server/cli/events"
 	"github.com/spiffe/spire/cmd/spire-server/cli/federation"
 	"github.com/spiffe/spire/cmd/spire-server/cli/healthcheck"
 	"github.com/spiffe/spire/cmd/spire-server/cli/jwt"
 	"github.com/spiffe/spire/cmd/spire-server/cli/run"
+	"github.com/spiffe/spire/cmd/spire-server/cli/selectorset"
 	"github.com/spiffe/spire/cmd/spire-server/cli/token"
+	"github.com/spiffe/spire/cmd/spire-server/cli/upstreamauthority"
 	"github.com/spiffe/spire/cmd/spire-server/cli/validate"
 	"github.com/spiffe/spire/cmd/spire-server/cli/x509"
 	"github.com/spiffe/spire/pkg/common/log"
@@ -29,6 +32,12 @@ func (cc *CLI) Run(args []string) int {
 	c := cli.NewCLI("spire-server", version.Version())
 	c.Args = args
 	c.Commands = map[string]cli.CommandFactory{
+		"agent approve": func() (cli.Command, error) {
+			return agent.NewApproveCommand(), nil
+		},
+		"agent attest-history": func() (cli.Command, error) {
+			return agent.NewAttestHistoryCommand(), nil
+		},
 		"agent ban": func() (cli.Command, error) {
 			return agent.NewBanCommand(), nil
 		},
@@ -41,6 +50,9 @@ func (cc *CLI) Run(args []string) int {
 		"agent list": func() (cli.Command, error) {
 			return agent.NewListCommand(), nil
 		},
+		"agent pending": func() (cli.Command, error) {
+			return agent.NewPendingCommand(), nil
+		},
 		"agent show": func() (cli.Command, error) {
 			return agent.NewShowCommand(), nil
 		},
@@ -59,6 +71,9 @@ func (cc *CLI) Run(args []string) int {
 		"bundle delete": func() (cli.Command, error) {
 			return bundle.NewDeleteCommand(), nil
 		},
+		"entry cache": func() (cli.Command, error) {
+			return entry.NewCacheCommand(), nil
+		},
 		"entry count": func() (cli.Command, error) {
 			return entry.NewCountCommand(), nil
 		},
@@ -80,6 +95,15 @@ func (cc *CLI) Run(args []string) int {
 		"federation delete": func() (cli.Command, error) {
 			return federation.NewDeleteCommand(), nil
 		},
+		"events list": func() (cli.Command, error) {
+			return events.NewListCommand(), nil
+		},
+		"federation export": func() (cli.Command, error) {
+			return federation.NewExportCommand(), nil
+		},
+		"federation import": func() (cli.Command, error) {
+			return federation.NewImportCommand(), nil
+		},
 		"federation list": func() (cli.Command, error) {
 			return federation.NewListCommand(), nil
 		},
@@ -95,12 +119,33 @@ func (cc *CLI) Run(args []string) int {
 		"run": func() (cli.Command, error) {
 			return run.NewRunCommand(cc.LogOptions, cc.AllowUnknownConfig), nil
 		},
+		"selectorset create": func() (cli.Command, error) {
+			return selectorset.NewCreateCommand(), nil
+		},
+		"selectorset delete": func() (cli.Command, error) {
+			return selectorset.NewDeleteCommand(), nil
+		},
+		"selectorset list": func() (cli.Command, error) {
+			return selectorset.NewListCommand(), nil
+		},
+		"selectorset show": func() (cli.Command, error) {
+			return selectorset.NewShowCommand(), nil
+		},
+		"selectorset update": func() (cli.Command, error) {
+			return selectorset.NewUpdateCommand(), nil
+		},
 		"token generate": func() (cli.Command, error) {
 			return token.NewGenerateCommand(), nil
 		},
 		"healthcheck": func() (cli.Command, error) {
 			return healthcheck.NewHealthCheckCommand(), nil
 		},
+		"upstreamauthority status": func() (cli.Command, error) {
+			return upstreamauthority.NewStatusCommand(), nil
+		},
+		"x509 issuances": func() (cli.Command, error) {
+			return x509.NewIssuancesCommand(), nil
+		},
 		"x509 mint": func() (cli.Command, error) {
 			return x509.NewMintCommand(), nil
 		},
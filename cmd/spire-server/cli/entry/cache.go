@@ -0,0 +1,74 @@
+package entry
+
+import (
+	"context"
+	"flag"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+)
+
+type cacheCommand struct {
+	// Fully reload the entry cache before reporting its status
+	reload bool
+}
+
+// NewCacheCommand creates a new "cache" subcommand for "entry" command.
+func NewCacheCommand() cli.Command {
+	return NewCacheCommandWithEnv(common_cli.DefaultEnv)
+}
+
+// NewCacheCommandWithEnv creates a new "cache" subcommand for "entry" command
+// using the environment specified.
+func NewCacheCommandWithEnv(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(cacheCommand))
+}
+
+func (*cacheCommand) Name() string {
+	return "entry cache"
+}
+
+func (*cacheCommand) Synopsis() string {
+	return "Compares the in-memory entry cache of the server to the datastore"
+}
+
+// Run prints the status of the entry cache, after reloading it if requested
+func (c *cacheCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	adminClient := serverClient.NewAdminClient()
+
+	var status *adminv1.EntryCacheStatus
+	var err error
+	if c.reload {
+		status, err = adminClient.ReloadEntryCache(ctx)
+	} else {
+		status, err = adminClient.GetEntryCacheStatus(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := env.Printf("Cached entries    : %d\n", status.EntryCount); err != nil {
+		return err
+	}
+	if err := env.Printf("Datastore entries : %d\n", status.DataStoreEntryCount); err != nil {
+		return err
+	}
+	if err := env.Printf("Stale             : %t\n", status.Stale); err != nil {
+		return err
+	}
+	for _, entryID := range status.StaleEntryIDs {
+		if err := env.Printf("Stale entry ID    : %s\n", entryID); err != nil {
+			return err
+		}
+	}
+	if err := env.Printf("Last reload       : %s\n", status.LastReload); err != nil {
+		return err
+	}
+	return env.Printf("Reload duration   : %s\n", status.LastReloadDuration)
+}
+
+func (c *cacheCommand) AppendFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&c.reload, "reload", false, "Fully reload the entry cache from the datastore first")
+}
@@ -0,0 +1,122 @@
+package entry
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire/cmd/spire-server/cli/common"
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCacheHelp(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	cmd := NewCacheCommandWithEnv(&common_cli.Env{
+		Stdin:  new(bytes.Buffer),
+		Stdout: new(bytes.Buffer),
+		Stderr: stderr,
+	})
+	assert.Equal(t, "flag: help requested", cmd.Help())
+	assert.Equal(t, `Usage of entry cache:
+  -reload
+    	Fully reload the entry cache from the datastore first`+common.AddrUsage, stderr.String())
+}
+
+func TestCache(t *testing.T) {
+	cacheStatus := &adminv1.EntryCacheStatus{
+		EntryCount:          3,
+		DataStoreEntryCount: 3,
+		StaleEntryIDs:       []string{"entry1"},
+		Stale:               true,
+		LastReload:          time.Unix(1000, 0).UTC(),
+		LastReloadDuration:  250 * time.Millisecond,
+	}
+
+	for _, tt := range []struct {
+		name         string
+		args         []string
+		serverErr    error
+		code         int
+		stdout       string
+		stderr       string
+		expectMethod string
+	}{
+		{
+			name: "status",
+			stdout: `Cached entries    : 3
+Datastore entries : 3
+Stale             : true
+Stale entry ID    : entry1
+Last reload       : 1970-01-01 00:16:40 +0000 UTC
+Reload duration   : 250ms
+`,
+			expectMethod: "GetEntryCacheStatus",
+		},
+		{
+			name: "reload",
+			args: []string{"-reload"},
+			stdout: `Cached entries    : 3
+Datastore entries : 3
+Stale             : true
+Stale entry ID    : entry1
+Last reload       : 1970-01-01 00:16:40 +0000 UTC
+Reload duration   : 250ms
+`,
+			expectMethod: "ReloadEntryCache",
+		},
+		{
+			name:         "server error",
+			args:         []string{"-reload"},
+			serverErr:    status.Error(codes.Internal, "failed to reload entry cache: oh no"),
+			code:         1,
+			stderr:       "Error: rpc error: code = Internal desc = failed to reload entry cache: oh no\n",
+			expectMethod: "ReloadEntryCache",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod string
+			handler := func(method string) adminapi.Handler {
+				return func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+					gotMethod = method
+					if tt.serverErr != nil {
+						return nil, tt.serverErr
+					}
+					return cacheStatus, nil
+				}
+			}
+			addr := spiretest.StartGRPCServer(t, func(s *grpc.Server) {
+				adminapi.Register(s, adminapi.Service{
+					Name: adminv1.ServiceName,
+					Methods: map[string]adminapi.Handler{
+						"GetEntryCacheStatus": handler("GetEntryCacheStatus"),
+						"ReloadEntryCache":    handler("ReloadEntryCache"),
+					},
+				})
+			})
+
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			cmd := NewCacheCommandWithEnv(&common_cli.Env{
+				Stdin:  new(bytes.Buffer),
+				Stdout: stdout,
+				Stderr: stderr,
+			})
+
+			code := cmd.Run(append([]string{common.AddrArg, common.GetAddr(addr)}, tt.args...))
+			assert.Equal(t, tt.code, code)
+			assert.Equal(t, tt.stdout, stdout.String())
+			assert.Equal(t, tt.stderr, stderr.String())
+			require.Equal(t, tt.expectMethod, gotMethod)
+		})
+	}
+}
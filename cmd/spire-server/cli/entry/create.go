@@ -29,6 +29,13 @@ type createCommand struct {
 	// opts will be ignored.
 	path string
 
+	// Path to an optional Kubernetes manifest the entries are derived from.
+	fromK8s string
+
+	// Namespace of the objects of the Kubernetes manifest that do not set
+	// one.
+	namespace string
+
 	// Type and value are delimited by a colon (:)
 	// ex. "unix:uid:1000" or "spiffe_id:spiffe://example.org/foo"
 	selectors StringsFlag
@@ -77,6 +84,8 @@ func (c *createCommand) AppendFlags(f *flag.FlagSet) {
 	f.StringVar(&c.spiffeID, "spiffeID", "", "The SPIFFE ID that this record represents")
 	f.IntVar(&c.ttl, "ttl", 0, "The lifetime, in seconds, for SVIDs issued based on this registration entry")
 	f.StringVar(&c.path, "data", "", "Path to a file containing registration JSON (optional). If set to '-', read the JSON from stdin.")
+	f.StringVar(&c.fromK8s, "from-k8s", "", "Path to a Kubernetes manifest to derive an entry for every workload container from (optional). If set to '-', read the manifest from stdin. If set, the SPIFFE ID is a template")
+	f.StringVar(&c.namespace, "namespace", "", "Namespace of the objects of the Kubernetes manifest that do not set one, used with -from-k8s. Defaults to 'default'")
 	f.Var(&c.selectors, "selector", "A colon-delimited type:value selector. Can be used more than once")
 	f.Var(&c.federatesWith, "federatesWith", "SPIFFE ID of a trust domain to federate with. Can be used more than once")
 	f.BoolVar(&c.node, "node", false, "If set, this entry will be applied to matching nodes rather than workloads")
@@ -94,9 +103,12 @@ func (c *createCommand) Run(ctx context.Context, env *common_cli.Env, serverClie
 
 	var entries []*types.Entry
 	var err error
-	if c.path != "" {
+	switch {
+	case c.path != "":
 		entries, err = parseFile(c.path)
-	} else {
+	case c.fromK8s != "":
+		entries, err = c.parseK8sConfig()
+	default:
 		entries, err = c.parseConfig()
 	}
 	if err != nil {
@@ -131,11 +143,22 @@ func (c *createCommand) Run(ctx context.Context, env *common_cli.Env, serverClie
 // validate performs basic validation, even on fields that we
 // have defaults defined for.
 func (c *createCommand) validate() (err error) {
+	if c.namespace != "" && c.fromK8s == "" {
+		return errors.New("the namespace flag can only be used with the from-k8s flag")
+	}
+
 	// If a path is set, we have all we need
 	if c.path != "" {
+		if c.fromK8s != "" {
+			return errors.New("the data and from-k8s flags are mutually exclusive")
+		}
 		return nil
 	}
 
+	if c.fromK8s != "" {
+		return c.validateK8s()
+	}
+
 	if len(c.selectors) < 1 {
 		return errors.New("at least one selector is required")
 	}
@@ -159,6 +182,25 @@ func (c *createCommand) validate() (err error) {
 	return nil
 }
 
+// validateK8s performs basic validation when entries are derived from a
+// Kubernetes manifest. Selectors and the SPIFFE ID are derived from the
+// manifest, so they are optional.
+func (c *createCommand) validateK8s() error {
+	if c.node {
+		return errors.New("node entries can not be derived from Kubernetes manifests")
+	}
+
+	if c.parentID == "" {
+		return errors.New("a parent ID is required")
+	}
+
+	if c.ttl < 0 {
+		return errors.New("a positive TTL is required")
+	}
+
+	return nil
+}
+
 // parseConfig builds a registration entry from the given config
 func (c *createCommand) parseConfig() ([]*types.Entry, error) {
 	spiffeID, err := idStringToProto(c.spiffeID)
@@ -171,16 +213,82 @@ func (c *createCommand) parseConfig() ([]*types.Entry, error) {
 		return nil, err
 	}
 
-	e := &types.Entry{
-		ParentId:   parentID,
-		SpiffeId:   spiffeID,
-		Ttl:        int32(c.ttl),
-		Downstream: c.downstream,
-		ExpiresAt:  c.entryExpiry,
-		DnsNames:   c.dnsNames,
-		StoreSvid:  c.storeSVID,
+	selectors, err := c.parseSelectors()
+	if err != nil {
+		return nil, err
+	}
+
+	e := c.newEntry(parentID, spiffeID)
+	e.Selectors = selectors
+	return []*types.Entry{e}, nil
+}
+
+// parseK8sConfig builds a registration entry for every container of the
+// workloads defined in the given Kubernetes manifest. The selectors are
+// derived from the workload and the container, and extended with the given
+// selectors. The SPIFFE ID is derived from a template.
+func (c *createCommand) parseK8sConfig() ([]*types.Entry, error) {
+	parentID, err := idStringToProto(c.parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	extraSelectors, err := c.parseSelectors()
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := parseK8sSPIFFEIDTemplate(c.spiffeID)
+	if err != nil {
+		return nil, err
+	}
+
+	workloads, err := parseK8sManifestFile(c.fromK8s, c.namespace)
+	if err != nil {
+		return nil, err
 	}
 
+	var entries []*types.Entry
+	for _, workload := range workloads {
+		for i := range workload.Containers {
+			container := &workload.Containers[i]
+			spiffeID, err := executeK8sSPIFFEIDTemplate(tmpl, k8sSPIFFEIDTemplateData{
+				TrustDomain:    parentID.TrustDomain,
+				Kind:           workload.Kind,
+				Name:           workload.Name,
+				Namespace:      workload.Namespace,
+				ServiceAccount: workload.ServiceAccount,
+				Container:      container.Name,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			e := c.newEntry(parentID, spiffeID)
+			e.Selectors = append(workload.selectors(container), extraSelectors...)
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// newEntry builds a registration entry with the given IDs and the entry
+// attributes given in the config.
+func (c *createCommand) newEntry(parentID, spiffeID *types.SPIFFEID) *types.Entry {
+	return &types.Entry{
+		ParentId:      parentID,
+		SpiffeId:      spiffeID,
+		Ttl:           int32(c.ttl),
+		Downstream:    c.downstream,
+		ExpiresAt:     c.entryExpiry,
+		DnsNames:      c.dnsNames,
+		StoreSvid:     c.storeSVID,
+		FederatesWith: c.federatesWith,
+		Admin:         c.admin,
+	}
+}
+
+func (c *createCommand) parseSelectors() ([]*types.Selector, error) {
 	selectors := []*types.Selector{}
 	for _, s := range c.selectors {
 		cs, err := util.ParseSelector(s)
@@ -190,11 +298,7 @@ func (c *createCommand) parseConfig() ([]*types.Entry, error) {
 
 		selectors = append(selectors, cs)
 	}
-
-	e.Selectors = selectors
-	e.FederatesWith = c.federatesWith
-	e.Admin = c.admin
-	return []*types.Entry{e}, nil
+	return selectors, nil
 }
 
 func createEntries(ctx context.Context, c entryv1.EntryClient, entries []*types.Entry) (succeeded, failed []*entryv1.BatchCreateEntryResponse_Result, err error) {
@@ -101,6 +101,61 @@ func TestCreate(t *testing.T) {
 		},
 	}
 
+	fakeRespOKFromK8s := &entryv1.BatchCreateEntryResponse{
+		Results: []*entryv1.BatchCreateEntryResponse_Result{
+			{
+				Entry: &types.Entry{
+					Id:        "entry-id-1",
+					SpiffeId:  &types.SPIFFEID{TrustDomain: "example.org", Path: "/ns/blog/sa/blog-sa"},
+					ParentId:  &types.SPIFFEID{TrustDomain: "example.org", Path: "/parent"},
+					Selectors: k8sDeploymentSelectors("blog", "ghcr.io/example/blog:1.0"),
+				},
+				Status: &types.Status{
+					Code:    int32(codes.OK),
+					Message: "OK",
+				},
+			},
+			{
+				Entry: &types.Entry{
+					Id:        "entry-id-2",
+					SpiffeId:  &types.SPIFFEID{TrustDomain: "example.org", Path: "/ns/blog/sa/blog-sa"},
+					ParentId:  &types.SPIFFEID{TrustDomain: "example.org", Path: "/parent"},
+					Selectors: k8sDeploymentSelectors("proxy", "ghcr.io/example/proxy:2.1"),
+				},
+				Status: &types.Status{
+					Code:    int32(codes.OK),
+					Message: "OK",
+				},
+			},
+		},
+	}
+
+	expOutFromK8s := `Entry ID         : entry-id-1
+SPIFFE ID        : spiffe://example.org/ns/blog/sa/blog-sa
+Parent ID        : spiffe://example.org/parent
+Revision         : 0
+TTL              : default
+Selector         : k8s:ns:blog
+Selector         : k8s:sa:blog-sa
+Selector         : k8s:pod-label:app:blog
+Selector         : k8s:pod-label:tier:frontend
+Selector         : k8s:container-name:blog
+Selector         : k8s:container-image:ghcr.io/example/blog:1.0
+
+Entry ID         : entry-id-2
+SPIFFE ID        : spiffe://example.org/ns/blog/sa/blog-sa
+Parent ID        : spiffe://example.org/parent
+Revision         : 0
+TTL              : default
+Selector         : k8s:ns:blog
+Selector         : k8s:sa:blog-sa
+Selector         : k8s:pod-label:app:blog
+Selector         : k8s:pod-label:tier:frontend
+Selector         : k8s:container-name:proxy
+Selector         : k8s:container-image:ghcr.io/example/proxy:2.1
+
+`
+
 	fakeRespErr := &entryv1.BatchCreateEntryResponse{
 		Results: []*entryv1.BatchCreateEntryResponse_Result{
 			{
@@ -279,6 +334,84 @@ StoreSvid        : true
 
 `,
 		},
+		{
+			name:   "Data and Kubernetes manifest",
+			args:   []string{"-data", "../../../../test/fixture/registration/good.json", "-from-k8s", "../../../../test/fixture/registration/k8s_deployment.yaml"},
+			expErr: "Error: the data and from-k8s flags are mutually exclusive\n",
+		},
+		{
+			name:   "Namespace without from-k8s",
+			args:   []string{"-namespace", "blog", "-spiffeID", "spiffe://example.org/workload", "-parentID", "spiffe://example.org/parent", "-selector", "unix:uid:1"},
+			expErr: "Error: the namespace flag can only be used with the from-k8s flag\n",
+		},
+		{
+			name:   "Missing parent SPIFFE ID with Kubernetes manifest",
+			args:   []string{"-from-k8s", "../../../../test/fixture/registration/k8s_deployment.yaml"},
+			expErr: "Error: a parent ID is required\n",
+		},
+		{
+			name:   "Node entries from Kubernetes manifest",
+			args:   []string{"-from-k8s", "../../../../test/fixture/registration/k8s_deployment.yaml", "-node"},
+			expErr: "Error: node entries can not be derived from Kubernetes manifests\n",
+		},
+		{
+			name:   "Invalid SPIFFE ID template",
+			args:   []string{"-from-k8s", "../../../../test/fixture/registration/k8s_deployment.yaml", "-parentID", "spiffe://example.org/parent", "-spiffeID", "spiffe://example.org/{{.Unknown}}"},
+			expErr: "Error: failed to execute SPIFFE ID template: template: spiffe-id:1:23: executing \"spiffe-id\" at <.Unknown>: can't evaluate field Unknown in type entry.k8sSPIFFEIDTemplateData\n",
+		},
+		{
+			name: "Create succeeds using Kubernetes manifest",
+			args: []string{
+				"-from-k8s", "../../../../test/fixture/registration/k8s_deployment.yaml",
+				"-parentID", "spiffe://example.org/parent",
+			},
+			expReq: &entryv1.BatchCreateEntryRequest{
+				Entries: []*types.Entry{
+					{
+						SpiffeId:  &types.SPIFFEID{TrustDomain: "example.org", Path: "/ns/blog/sa/blog-sa"},
+						ParentId:  &types.SPIFFEID{TrustDomain: "example.org", Path: "/parent"},
+						Selectors: k8sDeploymentSelectors("blog", "ghcr.io/example/blog:1.0"),
+					},
+					{
+						SpiffeId:  &types.SPIFFEID{TrustDomain: "example.org", Path: "/ns/blog/sa/blog-sa"},
+						ParentId:  &types.SPIFFEID{TrustDomain: "example.org", Path: "/parent"},
+						Selectors: k8sDeploymentSelectors("proxy", "ghcr.io/example/proxy:2.1"),
+					},
+				},
+			},
+			fakeResp: fakeRespOKFromK8s,
+			expOut:   expOutFromK8s,
+		},
+		{
+			name: "Create succeeds using Kubernetes manifest and SPIFFE ID template",
+			args: []string{
+				"-from-k8s", "../../../../test/fixture/registration/k8s_deployment.yaml",
+				"-parentID", "spiffe://example.org/parent",
+				"-spiffeID", "spiffe://example.org/{{.Name}}/{{.Container}}",
+				"-selector", "k8s:node-name:node-1",
+				"-ttl", "60",
+			},
+			expReq: &entryv1.BatchCreateEntryRequest{
+				Entries: []*types.Entry{
+					{
+						SpiffeId: &types.SPIFFEID{TrustDomain: "example.org", Path: "/blog/blog"},
+						ParentId: &types.SPIFFEID{TrustDomain: "example.org", Path: "/parent"},
+						Selectors: append(k8sDeploymentSelectors("blog", "ghcr.io/example/blog:1.0"),
+							&types.Selector{Type: "k8s", Value: "node-name:node-1"}),
+						Ttl: 60,
+					},
+					{
+						SpiffeId: &types.SPIFFEID{TrustDomain: "example.org", Path: "/blog/proxy"},
+						ParentId: &types.SPIFFEID{TrustDomain: "example.org", Path: "/parent"},
+						Selectors: append(k8sDeploymentSelectors("proxy", "ghcr.io/example/proxy:2.1"),
+							&types.Selector{Type: "k8s", Value: "node-name:node-1"}),
+						Ttl: 60,
+					},
+				},
+			},
+			fakeResp: fakeRespOKFromK8s,
+			expOut:   expOutFromK8s,
+		},
 		{
 			name: "Entry already exist",
 			args: []string{"-spiffeID", "spiffe://example.org/already-exist", "-node", "-selector", "unix:uid:1"},
@@ -321,3 +454,14 @@ Error: failed to create one or more entries
 		})
 	}
 }
+
+func k8sDeploymentSelectors(container, image string) []*types.Selector {
+	return []*types.Selector{
+		{Type: "k8s", Value: "ns:blog"},
+		{Type: "k8s", Value: "sa:blog-sa"},
+		{Type: "k8s", Value: "pod-label:app:blog"},
+		{Type: "k8s", Value: "pod-label:tier:frontend"},
+		{Type: "k8s", Value: "container-name:" + container},
+		{Type: "k8s", Value: "container-image:" + image},
+	}
+}
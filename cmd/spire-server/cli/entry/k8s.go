@@ -0,0 +1,243 @@
+package entry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/template"
+
+	"github.com/docker/distribution/reference"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const (
+	// defaultK8sSPIFFEIDTemplate is the template used to derive the SPIFFE
+	// IDs of the entries created from Kubernetes manifests when no SPIFFE
+	// ID is given. It follows the format used by the k8s-workload-registrar.
+	defaultK8sSPIFFEIDTemplate = "spiffe://{{.TrustDomain}}/ns/{{.Namespace}}/sa/{{.ServiceAccount}}"
+
+	defaultK8sNamespace      = "default"
+	defaultK8sServiceAccount = "default"
+)
+
+// k8sWorkload is a workload defined in a Kubernetes manifest.
+type k8sWorkload struct {
+	Kind           string
+	Name           string
+	Namespace      string
+	ServiceAccount string
+	Labels         map[string]string
+	Containers     []corev1.Container
+}
+
+// k8sSPIFFEIDTemplateData is the data the SPIFFE ID template is executed
+// with for every container of a workload.
+type k8sSPIFFEIDTemplateData struct {
+	TrustDomain    string
+	Kind           string
+	Name           string
+	Namespace      string
+	ServiceAccount string
+	Container      string
+}
+
+type k8sObject struct {
+	Kind     string            `json:"kind"`
+	Metadata metav1.ObjectMeta `json:"metadata"`
+	Spec     json.RawMessage   `json:"spec"`
+}
+
+type k8sPodTemplateSpec struct {
+	Template corev1.PodTemplateSpec `json:"template"`
+}
+
+type k8sCronJobSpec struct {
+	JobTemplate struct {
+		Spec k8sPodTemplateSpec `json:"spec"`
+	} `json:"jobTemplate"`
+}
+
+// parseK8sManifestFile parses the workloads defined in a Kubernetes manifest
+// file. If path is "-" the manifest is read from STDIN.
+func parseK8sManifestFile(path, namespace string) ([]k8sWorkload, error) {
+	return parseK8sManifest(os.Stdin, path, namespace)
+}
+
+// parseK8sManifest parses the workloads defined in a YAML or JSON Kubernetes
+// manifest, which can hold multiple documents. Objects that do not define
+// pods, like services, are ignored. Objects that do not set a namespace
+// belong to the given one, or to the default namespace if empty.
+func parseK8sManifest(in io.Reader, path, namespace string) ([]k8sWorkload, error) {
+	if namespace == "" {
+		namespace = defaultK8sNamespace
+	}
+
+
+	r := in
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var workloads []k8sWorkload
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var obj k8sObject
+		err := decoder.Decode(&obj)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Kubernetes manifest: %w", err)
+		}
+
+		workload, ok, err := workloadFromK8sObject(&obj, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s %q: %w", obj.Kind, obj.Metadata.Name, err)
+		}
+		if ok {
+			workloads = append(workloads, workload)
+		}
+	}
+
+	if len(workloads) == 0 {
+		return nil, errors.New("no workloads found in Kubernetes manifest")
+	}
+	return workloads, nil
+}
+
+func workloadFromK8sObject(obj *k8sObject, namespace string) (k8sWorkload, bool, error) {
+	var podTemplate corev1.PodTemplateSpec
+	switch obj.Kind {
+	case "Pod":
+		podTemplate.ObjectMeta = obj.Metadata
+		if err := unmarshalK8sSpec(obj.Spec, &podTemplate.Spec); err != nil {
+			return k8sWorkload{}, false, err
+		}
+	case "Deployment", "DaemonSet", "Job", "ReplicaSet", "ReplicationController", "StatefulSet":
+		var spec k8sPodTemplateSpec
+		if err := unmarshalK8sSpec(obj.Spec, &spec); err != nil {
+			return k8sWorkload{}, false, err
+		}
+		podTemplate = spec.Template
+	case "CronJob":
+		var spec k8sCronJobSpec
+		if err := unmarshalK8sSpec(obj.Spec, &spec); err != nil {
+			return k8sWorkload{}, false, err
+		}
+		podTemplate = spec.JobTemplate.Spec.Template
+	default:
+		return k8sWorkload{}, false, nil
+	}
+
+	if len(podTemplate.Spec.Containers) == 0 {
+		return k8sWorkload{}, false, errors.New("no containers defined")
+	}
+	for i := range podTemplate.Spec.Containers {
+		container := &podTemplate.Spec.Containers[i]
+		image, err := normalizeK8sImage(container.Image)
+		if err != nil {
+			return k8sWorkload{}, false, fmt.Errorf("invalid image of container %q: %w", container.Name, err)
+		}
+		container.Image = image
+	}
+
+	workload := k8sWorkload{
+		Kind:           obj.Kind,
+		Name:           obj.Metadata.Name,
+		Namespace:      obj.Metadata.Namespace,
+		ServiceAccount: podTemplate.Spec.ServiceAccountName,
+		Labels:         podTemplate.Labels,
+		Containers:     podTemplate.Spec.Containers,
+	}
+	if workload.Namespace == "" {
+		workload.Namespace = namespace
+	}
+	if workload.ServiceAccount == "" {
+		workload.ServiceAccount = podTemplate.Spec.DeprecatedServiceAccount
+	}
+	if workload.ServiceAccount == "" {
+		workload.ServiceAccount = defaultK8sServiceAccount
+	}
+	return workload, true, nil
+}
+
+// normalizeK8sImage returns the fully qualified form of the image reference,
+// e.g. docker.io/library/nginx:latest for nginx, which is how container
+// runtimes like containerd and CRI-O report the images of the containers.
+func normalizeK8sImage(image string) (string, error) {
+	if image == "" {
+		return "", nil
+	}
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", err
+	}
+	return reference.TagNameOnly(named).String(), nil
+}
+
+func unmarshalK8sSpec(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 {
+		return errors.New("spec is missing")
+	}
+	return json.Unmarshal(data, v)
+}
+
+// selectors returns the k8s workload attestor selectors that match the
+// given container of the workload.
+func (w *k8sWorkload) selectors(container *corev1.Container) []*types.Selector {
+	selectors := []*types.Selector{
+		{Type: "k8s", Value: "ns:" + w.Namespace},
+		{Type: "k8s", Value: "sa:" + w.ServiceAccount},
+	}
+
+	labels := make([]string, 0, len(w.Labels))
+	for label := range w.Labels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		selectors = append(selectors, &types.Selector{
+			Type:  "k8s",
+			Value: fmt.Sprintf("pod-label:%s:%s", label, w.Labels[label]),
+		})
+	}
+
+	selectors = append(selectors, &types.Selector{Type: "k8s", Value: "container-name:" + container.Name})
+	if container.Image != "" {
+		selectors = append(selectors, &types.Selector{Type: "k8s", Value: "container-image:" + container.Image})
+	}
+	return selectors
+}
+
+// parseK8sSPIFFEIDTemplate parses the template used to derive the SPIFFE IDs
+// of the entries created from Kubernetes manifests.
+func parseK8sSPIFFEIDTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = defaultK8sSPIFFEIDTemplate
+	}
+	tmpl, err := template.New("spiffe-id").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SPIFFE ID template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func executeK8sSPIFFEIDTemplate(tmpl *template.Template, data k8sSPIFFEIDTemplateData) (*types.SPIFFEID, error) {
+	buf := new(bytes.Buffer)
+	if err := tmpl.Execute(buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute SPIFFE ID template: %w", err)
+	}
+	return idStringToProto(buf.String())
+}
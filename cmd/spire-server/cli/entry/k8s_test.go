@@ -0,0 +1,151 @@
+package entry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseK8sManifest(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		manifest    string
+		namespace   string
+		expWorkload []k8sWorkload
+		expErr      string
+	}{
+		{
+			name: "pod with defaults",
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+  labels:
+    app: app
+spec:
+  containers:
+    - name: app
+      image: app:1.0
+`,
+			expWorkload: []k8sWorkload{
+				{
+					Kind:           "Pod",
+					Name:           "app",
+					Namespace:      "default",
+					ServiceAccount: "default",
+					Labels:         map[string]string{"app": "app"},
+					Containers:     []corev1.Container{{Name: "app", Image: "docker.io/library/app:1.0"}},
+				},
+			},
+		},
+		{
+			name: "pod in given namespace",
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  containers:
+    - name: app
+      image: ghcr.io/example/app@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef
+    - name: sidecar
+      image: example/sidecar
+`,
+			namespace: "apps",
+			expWorkload: []k8sWorkload{
+				{
+					Kind:           "Pod",
+					Name:           "app",
+					Namespace:      "apps",
+					ServiceAccount: "default",
+					Containers: []corev1.Container{
+						{Name: "app", Image: "ghcr.io/example/app@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"},
+						{Name: "sidecar", Image: "docker.io/example/sidecar:latest"},
+					},
+				},
+			},
+		},
+		{
+			name: "cron job in JSON",
+			manifest: `{
+  "apiVersion": "batch/v1",
+  "kind": "CronJob",
+  "metadata": {"name": "backup", "namespace": "ops"},
+  "spec": {
+    "schedule": "@daily",
+    "jobTemplate": {
+      "spec": {
+        "template": {
+          "spec": {
+            "serviceAccountName": "backup",
+            "containers": [{"name": "backup", "image": "backup:2.0"}]
+          }
+        }
+      }
+    }
+  }
+}`,
+			expWorkload: []k8sWorkload{
+				{
+					Kind:           "CronJob",
+					Name:           "backup",
+					Namespace:      "ops",
+					ServiceAccount: "backup",
+					Containers:     []corev1.Container{{Name: "backup", Image: "docker.io/library/backup:2.0"}},
+				},
+			},
+		},
+		{
+			name: "no workloads",
+			manifest: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`,
+			expErr: "no workloads found in Kubernetes manifest",
+		},
+		{
+			name: "no containers",
+			manifest: `
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+spec:
+  template:
+    spec: {}
+`,
+			expErr: `failed to parse StatefulSet "db": no containers defined`,
+		},
+		{
+			name: "invalid image",
+			manifest: `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: app
+spec:
+  containers:
+    - name: app
+      image: Invalid:Image
+`,
+			expErr: `failed to parse Pod "app": invalid image of container "app": invalid reference format: repository name must be lowercase`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			workloads, err := parseK8sManifest(strings.NewReader(tt.manifest), "-", tt.namespace)
+			if tt.expErr != "" {
+				require.EqualError(t, err, tt.expErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expWorkload, workloads)
+		})
+	}
+}
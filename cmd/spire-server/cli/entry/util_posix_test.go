@@ -17,6 +17,10 @@ const (
     	An expiry, from epoch in seconds, for the resulting registration entry to be pruned
   -federatesWith value
     	SPIFFE ID of a trust domain to federate with. Can be used more than once
+  -from-k8s string
+    	Path to a Kubernetes manifest to derive an entry for every workload container from (optional). If set to '-', read the manifest from stdin. If set, the SPIFFE ID is a template
+  -namespace string
+    	Namespace of the objects of the Kubernetes manifest that do not set one, used with -from-k8s. Defaults to 'default'
   -node
     	If set, this entry will be applied to matching nodes rather than workloads
   -parentID string
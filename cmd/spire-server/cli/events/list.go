@@ -0,0 +1,47 @@
+package events
+
+import (
+	"context"
+	"flag"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+)
+
+// NewListCommand creates a new "list" subcommand for "events" command.
+func NewListCommand() cli.Command {
+	return NewListCommandWithEnv(common_cli.DefaultEnv)
+}
+
+// NewListCommandWithEnv creates a new "list" subcommand for "events" command
+// using the environment specified.
+func NewListCommandWithEnv(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(listCommand))
+}
+
+type listCommand struct{}
+
+func (*listCommand) Name() string {
+	return "events list"
+}
+
+func (*listCommand) Synopsis() string {
+	return "Prints the recent significant log entries of the server"
+}
+
+func (*listCommand) AppendFlags(*flag.FlagSet) {}
+
+// Run prints the recent significant log entries, oldest first
+func (c *listCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	resp, err := serverClient.NewAdminClient().ListEvents(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range resp.Events {
+		if err := env.Println(event.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
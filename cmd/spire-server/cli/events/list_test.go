@@ -0,0 +1,98 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire/cmd/spire-server/cli/common"
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	"github.com/spiffe/spire/pkg/common/log"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListHelp(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	cmd := NewListCommandWithEnv(&common_cli.Env{
+		Stdin:  new(bytes.Buffer),
+		Stdout: new(bytes.Buffer),
+		Stderr: stderr,
+	})
+	assert.Equal(t, "flag: help requested", cmd.Help())
+	assert.Equal(t, "Usage of events list:"+common.AddrUsage, stderr.String())
+}
+
+func TestList(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		serverErr error
+		code      int
+		stdout    string
+		stderr    string
+	}{
+		{
+			name: "success",
+			stdout: `2022-01-01T12:00:00Z [info] X509 CA activated subsystem_name=ca_manager
+2022-01-01T12:01:00Z [warning] Agent attestation failed error="oh no"
+`,
+		},
+		{
+			name:      "event buffer disabled",
+			serverErr: status.Error(codes.FailedPrecondition, "event buffer is disabled; set event_buffer_size to enable it"),
+			code:      1,
+			stderr:    "Error: rpc error: code = FailedPrecondition desc = event buffer is disabled; set event_buffer_size to enable it\n",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			addr := spiretest.StartGRPCServer(t, func(s *grpc.Server) {
+				adminapi.Register(s, adminapi.Service{
+					Name: adminv1.ServiceName,
+					Methods: map[string]adminapi.Handler{
+						"ListEvents": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+							if tt.serverErr != nil {
+								return nil, tt.serverErr
+							}
+							return &adminv1.ListEventsResponse{
+								Events: []log.Event{
+									{
+										Time:    time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC),
+										Level:   "info",
+										Message: "X509 CA activated",
+										Fields:  map[string]string{"subsystem_name": "ca_manager"},
+									},
+									{
+										Time:    time.Date(2022, 1, 1, 12, 1, 0, 0, time.UTC),
+										Level:   "warning",
+										Message: "Agent attestation failed",
+										Fields:  map[string]string{"error": "oh no"},
+									},
+								},
+							}, nil
+						},
+					},
+				})
+			})
+
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			cmd := NewListCommandWithEnv(&common_cli.Env{
+				Stdin:  new(bytes.Buffer),
+				Stdout: stdout,
+				Stderr: stderr,
+			})
+
+			code := cmd.Run([]string{common.AddrArg, common.GetAddr(addr)})
+			assert.Equal(t, tt.code, code)
+			assert.Equal(t, tt.stdout, stdout.String())
+			assert.Equal(t, tt.stderr, stderr.String())
+		})
+	}
+}
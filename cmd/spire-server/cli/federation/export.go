@@ -0,0 +1,133 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/mitchellh/cli"
+	trustdomainv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/trustdomain/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+)
+
+// NewExportCommand creates a new "export" subcommand for "federation" command.
+func NewExportCommand() cli.Command {
+	return newExportCommand(common_cli.DefaultEnv)
+}
+
+func newExportCommand(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(exportCommand))
+}
+
+type exportCommand struct {
+	path string
+}
+
+func (*exportCommand) Name() string {
+	return "federation export"
+}
+
+func (*exportCommand) Synopsis() string {
+	return "Exports all dynamic federation relationships in JSON format"
+}
+
+func (c *exportCommand) AppendFlags(f *flag.FlagSet) {
+	f.StringVar(&c.path, "output", "-", "Path to the file the federation relationships are written to. If set to '-', write the JSON to stdout.")
+}
+
+func (c *exportCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	relationships, err := listAllRelationships(ctx, serverClient.NewTrustDomainClient())
+	if err != nil {
+		return err
+	}
+
+	out := &federationRelationships{
+		FederationRelationships: []*federationRelationshipConfig{},
+	}
+	for _, relationship := range relationships {
+		config, err := protoToJSON(relationship)
+		if err != nil {
+			return err
+		}
+		out.FederationRelationships = append(out.FederationRelationships, config)
+	}
+
+	data, err := json.MarshalIndent(out, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal federation relationships: %w", err)
+	}
+	data = append(data, '\n')
+
+	if c.path == "-" {
+		return env.Printf("%s", data)
+	}
+	if err := os.WriteFile(c.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write federation relationships: %w", err)
+	}
+	msg := fmt.Sprintf("Exported %d ", len(relationships))
+	msg = util.Pluralizer(msg, "federation relationship", "federation relationships", len(relationships))
+	return env.Printf("%s to %s\n", msg, c.path)
+}
+
+// listAllRelationships returns every dynamic federation relationship, following
+// the pagination of the trust domain API.
+func listAllRelationships(ctx context.Context, client trustdomainv1.TrustDomainClient) ([]*types.FederationRelationship, error) {
+	var relationships []*types.FederationRelationship
+	pageToken := ""
+	for {
+		resp, err := client.ListFederationRelationships(ctx, &trustdomainv1.ListFederationRelationshipsRequest{
+			PageToken: pageToken,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error listing federation relationships: %w", err)
+		}
+		relationships = append(relationships, resp.FederationRelationships...)
+		if resp.NextPageToken == "" {
+			return relationships, nil
+		}
+		pageToken = resp.NextPageToken
+	}
+}
+
+// protoToJSON converts a federation relationship into the data file format
+// understood by the create, update and import commands. The trust domain
+// bundle, if any, is exported as PEM encoded X.509 authorities.
+func protoToJSON(fr *types.FederationRelationship) (*federationRelationshipConfig, error) {
+	config := &federationRelationshipConfig{
+		TrustDomain:       fr.TrustDomain,
+		BundleEndpointURL: fr.BundleEndpointUrl,
+	}
+
+	switch profile := fr.BundleEndpointProfile.(type) {
+	case *types.FederationRelationship_HttpsWeb:
+		config.BundleEndpointProfile = profileHTTPSWeb
+	case *types.FederationRelationship_HttpsSpiffe:
+		config.BundleEndpointProfile = profileHTTPSSPIFFE
+		config.EndpointSPIFFEID = profile.HttpsSpiffe.EndpointSpiffeId
+	default:
+		return nil, fmt.Errorf("federation relationship for %q has an unknown bundle endpoint profile", fr.TrustDomain)
+	}
+
+	if fr.TrustDomainBundle != nil && len(fr.TrustDomainBundle.X509Authorities) > 0 {
+		var bundlePEM []byte
+		for _, authority := range fr.TrustDomainBundle.X509Authorities {
+			bundlePEM = append(bundlePEM, pem.EncodeToMemory(&pem.Block{
+				Type:  "CERTIFICATE",
+				Bytes: authority.Asn1,
+			})...)
+		}
+		raw, err := json.Marshal(string(bundlePEM))
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal bundle for %q: %w", fr.TrustDomain, err)
+		}
+		config.TrustDomainBundle = raw
+		config.TrustDomainBundleFormat = util.FormatPEM
+	}
+
+	return config, nil
+}
@@ -0,0 +1,123 @@
+package federation
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	trustdomainv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/trustdomain/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/cmd/spire-server/cli/common"
+	"github.com/spiffe/spire/pkg/common/pemutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExportHelp(t *testing.T) {
+	test := setupTest(t, newExportCommand)
+	test.client.Help()
+
+	require.Equal(t, `Usage of federation export:
+  -output string
+    	Path to the file the federation relationships are written to. If set to '-', write the JSON to stdout. (default "-")`+common.AddrUsage, test.stderr.String())
+}
+
+func TestExportSynopsis(t *testing.T) {
+	test := setupTest(t, newExportCommand)
+	require.Equal(t, "Exports all dynamic federation relationships in JSON format", test.client.Synopsis())
+}
+
+func TestExport(t *testing.T) {
+	cert, err := pemutil.ParseCertificate([]byte(pemCert))
+	require.NoError(t, err)
+
+	listResp := &trustdomainv1.ListFederationRelationshipsResponse{
+		FederationRelationships: []*types.FederationRelationship{
+			{
+				TrustDomain:           "td-1.org",
+				BundleEndpointUrl:     "https://td-1.org/bundle",
+				BundleEndpointProfile: &types.FederationRelationship_HttpsWeb{},
+			},
+			{
+				TrustDomain:       "td-3.org",
+				BundleEndpointUrl: "https://td-3.org/bundle",
+				BundleEndpointProfile: &types.FederationRelationship_HttpsSpiffe{
+					HttpsSpiffe: &types.HTTPSSPIFFEProfile{
+						EndpointSpiffeId: "spiffe://td-3.org/bundle",
+					},
+				},
+				TrustDomainBundle: &types.Bundle{
+					TrustDomain:     "td-3.org",
+					X509Authorities: []*types.X509Certificate{{Asn1: cert.Raw}},
+				},
+			},
+		},
+	}
+
+	expectJSON := `{
+    "federationRelationships": [
+        {
+            "trustDomain": "td-1.org",
+            "bundleEndpointURL": "https://td-1.org/bundle",
+            "bundleEndpointProfile": "https_web"
+        },
+        {
+            "trustDomain": "td-3.org",
+            "bundleEndpointURL": "https://td-3.org/bundle",
+            "bundleEndpointProfile": "https_spiffe",
+            "endpointSPIFFEID": "spiffe://td-3.org/bundle",
+            "trustDomainBundle": "` + jsonEscapedPEM(pemCert) + `",
+            "trustDomainBundleFormat": "pem"
+        }
+    ]
+}
+`
+
+	t.Run("to stdout", func(t *testing.T) {
+		test := setupTest(t, newExportCommand)
+		test.server.expectListReq = &trustdomainv1.ListFederationRelationshipsRequest{}
+		test.server.listResp = listResp
+
+		rc := test.client.Run(test.args())
+		require.Equal(t, 0, rc)
+		require.Equal(t, expectJSON, test.stdout.String())
+		require.Empty(t, test.stderr.String())
+	})
+
+	t.Run("to file", func(t *testing.T) {
+		test := setupTest(t, newExportCommand)
+		test.server.expectListReq = &trustdomainv1.ListFederationRelationshipsRequest{}
+		test.server.listResp = listResp
+		path := filepath.Join(t.TempDir(), "federation.json")
+
+		rc := test.client.Run(test.args("-output", path))
+		require.Equal(t, 0, rc)
+		require.Equal(t, "Exported 2 federation relationships to "+path+"\n", test.stdout.String())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, expectJSON, string(data))
+
+		// The exported file can be read back by the create, update and import commands
+		relationships, err := federationRelationshipsFromFile(path)
+		require.NoError(t, err)
+		require.Len(t, relationships, 2)
+		require.Len(t, relationships[1].TrustDomainBundle.X509Authorities, 1)
+		require.Equal(t, cert.Raw, relationships[1].TrustDomainBundle.X509Authorities[0].Asn1)
+	})
+
+	t.Run("server fails", func(t *testing.T) {
+		test := setupTest(t, newExportCommand)
+		test.server.err = status.Error(codes.Internal, "oh! no")
+
+		rc := test.client.Run(test.args())
+		require.Equal(t, 1, rc)
+		require.Equal(t, "Error: error listing federation relationships: rpc error: code = Internal desc = oh! no\n", test.stderr.String())
+	})
+}
+
+func jsonEscapedPEM(s string) string {
+	return strings.ReplaceAll(s, "\n", `\n`) + `\n`
+}
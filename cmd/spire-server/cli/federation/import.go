@@ -0,0 +1,225 @@
+package federation
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/mitchellh/cli"
+	trustdomainv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/trustdomain/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	"google.golang.org/grpc/codes"
+)
+
+// NewImportCommand creates a new "import" subcommand for "federation" command.
+func NewImportCommand() cli.Command {
+	return newImportCommand(common_cli.DefaultEnv)
+}
+
+func newImportCommand(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(importCommand))
+}
+
+type importCommand struct {
+	path   string
+	dryRun bool
+}
+
+// importPlan holds the changes needed to bring the dynamic federation
+// relationships in line with the desired state.
+type importPlan struct {
+	create []*types.FederationRelationship
+	update []*types.FederationRelationship
+	delete []string
+}
+
+func (p *importPlan) isEmpty() bool {
+	return len(p.create) == 0 && len(p.update) == 0 && len(p.delete) == 0
+}
+
+func (*importCommand) Name() string {
+	return "federation import"
+}
+
+func (*importCommand) Synopsis() string {
+	return "Applies a desired set of dynamic federation relationships from a JSON file"
+}
+
+func (c *importCommand) AppendFlags(f *flag.FlagSet) {
+	f.StringVar(&c.path, "data", "", "Path to a file containing the desired federation relationships in JSON format. If set to '-', read the JSON from stdin.")
+	f.BoolVar(&c.dryRun, "dryRun", false, "Print the changes that would be applied without applying them")
+}
+
+func (c *importCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	if c.path == "" {
+		return errors.New("data is required")
+	}
+
+	desired, err := federationRelationshipsFromFile(c.path)
+	if err != nil {
+		return err
+	}
+
+	client := serverClient.NewTrustDomainClient()
+	current, err := listAllRelationships(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	plan, err := planImport(current, desired, env.Printf)
+	if err != nil {
+		return err
+	}
+
+	if plan.isEmpty() {
+		return env.Println("Federation relationships are up to date")
+	}
+	if err := env.Printf("Plan: %d to create, %d to update, %d to delete\n", len(plan.create), len(plan.update), len(plan.delete)); err != nil {
+		return err
+	}
+	if c.dryRun {
+		return env.Println("Dry run: no changes were applied")
+	}
+
+	return applyImportPlan(ctx, env, client, plan)
+}
+
+// planImport compares the current relationships with the desired ones and
+// prints the difference. Trust domain bundles are only used to bootstrap new
+// relationships, so they are not compared; once a relationship exists its
+// bundle is maintained by refreshing it from the bundle endpoint.
+func planImport(current, desired []*types.FederationRelationship, printf func(format string, args ...interface{}) error) (*importPlan, error) {
+	currentByTD := make(map[string]*types.FederationRelationship, len(current))
+	for _, fr := range current {
+		currentByTD[fr.TrustDomain] = fr
+	}
+
+	plan := new(importPlan)
+	seen := make(map[string]bool, len(desired))
+	for _, fr := range desired {
+		if seen[fr.TrustDomain] {
+			return nil, fmt.Errorf("federation relationship for %q is defined more than once", fr.TrustDomain)
+		}
+		seen[fr.TrustDomain] = true
+
+		existing, ok := currentByTD[fr.TrustDomain]
+		if !ok {
+			plan.create = append(plan.create, fr)
+			_ = printf("+ %s\n", fr.TrustDomain)
+			continue
+		}
+
+		changes := diffRelationships(existing, fr)
+		if len(changes) == 0 {
+			continue
+		}
+		plan.update = append(plan.update, fr)
+		_ = printf("~ %s\n", fr.TrustDomain)
+		for _, change := range changes {
+			_ = printf("    %s\n", change)
+		}
+	}
+
+	for _, fr := range current {
+		if !seen[fr.TrustDomain] {
+			plan.delete = append(plan.delete, fr.TrustDomain)
+			_ = printf("- %s\n", fr.TrustDomain)
+		}
+	}
+
+	return plan, nil
+}
+
+func diffRelationships(current, desired *types.FederationRelationship) []string {
+	var changes []string
+	if current.BundleEndpointUrl != desired.BundleEndpointUrl {
+		changes = append(changes, fmt.Sprintf("bundleEndpointURL: %q => %q", current.BundleEndpointUrl, desired.BundleEndpointUrl))
+	}
+
+	currentProfile, currentEndpointID := bundleEndpointProfile(current)
+	desiredProfile, desiredEndpointID := bundleEndpointProfile(desired)
+	if currentProfile != desiredProfile {
+		changes = append(changes, fmt.Sprintf("bundleEndpointProfile: %q => %q", currentProfile, desiredProfile))
+	}
+	if currentEndpointID != desiredEndpointID {
+		changes = append(changes, fmt.Sprintf("endpointSPIFFEID: %q => %q", currentEndpointID, desiredEndpointID))
+	}
+	return changes
+}
+
+func bundleEndpointProfile(fr *types.FederationRelationship) (profile string, endpointSPIFFEID string) {
+	switch p := fr.BundleEndpointProfile.(type) {
+	case *types.FederationRelationship_HttpsWeb:
+		return profileHTTPSWeb, ""
+	case *types.FederationRelationship_HttpsSpiffe:
+		return profileHTTPSSPIFFE, p.HttpsSpiffe.EndpointSpiffeId
+	default:
+		return "", ""
+	}
+}
+
+func applyImportPlan(ctx context.Context, env *common_cli.Env, client trustdomainv1.TrustDomainClient, plan *importPlan) error {
+	failed := false
+
+	if len(plan.create) > 0 {
+		resp, err := client.BatchCreateFederationRelationship(ctx, &trustdomainv1.BatchCreateFederationRelationshipRequest{
+			FederationRelationships: plan.create,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create federation relationships: %w", err)
+		}
+		for i, r := range resp.Results {
+			if r.Status.Code != int32(codes.OK) {
+				failed = true
+				env.ErrPrintf("Failed to create federation relationship for %q (code: %s, msg: %q)\n",
+					plan.create[i].TrustDomain, codes.Code(r.Status.Code), r.Status.Message)
+			}
+		}
+	}
+
+	if len(plan.update) > 0 {
+		resp, err := client.BatchUpdateFederationRelationship(ctx, &trustdomainv1.BatchUpdateFederationRelationshipRequest{
+			FederationRelationships: plan.update,
+			// Leave the trust domain bundle untouched; it is kept up to date
+			// by the bundle endpoint.
+			InputMask: &types.FederationRelationshipMask{
+				BundleEndpointUrl:     true,
+				BundleEndpointProfile: true,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update federation relationships: %w", err)
+		}
+		for i, r := range resp.Results {
+			if r.Status.Code != int32(codes.OK) {
+				failed = true
+				env.ErrPrintf("Failed to update federation relationship for %q (code: %s, msg: %q)\n",
+					plan.update[i].TrustDomain, codes.Code(r.Status.Code), r.Status.Message)
+			}
+		}
+	}
+
+	if len(plan.delete) > 0 {
+		resp, err := client.BatchDeleteFederationRelationship(ctx, &trustdomainv1.BatchDeleteFederationRelationshipRequest{
+			TrustDomains: plan.delete,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete federation relationships: %w", err)
+		}
+		for _, r := range resp.Results {
+			if r.Status.Code != int32(codes.OK) {
+				failed = true
+				env.ErrPrintf("Failed to delete federation relationship for %q (code: %s, msg: %q)\n",
+					r.TrustDomain, codes.Code(r.Status.Code), r.Status.Message)
+			}
+		}
+	}
+
+	if failed {
+		return errors.New("failed to apply one or more federation relationship changes")
+	}
+	return env.Println("Federation relationships imported")
+}
@@ -0,0 +1,242 @@
+package federation
+
+import (
+	"testing"
+
+	trustdomainv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/trustdomain/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/cmd/spire-server/cli/common"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestImportHelp(t *testing.T) {
+	test := setupTest(t, newImportCommand)
+	test.client.Help()
+
+	require.Equal(t, `Usage of federation import:
+  -data string
+    	Path to a file containing the desired federation relationships in JSON format. If set to '-', read the JSON from stdin.
+  -dryRun
+    	Print the changes that would be applied without applying them`+common.AddrUsage, test.stderr.String())
+}
+
+func TestImportSynopsis(t *testing.T) {
+	test := setupTest(t, newImportCommand)
+	require.Equal(t, "Applies a desired set of dynamic federation relationships from a JSON file", test.client.Synopsis())
+}
+
+func TestImport(t *testing.T) {
+	desiredFile := createJSONDataFile(t, `
+{
+    "federationRelationships": [
+        {
+            "trustDomain": "td-1.org",
+            "bundleEndpointURL": "https://td-1.org/bundle",
+            "bundleEndpointProfile": "https_web"
+        },
+        {
+            "trustDomain": "td-2.org",
+            "bundleEndpointURL": "https://td-2.org/new-bundle",
+            "bundleEndpointProfile": "https_web"
+        }
+    ]
+}
+`)
+	duplicatedFile := createJSONDataFile(t, `
+{
+    "federationRelationships": [
+        {
+            "trustDomain": "td-1.org",
+            "bundleEndpointURL": "https://td-1.org/bundle",
+            "bundleEndpointProfile": "https_web"
+        },
+        {
+            "trustDomain": "td-1.org",
+            "bundleEndpointURL": "https://td-1.org/other-bundle",
+            "bundleEndpointProfile": "https_web"
+        }
+    ]
+}
+`)
+
+	td1 := &types.FederationRelationship{
+		TrustDomain:       "td-1.org",
+		BundleEndpointUrl: "https://td-1.org/bundle",
+		BundleEndpointProfile: &types.FederationRelationship_HttpsWeb{
+			HttpsWeb: &types.HTTPSWebProfile{},
+		},
+	}
+	td2 := &types.FederationRelationship{
+		TrustDomain:       "td-2.org",
+		BundleEndpointUrl: "https://td-2.org/bundle",
+		BundleEndpointProfile: &types.FederationRelationship_HttpsSpiffe{
+			HttpsSpiffe: &types.HTTPSSPIFFEProfile{
+				EndpointSpiffeId: "spiffe://td-2.org/bundle",
+			},
+		},
+		TrustDomainBundle: &types.Bundle{TrustDomain: "td-2.org"},
+	}
+	td2Updated := &types.FederationRelationship{
+		TrustDomain:       "td-2.org",
+		BundleEndpointUrl: "https://td-2.org/new-bundle",
+		BundleEndpointProfile: &types.FederationRelationship_HttpsWeb{
+			HttpsWeb: &types.HTTPSWebProfile{},
+		},
+	}
+	td3 := &types.FederationRelationship{
+		TrustDomain:       "td-3.org",
+		BundleEndpointUrl: "https://td-3.org/bundle",
+		BundleEndpointProfile: &types.FederationRelationship_HttpsWeb{
+			HttpsWeb: &types.HTTPSWebProfile{},
+		},
+	}
+
+	const expectPlan = `+ td-1.org
+~ td-2.org
+    bundleEndpointURL: "https://td-2.org/bundle" => "https://td-2.org/new-bundle"
+    bundleEndpointProfile: "https_spiffe" => "https_web"
+    endpointSPIFFEID: "spiffe://td-2.org/bundle" => ""
+- td-3.org
+Plan: 1 to create, 1 to update, 1 to delete
+`
+
+	expectCreateReq := &trustdomainv1.BatchCreateFederationRelationshipRequest{
+		FederationRelationships: []*types.FederationRelationship{td1},
+	}
+	expectUpdateReq := &trustdomainv1.BatchUpdateFederationRelationshipRequest{
+		FederationRelationships: []*types.FederationRelationship{td2Updated},
+		InputMask: &types.FederationRelationshipMask{
+			BundleEndpointUrl:     true,
+			BundleEndpointProfile: true,
+		},
+	}
+	expectDeleteReq := &trustdomainv1.BatchDeleteFederationRelationshipRequest{
+		TrustDomains: []string{"td-3.org"},
+	}
+	okStatus := &types.Status{Code: int32(codes.OK)}
+
+	for _, tt := range []struct {
+		name string
+		args []string
+
+		current         []*types.FederationRelationship
+		serverErr       error
+		expectCreateReq *trustdomainv1.BatchCreateFederationRelationshipRequest
+		createResp      *trustdomainv1.BatchCreateFederationRelationshipResponse
+		expectUpdateReq *trustdomainv1.BatchUpdateFederationRelationshipRequest
+		updateResp      *trustdomainv1.BatchUpdateFederationRelationshipResponse
+		expectDeleteReq *trustdomainv1.BatchDeleteFederationRelationshipRequest
+		deleteResp      *trustdomainv1.BatchDeleteFederationRelationshipResponse
+
+		expectOut string
+		expectErr string
+	}{
+		{
+			name:      "missing data",
+			expectErr: "Error: data is required\n",
+		},
+		{
+			name:      "up to date",
+			args:      []string{"-data", desiredFile},
+			current:   []*types.FederationRelationship{td1, td2Updated},
+			expectOut: "Federation relationships are up to date\n",
+		},
+		{
+			name:      "dry run",
+			args:      []string{"-data", desiredFile, "-dryRun"},
+			current:   []*types.FederationRelationship{td2, td3},
+			expectOut: expectPlan + "Dry run: no changes were applied\n",
+		},
+		{
+			name:            "apply",
+			args:            []string{"-data", desiredFile},
+			current:         []*types.FederationRelationship{td2, td3},
+			expectCreateReq: expectCreateReq,
+			createResp: &trustdomainv1.BatchCreateFederationRelationshipResponse{
+				Results: []*trustdomainv1.BatchCreateFederationRelationshipResponse_Result{
+					{Status: okStatus, FederationRelationship: td1},
+				},
+			},
+			expectUpdateReq: expectUpdateReq,
+			updateResp: &trustdomainv1.BatchUpdateFederationRelationshipResponse{
+				Results: []*trustdomainv1.BatchUpdateFederationRelationshipResponse_Result{
+					{Status: okStatus, FederationRelationship: td2Updated},
+				},
+			},
+			expectDeleteReq: expectDeleteReq,
+			deleteResp: &trustdomainv1.BatchDeleteFederationRelationshipResponse{
+				Results: []*trustdomainv1.BatchDeleteFederationRelationshipResponse_Result{
+					{Status: okStatus, TrustDomain: "td-3.org"},
+				},
+			},
+			expectOut: expectPlan + "Federation relationships imported\n",
+		},
+		{
+			name:            "apply fails",
+			args:            []string{"-data", desiredFile},
+			current:         []*types.FederationRelationship{td2, td3},
+			expectCreateReq: expectCreateReq,
+			createResp: &trustdomainv1.BatchCreateFederationRelationshipResponse{
+				Results: []*trustdomainv1.BatchCreateFederationRelationshipResponse_Result{
+					{Status: &types.Status{Code: int32(codes.Internal), Message: "oh! no"}},
+				},
+			},
+			expectUpdateReq: expectUpdateReq,
+			updateResp: &trustdomainv1.BatchUpdateFederationRelationshipResponse{
+				Results: []*trustdomainv1.BatchUpdateFederationRelationshipResponse_Result{
+					{Status: okStatus, FederationRelationship: td2Updated},
+				},
+			},
+			expectDeleteReq: expectDeleteReq,
+			deleteResp: &trustdomainv1.BatchDeleteFederationRelationshipResponse{
+				Results: []*trustdomainv1.BatchDeleteFederationRelationshipResponse_Result{
+					{Status: &types.Status{Code: int32(codes.NotFound), Message: "not found"}, TrustDomain: "td-3.org"},
+				},
+			},
+			expectErr: `Failed to create federation relationship for "td-1.org" (code: Internal, msg: "oh! no")
+Failed to delete federation relationship for "td-3.org" (code: NotFound, msg: "not found")
+Error: failed to apply one or more federation relationship changes
+`,
+		},
+		{
+			name:      "duplicated trust domain",
+			args:      []string{"-data", duplicatedFile},
+			expectErr: "Error: federation relationship for \"td-1.org\" is defined more than once\n",
+		},
+		{
+			name:      "server fails",
+			args:      []string{"-data", desiredFile},
+			serverErr: status.Error(codes.Internal, "oh! no"),
+			expectErr: "Error: error listing federation relationships: rpc error: code = Internal desc = oh! no\n",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			test := setupTest(t, newImportCommand)
+			test.server.err = tt.serverErr
+			test.server.expectListReq = &trustdomainv1.ListFederationRelationshipsRequest{}
+			test.server.listResp = &trustdomainv1.ListFederationRelationshipsResponse{
+				FederationRelationships: tt.current,
+			}
+			test.server.expectCreateReq = tt.expectCreateReq
+			test.server.createResp = tt.createResp
+			test.server.expectUpdateReq = tt.expectUpdateReq
+			test.server.updateResp = tt.updateResp
+			test.server.expectDeleteReq = tt.expectDeleteReq
+			test.server.deleteResp = tt.deleteResp
+
+			rc := test.client.Run(test.args(tt.args...))
+			if tt.expectErr != "" {
+				require.Equal(t, 1, rc)
+				require.Equal(t, tt.expectErr, test.stderr.String())
+				return
+			}
+
+			require.Equal(t, 0, rc)
+			require.Equal(t, tt.expectOut, test.stdout.String())
+			require.Empty(t, test.stderr.String())
+		})
+	}
+}
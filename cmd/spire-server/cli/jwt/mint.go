@@ -2,6 +2,8 @@ package jwt
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -10,10 +12,12 @@ import (
 
 	"github.com/mitchellh/cli"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
 	svidv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/svid/v1"
 	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"github.com/spiffe/spire/cmd/spire-server/util"
 	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	"gopkg.in/square/go-jose.v2"
 	"gopkg.in/square/go-jose.v2/jwt"
 )
 
@@ -26,10 +30,12 @@ func newMintCommand(env *common_cli.Env) cli.Command {
 }
 
 type mintCommand struct {
-	spiffeID string
-	ttl      time.Duration
-	audience common_cli.StringsFlag
-	write    string
+	spiffeID         string
+	ttl              time.Duration
+	audience         common_cli.StringsFlag
+	write            string
+	writeBundle      string
+	includeFederated bool
 }
 
 func (c *mintCommand) Name() string {
@@ -44,6 +50,8 @@ func (c *mintCommand) AppendFlags(fs *flag.FlagSet) {
 	fs.DurationVar(&c.ttl, "ttl", 0, "TTL of the JWT-SVID")
 	fs.Var(&c.audience, "audience", "Audience claim that will be included in the SVID. Can be used more than once.")
 	fs.StringVar(&c.write, "write", "", "File to write token to instead of stdout")
+	fs.StringVar(&c.writeBundle, "writeBundle", "", "File to write the JWT authorities needed to validate the token to, as a JWKS")
+	fs.BoolVar(&c.includeFederated, "includeFederated", false, "Include the JWT authorities of federated bundles in the JWKS written with -writeBundle")
 }
 
 func (c *mintCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
@@ -53,6 +61,9 @@ func (c *mintCommand) Run(ctx context.Context, env *common_cli.Env, serverClient
 	if len(c.audience) == 0 {
 		return errors.New("at least one audience must be specified")
 	}
+	if c.includeFederated && c.writeBundle == "" {
+		return errors.New("includeFederated requires writeBundle to be specified")
+	}
 	spiffeID, err := spiffeid.FromString(c.spiffeID)
 	if err != nil {
 		return err
@@ -74,6 +85,17 @@ func (c *mintCommand) Run(ctx context.Context, env *common_cli.Env, serverClient
 		return err
 	}
 
+	if err := c.printToken(env, token); err != nil {
+		return err
+	}
+
+	if c.writeBundle == "" {
+		return nil
+	}
+	return c.writeJWKS(ctx, env, serverClient.NewBundleClient())
+}
+
+func (c *mintCommand) printToken(env *common_cli.Env, token string) error {
 	// Print in stdout
 	if c.write == "" {
 		return env.Println(token)
@@ -87,6 +109,51 @@ func (c *mintCommand) Run(ctx context.Context, env *common_cli.Env, serverClient
 	return env.Printf("JWT-SVID written to %s\n", tokenPath)
 }
 
+// writeJWKS writes the JWT authorities of the server bundle, and optionally
+// of the federated bundles, as a JWKS that non-SPIRE clients can use to
+// validate the token.
+func (c *mintCommand) writeJWKS(ctx context.Context, env *common_cli.Env, bundleClient bundlev1.BundleClient) error {
+	bundle, err := bundleClient.GetBundle(ctx, &bundlev1.GetBundleRequest{})
+	if err != nil {
+		return fmt.Errorf("unable to get bundle: %w", err)
+	}
+	authorities := bundle.JwtAuthorities
+
+	if c.includeFederated {
+		resp, err := bundleClient.ListFederatedBundles(ctx, &bundlev1.ListFederatedBundlesRequest{})
+		if err != nil {
+			return fmt.Errorf("unable to list federated bundles: %w", err)
+		}
+		for _, federated := range resp.Bundles {
+			authorities = append(authorities, federated.JwtAuthorities...)
+		}
+	}
+
+	jwks := new(jose.JSONWebKeySet)
+	for _, authority := range authorities {
+		publicKey, err := x509.ParsePKIXPublicKey(authority.PublicKey)
+		if err != nil {
+			return fmt.Errorf("unable to parse JWT authority %q: %w", authority.KeyId, err)
+		}
+		jwks.Keys = append(jwks.Keys, jose.JSONWebKey{
+			Key:   publicKey,
+			KeyID: authority.KeyId,
+			Use:   "jwt-svid",
+		})
+	}
+
+	jwksBytes, err := json.MarshalIndent(jwks, "", "    ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal JWKS: %w", err)
+	}
+
+	bundlePath := env.JoinPath(c.writeBundle)
+	if err := os.WriteFile(bundlePath, jwksBytes, 0644); err != nil { // nolint: gosec // expected permission
+		return fmt.Errorf("unable to write bundle: %w", err)
+	}
+	return env.Printf("JWT authorities written to %s\n", bundlePath)
+}
+
 func (c *mintCommand) validateToken(token string, env *common_cli.Env) error {
 	if token == "" {
 		return errors.New("server response missing token")
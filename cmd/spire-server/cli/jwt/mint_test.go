@@ -3,6 +3,8 @@ package jwt
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -12,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
 	svidv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/svid/v1"
 	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"github.com/spiffe/spire/cmd/spire-server/cli/common"
@@ -37,13 +40,17 @@ qNV3lKIL59N7G2B4ojbhfSNneSIIpP448uPxUnaunaQZ+/m7+x9oobIp
 var (
 	expectedUsage = `Usage of jwt mint:
   -audience value
-    	Audience claim that will be included in the SVID. Can be used more than once.` + common.AddrUsage +
+    	Audience claim that will be included in the SVID. Can be used more than once.
+  -includeFederated
+    	Include the JWT authorities of federated bundles in the JWKS written with -writeBundle` + common.AddrUsage +
 		`  -spiffeID string
     	SPIFFE ID of the JWT-SVID
   -ttl duration
     	TTL of the JWT-SVID
   -write string
     	File to write token to instead of stdout
+  -writeBundle string
+    	File to write the JWT authorities needed to validate the token to, as a JWKS
 `
 )
 
@@ -318,12 +325,113 @@ func TestMintRun(t *testing.T) {
 	}
 }
 
+func TestMintRunWriteBundle(t *testing.T) {
+	signer, err := jose.NewSigner(jose.SigningKey{
+		Algorithm: jose.ES256,
+		Key:       testKey,
+	}, nil)
+	require.NoError(t, err)
+	token, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Expiry: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).CompactSerialize()
+	require.NoError(t, err)
+
+	publicKey, err := x509.MarshalPKIXPublicKey(testKey.Public())
+	require.NoError(t, err)
+
+	server := &fakeSVIDServer{
+		resp: &svidv1.MintJWTSVIDResponse{
+			Svid: &types.JWTSVID{Token: token},
+		},
+		bundle: &types.Bundle{
+			TrustDomain:    "domain.test",
+			JwtAuthorities: []*types.JWTKey{{KeyId: "local", PublicKey: publicKey}},
+		},
+		federatedBundles: []*types.Bundle{
+			{
+				TrustDomain:    "federated.test",
+				JwtAuthorities: []*types.JWTKey{{KeyId: "federated", PublicKey: publicKey}},
+			},
+		},
+	}
+	addr := spiretest.StartGRPCServer(t, func(s *grpc.Server) {
+		svidv1.RegisterSVIDServer(s, server)
+		bundlev1.RegisterBundleServer(s, server)
+	})
+
+	for _, tt := range []struct {
+		name         string
+		args         []string
+		expectCode   int
+		expectStderr string
+		expectKeyIDs []string
+	}{
+		{
+			name:         "includeFederated without writeBundle",
+			args:         []string{"-includeFederated"},
+			expectCode:   1,
+			expectStderr: "Error: includeFederated requires writeBundle to be specified\n",
+		},
+		{
+			name:         "server bundle only",
+			args:         []string{"-writeBundle", "jwks.json"},
+			expectKeyIDs: []string{"local"},
+		},
+		{
+			name:         "server and federated bundles",
+			args:         []string{"-writeBundle", "jwks.json", "-includeFederated"},
+			expectKeyIDs: []string{"local", "federated"},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := spiretest.TempDir(t)
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			cmd := newMintCommand(&common_cli.Env{
+				Stdin:   new(bytes.Buffer),
+				Stdout:  stdout,
+				Stderr:  stderr,
+				BaseDir: dir,
+			})
+
+			args := append([]string{common.AddrArg, common.GetAddr(addr), "-spiffeID", "spiffe://domain.test/workload", "-audience", "AUDIENCE"}, tt.args...)
+			code := cmd.Run(args)
+			assert.Equal(t, tt.expectCode, code)
+			assert.Equal(t, tt.expectStderr, stderr.String())
+			if tt.expectCode != 0 {
+				return
+			}
+
+			bundlePath := filepath.Join(dir, "jwks.json")
+			assert.Equal(t, fmt.Sprintf("%s\nJWT authorities written to %s\n", token, bundlePath), stdout.String())
+
+			jwksBytes, err := os.ReadFile(bundlePath)
+			require.NoError(t, err)
+			jwks := new(jose.JSONWebKeySet)
+			require.NoError(t, json.Unmarshal(jwksBytes, jwks))
+
+			var keyIDs []string
+			for _, key := range jwks.Keys {
+				keyIDs = append(keyIDs, key.KeyID)
+				assert.Equal(t, "jwt-svid", key.Use)
+				assert.Equal(t, testKey.Public(), key.Key)
+			}
+			assert.Equal(t, tt.expectKeyIDs, keyIDs)
+		})
+	}
+}
+
 type fakeSVIDServer struct {
 	svidv1.SVIDServer
+	bundlev1.BundleServer
 
 	mu   sync.Mutex
 	req  *svidv1.MintJWTSVIDRequest
 	resp *svidv1.MintJWTSVIDResponse
+
+	bundle           *types.Bundle
+	federatedBundles []*types.Bundle
 }
 
 func (f *fakeSVIDServer) resetMintJWTSVIDRequest() {
@@ -355,6 +463,16 @@ func (f *fakeSVIDServer) MintJWTSVID(ctx context.Context, req *svidv1.MintJWTSVI
 	return f.resp, nil
 }
 
+func (f *fakeSVIDServer) GetBundle(context.Context, *bundlev1.GetBundleRequest) (*types.Bundle, error) {
+	return f.bundle, nil
+}
+
+func (f *fakeSVIDServer) ListFederatedBundles(context.Context, *bundlev1.ListFederatedBundlesRequest) (*bundlev1.ListFederatedBundlesResponse, error) {
+	return &bundlev1.ListFederatedBundlesResponse{
+		Bundles: f.federatedBundles,
+	}, nil
+}
+
 func assertFileData(t *testing.T, path string, expectedData string) {
 	b, err := os.ReadFile(path)
 	if assert.NoError(t, err) {
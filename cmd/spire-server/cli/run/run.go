@@ -3,12 +3,14 @@ package run
 import (
 	"bytes"
 	"context"
+	"crypto/x509"
 	"crypto/x509/pkix"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"net"
+	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -25,17 +27,26 @@ import (
 	"github.com/mitchellh/cli"
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	util_cmd "github.com/spiffe/spire/cmd/spire-server/util"
+	"github.com/spiffe/spire/pkg/common/agentpathtemplate"
 	"github.com/spiffe/spire/pkg/common/catalog"
 	common_cli "github.com/spiffe/spire/pkg/common/cli"
 	"github.com/spiffe/spire/pkg/common/fflag"
 	"github.com/spiffe/spire/pkg/common/health"
 	"github.com/spiffe/spire/pkg/common/log"
+	"github.com/spiffe/spire/pkg/common/pemutil"
 	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/pkg/common/util"
+	"github.com/spiffe/spire/pkg/common/x509util"
 	"github.com/spiffe/spire/pkg/server"
+	"github.com/spiffe/spire/pkg/server/api"
+	svidv1 "github.com/spiffe/spire/pkg/server/api/svid/v1"
 	"github.com/spiffe/spire/pkg/server/authpolicy"
 	bundleClient "github.com/spiffe/spire/pkg/server/bundle/client"
 	"github.com/spiffe/spire/pkg/server/ca"
 	"github.com/spiffe/spire/pkg/server/endpoints/bundle"
+	"github.com/spiffe/spire/pkg/server/endpoints/ocsp"
+	"github.com/spiffe/spire/pkg/server/entryadmission"
 	"github.com/spiffe/spire/pkg/server/plugin/keymanager"
 )
 
@@ -44,6 +55,10 @@ const (
 
 	defaultConfigPath = "conf/server/server.conf"
 	defaultLogLevel   = "INFO"
+
+	// defaultMinRSAKeySize is the minimum size of RSA keys when a CSR key
+	// policy is configured without one.
+	defaultMinRSAKeySize = 2048
 )
 
 var (
@@ -65,28 +80,43 @@ type Config struct {
 }
 
 type serverConfig struct {
-	AdminIDs        []string           `hcl:"admin_ids"`
-	AgentTTL        string             `hcl:"agent_ttl"`
-	AuditLogEnabled bool               `hcl:"audit_log_enabled"`
-	BindAddress     string             `hcl:"bind_address"`
-	BindPort        int                `hcl:"bind_port"`
-	CAKeyType       string             `hcl:"ca_key_type"`
-	CASubject       *caSubjectConfig   `hcl:"ca_subject"`
-	CATTL           string             `hcl:"ca_ttl"`
-	DataDir         string             `hcl:"data_dir"`
-	DefaultSVIDTTL  string             `hcl:"default_svid_ttl"`
-	Experimental    experimentalConfig `hcl:"experimental"`
-	Federation      *federationConfig  `hcl:"federation"`
-	JWTIssuer       string             `hcl:"jwt_issuer"`
-	JWTKeyType      string             `hcl:"jwt_key_type"`
-	LogFile         string             `hcl:"log_file"`
-	LogLevel        string             `hcl:"log_level"`
-	LogFormat       string             `hcl:"log_format"`
+	AdminIDs                       []string                     `hcl:"admin_ids"`
+	AgentPathTemplates             map[string]string            `hcl:"agent_path_templates"`
+	AgentSelectorsWarningThreshold int                          `hcl:"agent_selectors_warning_threshold"`
+	AgentTTL                       string                       `hcl:"agent_ttl"`
+	ApprovalRequiredAttestorTypes  []string                     `hcl:"approval_required_attestor_types"`
+	AttestationEventRetention      string                       `hcl:"attestation_event_retention"`
+	AuditLogEnabled                bool                         `hcl:"audit_log_enabled"`
+	BindAddress                    string                       `hcl:"bind_address"`
+	BindPort                       int                          `hcl:"bind_port"`
+	CABackup                       *caBackupConfig              `hcl:"ca_backup"`
+	CAKeyType                      string                       `hcl:"ca_key_type"`
+	CASerialNumberPolicy           string                       `hcl:"ca_serial_number_policy"`
+	CAStagedRollout                *caStagedRollout             `hcl:"ca_staged_rollout"`
+	CASubject                      *caSubjectConfig             `hcl:"ca_subject"`
+	CATTL                          string                       `hcl:"ca_ttl"`
+	CSRKeyPolicy                   *csrKeyPolicyConfig          `hcl:"csr_key_policy"`
+	DataDir                        string                       `hcl:"data_dir"`
+	DefaultSVIDTTL                 string                       `hcl:"default_svid_ttl"`
+	DrainDelay                     string                       `hcl:"drain_delay"`
+	DrainTimeout                   string                       `hcl:"drain_timeout"`
+	EntryAdmissionWebhook          *entryAdmissionWebhookConfig `hcl:"entry_admission_webhook"`
+	Experimental                   experimentalConfig           `hcl:"experimental"`
+	Federation                     *federationConfig            `hcl:"federation"`
+	IssuanceRecordRetention        string                       `hcl:"issuance_record_retention"`
+	JWTIssuer                      string                       `hcl:"jwt_issuer"`
+	JWTKeyType                     string                       `hcl:"jwt_key_type"`
+	LogFile                        string                       `hcl:"log_file"`
+	LogLevel                       string                       `hcl:"log_level"`
+	LogFormat                      string                       `hcl:"log_format"`
+	MaxAgentClockSkew              string                       `hcl:"max_agent_clock_skew"`
+	MaxDownstreamDepth             int                          `hcl:"max_downstream_depth"`
 	// Deprecated: remove in SPIRE 1.6.0
-	OmitX509SVIDUID *bool           `hcl:"omit_x509svid_uid"`
-	RateLimit       rateLimitConfig `hcl:"ratelimit"`
-	SocketPath      string          `hcl:"socket_path"`
-	TrustDomain     string          `hcl:"trust_domain"`
+	OmitX509SVIDUID     *bool           `hcl:"omit_x509svid_uid"`
+	RateLimit           rateLimitConfig `hcl:"ratelimit"`
+	RecordX509Issuances bool            `hcl:"record_x509_issuances"`
+	SocketPath          string          `hcl:"socket_path"`
+	TrustDomain         string          `hcl:"trust_domain"`
 
 	ConfigPath string
 	ExpandEnv  bool
@@ -101,16 +131,53 @@ type serverConfig struct {
 }
 
 type experimentalConfig struct {
+	AgentEviction       *agentEvictionConfig        `hcl:"agent_eviction"`
 	AuthOpaPolicyEngine *authpolicy.OpaEngineConfig `hcl:"auth_opa_policy_engine"`
 	CacheReloadInterval string                      `hcl:"cache_reload_interval"`
+	EntryMirror         *entryMirrorConfig          `hcl:"entry_mirror"`
+	EventBufferSize     int                         `hcl:"event_buffer_size"`
 
 	Flags fflag.RawConfig `hcl:"feature_flags"`
 
 	NamedPipeName string `hcl:"named_pipe_name"`
 
+	OCSPResponder *ocspResponderConfig `hcl:"ocsp_responder"`
+
+	RESTGateway *restGatewayConfig `hcl:"rest_gateway"`
+
+	UnusedKeys []string `hcl:",unusedKeys"`
+}
+
+type restGatewayConfig struct {
+	Address    string   `hcl:"address"`
+	Port       int      `hcl:"port"`
+	UnusedKeys []string `hcl:",unusedKeys"`
+}
+
+type agentEvictionConfig struct {
+	MaxAgents  int      `hcl:"max_agents"`
 	UnusedKeys []string `hcl:",unusedKeys"`
 }
 
+type entryMirrorConfig struct {
+	PeerTrustDomain   string            `hcl:"peer_trust_domain"`
+	PeerAddress       string            `hcl:"peer_address"`
+	TagSelectors      []string          `hcl:"tag_selectors"`
+	PathPrefixes      []string          `hcl:"path_prefixes"`
+	PathRewrites      map[string]string `hcl:"path_rewrites"`
+	Interval          string            `hcl:"interval"`
+	WorkloadAPISocket string            `hcl:"workload_api_socket"`
+	UnusedKeys        []string          `hcl:",unusedKeys"`
+}
+
+type ocspResponderConfig struct {
+	Address       string   `hcl:"address"`
+	Port          int      `hcl:"port"`
+	CacheTTL      string   `hcl:"cache_ttl"`
+	MaxLookupRate int      `hcl:"max_lookup_rate"`
+	UnusedKeys    []string `hcl:",unusedKeys"`
+}
+
 type caSubjectConfig struct {
 	Country      []string `hcl:"country"`
 	Organization []string `hcl:"organization"`
@@ -118,6 +185,34 @@ type caSubjectConfig struct {
 	UnusedKeys   []string `hcl:",unusedKeys"`
 }
 
+type caBackupConfig struct {
+	Path       string   `hcl:"path"`
+	Interval   string   `hcl:"interval"`
+	Retention  int      `hcl:"retention"`
+	UnusedKeys []string `hcl:",unusedKeys"`
+}
+
+type caStagedRollout struct {
+	InitialPercentage int      `hcl:"initial_percentage"`
+	Duration          string   `hcl:"duration"`
+	UnusedKeys        []string `hcl:",unusedKeys"`
+}
+
+type csrKeyPolicyConfig struct {
+	AllowedKeyTypes []string `hcl:"allowed_key_types"`
+	MinRSAKeySize   int      `hcl:"min_rsa_key_size"`
+	AllowedCurves   []string `hcl:"allowed_curves"`
+	UnusedKeys      []string `hcl:",unusedKeys"`
+}
+
+type entryAdmissionWebhookConfig struct {
+	URL           string   `hcl:"url"`
+	CABundlePath  string   `hcl:"ca_bundle_path"`
+	Timeout       string   `hcl:"timeout"`
+	FailurePolicy string   `hcl:"failure_policy"`
+	UnusedKeys    []string `hcl:",unusedKeys"`
+}
+
 type federationConfig struct {
 	BundleEndpoint *bundleEndpointConfig          `hcl:"bundle_endpoint"`
 	FederatesWith  map[string]federatesWithConfig `hcl:"federates_with"`
@@ -140,9 +235,12 @@ type bundleEndpointACMEConfig struct {
 }
 
 type federatesWithConfig struct {
-	BundleEndpointURL     string   `hcl:"bundle_endpoint_url"`
-	BundleEndpointProfile ast.Node `hcl:"bundle_endpoint_profile"`
-	UnusedKeys            []string `hcl:",unusedKeys"`
+	BundleEndpointURL         string   `hcl:"bundle_endpoint_url"`
+	BundleEndpointProfile     ast.Node `hcl:"bundle_endpoint_profile"`
+	BundleEndpointProxyURL    string   `hcl:"bundle_endpoint_proxy_url"`
+	BundleEndpointDialTimeout string   `hcl:"bundle_endpoint_dial_timeout"`
+	BundleSignatureKeysPath   string   `hcl:"bundle_signature_keys_path"`
+	UnusedKeys                []string `hcl:",unusedKeys"`
 }
 
 type bundleEndpointProfileConfig struct {
@@ -160,9 +258,17 @@ type httpsWebProfileConfig struct {
 }
 
 type rateLimitConfig struct {
-	Attestation *bool    `hcl:"attestation"`
-	Signing     *bool    `hcl:"signing"`
-	UnusedKeys  []string `hcl:",unusedKeys"`
+	Attestation     *bool               `hcl:"attestation"`
+	JWTSVIDPerAgent *jwtSVIDQuotaConfig `hcl:"jwt_svid_per_agent"`
+	JWTSVIDPerEntry *jwtSVIDQuotaConfig `hcl:"jwt_svid_per_entry"`
+	Signing         *bool               `hcl:"signing"`
+	UnusedKeys      []string            `hcl:",unusedKeys"`
+}
+
+type jwtSVIDQuotaConfig struct {
+	Rate       float64  `hcl:"rate"`
+	Burst      int      `hcl:"burst"`
+	UnusedKeys []string `hcl:",unusedKeys"`
 }
 
 func NewRunCommand(logOptions []log.Option, allowUnknownConfig bool) cli.Command {
@@ -241,6 +347,8 @@ func (cmd *Command) Run(args []string) int {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
+	drainOnSignal(ctx, s)
+
 	err = s.Run(ctx)
 	if err != nil {
 		c.Log.WithError(err).Error("Server crashed")
@@ -349,6 +457,22 @@ func NewServerConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool
 		log.WithLevel(c.Server.LogLevel),
 		log.WithFormat(c.Server.LogFormat),
 	)
+	if c.Telemetry.RedactionMode != "" {
+		// Redaction must come before the event buffer, so the buffered
+		// events are redacted too.
+		redactor, err := telemetry.NewRedactorFromConfig(&c.Telemetry)
+		if err != nil {
+			return nil, fmt.Errorf("telemetry: %w", err)
+		}
+		logOptions = append(logOptions, log.WithRedaction(telemetry.RedactedFields, redactor.Redact))
+	}
+	if c.Server.Experimental.EventBufferSize < 0 {
+		return nil, errors.New("event_buffer_size should not be negative")
+	}
+	if c.Server.Experimental.EventBufferSize > 0 {
+		sc.EventBuffer = log.NewEventBuffer(c.Server.Experimental.EventBufferSize)
+		logOptions = append(logOptions, log.WithEventBuffer(sc.EventBuffer))
+	}
 	var reopenableFile *log.ReopenableFile
 	if c.Server.LogFile != "" {
 		reopenableFile, err := log.NewReopenableFile(c.Server.LogFile)
@@ -404,6 +528,20 @@ func NewServerConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool
 	}
 	sc.RateLimit.Signing = *c.Server.RateLimit.Signing
 
+	if quota := c.Server.RateLimit.JWTSVIDPerEntry; quota != nil {
+		sc.RateLimit.JWTSVIDPerEntry, err = parseJWTSVIDQuota(quota)
+		if err != nil {
+			return nil, fmt.Errorf("ratelimit: jwt_svid_per_entry: %w", err)
+		}
+	}
+
+	if quota := c.Server.RateLimit.JWTSVIDPerAgent; quota != nil {
+		sc.RateLimit.JWTSVIDPerAgent, err = parseJWTSVIDQuota(quota)
+		if err != nil {
+			return nil, fmt.Errorf("ratelimit: jwt_svid_per_agent: %w", err)
+		}
+	}
+
 	if c.Server.Federation != nil {
 		if c.Server.Federation.BundleEndpoint != nil {
 			sc.Federation.BundleEndpoint = &bundle.EndpointConfig{
@@ -442,6 +580,20 @@ func NewServerConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool
 			default:
 				return nil, fmt.Errorf("federation configuration for trust domain %q: missing bundle endpoint configuration", trustDomain)
 			}
+			trustDomainConfig.ProxyURL = config.BundleEndpointProxyURL
+			if config.BundleEndpointDialTimeout != "" {
+				trustDomainConfig.DialTimeout, err = time.ParseDuration(config.BundleEndpointDialTimeout)
+				if err != nil {
+					return nil, fmt.Errorf("could not parse bundle_endpoint_dial_timeout for trust domain %q: %w", trustDomain, err)
+				}
+			}
+			if config.BundleSignatureKeysPath != "" {
+				// Fail early rather than on every bundle refresh
+				if _, err := bundleClient.LoadSignatureKeys(config.BundleSignatureKeysPath); err != nil {
+					return nil, fmt.Errorf("could not load bundle_signature_keys_path for trust domain %q: %w", trustDomain, err)
+				}
+				trustDomainConfig.SignatureKeysPath = config.BundleSignatureKeysPath
+			}
 			federatesWith[td] = *trustDomainConfig
 		}
 		sc.Federation.FederatesWith = federatesWith
@@ -570,6 +722,156 @@ func NewServerConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool
 		sc.OmitX509SVIDUID = *c.Server.OmitX509SVIDUID
 	}
 
+	if c.Server.AttestationEventRetention != "" {
+		retention, err := time.ParseDuration(c.Server.AttestationEventRetention)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse attestation_event_retention %q: %w", c.Server.AttestationEventRetention, err)
+		}
+		if retention <= 0 {
+			return nil, errors.New("attestation_event_retention must be positive")
+		}
+		sc.AttestationEventRetention = retention
+	}
+
+	if c.Server.DrainDelay != "" {
+		drainDelay, err := time.ParseDuration(c.Server.DrainDelay)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse drain_delay %q: %w", c.Server.DrainDelay, err)
+		}
+		if drainDelay <= 0 {
+			return nil, errors.New("drain_delay must be positive")
+		}
+		sc.DrainDelay = drainDelay
+	}
+
+	if c.Server.DrainTimeout != "" {
+		drainTimeout, err := time.ParseDuration(c.Server.DrainTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse drain_timeout %q: %w", c.Server.DrainTimeout, err)
+		}
+		if drainTimeout <= 0 {
+			return nil, errors.New("drain_timeout must be positive")
+		}
+		sc.DrainTimeout = drainTimeout
+	}
+
+	if c.Server.MaxAgentClockSkew != "" {
+		maxAgentClockSkew, err := time.ParseDuration(c.Server.MaxAgentClockSkew)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse max_agent_clock_skew %q: %w", c.Server.MaxAgentClockSkew, err)
+		}
+		if maxAgentClockSkew < 0 {
+			return nil, errors.New("max_agent_clock_skew cannot be negative")
+		}
+		sc.MaxAgentClockSkew = maxAgentClockSkew
+	}
+
+	if c.Server.AgentSelectorsWarningThreshold < 0 {
+		return nil, errors.New("agent_selectors_warning_threshold cannot be negative")
+	}
+	sc.AgentSelectorWarningThreshold = c.Server.AgentSelectorsWarningThreshold
+
+	if len(c.Server.AgentPathTemplates) > 0 {
+		sc.AgentPathTemplates = make(map[string]*agentpathtemplate.Template, len(c.Server.AgentPathTemplates))
+		for nodeAttestor, text := range c.Server.AgentPathTemplates {
+			tmpl, err := agentpathtemplate.Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse agent_path_templates entry for %q: %w", nodeAttestor, err)
+			}
+			// Agents attested with the same ID would share their identity,
+			// so the template must at least depend on data that identifies
+			// the node.
+			if !tmpl.References("AgentPath") && !tmpl.References("Selectors") {
+				return nil, fmt.Errorf("agent_path_templates entry for %q must reference .AgentPath or .Selectors to produce a unique agent ID per node", nodeAttestor)
+			}
+			sc.AgentPathTemplates[nodeAttestor] = tmpl
+		}
+	}
+
+	for _, attestorType := range c.Server.ApprovalRequiredAttestorTypes {
+		if attestorType == "" {
+			return nil, errors.New("approval_required_attestor_types cannot contain an empty node attestor name")
+		}
+	}
+	sc.ApprovalRequiredAttestorTypes = c.Server.ApprovalRequiredAttestorTypes
+
+	if c.Server.MaxDownstreamDepth < 0 {
+		return nil, errors.New("max_downstream_depth cannot be negative")
+	}
+	sc.MaxDownstreamDepth = c.Server.MaxDownstreamDepth
+
+	sc.CASerialNumberPolicy, err = x509util.ParseSerialNumberPolicy(c.Server.CASerialNumberPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing ca_serial_number_policy: %w", err)
+	}
+	sc.RecordX509Issuances = c.Server.RecordX509Issuances
+
+	if c.Server.IssuanceRecordRetention != "" {
+		retention, err := time.ParseDuration(c.Server.IssuanceRecordRetention)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse issuance_record_retention %q: %w", c.Server.IssuanceRecordRetention, err)
+		}
+		if retention <= 0 {
+			return nil, errors.New("issuance_record_retention must be positive")
+		}
+		sc.IssuanceRecordRetention = retention
+	}
+
+	if rollout := c.Server.CAStagedRollout; rollout != nil {
+		if rollout.InitialPercentage < 0 || rollout.InitialPercentage > 100 {
+			return nil, errors.New("ca_staged_rollout: initial_percentage must be between 0 and 100")
+		}
+		if rollout.Duration == "" {
+			return nil, errors.New("ca_staged_rollout: duration must be set")
+		}
+		duration, err := time.ParseDuration(rollout.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("ca_staged_rollout: could not parse duration %q: %w", rollout.Duration, err)
+		}
+		if duration <= 0 {
+			return nil, errors.New("ca_staged_rollout: duration must be positive")
+		}
+		sc.CAStagedRollout = ca.StagedRolloutConfig{
+			InitialPercentage: rollout.InitialPercentage,
+			Duration:          duration,
+		}
+	}
+
+	if backup := c.Server.CABackup; backup != nil {
+		if backup.Path == "" {
+			return nil, errors.New("ca_backup: path must be set")
+		}
+		sc.CABackup.Dir = backup.Path
+		if backup.Interval != "" {
+			interval, err := time.ParseDuration(backup.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("ca_backup: could not parse interval %q: %w", backup.Interval, err)
+			}
+			if interval <= 0 {
+				return nil, errors.New("ca_backup: interval must be positive")
+			}
+			sc.CABackup.Interval = interval
+		}
+		if backup.Retention < 0 {
+			return nil, errors.New("ca_backup: retention cannot be negative")
+		}
+		sc.CABackup.Retention = backup.Retention
+	}
+
+	if policy := c.Server.CSRKeyPolicy; policy != nil {
+		sc.CSRKeyPolicy, err = parseCSRKeyPolicyConfig(policy)
+		if err != nil {
+			return nil, fmt.Errorf("csr_key_policy: %w", err)
+		}
+	}
+
+	if webhook := c.Server.EntryAdmissionWebhook; webhook != nil {
+		sc.EntryAdmissionWebhook, err = parseEntryAdmissionWebhookConfig(webhook)
+		if err != nil {
+			return nil, fmt.Errorf("entry_admission_webhook: %w", err)
+		}
+	}
+
 	sc.PluginConfigs = *c.Plugins
 	sc.Telemetry = c.Telemetry
 	sc.HealthChecks = c.HealthChecks
@@ -594,6 +896,67 @@ func NewServerConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool
 
 	sc.AuthOpaPolicyEngineConfig = c.Server.Experimental.AuthOpaPolicyEngine
 
+	if gw := c.Server.Experimental.RESTGateway; gw != nil {
+		ip := net.IPv4zero
+		if gw.Address != "" {
+			if ip = net.ParseIP(gw.Address); ip == nil {
+				return nil, fmt.Errorf("could not parse rest_gateway address %q", gw.Address)
+			}
+		}
+		sc.RESTGatewayAddr = &net.TCPAddr{
+			IP:   ip,
+			Port: gw.Port,
+		}
+	}
+
+	if responder := c.Server.Experimental.OCSPResponder; responder != nil {
+		if !c.Server.RecordX509Issuances {
+			return nil, errors.New("ocsp_responder requires record_x509_issuances to be enabled")
+		}
+		ip := net.IPv4zero
+		if responder.Address != "" {
+			if ip = net.ParseIP(responder.Address); ip == nil {
+				return nil, fmt.Errorf("could not parse ocsp_responder address %q", responder.Address)
+			}
+		}
+		if responder.Port <= 0 || responder.Port > 65535 {
+			return nil, fmt.Errorf("ocsp_responder port %d is invalid; it must be between 1 and 65535", responder.Port)
+		}
+		sc.OCSPResponder = &ocsp.ResponderConfig{
+			Address: &net.TCPAddr{
+				IP:   ip,
+				Port: responder.Port,
+			},
+		}
+		if responder.CacheTTL != "" {
+			cacheTTL, err := time.ParseDuration(responder.CacheTTL)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse ocsp_responder cache_ttl %q: %w", responder.CacheTTL, err)
+			}
+			if cacheTTL <= 0 {
+				return nil, errors.New("ocsp_responder cache_ttl must be positive")
+			}
+			sc.OCSPResponder.CacheTTL = cacheTTL
+		}
+		if responder.MaxLookupRate < 0 {
+			return nil, errors.New("ocsp_responder max_lookup_rate must be positive")
+		}
+		sc.OCSPResponder.MaxLookupRate = responder.MaxLookupRate
+	}
+
+	if eviction := c.Server.Experimental.AgentEviction; eviction != nil {
+		if eviction.MaxAgents <= 0 {
+			return nil, errors.New("agent_eviction max_agents must be positive")
+		}
+		sc.AgentEviction.MaxAgents = eviction.MaxAgents
+	}
+
+	if mirror := c.Server.Experimental.EntryMirror; mirror != nil {
+		if sc.EntryMirror, err = parseEntryMirrorConfig(mirror, sc.TrustDomain); err != nil {
+			return nil, err
+		}
+	}
+
 	for _, f := range c.Server.Experimental.Flags {
 		sc.Log.Warnf("Developer feature flag %q has been enabled", f)
 	}
@@ -601,6 +964,65 @@ func NewServerConfig(c *Config, logOptions []log.Option, allowUnknownConfig bool
 	return sc, nil
 }
 
+func parseEntryMirrorConfig(c *entryMirrorConfig, td spiffeid.TrustDomain) (server.EntryMirrorConfig, error) {
+	var config server.EntryMirrorConfig
+
+	peerTrustDomain, err := spiffeid.TrustDomainFromString(c.PeerTrustDomain)
+	if err != nil {
+		return config, fmt.Errorf("could not parse entry_mirror peer_trust_domain %q: %w", c.PeerTrustDomain, err)
+	}
+	if peerTrustDomain == td {
+		return config, errors.New("entry_mirror peer_trust_domain must not be the trust domain of the server")
+	}
+	if c.PeerAddress == "" {
+		return config, errors.New("entry_mirror peer_address must be set")
+	}
+	if c.WorkloadAPISocket == "" {
+		return config, errors.New("entry_mirror workload_api_socket must be set")
+	}
+	workloadAPIAddr, err := util.GetUnixAddrWithAbsPath(c.WorkloadAPISocket)
+	if err != nil {
+		return config, fmt.Errorf("could not parse entry_mirror workload_api_socket %q: %w", c.WorkloadAPISocket, err)
+	}
+	if len(c.TagSelectors) == 0 && len(c.PathPrefixes) == 0 {
+		return config, errors.New("entry_mirror tag_selectors or path_prefixes must be set")
+	}
+	for _, tag := range c.TagSelectors {
+		selector, err := util_cmd.ParseSelector(tag)
+		if err != nil {
+			return config, fmt.Errorf("could not parse entry_mirror tag selector: %w", err)
+		}
+		config.TagSelectors = append(config.TagSelectors, selector)
+	}
+	for _, prefix := range c.PathPrefixes {
+		if !strings.HasPrefix(prefix, "/") {
+			return config, fmt.Errorf("entry_mirror path prefix %q must start with a slash", prefix)
+		}
+	}
+	for from, to := range c.PathRewrites {
+		if !strings.HasPrefix(from, "/") || !strings.HasPrefix(to, "/") {
+			return config, fmt.Errorf("entry_mirror path rewrite from %q to %q must be between prefixes starting with a slash", from, to)
+		}
+	}
+
+	config.PeerTrustDomain = peerTrustDomain
+	config.PeerAddress = c.PeerAddress
+	config.WorkloadAPIAddr = workloadAPIAddr
+	config.PathPrefixes = c.PathPrefixes
+	config.PathRewrites = c.PathRewrites
+	if c.Interval != "" {
+		interval, err := time.ParseDuration(c.Interval)
+		if err != nil {
+			return config, fmt.Errorf("could not parse entry_mirror interval %q: %w", c.Interval, err)
+		}
+		if interval <= 0 {
+			return config, errors.New("entry_mirror interval must be positive")
+		}
+		config.Interval = interval
+	}
+	return config, nil
+}
+
 func parseBundleEndpointProfile(config federatesWithConfig) (trustDomainConfig *bundleClient.TrustDomainConfig, err error) {
 	// First check the number of bundle endpoint profiles in the config
 	objectList, ok := config.BundleEndpointProfile.(*ast.ObjectList)
@@ -684,12 +1106,117 @@ func validateConfig(c *Config) error {
 			case !strings.HasPrefix(strings.ToLower(tdConfig.BundleEndpointURL), "https://"):
 				return fmt.Errorf("federation.federates_with[\"%s\"].bundle_endpoint_url must use the HTTPS protocol; URL found: %q", td, tdConfig.BundleEndpointURL)
 			}
+			if tdConfig.BundleEndpointProxyURL != "" {
+				proxyURL, err := url.Parse(tdConfig.BundleEndpointProxyURL)
+				if err != nil {
+					return fmt.Errorf("federation.federates_with[\"%s\"].bundle_endpoint_proxy_url is invalid: %w", td, err)
+				}
+				if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" {
+					return fmt.Errorf("federation.federates_with[\"%s\"].bundle_endpoint_proxy_url must use the HTTP or HTTPS protocol; URL found: %q", td, tdConfig.BundleEndpointProxyURL)
+				}
+			}
 		}
 	}
 
 	return c.validateOS()
 }
 
+func parseJWTSVIDQuota(c *jwtSVIDQuotaConfig) (svidv1.JWTSVIDQuota, error) {
+	if c.Rate <= 0 {
+		return svidv1.JWTSVIDQuota{}, errors.New("rate must be positive")
+	}
+	if c.Burst < 0 {
+		return svidv1.JWTSVIDQuota{}, errors.New("burst cannot be negative")
+	}
+	return svidv1.JWTSVIDQuota{
+		Rate:  c.Rate,
+		Burst: c.Burst,
+	}, nil
+}
+
+func parseCSRKeyPolicyConfig(c *csrKeyPolicyConfig) (api.KeyPolicy, error) {
+	policy := api.KeyPolicy{
+		AllowedKeyTypes: c.AllowedKeyTypes,
+		MinRSAKeySize:   c.MinRSAKeySize,
+		AllowedCurves:   c.AllowedCurves,
+	}
+
+	for _, keyType := range policy.AllowedKeyTypes {
+		switch keyType {
+		case api.KeyTypeRSA, api.KeyTypeECDSA, api.KeyTypeEd25519:
+		default:
+			return api.KeyPolicy{}, fmt.Errorf("unknown key type %q; must be one of %q, %q or %q", keyType, api.KeyTypeRSA, api.KeyTypeECDSA, api.KeyTypeEd25519)
+		}
+	}
+
+	switch {
+	case policy.MinRSAKeySize < 0:
+		return api.KeyPolicy{}, errors.New("min_rsa_key_size cannot be negative")
+	case policy.MinRSAKeySize == 0:
+		policy.MinRSAKeySize = defaultMinRSAKeySize
+	}
+
+	for _, curve := range policy.AllowedCurves {
+		switch curve {
+		case "P-224", "P-256", "P-384", "P-521":
+		default:
+			return api.KeyPolicy{}, fmt.Errorf("unknown curve %q; must be one of \"P-224\", \"P-256\", \"P-384\" or \"P-521\"", curve)
+		}
+	}
+	if len(policy.AllowedCurves) == 0 {
+		policy.AllowedCurves = []string{"P-256", "P-384", "P-521"}
+	}
+
+	return policy, nil
+}
+
+func parseEntryAdmissionWebhookConfig(c *entryAdmissionWebhookConfig) (*entryadmission.Config, error) {
+	u, err := url.Parse(c.URL)
+	switch {
+	case c.URL == "":
+		return nil, errors.New("url must be set")
+	case err != nil:
+		return nil, fmt.Errorf("could not parse url: %w", err)
+	case u.Scheme != "https":
+		return nil, errors.New("url must use the https scheme")
+	}
+
+	config := &entryadmission.Config{
+		URL: c.URL,
+	}
+
+	if c.CABundlePath != "" {
+		certs, err := pemutil.LoadCertificates(c.CABundlePath)
+		if err != nil {
+			return nil, fmt.Errorf("could not load CA bundle: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		for _, cert := range certs {
+			config.RootCAs.AddCert(cert)
+		}
+	}
+
+	if c.Timeout != "" {
+		config.Timeout, err = time.ParseDuration(c.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse timeout %q: %w", c.Timeout, err)
+		}
+		if config.Timeout <= 0 {
+			return nil, errors.New("timeout must be positive")
+		}
+	}
+
+	switch c.FailurePolicy {
+	case "", "fail":
+	case "ignore":
+		config.FailOpen = true
+	default:
+		return nil, fmt.Errorf("unknown failure_policy %q; must be one of \"fail\" or \"ignore\"", c.FailurePolicy)
+	}
+
+	return config, nil
+}
+
 func checkForUnknownConfig(c *Config, l logrus.FieldLogger) (err error) {
 	detectedUnknown := func(section string, keys []string) {
 		l.WithFields(logrus.Fields{
@@ -708,14 +1235,38 @@ func checkForUnknownConfig(c *Config, l logrus.FieldLogger) (err error) {
 			detectedUnknown("server", c.Server.UnusedKeys)
 		}
 
+		if backup := c.Server.CABackup; backup != nil && len(backup.UnusedKeys) != 0 {
+			detectedUnknown("ca_backup", backup.UnusedKeys)
+		}
+
 		if cs := c.Server.CASubject; cs != nil && len(cs.UnusedKeys) != 0 {
 			detectedUnknown("ca_subject", cs.UnusedKeys)
 		}
 
+		if rollout := c.Server.CAStagedRollout; rollout != nil && len(rollout.UnusedKeys) != 0 {
+			detectedUnknown("ca_staged_rollout", rollout.UnusedKeys)
+		}
+
+		if policy := c.Server.CSRKeyPolicy; policy != nil && len(policy.UnusedKeys) != 0 {
+			detectedUnknown("csr_key_policy", policy.UnusedKeys)
+		}
+
+		if webhook := c.Server.EntryAdmissionWebhook; webhook != nil && len(webhook.UnusedKeys) != 0 {
+			detectedUnknown("entry_admission_webhook", webhook.UnusedKeys)
+		}
+
 		if rl := c.Server.RateLimit; len(rl.UnusedKeys) != 0 {
 			detectedUnknown("ratelimit", rl.UnusedKeys)
 		}
 
+		if quota := c.Server.RateLimit.JWTSVIDPerEntry; quota != nil && len(quota.UnusedKeys) != 0 {
+			detectedUnknown("ratelimit jwt_svid_per_entry", quota.UnusedKeys)
+		}
+
+		if quota := c.Server.RateLimit.JWTSVIDPerAgent; quota != nil && len(quota.UnusedKeys) != 0 {
+			detectedUnknown("ratelimit jwt_svid_per_agent", quota.UnusedKeys)
+		}
+
 		// TODO: Re-enable unused key detection for experimental config. See
 		// https://github.com/spiffe/spire/issues/1101 for more information
 		//
@@ -4,11 +4,16 @@
 package run
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"net"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/spiffe/spire/pkg/common/util"
+	"github.com/spiffe/spire/pkg/server"
 )
 
 const (
@@ -36,3 +41,18 @@ func (c *Config) validateOS() error {
 	}
 	return nil
 }
+
+// drainOnSignal drains the server when it receives SIGUSR1, e.g. before it
+// is replaced during a rolling restart.
+func drainOnSignal(ctx context.Context, s *server.Server) {
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, syscall.SIGUSR1)
+	go func() {
+		defer signal.Stop(signalCh)
+		select {
+		case <-signalCh:
+			s.Drain()
+		case <-ctx.Done():
+		}
+	}()
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/x509/pkix"
 	"io"
+	"net"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,11 +16,18 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"github.com/spiffe/spire/pkg/common/catalog"
 	"github.com/spiffe/spire/pkg/common/log"
+	"github.com/spiffe/spire/pkg/common/util"
+	"github.com/spiffe/spire/pkg/common/x509util"
 	"github.com/spiffe/spire/pkg/server"
+	"github.com/spiffe/spire/pkg/server/api"
+	svidv1 "github.com/spiffe/spire/pkg/server/api/svid/v1"
 	bundleClient "github.com/spiffe/spire/pkg/server/bundle/client"
 	"github.com/spiffe/spire/pkg/server/ca"
+	"github.com/spiffe/spire/pkg/server/endpoints/ocsp"
+	"github.com/spiffe/spire/pkg/server/entryadmission"
 	"github.com/spiffe/spire/pkg/server/plugin/keymanager"
 	"github.com/spiffe/spire/test/spiretest"
 	"github.com/stretchr/testify/assert"
@@ -592,375 +600,1268 @@ func TestNewServerConfig(t *testing.T) {
 				}, c.Federation.FederatesWith)
 			},
 		},
+		{
+			msg: "bundle endpoint proxy URL and dial timeout are configured",
+			input: func(c *Config) {
+				federatesWith := webPKIConfigTest(t)
+				federatesWith.BundleEndpointProxyURL = "http://proxy.test:3128"
+				federatesWith.BundleEndpointDialTimeout = "5s"
+				c.Server.Federation = &federationConfig{
+					FederatesWith: map[string]federatesWithConfig{
+						"domain1.test": federatesWith,
+					},
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, map[spiffeid.TrustDomain]bundleClient.TrustDomainConfig{
+					spiffeid.RequireTrustDomainFromString("domain1.test"): {
+						EndpointURL:     "https://192.168.1.1:1337",
+						EndpointProfile: bundleClient.HTTPSWebProfile{},
+						ProxyURL:        "http://proxy.test:3128",
+						DialTimeout:     5 * time.Second,
+					},
+				}, c.Federation.FederatesWith)
+			},
+		},
+		{
+			msg: "bundle signature keys are configured",
+			input: func(c *Config) {
+				federatesWith := webPKIConfigTest(t)
+				federatesWith.BundleSignatureKeysPath = writeBundleSignatureKeys(t)
+				c.Server.Federation = &federationConfig{
+					FederatesWith: map[string]federatesWithConfig{
+						"domain1.test": federatesWith,
+					},
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				tdConfig := c.Federation.FederatesWith[spiffeid.RequireTrustDomainFromString("domain1.test")]
+				require.NotEmpty(t, tdConfig.SignatureKeysPath)
+			},
+		},
+		{
+			msg:         "invalid bundle signature keys return an error",
+			expectError: true,
+			input: func(c *Config) {
+				federatesWith := webPKIConfigTest(t)
+				federatesWith.BundleSignatureKeysPath = filepath.Join(t.TempDir(), "missing.jwks")
+				c.Server.Federation = &federationConfig{
+					FederatesWith: map[string]federatesWithConfig{
+						"domain1.test": federatesWith,
+					},
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "invalid bundle endpoint dial timeout returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				federatesWith := webPKIConfigTest(t)
+				federatesWith.BundleEndpointDialTimeout = "b"
+				c.Server.Federation = &federationConfig{
+					FederatesWith: map[string]federatesWithConfig{
+						"domain1.test": federatesWith,
+					},
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
 		{
 			msg: "default_svid_ttl is correctly parsed",
 			input: func(c *Config) {
-				c.Server.DefaultSVIDTTL = "1m"
+				c.Server.DefaultSVIDTTL = "1m"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, time.Minute, c.SVIDTTL)
+			},
+		},
+		{
+			msg:         "invalid default_svid_ttl returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.DefaultSVIDTTL = "b"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "ca_key_type and jwt_key_type are set as default",
+			input: func(c *Config) {
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, keymanager.ECP256, c.CAKeyType)
+				require.Equal(t, keymanager.ECP256, c.JWTKeyType)
+			},
+		},
+		{
+			msg: "rsa-2048 ca_key_type is correctly parsed and is set as default for jwt key",
+			input: func(c *Config) {
+				c.Server.CAKeyType = "rsa-2048"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, keymanager.RSA2048, c.CAKeyType)
+				require.Equal(t, keymanager.RSA2048, c.JWTKeyType)
+			},
+		},
+		{
+			msg: "rsa-4096 ca_key_type is correctly parsed and is set as default for jwt key",
+			input: func(c *Config) {
+				c.Server.CAKeyType = "rsa-4096"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, keymanager.RSA4096, c.CAKeyType)
+				require.Equal(t, keymanager.RSA4096, c.JWTKeyType)
+			},
+		},
+		{
+			msg: "ec-p256 ca_key_type is correctly parsed and is set as default for jwt key",
+			input: func(c *Config) {
+				c.Server.CAKeyType = "ec-p256"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, keymanager.ECP256, c.CAKeyType)
+				require.Equal(t, keymanager.ECP256, c.JWTKeyType)
+			},
+		},
+		{
+			msg: "ec-p384 ca_key_type is correctly parsed and is set as default for jwt key",
+			input: func(c *Config) {
+				c.Server.CAKeyType = "ec-p384"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, keymanager.ECP384, c.CAKeyType)
+				require.Equal(t, keymanager.ECP384, c.JWTKeyType)
+			},
+		},
+		{
+			msg:         "unsupported ca_key_type is rejected",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.CAKeyType = "rsa-1024"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "rsa-2048 jwt_key_type is correctly parsed and ca_key_type is unspecified",
+			input: func(c *Config) {
+				c.Server.JWTKeyType = "rsa-2048"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, keymanager.ECP256, c.CAKeyType)
+				require.Equal(t, keymanager.RSA2048, c.JWTKeyType)
+			},
+		},
+		{
+			msg: "rsa-4096 jwt_key_type is correctly parsed and ca_key_type is unspecified",
+			input: func(c *Config) {
+				c.Server.JWTKeyType = "rsa-4096"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, keymanager.ECP256, c.CAKeyType)
+				require.Equal(t, keymanager.RSA4096, c.JWTKeyType)
+			},
+		},
+		{
+			msg: "ec-p256 jwt_key_type is correctly parsed and ca_key_type is unspecified",
+			input: func(c *Config) {
+				c.Server.JWTKeyType = "ec-p256"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, keymanager.ECP256, c.CAKeyType)
+				require.Equal(t, keymanager.ECP256, c.JWTKeyType)
+			},
+		},
+		{
+			msg: "ec-p384 jwt_key_type is correctly parsed and ca_key_type is unspecified",
+			input: func(c *Config) {
+				c.Server.JWTKeyType = "ec-p384"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, keymanager.ECP256, c.CAKeyType)
+				require.Equal(t, keymanager.ECP384, c.JWTKeyType)
+			},
+		},
+		{
+			msg:         "unsupported jwt_key_type is rejected",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.JWTKeyType = "rsa-1024"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "override jwt_key_type from the default ca_key_type",
+			input: func(c *Config) {
+				c.Server.CAKeyType = "rsa-2048"
+				c.Server.JWTKeyType = "ec-p256"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, keymanager.RSA2048, c.CAKeyType)
+				require.Equal(t, keymanager.ECP256, c.JWTKeyType)
+			},
+		},
+		{
+			msg: "ca_ttl is correctly parsed",
+			input: func(c *Config) {
+				c.Server.CATTL = "1h"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, time.Hour, c.CATTL)
+			},
+		},
+		{
+			msg:         "invalid ca_ttl returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.CATTL = "b"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "ca_subject is defaulted when unset",
+			input: func(c *Config) {
+				c.Server.CASubject = nil
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, defaultCASubject, c.CASubject)
+			},
+		},
+		{
+			msg: "ca_subject is defaulted when set but empty",
+			input: func(c *Config) {
+				c.Server.CASubject = &caSubjectConfig{}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, defaultCASubject, c.CASubject)
+			},
+		},
+		{
+			msg: "ca_subject is overridable",
+			input: func(c *Config) {
+				c.Server.CASubject = &caSubjectConfig{
+					Organization: []string{"foo"},
+					Country:      []string{"us"},
+					CommonName:   "bar",
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, pkix.Name{
+					Organization: []string{"foo"},
+					Country:      []string{"us"},
+					CommonName:   "bar",
+				}, c.CASubject)
+			},
+		},
+		{
+			msg: "attestation rate limit is on by default",
+			input: func(c *Config) {
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.True(t, c.RateLimit.Attestation)
+			},
+		},
+		{
+			msg: "attestation rate limits can be explicitly disabled",
+			input: func(c *Config) {
+				value := false
+				c.Server.RateLimit.Attestation = &value
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.False(t, c.RateLimit.Attestation)
+			},
+		},
+		{
+			msg: "attestation rate limits can be explicitly enabled",
+			input: func(c *Config) {
+				value := true
+				c.Server.RateLimit.Attestation = &value
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.True(t, c.RateLimit.Attestation)
+			},
+		},
+		{
+			msg: "signing rate limit is on by default",
+			input: func(c *Config) {
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.True(t, c.RateLimit.Signing)
+			},
+		},
+		{
+			msg: "signing rate limit can be explicitly disabled",
+			input: func(c *Config) {
+				value := false
+				c.Server.RateLimit.Signing = &value
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.False(t, c.RateLimit.Signing)
+			},
+		},
+		{
+			msg: "signing rate limit can be explicitly enabled",
+			input: func(c *Config) {
+				value := true
+				c.Server.RateLimit.Signing = &value
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.True(t, c.RateLimit.Signing)
+			},
+		},
+		{
+			msg:   "JWT-SVID quotas are disabled by default",
+			input: func(c *Config) {},
+			test: func(t *testing.T, c *server.Config) {
+				require.Zero(t, c.RateLimit.JWTSVIDPerEntry)
+				require.Zero(t, c.RateLimit.JWTSVIDPerAgent)
+			},
+		},
+		{
+			msg: "JWT-SVID quotas are correctly parsed",
+			input: func(c *Config) {
+				c.Server.RateLimit.JWTSVIDPerEntry = &jwtSVIDQuotaConfig{Rate: 0.5, Burst: 10}
+				c.Server.RateLimit.JWTSVIDPerAgent = &jwtSVIDQuotaConfig{Rate: 100}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, svidv1.JWTSVIDQuota{Rate: 0.5, Burst: 10}, c.RateLimit.JWTSVIDPerEntry)
+				require.Equal(t, svidv1.JWTSVIDQuota{Rate: 100}, c.RateLimit.JWTSVIDPerAgent)
+			},
+		},
+		{
+			msg:         "JWT-SVID quota without a rate returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.RateLimit.JWTSVIDPerEntry = &jwtSVIDQuotaConfig{Burst: 10}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "JWT-SVID quota with a negative burst returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.RateLimit.JWTSVIDPerAgent = &jwtSVIDQuotaConfig{Rate: 1, Burst: -1}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "warn_on_long_trust_domain",
+			input: func(c *Config) {
+				c.Server.TrustDomain = strings.Repeat("a", 256)
+			},
+			logOptions: assertLogsContainEntries([]spiretest.LogEntry{
+				{
+					Data:  map[string]interface{}{"trust_domain": strings.Repeat("a", 256)},
+					Level: logrus.WarnLevel,
+					Message: "Configured trust domain name should be less than 255 characters to be " +
+						"SPIFFE compliant; a longer trust domain name may impact interoperability",
+				},
+			}),
+			test: func(t *testing.T, c *server.Config) {
+				assert.NotNil(t, c)
+			},
+		},
+		{
+			msg: "cache_reload_interval is correctly parsed",
+			input: func(c *Config) {
+				c.Server.Experimental.CacheReloadInterval = "1m"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, time.Minute, c.CacheReloadInterval)
+			},
+		},
+		{
+			msg:         "invalid cache_reload_interval returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.Experimental.CacheReloadInterval = "b"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "event_buffer_size is set",
+			input: func(c *Config) {
+				c.Server.Experimental.EventBufferSize = 100
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.NotNil(t, c.EventBuffer)
+			},
+		},
+		{
+			msg: "event_buffer_size is not set",
+			input: func(c *Config) {
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c.EventBuffer)
+			},
+		},
+		{
+			msg:         "event_buffer_size is negative",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.Experimental.EventBufferSize = -1
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "telemetry RedactionMode is set",
+			input: func(c *Config) {
+				c.Telemetry.RedactionMode = "truncate"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, "truncate", c.Telemetry.RedactionMode)
+			},
+		},
+		{
+			msg:         "telemetry RedactionMode hash requires RedactionKeyFile",
+			expectError: true,
+			input: func(c *Config) {
+				c.Telemetry.RedactionMode = "hash"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "telemetry RedactionMode is unknown",
+			expectError: true,
+			input: func(c *Config) {
+				c.Telemetry.RedactionMode = "encrypt"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "rest_gateway is correctly parsed",
+			input: func(c *Config) {
+				c.Server.Experimental.RESTGateway = &restGatewayConfig{
+					Address: "127.0.0.1",
+					Port:    8443,
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8443}, c.RESTGatewayAddr)
+			},
+		},
+		{
+			msg:         "invalid rest_gateway address returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.Experimental.RESTGateway = &restGatewayConfig{
+					Address: "localhost",
+					Port:    8443,
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "ocsp_responder is correctly parsed",
+			input: func(c *Config) {
+				c.Server.RecordX509Issuances = true
+				c.Server.Experimental.OCSPResponder = &ocspResponderConfig{
+					Address:       "127.0.0.1",
+					Port:          8082,
+					CacheTTL:      "10m",
+					MaxLookupRate: 50,
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, &ocsp.ResponderConfig{
+					Address:       &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8082},
+					CacheTTL:      10 * time.Minute,
+					MaxLookupRate: 50,
+				}, c.OCSPResponder)
+			},
+		},
+		{
+			msg:         "ocsp_responder requires record_x509_issuances",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.Experimental.OCSPResponder = &ocspResponderConfig{
+					Port: 8082,
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "missing ocsp_responder port returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.RecordX509Issuances = true
+				c.Server.Experimental.OCSPResponder = &ocspResponderConfig{}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "out of range ocsp_responder port returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.RecordX509Issuances = true
+				c.Server.Experimental.OCSPResponder = &ocspResponderConfig{
+					Port: 65536,
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "negative ocsp_responder max_lookup_rate returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.RecordX509Issuances = true
+				c.Server.Experimental.OCSPResponder = &ocspResponderConfig{
+					Port:          8082,
+					MaxLookupRate: -1,
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "invalid ocsp_responder cache_ttl returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.RecordX509Issuances = true
+				c.Server.Experimental.OCSPResponder = &ocspResponderConfig{
+					Port:     8082,
+					CacheTTL: "forever",
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "agent_eviction is correctly parsed",
+			input: func(c *Config) {
+				c.Server.Experimental.AgentEviction = &agentEvictionConfig{
+					MaxAgents: 10000,
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, server.AgentEvictionConfig{MaxAgents: 10000}, c.AgentEviction)
+			},
+		},
+		{
+			msg:         "agent_eviction requires a positive max_agents",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.Experimental.AgentEviction = &agentEvictionConfig{}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "entry_mirror is correctly parsed",
+			input: func(c *Config) {
+				c.Server.Experimental.EntryMirror = &entryMirrorConfig{
+					PeerTrustDomain:   "peer.org",
+					PeerAddress:       "spire-server.peer.org:8081",
+					TagSelectors:      []string{"k8s:pod-label:mirror:true"},
+					PathPrefixes:      []string{"/batch/"},
+					PathRewrites:      map[string]string{"/batch/east/": "/batch/west/"},
+					Interval:          "30s",
+					WorkloadAPISocket: "/tmp/peer-agent/api.sock",
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				workloadAPIAddr, err := util.GetUnixAddrWithAbsPath("/tmp/peer-agent/api.sock")
+				require.NoError(t, err)
+				require.Equal(t, server.EntryMirrorConfig{
+					PeerTrustDomain: spiffeid.RequireTrustDomainFromString("peer.org"),
+					PeerAddress:     "spire-server.peer.org:8081",
+					WorkloadAPIAddr: workloadAPIAddr,
+					TagSelectors:    []*types.Selector{{Type: "k8s", Value: "pod-label:mirror:true"}},
+					PathPrefixes:    []string{"/batch/"},
+					PathRewrites:    map[string]string{"/batch/east/": "/batch/west/"},
+					Interval:        30 * time.Second,
+				}, c.EntryMirror)
+			},
+		},
+		{
+			msg:         "entry_mirror cannot mirror to the trust domain of the server",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.Experimental.EntryMirror = &entryMirrorConfig{
+					PeerTrustDomain:   "example.org",
+					PeerAddress:       "spire-server.example.org:8081",
+					PathPrefixes:      []string{"/batch/"},
+					WorkloadAPISocket: "/tmp/peer-agent/api.sock",
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "entry_mirror requires workload_api_socket",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.Experimental.EntryMirror = &entryMirrorConfig{
+					PeerTrustDomain: "peer.org",
+					PeerAddress:     "spire-server.peer.org:8081",
+					PathPrefixes:    []string{"/batch/"},
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "entry_mirror requires tag_selectors or path_prefixes",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.Experimental.EntryMirror = &entryMirrorConfig{
+					PeerTrustDomain:   "peer.org",
+					PeerAddress:       "spire-server.peer.org:8081",
+					WorkloadAPISocket: "/tmp/peer-agent/api.sock",
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "entry_mirror tag selectors must be formatted as type:value",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.Experimental.EntryMirror = &entryMirrorConfig{
+					PeerTrustDomain:   "peer.org",
+					PeerAddress:       "spire-server.peer.org:8081",
+					TagSelectors:      []string{"mirror"},
+					WorkloadAPISocket: "/tmp/peer-agent/api.sock",
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "entry_mirror path rewrites must start with a slash",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.Experimental.EntryMirror = &entryMirrorConfig{
+					PeerTrustDomain:   "peer.org",
+					PeerAddress:       "spire-server.peer.org:8081",
+					PathPrefixes:      []string{"/batch/"},
+					PathRewrites:      map[string]string{"batch": "/other/"},
+					WorkloadAPISocket: "/tmp/peer-agent/api.sock",
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "audit_log_enabled is enabled",
+			input: func(c *Config) {
+				c.Server.AuditLogEnabled = true
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.True(t, c.AuditLogEnabled)
+			},
+		},
+		{
+			msg: "audit_log_enabled is disabled",
+			input: func(c *Config) {
+				c.Server.AuditLogEnabled = false
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.False(t, c.AuditLogEnabled)
+			},
+		},
+		{
+			msg: "admin IDs are set",
+			input: func(c *Config) {
+				c.Server.AdminIDs = []string{
+					"spiffe://example.org/my/admin1",
+					"spiffe://example.org/my/admin2",
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, []spiffeid.ID{
+					spiffeid.RequireFromString("spiffe://example.org/my/admin1"),
+					spiffeid.RequireFromString("spiffe://example.org/my/admin2"),
+				}, c.AdminIDs)
+			},
+		},
+		{
+			msg: "admin ID does not belong to the trust domain",
+			input: func(c *Config) {
+				c.Server.AdminIDs = []string{
+					"spiffe://otherdomain.test/my/admin",
+				}
+			},
+			expectError: true,
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg: "omit_x509svid_uid is unset",
+			input: func(c *Config) {
+				c.Server.OmitX509SVIDUID = nil
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.False(t, c.OmitX509SVIDUID)
+			},
+		},
+		{
+			msg: "omit_x509svid_uid is set to false",
+			input: func(c *Config) {
+				value := false
+				c.Server.OmitX509SVIDUID = &value
+			},
+			logOptions: assertLogsContainEntries([]spiretest.LogEntry{
+				{
+					Level:   logrus.WarnLevel,
+					Message: "The omit_x509svid_uid flag is deprecated and will be removed from a future release",
+				},
+			}),
+			test: func(t *testing.T, c *server.Config) {
+				require.False(t, c.OmitX509SVIDUID)
+			},
+		},
+		{
+			msg: "omit_x509svid_uid is set to true",
+			input: func(c *Config) {
+				value := true
+				c.Server.OmitX509SVIDUID = &value
+			},
+			logOptions: assertLogsContainEntries([]spiretest.LogEntry{
+				{
+					Level:   logrus.WarnLevel,
+					Message: "The omit_x509svid_uid flag is deprecated and will be removed from a future release",
+				},
+			}),
+			test: func(t *testing.T, c *server.Config) {
+				require.True(t, c.OmitX509SVIDUID)
+			},
+		},
+		{
+			msg: "drain_delay is correctly parsed",
+			input: func(c *Config) {
+				c.Server.DrainDelay = "10s"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, 10*time.Second, c.DrainDelay)
+			},
+		},
+		{
+			msg:         "unparseable drain_delay returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.DrainDelay = "a while"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Nil(t, c)
+			},
+		},
+		{
+			msg:         "zero drain_delay returns an error",
+			expectError: true,
+			input: func(c *Config) {
+				c.Server.DrainDelay = "0s"
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, time.Minute, c.SVIDTTL)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg:         "invalid default_svid_ttl returns an error",
+			msg: "drain_timeout is correctly parsed",
+			input: func(c *Config) {
+				c.Server.DrainTimeout = "1m"
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, time.Minute, c.DrainTimeout)
+			},
+		},
+		{
+			msg:         "unparseable drain_timeout returns an error",
 			expectError: true,
 			input: func(c *Config) {
-				c.Server.DefaultSVIDTTL = "b"
+				c.Server.DrainTimeout = "a while"
 			},
 			test: func(t *testing.T, c *server.Config) {
 				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "ca_key_type and jwt_key_type are set as default",
+			msg:         "zero drain_timeout returns an error",
+			expectError: true,
 			input: func(c *Config) {
+				c.Server.DrainTimeout = "0s"
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, keymanager.ECP256, c.CAKeyType)
-				require.Equal(t, keymanager.ECP256, c.JWTKeyType)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "rsa-2048 ca_key_type is correctly parsed and is set as default for jwt key",
+			msg: "max_agent_clock_skew is correctly parsed",
 			input: func(c *Config) {
-				c.Server.CAKeyType = "rsa-2048"
+				c.Server.MaxAgentClockSkew = "5m"
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, keymanager.RSA2048, c.CAKeyType)
-				require.Equal(t, keymanager.RSA2048, c.JWTKeyType)
+				require.Equal(t, 5*time.Minute, c.MaxAgentClockSkew)
 			},
 		},
 		{
-			msg: "rsa-4096 ca_key_type is correctly parsed and is set as default for jwt key",
+			msg:         "unparseable max_agent_clock_skew returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				c.Server.CAKeyType = "rsa-4096"
+				c.Server.MaxAgentClockSkew = "a while"
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, keymanager.RSA4096, c.CAKeyType)
-				require.Equal(t, keymanager.RSA4096, c.JWTKeyType)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "ec-p256 ca_key_type is correctly parsed and is set as default for jwt key",
+			msg:         "negative max_agent_clock_skew returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				c.Server.CAKeyType = "ec-p256"
+				c.Server.MaxAgentClockSkew = "-5m"
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, keymanager.ECP256, c.CAKeyType)
-				require.Equal(t, keymanager.ECP256, c.JWTKeyType)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "ec-p384 ca_key_type is correctly parsed and is set as default for jwt key",
+			msg: "agent_selectors_warning_threshold is set",
 			input: func(c *Config) {
-				c.Server.CAKeyType = "ec-p384"
+				c.Server.AgentSelectorsWarningThreshold = 500
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, keymanager.ECP384, c.CAKeyType)
-				require.Equal(t, keymanager.ECP384, c.JWTKeyType)
+				require.Equal(t, 500, c.AgentSelectorWarningThreshold)
 			},
 		},
 		{
-			msg:         "unsupported ca_key_type is rejected",
+			msg:         "negative agent_selectors_warning_threshold returns an error",
 			expectError: true,
 			input: func(c *Config) {
-				c.Server.CAKeyType = "rsa-1024"
+				c.Server.AgentSelectorsWarningThreshold = -1
 			},
 			test: func(t *testing.T, c *server.Config) {
 				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "rsa-2048 jwt_key_type is correctly parsed and ca_key_type is unspecified",
+			msg: "agent_path_templates is set",
 			input: func(c *Config) {
-				c.Server.JWTKeyType = "rsa-2048"
+				c.Server.AgentPathTemplates = map[string]string{
+					"aws_iid": "/{{ .PluginName }}/{{ .Selectors.az }}",
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, keymanager.ECP256, c.CAKeyType)
-				require.Equal(t, keymanager.RSA2048, c.JWTKeyType)
+				require.Len(t, c.AgentPathTemplates, 1)
+				require.NotNil(t, c.AgentPathTemplates["aws_iid"])
 			},
 		},
 		{
-			msg: "rsa-4096 jwt_key_type is correctly parsed and ca_key_type is unspecified",
+			msg:         "agent_path_templates entry without node data returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				c.Server.JWTKeyType = "rsa-4096"
+				c.Server.AgentPathTemplates = map[string]string{
+					"aws_iid": "/{{ .PluginName }}/{{ .TrustDomain }}",
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, keymanager.ECP256, c.CAKeyType)
-				require.Equal(t, keymanager.RSA4096, c.JWTKeyType)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "ec-p256 jwt_key_type is correctly parsed and ca_key_type is unspecified",
+			msg:         "invalid agent_path_templates entry returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				c.Server.JWTKeyType = "ec-p256"
+				c.Server.AgentPathTemplates = map[string]string{
+					"aws_iid": "/{{ .PluginName ",
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, keymanager.ECP256, c.CAKeyType)
-				require.Equal(t, keymanager.ECP256, c.JWTKeyType)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "ec-p384 jwt_key_type is correctly parsed and ca_key_type is unspecified",
+			msg: "attestation_event_retention is correctly parsed",
 			input: func(c *Config) {
-				c.Server.JWTKeyType = "ec-p384"
+				c.Server.AttestationEventRetention = "168h"
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, keymanager.ECP256, c.CAKeyType)
-				require.Equal(t, keymanager.ECP384, c.JWTKeyType)
+				require.Equal(t, 7*24*time.Hour, c.AttestationEventRetention)
 			},
 		},
 		{
-			msg:         "unsupported jwt_key_type is rejected",
+			msg:         "non-positive attestation_event_retention returns an error",
 			expectError: true,
 			input: func(c *Config) {
-				c.Server.JWTKeyType = "rsa-1024"
+				c.Server.AttestationEventRetention = "0s"
 			},
 			test: func(t *testing.T, c *server.Config) {
 				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "override jwt_key_type from the default ca_key_type",
+			msg: "issuance_record_retention is correctly parsed",
 			input: func(c *Config) {
-				c.Server.CAKeyType = "rsa-2048"
-				c.Server.JWTKeyType = "ec-p256"
+				c.Server.IssuanceRecordRetention = "168h"
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, keymanager.RSA2048, c.CAKeyType)
-				require.Equal(t, keymanager.ECP256, c.JWTKeyType)
+				require.Equal(t, 7*24*time.Hour, c.IssuanceRecordRetention)
 			},
 		},
 		{
-			msg: "ca_ttl is correctly parsed",
+			msg:         "invalid issuance_record_retention returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				c.Server.CATTL = "1h"
+				c.Server.IssuanceRecordRetention = "forever"
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, time.Hour, c.CATTL)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg:         "invalid ca_ttl returns an error",
+			msg: "approval_required_attestor_types is set",
+			input: func(c *Config) {
+				c.Server.ApprovalRequiredAttestorTypes = []string{"x509pop", "tpm_devid"}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, []string{"x509pop", "tpm_devid"}, c.ApprovalRequiredAttestorTypes)
+			},
+		},
+		{
+			msg:         "empty approval_required_attestor_types entry returns an error",
 			expectError: true,
 			input: func(c *Config) {
-				c.Server.CATTL = "b"
+				c.Server.ApprovalRequiredAttestorTypes = []string{""}
 			},
 			test: func(t *testing.T, c *server.Config) {
 				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "ca_subject is defaulted when unset",
+			msg:   "ca_serial_number_policy defaults to random",
+			input: func(c *Config) {},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, x509util.SerialNumberRandom, c.CASerialNumberPolicy)
+			},
+		},
+		{
+			msg: "ca_serial_number_policy is correctly parsed",
 			input: func(c *Config) {
-				c.Server.CASubject = nil
+				c.Server.CASerialNumberPolicy = "time_ordered"
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, defaultCASubject, c.CASubject)
+				require.Equal(t, x509util.SerialNumberTimeOrdered, c.CASerialNumberPolicy)
 			},
 		},
 		{
-			msg: "ca_subject is defaulted when set but empty",
+			msg:         "unsupported ca_serial_number_policy returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				c.Server.CASubject = &caSubjectConfig{}
+				c.Server.CASerialNumberPolicy = "sequential"
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, defaultCASubject, c.CASubject)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "ca_subject is overridable",
+			msg: "ca_staged_rollout is correctly parsed",
 			input: func(c *Config) {
-				c.Server.CASubject = &caSubjectConfig{
-					Organization: []string{"foo"},
-					Country:      []string{"us"},
-					CommonName:   "bar",
+				c.Server.CAStagedRollout = &caStagedRollout{
+					InitialPercentage: 10,
+					Duration:          "6h",
 				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, pkix.Name{
-					Organization: []string{"foo"},
-					Country:      []string{"us"},
-					CommonName:   "bar",
-				}, c.CASubject)
+				require.Equal(t, ca.StagedRolloutConfig{
+					InitialPercentage: 10,
+					Duration:          6 * time.Hour,
+				}, c.CAStagedRollout)
 			},
 		},
 		{
-			msg: "attestation rate limit is on by default",
+			msg:         "ca_staged_rollout with an invalid initial_percentage returns an error",
+			expectError: true,
 			input: func(c *Config) {
+				c.Server.CAStagedRollout = &caStagedRollout{
+					InitialPercentage: 101,
+					Duration:          "6h",
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.True(t, c.RateLimit.Attestation)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "attestation rate limits can be explicitly disabled",
+			msg:         "ca_staged_rollout without a duration returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				value := false
-				c.Server.RateLimit.Attestation = &value
+				c.Server.CAStagedRollout = &caStagedRollout{
+					InitialPercentage: 10,
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.False(t, c.RateLimit.Attestation)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "attestation rate limits can be explicitly enabled",
+			msg:         "ca_staged_rollout with an unparseable duration returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				value := true
-				c.Server.RateLimit.Attestation = &value
+				c.Server.CAStagedRollout = &caStagedRollout{
+					InitialPercentage: 10,
+					Duration:          "soon",
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.True(t, c.RateLimit.Attestation)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "signing rate limit is on by default",
+			msg: "ca_backup is correctly parsed",
 			input: func(c *Config) {
+				c.Server.CABackup = &caBackupConfig{
+					Path:      "/var/backups/spire",
+					Interval:  "12h",
+					Retention: 14,
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.True(t, c.RateLimit.Signing)
+				require.Equal(t, server.CABackupConfig{
+					Dir:       "/var/backups/spire",
+					Interval:  12 * time.Hour,
+					Retention: 14,
+				}, c.CABackup)
 			},
 		},
 		{
-			msg: "signing rate limit can be explicitly disabled",
+			msg: "ca_backup defaults are left to the server",
 			input: func(c *Config) {
-				value := false
-				c.Server.RateLimit.Signing = &value
+				c.Server.CABackup = &caBackupConfig{
+					Path: "/var/backups/spire",
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.False(t, c.RateLimit.Signing)
+				require.Equal(t, server.CABackupConfig{
+					Dir: "/var/backups/spire",
+				}, c.CABackup)
 			},
 		},
 		{
-			msg: "signing rate limit can be explicitly enabled",
+			msg:         "ca_backup without a path returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				value := true
-				c.Server.RateLimit.Signing = &value
+				c.Server.CABackup = &caBackupConfig{
+					Interval: "12h",
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.True(t, c.RateLimit.Signing)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "warn_on_long_trust_domain",
+			msg:         "ca_backup with an unparseable interval returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				c.Server.TrustDomain = strings.Repeat("a", 256)
+				c.Server.CABackup = &caBackupConfig{
+					Path:     "/var/backups/spire",
+					Interval: "daily",
+				}
 			},
-			logOptions: assertLogsContainEntries([]spiretest.LogEntry{
-				{
-					Data:  map[string]interface{}{"trust_domain": strings.Repeat("a", 256)},
-					Level: logrus.WarnLevel,
-					Message: "Configured trust domain name should be less than 255 characters to be " +
-						"SPIFFE compliant; a longer trust domain name may impact interoperability",
-				},
-			}),
 			test: func(t *testing.T, c *server.Config) {
-				assert.NotNil(t, c)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "cache_reload_interval is correctly parsed",
+			msg:         "ca_backup with a negative retention returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				c.Server.Experimental.CacheReloadInterval = "1m"
+				c.Server.CABackup = &caBackupConfig{
+					Path:      "/var/backups/spire",
+					Retention: -1,
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, time.Minute, c.CacheReloadInterval)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg:         "invalid cache_reload_interval returns an error",
+			msg: "csr_key_policy is correctly parsed",
+			input: func(c *Config) {
+				c.Server.CSRKeyPolicy = &csrKeyPolicyConfig{
+					AllowedKeyTypes: []string{"rsa", "ecdsa"},
+					MinRSAKeySize:   3072,
+					AllowedCurves:   []string{"P-384"},
+				}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, api.KeyPolicy{
+					AllowedKeyTypes: []string{"rsa", "ecdsa"},
+					MinRSAKeySize:   3072,
+					AllowedCurves:   []string{"P-384"},
+				}, c.CSRKeyPolicy)
+			},
+		},
+		{
+			msg: "csr_key_policy has defaults",
+			input: func(c *Config) {
+				c.Server.CSRKeyPolicy = &csrKeyPolicyConfig{}
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, api.KeyPolicy{
+					MinRSAKeySize: 2048,
+					AllowedCurves: []string{"P-256", "P-384", "P-521"},
+				}, c.CSRKeyPolicy)
+			},
+		},
+		{
+			msg: "csr_key_policy is not enforced by default",
+			input: func(c *Config) {
+				c.Server.CSRKeyPolicy = nil
+			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, api.KeyPolicy{}, c.CSRKeyPolicy)
+			},
+		},
+		{
+			msg:         "csr_key_policy with an unknown key type returns an error",
 			expectError: true,
 			input: func(c *Config) {
-				c.Server.Experimental.CacheReloadInterval = "b"
+				c.Server.CSRKeyPolicy = &csrKeyPolicyConfig{
+					AllowedKeyTypes: []string{"dsa"},
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
 				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "audit_log_enabled is enabled",
+			msg:         "csr_key_policy with an unknown curve returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				c.Server.AuditLogEnabled = true
+				c.Server.CSRKeyPolicy = &csrKeyPolicyConfig{
+					AllowedCurves: []string{"secp256k1"},
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.True(t, c.AuditLogEnabled)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "audit_log_enabled is disabled",
+			msg:         "csr_key_policy with a negative min_rsa_key_size returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				c.Server.AuditLogEnabled = false
+				c.Server.CSRKeyPolicy = &csrKeyPolicyConfig{
+					MinRSAKeySize: -1,
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.False(t, c.AuditLogEnabled)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "admin IDs are set",
+			msg: "entry_admission_webhook is correctly parsed",
 			input: func(c *Config) {
-				c.Server.AdminIDs = []string{
-					"spiffe://example.org/my/admin1",
-					"spiffe://example.org/my/admin2",
+				c.Server.EntryAdmissionWebhook = &entryAdmissionWebhookConfig{
+					URL:           "https://admission.example.org/review",
+					Timeout:       "2s",
+					FailurePolicy: "ignore",
 				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.Equal(t, []spiffeid.ID{
-					spiffeid.RequireFromString("spiffe://example.org/my/admin1"),
-					spiffeid.RequireFromString("spiffe://example.org/my/admin2"),
-				}, c.AdminIDs)
+				require.Equal(t, &entryadmission.Config{
+					URL:      "https://admission.example.org/review",
+					Timeout:  2 * time.Second,
+					FailOpen: true,
+				}, c.EntryAdmissionWebhook)
 			},
 		},
 		{
-			msg: "admin ID does not belong to the trust domain",
+			msg: "entry_admission_webhook fails closed by default",
 			input: func(c *Config) {
-				c.Server.AdminIDs = []string{
-					"spiffe://otherdomain.test/my/admin",
+				c.Server.EntryAdmissionWebhook = &entryAdmissionWebhookConfig{
+					URL: "https://admission.example.org/review",
 				}
 			},
+			test: func(t *testing.T, c *server.Config) {
+				require.Equal(t, &entryadmission.Config{
+					URL: "https://admission.example.org/review",
+				}, c.EntryAdmissionWebhook)
+			},
+		},
+		{
+			msg:         "entry_admission_webhook without https returns an error",
 			expectError: true,
+			input: func(c *Config) {
+				c.Server.EntryAdmissionWebhook = &entryAdmissionWebhookConfig{
+					URL: "http://admission.example.org/review",
+				}
+			},
 			test: func(t *testing.T, c *server.Config) {
 				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "omit_x509svid_uid is unset",
+			msg:         "entry_admission_webhook with an unknown failure policy returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				c.Server.OmitX509SVIDUID = nil
+				c.Server.EntryAdmissionWebhook = &entryAdmissionWebhookConfig{
+					URL:           "https://admission.example.org/review",
+					FailurePolicy: "retry",
+				}
 			},
 			test: func(t *testing.T, c *server.Config) {
-				require.False(t, c.OmitX509SVIDUID)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "omit_x509svid_uid is set to false",
+			msg:         "entry_admission_webhook with a missing CA bundle returns an error",
+			expectError: true,
 			input: func(c *Config) {
-				value := false
-				c.Server.OmitX509SVIDUID = &value
+				c.Server.EntryAdmissionWebhook = &entryAdmissionWebhookConfig{
+					URL:          "https://admission.example.org/review",
+					CABundlePath: "/does/not/exist.pem",
+				}
 			},
-			logOptions: assertLogsContainEntries([]spiretest.LogEntry{
-				{
-					Level:   logrus.WarnLevel,
-					Message: "The omit_x509svid_uid flag is deprecated and will be removed from a future release",
-				},
-			}),
 			test: func(t *testing.T, c *server.Config) {
-				require.False(t, c.OmitX509SVIDUID)
+				require.Nil(t, c)
 			},
 		},
 		{
-			msg: "omit_x509svid_uid is set to true",
+			msg: "record_x509_issuances is set",
 			input: func(c *Config) {
-				value := true
-				c.Server.OmitX509SVIDUID = &value
+				c.Server.RecordX509Issuances = true
 			},
-			logOptions: assertLogsContainEntries([]spiretest.LogEntry{
-				{
-					Level:   logrus.WarnLevel,
-					Message: "The omit_x509svid_uid flag is deprecated and will be removed from a future release",
-				},
-			}),
 			test: func(t *testing.T, c *server.Config) {
-				require.True(t, c.OmitX509SVIDUID)
+				require.True(t, c.RecordX509Issuances)
 			},
 		},
 	}
@@ -1088,6 +1989,20 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectedErr: `federation.federates_with["domain.test"].bundle_endpoint_url must use the HTTPS protocol; URL found: "http://example.org/test"`,
 		},
+		{
+			name: "bundle_endpoint_proxy_url must use the HTTP or HTTPS protocol",
+			applyConf: func(c *Config) {
+				federatesWith := make(map[string]federatesWithConfig)
+				federatesWith["domain.test"] = federatesWithConfig{
+					BundleEndpointURL:      "https://example.org/test",
+					BundleEndpointProxyURL: "socks5://proxy.test:1080",
+				}
+				c.Server.Federation = &federationConfig{
+					FederatesWith: federatesWith,
+				}
+			},
+			expectedErr: `federation.federates_with["domain.test"].bundle_endpoint_proxy_url must use the HTTP or HTTPS protocol; URL found: "socks5://proxy.test:1080"`,
+		},
 	}
 
 	for _, testCase := range testCases {
@@ -1578,3 +2493,10 @@ func webPKIConfigTest(t *testing.T) federatesWithConfig {
 
 	return *webPKIConfig
 }
+
+func writeBundleSignatureKeys(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "bundle_signature_keys.jwks")
+	keys := `{"keys":[{"kty":"EC","kid":"signer","crv":"P-256","x":"JmbCGjDxjN473sleh7q7YotzNMyITzRPU1vtWYmFEVY","y":"Gw2apTG5RsSZQiRz9hL4JnCPDONdCTNz5jbdJhtez0Q"}]}`
+	require.NoError(t, os.WriteFile(path, []byte(keys), 0600))
+	return path
+}
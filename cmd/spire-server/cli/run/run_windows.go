@@ -4,12 +4,14 @@
 package run
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"net"
 
 	util_cmd "github.com/spiffe/spire/cmd/spire-server/util"
 	"github.com/spiffe/spire/pkg/common/namedpipe"
+	"github.com/spiffe/spire/pkg/server"
 )
 
 func (c *serverConfig) addOSFlags(flags *flag.FlagSet) {
@@ -33,3 +35,7 @@ func (c *Config) validateOS() error {
 	}
 	return nil
 }
+
+// drainOnSignal is a no-op, since there is no signal to drain the server
+// with in this platform.
+func drainOnSignal(context.Context, *server.Server) {}
@@ -0,0 +1,55 @@
+package selectorset
+
+import (
+	"context"
+	"flag"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+)
+
+// NewCreateCommand creates a new "create" subcommand for "selectorset" command.
+func NewCreateCommand() cli.Command {
+	return NewCreateCommandWithEnv(common_cli.DefaultEnv)
+}
+
+// NewCreateCommandWithEnv creates a new "create" subcommand for "selectorset"
+// command using the environment specified.
+func NewCreateCommandWithEnv(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(createCommand))
+}
+
+type createCommand struct {
+	name      string
+	selectors common_cli.StringsFlag
+}
+
+func (*createCommand) Name() string {
+	return "selectorset create"
+}
+
+func (*createCommand) Synopsis() string {
+	return "Creates a selector set"
+}
+
+func (c *createCommand) AppendFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.name, "name", "", "Name of the selector set. Entries reference it with the selector selector_set:<name>")
+	fs.Var(&c.selectors, "selector", "A colon-delimited type:value selector. Can be used more than once")
+}
+
+// Run creates the selector set
+func (c *createCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	if err := validateSelectorSetFlags(c.name, c.selectors); err != nil {
+		return err
+	}
+	set, err := serverClient.NewAdminClient().CreateSelectorSet(ctx, &adminv1.SelectorSet{
+		Name:      c.name,
+		Selectors: c.selectors,
+	})
+	if err != nil {
+		return err
+	}
+	return printSelectorSet(env, set)
+}
@@ -0,0 +1,50 @@
+package selectorset
+
+import (
+	"context"
+	"errors"
+	"flag"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+)
+
+// NewDeleteCommand creates a new "delete" subcommand for "selectorset" command.
+func NewDeleteCommand() cli.Command {
+	return NewDeleteCommandWithEnv(common_cli.DefaultEnv)
+}
+
+// NewDeleteCommandWithEnv creates a new "delete" subcommand for "selectorset"
+// command using the environment specified.
+func NewDeleteCommandWithEnv(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(deleteCommand))
+}
+
+type deleteCommand struct {
+	name string
+}
+
+func (*deleteCommand) Name() string {
+	return "selectorset delete"
+}
+
+func (*deleteCommand) Synopsis() string {
+	return "Deletes a selector set that no registration entry references"
+}
+
+func (c *deleteCommand) AppendFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.name, "name", "", "Name of the selector set")
+}
+
+// Run deletes the selector set
+func (c *deleteCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	if c.name == "" {
+		return errors.New("a selector set name is required")
+	}
+	if err := serverClient.NewAdminClient().DeleteSelectorSet(ctx, &adminv1.DeleteSelectorSetRequest{Name: c.name}); err != nil {
+		return err
+	}
+	return env.Printf("Selector set %q deleted\n", c.name)
+}
@@ -0,0 +1,61 @@
+package selectorset
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+)
+
+// NewListCommand creates a new "list" subcommand for "selectorset" command.
+func NewListCommand() cli.Command {
+	return NewListCommandWithEnv(common_cli.DefaultEnv)
+}
+
+// NewListCommandWithEnv creates a new "list" subcommand for "selectorset"
+// command using the environment specified.
+func NewListCommandWithEnv(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(listCommand))
+}
+
+type listCommand struct{}
+
+func (*listCommand) Name() string {
+	return "selectorset list"
+}
+
+func (*listCommand) Synopsis() string {
+	return "Lists the selector sets"
+}
+
+func (*listCommand) AppendFlags(*flag.FlagSet) {}
+
+// Run lists the selector sets
+func (c *listCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	resp, err := serverClient.NewAdminClient().ListSelectorSets(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(resp.SelectorSets) == 0 {
+		return env.Printf("No selector sets found\n")
+	}
+
+	msg := fmt.Sprintf("Found %d ", len(resp.SelectorSets))
+	msg = util.Pluralizer(msg, "selector set", "selector sets", len(resp.SelectorSets))
+	if err := env.Printf(msg + ":\n\n"); err != nil {
+		return err
+	}
+	for _, set := range resp.SelectorSets {
+		if err := printSelectorSet(env, set); err != nil {
+			return err
+		}
+		if err := env.Println(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
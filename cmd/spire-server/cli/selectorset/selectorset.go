@@ -0,0 +1,29 @@
+package selectorset
+
+import (
+	"errors"
+	"strings"
+
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+)
+
+func printSelectorSet(env *common_cli.Env, set *adminv1.SelectorSet) error {
+	if err := env.Printf("Name      : %s\n", set.Name); err != nil {
+		return err
+	}
+	if len(set.Selectors) == 0 {
+		return env.Printf("Selectors : (none)\n")
+	}
+	return env.Printf("Selectors : %s\n", strings.Join(set.Selectors, "\n            "))
+}
+
+func validateSelectorSetFlags(name string, selectors []string) error {
+	if name == "" {
+		return errors.New("a selector set name is required")
+	}
+	if len(selectors) == 0 {
+		return errors.New("at least one selector is required")
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package selectorset
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/spire/cmd/spire-server/cli/common"
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListHelp(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	cmd := NewListCommandWithEnv(&common_cli.Env{
+		Stdin:  new(bytes.Buffer),
+		Stdout: new(bytes.Buffer),
+		Stderr: stderr,
+	})
+	assert.Equal(t, "flag: help requested", cmd.Help())
+	assert.Equal(t, "Usage of selectorset list:"+common.AddrUsage, stderr.String())
+}
+
+func TestList(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		sets   []*adminv1.SelectorSet
+		stdout string
+	}{
+		{
+			name:   "no selector sets",
+			stdout: "No selector sets found\n",
+		},
+		{
+			name: "selector sets",
+			sets: []*adminv1.SelectorSet{
+				{Name: "db", Selectors: []string{"k8s:ns:db", "k8s:sa:postgres"}},
+				{Name: "web", Selectors: []string{"unix:uid:1000"}},
+			},
+			stdout: `Found 2 selector sets:
+
+Name      : db
+Selectors : k8s:ns:db
+            k8s:sa:postgres
+
+Name      : web
+Selectors : unix:uid:1000
+
+`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			addr := startServer(t, map[string]adminapi.Handler{
+				"ListSelectorSets": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+					return &adminv1.ListSelectorSetsResponse{SelectorSets: tt.sets}, nil
+				},
+			})
+
+			code, stdout, stderr := runCommand(NewListCommandWithEnv, common.AddrArg, common.GetAddr(addr))
+			assert.Equal(t, 0, code, stderr)
+			assert.Equal(t, tt.stdout, stdout)
+		})
+	}
+}
+
+func TestShow(t *testing.T) {
+	var gotReq *adminv1.GetSelectorSetRequest
+	addr := startServer(t, map[string]adminapi.Handler{
+		"GetSelectorSet": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+			gotReq = new(adminv1.GetSelectorSetRequest)
+			if err := decode(gotReq); err != nil {
+				return nil, err
+			}
+			if gotReq.Name != "db" {
+				return nil, status.Error(codes.NotFound, "selector set not found")
+			}
+			return &adminv1.SelectorSet{Name: "db", Selectors: []string{"k8s:ns:db"}}, nil
+		},
+	})
+
+	code, stdout, stderr := runCommand(NewShowCommandWithEnv, common.AddrArg, common.GetAddr(addr), "-name", "db")
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, &adminv1.GetSelectorSetRequest{Name: "db"}, gotReq)
+	assert.Equal(t, "Name      : db\nSelectors : k8s:ns:db\n", stdout)
+
+	code, _, stderr = runCommand(NewShowCommandWithEnv, common.AddrArg, common.GetAddr(addr), "-name", "web")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "Error: rpc error: code = NotFound desc = selector set not found\n", stderr)
+
+	code, _, stderr = runCommand(NewShowCommandWithEnv, common.AddrArg, common.GetAddr(addr))
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "Error: a selector set name is required\n", stderr)
+}
+
+func TestCreateAndUpdate(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		method     string
+		newCommand func(*common_cli.Env) cli.Command
+	}{
+		{name: "create", method: "CreateSelectorSet", newCommand: NewCreateCommandWithEnv},
+		{name: "update", method: "UpdateSelectorSet", newCommand: NewUpdateCommandWithEnv},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var gotReq *adminv1.SelectorSet
+			addr := startServer(t, map[string]adminapi.Handler{
+				tt.method: func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+					gotReq = new(adminv1.SelectorSet)
+					if err := decode(gotReq); err != nil {
+						return nil, err
+					}
+					return gotReq, nil
+				},
+			})
+
+			code, stdout, stderr := runCommand(tt.newCommand, common.AddrArg, common.GetAddr(addr),
+				"-name", "db", "-selector", "k8s:ns:db", "-selector", "k8s:sa:postgres")
+			assert.Equal(t, 0, code, stderr)
+			assert.Equal(t, &adminv1.SelectorSet{Name: "db", Selectors: []string{"k8s:ns:db", "k8s:sa:postgres"}}, gotReq)
+			assert.Equal(t, "Name      : db\nSelectors : k8s:ns:db\n            k8s:sa:postgres\n", stdout)
+
+			code, _, stderr = runCommand(tt.newCommand, common.AddrArg, common.GetAddr(addr), "-selector", "k8s:ns:db")
+			assert.Equal(t, 1, code)
+			assert.Equal(t, "Error: a selector set name is required\n", stderr)
+
+			code, _, stderr = runCommand(tt.newCommand, common.AddrArg, common.GetAddr(addr), "-name", "db")
+			assert.Equal(t, 1, code)
+			assert.Equal(t, "Error: at least one selector is required\n", stderr)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	addr := startServer(t, map[string]adminapi.Handler{
+		"DeleteSelectorSet": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+			req := new(adminv1.DeleteSelectorSetRequest)
+			if err := decode(req); err != nil {
+				return nil, err
+			}
+			if req.Name == "in-use" {
+				return nil, status.Error(codes.FailedPrecondition, "selector set is in use")
+			}
+			return nil, nil
+		},
+	})
+
+	code, stdout, stderr := runCommand(NewDeleteCommandWithEnv, common.AddrArg, common.GetAddr(addr), "-name", "db")
+	assert.Equal(t, 0, code, stderr)
+	assert.Equal(t, "Selector set \"db\" deleted\n", stdout)
+
+	code, _, stderr = runCommand(NewDeleteCommandWithEnv, common.AddrArg, common.GetAddr(addr), "-name", "in-use")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "Error: rpc error: code = FailedPrecondition desc = selector set is in use\n", stderr)
+}
+
+func startServer(t *testing.T, methods map[string]adminapi.Handler) net.Addr {
+	return spiretest.StartGRPCServer(t, func(s *grpc.Server) {
+		adminapi.Register(s, adminapi.Service{
+			Name:    adminv1.ServiceName,
+			Methods: methods,
+		})
+	})
+}
+
+func runCommand(newCommand func(*common_cli.Env) cli.Command, args ...string) (int, string, string) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd := newCommand(&common_cli.Env{
+		Stdin:  new(bytes.Buffer),
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	code := cmd.Run(args)
+	return code, stdout.String(), stderr.String()
+}
@@ -0,0 +1,51 @@
+package selectorset
+
+import (
+	"context"
+	"errors"
+	"flag"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+)
+
+// NewShowCommand creates a new "show" subcommand for "selectorset" command.
+func NewShowCommand() cli.Command {
+	return NewShowCommandWithEnv(common_cli.DefaultEnv)
+}
+
+// NewShowCommandWithEnv creates a new "show" subcommand for "selectorset"
+// command using the environment specified.
+func NewShowCommandWithEnv(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(showCommand))
+}
+
+type showCommand struct {
+	name string
+}
+
+func (*showCommand) Name() string {
+	return "selectorset show"
+}
+
+func (*showCommand) Synopsis() string {
+	return "Shows a selector set"
+}
+
+func (c *showCommand) AppendFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.name, "name", "", "Name of the selector set")
+}
+
+// Run shows the selector set with the given name
+func (c *showCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	if c.name == "" {
+		return errors.New("a selector set name is required")
+	}
+	set, err := serverClient.NewAdminClient().GetSelectorSet(ctx, &adminv1.GetSelectorSetRequest{Name: c.name})
+	if err != nil {
+		return err
+	}
+	return printSelectorSet(env, set)
+}
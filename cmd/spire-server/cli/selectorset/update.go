@@ -0,0 +1,55 @@
+package selectorset
+
+import (
+	"context"
+	"flag"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+)
+
+// NewUpdateCommand creates a new "update" subcommand for "selectorset" command.
+func NewUpdateCommand() cli.Command {
+	return NewUpdateCommandWithEnv(common_cli.DefaultEnv)
+}
+
+// NewUpdateCommandWithEnv creates a new "update" subcommand for "selectorset"
+// command using the environment specified.
+func NewUpdateCommandWithEnv(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(updateCommand))
+}
+
+type updateCommand struct {
+	name      string
+	selectors common_cli.StringsFlag
+}
+
+func (*updateCommand) Name() string {
+	return "selectorset update"
+}
+
+func (*updateCommand) Synopsis() string {
+	return "Replaces the selectors of a selector set"
+}
+
+func (c *updateCommand) AppendFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.name, "name", "", "Name of the selector set")
+	fs.Var(&c.selectors, "selector", "A colon-delimited type:value selector. Can be used more than once")
+}
+
+// Run replaces the selectors of the selector set
+func (c *updateCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	if err := validateSelectorSetFlags(c.name, c.selectors); err != nil {
+		return err
+	}
+	set, err := serverClient.NewAdminClient().UpdateSelectorSet(ctx, &adminv1.SelectorSet{
+		Name:      c.name,
+		Selectors: c.selectors,
+	})
+	if err != nil {
+		return err
+	}
+	return printSelectorSet(env, set)
+}
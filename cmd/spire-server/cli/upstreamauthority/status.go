@@ -0,0 +1,91 @@
+package upstreamauthority
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+)
+
+// NewStatusCommand creates a new "status" subcommand for "upstreamauthority" command.
+func NewStatusCommand() cli.Command {
+	return NewStatusCommandWithEnv(common_cli.DefaultEnv)
+}
+
+// NewStatusCommandWithEnv creates a new "status" subcommand for
+// "upstreamauthority" command using the environment specified.
+func NewStatusCommandWithEnv(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(statusCommand))
+}
+
+type statusCommand struct{}
+
+func (*statusCommand) Name() string {
+	return "upstreamauthority status"
+}
+
+func (*statusCommand) Synopsis() string {
+	return "Prints the status of the upstream authority"
+}
+
+func (*statusCommand) AppendFlags(*flag.FlagSet) {}
+
+// Run prints the status of the upstream authority
+func (c *statusCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	status, err := serverClient.NewAdminClient().GetUpstreamAuthorityStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := env.Printf("Upstream authority : %s\n", status.PluginName); err != nil {
+		return err
+	}
+
+	lastMint := "never since the server started"
+	if status.LastMint != nil {
+		lastMint = status.LastMint.Format(time.RFC3339)
+	}
+	if err := env.Printf("Last mint          : %s\n", lastMint); err != nil {
+		return err
+	}
+
+	if status.X509CA == nil {
+		if err := env.Println("X509 CA            : none active"); err != nil {
+			return err
+		}
+	} else {
+		if err := env.Printf("X509 CA            : %s\n", status.X509CA.Subject); err != nil {
+			return err
+		}
+		if err := env.Printf("X509 CA expires at : %s\n", status.X509CA.NotAfter.Format(time.RFC3339)); err != nil {
+			return err
+		}
+	}
+
+	if err := env.Printf("\nUpstream chain (%d certificates)\n", len(status.UpstreamChain)); err != nil {
+		return err
+	}
+	for _, cert := range status.UpstreamChain {
+		if err := printCertificate(env, cert); err != nil {
+			return err
+		}
+	}
+
+	if err := env.Printf("\nRecent errors (%d)\n", len(status.RecentErrors)); err != nil {
+		return err
+	}
+	for _, upstreamErr := range status.RecentErrors {
+		if err := env.Printf("%s: %s\n", upstreamErr.Time.Format(time.RFC3339), upstreamErr.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printCertificate(env *common_cli.Env, cert *adminv1.Certificate) error {
+	return env.Printf("\nSubject            : %s\nIssuer             : %s\nExpires at         : %s\n", cert.Subject, cert.Issuer, cert.NotAfter.Format(time.RFC3339))
+}
@@ -0,0 +1,138 @@
+package upstreamauthority
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire/cmd/spire-server/cli/common"
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatusHelp(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	cmd := NewStatusCommandWithEnv(&common_cli.Env{
+		Stdin:  new(bytes.Buffer),
+		Stdout: new(bytes.Buffer),
+		Stderr: stderr,
+	})
+	assert.Equal(t, "flag: help requested", cmd.Help())
+	assert.Equal(t, "Usage of upstreamauthority status:"+common.AddrUsage, stderr.String())
+}
+
+func TestStatus(t *testing.T) {
+	lastMint := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name      string
+		status    *adminv1.UpstreamAuthorityStatus
+		serverErr error
+		code      int
+		stdout    string
+		stderr    string
+	}{
+		{
+			name: "X509 CA minted",
+			status: &adminv1.UpstreamAuthorityStatus{
+				PluginName: "disk",
+				X509CA: &adminv1.Certificate{
+					Subject:  "O=SPIRE",
+					Issuer:   "CN=upstream",
+					NotAfter: lastMint.Add(24 * time.Hour),
+				},
+				UpstreamChain: []*adminv1.Certificate{
+					{
+						Subject:  "O=SPIRE",
+						Issuer:   "CN=upstream",
+						NotAfter: lastMint.Add(24 * time.Hour),
+					},
+					{
+						Subject:  "CN=upstream",
+						Issuer:   "CN=upstream",
+						NotAfter: lastMint.Add(365 * 24 * time.Hour),
+					},
+				},
+				LastMint: &lastMint,
+				RecentErrors: []*adminv1.UpstreamAuthorityError{
+					{Time: lastMint.Add(-time.Minute), Message: "upstream authority is unavailable"},
+				},
+			},
+			stdout: `Upstream authority : disk
+Last mint          : 2022-01-01T12:00:00Z
+X509 CA            : O=SPIRE
+X509 CA expires at : 2022-01-02T12:00:00Z
+
+Upstream chain (2 certificates)
+
+Subject            : O=SPIRE
+Issuer             : CN=upstream
+Expires at         : 2022-01-02T12:00:00Z
+
+Subject            : CN=upstream
+Issuer             : CN=upstream
+Expires at         : 2023-01-01T12:00:00Z
+
+Recent errors (1)
+2022-01-01T11:59:00Z: upstream authority is unavailable
+`,
+		},
+		{
+			name: "no X509 CA minted yet",
+			status: &adminv1.UpstreamAuthorityStatus{
+				PluginName: "disk",
+			},
+			stdout: `Upstream authority : disk
+Last mint          : never since the server started
+X509 CA            : none active
+
+Upstream chain (0 certificates)
+
+Recent errors (0)
+`,
+		},
+		{
+			name:      "not configured",
+			serverErr: status.Error(codes.NotFound, "no upstream authority is configured"),
+			code:      1,
+			stderr:    "Error: rpc error: code = NotFound desc = no upstream authority is configured\n",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			addr := spiretest.StartGRPCServer(t, func(s *grpc.Server) {
+				adminapi.Register(s, adminapi.Service{
+					Name: adminv1.ServiceName,
+					Methods: map[string]adminapi.Handler{
+						"GetUpstreamAuthorityStatus": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+							if tt.serverErr != nil {
+								return nil, tt.serverErr
+							}
+							return tt.status, nil
+						},
+					},
+				})
+			})
+
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			cmd := NewStatusCommandWithEnv(&common_cli.Env{
+				Stdin:  new(bytes.Buffer),
+				Stdout: stdout,
+				Stderr: stderr,
+			})
+
+			code := cmd.Run([]string{common.AddrArg, common.GetAddr(addr)})
+			assert.Equal(t, tt.code, code)
+			assert.Equal(t, tt.stdout, stdout.String())
+			assert.Equal(t, tt.stderr, stderr.String())
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package x509
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/mitchellh/cli"
+	"github.com/spiffe/spire/cmd/spire-server/util"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+)
+
+// issuancesPageSize is the number of issuance records fetched per request
+const issuancesPageSize = 500
+
+func NewIssuancesCommand() cli.Command {
+	return newIssuancesCommand(common_cli.DefaultEnv)
+}
+
+func newIssuancesCommand(env *common_cli.Env) cli.Command {
+	return util.AdaptCommand(env, new(issuancesCommand))
+}
+
+type issuancesCommand struct {
+	serialNumber string
+	spiffeID     string
+	entryID      string
+}
+
+func (c *issuancesCommand) Name() string {
+	return "x509 issuances"
+}
+
+func (c *issuancesCommand) Synopsis() string {
+	return "Lists the recorded X509 certificates signed by the server CA"
+}
+
+func (c *issuancesCommand) AppendFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.serialNumber, "serialNumber", "", "Only list the certificate with the given serial number, in decimal")
+	fs.StringVar(&c.spiffeID, "spiffeID", "", "Only list the certificates issued for the given SPIFFE ID")
+	fs.StringVar(&c.entryID, "entryID", "", "Only list the certificates issued for the given registration entry")
+}
+
+func (c *issuancesCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
+	adminClient := serverClient.NewAdminClient()
+
+	var records []*adminv1.IssuanceRecord
+	pageToken := ""
+	for {
+		resp, err := adminClient.ListIssuanceRecords(ctx, &adminv1.ListIssuanceRecordsRequest{
+			BySerialNumber: c.serialNumber,
+			BySpiffeID:     c.spiffeID,
+			ByEntryID:      c.entryID,
+			PageSize:       issuancesPageSize,
+			PageToken:      pageToken,
+		})
+		if err != nil {
+			return err
+		}
+		records = append(records, resp.Records...)
+		if pageToken = resp.NextPageToken; pageToken == "" || len(resp.Records) == 0 {
+			break
+		}
+	}
+
+	if len(records) == 0 {
+		return env.Printf("No issuance records found\n")
+	}
+
+	msg := fmt.Sprintf("Found %d ", len(records))
+	msg = util.Pluralizer(msg, "issuance record", "issuance records", len(records))
+	env.Printf(msg + ":\n\n")
+
+	for _, record := range records {
+		if err := env.Printf("Serial number     : %s\n", record.SerialNumber); err != nil {
+			return err
+		}
+		if err := env.Printf("SPIFFE ID         : %s\n", record.SpiffeID); err != nil {
+			return err
+		}
+		if record.EntryID != "" {
+			if err := env.Printf("Entry ID          : %s\n", record.EntryID); err != nil {
+				return err
+			}
+		}
+		if err := env.Printf("Expires at        : %s\n", record.ExpiresAt); err != nil {
+			return err
+		}
+		if err := env.Println(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package x509
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire/cmd/spire-server/cli/common"
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	common_cli "github.com/spiffe/spire/pkg/common/cli"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIssuancesHelp(t *testing.T) {
+	stderr := new(bytes.Buffer)
+	cmd := newIssuancesCommand(&common_cli.Env{
+		Stdin:  new(bytes.Buffer),
+		Stdout: new(bytes.Buffer),
+		Stderr: stderr,
+	})
+	assert.Equal(t, "flag: help requested", cmd.Help())
+	assert.Equal(t, `Usage of x509 issuances:
+  -entryID string
+    	Only list the certificates issued for the given registration entry
+  -serialNumber string
+    	Only list the certificate with the given serial number, in decimal`+common.AddrUsage+
+		`  -spiffeID string
+    	Only list the certificates issued for the given SPIFFE ID
+`, stderr.String())
+}
+
+func TestIssuancesRun(t *testing.T) {
+	expiresAt := time.Unix(1000, 0).UTC()
+	records := []*adminv1.IssuanceRecord{
+		{SerialNumber: "1", SpiffeID: "spiffe://domain.test/workload", EntryID: "entry1", ExpiresAt: expiresAt},
+		{SerialNumber: "2", SpiffeID: "spiffe://domain.test/downstream", ExpiresAt: expiresAt},
+	}
+
+	for _, tt := range []struct {
+		name          string
+		args          []string
+		pages         [][]*adminv1.IssuanceRecord
+		serverErr     error
+		code          int
+		stdout        string
+		stderr        string
+		expectRequest *adminv1.ListIssuanceRecordsRequest
+	}{
+		{
+			name:  "success across pages",
+			pages: [][]*adminv1.IssuanceRecord{records[:1], records[1:]},
+			stdout: `Found 2 issuance records:
+
+Serial number     : 1
+SPIFFE ID         : spiffe://domain.test/workload
+Entry ID          : entry1
+Expires at        : 1970-01-01 00:16:40 +0000 UTC
+
+Serial number     : 2
+SPIFFE ID         : spiffe://domain.test/downstream
+Expires at        : 1970-01-01 00:16:40 +0000 UTC
+
+`,
+			expectRequest: &adminv1.ListIssuanceRecordsRequest{PageSize: issuancesPageSize, PageToken: "1"},
+		},
+		{
+			name:   "filters",
+			args:   []string{"-serialNumber", "1", "-spiffeID", "spiffe://domain.test/workload", "-entryID", "entry1"},
+			stdout: "No issuance records found\n",
+			expectRequest: &adminv1.ListIssuanceRecordsRequest{
+				BySerialNumber: "1",
+				BySpiffeID:     "spiffe://domain.test/workload",
+				ByEntryID:      "entry1",
+				PageSize:       issuancesPageSize,
+			},
+		},
+		{
+			name:          "server error",
+			serverErr:     status.Error(codes.InvalidArgument, "invalid serial number"),
+			code:          1,
+			stderr:        "Error: rpc error: code = InvalidArgument desc = invalid serial number\n",
+			expectRequest: &adminv1.ListIssuanceRecordsRequest{PageSize: issuancesPageSize},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRequest *adminv1.ListIssuanceRecordsRequest
+			addr := spiretest.StartGRPCServer(t, func(s *grpc.Server) {
+				adminapi.Register(s, adminapi.Service{
+					Name: adminv1.ServiceName,
+					Methods: map[string]adminapi.Handler{
+						"ListIssuanceRecords": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+							req := new(adminv1.ListIssuanceRecordsRequest)
+							if err := decode(req); err != nil {
+								return nil, err
+							}
+							gotRequest = req
+							if tt.serverErr != nil {
+								return nil, tt.serverErr
+							}
+							resp := new(adminv1.ListIssuanceRecordsResponse)
+							page := 0
+							if req.PageToken != "" {
+								page = 1
+							}
+							if page < len(tt.pages) {
+								resp.Records = tt.pages[page]
+								if page+1 < len(tt.pages) {
+									resp.NextPageToken = "1"
+								}
+							}
+							return resp, nil
+						},
+					},
+				})
+			})
+
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			cmd := newIssuancesCommand(&common_cli.Env{
+				Stdin:  new(bytes.Buffer),
+				Stdout: stdout,
+				Stderr: stderr,
+			})
+
+			code := cmd.Run(append([]string{common.AddrArg, common.GetAddr(addr)}, tt.args...))
+			assert.Equal(t, tt.code, code)
+			assert.Equal(t, tt.stdout, stdout.String())
+			assert.Equal(t, tt.stderr, stderr.String())
+			require.Equal(t, tt.expectRequest, gotRequest)
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
@@ -20,11 +21,20 @@ import (
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
 	svidv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/svid/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"github.com/spiffe/spire/cmd/spire-server/util"
 	common_cli "github.com/spiffe/spire/pkg/common/cli"
 )
 
-type generateKeyFunc func() (crypto.Signer, error)
+const (
+	keyTypeECP256  = "ec-p256"
+	keyTypeRSA2048 = "rsa-2048"
+
+	keyFormatPKCS1 = "pkcs1"
+	keyFormatPKCS8 = "pkcs8"
+)
+
+type generateKeyFunc func(keyType string) (crypto.Signer, error)
 
 func NewMintCommand() cli.Command {
 	return newMintCommand(common_cli.DefaultEnv, nil)
@@ -32,9 +42,7 @@ func NewMintCommand() cli.Command {
 
 func newMintCommand(env *common_cli.Env, generateKey generateKeyFunc) cli.Command {
 	if generateKey == nil {
-		generateKey = func() (crypto.Signer, error) {
-			return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
-		}
+		generateKey = generateKeyForType
 	}
 	return util.AdaptCommand(env, &mintCommand{
 		generateKey: generateKey,
@@ -44,10 +52,14 @@ func newMintCommand(env *common_cli.Env, generateKey generateKeyFunc) cli.Comman
 type mintCommand struct {
 	generateKey generateKeyFunc
 
-	spiffeID string
-	ttl      time.Duration
-	dnsNames common_cli.StringsFlag
-	write    string
+	spiffeID         string
+	ttl              time.Duration
+	dnsNames         common_cli.StringsFlag
+	write            string
+	keyType          string
+	keyFormat        string
+	splitChain       bool
+	includeFederated bool
 }
 
 func (c *mintCommand) Name() string {
@@ -63,6 +75,10 @@ func (c *mintCommand) AppendFlags(fs *flag.FlagSet) {
 	fs.DurationVar(&c.ttl, "ttl", 0, "TTL of the X509-SVID")
 	fs.Var(&c.dnsNames, "dns", "DNS name that will be included in SVID. Can be used more than once.")
 	fs.StringVar(&c.write, "write", "", "Directory to write output to instead of stdout")
+	fs.StringVar(&c.keyType, "keyType", keyTypeECP256, "Type of the private key <ec-p256|rsa-2048>")
+	fs.StringVar(&c.keyFormat, "keyFormat", keyFormatPKCS8, "Encoding of the private key <pkcs8|pkcs1>. The pkcs1 format requires an RSA key")
+	fs.BoolVar(&c.splitChain, "splitChain", false, "Output the intermediate certificates separately from the X509-SVID instead of appending them to it")
+	fs.BoolVar(&c.includeFederated, "includeFederated", false, "Include the X.509 authorities of federated bundles in the output")
 }
 
 func (c *mintCommand) Run(ctx context.Context, env *common_cli.Env, serverClient util.ServerClient) error {
@@ -75,7 +91,22 @@ func (c *mintCommand) Run(ctx context.Context, env *common_cli.Env, serverClient
 		return err
 	}
 
-	key, err := c.generateKey()
+	switch c.keyType {
+	case keyTypeECP256, keyTypeRSA2048:
+	default:
+		return fmt.Errorf("unsupported key type %q", c.keyType)
+	}
+	switch c.keyFormat {
+	case keyFormatPKCS8:
+	case keyFormatPKCS1:
+		if c.keyType != keyTypeRSA2048 {
+			return fmt.Errorf("key format %q requires an RSA key type", c.keyFormat)
+		}
+	default:
+		return fmt.Errorf("unsupported key format %q", c.keyFormat)
+	}
+
+	key, err := c.generateKey(c.keyType)
 	if err != nil {
 		return fmt.Errorf("unable to generate key: %w", err)
 	}
@@ -111,70 +142,152 @@ func (c *mintCommand) Run(ctx context.Context, env *common_cli.Env, serverClient
 		return errors.New("server response missing X509 Authorities")
 	}
 
+	var federatedAuthorities []*types.X509Certificate
+	if c.includeFederated {
+		federated, err := bundleClient.ListFederatedBundles(ctx, &bundlev1.ListFederatedBundlesRequest{})
+		if err != nil {
+			return fmt.Errorf("unable to list federated bundles: %w", err)
+		}
+		for _, bundle := range federated.Bundles {
+			federatedAuthorities = append(federatedAuthorities, bundle.X509Authorities...)
+		}
+	}
+
 	eol := time.Unix(resp.Svid.ExpiresAt, 0)
 	if time.Until(eol) < c.ttl {
 		env.ErrPrintf("X509-SVID lifetime was capped shorter than specified ttl; expires %q\n", eol.UTC().Format(time.RFC3339))
 	}
 
-	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	keyPEM, err := encodeKey(key, c.keyFormat)
 	if err != nil {
 		return err
 	}
 
-	svidPEM := new(bytes.Buffer)
-	for _, certDER := range resp.Svid.CertChain {
-		_ = pem.Encode(svidPEM, &pem.Block{
-			Type:  "CERTIFICATE",
-			Bytes: certDER,
-		})
+	svidChain := resp.Svid.CertChain
+	var intermediates [][]byte
+	if c.splitChain {
+		svidChain, intermediates = resp.Svid.CertChain[:1], resp.Svid.CertChain[1:]
 	}
 
-	keyPEM := new(bytes.Buffer)
-	_ = pem.Encode(keyPEM, &pem.Block{
-		Type:  "PRIVATE KEY",
-		Bytes: keyBytes,
-	})
-
-	bundlePEM := new(bytes.Buffer)
-	for _, rootCA := range ca.X509Authorities {
-		_ = pem.Encode(bundlePEM, &pem.Block{
-			Type:  "CERTIFICATE",
-			Bytes: rootCA.Asn1,
-		})
-	}
+	svidPEM := encodeCertificates(svidChain)
+	chainPEM := encodeCertificates(intermediates)
+	bundlePEM := encodeCertificates(asn1FromAuthorities(ca.X509Authorities))
+	federatedPEM := encodeCertificates(asn1FromAuthorities(federatedAuthorities))
 
 	if c.write == "" {
-		if err := env.Printf("X509-SVID:\n%s\n", svidPEM.String()); err != nil {
+		if err := env.Printf("X509-SVID:\n%s\n", svidPEM); err != nil {
 			return err
 		}
-		if err := env.Printf("Private key:\n%s\n", keyPEM.String()); err != nil {
+		if c.splitChain {
+			if err := env.Printf("Intermediates:\n%s\n", chainPEM); err != nil {
+				return err
+			}
+		}
+		if err := env.Printf("Private key:\n%s\n", keyPEM); err != nil {
+			return err
+		}
+		if err := env.Printf("Root CAs:\n%s\n", bundlePEM); err != nil {
 			return err
 		}
-		return env.Printf("Root CAs:\n%s\n", bundlePEM.String())
+		if c.includeFederated {
+			return env.Printf("Federated CAs:\n%s\n", federatedPEM)
+		}
+		return nil
 	}
 
 	svidPath := env.JoinPath(c.write, "svid.pem")
+	chainPath := env.JoinPath(c.write, "chain.pem")
 	keyPath := env.JoinPath(c.write, "key.pem")
 	bundlePath := env.JoinPath(c.write, "bundle.pem")
+	federatedPath := env.JoinPath(c.write, "federated_bundle.pem")
 
-	if err := os.WriteFile(svidPath, svidPEM.Bytes(), 0644); err != nil { // nolint: gosec // expected permission
+	if err := os.WriteFile(svidPath, svidPEM, 0644); err != nil { // nolint: gosec // expected permission
 		return fmt.Errorf("unable to write SVID: %w", err)
 	}
 	if err := env.Printf("X509-SVID written to %s\n", svidPath); err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(keyPath, keyPEM.Bytes(), 0600); err != nil {
+	if c.splitChain {
+		if err := os.WriteFile(chainPath, chainPEM, 0644); err != nil { // nolint: gosec // expected permission
+			return fmt.Errorf("unable to write intermediates: %w", err)
+		}
+		if err := env.Printf("Intermediates written to %s\n", chainPath); err != nil {
+			return err
+		}
+	}
+
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
 		return fmt.Errorf("unable to write key: %w", err)
 	}
 	if err := env.Printf("Private key written to %s\n", keyPath); err != nil {
 		return err
 	}
 
-	if err := os.WriteFile(bundlePath, bundlePEM.Bytes(), 0644); err != nil { // nolint: gosec // expected permission
+	if err := os.WriteFile(bundlePath, bundlePEM, 0644); err != nil { // nolint: gosec // expected permission
 		return fmt.Errorf("unable to write bundle: %w", err)
 	}
-	return env.Printf("Root CAs written to %s\n", bundlePath)
+	if err := env.Printf("Root CAs written to %s\n", bundlePath); err != nil {
+		return err
+	}
+
+	if c.includeFederated {
+		if err := os.WriteFile(federatedPath, federatedPEM, 0644); err != nil { // nolint: gosec // expected permission
+			return fmt.Errorf("unable to write federated bundle: %w", err)
+		}
+		return env.Printf("Federated CAs written to %s\n", federatedPath)
+	}
+	return nil
+}
+
+func generateKeyForType(keyType string) (crypto.Signer, error) {
+	switch keyType {
+	case keyTypeRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	default:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	}
+}
+
+func encodeKey(key crypto.Signer, keyFormat string) ([]byte, error) {
+	if keyFormat == keyFormatPKCS1 {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("key format %q requires an RSA key", keyFormat)
+		}
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+		}), nil
+	}
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: keyBytes,
+	}), nil
+}
+
+func encodeCertificates(certsDER [][]byte) []byte {
+	certsPEM := new(bytes.Buffer)
+	for _, certDER := range certsDER {
+		_ = pem.Encode(certsPEM, &pem.Block{
+			Type:  "CERTIFICATE",
+			Bytes: certDER,
+		})
+	}
+	return certsPEM.Bytes()
+}
+
+func asn1FromAuthorities(authorities []*types.X509Certificate) [][]byte {
+	certsDER := make([][]byte, 0, len(authorities))
+	for _, authority := range authorities {
+		certsDER = append(certsDER, authority.Asn1)
+	}
+	return certsDER
 }
 
 // ttlToSeconds returns the number of seconds in a duration, rounded up to
@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
@@ -33,9 +34,17 @@ import (
 var (
 	expectedUsage = `Usage of x509 mint:
   -dns value
-    	DNS name that will be included in SVID. Can be used more than once.` + common.AddrUsage +
+    	DNS name that will be included in SVID. Can be used more than once.
+  -includeFederated
+    	Include the X.509 authorities of federated bundles in the output
+  -keyFormat string
+    	Encoding of the private key <pkcs8|pkcs1>. The pkcs1 format requires an RSA key (default "pkcs8")
+  -keyType string
+    	Type of the private key <ec-p256|rsa-2048> (default "ec-p256")` + common.AddrUsage +
 		`  -spiffeID string
     	SPIFFE ID of the X509-SVID
+  -splitChain
+    	Output the intermediate certificates separately from the X509-SVID instead of appending them to it
   -ttl duration
     	TTL of the X509-SVID
   -write string
@@ -258,7 +267,7 @@ func TestMintRun(t *testing.T) {
 				Stdout:  stdout,
 				Stderr:  stderr,
 				BaseDir: dir,
-			}, func() (crypto.Signer, error) {
+			}, func(string) (crypto.Signer, error) {
 				if testCase.generateErr != nil {
 					return nil, testCase.generateErr
 				}
@@ -328,6 +337,167 @@ Root CAs:
 	}
 }
 
+func TestMintRunWithOptions(t *testing.T) {
+	rootDER, intermediateDER, leafDER := createTestChain(t)
+	federatedDER := createTestCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(4),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}, nil)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	server := &fakeSVIDServer{
+		resp: &svidv1.MintX509SVIDResponse{
+			Svid: &types.X509SVID{
+				CertChain: [][]byte{leafDER, intermediateDER},
+				ExpiresAt: time.Now().Add(time.Hour).Unix(),
+			},
+		},
+		bundle: &types.Bundle{
+			X509Authorities: []*types.X509Certificate{{Asn1: rootDER}},
+		},
+		federatedBundles: []*types.Bundle{
+			{
+				TrustDomain:     "federated.test",
+				X509Authorities: []*types.X509Certificate{{Asn1: federatedDER}},
+			},
+		},
+	}
+	addr := spiretest.StartGRPCServer(t, func(s *grpc.Server) {
+		svidv1.RegisterSVIDServer(s, server)
+		bundlev1.RegisterBundleServer(s, server)
+	})
+
+	pemOf := func(certsDER ...[]byte) string {
+		return string(encodeCertificates(certsDER))
+	}
+	rsaKeyPEM := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(rsaKey),
+	}))
+
+	for _, tt := range []struct {
+		name        string
+		args        []string
+		expectFiles map[string]string
+		expectOut   string
+		expectErr   string
+		expectKey   string
+	}{
+		{
+			name:      "unsupported key type",
+			args:      []string{"-keyType", "ed25519"},
+			expectErr: "Error: unsupported key type \"ed25519\"\n",
+		},
+		{
+			name:      "unsupported key format",
+			args:      []string{"-keyFormat", "sec1"},
+			expectErr: "Error: unsupported key format \"sec1\"\n",
+		},
+		{
+			name:      "pkcs1 requires an RSA key",
+			args:      []string{"-keyFormat", "pkcs1"},
+			expectErr: "Error: key format \"pkcs1\" requires an RSA key type\n",
+		},
+		{
+			name:      "split chain and federated bundles to stdout",
+			args:      []string{"-splitChain", "-includeFederated"},
+			expectKey: "ec-p256",
+			expectOut: fmt.Sprintf("X509-SVID:\n%s\nIntermediates:\n%s\nPrivate key:\n%s\nRoot CAs:\n%s\nFederated CAs:\n%s\n",
+				pemOf(leafDER), pemOf(intermediateDER), testKeyPEM, pemOf(rootDER), pemOf(federatedDER)),
+		},
+		{
+			name:      "pkcs1 RSA key, split chain and federated bundles written to directory",
+			args:      []string{"-keyType", "rsa-2048", "-keyFormat", "pkcs1", "-splitChain", "-includeFederated", "-write", "out"},
+			expectKey: "rsa-2048",
+			expectFiles: map[string]string{
+				"svid.pem":             pemOf(leafDER),
+				"chain.pem":            pemOf(intermediateDER),
+				"key.pem":              rsaKeyPEM,
+				"bundle.pem":           pemOf(rootDER),
+				"federated_bundle.pem": pemOf(federatedDER),
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := spiretest.TempDir(t)
+			require.NoError(t, os.Mkdir(filepath.Join(dir, "out"), 0755))
+
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			var keyType string
+			cmd := newMintCommand(&common_cli.Env{
+				Stdin:   new(bytes.Buffer),
+				Stdout:  stdout,
+				Stderr:  stderr,
+				BaseDir: dir,
+			}, func(kt string) (crypto.Signer, error) {
+				keyType = kt
+				if kt == "rsa-2048" {
+					return rsaKey, nil
+				}
+				return testKey, nil
+			})
+
+			args := append([]string{common.AddrArg, common.GetAddr(addr), "-spiffeID", "spiffe://domain.test/workload"}, tt.args...)
+			code := cmd.Run(args)
+			if tt.expectErr != "" {
+				assert.Equal(t, 1, code)
+				assert.Equal(t, tt.expectErr, stderr.String())
+				return
+			}
+			require.Equal(t, 0, code, stderr.String())
+			assert.Equal(t, tt.expectKey, keyType)
+
+			if tt.expectOut != "" {
+				assert.Equal(t, tt.expectOut, stdout.String())
+			}
+			for name, data := range tt.expectFiles {
+				assertFileData(t, filepath.Join(dir, "out", name), data)
+			}
+		})
+	}
+}
+
+func createTestChain(t *testing.T) (rootDER, intermediateDER, leafDER []byte) {
+	rootDER = createTestCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}, nil)
+	intermediateDER = createTestCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}, rootDER)
+	leafDER = createTestCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, intermediateDER)
+	return rootDER, intermediateDER, leafDER
+}
+
+// createTestCertificate creates a certificate for the template, signed by
+// the parent if provided or self-signed otherwise. All the certificates share
+// the same test key.
+func createTestCertificate(t *testing.T, tmpl *x509.Certificate, parentDER []byte) []byte {
+	parent := tmpl
+	if parentDER != nil {
+		var err error
+		parent, err = x509.ParseCertificate(parentDER)
+		require.NoError(t, err)
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, tmpl, parent, testKey.Public(), testKey)
+	require.NoError(t, err)
+	return certDER
+}
+
 type fakeSVIDServer struct {
 	svidv1.SVIDServer
 	bundlev1.BundleServer
@@ -338,6 +508,8 @@ type fakeSVIDServer struct {
 
 	bundle    *types.Bundle
 	bundleErr error
+
+	federatedBundles []*types.Bundle
 }
 
 func (f *fakeSVIDServer) resetMintX509SVIDRequest() {
@@ -377,6 +549,12 @@ func (f *fakeSVIDServer) GetBundle(ctx context.Context, req *bundlev1.GetBundleR
 	return f.bundle, nil
 }
 
+func (f *fakeSVIDServer) ListFederatedBundles(ctx context.Context, req *bundlev1.ListFederatedBundlesRequest) (*bundlev1.ListFederatedBundlesResponse, error) {
+	return &bundlev1.ListFederatedBundlesResponse{
+		Bundles: f.federatedBundles,
+	}, nil
+}
+
 func assertFileData(t *testing.T, path string, expectedData string) {
 	b, err := os.ReadFile(path)
 	if assert.NoError(t, err) {
@@ -19,6 +19,7 @@ import (
 	api_types "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	common_cli "github.com/spiffe/spire/pkg/common/cli"
 	"github.com/spiffe/spire/pkg/common/pemutil"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/health/grpc_health_v1"
@@ -42,6 +43,7 @@ func Dial(addr net.Addr) (*grpc.ClientConn, error) {
 
 type ServerClient interface {
 	Release()
+	NewAdminClient() *adminv1.Client
 	NewAgentClient() agentv1.AgentClient
 	NewBundleClient() bundlev1.BundleClient
 	NewEntryClient() entryv1.EntryClient
@@ -66,6 +68,10 @@ func (c *serverClient) Release() {
 	c.conn.Close()
 }
 
+func (c *serverClient) NewAdminClient() *adminv1.Client {
+	return adminv1.NewClient(c.conn)
+}
+
 func (c *serverClient) NewAgentClient() agentv1.AgentClient {
 	return agentv1.NewAgentClient(c.conn)
 }
@@ -10,11 +10,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/andres-erbsen/clock"
 	"github.com/spiffe/go-spiffe/v2/workloadapi"
 	admin_api "github.com/spiffe/spire/pkg/agent/api"
 	node_attestor "github.com/spiffe/spire/pkg/agent/attestor/node"
 	workload_attestor "github.com/spiffe/spire/pkg/agent/attestor/workload"
 	"github.com/spiffe/spire/pkg/agent/catalog"
+	"github.com/spiffe/spire/pkg/agent/common/cgroups"
 	"github.com/spiffe/spire/pkg/agent/endpoints"
 	"github.com/spiffe/spire/pkg/agent/manager"
 	"github.com/spiffe/spire/pkg/agent/manager/storecache"
@@ -95,23 +97,32 @@ func (a *Agent) Run(ctx context.Context) error {
 
 	svidStoreCache := a.newSVIDStoreCache()
 
-	manager, err := a.newManager(ctx, sto, cat, metrics, as, svidStoreCache, nodeAttestor)
+	manager, err := a.newManager(ctx, sto, cat, metrics, as, svidStoreCache, nodeAttestor, healthChecker)
 	if err != nil {
 		return err
 	}
 
 	storeService := a.newSVIDStoreService(svidStoreCache, cat, metrics)
-	workloadAttestor := workload_attestor.New(&workload_attestor.Config{
+	workloadAttestor, err := workload_attestor.New(&workload_attestor.Config{
 		Catalog: cat,
 		Log:     a.c.Log.WithField(telemetry.SubsystemName, telemetry.WorkloadAttestor),
 		Metrics: metrics,
+
+		HealthChecker:             healthChecker,
+		MaxConcurrentAttestations: a.c.MaxConcurrentWorkloadAttestations,
 	})
+	if err != nil {
+		return err
+	}
 
 	endpoints := a.newEndpoints(metrics, manager, workloadAttestor)
 
 	if err := healthChecker.AddCheck("agent", a); err != nil {
 		return fmt.Errorf("failed adding healthcheck: %w", err)
 	}
+	if err := healthChecker.AddCheck("agent.keymanager", &keyManagerHealth{km: cat.GetKeyManager()}); err != nil {
+		return fmt.Errorf("failed adding healthcheck: %w", err)
+	}
 
 	tasks := []func(context.Context) error{
 		manager.Run,
@@ -122,7 +133,7 @@ func (a *Agent) Run(ctx context.Context) error {
 	}
 
 	if a.c.AdminBindAddress != nil {
-		adminEndpoints := a.newAdminEndpoints(manager, workloadAttestor, a.c.AuthorizedDelegates)
+		adminEndpoints := a.newAdminEndpoints(cat, manager, workloadAttestor, a.c.AuthorizedDelegates)
 		tasks = append(tasks, adminEndpoints.ListenAndServe)
 	}
 
@@ -207,10 +218,11 @@ func (a *Agent) attest(ctx context.Context, sto storage.Storage, cat catalog.Cat
 		ServerAddress:     a.c.ServerAddress,
 		NodeAttestor:      na,
 	}
-	return node_attestor.New(&config).Attest(ctx)
+	attestor := node_attestor.WithRetry(node_attestor.New(&config), a.c.AttestationRetry, config.Log, clock.New())
+	return attestor.Attest(ctx)
 }
 
-func (a *Agent) newManager(ctx context.Context, sto storage.Storage, cat catalog.Catalog, metrics telemetry.Metrics, as *node_attestor.AttestationResult, cache *storecache.Cache, na nodeattestor.NodeAttestor) (manager.Manager, error) {
+func (a *Agent) newManager(ctx context.Context, sto storage.Storage, cat catalog.Catalog, metrics telemetry.Metrics, as *node_attestor.AttestationResult, cache *storecache.Cache, na nodeattestor.NodeAttestor, healthChecker health.Checker) (manager.Manager, error) {
 	config := &manager.Config{
 		SVID:             as.SVID,
 		SVIDKey:          as.Key,
@@ -226,9 +238,20 @@ func (a *Agent) newManager(ctx context.Context, sto storage.Storage, cat catalog
 		SVIDCacheMaxSize: a.c.X509SVIDCacheMaxSize,
 		SVIDStoreCache:   cache,
 		NodeAttestor:     na,
+		HealthChecker:    healthChecker,
+	}
+	if a.c.LoadSheddingMemoryThreshold > 0 || a.c.LoadSheddingCPUPressureThreshold > 0 {
+		detector := cgroups.NewPressureDetector(a.c.LoadSheddingMemoryThreshold, a.c.LoadSheddingCPUPressureThreshold)
+		if err := detector.CheckSupported(); err != nil {
+			return nil, fmt.Errorf("load shedding is not supported on this host: %w", err)
+		}
+		config.PressureDetector = detector
 	}
 
-	mgr := manager.New(config)
+	mgr, err := manager.New(config)
+	if err != nil {
+		return nil, err
+	}
 	if err := mgr.Initialize(ctx); err != nil {
 		return nil, err
 	}
@@ -260,6 +283,8 @@ func (a *Agent) newSVIDStoreService(cache *storecache.Cache, cat catalog.Catalog
 func (a *Agent) newEndpoints(metrics telemetry.Metrics, mgr manager.Manager, attestor workload_attestor.Attestor) endpoints.Server {
 	return endpoints.New(endpoints.Config{
 		BindAddr:                      a.c.BindAddress,
+		AdditionalBindAddrs:           a.c.AdditionalBindAddresses,
+		SocketActivation:              a.c.SocketActivation,
 		Attestor:                      attestor,
 		Manager:                       mgr,
 		Log:                           a.c.Log.WithField(telemetry.SubsystemName, telemetry.Endpoints),
@@ -269,12 +294,14 @@ func (a *Agent) newEndpoints(metrics telemetry.Metrics, mgr manager.Manager, att
 		DefaultAllBundlesName:         a.c.DefaultAllBundlesName,
 		DisableSPIFFECertValidation:   a.c.DisableSPIFFECertValidation,
 		AllowUnauthenticatedVerifiers: a.c.AllowUnauthenticatedVerifiers,
+		AllowLegacySecurityHeaders:    a.c.AllowLegacySecurityHeaders,
 		AllowedForeignJWTClaims:       a.c.AllowedForeignJWTClaims,
+		DenyJWTSVIDSelectors:          a.c.DenyJWTSVIDSelectors,
 		TrustDomain:                   a.c.TrustDomain,
 	})
 }
 
-func (a *Agent) newAdminEndpoints(mgr manager.Manager, attestor workload_attestor.Attestor, authorizedDelegates []string) admin_api.Server {
+func (a *Agent) newAdminEndpoints(cat catalog.Catalog, mgr manager.Manager, attestor workload_attestor.Attestor, authorizedDelegates []string) admin_api.Server {
 	config := &admin_api.Config{
 		BindAddr:            a.c.AdminBindAddress,
 		Manager:             mgr,
@@ -282,8 +309,15 @@ func (a *Agent) newAdminEndpoints(mgr manager.Manager, attestor workload_attesto
 		TrustDomain:         a.c.TrustDomain,
 		Uptime:              uptime.Uptime,
 		Attestor:            attestor,
+		Catalog:             cat,
 		AuthorizedDelegates: authorizedDelegates,
 	}
+	if a.c.EventBuffer != nil {
+		config.Events = a.c.EventBuffer
+	}
+	if a.c.LogLevels != nil {
+		config.LogLevels = a.c.LogLevels
+	}
 
 	return admin_api.New(config)
 }
@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package admin
+
+import (
+	"context"
+	"os"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// authorizeCaller only lets through the callers running as root or as the
+// user the agent runs as. The permissions of the admin socket admit the
+// members of its group too, which may include workloads.
+func authorizeCaller(ctx context.Context) error {
+	caller, err := callerFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	if caller.UID != 0 && caller.UID != uint32(os.Geteuid()) {
+		return status.Errorf(codes.PermissionDenied, "caller uid %d is neither root nor the uid of the agent", caller.UID)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	"google.golang.org/grpc"
+)
+
+// Client is a client of the agent admin service
+type Client struct {
+	conn grpc.ClientConnInterface
+}
+
+// NewClient creates a new agent admin service client
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{conn: conn}
+}
+
+func (c *Client) AttestWorkload(ctx context.Context, req *AttestWorkloadRequest) (*AttestWorkloadResponse, error) {
+	resp := new(AttestWorkloadResponse)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "AttestWorkload", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) ListEntryUsage(ctx context.Context, req *ListEntryUsageRequest) (*ListEntryUsageResponse, error) {
+	resp := new(ListEntryUsageResponse)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "ListEntryUsage", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) GetLogLevels(ctx context.Context) (*LogLevels, error) {
+	resp := new(LogLevels)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "GetLogLevels", nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) SetLogLevels(ctx context.Context, req *SetLogLevelsRequest) (*LogLevels, error) {
+	resp := new(LogLevels)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "SetLogLevels", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) ListEvents(ctx context.Context) (*ListEventsResponse, error) {
+	resp := new(ListEventsResponse)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "ListEvents", nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,320 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/spire/pkg/agent/api/rpccontext"
+	attestor "github.com/spiffe/spire/pkg/agent/attestor/workload"
+	"github.com/spiffe/spire/pkg/agent/catalog"
+	"github.com/spiffe/spire/pkg/agent/manager"
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	"github.com/spiffe/spire/pkg/common/log"
+	"github.com/spiffe/spire/pkg/common/peertracker"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName is the name of the agent admin service, which exposes the
+// troubleshooting operations of the agent. It is served through the adminapi
+// package.
+const ServiceName = "spire.agent.admin.Admin"
+
+const (
+	// attestTimeout bounds the time spent attesting a workload on behalf
+	// of AttestWorkload, regardless of the deadline of the caller.
+	attestTimeout = 30 * time.Second
+
+	// maxConcurrentAttestations is the maximum number of AttestWorkload
+	// calls served at once. Additional calls fail with ResourceExhausted
+	// instead of piling up on the workload attestor plugins.
+	maxConcurrentAttestations = 4
+)
+
+type AttestWorkloadRequest struct {
+	// PID is the process ID of the workload to attest.
+	PID int32 `json:"pid"`
+}
+
+type AttestWorkloadResponse struct {
+	Attestation *attestor.DebugAttestation `json:"attestation"`
+}
+
+// EntryUsageSource returns when the SVID of each cached entry was last
+// fetched by a workload.
+type EntryUsageSource interface {
+	EntryUsage() []manager.EntryUsage
+}
+
+type ListEntryUsageRequest struct {
+	// UnusedOnly, if true, only lists the entries whose SVID was never
+	// fetched by a workload since the agent started.
+	UnusedOnly bool `json:"unused_only,omitempty"`
+}
+
+// EntryUsage is the usage of a cached registration entry.
+type EntryUsage struct {
+	EntryID  string `json:"entry_id"`
+	SpiffeID string `json:"spiffe_id"`
+	ParentID string `json:"parent_id"`
+
+	// LastUsed is unset if no workload fetched the SVID of the entry since
+	// the agent started.
+	LastUsed *time.Time `json:"last_used,omitempty"`
+}
+
+type ListEntryUsageResponse struct {
+	Entries []*EntryUsage `json:"entries"`
+}
+
+// EventSource returns the recent significant log entries of the agent.
+type EventSource interface {
+	Events() []log.Event
+}
+
+type ListEventsResponse struct {
+	// Events are the recent log entries at the INFO level or above, oldest
+	// first.
+	Events []log.Event `json:"events"`
+}
+
+// LogLevelSource manages the log level overrides of the agent subsystems.
+type LogLevelSource interface {
+	SubsystemLevels() (logrus.Level, map[string]logrus.Level, error)
+	SetSubsystemLevels(levels map[string]logrus.Level) error
+}
+
+type LogLevels struct {
+	// Level is the log level of the subsystems without an override.
+	Level string `json:"level"`
+
+	// SubsystemLevels are the log level overrides, by subsystem name. See
+	// log.Logger.SetSubsystemLevels for how subsystems are named.
+	SubsystemLevels map[string]string `json:"subsystem_levels,omitempty"`
+}
+
+type SetLogLevelsRequest struct {
+	// SubsystemLevels replace the current log level overrides.
+	SubsystemLevels map[string]string `json:"subsystem_levels"`
+}
+
+// RegisterService registers the admin service on the gRPC server.
+func RegisterService(s grpc.ServiceRegistrar, service *Service) {
+	adminapi.Register(s, adminapi.Service{
+		Name: ServiceName,
+		Methods: map[string]adminapi.Handler{
+			"AttestWorkload": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(AttestWorkloadRequest)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				return service.AttestWorkload(ctx, req)
+			},
+			"ListEntryUsage": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(ListEntryUsageRequest)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				return service.ListEntryUsage(ctx, req)
+			},
+			"ListEvents": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				if err := decode(&struct{}{}); err != nil {
+					return nil, err
+				}
+				return service.ListEvents(ctx)
+			},
+			"GetLogLevels": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				if err := decode(&struct{}{}); err != nil {
+					return nil, err
+				}
+				return service.GetLogLevels(ctx)
+			},
+			"SetLogLevels": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(SetLogLevelsRequest)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				return service.SetLogLevels(ctx, req)
+			},
+		},
+	})
+}
+
+// Config is the configuration of the admin service
+type Config struct {
+	Catalog    catalog.Catalog
+	Entries    attestor.EntryMatcher
+	EntryUsage EntryUsageSource
+
+	// Events provides the recent significant log entries. It is not set
+	// when the event buffer is disabled.
+	Events EventSource
+
+	// LogLevels manages the log level overrides of the subsystems. It is
+	// not set when they cannot be changed at runtime.
+	LogLevels LogLevelSource
+}
+
+// Service implements the agent admin service
+type Service struct {
+	catalog    catalog.Catalog
+	entries    attestor.EntryMatcher
+	entryUsage EntryUsageSource
+	events     EventSource
+	logLevels  LogLevelSource
+
+	// attestations holds a token for each AttestWorkload call in flight
+	attestations chan struct{}
+}
+
+// New creates a new admin service
+func New(config Config) *Service {
+	return &Service{
+		catalog:      config.Catalog,
+		entries:      config.Entries,
+		entryUsage:   config.EntryUsage,
+		events:       config.Events,
+		logLevels:    config.LogLevels,
+		attestations: make(chan struct{}, maxConcurrentAttestations),
+	}
+}
+
+// AttestWorkload runs every workload attestor against a process and returns
+// the selectors each one produced and the cached registration entries they
+// match. No SVID is issued.
+func (s *Service) AttestWorkload(ctx context.Context, req *AttestWorkloadRequest) (*AttestWorkloadResponse, error) {
+	log := rpccontext.Logger(ctx).WithField(telemetry.PID, req.PID)
+
+	if err := authorizeCaller(ctx); err != nil {
+		log.WithError(err).Warn("Rejected workload attestation request")
+		return nil, err
+	}
+	if req.PID <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "a positive pid is required")
+	}
+
+	select {
+	case s.attestations <- struct{}{}:
+		defer func() { <-s.attestations }()
+	default:
+		return nil, status.Errorf(codes.ResourceExhausted, "too many workload attestations in progress; at most %d are allowed at once", maxConcurrentAttestations)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, attestTimeout)
+	defer cancel()
+
+	attestation := attestor.DebugAttest(ctx, s.catalog, s.entries, int(req.PID))
+	log.Info("Attested workload for troubleshooting")
+	return &AttestWorkloadResponse{Attestation: attestation}, nil
+}
+
+// ListEntryUsage lists when the SVID of each cached registration entry was
+// last fetched by a workload through the Workload API or the SDS v3 API.
+func (s *Service) ListEntryUsage(ctx context.Context, req *ListEntryUsageRequest) (*ListEntryUsageResponse, error) {
+	if err := authorizeCaller(ctx); err != nil {
+		rpccontext.Logger(ctx).WithError(err).Warn("Rejected entry usage request")
+		return nil, err
+	}
+
+	resp := &ListEntryUsageResponse{
+		Entries: []*EntryUsage{},
+	}
+	for _, usage := range s.entryUsage.EntryUsage() {
+		if req.UnusedOnly && !usage.LastUsed.IsZero() {
+			continue
+		}
+		entryUsage := &EntryUsage{
+			EntryID:  usage.Entry.EntryId,
+			SpiffeID: usage.Entry.SpiffeId,
+			ParentID: usage.Entry.ParentId,
+		}
+		if !usage.LastUsed.IsZero() {
+			lastUsed := usage.LastUsed.UTC()
+			entryUsage.LastUsed = &lastUsed
+		}
+		resp.Entries = append(resp.Entries, entryUsage)
+	}
+	return resp, nil
+}
+
+// ListEvents lists the recent significant log entries of the agent, oldest
+// first.
+func (s *Service) ListEvents(ctx context.Context) (*ListEventsResponse, error) {
+	if err := authorizeCaller(ctx); err != nil {
+		rpccontext.Logger(ctx).WithError(err).Warn("Rejected events request")
+		return nil, err
+	}
+	if s.events == nil {
+		return nil, status.Error(codes.FailedPrecondition, "event buffer is disabled; set event_buffer_size to enable it")
+	}
+	return &ListEventsResponse{Events: s.events.Events()}, nil
+}
+
+// GetLogLevels returns the base log level of the agent and the log level
+// overrides of its subsystems.
+func (s *Service) GetLogLevels(ctx context.Context) (*LogLevels, error) {
+	if err := authorizeCaller(ctx); err != nil {
+		rpccontext.Logger(ctx).WithError(err).Warn("Rejected log levels request")
+		return nil, err
+	}
+	if s.logLevels == nil {
+		return nil, status.Error(codes.FailedPrecondition, "log levels cannot be changed at runtime")
+	}
+	return s.currentLogLevels()
+}
+
+// SetLogLevels replaces the log level overrides of the agent subsystems. The
+// subsystems that are not given use the base log level, which cannot be
+// changed at runtime.
+func (s *Service) SetLogLevels(ctx context.Context, req *SetLogLevelsRequest) (*LogLevels, error) {
+	if err := authorizeCaller(ctx); err != nil {
+		rpccontext.Logger(ctx).WithError(err).Warn("Rejected log levels update")
+		return nil, err
+	}
+	if s.logLevels == nil {
+		return nil, status.Error(codes.FailedPrecondition, "log levels cannot be changed at runtime")
+	}
+
+	levels, err := log.ParseSubsystemLevels(req.SubsystemLevels)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.logLevels.SetSubsystemLevels(levels); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to set log levels: %v", err)
+	}
+
+	resp, err := s.currentLogLevels()
+	if err != nil {
+		return nil, err
+	}
+	rpccontext.Logger(ctx).WithField(telemetry.SubsystemLevels, resp.SubsystemLevels).Info("Log levels updated")
+	return resp, nil
+}
+
+func (s *Service) currentLogLevels() (*LogLevels, error) {
+	level, levels, err := s.logLevels.SubsystemLevels()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get log levels: %v", err)
+	}
+	resp := &LogLevels{
+		Level: level.String(),
+	}
+	if len(levels) > 0 {
+		resp.SubsystemLevels = make(map[string]string, len(levels))
+		for subsystem, level := range levels {
+			resp.SubsystemLevels[subsystem] = level.String()
+		}
+	}
+	return resp, nil
+}
+
+func callerFromContext(ctx context.Context) (peertracker.CallerInfo, error) {
+	caller, ok := peertracker.CallerFromContext(ctx)
+	if !ok {
+		return peertracker.CallerInfo{}, status.Error(codes.Unauthenticated, "caller information is not available")
+	}
+	return caller, nil
+}
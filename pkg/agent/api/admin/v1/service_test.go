@@ -0,0 +1,296 @@
+package admin_test
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	admin "github.com/spiffe/spire/pkg/agent/api/admin/v1"
+	"github.com/spiffe/spire/pkg/agent/api/rpccontext"
+	attestor "github.com/spiffe/spire/pkg/agent/attestor/workload"
+	"github.com/spiffe/spire/pkg/agent/manager"
+	"github.com/spiffe/spire/pkg/agent/plugin/workloadattestor"
+	"github.com/spiffe/spire/pkg/common/log"
+	"github.com/spiffe/spire/pkg/common/peertracker"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/spiffe/spire/test/fakes/fakeagentcatalog"
+	"github.com/spiffe/spire/test/fakes/fakeworkloadattestor"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+)
+
+func TestAttestWorkload(t *testing.T) {
+	entry := &common.RegistrationEntry{
+		EntryId:   "ENTRY",
+		SpiffeId:  "spiffe://example.org/workload",
+		ParentId:  "spiffe://example.org/agent",
+		Selectors: []*common.Selector{{Type: "fake", Value: "bar"}},
+	}
+	test := setupServiceTest(t, fakeworkloadattestor.New(t, "fake", map[int32][]string{1: {"bar"}}))
+	test.entries = []*common.RegistrationEntry{entry}
+
+	resp, err := test.client.AttestWorkload(context.Background(), &admin.AttestWorkloadRequest{PID: 1})
+	require.NoError(t, err)
+	require.Equal(t, &attestor.DebugAttestation{
+		PID:       1,
+		Attestors: []attestor.DebugPluginResult{{Name: "fake", Selectors: []string{"fake:bar"}}},
+		Entries: []attestor.DebugEntry{
+			{
+				EntryID:   "ENTRY",
+				SPIFFEID:  "spiffe://example.org/workload",
+				ParentID:  "spiffe://example.org/agent",
+				Selectors: []string{"fake:bar"},
+			},
+		},
+	}, resp.Attestation)
+
+	_, err = test.client.AttestWorkload(context.Background(), &admin.AttestWorkloadRequest{})
+	spiretest.RequireGRPCStatus(t, err, codes.InvalidArgument, "a positive pid is required")
+}
+
+func TestAttestWorkloadAuthorization(t *testing.T) {
+	test := setupServiceTest(t, fakeworkloadattestor.New(t, "fake", map[int32][]string{1: {"bar"}}))
+
+	test.caller = nil
+	_, err := test.client.AttestWorkload(context.Background(), &admin.AttestWorkloadRequest{PID: 1})
+	spiretest.RequireGRPCStatus(t, err, codes.Unauthenticated, "caller information is not available")
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	test.caller = &peertracker.CallerInfo{UID: uint32(os.Geteuid()) + 1}
+	_, err = test.client.AttestWorkload(context.Background(), &admin.AttestWorkloadRequest{PID: 1})
+	spiretest.RequireGRPCStatusContains(t, err, codes.PermissionDenied, "is neither root nor the uid of the agent")
+}
+
+func TestAttestWorkloadConcurrencyLimit(t *testing.T) {
+	plugin := &blockingAttestor{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	test := setupServiceTest(t, plugin)
+
+	// Fill every slot with attestations blocked in the plugin
+	const limit = 4
+	errs := make(chan error, limit)
+	for i := 0; i < limit; i++ {
+		go func() {
+			_, err := test.client.AttestWorkload(context.Background(), &admin.AttestWorkloadRequest{PID: 1})
+			errs <- err
+		}()
+		<-plugin.started
+	}
+
+	_, err := test.client.AttestWorkload(context.Background(), &admin.AttestWorkloadRequest{PID: 1})
+	spiretest.RequireGRPCStatusContains(t, err, codes.ResourceExhausted, "too many workload attestations in progress")
+
+	close(plugin.release)
+	for i := 0; i < limit; i++ {
+		require.NoError(t, <-errs)
+	}
+}
+
+func TestListEntryUsage(t *testing.T) {
+	lastUsed := time.Unix(1000, 0).UTC()
+	test := setupServiceTest(t, fakeworkloadattestor.New(t, "fake", nil))
+	test.usage = []manager.EntryUsage{
+		{
+			Entry: &common.RegistrationEntry{
+				EntryId:  "USED",
+				SpiffeId: "spiffe://example.org/used",
+				ParentId: "spiffe://example.org/agent",
+			},
+			LastUsed: lastUsed,
+		},
+		{
+			Entry: &common.RegistrationEntry{
+				EntryId:  "UNUSED",
+				SpiffeId: "spiffe://example.org/unused",
+				ParentId: "spiffe://example.org/agent",
+			},
+		},
+	}
+	used := &admin.EntryUsage{
+		EntryID:  "USED",
+		SpiffeID: "spiffe://example.org/used",
+		ParentID: "spiffe://example.org/agent",
+		LastUsed: &lastUsed,
+	}
+	unused := &admin.EntryUsage{
+		EntryID:  "UNUSED",
+		SpiffeID: "spiffe://example.org/unused",
+		ParentID: "spiffe://example.org/agent",
+	}
+
+	resp, err := test.client.ListEntryUsage(context.Background(), &admin.ListEntryUsageRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []*admin.EntryUsage{used, unused}, resp.Entries)
+
+	resp, err = test.client.ListEntryUsage(context.Background(), &admin.ListEntryUsageRequest{UnusedOnly: true})
+	require.NoError(t, err)
+	require.Equal(t, []*admin.EntryUsage{unused}, resp.Entries)
+
+	test.caller = nil
+	_, err = test.client.ListEntryUsage(context.Background(), &admin.ListEntryUsageRequest{})
+	spiretest.RequireGRPCStatus(t, err, codes.Unauthenticated, "caller information is not available")
+}
+
+func TestListEvents(t *testing.T) {
+	test := setupServiceTest(t, fakeworkloadattestor.New(t, "fake", nil))
+
+	_, err := test.client.ListEvents(context.Background())
+	spiretest.RequireGRPCStatus(t, err, codes.FailedPrecondition, "event buffer is disabled; set event_buffer_size to enable it")
+
+	events := []log.Event{
+		{
+			Time:    time.Unix(1000, 0).UTC(),
+			Level:   "error",
+			Message: "Failed to attest workload",
+			Fields:  map[string]string{"subsystem_name": "workload_attestor"},
+		},
+	}
+	test = setupServiceTest(t, fakeworkloadattestor.New(t, "fake", nil), func(config *admin.Config) {
+		config.Events = eventSource(events)
+	})
+	resp, err := test.client.ListEvents(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, events, resp.Events)
+
+	test.caller = nil
+	_, err = test.client.ListEvents(context.Background())
+	spiretest.RequireGRPCStatus(t, err, codes.Unauthenticated, "caller information is not available")
+}
+
+func TestLogLevels(t *testing.T) {
+	test := setupServiceTest(t, fakeworkloadattestor.New(t, "fake", nil))
+
+	_, err := test.client.GetLogLevels(context.Background())
+	spiretest.RequireGRPCStatus(t, err, codes.FailedPrecondition, "log levels cannot be changed at runtime")
+	_, err = test.client.SetLogLevels(context.Background(), &admin.SetLogLevelsRequest{})
+	spiretest.RequireGRPCStatus(t, err, codes.FailedPrecondition, "log levels cannot be changed at runtime")
+
+	logger, err := log.NewLogger(log.WithLevel("INFO"), log.WithSubsystemLevels(nil))
+	require.NoError(t, err)
+	test = setupServiceTest(t, fakeworkloadattestor.New(t, "fake", nil), func(config *admin.Config) {
+		config.LogLevels = logger
+	})
+
+	resp, err := test.client.GetLogLevels(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &admin.LogLevels{Level: "info"}, resp)
+
+	resp, err = test.client.SetLogLevels(context.Background(), &admin.SetLogLevelsRequest{
+		SubsystemLevels: map[string]string{"WorkloadAttestor.k8s": "DEBUG"},
+	})
+	require.NoError(t, err)
+	expected := &admin.LogLevels{
+		Level:           "info",
+		SubsystemLevels: map[string]string{"workloadattestor.k8s": "debug"},
+	}
+	require.Equal(t, expected, resp)
+
+	resp, err = test.client.GetLogLevels(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, expected, resp)
+
+	_, err = test.client.SetLogLevels(context.Background(), &admin.SetLogLevelsRequest{
+		SubsystemLevels: map[string]string{"manager": "LOUD"},
+	})
+	spiretest.RequireGRPCStatus(t, err, codes.InvalidArgument, `invalid log level for subsystem "manager": not a valid logrus Level: "LOUD"`)
+
+	// Overrides are left unchanged on failure
+	resp, err = test.client.GetLogLevels(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, expected, resp)
+
+	test.caller = nil
+	_, err = test.client.SetLogLevels(context.Background(), &admin.SetLogLevelsRequest{})
+	spiretest.RequireGRPCStatus(t, err, codes.Unauthenticated, "caller information is not available")
+}
+
+type serviceTest struct {
+	client  *admin.Client
+	caller  *peertracker.CallerInfo
+	entries []*common.RegistrationEntry
+	usage   []manager.EntryUsage
+}
+
+func (s *serviceTest) MatchingRegistrationEntries([]*common.Selector) []*common.RegistrationEntry {
+	return s.entries
+}
+
+func (s *serviceTest) EntryUsage() []manager.EntryUsage {
+	return s.usage
+}
+
+func setupServiceTest(t *testing.T, plugin workloadattestor.WorkloadAttestor, opts ...func(*admin.Config)) *serviceTest {
+	cat := fakeagentcatalog.New()
+	cat.SetWorkloadAttestors(plugin)
+
+	log, _ := test.NewNullLogger()
+	st := &serviceTest{
+		caller: &peertracker.CallerInfo{UID: uint32(os.Geteuid())},
+	}
+	config := admin.Config{
+		Catalog:    cat,
+		Entries:    st,
+		EntryUsage: st,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	service := admin.New(config)
+
+	registerFn := func(s *grpc.Server) {
+		admin.RegisterService(s, service)
+	}
+	contextFn := func(ctx context.Context) context.Context {
+		ctx = rpccontext.WithLogger(ctx, log)
+		if st.caller != nil {
+			ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: peertracker.AuthInfo{Caller: *st.caller}})
+		}
+		return ctx
+	}
+
+	conn, done := spiretest.NewAPIServer(t, registerFn, contextFn)
+	t.Cleanup(done)
+
+	st.client = admin.NewClient(conn)
+	return st
+}
+
+type eventSource []log.Event
+
+func (s eventSource) Events() []log.Event {
+	return s
+}
+
+type blockingAttestor struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (p *blockingAttestor) Name() string {
+	return "blocking"
+}
+
+func (p *blockingAttestor) Type() string {
+	return "WorkloadAttestor"
+}
+
+func (p *blockingAttestor) Attest(ctx context.Context, pid int) ([]*common.Selector, error) {
+	p.started <- struct{}{}
+	select {
+	case <-p.release:
+		return nil, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
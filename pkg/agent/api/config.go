@@ -6,7 +6,9 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	adminv1 "github.com/spiffe/spire/pkg/agent/api/admin/v1"
 	attestor "github.com/spiffe/spire/pkg/agent/attestor/workload"
+	"github.com/spiffe/spire/pkg/agent/catalog"
 	"github.com/spiffe/spire/pkg/agent/manager"
 	"github.com/spiffe/spire/pkg/common/peertracker"
 )
@@ -25,7 +27,19 @@ type Config struct {
 
 	Attestor attestor.Attestor
 
+	// Catalog provides the workload attestor plugins run on behalf of the
+	// admin service
+	Catalog catalog.Catalog
+
 	AuthorizedDelegates []string
+
+	// Events, if set, provides the recent significant log entries served by
+	// the admin service
+	Events adminv1.EventSource
+
+	// LogLevels, if set, manages the log level overrides of the subsystems
+	// through the admin service
+	LogLevels adminv1.LogLevelSource
 }
 
 func New(c *Config) *Endpoints {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/andres-erbsen/clock"
 	"github.com/sirupsen/logrus"
+	adminv1 "github.com/spiffe/spire/pkg/agent/api/admin/v1"
 	debugv1 "github.com/spiffe/spire/pkg/agent/api/debug/v1"
 	delegatedidentityv1 "github.com/spiffe/spire/pkg/agent/api/delegatedidentity/v1"
 	"github.com/spiffe/spire/pkg/common/api/middleware"
@@ -34,6 +35,7 @@ func (e *Endpoints) ListenAndServe(ctx context.Context) error {
 		grpc.StreamInterceptor(streamInterceptor),
 	)
 
+	e.registerAdminAPI(server)
 	e.registerDebugAPI(server)
 	e.registerDelegatedIdentityAPI(server)
 
@@ -63,6 +65,18 @@ func (e *Endpoints) ListenAndServe(ctx context.Context) error {
 	}
 }
 
+func (e *Endpoints) registerAdminAPI(server *grpc.Server) {
+	service := adminv1.New(adminv1.Config{
+		Catalog:    e.c.Catalog,
+		Entries:    e.c.Manager,
+		EntryUsage: e.c.Manager,
+		Events:     e.c.Events,
+		LogLevels:  e.c.LogLevels,
+	})
+
+	adminv1.RegisterService(server, service)
+}
+
 func (e *Endpoints) registerDebugAPI(server *grpc.Server) {
 	clk := clock.New()
 	service := debugv1.New(debugv1.Config{
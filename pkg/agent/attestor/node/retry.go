@@ -0,0 +1,108 @@
+package attestor
+
+import (
+	"context"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"github.com/cenkalti/backoff/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+)
+
+const (
+	// DefaultRetryInitialBackoff is the default time waited after the first
+	// failed attestation attempt.
+	DefaultRetryInitialBackoff = time.Second
+
+	// DefaultRetryMaxBackoff is the default upper bound of the time waited
+	// between attestation attempts.
+	DefaultRetryMaxBackoff = 30 * time.Second
+
+	// DefaultRetryBackoffMultiplier is the default factor the backoff grows
+	// by after each failed attestation attempt.
+	DefaultRetryBackoffMultiplier = backoff.DefaultMultiplier
+
+	// DefaultRetryJitter is the fraction of the backoff that is randomized
+	// when not otherwise configured.
+	DefaultRetryJitter = 0.1
+)
+
+// RetryConfig configures how node attestation is retried at startup.
+type RetryConfig struct {
+	// MaxAttempts is the number of attestation attempts made before giving
+	// up. Zero or one means attestation is not retried.
+	MaxAttempts int
+
+	// InitialBackoff is the time waited after the first failed attempt.
+	InitialBackoff time.Duration
+
+	// MaxBackoff bounds the time waited between attempts.
+	MaxBackoff time.Duration
+
+	// BackoffMultiplier is the factor the backoff grows by after each
+	// failed attempt.
+	BackoffMultiplier float64
+
+	// Jitter is the fraction, between 0 and 1, of the backoff that is
+	// randomized so agents started together do not retry in lockstep.
+	Jitter float64
+}
+
+// WithRetry returns an attestor that retries failed attestations using an
+// exponential backoff, as configured. Unset backoff durations and multiplier
+// take their default values.
+func WithRetry(attestor Attestor, config RetryConfig, log logrus.FieldLogger, clk clock.Clock) Attestor {
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = DefaultRetryInitialBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = DefaultRetryMaxBackoff
+	}
+	if config.BackoffMultiplier < 1 {
+		config.BackoffMultiplier = DefaultRetryBackoffMultiplier
+	}
+	return &retryAttestor{
+		attestor: attestor,
+		c:        config,
+		log:      log,
+		clk:      clk,
+	}
+}
+
+type retryAttestor struct {
+	attestor Attestor
+	c        RetryConfig
+	log      logrus.FieldLogger
+	clk      clock.Clock
+}
+
+func (r *retryAttestor) Attest(ctx context.Context) (*AttestationResult, error) {
+	b := &backoff.ExponentialBackOff{
+		Clock:               r.clk,
+		InitialInterval:     r.c.InitialBackoff,
+		RandomizationFactor: r.c.Jitter,
+		Multiplier:          r.c.BackoffMultiplier,
+		MaxInterval:         r.c.MaxBackoff,
+	}
+	b.Reset()
+
+	for attempt := 1; ; attempt++ {
+		res, err := r.attestor.Attest(ctx)
+		if err == nil || attempt >= r.c.MaxAttempts {
+			return res, err
+		}
+
+		retryInterval := b.NextBackOff()
+		r.log.WithError(err).WithFields(logrus.Fields{
+			telemetry.Attempt:       attempt,
+			telemetry.RetryInterval: retryInterval.String(),
+		}).Warn("Node attestation failed; retrying")
+
+		select {
+		case <-r.clk.After(retryInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package attestor_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	attestor "github.com/spiffe/spire/pkg/agent/attestor/node"
+	"github.com/spiffe/spire/test/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRetry(t *testing.T) {
+	for _, tt := range []struct {
+		name            string
+		config          attestor.RetryConfig
+		failures        int
+		expectCalls     int
+		expectBackoffs  []time.Duration
+		expectErr       string
+		expectRetryLogs int
+	}{
+		{
+			name:        "no retries by default",
+			failures:    1,
+			expectCalls: 1,
+			expectErr:   "attestation failed",
+		},
+		{
+			name: "succeeds after retries",
+			config: attestor.RetryConfig{
+				MaxAttempts:    5,
+				InitialBackoff: time.Second,
+				MaxBackoff:     3 * time.Second,
+			},
+			failures:        3,
+			expectCalls:     4,
+			expectBackoffs:  []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+			expectRetryLogs: 3,
+		},
+		{
+			name: "gives up after max attempts",
+			config: attestor.RetryConfig{
+				MaxAttempts:       3,
+				InitialBackoff:    time.Second,
+				BackoffMultiplier: 3,
+			},
+			failures:        5,
+			expectCalls:     3,
+			expectBackoffs:  []time.Duration{time.Second, 3 * time.Second},
+			expectErr:       "attestation failed",
+			expectRetryLogs: 2,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			log, hook := test.NewNullLogger()
+			clk := clock.NewMock(t)
+			fake := &fakeAttestor{failures: tt.failures}
+
+			var res *attestor.AttestationResult
+			var err error
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				res, err = attestor.WithRetry(fake, tt.config, log, clk).Attest(context.Background())
+			}()
+
+			for _, expectBackoff := range tt.expectBackoffs {
+				select {
+				case backoff := <-clk.WaitForAfterCh():
+					require.Equal(t, expectBackoff, backoff)
+					clk.Add(backoff)
+				case <-time.After(time.Minute):
+					require.FailNow(t, "timed out waiting for backoff")
+				}
+			}
+			<-done
+
+			require.Equal(t, tt.expectCalls, fake.calls)
+			require.Len(t, hook.AllEntries(), tt.expectRetryLogs)
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				require.Nil(t, res)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, res)
+		})
+	}
+}
+
+func TestWithRetryCanceled(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	clk := clock.NewMock(t)
+	fake := &fakeAttestor{failures: 5}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var err error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err = attestor.WithRetry(fake, attestor.RetryConfig{MaxAttempts: 5}, log, clk).Attest(ctx)
+	}()
+
+	clk.WaitForAfter(time.Minute, "timed out waiting for backoff")
+	cancel()
+	<-done
+
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, 1, fake.calls)
+}
+
+type fakeAttestor struct {
+	failures int
+	calls    int
+}
+
+func (a *fakeAttestor) Attest(context.Context) (*attestor.AttestationResult, error) {
+	a.calls++
+	if a.calls <= a.failures {
+		return nil, errors.New("attestation failed")
+	}
+	return &attestor.AttestationResult{}, nil
+}
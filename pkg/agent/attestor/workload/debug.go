@@ -0,0 +1,92 @@
+package attestor
+
+import (
+	"context"
+
+	"github.com/spiffe/spire/pkg/agent/catalog"
+	"github.com/spiffe/spire/proto/spire/common"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EntryMatcher returns the registration entries matched by a set of
+// selectors.
+type EntryMatcher interface {
+	MatchingRegistrationEntries(selectors []*common.Selector) []*common.RegistrationEntry
+}
+
+// DebugAttestation is the outcome of the attestation of a workload, as
+// returned by DebugAttest.
+type DebugAttestation struct {
+	PID       int                 `json:"pid"`
+	Attestors []DebugPluginResult `json:"attestors"`
+
+	// Denied is true if a plugin denied the workload, in which case it is
+	// not issued any identity, regardless of the matching entries.
+	Denied bool `json:"denied,omitempty"`
+
+	// Entries are the registration entries cached by the agent that are
+	// matched by the selectors of all the plugins that succeeded.
+	Entries []DebugEntry `json:"entries"`
+}
+
+// DebugPluginResult is the outcome of the attestation of a workload by a
+// single workload attestor plugin.
+type DebugPluginResult struct {
+	Name      string   `json:"name"`
+	Selectors []string `json:"selectors,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// DebugEntry is a registration entry matched by a workload.
+type DebugEntry struct {
+	EntryID   string   `json:"entry_id"`
+	SPIFFEID  string   `json:"spiffe_id"`
+	ParentID  string   `json:"parent_id"`
+	Selectors []string `json:"selectors"`
+}
+
+// DebugAttest attests the workload with the given PID and returns the
+// selectors produced by each workload attestor plugin and the registration
+// entries they match. No SVID is issued.
+func DebugAttest(ctx context.Context, cat catalog.Catalog, entries EntryMatcher, pid int) *DebugAttestation {
+	attestation := &DebugAttestation{
+		PID:       pid,
+		Attestors: []DebugPluginResult{},
+		Entries:   []DebugEntry{},
+	}
+	var selectors []*common.Selector
+	for _, result := range AttestEach(ctx, cat, pid) {
+		pluginResult := DebugPluginResult{
+			Name:      result.Name,
+			Selectors: selectorStrings(result.Selectors),
+		}
+		if result.Err != nil {
+			pluginResult.Error = result.Err.Error()
+			if status.Code(result.Err) == codes.PermissionDenied {
+				attestation.Denied = true
+			}
+		} else {
+			selectors = append(selectors, result.Selectors...)
+		}
+		attestation.Attestors = append(attestation.Attestors, pluginResult)
+	}
+
+	for _, entry := range entries.MatchingRegistrationEntries(selectors) {
+		attestation.Entries = append(attestation.Entries, DebugEntry{
+			EntryID:   entry.EntryId,
+			SPIFFEID:  entry.SpiffeId,
+			ParentID:  entry.ParentId,
+			Selectors: selectorStrings(entry.Selectors),
+		})
+	}
+	return attestation
+}
+
+func selectorStrings(selectors []*common.Selector) []string {
+	var s []string
+	for _, selector := range selectors {
+		s = append(s, selector.Type+":"+selector.Value)
+	}
+	return s
+}
@@ -0,0 +1,93 @@
+package attestor
+
+import (
+	"time"
+
+	"github.com/spiffe/spire/pkg/common/health"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// failedAttestationThreshold is the number of consecutive failed
+// attestations of a workload attestor plugin after which the agent is no
+// longer reported as ready. A single failure is often specific to the
+// workload, e.g. a process that exited before it was attested.
+const failedAttestationThreshold = 5
+
+// attestorResult is the outcome of an attestation performed by a workload
+// attestor plugin.
+type attestorResult struct {
+	attestedAt time.Time
+	latency    time.Duration
+	err        error
+
+	// consecutiveFailures is the number of attestations that failed in a
+	// row, including this one.
+	consecutiveFailures int
+}
+
+func (wla *attestor) recordResult(name string, start time.Time, err error) {
+	// Deny verdicts are the plugin working as intended
+	if status.Code(err) == codes.PermissionDenied {
+		err = nil
+	}
+
+	wla.resultsMtx.Lock()
+	defer wla.resultsMtx.Unlock()
+	result := attestorResult{
+		attestedAt: start,
+		latency:    time.Since(start),
+		err:        err,
+	}
+	if err != nil {
+		result.consecutiveFailures = wla.results[name].consecutiveFailures + 1
+	}
+	wla.results[name] = result
+}
+
+type attestorHealth struct {
+	a *attestor
+}
+
+// CheckHealth reports the outcome of the last attestation performed by each
+// workload attestor plugin. Readiness is lost while the attestations of any
+// plugin keep failing.
+func (h *attestorHealth) CheckHealth() health.State {
+	h.a.resultsMtx.Lock()
+	defer h.a.resultsMtx.Unlock()
+
+	ready := true
+	details := make(map[string]attestorHealthDetails, len(h.a.results))
+	for name, result := range h.a.results {
+		if result.consecutiveFailures >= failedAttestationThreshold {
+			ready = false
+		}
+		details[name] = attestorHealthDetails{
+			LastAttestation:     result.attestedAt.UTC().Format(time.RFC3339),
+			Latency:             result.latency.String(),
+			ConsecutiveFailures: result.consecutiveFailures,
+			AttestErr:           errString(result.err),
+		}
+	}
+
+	return health.State{
+		Live:         true,
+		Ready:        ready,
+		ReadyDetails: details,
+		LiveDetails:  details,
+	}
+}
+
+type attestorHealthDetails struct {
+	LastAttestation     string `json:"last_attestation"`
+	Latency             string `json:"latency"`
+	ConsecutiveFailures int    `json:"consecutive_failures,omitempty"`
+	AttestErr           string `json:"attest_err,omitempty"`
+}
+
+func errString(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
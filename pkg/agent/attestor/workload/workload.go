@@ -4,40 +4,88 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/spire/pkg/agent/catalog"
 	"github.com/spiffe/spire/pkg/agent/plugin/workloadattestor"
+	"github.com/spiffe/spire/pkg/common/health"
 	"github.com/spiffe/spire/pkg/common/telemetry"
 	telemetry_workload "github.com/spiffe/spire/pkg/common/telemetry/agent/workloadapi"
 	"github.com/spiffe/spire/proto/spire/common"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type attestor struct {
 	c *Config
+
+	// results holds the outcome of the last attestation performed by each
+	// workload attestor plugin, keyed by plugin name.
+	resultsMtx sync.Mutex
+	results    map[string]attestorResult
+
+	// slots limits the number of concurrent attestations, if set. queued is
+	// the number of attestations waiting for a slot.
+	slots     chan struct{}
+	queuedMtx sync.Mutex
+	queued    int
 }
 
 type Attestor interface {
-	Attest(ctx context.Context, pid int) []*common.Selector
+	Attest(ctx context.Context, pid int) ([]*common.Selector, error)
 }
 
-func New(config *Config) Attestor {
-	return newAttestor(config)
+func New(config *Config) (Attestor, error) {
+	a := newAttestor(config)
+	if config.HealthChecker != nil {
+		if err := config.HealthChecker.AddCheck("agent.workload_attestors", &attestorHealth{a: a}); err != nil {
+			return nil, fmt.Errorf("failed adding healthcheck: %w", err)
+		}
+	}
+	return a, nil
 }
 
 func newAttestor(config *Config) *attestor {
-	return &attestor{c: config}
+	a := &attestor{
+		c:       config,
+		results: make(map[string]attestorResult),
+	}
+	if config.MaxConcurrentAttestations > 0 {
+		a.slots = make(chan struct{}, config.MaxConcurrentAttestations)
+	}
+	return a
 }
 
 type Config struct {
 	Catalog catalog.Catalog
 	Log     logrus.FieldLogger
 	Metrics telemetry.Metrics
+
+	// HealthChecker, if set, is used to report the outcome of the last
+	// attestation performed by each workload attestor plugin.
+	HealthChecker health.Checker
+
+	// MaxConcurrentAttestations, if positive, limits the number of workloads
+	// attested concurrently. Further attestations wait for one to complete,
+	// which keeps a burst of new connections (e.g. after a node reboot) from
+	// overwhelming the kubelet or container runtime queried by the plugins.
+	MaxConcurrentAttestations int
 }
 
 // Attest invokes all workload attestor plugins against the provided PID. If an error
 // is encountered, it is logged and selectors from the failing plugin are discarded.
-func (wla *attestor) Attest(ctx context.Context, pid int) []*common.Selector {
+// If any plugin denies the workload by returning a PermissionDenied status,
+// attestation fails regardless of the selectors returned by the other plugins.
+func (wla *attestor) Attest(ctx context.Context, pid int) ([]*common.Selector, error) {
+	release, err := wla.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	counter := telemetry_workload.StartAttestationCall(wla.c.Metrics)
 	defer counter.Done(nil)
 
@@ -59,14 +107,25 @@ func (wla *attestor) Attest(ctx context.Context, pid int) []*common.Selector {
 
 	// Collect the results
 	selectors := []*common.Selector{}
+	var denyErr error
 	for i := 0; i < len(plugins); i++ {
 		select {
 		case s := <-sChan:
 			selectors = append(selectors, s...)
 		case err := <-errChan:
+			if status.Code(err) == codes.PermissionDenied {
+				log.WithError(err).Warn("Workload attestation denied by plugin")
+				if denyErr == nil {
+					denyErr = status.Errorf(codes.PermissionDenied, "workload attestation denied: %s", status.Convert(err).Message())
+				}
+				continue
+			}
 			log.WithError(err).Error("Failed to collect all selectors for PID")
 		}
 	}
+	if denyErr != nil {
+		return nil, denyErr
+	}
 
 	telemetry_workload.AddDiscoveredSelectorsSample(wla.c.Metrics, float32(len(selectors)))
 	// The agent health check currently exercises the Workload API. Since this
@@ -76,7 +135,76 @@ func (wla *attestor) Attest(ctx context.Context, pid int) []*common.Selector {
 	if pid != os.Getpid() {
 		log.WithField(telemetry.Selectors, selectors).Debug("PID attested to have selectors")
 	}
-	return selectors
+	return selectors, nil
+}
+
+// acquireSlot waits until the attestation can proceed without exceeding the
+// maximum number of concurrent attestations, and returns a function that
+// frees the slot.
+func (wla *attestor) acquireSlot(ctx context.Context) (func(), error) {
+	if wla.slots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-wla.slots }
+
+	// Fast path when a slot is free
+	select {
+	case wla.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	start := time.Now()
+	wla.addQueued(1)
+	defer wla.addQueued(-1)
+
+	select {
+	case wla.slots <- struct{}{}:
+		telemetry_workload.MeasureAttestationQueueTime(wla.c.Metrics, start)
+		return release, nil
+	case <-ctx.Done():
+		return nil, status.Errorf(codes.Canceled, "workload attestation canceled while queued: %v", ctx.Err())
+	}
+}
+
+func (wla *attestor) addQueued(delta int) {
+	wla.queuedMtx.Lock()
+	defer wla.queuedMtx.Unlock()
+	wla.queued += delta
+	telemetry_workload.SetQueuedAttestationsGauge(wla.c.Metrics, wla.queued)
+}
+
+// PluginResult holds the outcome of the attestation of a workload by a single
+// workload attestor plugin.
+type PluginResult struct {
+	Name      string
+	Selectors []*common.Selector
+	Err       error
+}
+
+// AttestEach invokes all workload attestor plugins against the provided PID
+// and returns the outcome of each plugin, sorted by plugin name. Unlike
+// Attest, it does not combine the results, and does not emit metrics or
+// affect health checks, since it is meant for troubleshooting.
+func AttestEach(ctx context.Context, cat catalog.Catalog, pid int) []PluginResult {
+	plugins := cat.GetWorkloadAttestors()
+	results := make([]PluginResult, len(plugins))
+
+	var wg sync.WaitGroup
+	for i, p := range plugins {
+		wg.Add(1)
+		go func(i int, p workloadattestor.WorkloadAttestor) {
+			defer wg.Done()
+			selectors, err := p.Attest(ctx, pid)
+			results[i] = PluginResult{Name: p.Name(), Selectors: selectors, Err: err}
+		}(i, p)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results
 }
 
 // invokeAttestor invokes attestation against the supplied plugin. Should be called from a goroutine.
@@ -84,8 +212,15 @@ func (wla *attestor) invokeAttestor(ctx context.Context, a workloadattestor.Work
 	counter := telemetry_workload.StartAttestorCall(wla.c.Metrics, a.Name())
 	defer counter.Done(&err)
 
+	start := time.Now()
 	selectors, err := a.Attest(ctx, pid)
-	if err != nil {
+	wla.recordResult(a.Name(), start, err)
+	switch {
+	case status.Code(err) == codes.PermissionDenied:
+		// Deny verdicts keep their status so they can be told apart from
+		// plugin failures.
+		return nil, err
+	case err != nil:
 		return nil, fmt.Errorf("workload attestor %q failed: %w", a.Name(), err)
 	}
 	return selectors, nil
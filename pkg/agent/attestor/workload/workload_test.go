@@ -15,6 +15,7 @@ import (
 	"github.com/spiffe/spire/test/fakes/fakeworkloadattestor"
 	"github.com/spiffe/spire/test/spiretest"
 	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc/codes"
 )
 
 var (
@@ -66,19 +67,23 @@ func (s *WorkloadAttestorTestSuite) TestAttestWorkload() {
 	)
 
 	// both attestors succeed but with no selectors
-	selectors := s.attestor.Attest(ctx, 1)
+	selectors, err := s.attestor.Attest(ctx, 1)
+	s.Require().NoError(err)
 	s.Empty(selectors)
 
 	// attestor1 has selectors, but not attestor2
-	selectors = s.attestor.Attest(ctx, 2)
+	selectors, err = s.attestor.Attest(ctx, 2)
+	s.Require().NoError(err)
 	spiretest.AssertProtoListEqual(s.T(), selectors1, selectors)
 
 	// attestor2 has selectors, attestor1 fails
-	selectors = s.attestor.Attest(ctx, 3)
+	selectors, err = s.attestor.Attest(ctx, 3)
+	s.Require().NoError(err)
 	spiretest.AssertProtoListEqual(s.T(), selectors2, selectors)
 
 	// both have selectors
-	selectors = s.attestor.Attest(ctx, 4)
+	selectors, err = s.attestor.Attest(ctx, 4)
+	s.Require().NoError(err)
 	util.SortSelectors(selectors)
 	combined := make([]*common.Selector, 0, len(selectors1)+len(selectors2))
 	combined = append(combined, selectors1...)
@@ -87,6 +92,147 @@ func (s *WorkloadAttestorTestSuite) TestAttestWorkload() {
 	spiretest.AssertProtoListEqual(s.T(), combined, selectors)
 }
 
+func (s *WorkloadAttestorTestSuite) TestAttestWorkloadDenied() {
+	s.catalog.SetWorkloadAttestors(
+		fakeworkloadattestor.New(s.T(), "fake1", attestor1Pids),
+		fakeworkloadattestor.NewDenier(s.T(), "denier", map[int32]string{
+			2: "image is not signed",
+		}),
+	)
+
+	// the deny verdict wins over the selectors of the other attestor
+	selectors, err := s.attestor.Attest(ctx, 2)
+	spiretest.AssertGRPCStatus(s.T(), err, codes.PermissionDenied, "workload attestation denied: workloadattestor(denier): image is not signed")
+	s.Nil(selectors)
+
+	// workloads that are not denied are attested as usual
+	selectors, err = s.attestor.Attest(ctx, 4)
+	s.Require().NoError(err)
+	spiretest.AssertProtoListEqual(s.T(), selectors1, selectors)
+}
+
+func (s *WorkloadAttestorTestSuite) TestAttestEach() {
+	s.catalog.SetWorkloadAttestors(
+		fakeworkloadattestor.New(s.T(), "fake2", attestor2Pids),
+		fakeworkloadattestor.New(s.T(), "fake1", attestor1Pids),
+	)
+
+	// results are sorted by plugin name
+	results := AttestEach(ctx, s.catalog, 4)
+	s.Require().Len(results, 2)
+	s.Equal("fake1", results[0].Name)
+	s.NoError(results[0].Err)
+	spiretest.AssertProtoListEqual(s.T(), selectors1, results[0].Selectors)
+	s.Equal("fake2", results[1].Name)
+	s.NoError(results[1].Err)
+	spiretest.AssertProtoListEqual(s.T(), selectors2, results[1].Selectors)
+
+	// failures are reported along with the selectors of the other plugins
+	results = AttestEach(ctx, s.catalog, 3)
+	s.Require().Len(results, 2)
+	s.Equal("fake1", results[0].Name)
+	s.ErrorContains(results[0].Err, "cannot attest pid 3")
+	s.Empty(results[0].Selectors)
+	s.Equal("fake2", results[1].Name)
+	s.NoError(results[1].Err)
+	spiretest.AssertProtoListEqual(s.T(), selectors2, results[1].Selectors)
+}
+
+func (s *WorkloadAttestorTestSuite) TestDebugAttest() {
+	s.catalog.SetWorkloadAttestors(
+		fakeworkloadattestor.New(s.T(), "fake1", attestor1Pids),
+		fakeworkloadattestor.New(s.T(), "fake2", attestor2Pids),
+	)
+	entry := &common.RegistrationEntry{
+		EntryId:   "ENTRY",
+		SpiffeId:  "spiffe://example.org/workload",
+		ParentId:  "spiffe://example.org/agent",
+		Selectors: selectors2,
+	}
+	entries := entryMatcherFunc(func(selectors []*common.Selector) []*common.RegistrationEntry {
+		if len(selectors) == 1 && selectors[0].Type == "fake2" {
+			return []*common.RegistrationEntry{entry}
+		}
+		return nil
+	})
+
+	attestation := DebugAttest(ctx, s.catalog, entries, 3)
+	s.Require().Len(attestation.Attestors, 2)
+	s.Contains(attestation.Attestors[0].Error, "cannot attest pid 3")
+	attestation.Attestors[0].Error = ""
+	s.Equal(&DebugAttestation{
+		PID: 3,
+		Attestors: []DebugPluginResult{
+			{Name: "fake1"},
+			{Name: "fake2", Selectors: []string{"fake2:baz"}},
+		},
+		Entries: []DebugEntry{
+			{
+				EntryID:   "ENTRY",
+				SPIFFEID:  "spiffe://example.org/workload",
+				ParentID:  "spiffe://example.org/agent",
+				Selectors: []string{"fake2:baz"},
+			},
+		},
+	}, attestation)
+}
+
+type entryMatcherFunc func(selectors []*common.Selector) []*common.RegistrationEntry
+
+func (fn entryMatcherFunc) MatchingRegistrationEntries(selectors []*common.Selector) []*common.RegistrationEntry {
+	return fn(selectors)
+}
+
+func (s *WorkloadAttestorTestSuite) TestAttestWorkloadHealth() {
+	s.catalog.SetWorkloadAttestors(
+		fakeworkloadattestor.New(s.T(), "fake1", attestor1Pids),
+		fakeworkloadattestor.New(s.T(), "fake2", attestor2Pids),
+	)
+	h := &attestorHealth{a: s.attestor}
+
+	// Nothing is reported until the attestors are used
+	state := h.CheckHealth()
+	s.True(state.Live)
+	s.True(state.Ready)
+	s.Empty(state.ReadyDetails)
+
+	// A single failure of attestor1 on process 3 does not affect readiness
+	_, err := s.attestor.Attest(ctx, 3)
+	s.Require().NoError(err)
+	state = h.CheckHealth()
+	s.True(state.Live)
+	s.True(state.Ready)
+	details := state.ReadyDetails.(map[string]attestorHealthDetails)
+	s.Require().Len(details, 2)
+	s.Contains(details["fake1"].AttestErr, "cannot attest pid 3")
+	s.Equal(1, details["fake1"].ConsecutiveFailures)
+	s.NotEmpty(details["fake1"].LastAttestation)
+	s.NotEmpty(details["fake1"].Latency)
+	s.Empty(details["fake2"].AttestErr)
+
+	// Sustained failures do
+	for i := 1; i < failedAttestationThreshold; i++ {
+		_, err = s.attestor.Attest(ctx, 3)
+		s.Require().NoError(err)
+	}
+	state = h.CheckHealth()
+	s.True(state.Live)
+	s.False(state.Ready)
+	details = state.ReadyDetails.(map[string]attestorHealthDetails)
+	s.Equal(failedAttestationThreshold, details["fake1"].ConsecutiveFailures)
+	s.Zero(details["fake2"].ConsecutiveFailures)
+
+	// Both attestors succeed on process 4
+	_, err = s.attestor.Attest(ctx, 4)
+	s.Require().NoError(err)
+	state = h.CheckHealth()
+	s.True(state.Ready)
+	details = state.ReadyDetails.(map[string]attestorHealthDetails)
+	s.Empty(details["fake1"].AttestErr)
+	s.Zero(details["fake1"].ConsecutiveFailures)
+	s.Empty(details["fake2"].AttestErr)
+}
+
 func (s *WorkloadAttestorTestSuite) TestAttestWorkloadMetrics() {
 	// Add only one attestor
 	s.catalog.SetWorkloadAttestors(
@@ -97,7 +243,8 @@ func (s *WorkloadAttestorTestSuite) TestAttestWorkloadMetrics() {
 	metrics := fakemetrics.New()
 	s.attestor.c.Metrics = metrics
 
-	selectors := s.attestor.Attest(ctx, 2)
+	selectors, err := s.attestor.Attest(ctx, 2)
+	s.Require().NoError(err)
 
 	// Create expected metrics
 	expected := fakemetrics.New()
@@ -114,12 +261,13 @@ func (s *WorkloadAttestorTestSuite) TestAttestWorkloadMetrics() {
 	s.attestor.c.Metrics = metrics
 
 	// No selectors expected
-	selectors = s.attestor.Attest(ctx, 3)
+	selectors, err = s.attestor.Attest(ctx, 3)
+	s.Require().NoError(err)
 	s.Empty(selectors)
 
 	// Create expected metrics with error key
 	expected = fakemetrics.New()
-	err := errors.New("some error")
+	err = errors.New("some error")
 	attestorCounter = telemetry_workload.StartAttestorCall(expected, "fake1")
 	attestorCounter.Done(&err)
 	telemetry_workload.AddDiscoveredSelectorsSample(expected, float32(0))
@@ -128,3 +276,33 @@ func (s *WorkloadAttestorTestSuite) TestAttestWorkloadMetrics() {
 
 	s.Require().Equal(expected.AllMetrics(), metrics.AllMetrics())
 }
+
+func (s *WorkloadAttestorTestSuite) TestAttestWorkloadConcurrencyLimit() {
+	s.catalog.SetWorkloadAttestors(
+		fakeworkloadattestor.New(s.T(), "fake1", attestor1Pids),
+	)
+	metrics := fakemetrics.New()
+	s.attestor.c.Metrics = metrics
+	s.attestor.slots = make(chan struct{}, 1)
+
+	// Take the only slot
+	release, err := s.attestor.acquireSlot(ctx)
+	s.Require().NoError(err)
+
+	// Attestations are queued until the slot is freed, or they are canceled
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = s.attestor.Attest(canceledCtx, 2)
+	spiretest.RequireGRPCStatusContains(s.T(), err, codes.Canceled, "workload attestation canceled while queued")
+
+	expected := fakemetrics.New()
+	telemetry_workload.SetQueuedAttestationsGauge(expected, 1)
+	telemetry_workload.SetQueuedAttestationsGauge(expected, 0)
+	s.Require().Equal(expected.AllMetrics(), metrics.AllMetrics())
+
+	// Once the slot is freed, attestations proceed without waiting
+	release()
+	selectors, err := s.attestor.Attest(ctx, 2)
+	s.Require().NoError(err)
+	spiretest.AssertProtoListEqual(s.T(), selectors1, selectors)
+}
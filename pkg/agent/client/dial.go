@@ -13,7 +13,9 @@ import (
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
 	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/spiffe/spire/pkg/common/clockskew"
 	"github.com/spiffe/spire/pkg/common/idutil"
+	"github.com/spiffe/spire/pkg/common/negotiation"
 	"github.com/spiffe/spire/pkg/common/x509util"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
@@ -63,12 +65,23 @@ func DialServer(ctx context.Context, config DialServerConfig) (*grpc.ClientConn,
 	if config.dialContext == nil {
 		config.dialContext = grpc.DialContext
 	}
+	negotiationClient := negotiation.NewClient()
 	client, err := config.dialContext(ctx, config.Address,
 		grpc.WithDefaultServiceConfig(roundRobinServiceConfig),
 		grpc.FailOnNonTempDialError(true),
 		grpc.WithBlock(),
 		grpc.WithReturnConnectionError(),
 		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		// Report the agent time so the server can detect clock skew, and
+		// negotiate optional features with the server
+		grpc.WithChainUnaryInterceptor(
+			clockskew.UnaryClientInterceptor(time.Now),
+			negotiationClient.UnaryClientInterceptor(),
+		),
+		grpc.WithChainStreamInterceptor(
+			clockskew.StreamClientInterceptor(time.Now),
+			negotiationClient.StreamClientInterceptor(),
+		),
 	)
 	switch {
 	case err == nil:
@@ -0,0 +1,333 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+const (
+	srvScheme    = "spire-srv"
+	staticScheme = "spire-static"
+
+	// weightedBalancerName is the name of the balancer that picks servers
+	// by priority and weight. The discovery resolvers select it through the
+	// service config.
+	weightedBalancerName  = "spire_weighted"
+	weightedServiceConfig = `{ "loadBalancingConfig": [ { "spire_weighted": {} } ] }`
+
+	srvRefreshInterval    = 30 * time.Second
+	srvMinResolveInterval = 5 * time.Second
+	srvLookupTimeout      = 10 * time.Second
+
+	defaultServerWeight = 1
+	weightParam         = "weight"
+	priorityParam       = "priority"
+)
+
+func init() {
+	resolver.Register(&srvResolverBuilder{lookupSRV: net.DefaultResolver.LookupSRV})
+	resolver.Register(staticResolverBuilder{})
+	balancer.Register(base.NewBalancerBuilder(weightedBalancerName, weightedPickerBuilder{}, base.Config{}))
+}
+
+// ServerEndpoint is a server the agent can connect to.
+type ServerEndpoint struct {
+	// Address is the host and port of the server.
+	Address string
+
+	// Weight is the relative share of the connections the server gets
+	// among the servers with the same priority. Zero means one.
+	Weight uint32
+
+	// Priority of the server. Requests are only sent to servers with a
+	// higher priority value when none of the servers with a lower value
+	// can be reached.
+	Priority uint32
+}
+
+// SRVTarget returns the target to dial to discover the servers through the
+// DNS SRV records with the given name, e.g.
+// "_spire-server._tcp.example.org". The records are looked up periodically.
+func SRVTarget(name string) string {
+	return srvScheme + ":///" + name
+}
+
+// StaticTarget returns the target to dial to connect to the given servers.
+func StaticTarget(servers []ServerEndpoint) string {
+	parts := make([]string, 0, len(servers))
+	for _, server := range servers {
+		part := server.Address
+		if server.Weight != 0 {
+			part += fmt.Sprintf(";%s=%d", weightParam, server.Weight)
+		}
+		if server.Priority != 0 {
+			part += fmt.Sprintf(";%s=%d", priorityParam, server.Priority)
+		}
+		parts = append(parts, part)
+	}
+	return staticScheme + ":///" + strings.Join(parts, ",")
+}
+
+// serverInfo holds the weight and priority of a server address. It is
+// attached to the addresses passed to the weighted balancer.
+type serverInfo struct {
+	weight   uint32
+	priority uint32
+}
+
+type serverInfoKey struct{}
+
+func newServerAddress(addr string, weight, priority uint32) resolver.Address {
+	return resolver.Address{
+		Addr: addr,
+		BalancerAttributes: attributes.New(serverInfoKey{}, serverInfo{
+			weight:   weight,
+			priority: priority,
+		}),
+	}
+}
+
+func getServerInfo(addr resolver.Address) serverInfo {
+	info, _ := addr.BalancerAttributes.Value(serverInfoKey{}).(serverInfo)
+	return info
+}
+
+func parseStaticServers(endpoint string) ([]resolver.Address, error) {
+	var addrs []resolver.Address
+	for _, part := range strings.Split(endpoint, ",") {
+		fields := strings.Split(part, ";")
+		if fields[0] == "" {
+			return nil, errors.New("server address is empty")
+		}
+		weight, priority := uint64(defaultServerWeight), uint64(0)
+		for _, field := range fields[1:] {
+			name, value, _ := strings.Cut(field, "=")
+			n, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s for server %q: %w", name, fields[0], err)
+			}
+			switch name {
+			case weightParam:
+				weight = n
+			case priorityParam:
+				priority = n
+			default:
+				return nil, fmt.Errorf("unknown parameter %q for server %q", name, fields[0])
+			}
+		}
+		addrs = append(addrs, newServerAddress(fields[0], uint32(weight), uint32(priority)))
+	}
+	return addrs, nil
+}
+
+func targetEndpoint(target resolver.Target) string {
+	if target.URL.Opaque != "" {
+		return target.URL.Opaque
+	}
+	return strings.TrimPrefix(target.URL.Path, "/")
+}
+
+type staticResolverBuilder struct{}
+
+func (staticResolverBuilder) Scheme() string {
+	return staticScheme
+}
+
+func (staticResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	addrs, err := parseStaticServers(targetEndpoint(target))
+	if err != nil {
+		return nil, err
+	}
+	_ = cc.UpdateState(resolver.State{
+		Addresses:     addrs,
+		ServiceConfig: cc.ParseServiceConfig(weightedServiceConfig),
+	})
+	return staticResolver{}, nil
+}
+
+type staticResolver struct{}
+
+func (staticResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (staticResolver) Close() {}
+
+type srvResolverBuilder struct {
+	lookupSRV func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+func (b *srvResolverBuilder) Scheme() string {
+	return srvScheme
+}
+
+func (b *srvResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	name := targetEndpoint(target)
+	if name == "" {
+		return nil, errors.New("SRV record name is empty")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &srvResolver{
+		name:          name,
+		cc:            cc,
+		lookupSRV:     b.lookupSRV,
+		serviceConfig: cc.ParseServiceConfig(weightedServiceConfig),
+		resolveNow:    make(chan struct{}, 1),
+		cancel:        cancel,
+	}
+	r.wg.Add(1)
+	go r.run(ctx)
+	return r, nil
+}
+
+type srvResolver struct {
+	name          string
+	cc            resolver.ClientConn
+	lookupSRV     func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+	serviceConfig *serviceconfig.ParseResult
+	resolveNow    chan struct{}
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+func (r *srvResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case r.resolveNow <- struct{}{}:
+	default:
+	}
+}
+
+func (r *srvResolver) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *srvResolver) run(ctx context.Context) {
+	defer r.wg.Done()
+	for {
+		r.resolve(ctx)
+
+		// Don't hammer the DNS server when connections keep failing
+		select {
+		case <-time.After(srvMinResolveInterval):
+		case <-ctx.Done():
+			return
+		}
+
+		timer := time.NewTimer(srvRefreshInterval - srvMinResolveInterval)
+		select {
+		case <-timer.C:
+		case <-r.resolveNow:
+			timer.Stop()
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (r *srvResolver) resolve(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, srvLookupTimeout)
+	defer cancel()
+
+	_, records, err := r.lookupSRV(ctx, "", "", r.name)
+	switch {
+	case err != nil:
+		r.cc.ReportError(fmt.Errorf("failed to look up SRV records %q: %w", r.name, err))
+		return
+	case len(records) == 0:
+		r.cc.ReportError(fmt.Errorf("no SRV records found for %q", r.name))
+		return
+	}
+
+	addrs := make([]resolver.Address, 0, len(records))
+	for _, record := range records {
+		addr := net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+		addrs = append(addrs, newServerAddress(addr, uint32(record.Weight), uint32(record.Priority)))
+	}
+	_ = r.cc.UpdateState(resolver.State{
+		Addresses:     addrs,
+		ServiceConfig: r.serviceConfig,
+	})
+}
+
+// weightedPickerBuilder builds pickers that pick among the servers with a
+// ready connection and the lowest priority value, proportionally to their
+// weights. Servers that cannot be connected to are not ready, which makes
+// the agent fail over to the remaining servers, or to the servers with the
+// next priority.
+//
+// As in the selection algorithm of RFC 2782, servers with a zero weight are
+// picked with a very small chance when other servers have a weight, and
+// uniformly when none of them has.
+type weightedPickerBuilder struct{}
+
+func (weightedPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	var p *weightedPicker
+	for subConn, subConnInfo := range info.ReadySCs {
+		server := getServerInfo(subConnInfo.Address)
+		switch {
+		case p == nil || server.priority < p.priority:
+			p = &weightedPicker{priority: server.priority}
+		case server.priority > p.priority:
+			continue
+		}
+		if server.weight == 0 {
+			p.unweighted = append(p.unweighted, subConn)
+			continue
+		}
+		p.total += uint64(server.weight)
+		p.subConns = append(p.subConns, subConn)
+		p.cumulativeWeights = append(p.cumulativeWeights, p.total)
+	}
+	return p
+}
+
+type weightedPicker struct {
+	priority          uint32
+	subConns          []balancer.SubConn
+	cumulativeWeights []uint64
+	total             uint64
+
+	// unweighted holds the servers with a zero weight
+	unweighted []balancer.SubConn
+}
+
+func (p *weightedPicker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	// Like in RFC 2782, a number is picked between zero and the total weight,
+	// inclusive, and the servers with a zero weight are only picked when it
+	// is zero.
+	total := p.total
+	if len(p.unweighted) > 0 {
+		total++
+	}
+	n := uint64(rand.Int63n(int64(total))) //nolint: gosec // not used for security
+	if len(p.unweighted) > 0 {
+		if n == 0 {
+			return balancer.PickResult{SubConn: p.unweighted[rand.Intn(len(p.unweighted))]}, nil //nolint: gosec // not used for security
+		}
+		n--
+	}
+
+	i := sort.Search(len(p.cumulativeWeights), func(i int) bool {
+		return p.cumulativeWeights[i] > n
+	})
+	return balancer.PickResult{SubConn: p.subConns[i]}, nil
+}
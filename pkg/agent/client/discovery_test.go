@@ -0,0 +1,228 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+func TestStaticTarget(t *testing.T) {
+	target := StaticTarget([]ServerEndpoint{
+		{Address: "10.0.0.1:8081", Weight: 2},
+		{Address: "[::1]:8081", Priority: 1},
+		{Address: "spire-server:8081"},
+	})
+	require.Equal(t, "spire-static:///10.0.0.1:8081;weight=2,[::1]:8081;priority=1,spire-server:8081", target)
+
+	cc := newFakeResolverClientConn()
+	r, err := staticResolverBuilder{}.Build(parseTarget(t, target), cc, resolver.BuildOptions{})
+	require.NoError(t, err)
+	defer r.Close()
+
+	state := cc.lastState(t)
+	require.Len(t, state.Addresses, 3)
+	assertServerAddress(t, state.Addresses[0], "10.0.0.1:8081", 2, 0)
+	assertServerAddress(t, state.Addresses[1], "[::1]:8081", 1, 1)
+	assertServerAddress(t, state.Addresses[2], "spire-server:8081", 1, 0)
+	require.NotNil(t, state.ServiceConfig)
+}
+
+func TestStaticTargetInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		target    string
+		expectErr string
+	}{
+		{
+			name:      "empty address",
+			target:    "spire-static:///10.0.0.1:8081,",
+			expectErr: "server address is empty",
+		},
+		{
+			name:      "invalid weight",
+			target:    "spire-static:///10.0.0.1:8081;weight=heavy",
+			expectErr: `invalid weight for server "10.0.0.1:8081": strconv.ParseUint: parsing "heavy": invalid syntax`,
+		},
+		{
+			name:      "unknown parameter",
+			target:    "spire-static:///10.0.0.1:8081;zone=1",
+			expectErr: `unknown parameter "zone" for server "10.0.0.1:8081"`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := staticResolverBuilder{}.Build(parseTarget(t, tt.target), newFakeResolverClientConn(), resolver.BuildOptions{})
+			require.EqualError(t, err, tt.expectErr)
+		})
+	}
+}
+
+func TestSRVTarget(t *testing.T) {
+	lookups := make(chan string, 10)
+	records := []*net.SRV{
+		{Target: "server1.example.org.", Port: 8081, Priority: 0, Weight: 10},
+		{Target: "server2.example.org.", Port: 8082, Priority: 1, Weight: 0},
+	}
+	builder := &srvResolverBuilder{
+		lookupSRV: func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+			lookups <- name
+			return "", records, nil
+		},
+	}
+
+	target := SRVTarget("_spire-server._tcp.example.org")
+	require.Equal(t, "spire-srv:///_spire-server._tcp.example.org", target)
+
+	cc := newFakeResolverClientConn()
+	r, err := builder.Build(parseTarget(t, target), cc, resolver.BuildOptions{})
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.Equal(t, "_spire-server._tcp.example.org", <-lookups)
+	state := cc.lastState(t)
+	require.Len(t, state.Addresses, 2)
+	assertServerAddress(t, state.Addresses[0], "server1.example.org:8081", 10, 0)
+	assertServerAddress(t, state.Addresses[1], "server2.example.org:8082", 0, 1)
+	require.NotNil(t, state.ServiceConfig)
+}
+
+func TestSRVTargetLookupFailure(t *testing.T) {
+	builder := &srvResolverBuilder{
+		lookupSRV: func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+			return "", nil, errors.New("oh no")
+		},
+	}
+
+	cc := newFakeResolverClientConn()
+	r, err := builder.Build(parseTarget(t, SRVTarget("_spire-server._tcp.example.org")), cc, resolver.BuildOptions{})
+	require.NoError(t, err)
+	defer r.Close()
+
+	require.EqualError(t, <-cc.errs, `failed to look up SRV records "_spire-server._tcp.example.org": oh no`)
+}
+
+func TestWeightedPicker(t *testing.T) {
+	primary1 := &fakeSubConn{name: "primary1"}
+	primary2 := &fakeSubConn{name: "primary2"}
+	secondary := &fakeSubConn{name: "secondary"}
+
+	pickCounts := func(picker balancer.Picker) map[string]int {
+		counts := make(map[string]int)
+		for i := 0; i < 1000; i++ {
+			result, err := picker.Pick(balancer.PickInfo{})
+			require.NoError(t, err)
+			counts[result.SubConn.(*fakeSubConn).name]++
+		}
+		return counts
+	}
+
+	// Only the servers with the lowest priority value are picked, according
+	// to their weights
+	counts := pickCounts(weightedPickerBuilder{}.Build(base.PickerBuildInfo{
+		ReadySCs: map[balancer.SubConn]base.SubConnInfo{
+			primary1:  {Address: newServerAddress("primary1:8081", 3, 0)},
+			primary2:  {Address: newServerAddress("primary2:8081", 1, 0)},
+			secondary: {Address: newServerAddress("secondary:8081", 100, 1)},
+		},
+	}))
+	require.Zero(t, counts["secondary"])
+	require.Greater(t, counts["primary1"], counts["primary2"])
+
+	// Servers with a zero weight are rarely picked when other servers have
+	// a weight
+	counts = pickCounts(weightedPickerBuilder{}.Build(base.PickerBuildInfo{
+		ReadySCs: map[balancer.SubConn]base.SubConnInfo{
+			primary1: {Address: newServerAddress("primary1:8081", 1000, 0)},
+			primary2: {Address: newServerAddress("primary2:8081", 0, 0)},
+		},
+	}))
+	require.Less(t, counts["primary2"], 20)
+	require.Equal(t, 1000, counts["primary1"]+counts["primary2"])
+
+	// Servers with a zero weight are picked uniformly when none has a weight
+	counts = pickCounts(weightedPickerBuilder{}.Build(base.PickerBuildInfo{
+		ReadySCs: map[balancer.SubConn]base.SubConnInfo{
+			primary1: {Address: newServerAddress("primary1:8081", 0, 0)},
+			primary2: {Address: newServerAddress("primary2:8081", 0, 0)},
+		},
+	}))
+	require.Greater(t, counts["primary1"], 350)
+	require.Greater(t, counts["primary2"], 350)
+
+	// Fail over to the next priority when no primary server is ready
+	counts = pickCounts(weightedPickerBuilder{}.Build(base.PickerBuildInfo{
+		ReadySCs: map[balancer.SubConn]base.SubConnInfo{
+			secondary: {Address: newServerAddress("secondary:8081", 0, 1)},
+		},
+	}))
+	require.Equal(t, map[string]int{"secondary": 1000}, counts)
+
+	// No server is ready
+	_, err := weightedPickerBuilder{}.Build(base.PickerBuildInfo{}).Pick(balancer.PickInfo{})
+	require.Equal(t, balancer.ErrNoSubConnAvailable, err)
+}
+
+func parseTarget(t *testing.T, target string) resolver.Target {
+	u, err := url.Parse(target)
+	require.NoError(t, err)
+	return resolver.Target{URL: *u}
+}
+
+func assertServerAddress(t *testing.T, addr resolver.Address, expectAddr string, expectWeight, expectPriority uint32) {
+	assert.Equal(t, expectAddr, addr.Addr)
+	info := getServerInfo(addr)
+	assert.Equal(t, expectWeight, info.weight)
+	assert.Equal(t, expectPriority, info.priority)
+}
+
+type fakeResolverClientConn struct {
+	resolver.ClientConn
+
+	states chan resolver.State
+	errs   chan error
+}
+
+func newFakeResolverClientConn() *fakeResolverClientConn {
+	return &fakeResolverClientConn{
+		states: make(chan resolver.State, 10),
+		errs:   make(chan error, 10),
+	}
+}
+
+func (cc *fakeResolverClientConn) UpdateState(state resolver.State) error {
+	cc.states <- state
+	return nil
+}
+
+func (cc *fakeResolverClientConn) ReportError(err error) {
+	cc.errs <- err
+}
+
+func (cc *fakeResolverClientConn) ParseServiceConfig(string) *serviceconfig.ParseResult {
+	return &serviceconfig.ParseResult{}
+}
+
+func (cc *fakeResolverClientConn) lastState(t *testing.T) resolver.State {
+	select {
+	case state := <-cc.states:
+		return state
+	case err := <-cc.errs:
+		require.FailNow(t, "unexpected resolver error", err.Error())
+	}
+	return resolver.State{}
+}
+
+type fakeSubConn struct {
+	balancer.SubConn
+
+	name string
+}
@@ -0,0 +1,173 @@
+package cgroups
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const defaultCgroupRoot = "/sys/fs/cgroup"
+
+// PressureDetector detects whether the cgroup v2 of a process is under
+// memory or CPU pressure.
+type PressureDetector struct {
+	fs   FileSystem
+	pid  int32
+	root string
+
+	memoryThreshold      float64
+	cpuPressureThreshold float64
+}
+
+// NewPressureDetector returns a detector for the cgroup of the current
+// process. The memory threshold is the fraction, between 0 and 1, of the
+// cgroup memory limit in use above which the cgroup is under pressure. The CPU
+// pressure threshold is the percentage of time, over the last 10 seconds, that
+// tasks in the cgroup were stalled waiting for CPU above which the cgroup is
+// under pressure. A threshold of zero is not checked.
+func NewPressureDetector(memoryThreshold, cpuPressureThreshold float64) *PressureDetector {
+	return &PressureDetector{
+		fs:                   OSFileSystem{},
+		pid:                  int32(os.Getpid()),
+		root:                 defaultCgroupRoot,
+		memoryThreshold:      memoryThreshold,
+		cpuPressureThreshold: cpuPressureThreshold,
+	}
+}
+
+// UnderPressure returns whether the cgroup is at or above any of the
+// configured thresholds.
+func (d *PressureDetector) UnderPressure() (bool, error) {
+	dir, err := d.cgroupDir()
+	if err != nil {
+		return false, err
+	}
+
+	if d.memoryThreshold > 0 {
+		usage, limit, err := d.memoryUsage(dir)
+		if err != nil {
+			return false, err
+		}
+		if limit > 0 && float64(usage)/float64(limit) >= d.memoryThreshold {
+			return true, nil
+		}
+	}
+
+	if d.cpuPressureThreshold > 0 {
+		pressure, err := d.cpuPressure(dir)
+		if err != nil {
+			return false, err
+		}
+		if pressure >= d.cpuPressureThreshold {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// CheckSupported returns an error if the pressure of the cgroup cannot be
+// detected for the configured thresholds, e.g. because the host does not use
+// cgroup v2 or does not report CPU pressure.
+func (d *PressureDetector) CheckSupported() error {
+	dir, err := d.cgroupDir()
+	if err != nil {
+		return err
+	}
+	if d.memoryThreshold > 0 {
+		if _, _, err := d.memoryUsage(dir); err != nil {
+			return err
+		}
+	}
+	if d.cpuPressureThreshold > 0 {
+		if _, err := d.cpuPressure(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cgroupDir returns the directory of the cgroup v2 of the process
+func (d *PressureDetector) cgroupDir() (string, error) {
+	cgroups, err := GetCgroups(d.pid, d.fs)
+	if err != nil {
+		return "", err
+	}
+	for _, cgroup := range cgroups {
+		if cgroup.HierarchyID == "0" && cgroup.ControllerList == "" {
+			return path.Join(d.root, cgroup.GroupPath), nil
+		}
+	}
+	return "", fmt.Errorf("no cgroup v2 hierarchy found for pid %d", d.pid)
+}
+
+// memoryUsage returns the memory usage and limit of the cgroup, in bytes. The
+// limit is zero if the cgroup has no memory limit.
+func (d *PressureDetector) memoryUsage(dir string) (uint64, uint64, error) {
+	current, err := d.readFile(path.Join(dir, "memory.current"))
+	if err != nil {
+		return 0, 0, err
+	}
+	usage, err := strconv.ParseUint(current, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid memory.current value %q: %w", current, err)
+	}
+
+	maxValue, err := d.readFile(path.Join(dir, "memory.max"))
+	if err != nil {
+		return 0, 0, err
+	}
+	if maxValue == "max" {
+		return usage, 0, nil
+	}
+	limit, err := strconv.ParseUint(maxValue, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid memory.max value %q: %w", maxValue, err)
+	}
+	return usage, limit, nil
+}
+
+// cpuPressure returns the "some" CPU pressure stall average over the last 10
+// seconds, as a percentage.
+func (d *PressureDetector) cpuPressure(dir string) (float64, error) {
+	data, err := d.readFile(path.Join(dir, "cpu.pressure"))
+	if err != nil {
+		return 0, err
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "some" {
+			continue
+		}
+		for _, field := range fields[1:] {
+			if key, value, ok := strings.Cut(field, "="); ok && key == "avg10" {
+				pressure, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return 0, fmt.Errorf("invalid cpu.pressure avg10 value %q: %w", value, err)
+				}
+				return pressure, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no \"some avg10\" value found in cpu.pressure")
+}
+
+func (d *PressureDetector) readFile(name string) (string, error) {
+	f, err := d.fs.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,187 @@
+package cgroups
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	cgUnified = "0::/system.slice/spire-agent.service\n"
+
+	cpuPressure = `some avg10=42.50 avg60=10.00 avg300=2.00 total=123456
+full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+`
+)
+
+func TestPressureDetector(t *testing.T) {
+	const dir = "/sys/fs/cgroup/system.slice/spire-agent.service"
+
+	for _, tt := range []struct {
+		name                 string
+		files                map[string]string
+		memoryThreshold      float64
+		cpuPressureThreshold float64
+		expectPressure       bool
+		expectErr            string
+	}{
+		{
+			name: "no thresholds",
+			files: map[string]string{
+				"/proc/123/cgroup": cgUnified,
+			},
+		},
+		{
+			name: "memory below threshold",
+			files: map[string]string{
+				"/proc/123/cgroup":      cgUnified,
+				dir + "/memory.current": "800\n",
+				dir + "/memory.max":     "1000\n",
+			},
+			memoryThreshold: 0.9,
+		},
+		{
+			name: "memory above threshold",
+			files: map[string]string{
+				"/proc/123/cgroup":      cgUnified,
+				dir + "/memory.current": "950\n",
+				dir + "/memory.max":     "1000\n",
+			},
+			memoryThreshold: 0.9,
+			expectPressure:  true,
+		},
+		{
+			name: "no memory limit",
+			files: map[string]string{
+				"/proc/123/cgroup":      cgUnified,
+				dir + "/memory.current": "950\n",
+				dir + "/memory.max":     "max\n",
+			},
+			memoryThreshold: 0.9,
+		},
+		{
+			name: "cpu pressure below threshold",
+			files: map[string]string{
+				"/proc/123/cgroup":    cgUnified,
+				dir + "/cpu.pressure": cpuPressure,
+			},
+			cpuPressureThreshold: 50,
+		},
+		{
+			name: "cpu pressure above threshold",
+			files: map[string]string{
+				"/proc/123/cgroup":    cgUnified,
+				dir + "/cpu.pressure": cpuPressure,
+			},
+			cpuPressureThreshold: 40,
+			expectPressure:       true,
+		},
+		{
+			name: "cgroup v1",
+			files: map[string]string{
+				"/proc/123/cgroup": cgSimple,
+			},
+			memoryThreshold: 0.9,
+			expectErr:       "no cgroup v2 hierarchy found for pid 123",
+		},
+		{
+			name: "invalid memory usage",
+			files: map[string]string{
+				"/proc/123/cgroup":      cgUnified,
+				dir + "/memory.current": "lots\n",
+				dir + "/memory.max":     "1000\n",
+			},
+			memoryThreshold: 0.9,
+			expectErr:       `invalid memory.current value "lots": strconv.ParseUint: parsing "lots": invalid syntax`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			d := &PressureDetector{
+				fs:                   FakeFileSystem{Files: tt.files},
+				pid:                  123,
+				root:                 defaultCgroupRoot,
+				memoryThreshold:      tt.memoryThreshold,
+				cpuPressureThreshold: tt.cpuPressureThreshold,
+			}
+
+			underPressure, err := d.UnderPressure()
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectPressure, underPressure)
+		})
+	}
+}
+
+func TestPressureDetectorCheckSupported(t *testing.T) {
+	const dir = "/sys/fs/cgroup/system.slice/spire-agent.service"
+
+	for _, tt := range []struct {
+		name                 string
+		files                map[string]string
+		memoryThreshold      float64
+		cpuPressureThreshold float64
+		expectErr            string
+	}{
+		{
+			name: "supported",
+			files: map[string]string{
+				"/proc/123/cgroup":      cgUnified,
+				dir + "/memory.current": "800\n",
+				dir + "/memory.max":     "1000\n",
+				dir + "/cpu.pressure":   cpuPressure,
+			},
+			memoryThreshold:      0.9,
+			cpuPressureThreshold: 50,
+		},
+		{
+			name: "cgroup v1",
+			files: map[string]string{
+				"/proc/123/cgroup": cgSimple,
+			},
+			memoryThreshold: 0.9,
+			expectErr:       "no cgroup v2 hierarchy found for pid 123",
+		},
+		{
+			name: "no cpu pressure",
+			files: map[string]string{
+				"/proc/123/cgroup":      cgUnified,
+				dir + "/memory.current": "800\n",
+				dir + "/memory.max":     "1000\n",
+			},
+			memoryThreshold:      0.9,
+			cpuPressureThreshold: 50,
+			expectErr:            "file does not exist",
+		},
+		{
+			name: "cpu pressure not needed",
+			files: map[string]string{
+				"/proc/123/cgroup":      cgUnified,
+				dir + "/memory.current": "800\n",
+				dir + "/memory.max":     "1000\n",
+			},
+			memoryThreshold: 0.9,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			d := &PressureDetector{
+				fs:                   FakeFileSystem{Files: tt.files},
+				pid:                  123,
+				root:                 defaultCgroupRoot,
+				memoryThreshold:      tt.memoryThreshold,
+				cpuPressureThreshold: tt.cpuPressureThreshold,
+			}
+
+			err := d.CheckSupported()
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -8,16 +8,26 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	node_attestor "github.com/spiffe/spire/pkg/agent/attestor/node"
 	"github.com/spiffe/spire/pkg/agent/workloadkey"
 	"github.com/spiffe/spire/pkg/common/catalog"
 	"github.com/spiffe/spire/pkg/common/health"
+	"github.com/spiffe/spire/pkg/common/log"
 	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/proto/spire/common"
 )
 
 type Config struct {
 	// Address to bind the workload api to
 	BindAddress net.Addr
 
+	// Additional addresses the workload api is served on
+	AdditionalBindAddresses []net.Addr
+
+	// SocketActivation makes the workload api be served on the sockets
+	// passed by systemd, which stay open while the agent restarts
+	SocketActivation bool
+
 	// Directory to store runtime data
 	DataDir string
 
@@ -50,6 +60,14 @@ type Config struct {
 	// LogReopener facilitates handling a signal to rotate log file.
 	LogReopener func(context.Context) error
 
+	// EventBuffer, if set, keeps the recent significant log entries, which
+	// are served by the admin API
+	EventBuffer *log.EventBuffer
+
+	// LogLevels, if set, is the logger whose subsystem log levels can be
+	// changed through the admin API
+	LogLevels *log.Logger
+
 	// Address of SPIRE server
 	ServerAddress string
 
@@ -62,6 +80,13 @@ type Config struct {
 	// X509SVIDCacheMaxSize is a soft limit of max number of SVIDs that would be stored in cache
 	X509SVIDCacheMaxSize int
 
+	// LoadSheddingMemoryThreshold and LoadSheddingCPUPressureThreshold, when
+	// set, are the memory usage (as a fraction of the cgroup memory limit)
+	// and CPU pressure (as a percentage) above which the agent sheds SVID
+	// prefetches
+	LoadSheddingMemoryThreshold      float64
+	LoadSheddingCPUPressureThreshold float64
+
 	// Trust domain and associated CA bundle
 	TrustDomain spiffeid.TrustDomain
 	TrustBundle []*x509.Certificate
@@ -69,6 +94,9 @@ type Config struct {
 	// Join token to use for attestation, if needed
 	JoinToken string
 
+	// AttestationRetry configures how node attestation is retried at startup
+	AttestationRetry node_attestor.RetryConfig
+
 	// If true enables profiling.
 	ProfilingEnabled bool
 
@@ -86,9 +114,20 @@ type Config struct {
 
 	AllowUnauthenticatedVerifiers bool
 
+	// Accept deprecated variants of the Workload API security header sent by
+	// legacy clients
+	AllowLegacySecurityHeaders bool
+
 	// List of allowed claims response when calling ValidateJWTSVID using a foreign identity
 	AllowedForeignJWTClaims []string
 
+	// Workloads attested with any of these selectors are refused JWT-SVIDs
+	DenyJWTSVIDSelectors []*common.Selector
+
+	// MaxConcurrentWorkloadAttestations, if positive, limits the number of
+	// workloads attested concurrently
+	MaxConcurrentWorkloadAttestations int
+
 	AuthorizedDelegates []string
 }
 
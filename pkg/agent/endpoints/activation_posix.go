@@ -0,0 +1,68 @@
+//go:build !windows
+// +build !windows
+
+package endpoints
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd (see
+// sd_listen_fds(3)). Overridden in tests.
+var listenFDsStart = 3
+
+// activatedListeners returns the UDS listeners passed by systemd through
+// socket activation, keyed by socket path. The listeners stay open across
+// agent restarts, so connections that arrive while the agent restarts are
+// queued by the kernel instead of being refused. The environment variables
+// are unset so the listeners are not passed down to child processes.
+func activatedListeners() (map[string]*net.UnixListener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		// The listeners, if any, were not passed to this process
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", os.Getenv("LISTEN_FDS"))
+	}
+
+	listeners := make(map[string]*net.UnixListener, n)
+	for fd := listenFDsStart; fd < listenFDsStart+n; fd++ {
+		syscall.CloseOnExec(fd)
+		f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+		l, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			closeActivatedListeners(listeners)
+			return nil, fmt.Errorf("failed to use socket activated file descriptor %d: %w", fd, err)
+		}
+		unixListener, ok := l.(*net.UnixListener)
+		if !ok {
+			l.Close()
+			closeActivatedListeners(listeners)
+			return nil, fmt.Errorf("socket activated file descriptor %d is a %s listener, not a unix listener", fd, l.Addr().Network())
+		}
+		// The socket belongs to systemd, which keeps it open while the
+		// agent is restarted
+		unixListener.SetUnlinkOnClose(false)
+		listeners[unixListener.Addr().String()] = unixListener
+	}
+	return listeners, nil
+}
+
+func closeActivatedListeners(listeners map[string]*net.UnixListener) {
+	for _, l := range listeners {
+		l.Close()
+	}
+}
@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package endpoints
+
+import (
+	"net"
+
+	"github.com/spiffe/spire/pkg/common/peertracker"
+)
+
+func activatedListeners() (map[string]*net.UnixListener, error) {
+	return nil, peertracker.ErrUnsupportedPlatform
+}
+
+func closeActivatedListeners(map[string]*net.UnixListener) {}
@@ -15,12 +15,21 @@ import (
 	"github.com/spiffe/spire/pkg/agent/endpoints/workload"
 	"github.com/spiffe/spire/pkg/agent/manager"
 	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/proto/spire/common"
 	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type Config struct {
 	BindAddr net.Addr
 
+	// AdditionalBindAddrs are additional addresses the Workload and SDS APIs
+	// are served on, sharing the same state as BindAddr.
+	AdditionalBindAddrs []net.Addr
+
+	// SocketActivation makes the Workload and SDS APIs be served on the
+	// listeners passed by systemd for the bind addresses, if any (Unix only).
+	SocketActivation bool
+
 	Attestor attestor.Attestor
 
 	Manager manager.Manager
@@ -43,8 +52,16 @@ type Config struct {
 
 	AllowUnauthenticatedVerifiers bool
 
+	// AllowLegacySecurityHeaders makes the Workload API accept deprecated
+	// variants of the security header, logging a warning for each request.
+	AllowLegacySecurityHeaders bool
+
 	AllowedForeignJWTClaims []string
 
+	// DenyJWTSVIDSelectors are the selectors of the workloads that are
+	// refused JWT-SVIDs.
+	DenyJWTSVIDSelectors []*common.Selector
+
 	TrustDomain spiffeid.TrustDomain
 
 	// Hooks used by the unit tests to assert that the configuration provided
@@ -26,6 +26,7 @@ type Server interface {
 
 type Endpoints struct {
 	addr              net.Addr
+	additionalAddrs   []net.Addr
 	log               logrus.FieldLogger
 	metrics           telemetry.Metrics
 	workloadAPIServer workload_pb.SpiffeWorkloadAPIServer
@@ -33,6 +34,12 @@ type Endpoints struct {
 	sdsv3Server       secret_v3.SecretDiscoveryServiceServer
 	healthServer      grpc_health_v1.HealthServer
 
+	allowLegacySecurityHeaders bool
+
+	socketActivation bool
+	// activated holds the socket activated listeners not yet served
+	activated map[string]*net.UnixListener
+
 	hooks struct {
 		// test hook used to indicate that is listening
 		listening chan struct{}
@@ -73,6 +80,7 @@ func New(c Config) *Endpoints {
 		Attestor:                      attestor,
 		AllowUnauthenticatedVerifiers: c.AllowUnauthenticatedVerifiers,
 		AllowedForeignJWTClaims:       allowedClaims,
+		DenyJWTSVIDSelectors:          c.DenyJWTSVIDSelectors,
 		TrustDomain:                   c.TrustDomain,
 	})
 
@@ -98,18 +106,22 @@ func New(c Config) *Endpoints {
 
 	return &Endpoints{
 		addr:              c.BindAddr,
+		additionalAddrs:   c.AdditionalBindAddrs,
 		log:               c.Log,
 		metrics:           c.Metrics,
 		workloadAPIServer: workloadAPIServer,
 		sdsv2Server:       sdsv2Server,
 		sdsv3Server:       sdsv3Server,
 		healthServer:      healthServer,
+
+		allowLegacySecurityHeaders: c.AllowLegacySecurityHeaders,
+		socketActivation:           c.SocketActivation,
 	}
 }
 
 func (e *Endpoints) ListenAndServe(ctx context.Context) error {
 	unaryInterceptor, streamInterceptor := middleware.Interceptors(
-		Middleware(e.log, e.metrics),
+		Middleware(e.log, e.metrics, e.allowLegacySecurityHeaders),
 	)
 
 	server := grpc.NewServer(
@@ -123,27 +135,60 @@ func (e *Endpoints) ListenAndServe(ctx context.Context) error {
 	secret_v3.RegisterSecretDiscoveryServiceServer(server, e.sdsv3Server)
 	grpc_health_v1.RegisterHealthServer(server, e.healthServer)
 
-	l, err := e.createListener()
-	if err != nil {
-		return err
+	if e.socketActivation {
+		activated, err := activatedListeners()
+		if err != nil {
+			return err
+		}
+		e.activated = activated
+		defer func() {
+			closeActivatedListeners(e.activated)
+			e.activated = nil
+		}()
+	}
+
+	var listeners []net.Listener
+	defer func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}()
+	for _, addr := range append([]net.Addr{e.addr}, e.additionalAddrs...) {
+		l, err := e.createListener(addr)
+		if err != nil {
+			return err
+		}
+		listeners = append(listeners, l)
+	}
+	for addr, l := range e.activated {
+		e.log.WithField(telemetry.Address, addr).Warn("Ignoring socket activated listener that does not match any bind address")
+		l.Close()
+		delete(e.activated, addr)
 	}
-	defer l.Close()
 
 	// Update the listening address with the actual address.
 	// If a TCP address was specified with port 0, this will
 	// update the address with the actual port that is used
 	// to listen.
-	e.addr = l.Addr()
-	e.log.WithFields(logrus.Fields{
-		telemetry.Network: e.addr.Network(),
-		telemetry.Address: e.addr,
-	}).Info("Starting Workload and SDS APIs")
+	e.addr = listeners[0].Addr()
+	for _, l := range listeners {
+		e.log.WithFields(logrus.Fields{
+			telemetry.Network: l.Addr().Network(),
+			telemetry.Address: l.Addr(),
+		}).Info("Starting Workload and SDS APIs")
+	}
 	e.triggerListeningHook()
-	errChan := make(chan error)
-	go func() { errChan <- server.Serve(l) }()
+	errChan := make(chan error, len(listeners))
+	for _, l := range listeners {
+		l := l
+		go func() { errChan <- server.Serve(l) }()
+	}
 
+	var err error
 	select {
 	case err = <-errChan:
+		// Stop serving on the remaining listeners
+		server.Stop()
 	case <-ctx.Done():
 		e.log.Info("Stopping Workload and SDS APIs")
 		server.Stop()
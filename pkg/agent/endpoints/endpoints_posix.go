@@ -9,38 +9,65 @@ import (
 	"os"
 
 	"github.com/spiffe/spire/pkg/common/peertracker"
+	"github.com/spiffe/spire/pkg/common/telemetry"
 )
 
-func (e *Endpoints) createUDSListener() (net.Listener, error) {
+func (e *Endpoints) createUDSListener(addr net.Addr) (net.Listener, error) {
+	if l, ok := e.activated[addr.String()]; ok {
+		delete(e.activated, addr.String())
+		return e.createActivatedUDSListener(addr, l)
+	}
+
 	// Remove uds if already exists
-	os.Remove(e.addr.String())
+	os.Remove(addr.String())
 
 	unixListener := &peertracker.ListenerFactory{
 		Log: e.log,
 	}
 
-	unixAddr, ok := e.addr.(*net.UnixAddr)
+	unixAddr, ok := addr.(*net.UnixAddr)
 	if !ok {
-		return nil, fmt.Errorf("create UDS listener: address is type %T, not net.UnixAddr", e.addr)
+		return nil, fmt.Errorf("create UDS listener: address is type %T, not net.UnixAddr", addr)
 	}
-	l, err := unixListener.ListenUnix(e.addr.Network(), unixAddr)
+	l, err := unixListener.ListenUnix(addr.Network(), unixAddr)
 	if err != nil {
 		return nil, fmt.Errorf("create UDS listener: %w", err)
 	}
 
-	if err := os.Chmod(e.addr.String(), os.ModePerm); err != nil {
+	if err := os.Chmod(addr.String(), os.ModePerm); err != nil {
 		return nil, fmt.Errorf("unable to change UDS permissions: %w", err)
 	}
 	return l, nil
 }
 
-func (e *Endpoints) createListener() (net.Listener, error) {
-	switch e.addr.Network() {
+func (e *Endpoints) createListener(addr net.Addr) (net.Listener, error) {
+	switch addr.Network() {
 	case "unix":
-		return e.createUDSListener()
+		return e.createUDSListener(addr)
 	case "pipe":
 		return nil, peertracker.ErrUnsupportedPlatform
 	default:
-		return nil, net.UnknownNetworkError(e.addr.Network())
+		return nil, net.UnknownNetworkError(addr.Network())
 	}
 }
+
+// createActivatedUDSListener tracks the peers of a listener passed through
+// socket activation. The socket is neither recreated nor has its permissions
+// changed, since it is owned by systemd.
+func (e *Endpoints) createActivatedUDSListener(addr net.Addr, activated *net.UnixListener) (net.Listener, error) {
+	unixListener := &peertracker.ListenerFactory{
+		Log: e.log,
+		ListenerFactoryOS: peertracker.ListenerFactoryOS{
+			NewUnixListener: func(string, *net.UnixAddr) (*net.UnixListener, error) {
+				return activated, nil
+			},
+		},
+	}
+	l, err := unixListener.ListenUnix(addr.Network(), activated.Addr().(*net.UnixAddr))
+	if err != nil {
+		activated.Close()
+		return nil, fmt.Errorf("create UDS listener: %w", err)
+	}
+	e.log.WithField(telemetry.Address, addr).Info("Using socket activated listener")
+	return l, nil
+}
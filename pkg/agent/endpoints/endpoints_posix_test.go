@@ -6,12 +6,141 @@ package endpoints
 import (
 	"context"
 	"net"
+	"os"
 	"path/filepath"
+	"strconv"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/sirupsen/logrus/hooks/test"
+	workload_pb "github.com/spiffe/go-spiffe/v2/proto/spiffe/workload"
+	"github.com/spiffe/spire/pkg/agent/endpoints/workload"
+	"github.com/spiffe/spire/pkg/common/util"
+	"github.com/spiffe/spire/test/fakes/fakemetrics"
 	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
+func TestEndpointsServesAdditionalAddrs(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	dir := spiretest.TempDir(t)
+	addr := &net.UnixAddr{Net: "unix", Name: filepath.Join(dir, "agent.sock")}
+	additionalAddr := &net.UnixAddr{Net: "unix", Name: filepath.Join(dir, "api.sock")}
+
+	log, _ := test.NewNullLogger()
+	endpoints := New(Config{
+		BindAddr:            addr,
+		AdditionalBindAddrs: []net.Addr{additionalAddr},
+		Log:                 log,
+		Metrics:             fakemetrics.New(),
+		Attestor:            FakeAttestor{},
+		Manager:             FakeManager{},
+		newWorkloadAPIServer: func(c workload.Config) workload_pb.SpiffeWorkloadAPIServer {
+			return FakeWorkloadAPIServer{Attestor: c.Attestor.(PeerTrackerAttestor)}
+		},
+	})
+	endpoints.hooks.listening = make(chan struct{})
+
+	ctx, cancelServe := context.WithCancel(ctx)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- endpoints.ListenAndServe(ctx)
+	}()
+	defer func() {
+		cancelServe()
+		assert.NoError(t, <-errCh)
+	}()
+	waitForListening(t, endpoints, errCh)
+
+	for _, addr := range []net.Addr{addr, additionalAddr} {
+		target, err := util.GetTargetName(addr)
+		require.NoError(t, err)
+
+		conn, err := util.GRPCDialContext(ctx, target, grpc.WithBlock())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		wlClient := workload_pb.NewSpiffeWorkloadAPIClient(conn)
+		_, err = wlClient.FetchJWTSVID(metadata.NewOutgoingContext(ctx, metadata.Pairs("workload.spiffe.io", "true")), &workload_pb.JWTSVIDRequest{})
+		require.NoError(t, err, "failed to call the Workload API over %q", addr)
+	}
+}
+
+func TestEndpointsServesSocketActivatedListeners(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	dir := spiretest.TempDir(t)
+	addr := &net.UnixAddr{Net: "unix", Name: filepath.Join(dir, "agent.sock")}
+
+	// Simulate systemd passing the listener of the socket
+	activated, err := net.ListenUnix("unix", addr)
+	require.NoError(t, err)
+	activated.SetUnlinkOnClose(false)
+	defer activated.Close()
+	f, err := activated.File()
+	require.NoError(t, err)
+	fd, err := syscall.Dup(int(f.Fd()))
+	f.Close()
+	require.NoError(t, err)
+
+	listenFDsStart = fd
+	defer func() { listenFDsStart = 3 }()
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "1")
+
+	log, _ := test.NewNullLogger()
+	endpoints := New(Config{
+		BindAddr:         addr,
+		SocketActivation: true,
+		Log:              log,
+		Metrics:          fakemetrics.New(),
+		Attestor:         FakeAttestor{},
+		Manager:          FakeManager{},
+		newWorkloadAPIServer: func(c workload.Config) workload_pb.SpiffeWorkloadAPIServer {
+			return FakeWorkloadAPIServer{Attestor: c.Attestor.(PeerTrackerAttestor)}
+		},
+	})
+	endpoints.hooks.listening = make(chan struct{})
+
+	serveCtx, cancelServe := context.WithCancel(ctx)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- endpoints.ListenAndServe(serveCtx)
+	}()
+	waitForListening(t, endpoints, errCh)
+
+	// The variables are not passed down to child processes
+	_, ok := os.LookupEnv("LISTEN_FDS")
+	require.False(t, ok)
+
+	target, err := util.GetTargetName(addr)
+	require.NoError(t, err)
+	conn, err := util.GRPCDialContext(ctx, target, grpc.WithBlock())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	wlClient := workload_pb.NewSpiffeWorkloadAPIClient(conn)
+	_, err = wlClient.FetchJWTSVID(metadata.NewOutgoingContext(ctx, metadata.Pairs("workload.spiffe.io", "true")), &workload_pb.JWTSVIDRequest{})
+	require.NoError(t, err)
+
+	cancelServe()
+	require.NoError(t, <-errCh)
+
+	// The socket is left in place, so connections keep being queued while
+	// the agent restarts
+	require.FileExists(t, addr.Name)
+	rawConn, err := net.DialUnix("unix", nil, addr)
+	require.NoError(t, err)
+	rawConn.Close()
+}
+
 func getTestAddr(t *testing.T) net.Addr {
 	return &net.UnixAddr{
 		Net:  "unix",
@@ -12,24 +12,24 @@ import (
 	"github.com/spiffe/spire/pkg/common/sddl"
 )
 
-func (e *Endpoints) createPipeListener() (net.Listener, error) {
+func (e *Endpoints) createPipeListener(addr net.Addr) (net.Listener, error) {
 	pipeListener := &peertracker.ListenerFactory{
 		Log: e.log,
 	}
-	l, err := pipeListener.ListenPipe(e.addr.String(), &winio.PipeConfig{SecurityDescriptor: sddl.PublicListener})
+	l, err := pipeListener.ListenPipe(addr.String(), &winio.PipeConfig{SecurityDescriptor: sddl.PublicListener})
 	if err != nil {
 		return nil, fmt.Errorf("create named pipe listener: %w", err)
 	}
 	return l, nil
 }
 
-func (e *Endpoints) createListener() (net.Listener, error) {
-	switch e.addr.Network() {
+func (e *Endpoints) createListener(addr net.Addr) (net.Listener, error) {
+	switch addr.Network() {
 	case "unix":
 		return nil, peertracker.ErrUnsupportedPlatform
 	case "pipe":
-		return e.createPipeListener()
+		return e.createPipeListener(addr)
 	default:
-		return nil, net.UnknownNetworkError(e.addr.Network())
+		return nil, net.UnknownNetworkError(addr.Network())
 	}
 }
@@ -16,15 +16,16 @@ import (
 
 const (
 	workloadAPIMethodPrefix = "/SpiffeWorkloadAPI/"
+	securityHeaderKey       = "workload.spiffe.io"
 )
 
-func Middleware(log logrus.FieldLogger, metrics telemetry.Metrics) middleware.Middleware {
+func Middleware(log logrus.FieldLogger, metrics telemetry.Metrics, allowLegacySecurityHeaders bool) middleware.Middleware {
 	return middleware.Chain(
 		middleware.WithLogger(log),
 		middleware.WithMetrics(metrics),
 		withPerServiceConnectionMetrics(metrics),
 		middleware.Preprocess(addWatcherPID),
-		middleware.Preprocess(verifySecurityHeader),
+		middleware.Preprocess(securityHeaderVerifier(allowLegacySecurityHeaders)),
 	)
 }
 
@@ -38,11 +39,20 @@ func addWatcherPID(ctx context.Context, fullMethod string, req interface{}) (con
 	return ctx, nil
 }
 
-func verifySecurityHeader(ctx context.Context, fullMethod string, req interface{}) (context.Context, error) {
-	if isWorkloadAPIMethod(fullMethod) && !hasSecurityHeader(ctx) {
-		return nil, status.Error(codes.InvalidArgument, "security header missing from request")
+func securityHeaderVerifier(allowLegacy bool) middleware.PreprocessFunc {
+	return func(ctx context.Context, fullMethod string, req interface{}) (context.Context, error) {
+		if !isWorkloadAPIMethod(fullMethod) {
+			return ctx, nil
+		}
+		switch {
+		case hasSecurityHeader(ctx):
+		case allowLegacy && hasLegacySecurityHeader(ctx):
+			rpccontext.Logger(ctx).Warn("Request uses a deprecated variant of the security header; clients should send it once with the value \"true\"")
+		default:
+			return nil, status.Error(codes.InvalidArgument, "security header missing from request")
+		}
+		return ctx, nil
 	}
-	return ctx, nil
 }
 
 func isWorkloadAPIMethod(fullMethod string) bool {
@@ -51,5 +61,21 @@ func isWorkloadAPIMethod(fullMethod string) bool {
 
 func hasSecurityHeader(ctx context.Context) bool {
 	md, ok := metadata.FromIncomingContext(ctx)
-	return ok && len(md["workload.spiffe.io"]) == 1 && md["workload.spiffe.io"][0] == "true"
+	return ok && len(md[securityHeaderKey]) == 1 && md[securityHeaderKey][0] == "true"
+}
+
+// hasLegacySecurityHeader returns true if the security header was sent with
+// the variants accepted by legacy clients, i.e. repeated, or with a
+// differently cased value.
+func hasLegacySecurityHeader(ctx context.Context) bool {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md[securityHeaderKey]) == 0 {
+		return false
+	}
+	for _, value := range md[securityHeaderKey] {
+		if !strings.EqualFold(strings.TrimSpace(value), "true") {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,101 @@
+package endpoints
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/spire/pkg/agent/api/rpccontext"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestSecurityHeaderVerifier(t *testing.T) {
+	const fetchX509SVID = "/SpiffeWorkloadAPI/FetchX509SVID"
+
+	for _, tt := range []struct {
+		name        string
+		method      string
+		md          metadata.MD
+		allowLegacy bool
+		expectErr   bool
+		expectWarn  bool
+	}{
+		{
+			name:   "security header",
+			method: fetchX509SVID,
+			md:     metadata.Pairs("workload.spiffe.io", "true"),
+		},
+		{
+			name:      "no security header",
+			method:    fetchX509SVID,
+			md:        metadata.Pairs("other", "true"),
+			expectErr: true,
+		},
+		{
+			name:   "not a workload API method",
+			method: "/envoy.service.secret.v3.SecretDiscoveryService/FetchSecrets",
+		},
+		{
+			name:      "repeated security header",
+			method:    fetchX509SVID,
+			md:        metadata.Pairs("workload.spiffe.io", "true", "workload.spiffe.io", "true"),
+			expectErr: true,
+		},
+		{
+			name:        "repeated security header allowed as legacy",
+			method:      fetchX509SVID,
+			md:          metadata.Pairs("workload.spiffe.io", "true", "workload.spiffe.io", "true"),
+			allowLegacy: true,
+			expectWarn:  true,
+		},
+		{
+			name:      "differently cased security header value",
+			method:    fetchX509SVID,
+			md:        metadata.Pairs("workload.spiffe.io", "True"),
+			expectErr: true,
+		},
+		{
+			name:        "differently cased security header value allowed as legacy",
+			method:      fetchX509SVID,
+			md:          metadata.Pairs("workload.spiffe.io", "True"),
+			allowLegacy: true,
+			expectWarn:  true,
+		},
+		{
+			name:        "invalid security header value",
+			method:      fetchX509SVID,
+			md:          metadata.Pairs("workload.spiffe.io", "false"),
+			allowLegacy: true,
+			expectErr:   true,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			log, hook := test.NewNullLogger()
+			ctx := rpccontext.WithLogger(context.Background(), log)
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+
+			_, err := securityHeaderVerifier(tt.allowLegacy)(ctx, tt.method, nil)
+			if tt.expectErr {
+				spiretest.RequireGRPCStatus(t, err, codes.InvalidArgument, "security header missing from request")
+			} else {
+				require.NoError(t, err)
+			}
+
+			var expectLogs []spiretest.LogEntry
+			if tt.expectWarn {
+				expectLogs = append(expectLogs, spiretest.LogEntry{
+					Level:   logrus.WarnLevel,
+					Message: "Request uses a deprecated variant of the security header; clients should send it once with the value \"true\"",
+				})
+			}
+			spiretest.AssertLogs(t, hook.AllEntries(), expectLogs)
+		})
+	}
+}
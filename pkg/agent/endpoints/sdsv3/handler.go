@@ -41,6 +41,7 @@ type Attestor interface {
 type Manager interface {
 	SubscribeToCacheChanges(ctx context.Context, key cache.Selectors) (cache.Subscriber, error)
 	FetchWorkloadUpdate(selectors []*common.Selector) *cache.WorkloadUpdate
+	RecordEntryUsage(entryIDs []string)
 }
 
 type Config struct {
@@ -307,6 +308,7 @@ func (h *Handler) buildResponse(versionInfo string, req *discovery_v3.DiscoveryR
 		}
 	}
 
+	var entryIDs []string
 	for i, identity := range upd.Identities {
 		switch {
 		case returnAllEntries || names[identity.Entry.SpiffeId]:
@@ -316,6 +318,7 @@ func (h *Handler) buildResponse(versionInfo string, req *discovery_v3.DiscoveryR
 			}
 			delete(names, identity.Entry.SpiffeId)
 			resp.Resources = append(resp.Resources, tlsCertificate)
+			entryIDs = append(entryIDs, identity.Entry.EntryId)
 		case i == 0 && names[h.c.DefaultSVIDName]:
 			tlsCertificate, err := buildTLSCertificate(identity, h.c.DefaultSVIDName)
 			if err != nil {
@@ -323,6 +326,7 @@ func (h *Handler) buildResponse(versionInfo string, req *discovery_v3.DiscoveryR
 			}
 			delete(names, h.c.DefaultSVIDName)
 			resp.Resources = append(resp.Resources, tlsCertificate)
+			entryIDs = append(entryIDs, identity.Entry.EntryId)
 		}
 	}
 
@@ -330,6 +334,7 @@ func (h *Handler) buildResponse(versionInfo string, req *discovery_v3.DiscoveryR
 		return nil, status.Errorf(codes.InvalidArgument, "workload is not authorized for the requested identities %q", sortedNames(names))
 	}
 
+	h.c.Manager.RecordEntryUsage(entryIDs)
 	return resp, nil
 }
 
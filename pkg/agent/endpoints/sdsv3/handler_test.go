@@ -1179,6 +1179,24 @@ func TestFetchSecrets(t *testing.T) {
 	}
 }
 
+func TestFetchSecretsRecordsEntryUsage(t *testing.T) {
+	test := setupTest(t)
+	defer test.cleanup()
+
+	// Bundles are not an entry usage
+	_, err := test.handler.FetchSecrets(context.Background(), &discovery_v3.DiscoveryRequest{
+		ResourceNames: []string{"spiffe://domain.test"},
+	})
+	require.NoError(t, err)
+	require.Empty(t, test.manager.UsedEntries())
+
+	_, err = test.handler.FetchSecrets(context.Background(), &discovery_v3.DiscoveryRequest{
+		ResourceNames: []string{"spiffe://domain.test/workload"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"WORKLOAD"}, test.manager.UsedEntries())
+}
+
 func DeltaSecretsTest(t *testing.T) {
 	test := setupTest(t)
 	defer test.cleanup()
@@ -1265,6 +1283,7 @@ func (h *handlerTest) setWorkloadUpdate(workloadCert *x509.Certificate) {
 			Identities: []cache.Identity{
 				{
 					Entry: &common.RegistrationEntry{
+						EntryId:  "WORKLOAD",
 						SpiffeId: "spiffe://domain.test/workload",
 					},
 					SVID:       []*x509.Certificate{workloadCert},
@@ -1305,6 +1324,7 @@ type FakeManager struct {
 	next int
 	subs map[int]chan *cache.WorkloadUpdate
 	err  error
+	used []string
 }
 
 func NewFakeManager(t *testing.T) *FakeManager {
@@ -1342,6 +1362,18 @@ func (m *FakeManager) FetchWorkloadUpdate(selectors []*common.Selector) *cache.W
 	return m.upd
 }
 
+func (m *FakeManager) RecordEntryUsage(entryIDs []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.used = append(m.used, entryIDs...)
+}
+
+func (m *FakeManager) UsedEntries() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.used
+}
+
 func (m *FakeManager) SetWorkloadUpdate(upd *cache.WorkloadUpdate) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -34,6 +34,7 @@ type Manager interface {
 	MatchingRegistrationEntries(selectors []*common.Selector) []*common.RegistrationEntry
 	FetchJWTSVID(ctx context.Context, spiffeID spiffeid.ID, audience []string) (*client.JWTSVID, error)
 	FetchWorkloadUpdate([]*common.Selector) *cache.WorkloadUpdate
+	RecordEntryUsage(entryIDs []string)
 }
 
 type Attestor interface {
@@ -46,6 +47,7 @@ type Config struct {
 	Attestor                      Attestor
 	AllowUnauthenticatedVerifiers bool
 	AllowedForeignJWTClaims       map[string]struct{}
+	DenyJWTSVIDSelectors          []*common.Selector
 	TrustDomain                   spiffeid.TrustDomain
 }
 
@@ -81,7 +83,13 @@ func (h *Handler) FetchJWTSVID(ctx context.Context, req *workload.JWTSVIDRequest
 		return nil, err
 	}
 
+	if denied := h.deniedJWTSVIDSelector(selectors); denied != nil {
+		log.WithField(telemetry.Selector, denied.Type+":"+denied.Value).Error("JWT-SVID issuance is denied for the workload")
+		return nil, status.Error(codes.PermissionDenied, "JWT-SVID issuance is denied for the workload")
+	}
+
 	var spiffeIDs []spiffeid.ID
+	var entryIDs []string
 
 	log = log.WithField(telemetry.Registered, true)
 
@@ -98,6 +106,7 @@ func (h *Handler) FetchJWTSVID(ctx context.Context, req *workload.JWTSVIDRequest
 		}
 
 		spiffeIDs = append(spiffeIDs, spiffeID)
+		entryIDs = append(entryIDs, entry.EntryId)
 	}
 
 	if len(spiffeIDs) == 0 {
@@ -123,10 +132,24 @@ func (h *Handler) FetchJWTSVID(ctx context.Context, req *workload.JWTSVIDRequest
 		ttl := time.Until(svid.ExpiresAt)
 		loopLog.WithField(telemetry.TTL, ttl.Seconds()).Debug("Fetched JWT SVID")
 	}
+	h.c.Manager.RecordEntryUsage(entryIDs)
 
 	return resp, nil
 }
 
+// deniedJWTSVIDSelector returns the first attested selector that denies the
+// workload from being issued JWT-SVIDs, if any.
+func (h *Handler) deniedJWTSVIDSelector(selectors []*common.Selector) *common.Selector {
+	for _, denied := range h.c.DenyJWTSVIDSelectors {
+		for _, selector := range selectors {
+			if selector.Type == denied.Type && selector.Value == denied.Value {
+				return denied
+			}
+		}
+	}
+	return nil
+}
+
 // FetchJWTBundles processes request for JWT bundles
 func (h *Handler) FetchJWTBundles(req *workload.JWTBundlesRequest, stream workload.SpiffeWorkloadAPI_FetchJWTBundlesServer) error {
 	ctx := stream.Context()
@@ -241,6 +264,10 @@ func (h *Handler) FetchX509SVID(_ *workload.X509SVIDRequest, stream workload.Spi
 			if err := sendX509SVIDResponse(update, stream, log, quietLogging); err != nil {
 				return err
 			}
+			// The health check of the agent itself is not a workload usage
+			if !quietLogging {
+				h.c.Manager.RecordEntryUsage(identityEntryIDs(update.Identities))
+			}
 		case <-ctx.Done():
 			return nil
 		}
@@ -362,6 +389,14 @@ func sendX509SVIDResponse(update *cache.WorkloadUpdate, stream workload.SpiffeWo
 	return nil
 }
 
+func identityEntryIDs(identities []cache.Identity) []string {
+	entryIDs := make([]string, 0, len(identities))
+	for _, identity := range identities {
+		entryIDs = append(entryIDs, identity.Entry.EntryId)
+	}
+	return entryIDs
+}
+
 func composeX509SVIDResponse(update *cache.WorkloadUpdate) (*workload.X509SVIDResponse, error) {
 	resp := new(workload.X509SVIDResponse)
 	resp.Svids = []*workload.X509SVID{}
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -62,6 +63,7 @@ func TestFetchX509SVID(t *testing.T) {
 		expectMsg  string
 		expectResp *workloadPB.X509SVIDResponse
 		expectLogs []spiretest.LogEntry
+		expectUsed []string
 	}{
 		{
 			name:       "no identity issued",
@@ -146,6 +148,7 @@ func TestFetchX509SVID(t *testing.T) {
 					federatedBundle.TrustDomain().IDString(): x509util.DERFromCertificates(federatedBundle.X509Authorities()),
 				},
 			},
+			expectUsed: []string{"/one"},
 		},
 		{
 			name: "with two identities",
@@ -175,17 +178,40 @@ func TestFetchX509SVID(t *testing.T) {
 					},
 				},
 			},
+			expectUsed: []string{"/one", "/two"},
+		},
+		{
+			name: "with identity (healthcheck)",
+			updates: []*cache.WorkloadUpdate{{
+				Identities: []cache.Identity{
+					identityFromX509SVID(x509SVID1),
+				},
+				Bundle: utilBundleFromBundle(t, bundle),
+			}},
+			asPID:      os.Getpid(),
+			expectCode: codes.OK,
+			expectResp: &workloadPB.X509SVIDResponse{
+				Svids: []*workloadPB.X509SVID{
+					{
+						SpiffeId:    x509SVID1.ID.String(),
+						X509Svid:    x509util.DERFromCertificates(x509SVID1.Certificates),
+						X509SvidKey: pkcs8FromSigner(t, x509SVID1.PrivateKey),
+						Bundle:      x509util.DERFromCertificates(bundle.X509Authorities()),
+					},
+				},
+			},
 		},
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			params := testParams{
-				CA:         ca,
-				Updates:    tt.updates,
-				AttestErr:  tt.attestErr,
-				ExpectLogs: tt.expectLogs,
-				AsPID:      tt.asPID,
-				ManagerErr: tt.managerErr,
+				CA:                ca,
+				Updates:           tt.updates,
+				AttestErr:         tt.attestErr,
+				ExpectLogs:        tt.expectLogs,
+				AsPID:             tt.asPID,
+				ManagerErr:        tt.managerErr,
+				ExpectUsedEntries: tt.expectUsed,
 			}
 			runTest(t, params,
 				func(ctx context.Context, client workloadPB.SpiffeWorkloadAPIClient) {
@@ -503,10 +529,13 @@ func TestFetchJWTSVID(t *testing.T) {
 		audience       []string
 		attestErr      error
 		managerErr     error
+		selectors      []*common.Selector
+		denySelectors  []*common.Selector
 		expectCode     codes.Code
 		expectMsg      string
 		expectTokenIDs []spiffeid.ID
 		expectLogs     []spiretest.LogEntry
+		expectUsed     []string
 	}{
 		{
 			name:       "missing required audience",
@@ -631,6 +660,7 @@ func TestFetchJWTSVID(t *testing.T) {
 			audience:       []string{"AUDIENCE"},
 			expectCode:     codes.OK,
 			expectTokenIDs: []spiffeid.ID{x509SVID1.ID, x509SVID2.ID},
+			expectUsed:     []string{"/one", "/two"},
 		},
 		{
 			name: "success specific",
@@ -642,16 +672,63 @@ func TestFetchJWTSVID(t *testing.T) {
 			audience:       []string{"AUDIENCE"},
 			expectCode:     codes.OK,
 			expectTokenIDs: []spiffeid.ID{x509SVID2.ID},
+			expectUsed:     []string{"/two"},
+		},
+		{
+			name: "denied by selector",
+			identities: []cache.Identity{
+				identityFromX509SVID(x509SVID1),
+			},
+			audience: []string{"AUDIENCE"},
+			selectors: []*common.Selector{
+				{Type: "k8s", Value: "sa:default"},
+				{Type: "k8s", Value: "ns:untrusted"},
+			},
+			denySelectors: []*common.Selector{
+				{Type: "k8s", Value: "ns:untrusted"},
+			},
+			expectCode: codes.PermissionDenied,
+			expectMsg:  "JWT-SVID issuance is denied for the workload",
+			expectLogs: []spiretest.LogEntry{
+				{
+					Level:   logrus.ErrorLevel,
+					Message: "JWT-SVID issuance is denied for the workload",
+					Data: logrus.Fields{
+						"service":  "WorkloadAPI",
+						"method":   "FetchJWTSVID",
+						"selector": "k8s:ns:untrusted",
+					},
+				},
+			},
+		},
+		{
+			name: "not denied by unrelated selector",
+			identities: []cache.Identity{
+				identityFromX509SVID(x509SVID1),
+			},
+			audience: []string{"AUDIENCE"},
+			selectors: []*common.Selector{
+				{Type: "k8s", Value: "ns:trusted"},
+			},
+			denySelectors: []*common.Selector{
+				{Type: "k8s", Value: "ns:untrusted"},
+			},
+			expectCode:     codes.OK,
+			expectTokenIDs: []spiffeid.ID{x509SVID1.ID},
+			expectUsed:     []string{"/one"},
 		},
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			params := testParams{
-				CA:         ca,
-				Identities: tt.identities,
-				AttestErr:  tt.attestErr,
-				ManagerErr: tt.managerErr,
-				ExpectLogs: tt.expectLogs,
+				CA:                   ca,
+				Identities:           tt.identities,
+				Selectors:            tt.selectors,
+				AttestErr:            tt.attestErr,
+				ManagerErr:           tt.managerErr,
+				ExpectLogs:           tt.expectLogs,
+				DenyJWTSVIDSelectors: tt.denySelectors,
+				ExpectUsedEntries:    tt.expectUsed,
 			}
 			runTest(t, params,
 				func(ctx context.Context, client workloadPB.SpiffeWorkloadAPIClient) {
@@ -1288,12 +1365,15 @@ type testParams struct {
 	CA                            *testca.CA
 	Identities                    []cache.Identity
 	Updates                       []*cache.WorkloadUpdate
+	Selectors                     []*common.Selector
 	AttestErr                     error
 	ManagerErr                    error
 	ExpectLogs                    []spiretest.LogEntry
 	AsPID                         int
 	AllowUnauthenticatedVerifiers bool
 	AllowedForeignJWTClaims       map[string]struct{}
+	DenyJWTSVIDSelectors          []*common.Selector
+	ExpectUsedEntries             []string
 }
 
 func runTest(t *testing.T, params testParams, fn func(ctx context.Context, client workloadPB.SpiffeWorkloadAPIClient)) {
@@ -1309,9 +1389,10 @@ func runTest(t *testing.T, params testParams, fn func(ctx context.Context, clien
 	handler := workload.New(workload.Config{
 		TrustDomain:                   td,
 		Manager:                       manager,
-		Attestor:                      &FakeAttestor{err: params.AttestErr},
+		Attestor:                      &FakeAttestor{selectors: params.Selectors, err: params.AttestErr},
 		AllowUnauthenticatedVerifiers: params.AllowUnauthenticatedVerifiers,
 		AllowedForeignJWTClaims:       params.AllowedForeignJWTClaims,
+		DenyJWTSVIDSelectors:          params.DenyJWTSVIDSelectors,
 	})
 
 	unaryInterceptor, streamInterceptor := middleware.Interceptors(middleware.Chain(
@@ -1345,6 +1426,7 @@ func runTest(t *testing.T, params testParams, fn func(ctx context.Context, clien
 	server.GracefulStop()
 
 	assert.Equal(t, 0, manager.Subscribers(), "there should be no more subscribers")
+	assert.Equal(t, params.ExpectUsedEntries, manager.UsedEntries())
 
 	spiretest.AssertLogs(t, logHook.AllEntries(), params.ExpectLogs)
 }
@@ -1355,6 +1437,9 @@ type FakeManager struct {
 	updates     []*cache.WorkloadUpdate
 	subscribers int32
 	err         error
+
+	usedMtx sync.Mutex
+	used    []string
 }
 
 func (m *FakeManager) MatchingRegistrationEntries(selectors []*common.Selector) []*common.RegistrationEntry {
@@ -1390,6 +1475,18 @@ func (m *FakeManager) FetchWorkloadUpdate(selectors []*common.Selector) *cache.W
 	return m.updates[0]
 }
 
+func (m *FakeManager) RecordEntryUsage(entryIDs []string) {
+	m.usedMtx.Lock()
+	defer m.usedMtx.Unlock()
+	m.used = append(m.used, entryIDs...)
+}
+
+func (m *FakeManager) UsedEntries() []string {
+	m.usedMtx.Lock()
+	defer m.usedMtx.Unlock()
+	return m.used
+}
+
 func (m *FakeManager) Subscribers() int {
 	return int(atomic.LoadInt32(&m.subscribers))
 }
@@ -1444,7 +1541,7 @@ func (a *FakeAttestor) Attest(ctx context.Context) ([]*common.Selector, error) {
 
 func identityFromX509SVID(svid *x509svid.SVID) cache.Identity {
 	return cache.Identity{
-		Entry:      &common.RegistrationEntry{SpiffeId: svid.ID.String()},
+		Entry:      &common.RegistrationEntry{EntryId: svid.ID.Path(), SpiffeId: svid.ID.String()},
 		PrivateKey: svid.PrivateKey,
 		SVID:       svid.Certificates,
 	}
@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/spiffe/spire/pkg/agent/plugin/keymanager"
+	"github.com/spiffe/spire/pkg/common/health"
+)
+
+const (
+	// keyManagerHealthTimeout bounds the time the key manager has to list
+	// its keys during a health check.
+	keyManagerHealthTimeout = 5 * time.Second
+
+	// keyManagerUnreadyThreshold is the number of consecutive failed health
+	// checks after which the agent is no longer reported as ready.
+	keyManagerUnreadyThreshold = 2
+
+	// keyManagerDeadThreshold is the number of consecutive failed health
+	// checks after which the agent is no longer reported as live. Checks run
+	// every minute, so a key manager has to fail for several minutes before
+	// the agent is restarted.
+	keyManagerDeadThreshold = 5
+)
+
+type keyManagerHealth struct {
+	km keymanager.KeyManager
+
+	mtx                 sync.Mutex
+	consecutiveFailures int
+}
+
+// CheckHealth checks that the key manager is able to list its keys. A single
+// failure, e.g. a timeout while the host is under load, does not affect the
+// health of the agent; only sustained failures do.
+func (h *keyManagerHealth) CheckHealth() health.State {
+	ctx, cancel := context.WithTimeout(context.Background(), keyManagerHealthTimeout)
+	defer cancel()
+
+	start := time.Now()
+	_, err := h.km.GetKeys(ctx)
+	latency := time.Since(start)
+
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if err != nil {
+		h.consecutiveFailures++
+	} else {
+		h.consecutiveFailures = 0
+	}
+
+	details := keyManagerHealthDetails{
+		Latency:             latency.String(),
+		ConsecutiveFailures: h.consecutiveFailures,
+		GetKeysErr:          errString(err),
+	}
+	return health.State{
+		Live:         h.consecutiveFailures < keyManagerDeadThreshold,
+		Ready:        h.consecutiveFailures < keyManagerUnreadyThreshold,
+		ReadyDetails: details,
+		LiveDetails:  details,
+	}
+}
+
+type keyManagerHealthDetails struct {
+	Latency             string `json:"latency"`
+	ConsecutiveFailures int    `json:"consecutive_failures,omitempty"`
+	GetKeysErr          string `json:"get_keys_err,omitempty"`
+}
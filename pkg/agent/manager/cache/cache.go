@@ -476,6 +476,10 @@ func (c *Cache) SyncSVIDsWithSubscribers() {
 	c.log.Error("SyncSVIDsWithSubscribers method is not implemented")
 }
 
+// SetShedPrefetches is a no-op; this cache holds SVIDs for all the entries
+// and does not prefetch them.
+func (c *Cache) SetShedPrefetches(bool) {}
+
 func (c *Cache) subscribeToWorkloadUpdates(selectors []*common.Selector) Subscriber {
 	c.mu.Lock()
 	defer c.mu.Unlock()
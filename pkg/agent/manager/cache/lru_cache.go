@@ -12,6 +12,7 @@ import (
 	"github.com/spiffe/spire/pkg/agent/common/backoff"
 	"github.com/spiffe/spire/pkg/common/bundleutil"
 	"github.com/spiffe/spire/pkg/common/telemetry"
+	telemetry_agent "github.com/spiffe/spire/pkg/common/telemetry/agent"
 	"github.com/spiffe/spire/proto/spire/common"
 )
 
@@ -105,6 +106,10 @@ type LRUCache struct {
 	// svidCacheMaxSize is a soft limit of max number of SVIDs that would be stored in cache
 	svidCacheMaxSize   int
 	subscribeBackoffFn func() backoff.BackOff
+
+	// shedPrefetches, when true, stops SVIDs from being prefetched for
+	// entries without active subscribers
+	shedPrefetches bool
 }
 
 func NewLRUCache(log logrus.FieldLogger, trustDomain spiffeid.TrustDomain, bundle *Bundle, metrics telemetry.Metrics,
@@ -491,6 +496,17 @@ func (c *LRUCache) SyncSVIDsWithSubscribers() {
 	c.syncSVIDsWithSubscribers()
 }
 
+// SetShedPrefetches sets whether SVIDs are prefetched for entries without
+// active subscribers. Prefetches are shed while the agent is under resource
+// pressure; SVIDs already cached are still rotated and entries with active
+// subscribers still get their SVIDs.
+func (c *LRUCache) SetShedPrefetches(shed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.shedPrefetches = shed
+}
+
 // Notify subscribers of selector set only if all SVIDs for corresponding selector set are cached
 // It returns whether all SVIDs are cached or not.
 // This method should be retried with backoff to avoid lock contention.
@@ -590,6 +606,11 @@ func (c *LRUCache) syncSVIDsWithSubscribers() (map[string]struct{}, []recordAcce
 	}
 
 	remainderSize := c.svidCacheMaxSize - len(c.svids)
+	if c.shedPrefetches {
+		c.dropPrefetches(activeSubsByEntryID)
+		return activeSubsByEntryID, lastAccessTimestamps
+	}
+
 	// add records which are not cached for remainder of cache size
 	for id := range c.records {
 		if len(c.staleEntries) >= remainderSize {
@@ -605,6 +626,28 @@ func (c *LRUCache) syncSVIDsWithSubscribers() (map[string]struct{}, []recordAcce
 	return activeSubsByEntryID, lastAccessTimestamps
 }
 
+// dropPrefetches removes the stale markers of records without a cached SVID
+// and without active subscribers, so no SVID is minted for them until
+// prefetches are no longer shed.
+func (c *LRUCache) dropPrefetches(activeSubsByEntryID map[string]struct{}) {
+	shed := 0
+	for id := range c.records {
+		if _, svidCached := c.svids[id]; svidCached {
+			continue
+		}
+		if _, ok := activeSubsByEntryID[id]; ok {
+			continue
+		}
+		delete(c.staleEntries, id)
+		shed++
+	}
+
+	if shed > 0 {
+		telemetry_agent.AddCacheManagerShedSVIDPrefetchesSample(c.metrics, float32(shed))
+		c.log.WithField(telemetry.Count, shed).Debug("Shedding SVID prefetches while under resource pressure")
+	}
+}
+
 func (c *LRUCache) updateOrCreateRecord(newEntry *common.RegistrationEntry) (*lruCacheRecord, *common.RegistrationEntry) {
 	var existingEntry *common.RegistrationEntry
 	record, recordExists := c.records[newEntry.EntryId]
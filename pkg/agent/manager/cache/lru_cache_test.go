@@ -759,6 +759,44 @@ func TestSyncSVIDsWithSubscribers(t *testing.T) {
 	assert.Equal(t, 5, cache.CountSVIDs())
 }
 
+func TestShedPrefetches(t *testing.T) {
+	cache := newTestLRUCacheWithConfig(10, clock.NewMock())
+	cache.SetShedPrefetches(true)
+
+	foo := makeRegistrationEntry("FOO", "A")
+	bar := makeRegistrationEntry("BAR", "B")
+	baz := makeRegistrationEntry("BAZ", "C")
+	updateEntries := &UpdateEntries{
+		Bundles:             makeBundles(bundleV1),
+		RegistrationEntries: makeRegistrationEntries(foo, bar, baz),
+	}
+
+	// Only the entry with an active subscriber gets its SVID minted
+	subA := cache.NewSubscriber(foo.Selectors)
+	defer subA.Finish()
+	cache.UpdateEntries(updateEntries, nil)
+	assert.Equal(t, []*StaleEntry{{Entry: cache.records[foo.EntryId].entry}}, cache.GetStaleEntries())
+	expiresAt := time.Now()
+	svids := map[string]*X509SVID{
+		foo.EntryId: {Chain: []*x509.Certificate{{NotAfter: expiresAt}}},
+	}
+	cache.UpdateSVIDs(&UpdateSVIDs{X509SVIDs: svids})
+	require.Len(t, cache.GetStaleEntries(), 0)
+
+	// Cached SVIDs are still rotated
+	cache.UpdateEntries(updateEntries, func(*common.RegistrationEntry, *common.RegistrationEntry, *X509SVID) bool {
+		return true
+	})
+	assert.Equal(t, []*StaleEntry{{Entry: cache.records[foo.EntryId].entry, ExpiresAt: expiresAt}}, cache.GetStaleEntries())
+	cache.UpdateSVIDs(&UpdateSVIDs{X509SVIDs: svids})
+
+	// Prefetches resume once no longer shed
+	cache.SetShedPrefetches(false)
+	cache.SyncSVIDsWithSubscribers()
+	require.Len(t, cache.GetStaleEntries(), 2)
+	assert.Equal(t, 1, cache.CountSVIDs())
+}
+
 func TestNotify(t *testing.T) {
 	cache := newTestLRUCache()
 
@@ -2,6 +2,7 @@ package manager
 
 import (
 	"crypto/x509"
+	"fmt"
 	"sync"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/spiffe/spire/pkg/agent/storage"
 	"github.com/spiffe/spire/pkg/agent/svid"
 	"github.com/spiffe/spire/pkg/agent/workloadkey"
+	"github.com/spiffe/spire/pkg/common/health"
 	"github.com/spiffe/spire/pkg/common/telemetry"
 )
 
@@ -39,13 +41,27 @@ type Config struct {
 	SVIDCacheMaxSize int
 	NodeAttestor     nodeattestor.NodeAttestor
 
+	// HealthChecker, if set, is used to report the health of the
+	// synchronization with the server.
+	HealthChecker health.Checker
+
+	// PressureDetector, if set, is used to shed SVID prefetches while the
+	// agent is under resource pressure. Only used by the LRU cache.
+	PressureDetector PressureDetector
+
 	// Clk is the clock the manager will use to get time
 	Clk clock.Clock
 }
 
 // New creates a cache manager based on c's configuration
-func New(c *Config) Manager {
-	return newManager(c)
+func New(c *Config) (Manager, error) {
+	m := newManager(c)
+	if c.HealthChecker != nil {
+		if err := c.HealthChecker.AddCheck("agent.manager.sync", &syncHealth{m: m}); err != nil {
+			return nil, fmt.Errorf("failed adding healthcheck: %w", err)
+		}
+	}
+	return m, nil
 }
 
 func newManager(c *Config) *manager {
@@ -96,6 +112,7 @@ func newManager(c *Config) *manager {
 		client:         client,
 		clk:            c.Clk,
 		svidStoreCache: c.SVIDStoreCache,
+		lastUsed:       make(map[string]time.Time),
 	}
 
 	return m
@@ -0,0 +1,61 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/spiffe/spire/pkg/common/health"
+)
+
+// failedSyncThreshold is the number of consecutive failed syncs after which
+// the agent is no longer reported as ready.
+const failedSyncThreshold = 5
+
+type syncHealth struct {
+	m *manager
+}
+
+func (h *syncHealth) CheckHealth() health.State {
+	h.m.mtx.RLock()
+	lastSync := h.m.lastSync
+	details := syncHealthDetails{
+		LastSyncLatency:     durationString(h.m.lastSyncLatency),
+		ConsecutiveFailures: h.m.failedSyncs,
+		SyncErr:             errString(h.m.lastSyncErr),
+	}
+	failedSyncs := h.m.failedSyncs
+	h.m.mtx.RUnlock()
+
+	if !lastSync.IsZero() {
+		details.LastSync = lastSync.UTC().Format(time.RFC3339)
+	}
+
+	// Failing to sync with the server is not something the agent recovers
+	// from by restarting, so it only affects readiness.
+	return health.State{
+		Live:         true,
+		Ready:        failedSyncs < failedSyncThreshold,
+		ReadyDetails: details,
+		LiveDetails:  details,
+	}
+}
+
+type syncHealthDetails struct {
+	LastSync            string `json:"last_sync,omitempty"`
+	LastSyncLatency     string `json:"last_sync_latency,omitempty"`
+	ConsecutiveFailures int    `json:"consecutive_failures,omitempty"`
+	SyncErr             string `json:"sync_err,omitempty"`
+}
+
+func durationString(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+func errString(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package manager
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire/pkg/common/health"
+	"github.com/spiffe/spire/test/clock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncHealth(t *testing.T) {
+	clk := clock.NewMock(t)
+	m := &manager{
+		mtx: new(sync.RWMutex),
+		clk: clk,
+	}
+	h := &syncHealth{m: m}
+
+	requireState := func(ready bool, details syncHealthDetails) {
+		require.Equal(t, health.State{
+			Live:         true,
+			Ready:        ready,
+			ReadyDetails: details,
+			LiveDetails:  details,
+		}, h.CheckHealth())
+	}
+
+	// No sync yet
+	requireState(true, syncHealthDetails{})
+
+	m.setLastSync()
+	m.recordSyncResult(time.Second, nil)
+	lastSync := clk.Now().UTC().Format(time.RFC3339)
+	requireState(true, syncHealthDetails{
+		LastSync:        lastSync,
+		LastSyncLatency: "1s",
+	})
+
+	// Failures below the threshold keep the agent ready
+	for i := 1; i < failedSyncThreshold; i++ {
+		m.recordSyncResult(2*time.Second, errors.New("oh no"))
+	}
+	requireState(true, syncHealthDetails{
+		LastSync:            lastSync,
+		LastSyncLatency:     "2s",
+		ConsecutiveFailures: failedSyncThreshold - 1,
+		SyncErr:             "oh no",
+	})
+
+	m.recordSyncResult(2*time.Second, errors.New("oh no"))
+	requireState(false, syncHealthDetails{
+		LastSync:            lastSync,
+		LastSyncLatency:     "2s",
+		ConsecutiveFailures: failedSyncThreshold,
+		SyncErr:             "oh no",
+	})
+
+	// A successful sync resets the failures
+	clk.Add(time.Minute)
+	m.setLastSync()
+	m.recordSyncResult(time.Second, nil)
+	requireState(true, syncHealthDetails{
+		LastSync:        clk.Now().UTC().Format(time.RFC3339),
+		LastSyncLatency: "1s",
+	})
+}
@@ -0,0 +1,33 @@
+package manager
+
+// PressureDetector detects whether the agent is under resource pressure.
+type PressureDetector interface {
+	UnderPressure() (bool, error)
+}
+
+// updateLoadShedding checks whether the agent is under resource pressure and
+// sheds the low priority work accordingly. SVIDs are still rotated and
+// minted for the entries with active subscribers; only the SVIDs prefetched
+// for the entries without subscribers are shed.
+func (m *manager) updateLoadShedding() {
+	if m.c.PressureDetector == nil {
+		return
+	}
+
+	underPressure, err := m.c.PressureDetector.UnderPressure()
+	if err != nil {
+		m.c.Log.WithError(err).Debug("Failed to detect resource pressure")
+		underPressure = false
+	}
+	if underPressure == m.underPressure {
+		return
+	}
+
+	m.underPressure = underPressure
+	m.cache.SetShedPrefetches(underPressure)
+	if underPressure {
+		m.c.Log.Warn("Agent is under resource pressure; shedding SVID prefetches")
+	} else {
+		m.c.Log.Info("Agent is no longer under resource pressure; resuming SVID prefetches")
+	}
+}
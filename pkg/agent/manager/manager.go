@@ -74,6 +74,14 @@ type Manager interface {
 
 	// GetBundle get latest cached bundle
 	GetBundle() *cache.Bundle
+
+	// RecordEntryUsage records that the SVIDs of the given entries were
+	// fetched by a workload.
+	RecordEntryUsage(entryIDs []string)
+
+	// EntryUsage returns when the SVID of each cached entry was last
+	// fetched by a workload.
+	EntryUsage() []EntryUsage
 }
 
 // Cache stores each registration entry, signed X509-SVIDs for those entries,
@@ -87,6 +95,10 @@ type Cache interface {
 	// SyncSVIDsWithSubscribers syncs SVID cache
 	SyncSVIDsWithSubscribers()
 
+	// SetShedPrefetches sets whether SVIDs are prefetched for entries without
+	// active subscribers
+	SetShedPrefetches(shed bool)
+
 	// SubscribeToWorkloadUpdates creates a subscriber for given selector set.
 	SubscribeToWorkloadUpdates(ctx context.Context, selectors cache.Selectors) (cache.Subscriber, error)
 
@@ -140,8 +152,23 @@ type manager struct {
 	// Saves last success sync
 	lastSync time.Time
 
+	// Outcome of the last sync and number of consecutive failed syncs,
+	// reported by the health check
+	lastSyncLatency time.Duration
+	lastSyncErr     error
+	failedSyncs     int
+
 	// Cache for 'storable' SVIDs
 	svidStoreCache *storecache.Cache
+
+	// Whether the agent was under resource pressure the last time it was
+	// checked. Only accessed by the SVID sync loop.
+	underPressure bool
+
+	// Time the SVID of each entry was last fetched by a workload, keyed by
+	// entry ID.
+	usageMtx sync.Mutex
+	lastUsed map[string]time.Time
 }
 
 func (m *manager) Initialize(ctx context.Context) error {
@@ -317,6 +344,19 @@ func (m *manager) setLastSync() {
 	m.lastSync = m.clk.Now()
 }
 
+func (m *manager) recordSyncResult(latency time.Duration, err error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.lastSyncLatency = latency
+	m.lastSyncErr = err
+	if err != nil {
+		m.failedSyncs++
+	} else {
+		m.failedSyncs = 0
+	}
+}
+
 func (m *manager) GetLastSync() time.Time {
 	m.mtx.RLock()
 	defer m.mtx.RUnlock()
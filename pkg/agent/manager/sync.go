@@ -39,6 +39,7 @@ type SVIDCache interface {
 func (m *manager) syncSVIDs(ctx context.Context) (err error) {
 	// perform syncSVIDs only if using LRU cache
 	if m.c.SVIDCacheMaxSize > 0 {
+		m.updateLoadShedding()
 		m.cache.SyncSVIDsWithSubscribers()
 		return m.updateSVIDs(ctx, m.c.Log.WithField(telemetry.CacheType, "workload"), m.cache)
 	}
@@ -48,6 +49,11 @@ func (m *manager) syncSVIDs(ctx context.Context) (err error) {
 // synchronize fetches the authorized entries from the server, updates the
 // cache, and fetches missing/expiring SVIDs.
 func (m *manager) synchronize(ctx context.Context) (err error) {
+	start := m.clk.Now()
+	defer func() {
+		m.recordSyncResult(m.clk.Now().Sub(start), err)
+	}()
+
 	cacheUpdate, storeUpdate, err := m.fetchEntries(ctx)
 	if err != nil {
 		return err
@@ -63,6 +69,7 @@ func (m *manager) synchronize(ctx context.Context) (err error) {
 
 	// Set last success sync
 	m.setLastSync()
+	m.reportEntryUsage()
 	return nil
 }
 
@@ -0,0 +1,69 @@
+package manager
+
+import (
+	"time"
+
+	telemetry_agent "github.com/spiffe/spire/pkg/common/telemetry/agent"
+	"github.com/spiffe/spire/proto/spire/common"
+)
+
+// EntryUsage is the time the SVID of a cached registration entry was last
+// fetched by a workload.
+type EntryUsage struct {
+	Entry *common.RegistrationEntry
+
+	// LastUsed is zero if no workload fetched the SVID since the agent
+	// started.
+	LastUsed time.Time
+}
+
+func (m *manager) RecordEntryUsage(entryIDs []string) {
+	if len(entryIDs) == 0 {
+		return
+	}
+	now := m.clk.Now()
+
+	m.usageMtx.Lock()
+	defer m.usageMtx.Unlock()
+	for _, entryID := range entryIDs {
+		m.lastUsed[entryID] = now
+	}
+}
+
+func (m *manager) EntryUsage() []EntryUsage {
+	entries := m.cache.Entries()
+
+	m.usageMtx.Lock()
+	defer m.usageMtx.Unlock()
+	usage := make([]EntryUsage, 0, len(entries))
+	for _, entry := range entries {
+		usage = append(usage, EntryUsage{
+			Entry:    entry,
+			LastUsed: m.lastUsed[entry.EntryId],
+		})
+	}
+	return usage
+}
+
+// reportEntryUsage emits the number of cached entries never used by a
+// workload and forgets the usage of the entries no longer cached.
+func (m *manager) reportEntryUsage() {
+	entries := m.cache.Entries()
+
+	m.usageMtx.Lock()
+	defer m.usageMtx.Unlock()
+	cached := make(map[string]bool, len(entries))
+	unused := 0
+	for _, entry := range entries {
+		cached[entry.EntryId] = true
+		if _, ok := m.lastUsed[entry.EntryId]; !ok {
+			unused++
+		}
+	}
+	for entryID := range m.lastUsed {
+		if !cached[entryID] {
+			delete(m.lastUsed, entryID)
+		}
+	}
+	telemetry_agent.AddCacheManagerUnusedEntriesSample(m.c.Metrics, float32(unused))
+}
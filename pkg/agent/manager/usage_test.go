@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/spiffe/spire/test/clock"
+	"github.com/spiffe/spire/test/fakes/fakemetrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntryUsage(t *testing.T) {
+	clk := clock.NewMock(t)
+	metrics := fakemetrics.New()
+	foo := &common.RegistrationEntry{EntryId: "FOO", SpiffeId: "spiffe://example.org/foo"}
+	bar := &common.RegistrationEntry{EntryId: "BAR", SpiffeId: "spiffe://example.org/bar"}
+	cache := &entriesCache{entries: []*common.RegistrationEntry{foo, bar}}
+	m := &manager{
+		c:        &Config{Metrics: metrics},
+		cache:    cache,
+		clk:      clk,
+		lastUsed: make(map[string]time.Time),
+	}
+
+	// No entry is used initially
+	assert.Equal(t, []EntryUsage{{Entry: foo}, {Entry: bar}}, m.EntryUsage())
+	m.reportEntryUsage()
+	assert.Equal(t, []fakemetrics.MetricItem{unusedEntriesSample(2)}, metrics.AllMetrics())
+
+	m.RecordEntryUsage([]string{"FOO"})
+	fooUsed := clk.Now()
+	clk.Add(time.Minute)
+	assert.Equal(t, []EntryUsage{{Entry: foo, LastUsed: fooUsed}, {Entry: bar}}, m.EntryUsage())
+
+	// Usage is forgotten once the entry is no longer cached
+	metrics.Reset()
+	cache.entries = []*common.RegistrationEntry{bar}
+	m.reportEntryUsage()
+	assert.Equal(t, []fakemetrics.MetricItem{unusedEntriesSample(1)}, metrics.AllMetrics())
+	assert.Empty(t, m.lastUsed)
+}
+
+func unusedEntriesSample(val float32) fakemetrics.MetricItem {
+	return fakemetrics.MetricItem{
+		Type: fakemetrics.AddSampleType,
+		Key:  []string{telemetry.CacheManager, telemetry.UnusedEntries},
+		Val:  val,
+	}
+}
+
+type entriesCache struct {
+	Cache
+	entries []*common.RegistrationEntry
+}
+
+func (c *entriesCache) Entries() []*common.RegistrationEntry {
+	return c.entries
+}
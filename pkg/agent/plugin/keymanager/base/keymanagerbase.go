@@ -43,6 +43,7 @@ type Base struct {
 
 	mu      sync.RWMutex
 	entries map[string]*KeyEntry
+	zeroize bool
 }
 
 // New creates a new base key manager using the provided Funcs. Default
@@ -79,6 +80,28 @@ func (m *Base) SetEntries(entries []*KeyEntry) {
 	}
 }
 
+// SetZeroizeKeys sets whether the private keys are overwritten in memory once
+// they are replaced or the key manager is closed.
+func (m *Base) SetZeroizeKeys(zeroize bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.zeroize = zeroize
+}
+
+// Close zeroizes the private keys, if enabled. The key manager can not be
+// used afterwards.
+func (m *Base) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.zeroize {
+		for _, entry := range m.entries {
+			zeroizePrivateKey(entry.PrivateKey)
+		}
+		m.entries = make(map[string]*KeyEntry)
+	}
+	return nil
+}
+
 // GenerateKey implements the KeyManager RPC of the same name.
 func (m *Base) GenerateKey(ctx context.Context, req *keymanagerv1.GenerateKeyRequest) (*keymanagerv1.GenerateKeyResponse, error) {
 	resp, err := m.generateKey(ctx, req)
@@ -152,6 +175,9 @@ func (m *Base) generateKey(ctx context.Context, req *keymanagerv1.GenerateKeyReq
 			return nil, err
 		}
 	}
+	if hasEntry && m.zeroize {
+		zeroizePrivateKey(oldEntry.PrivateKey)
+	}
 
 	return &keymanagerv1.GenerateKeyResponse{
 		PublicKey: clonePublicKey(newEntry.PublicKey),
@@ -188,31 +214,27 @@ func (m *Base) signData(req *keymanagerv1.SignDataRequest) (*keymanagerv1.SignDa
 		return nil, status.Errorf(codes.InvalidArgument, "unsupported signer opts type %T", opts)
 	}
 
-	privateKey, fingerprint, ok := m.getPrivateKeyAndFingerprint(req.KeyId)
-	if !ok {
+	// Sign while holding the lock so the key is not zeroized in the middle
+	// of the operation
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry := m.entries[req.KeyId]
+	if entry == nil {
 		return nil, status.Errorf(codes.NotFound, "no such key %q", req.KeyId)
 	}
 
-	signature, err := privateKey.Sign(rand.Reader, req.Data, signerOpts)
+	signature, err := entry.PrivateKey.Sign(rand.Reader, req.Data, signerOpts)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "keypair %q signing operation failed: %v", req.KeyId, err)
 	}
 
 	return &keymanagerv1.SignDataResponse{
 		Signature:      signature,
-		KeyFingerprint: fingerprint,
+		KeyFingerprint: entry.PublicKey.Fingerprint,
 	}, nil
 }
 
-func (m *Base) getPrivateKeyAndFingerprint(id string) (crypto.Signer, string, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	if entry := m.entries[id]; entry != nil {
-		return entry.PrivateKey, entry.PublicKey.Fingerprint, true
-	}
-	return nil, "", false
-}
-
 func (m *Base) generateKeyEntry(keyID string, keyType keymanagerv1.KeyType) (e *KeyEntry, err error) {
 	var privateKey crypto.Signer
 	switch keyType {
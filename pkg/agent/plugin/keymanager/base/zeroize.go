@@ -0,0 +1,42 @@
+package keymanagerbase
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"math/big"
+)
+
+// zeroizePrivateKey overwrites the private values of the key in place. It is
+// best effort: copies made by the runtime (e.g. when a goroutine stack grows)
+// or by the crypto packages may remain until they are reused.
+func zeroizePrivateKey(key crypto.Signer) {
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		zeroizeInt(key.D)
+	case *rsa.PrivateKey:
+		zeroizeInt(key.D)
+		for _, prime := range key.Primes {
+			zeroizeInt(prime)
+		}
+		zeroizeInt(key.Precomputed.Dp)
+		zeroizeInt(key.Precomputed.Dq)
+		zeroizeInt(key.Precomputed.Qinv)
+		for _, crtValue := range key.Precomputed.CRTValues {
+			zeroizeInt(crtValue.Exp)
+			zeroizeInt(crtValue.Coeff)
+			zeroizeInt(crtValue.R)
+		}
+	}
+}
+
+func zeroizeInt(n *big.Int) {
+	if n == nil {
+		return
+	}
+	words := n.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	n.SetInt64(0)
+}
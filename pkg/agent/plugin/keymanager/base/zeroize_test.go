@@ -0,0 +1,67 @@
+package keymanagerbase
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"testing"
+
+	keymanagerv1 "github.com/spiffe/spire-plugin-sdk/proto/spire/plugin/agent/keymanager/v1"
+	"github.com/spiffe/spire/test/testkey"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZeroizePrivateKey(t *testing.T) {
+	ecKey := testkey.NewEC256(t)
+	zeroizePrivateKey(ecKey)
+	require.Zero(t, ecKey.D.Sign())
+
+	rsaKey := testkey.NewRSA2048(t)
+	zeroizePrivateKey(rsaKey)
+	require.Zero(t, rsaKey.D.Sign())
+	for _, prime := range rsaKey.Primes {
+		require.Zero(t, prime.Sign())
+	}
+	require.Zero(t, rsaKey.Precomputed.Dp.Sign())
+	require.Zero(t, rsaKey.Precomputed.Dq.Sign())
+	require.Zero(t, rsaKey.Precomputed.Qinv.Sign())
+}
+
+func TestZeroizeKeys(t *testing.T) {
+	for _, zeroize := range []bool{false, true} {
+		var keys []*ecdsa.PrivateKey
+		m := New(Funcs{
+			GenerateEC256Key: func() (*ecdsa.PrivateKey, error) {
+				key := testkey.NewEC256(t)
+				keys = append(keys, key)
+				return key, nil
+			},
+			GenerateRSA2048Key: func() (*rsa.PrivateKey, error) {
+				t.Fatal("unexpected RSA key generation")
+				return nil, nil
+			},
+		})
+		m.SetZeroizeKeys(zeroize)
+
+		generate := func(id string) {
+			_, err := m.GenerateKey(context.Background(), &keymanagerv1.GenerateKeyRequest{
+				KeyId:   id,
+				KeyType: keymanagerv1.KeyType_EC_P256,
+			})
+			require.NoError(t, err)
+		}
+		generate("A")
+		generate("A")
+		generate("B")
+
+		// The replaced key is zeroized
+		require.Equal(t, zeroize, keys[0].D.Sign() == 0)
+		require.NotZero(t, keys[1].D.Sign())
+		require.NotZero(t, keys[2].D.Sign())
+
+		// The remaining keys are zeroized on close
+		require.NoError(t, m.Close())
+		require.Equal(t, zeroize, keys[1].D.Sign() == 0)
+		require.Equal(t, zeroize, keys[2].D.Sign() == 0)
+	}
+}
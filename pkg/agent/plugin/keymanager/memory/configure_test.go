@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spiffe/spire/pkg/agent/plugin/keymanager"
+	"github.com/spiffe/spire/test/plugintest"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestConfigureLockMemory(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		config     string
+		lockErr    error
+		expectLock bool
+		expectCode codes.Code
+		expectMsg  string
+	}{
+		{
+			name:   "memory is not locked by default",
+			config: "",
+		},
+		{
+			name:       "memory is locked",
+			config:     "lock_memory = true",
+			expectLock: true,
+		},
+		{
+			name:       "memory fails to be locked",
+			config:     "lock_memory = true",
+			lockErr:    errors.New("oh no"),
+			expectLock: true,
+			expectCode: codes.FailedPrecondition,
+			expectMsg:  "unable to lock memory: oh no",
+		},
+		{
+			name:       "invalid configuration",
+			config:     "lock_memory = {",
+			expectCode: codes.InvalidArgument,
+			expectMsg:  "unable to decode configuration",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p := New()
+			locked := false
+			p.lockMemory = func() error {
+				locked = true
+				return tt.lockErr
+			}
+
+			var err error
+			plugintest.Load(t, builtin(p), new(keymanager.V1),
+				plugintest.Configure(tt.config),
+				plugintest.CaptureConfigureError(&err),
+			)
+			require.Equal(t, tt.expectLock, locked)
+			spiretest.RequireGRPCStatusContains(t, err, tt.expectCode, tt.expectMsg)
+		})
+	}
+}
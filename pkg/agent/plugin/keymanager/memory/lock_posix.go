@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+package memory
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockMemory locks all the current and future pages of the process in
+// memory, since the keys live in the Go heap, and disables core dumps.
+func lockMemory() error {
+	if err := raiseMemlockLimit(); err != nil {
+		return err
+	}
+	if err := unix.Mlockall(unix.MCL_CURRENT | unix.MCL_FUTURE); err != nil {
+		return err
+	}
+	return unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{})
+}
+
+// raiseMemlockLimit makes sure RLIMIT_MEMLOCK is unlimited. With
+// MCL_FUTURE, every page the process maps later is locked as well, so once
+// the heap grows past a finite limit the allocations of the Go runtime fail
+// and the agent crashes, instead of failing here.
+func raiseMemlockLimit() error {
+	var limit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_MEMLOCK, &limit); err != nil {
+		return fmt.Errorf("unable to get RLIMIT_MEMLOCK: %w", err)
+	}
+	if limit.Cur == unix.RLIM_INFINITY {
+		return nil
+	}
+
+	// Raising the hard limit requires CAP_SYS_RESOURCE
+	if err := unix.Setrlimit(unix.RLIMIT_MEMLOCK, &unix.Rlimit{Cur: unix.RLIM_INFINITY, Max: unix.RLIM_INFINITY}); err != nil {
+		return fmt.Errorf("RLIMIT_MEMLOCK is limited to %d bytes and could not be raised to unlimited: %w", limit.Cur, err)
+	}
+	return nil
+}
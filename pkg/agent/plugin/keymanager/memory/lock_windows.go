@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package memory
+
+import (
+	"errors"
+)
+
+// lockMemory is not supported since VirtualLock only locks given regions,
+// while the keys live in the Go heap.
+func lockMemory() error {
+	return errors.New("not supported on this platform")
+}
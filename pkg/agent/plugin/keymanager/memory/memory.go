@@ -1,9 +1,16 @@
 package memory
 
 import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/hcl"
 	keymanagerv1 "github.com/spiffe/spire-plugin-sdk/proto/spire/plugin/agent/keymanager/v1"
+	configv1 "github.com/spiffe/spire-plugin-sdk/proto/spire/service/common/config/v1"
 	keymanagerbase "github.com/spiffe/spire/pkg/agent/plugin/keymanager/base"
 	"github.com/spiffe/spire/pkg/common/catalog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func BuiltIn() catalog.BuiltIn {
@@ -11,15 +18,56 @@ func BuiltIn() catalog.BuiltIn {
 }
 
 func builtin(p *KeyManager) catalog.BuiltIn {
-	return catalog.MakeBuiltIn("memory", keymanagerv1.KeyManagerPluginServer(p))
+	return catalog.MakeBuiltIn("memory",
+		keymanagerv1.KeyManagerPluginServer(p),
+		configv1.ConfigServiceServer(p))
+}
+
+type configuration struct {
+	// LockMemory locks the memory of the agent so the keys are not swapped
+	// out, and disables core dumps.
+	LockMemory bool `hcl:"lock_memory"`
+
+	// ZeroizeKeys overwrites the private keys in memory once they are
+	// replaced or the agent stops.
+	ZeroizeKeys bool `hcl:"zeroize_keys"`
 }
 
 type KeyManager struct {
 	*keymanagerbase.Base
+	configv1.UnimplementedConfigServer
+
+	// lockMemory is a hook used by tests to avoid locking their memory
+	lockMemory func() error
+
+	mu     sync.Mutex
+	locked bool
 }
 
 func New() *KeyManager {
 	return &KeyManager{
-		Base: keymanagerbase.New(keymanagerbase.Funcs{}),
+		Base:       keymanagerbase.New(keymanagerbase.Funcs{}),
+		lockMemory: lockMemory,
 	}
 }
+
+func (m *KeyManager) Configure(ctx context.Context, req *configv1.ConfigureRequest) (*configv1.ConfigureResponse, error) {
+	config := new(configuration)
+	if err := hcl.Decode(config, req.HclConfiguration); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unable to decode configuration: %v", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Memory stays locked until the agent exits
+	if config.LockMemory && !m.locked {
+		if err := m.lockMemory(); err != nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "unable to lock memory: %v", err)
+		}
+		m.locked = true
+	}
+	m.Base.SetZeroizeKeys(config.ZeroizeKeys)
+
+	return &configv1.ConfigureResponse{}, nil
+}
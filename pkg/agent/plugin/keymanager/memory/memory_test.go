@@ -18,3 +18,13 @@ func TestKeyManagerContract(t *testing.T) {
 		},
 	})
 }
+
+func TestKeyManagerContractWithZeroization(t *testing.T) {
+	keymanagertest.Test(t, keymanagertest.Config{
+		Create: func(t *testing.T) keymanager.KeyManager {
+			km := new(keymanager.V1)
+			plugintest.Load(t, memory.BuiltIn(), km, plugintest.Configure("zeroize_keys = true"))
+			return km
+		},
+	})
+}
@@ -1,7 +1,10 @@
 package awssecretsmanager
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"os"
@@ -18,10 +21,14 @@ import (
 	"github.com/spiffe/spire/pkg/common/catalog"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 const (
 	pluginName = "aws_secretsmanager"
+
+	formatJSON   = "json"
+	formatPKCS12 = "pkcs12"
 )
 
 func BuiltIn() catalog.BuiltIn {
@@ -39,7 +46,7 @@ func New() *SecretsManagerPlugin {
 	return newPlugin(createSecretManagerClient)
 }
 
-func newPlugin(newClient func(ctx context.Context, secretAccessKey, accessKeyID, region string) (SecretsManagerClient, error)) *SecretsManagerPlugin {
+func newPlugin(newClient func(ctx context.Context, secretAccessKey, accessKeyID, region, roleARN string) (SecretsManagerClient, error)) *SecretsManagerPlugin {
 	p := &SecretsManagerPlugin{}
 	p.hooks.newClient = newClient
 	p.hooks.getenv = os.Getenv
@@ -51,6 +58,14 @@ type Configuration struct {
 	AccessKeyID     string `hcl:"access_key_id" json:"access_key_id"`
 	SecretAccessKey string `hcl:"secret_access_key" json:"secret_access_key"`
 	Region          string `hcl:"region" json:"region"`
+
+	// PKCS12Password is the password protecting the secrets stored in the
+	// PKCS#12 format. It is required to use that format.
+	PKCS12Password string `hcl:"pkcs12_password" json:"pkcs12_password"`
+
+	// AllowedRoleARNs are the roles that may be assumed through the
+	// "rolearn" selector.
+	AllowedRoleARNs []string `hcl:"allowed_role_arns" json:"allowed_role_arns,omitempty"`
 }
 
 type SecretsManagerPlugin struct {
@@ -58,11 +73,17 @@ type SecretsManagerPlugin struct {
 	configv1.UnsafeConfigServer
 
 	log      hclog.Logger
+	config   *Configuration
 	smClient SecretsManagerClient
 	mtx      sync.RWMutex
 
+	// roleClients caches the clients that assume the roles provided
+	// through the "rolearn" selector, keyed by role ARN. Only allowed roles
+	// are cached, which bounds its size.
+	roleClients map[string]SecretsManagerClient
+
 	hooks struct {
-		newClient func(ctx context.Context, secretAccessKey, accessKeyID, region string) (SecretsManagerClient, error)
+		newClient func(ctx context.Context, secretAccessKey, accessKeyID, region, roleARN string) (SecretsManagerClient, error)
 		getenv    func(string) string
 	}
 }
@@ -91,7 +112,7 @@ func (p *SecretsManagerPlugin) Configure(ctx context.Context, req *configv1.Conf
 		return nil, status.Error(codes.InvalidArgument, "region is required")
 	}
 
-	smClient, err := p.hooks.newClient(ctx, config.SecretAccessKey, config.AccessKeyID, config.Region)
+	smClient, err := p.hooks.newClient(ctx, config.SecretAccessKey, config.AccessKeyID, config.Region, "")
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create secrets manager client: %v", err)
 	}
@@ -99,7 +120,9 @@ func (p *SecretsManagerPlugin) Configure(ctx context.Context, req *configv1.Conf
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
 
+	p.config = config
 	p.smClient = smClient
+	p.roleClients = make(map[string]SecretsManagerClient)
 
 	return &configv1.ConfigureResponse{}, nil
 }
@@ -113,27 +136,33 @@ func (p *SecretsManagerPlugin) PutX509SVID(ctx context.Context, req *svidstorev1
 
 	secretID := opt.getSecretID()
 
-	// Encode the secret from PutX509SVIDRequest
-	secret, err := svidstore.SecretFromProto(req)
+	p.mtx.RLock()
+	config := p.config
+	p.mtx.RUnlock()
+	if config == nil {
+		return nil, status.Error(codes.FailedPrecondition, "not configured")
+	}
+
+	secretBinary, err := encodeSecret(req, opt.format, config.PKCS12Password)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "failed to parse request: %v", err)
+		return nil, err
 	}
 
-	secretBinary, err := json.Marshal(secret)
+	smClient, err := p.getClient(ctx, opt.roleARN)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to parse payload: %v", err)
+		return nil, err
 	}
 
 	// Call DescribeSecret to retrieve the details of the secret
 	// and be able to determine if the secret exists
-	secretDesc, err := p.smClient.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+	secretDesc, err := smClient.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
 		SecretId: aws.String(secretID),
 	})
 	if err != nil {
 		var resourceNorFoundErr *types.ResourceNotFoundException
 		if errors.As(err, &resourceNorFoundErr) {
 			// Secret not found, creating one with provided `name`
-			resp, err := createSecret(ctx, p.smClient, secretBinary, opt)
+			resp, err := createSecret(ctx, smClient, secretBinary, opt)
 			if err != nil {
 				return nil, err
 			}
@@ -154,7 +183,7 @@ func (p *SecretsManagerPlugin) PutX509SVID(ctx context.Context, req *svidstorev1
 
 	// If the secret has been scheduled for deletion, restore it
 	if secretDesc.DeletedDate != nil {
-		resp, err := p.smClient.RestoreSecret(ctx, &secretsmanager.RestoreSecretInput{
+		resp, err := smClient.RestoreSecret(ctx, &secretsmanager.RestoreSecretInput{
 			SecretId: aws.String(secretID),
 		})
 		if err != nil {
@@ -163,7 +192,7 @@ func (p *SecretsManagerPlugin) PutX509SVID(ctx context.Context, req *svidstorev1
 		p.log.With("arn", aws.ToString(resp.ARN)).With("name", aws.ToString(resp.Name)).Debug("Secret was scheduled for deletion and has been restored")
 	}
 
-	putResp, err := p.smClient.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
+	putResp, err := smClient.PutSecretValue(ctx, &secretsmanager.PutSecretValueInput{
 		SecretId:     secretDesc.ARN,
 		SecretBinary: secretBinary,
 	})
@@ -184,9 +213,14 @@ func (p *SecretsManagerPlugin) DeleteX509SVID(ctx context.Context, req *svidstor
 
 	secretID := opt.getSecretID()
 
+	smClient, err := p.getClient(ctx, opt.roleARN)
+	if err != nil {
+		return nil, err
+	}
+
 	// Call DescribeSecret to retrieve the details of the secret
 	// and be able to determine if the secret exists
-	secretDesc, err := p.smClient.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
+	secretDesc, err := smClient.DescribeSecret(ctx, &secretsmanager.DescribeSecretInput{
 		SecretId: aws.String(secretID),
 	})
 	if err != nil {
@@ -204,7 +238,7 @@ func (p *SecretsManagerPlugin) DeleteX509SVID(ctx context.Context, req *svidstor
 		return nil, err
 	}
 
-	resp, err := p.smClient.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
+	resp, err := smClient.DeleteSecret(ctx, &secretsmanager.DeleteSecretInput{
 		SecretId:             secretDesc.ARN,
 		RecoveryWindowInDays: aws.Int64(7),
 	})
@@ -218,10 +252,56 @@ func (p *SecretsManagerPlugin) DeleteX509SVID(ctx context.Context, req *svidstor
 	return &svidstorev1.DeleteX509SVIDResponse{}, nil
 }
 
+// getClient returns the client used to access the secrets. When a role ARN
+// is provided, the returned client assumes that role.
+func (p *SecretsManagerPlugin) getClient(ctx context.Context, roleARN string) (SecretsManagerClient, error) {
+	p.mtx.RLock()
+	config := p.config
+	smClient := p.smClient
+	roleClient, ok := p.roleClients[roleARN]
+	p.mtx.RUnlock()
+
+	switch {
+	case config == nil:
+		return nil, status.Error(codes.FailedPrecondition, "not configured")
+	case roleARN == "":
+		return smClient, nil
+	case ok:
+		return roleClient, nil
+	case !isRoleAllowed(config, roleARN):
+		return nil, status.Errorf(codes.PermissionDenied, "role %q is not in allowed_role_arns", roleARN)
+	}
+
+	roleClient, err := p.hooks.newClient(ctx, config.SecretAccessKey, config.AccessKeyID, config.Region, roleARN)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create secrets manager client for role %q: %v", roleARN, err)
+	}
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	// The plugin could have been reconfigured in the meantime
+	if p.config == config {
+		p.roleClients[roleARN] = roleClient
+	}
+	return roleClient, nil
+}
+
+func isRoleAllowed(config *Configuration, roleARN string) bool {
+	for _, allowed := range config.AllowedRoleARNs {
+		if allowed == roleARN {
+			return true
+		}
+	}
+	return false
+}
+
 type secretOptions struct {
 	name     string
 	arn      string
 	kmsKeyID string
+	roleARN  string
+	format   string
 }
 
 // getSecretID gets ARN if it is configured. If not configured, use secret name
@@ -243,15 +323,70 @@ func optionsFromSecretData(metadata []string) (*secretOptions, error) {
 		name:     data["secretname"],
 		arn:      data["arn"],
 		kmsKeyID: data["kmskeyid"],
+		roleARN:  data["rolearn"],
+		format:   data["format"],
 	}
 
 	if opt.name == "" && opt.arn == "" {
 		return nil, status.Error(codes.InvalidArgument, "either the secret name or ARN is required")
 	}
 
+	switch opt.format {
+	case "":
+		opt.format = formatJSON
+	case formatJSON, formatPKCS12:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported secret format %q", opt.format)
+	}
+
 	return opt, nil
 }
 
+// encodeSecret encodes the SVID in the given format. The PKCS#12 format
+// only holds the SVID and its key, protected with the given password, since
+// it has no room for the bundles.
+func encodeSecret(req *svidstorev1.PutX509SVIDRequest, format, pkcs12Password string) ([]byte, error) {
+	if format == formatPKCS12 {
+		return encodePKCS12(req, pkcs12Password)
+	}
+
+	// Encode the secret from PutX509SVIDRequest
+	secret, err := svidstore.SecretFromProto(req)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse request: %v", err)
+	}
+
+	secretBinary, err := json.Marshal(secret)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to parse payload: %v", err)
+	}
+	return secretBinary, nil
+}
+
+func encodePKCS12(req *svidstorev1.PutX509SVIDRequest, password string) ([]byte, error) {
+	if password == "" {
+		return nil, status.Error(codes.FailedPrecondition, "pkcs12_password must be configured to store secrets in the pkcs12 format")
+	}
+
+	certChain, err := x509.ParseCertificates(bytes.Join(req.Svid.CertChain, nil))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse CertChain: %v", err)
+	}
+	if len(certChain) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "failed to parse CertChain: certificate chain is empty")
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(req.Svid.PrivateKey)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to parse PrivateKey: %v", err)
+	}
+
+	secretBinary, err := pkcs12.Encode(rand.Reader, privateKey, certChain[0], certChain[1:], password)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode PKCS#12 payload: %v", err)
+	}
+	return secretBinary, nil
+}
+
 func createSecret(ctx context.Context, sm SecretsManagerClient, secretBinary []byte, opt *secretOptions) (*secretsmanager.CreateSecretOutput, error) {
 	if opt.name == "" {
 		return nil, status.Error(codes.InvalidArgument, "failed to create secret: name selector is required")
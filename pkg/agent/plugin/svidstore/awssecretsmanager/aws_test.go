@@ -21,6 +21,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 const (
@@ -159,7 +160,7 @@ func TestConfigure(t *testing.T) {
 				return env
 			}
 
-			newClientFunc := func(ctx context.Context, secretAccessKey, accessKeyID, region string) (SecretsManagerClient, error) {
+			newClientFunc := func(ctx context.Context, secretAccessKey, accessKeyID, region, roleARN string) (SecretsManagerClient, error) {
 				if tt.expectClientErr != nil {
 					return nil, tt.expectClientErr
 				}
@@ -170,6 +171,7 @@ func TestConfigure(t *testing.T) {
 				assert.Equal(t, tt.expectConfig.SecretAccessKey, secretAccessKey)
 				assert.Equal(t, tt.expectConfig.AccessKeyID, accessKeyID)
 				assert.Equal(t, tt.expectConfig.Region, region)
+				assert.Empty(t, roleARN)
 				return &fakeSecretsManagerClient{}, nil
 			}
 			p.hooks.newClient = newClientFunc
@@ -222,6 +224,7 @@ func TestPutX509SVID(t *testing.T) {
 		expectMsg  string
 		smConfig   *smConfig
 
+		expectRoleARNs           []string
 		expectDescribeInput      *secretsmanager.DescribeSecretInput
 		expectCreateSecretInput  func(*testing.T) *secretsmanager.CreateSecretInput
 		expectPutSecretInput     func(*testing.T) *secretsmanager.PutSecretValueInput
@@ -310,6 +313,104 @@ func TestPutX509SVID(t *testing.T) {
 				describeErr: &types.ResourceNotFoundException{Message: aws.String("not found")},
 			},
 		},
+		{
+			name: "Put SVID on existing secret using a cross-account role",
+			req: &svidstore.X509SVID{
+				SVID: &svidstore.SVID{
+					SPIFFEID:   spiffeid.RequireFromString("spiffe://example.org/lambda"),
+					CertChain:  []*x509.Certificate{x509Cert},
+					PrivateKey: x509Key,
+					Bundle:     []*x509.Certificate{x509Bundle},
+					ExpiresAt:  expiresAt,
+				},
+				Metadata: []string{
+					"arn:arn:aws:secretsmanager:us-east-1:123456789012:secret:secret1",
+					"rolearn:arn:aws:iam::123456789012:role/spire-svidstore",
+				},
+				FederatedBundles: map[string][]*x509.Certificate{
+					"federated1": {federatedBundle},
+				},
+			},
+			expectRoleARNs: []string{"arn:aws:iam::123456789012:role/spire-svidstore"},
+			expectDescribeInput: &secretsmanager.DescribeSecretInput{
+				SecretId: aws.String("arn:aws:secretsmanager:us-east-1:123456789012:secret:secret1"),
+			},
+			expectPutSecretInput: func(t *testing.T) *secretsmanager.PutSecretValueInput {
+				secret := &svidstore.Data{
+					SPIFFEID:    "spiffe://example.org/lambda",
+					X509SVID:    x509CertPem,
+					X509SVIDKey: x509KeyPem,
+					Bundle:      x509BundlePem,
+					FederatedBundles: map[string]string{
+						"federated1": x509FederatedBundlePem,
+					},
+				}
+				secretBinary, err := json.Marshal(secret)
+				assert.NoError(t, err)
+
+				return &secretsmanager.PutSecretValueInput{
+					SecretId:     aws.String("arn:aws:secretsmanager:us-east-1:123456789012:secret:secret1-arn"),
+					SecretBinary: secretBinary,
+				}
+			},
+			smConfig: &smConfig{},
+		},
+		{
+			name: "Fails to create client for cross-account role",
+			req: &svidstore.X509SVID{
+				SVID: &svidstore.SVID{
+					SPIFFEID:   spiffeid.RequireFromString("spiffe://example.org/lambda"),
+					CertChain:  []*x509.Certificate{x509Cert},
+					PrivateKey: x509Key,
+					Bundle:     []*x509.Certificate{x509Bundle},
+					ExpiresAt:  expiresAt,
+				},
+				Metadata: []string{
+					"secretname:secret1",
+					"rolearn:arn:aws:iam::123456789012:role/spire-svidstore",
+				},
+			},
+			smConfig: &smConfig{
+				newRoleClientErr: errors.New("oh no"),
+			},
+			expectCode: codes.Internal,
+			expectMsg:  "svidstore(aws_secretsmanager): failed to create secrets manager client for role \"arn:aws:iam::123456789012:role/spire-svidstore\": oh no",
+		},
+		{
+			name: "Cross-account role not allowed",
+			req: &svidstore.X509SVID{
+				SVID: &svidstore.SVID{
+					SPIFFEID:   spiffeid.RequireFromString("spiffe://example.org/lambda"),
+					CertChain:  []*x509.Certificate{x509Cert},
+					PrivateKey: x509Key,
+					Bundle:     []*x509.Certificate{x509Bundle},
+					ExpiresAt:  expiresAt,
+				},
+				Metadata: []string{
+					"secretname:secret1",
+					"rolearn:arn:aws:iam::210987654321:role/other",
+				},
+			},
+			smConfig:   &smConfig{},
+			expectCode: codes.PermissionDenied,
+			expectMsg:  "svidstore(aws_secretsmanager): role \"arn:aws:iam::210987654321:role/other\" is not in allowed_role_arns",
+		},
+		{
+			name: "Unsupported secret format",
+			req: &svidstore.X509SVID{
+				SVID: &svidstore.SVID{
+					SPIFFEID:   spiffeid.RequireFromString("spiffe://example.org/lambda"),
+					CertChain:  []*x509.Certificate{x509Cert},
+					PrivateKey: x509Key,
+					Bundle:     []*x509.Certificate{x509Bundle},
+					ExpiresAt:  expiresAt,
+				},
+				Metadata: []string{"secretname:secret1", "format:yaml"},
+			},
+			smConfig:   &smConfig{},
+			expectCode: codes.InvalidArgument,
+			expectMsg:  "svidstore(aws_secretsmanager): unsupported secret format \"yaml\"",
+		},
 		{
 			name: "No secret name or arn",
 			req: &svidstore.X509SVID{
@@ -507,7 +608,10 @@ func TestPutX509SVID(t *testing.T) {
 				plugintest.CoreConfig(catalog.CoreConfig{
 					TrustDomain: spiffeid.RequireTrustDomainFromString("example.org"),
 				}),
-				plugintest.ConfigureJSON(&Configuration{Region: "r1"}),
+				plugintest.ConfigureJSON(&Configuration{
+					Region:          "r1",
+					AllowedRoleARNs: []string{"arn:aws:iam::123456789012:role/spire-svidstore"},
+				}),
 			}
 			ss := new(svidstore.V1)
 			plugintest.Load(t, builtin(p), ss,
@@ -539,10 +643,93 @@ func TestPutX509SVID(t *testing.T) {
 			require.Equal(t, tt.expectDeleteSecretInput, sm.deleteSecretInput)
 			require.Equal(t, tt.expectDescribeInput, sm.drescribeSecretInput)
 			require.Equal(t, tt.expectRestoreSecretInput, sm.restoreSecretInput)
+			require.Equal(t, tt.expectRoleARNs, sm.roleARNs)
 		})
 	}
 }
 
+func TestPutX509SVIDPKCS12(t *testing.T) {
+	x509Cert, err := pemutil.ParseCertificate([]byte(x509CertPem))
+	require.NoError(t, err)
+
+	x509Bundle, err := pemutil.ParseCertificate([]byte(x509BundlePem))
+	require.NoError(t, err)
+
+	x509Key, err := pemutil.ParseECPrivateKey([]byte(x509KeyPem))
+	require.NoError(t, err)
+
+	req := &svidstore.X509SVID{
+		SVID: &svidstore.SVID{
+			SPIFFEID:   spiffeid.RequireFromString("spiffe://example.org/lambda"),
+			CertChain:  []*x509.Certificate{x509Cert, x509Bundle},
+			PrivateKey: x509Key,
+			Bundle:     []*x509.Certificate{x509Bundle},
+			ExpiresAt:  time.Now(),
+		},
+		Metadata: []string{
+			"secretname:secret1",
+			"kmskeyid:arn:aws:kms:us-east-1:123456789012:key/some-key-id",
+			"format:pkcs12",
+		},
+	}
+
+	load := func(t *testing.T, password string) (*svidstore.V1, *fakeSecretsManagerClient) {
+		p := new(SecretsManagerPlugin)
+		p.hooks.getenv = func(string) string {
+			return ""
+		}
+		sm := &fakeSecretsManagerClient{
+			t: t,
+			c: &smConfig{
+				describeErr: &types.ResourceNotFoundException{Message: aws.String("not found")},
+			},
+		}
+		p.hooks.newClient = sm.createTestClient
+
+		ss := new(svidstore.V1)
+		plugintest.Load(t, builtin(p), ss,
+			plugintest.CoreConfig(catalog.CoreConfig{
+				TrustDomain: spiffeid.RequireTrustDomainFromString("example.org"),
+			}),
+			plugintest.ConfigureJSON(&Configuration{Region: "r1", PKCS12Password: password}),
+		)
+		return ss, sm
+	}
+
+	t.Run("success", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		ss, sm := load(t, "s3cr3t")
+		err := ss.PutX509SVID(ctx, req)
+		require.NoError(t, err)
+
+		require.NotNil(t, sm.createSecretInput)
+		require.Equal(t, "secret1", aws.ToString(sm.createSecretInput.Name))
+		require.Equal(t, "arn:aws:kms:us-east-1:123456789012:key/some-key-id", aws.ToString(sm.createSecretInput.KmsKeyId))
+
+		key, cert, caCerts, err := pkcs12.DecodeChain(sm.createSecretInput.SecretBinary, "s3cr3t")
+		require.NoError(t, err)
+		require.True(t, x509Key.Equal(key))
+		require.Equal(t, x509Cert.Raw, cert.Raw)
+		require.Len(t, caCerts, 1)
+		require.Equal(t, x509Bundle.Raw, caCerts[0].Raw)
+
+		_, _, _, err = pkcs12.DecodeChain(sm.createSecretInput.SecretBinary, "wrong")
+		require.Error(t, err)
+	})
+
+	t.Run("password not configured", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		ss, sm := load(t, "")
+		err := ss.PutX509SVID(ctx, req)
+		spiretest.RequireGRPCStatus(t, err, codes.FailedPrecondition, "svidstore(aws_secretsmanager): pkcs12_password must be configured to store secrets in the pkcs12 format")
+		require.Nil(t, sm.createSecretInput)
+	})
+}
+
 func TestDeleteX509SVID(t *testing.T) {
 	for _, tt := range []struct {
 		name                    string
@@ -677,6 +864,7 @@ type smConfig struct {
 	createSecretErr  error
 	describeErr      error
 	newClientErr     error
+	newRoleClientErr error
 	putSecretErr     error
 	deleteSecretErr  error
 	restoreSecretErr error
@@ -690,16 +878,23 @@ type fakeSecretsManagerClient struct {
 	putSecretInput       *secretsmanager.PutSecretValueInput
 	deleteSecretInput    *secretsmanager.DeleteSecretInput
 	restoreSecretInput   *secretsmanager.RestoreSecretInput
+	roleARNs             []string
 	c                    *smConfig
 }
 
-func (sm *fakeSecretsManagerClient) createTestClient(ctx context.Context, _, _, region string) (SecretsManagerClient, error) {
+func (sm *fakeSecretsManagerClient) createTestClient(ctx context.Context, _, _, region, roleARN string) (SecretsManagerClient, error) {
 	if sm.c.newClientErr != nil {
 		return nil, sm.c.newClientErr
 	}
 	if region == "" {
 		return nil, errors.New("no region provided")
 	}
+	if roleARN != "" {
+		if sm.c.newRoleClientErr != nil {
+			return nil, sm.c.newRoleClientErr
+		}
+		sm.roleARNs = append(sm.roleARNs, roleARN)
+	}
 	return sm, nil
 }
 
@@ -3,9 +3,12 @@ package awssecretsmanager
 import (
 	"context"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
 type SecretsManagerClient interface {
@@ -16,7 +19,7 @@ type SecretsManagerClient interface {
 	RestoreSecret(context.Context, *secretsmanager.RestoreSecretInput, ...func(*secretsmanager.Options)) (*secretsmanager.RestoreSecretOutput, error)
 }
 
-func createSecretManagerClient(ctx context.Context, secretAccessKey, accessKeyID, region string) (SecretsManagerClient, error) {
+func createSecretManagerClient(ctx context.Context, secretAccessKey, accessKeyID, region, roleARN string) (SecretsManagerClient, error) {
 	cfg, err := config.LoadDefaultConfig(ctx,
 		config.WithRegion(region),
 	)
@@ -27,5 +30,13 @@ func createSecretManagerClient(ctx context.Context, secretAccessKey, accessKeyID
 	if secretAccessKey != "" && accessKeyID != "" {
 		cfg.Credentials = credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, "")
 	}
+
+	// Assume the role, usually from another account, using the configured
+	// credentials
+	if roleARN != "" {
+		stsClient := sts.NewFromConfig(cfg)
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(stsClient, roleARN))
+	}
+
 	return secretsmanager.NewFromConfig(cfg), nil
 }
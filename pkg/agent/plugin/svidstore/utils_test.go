@@ -81,6 +81,15 @@ func TestParseMetadata(t *testing.T) {
 				"c": "3",
 			},
 		},
+		{
+			name: "values with colons",
+			secretData: []string{
+				"arn:arn:aws:secretsmanager:us-east-1:123456789012:secret:secret1",
+			},
+			expect: map[string]string{
+				"arn": "arn:aws:secretsmanager:us-east-1:123456789012:secret:secret1",
+			},
+		},
 		{
 			name:       "no data",
 			secretData: []string{},
@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"github.com/spiffe/spire/pkg/common/plugin/k8s/apiserver"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// apiServerFallbackInterval is the minimum interval between two pod lists
+// requested from the API server when the kubelet cannot be reached.
+const apiServerFallbackInterval = 5 * time.Second
+
+// apiServerPodLister lists the pods of the node through the API server when
+// the kubelet cannot be reached. Every agent of the cluster falls back at
+// once during a kubelet outage, e.g. a cluster-wide kubelet upgrade, so the
+// lists are rate limited instead of issued on every attestation attempt:
+// concurrent attestations share a single list, and its outcome, successful
+// or not, is reused until the minimum interval has passed.
+type apiServerPodLister struct {
+	clock       clock.Clock
+	minInterval time.Duration
+	client      apiserver.Client
+	nodeName    string
+
+	mtx      sync.Mutex
+	last     *apiServerPodList
+	inflight *apiServerPodList
+}
+
+// apiServerPodList is the outcome of a pod list query. Its fields are set
+// before done is closed.
+type apiServerPodList struct {
+	done      chan struct{}
+	list      *corev1.PodList
+	err       error
+	fetchedAt time.Time
+}
+
+func newAPIServerPodLister(clk clock.Clock, minInterval time.Duration, client apiserver.Client, nodeName string) *apiServerPodLister {
+	return &apiServerPodLister{
+		clock:       clk,
+		minInterval: minInterval,
+		client:      client,
+		nodeName:    nodeName,
+	}
+}
+
+// List returns the pods scheduled to the node.
+func (l *apiServerPodLister) List(ctx context.Context) (*corev1.PodList, error) {
+	l.mtx.Lock()
+	f := l.last
+	if f == nil || l.clock.Now().Sub(f.fetchedAt) >= l.minInterval {
+		f = l.startListLocked()
+	}
+	l.mtx.Unlock()
+
+	select {
+	case <-f.done:
+		return f.list, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// startListLocked starts a pod list query, unless one is already in flight,
+// and returns it. Like the pod list cache, the query is bound to its own
+// timeout rather than to the context of any of the attestations sharing it.
+func (l *apiServerPodLister) startListLocked() *apiServerPodList {
+	if l.inflight != nil {
+		return l.inflight
+	}
+	f := &apiServerPodList{done: make(chan struct{})}
+	l.inflight = f
+
+	go func() {
+		fetchedAt := l.clock.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), podListFetchTimeout)
+		list, err := l.client.ListNodePods(ctx, l.nodeName)
+		cancel()
+
+		l.mtx.Lock()
+		f.list = list
+		f.err = err
+		f.fetchedAt = fetchedAt
+		l.inflight = nil
+		l.last = f
+		l.mtx.Unlock()
+		close(f.done)
+	}()
+	return f
+}
@@ -0,0 +1,139 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire/pkg/common/plugin/k8s/apiserver"
+	"github.com/spiffe/spire/test/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestAPIServerPodListerRateLimits(t *testing.T) {
+	clk := clock.NewMock(t)
+	client := &countingPodListClient{}
+	lister := newAPIServerPodLister(clk, apiServerFallbackInterval, client, "k8s-node-1")
+	ctx := context.Background()
+
+	// The first list is queried on demand
+	list1, err := lister.List(ctx)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, client.lists())
+
+	// And reused within the interval
+	list, err := lister.List(ctx)
+	require.NoError(t, err)
+	require.Same(t, list1, list)
+	require.EqualValues(t, 1, client.lists())
+
+	// Failures are reused as well, so a failing API server is not queried
+	// on every attestation attempt
+	clk.Add(apiServerFallbackInterval)
+	client.setErr(errors.New("oh no"))
+	_, err = lister.List(ctx)
+	require.EqualError(t, err, "oh no")
+	_, err = lister.List(ctx)
+	require.EqualError(t, err, "oh no")
+	require.EqualValues(t, 2, client.lists())
+
+	clk.Add(apiServerFallbackInterval)
+	client.setErr(nil)
+	list2, err := lister.List(ctx)
+	require.NoError(t, err)
+	require.NotSame(t, list1, list2)
+	require.EqualValues(t, 3, client.lists())
+	require.Equal(t, []string{"k8s-node-1"}, client.nodeNames())
+}
+
+func TestAPIServerPodListerSharesLists(t *testing.T) {
+	release := make(chan struct{})
+	client := &countingPodListClient{release: release}
+	lister := newAPIServerPodLister(clock.NewMock(t), apiServerFallbackInterval, client, "k8s-node-1")
+
+	// Concurrent attestations wait on the same list
+	var wg sync.WaitGroup
+	lists := make([]*corev1.PodList, 10)
+	for i := range lists {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lists[i], _ = lister.List(context.Background())
+		}(i)
+	}
+	require.Eventually(t, func() bool {
+		return client.lists() == 1
+	}, time.Minute, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, client.lists())
+	for _, list := range lists {
+		assert.Same(t, lists[0], list)
+	}
+}
+
+func TestAPIServerPodListerCanceled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	lister := newAPIServerPodLister(clock.NewMock(t), apiServerFallbackInterval, &countingPodListClient{release: release}, "k8s-node-1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := lister.List(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+type countingPodListClient struct {
+	apiserver.Client
+
+	release <-chan struct{}
+
+	mtx   sync.Mutex
+	err   error
+	nodes map[string]bool
+	count int32
+}
+
+func (c *countingPodListClient) ListNodePods(ctx context.Context, nodeName string) (*corev1.PodList, error) {
+	atomic.AddInt32(&c.count, 1)
+	if c.release != nil {
+		<-c.release
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.nodes == nil {
+		c.nodes = make(map[string]bool)
+	}
+	c.nodes[nodeName] = true
+	if c.err != nil {
+		return nil, c.err
+	}
+	return new(corev1.PodList), nil
+}
+
+func (c *countingPodListClient) setErr(err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.err = err
+}
+
+func (c *countingPodListClient) lists() int32 {
+	return atomic.LoadInt32(&c.count)
+}
+
+func (c *countingPodListClient) nodeNames() []string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	var names []string
+	for name := range c.nodes {
+		names = append(names, name)
+	}
+	return names
+}
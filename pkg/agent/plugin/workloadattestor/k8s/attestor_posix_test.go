@@ -0,0 +1,95 @@
+//go:build !windows
+// +build !windows
+
+package k8s_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	attestor "github.com/spiffe/spire/pkg/agent/attestor/workload"
+	"github.com/spiffe/spire/pkg/agent/plugin/workloadattestor"
+	"github.com/spiffe/spire/pkg/agent/plugin/workloadattestor/k8s"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/spiffe/spire/test/fakes/fakeagentcatalog"
+	"github.com/spiffe/spire/test/fakes/fakeworkloadattestor"
+	"github.com/spiffe/spire/test/plugintest"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func TestAttestEphemeralContainerWithOtherAttestors(t *testing.T) {
+	const pid = 123
+
+	// The workload is the debug container of the pod
+	dir := spiretest.TempDir(t)
+	podList, err := os.ReadFile("testdata/ephemeral_pod_list.json")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pods.json"), podList, 0600))
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	cgroupPath := filepath.Join(dir, "proc", "123", "cgroup")
+	require.NoError(t, os.MkdirAll(filepath.Dir(cgroupPath), 0755))
+	require.NoError(t, os.Symlink(filepath.Join(wd, "testdata", "cgroups_pid_in_pod.txt"), cgroupPath))
+
+	unixSelectors := []*common.Selector{{Type: "unix", Value: "uid:0"}}
+
+	for _, tt := range []struct {
+		name         string
+		config       string
+		expectCode   codes.Code
+		expectMsg    string
+		expectResult []*common.Selector
+	}{
+		{
+			name:         "not attested",
+			config:       `pod_list_file = "pods.json"`,
+			expectResult: unixSelectors,
+		},
+		{
+			name: "denied",
+			config: `
+				pod_list_file = "pods.json"
+				deny_ephemeral_containers = true
+			`,
+			expectCode: codes.PermissionDenied,
+			expectMsg:  "workload attestation denied: workloadattestor(k8s): workload is an ephemeral container and ephemeral containers are denied",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			k8sAttestor := new(workloadattestor.V1)
+			plugintest.Load(t, k8s.BuiltInWithRootDir(dir), k8sAttestor,
+				plugintest.Configure(tt.config),
+			)
+
+			catalog := fakeagentcatalog.New()
+			catalog.SetWorkloadAttestors(
+				k8sAttestor,
+				fakeworkloadattestor.New(t, "unix", map[int32][]string{pid: {"uid:0"}}),
+			)
+
+			log, _ := test.NewNullLogger()
+			wla, err := attestor.New(&attestor.Config{
+				Catalog: catalog,
+				Log:     log,
+				Metrics: telemetry.Blackhole{},
+			})
+			require.NoError(t, err)
+
+			selectors, err := wla.Attest(context.Background(), pid)
+			if tt.expectCode != codes.OK {
+				spiretest.RequireGRPCStatus(t, err, tt.expectCode, tt.expectMsg)
+				require.Nil(t, selectors)
+				return
+			}
+			require.NoError(t, err)
+			spiretest.RequireProtoListEqual(t, tt.expectResult, selectors)
+		})
+	}
+}
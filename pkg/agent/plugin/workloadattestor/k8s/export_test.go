@@ -0,0 +1,14 @@
+package k8s
+
+import (
+	"github.com/spiffe/spire/pkg/common/catalog"
+)
+
+// BuiltInWithRootDir returns the plugin reading the process cgroups and the
+// pod list file under the given directory, so tests outside of the package
+// can attest fake workloads.
+func BuiltInWithRootDir(dir string) catalog.BuiltIn {
+	p := New()
+	p.fs = testFS(dir)
+	return builtin(p)
+}
@@ -24,9 +24,11 @@ import (
 	"github.com/spiffe/spire/pkg/agent/common/cgroups"
 	"github.com/spiffe/spire/pkg/common/catalog"
 	"github.com/spiffe/spire/pkg/common/pemutil"
+	"github.com/spiffe/spire/pkg/common/plugin/k8s/apiserver"
 	"github.com/spiffe/spire/pkg/common/telemetry"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"gopkg.in/square/go-jose.v2/jwt"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -40,6 +42,20 @@ const (
 	defaultTokenPath         = "/var/run/secrets/kubernetes.io/serviceaccount/token" //nolint: gosec // false positive
 	defaultNodeNameEnv       = "MY_NODE_NAME"
 	defaultReloadInterval    = time.Minute
+	namespaceLabelsMaxAge    = time.Minute
+
+	// kubeletRequestTimeout bounds how long a pod list query to the kubelet
+	// can take, so an unresponsive kubelet does not hang attestations or
+	// the pod list cache.
+	kubeletRequestTimeout = 10 * time.Second
+
+	// Annotations set by the kubelet on static pods (i.e. pods defined in
+	// manifest files on the node rather than through the API server) and on
+	// the mirror pods it creates in the API server to represent them.
+	configSourceAnnotation = "kubernetes.io/config.source"
+	configHashAnnotation   = "kubernetes.io/config.hash"
+	configMirrorAnnotation = "kubernetes.io/config.mirror"
+	configSourceAPI        = "api"
 )
 
 func BuiltIn() catalog.BuiltIn {
@@ -85,6 +101,11 @@ type HCLConfig struct {
 	// PrivateKeyPath and CertificatePath are specified.
 	TokenPath string `hcl:"token_path"`
 
+	// TokenAudience, if set, is the audience the token in TokenPath must be
+	// bound to. It is meant to be used with projected service account tokens
+	// requested with a custom audience for the kubelet.
+	TokenAudience string `hcl:"token_audience"`
+
 	// CertificatePath is the path to a certificate key used for client
 	// authentication with the kubelet. Must be used with PrivateKeyPath.
 	CertificatePath string `hcl:"certificate_path"`
@@ -119,6 +140,56 @@ type HCLConfig struct {
 	// but the container may not be in a ready state at the time of attestation
 	// (e.g. when a postStart hook has yet to complete).
 	DisableContainerSelectors bool `hcl:"disable_container_selectors"`
+
+	// SandboxedRuntimeClasses are the names of the runtime classes (e.g.
+	// "gvisor" or "kata") that run pod containers inside a sandbox. The
+	// processes seen by the agent for those pods belong to the sandbox (i.e.
+	// the runtime shim) and their cgroups do not identify the workload
+	// container, so the workload is attested using the pod identified in the
+	// cgroups instead.
+	SandboxedRuntimeClasses []string `hcl:"sandboxed_runtime_classes"`
+
+	// PodListFile, if set, is the path to a file holding the pods of the
+	// node in the JSON encoding of a PodList, as served by the kubelet. The
+	// file is maintained by a separate informer (e.g. a sidecar watching the
+	// pods of the node through the API server) and is read instead of
+	// contacting the kubelet, for clusters where kubelet API access is
+	// forbidden. This option is mutually exclusive with the kubelet options.
+	PodListFile string `hcl:"pod_list_file"`
+
+	// APIServerFallback controls whether the pods of the node are listed
+	// through the Kubernetes API server, using the in-cluster configuration,
+	// when the kubelet cannot be reached. Only the pods scheduled to the node
+	// are listed, so the node name must be known.
+	APIServerFallback bool `hcl:"api_server_fallback"`
+
+	// PodListRefreshInterval, if set, makes the pods of the node be cached
+	// and shared by attestations, instead of being queried on every attempt.
+	// The cache is refreshed in the background at this interval, and on
+	// demand when an attestation does not find the workload container in it.
+	PodListRefreshInterval string `hcl:"pod_list_refresh_interval"`
+
+	// PodAnnotationKeys are the keys of the pod annotations emitted as
+	// pod-annotation selectors. Annotations are not emitted unless allowed,
+	// since they often hold large or unrelated values.
+	PodAnnotationKeys []string `hcl:"pod_annotation_keys"`
+
+	// NamespaceLabelSelectors controls whether the labels of the workload's
+	// namespace are emitted as ns-label selectors. The namespace is fetched
+	// through the Kubernetes API server, using the in-cluster configuration.
+	NamespaceLabelSelectors bool `hcl:"namespace_label_selectors"`
+
+	// AttestEphemeralContainers controls whether ephemeral containers (e.g.
+	// debug containers added with kubectl debug) are attested. They are not
+	// by default, since they would otherwise be issued the identities of
+	// the pod they are added to.
+	AttestEphemeralContainers bool `hcl:"attest_ephemeral_containers"`
+
+	// DenyEphemeralContainers controls whether the attestation of ephemeral
+	// containers is denied when they are not attested. A denied workload is
+	// not issued any identity, including the ones matching the selectors of
+	// the other workload attestors.
+	DenyEphemeralContainers bool `hcl:"deny_ephemeral_containers"`
 }
 
 // k8sConfig holds the configuration distilled from HCL
@@ -129,6 +200,7 @@ type k8sConfig struct {
 	PollRetryInterval          time.Duration
 	SkipKubeletVerification    bool
 	TokenPath                  string
+	TokenAudience              string
 	CertificatePath            string
 	PrivateKeyPath             string
 	UseAnonymousAuthentication bool
@@ -136,9 +208,22 @@ type k8sConfig struct {
 	NodeName                   string
 	ReloadInterval             time.Duration
 	DisableContainerSelectors  bool
-
-	Client     *kubeletClient
-	LastReload time.Time
+	SandboxedRuntimeClasses    map[string]bool
+	PodListFile                string
+	PodAnnotationKeys          []string
+	AttestEphemeralContainers  bool
+	DenyEphemeralContainers    bool
+
+	Client          *kubeletClient
+	APIServer       apiserver.Client
+	APIServerPods   *apiServerPodLister
+	PodListCache    *podListCache
+	NamespaceLabels *namespaceLabelCache
+	LastReload      time.Time
+
+	// TokenRefreshAt, if set, is when the token should be reloaded ahead of
+	// its expiration, regardless of the reload interval.
+	TokenRefreshAt time.Time
 }
 
 type ContainerHelper interface {
@@ -155,15 +240,21 @@ type Plugin struct {
 	c      ContainerHelper
 	getenv func(string) string
 
+	newAPIServerClient func() apiserver.Client
+
 	mu     sync.RWMutex
 	config *k8sConfig
+
+	// cancelRefresh stops the background refresh of the pod list cache
+	cancelRefresh context.CancelFunc
 }
 
 func New() *Plugin {
 	return &Plugin{
-		fs:     cgroups.OSFileSystem{},
-		clock:  clock.New(),
-		getenv: os.Getenv,
+		fs:                 cgroups.OSFileSystem{},
+		clock:              clock.New(),
+		getenv:             os.Getenv,
+		newAPIServerClient: apiserver.NewInCluster,
 	}
 }
 
@@ -194,19 +285,28 @@ func (p *Plugin) Attest(ctx context.Context, req *workloadattestorv1.AttestReque
 	)
 
 	// Poll pod information and search for the pod with the container. If
-	// the pod is not found then delay for a little bit and try again.
+	// the pod is not found then delay for a little bit and try again. When
+	// the pod list is cached, each attempt searches a newer list.
+	var listGen uint64
 	for attempt := 1; ; attempt++ {
 		log = log.With(telemetry.Attempt, attempt)
 
-		list, err := config.Client.GetPodList()
+		var list *nodePodList
+		if config.PodListCache != nil {
+			list, listGen, err = config.PodListCache.Get(ctx, listGen)
+		} else {
+			list, err = p.getPodList(ctx, config, log)
+		}
 		if err != nil {
 			return nil, err
 		}
 
 		var attestResponse *workloadattestorv1.AttestResponse
+		var podNamespace string
 		for _, item := range list.Items {
 			item := item
-			if podKnown && item.UID != podUID {
+			static := isStaticPod(&item, list.fromKubelet)
+			if podKnown && !podHasUID(&item, podUID, static) {
 				// The pod holding the container is known. Skip unrelated pods.
 				continue
 			}
@@ -214,20 +314,72 @@ func (p *Plugin) Attest(ctx context.Context, req *workloadattestorv1.AttestReque
 			var selectorValues []string
 
 			containerStatus, containerFound := lookUpContainerInPod(containerID, item.Status, log)
+			ephemeralStatus, ephemeralFound := lookUpContainerInStatuses(containerID, item.Status.EphemeralContainerStatuses, log)
+			// When the workload container is not found, the workload may be
+			// an ephemeral container if the pod has any, including ones
+			// whose status is not reported yet.
+			mayBeEphemeral := len(item.Spec.EphemeralContainers) > 0
 			switch {
 			case containerFound:
 				// The workload container was found in this pod. Add pod
 				// selectors. Only add workload container selectors if
 				// container selectors have not been disabled.
-				selectorValues = append(selectorValues, getSelectorValuesFromPodInfo(&item)...)
+				selectorValues = append(selectorValues, getSelectorValuesFromPodInfo(&item, static, config.PodAnnotationKeys)...)
+				selectorValues = append(selectorValues, ephemeralContainerSelectorValue(false))
 				if !config.DisableContainerSelectors {
-					selectorValues = append(selectorValues, getSelectorValuesFromWorkloadContainerStatus(containerStatus)...)
+					selectorValues = append(selectorValues, getSelectorValuesFromWorkloadContainerStatus(&item, containerStatus)...)
 				}
+			case ephemeralFound:
+				// The workload is an ephemeral (i.e. debug) container.
+				// Unless enabled, it is not attested, so it is not issued
+				// the identities of the pod. Denying it also keeps it from
+				// being issued identities through the other attestors.
+				if !config.AttestEphemeralContainers {
+					if config.DenyEphemeralContainers {
+						log.Warn("Workload is an ephemeral container; denying it")
+						return nil, status.Error(codes.PermissionDenied, "workload is an ephemeral container and ephemeral containers are denied")
+					}
+					log.Debug("Workload is an ephemeral container; not attesting it")
+					return &workloadattestorv1.AttestResponse{}, nil
+				}
+				selectorValues = append(selectorValues, getSelectorValuesFromPodInfo(&item, static, config.PodAnnotationKeys)...)
+				selectorValues = append(selectorValues, ephemeralContainerSelectorValue(true))
+				if !config.DisableContainerSelectors {
+					selectorValues = append(selectorValues, getSelectorValuesFromWorkloadContainerStatus(&item, ephemeralStatus)...)
+				}
+			case podKnown && mayBeEphemeral && !config.AttestEphemeralContainers:
+				// The workload container was not found in a pod that has
+				// ephemeral containers, so the workload cannot be told apart
+				// from them. The pod selectors are not used, as if the pod
+				// was unknown.
+				log.Debug("Workload container not found in pod with ephemeral containers; not using pod selectors")
 			case podKnown && config.DisableContainerSelectors:
 				// The workload container was not found (i.e. not ready yet?)
 				// but the pod is known. If container selectors have been
 				// disabled, then allow the pod selectors to be used.
-				selectorValues = append(selectorValues, getSelectorValuesFromPodInfo(&item)...)
+				selectorValues = append(selectorValues, getSelectorValuesFromPodInfo(&item, static, config.PodAnnotationKeys)...)
+				selectorValues = append(selectorValues, ephemeralContainerSelectorValue(mayBeEphemeral))
+			case podKnown && static && len(item.Status.ContainerStatuses) == 0:
+				// The kubelet does not always report container statuses for
+				// static pods, since their status is tracked on the mirror
+				// pod. The pod was identified from the cgroups, so the pod
+				// selectors can be used.
+				log.Debug("Container statuses not reported for static pod; using pod selectors only")
+				selectorValues = append(selectorValues, getSelectorValuesFromPodInfo(&item, static, config.PodAnnotationKeys)...)
+				selectorValues = append(selectorValues, ephemeralContainerSelectorValue(mayBeEphemeral))
+			case podKnown && isSandboxedPod(&item, config.SandboxedRuntimeClasses):
+				// The process belongs to the sandbox of a pod running under a
+				// sandboxed runtime, so the container ID found in the cgroups
+				// is the one of the sandbox. Container selectors can only be
+				// used when the pod has a single container, since the
+				// workload container is otherwise ambiguous.
+				selectorValues = append(selectorValues, getSelectorValuesFromPodInfo(&item, static, config.PodAnnotationKeys)...)
+				selectorValues = append(selectorValues, ephemeralContainerSelectorValue(mayBeEphemeral))
+				if len(item.Status.ContainerStatuses) == 1 && !mayBeEphemeral && !config.DisableContainerSelectors {
+					selectorValues = append(selectorValues, getSelectorValuesFromWorkloadContainerStatus(&item, &item.Status.ContainerStatuses[0])...)
+				} else {
+					log.Debug("Workload container is ambiguous in sandboxed pod; using pod selectors only")
+				}
 			}
 
 			if len(selectorValues) > 0 {
@@ -236,10 +388,19 @@ func (p *Plugin) Attest(ctx context.Context, req *workloadattestorv1.AttestReque
 					return nil, status.Error(codes.Internal, "two pods found with same container Id")
 				}
 				attestResponse = &workloadattestorv1.AttestResponse{SelectorValues: selectorValues}
+				podNamespace = item.Namespace
 			}
 		}
 
 		if attestResponse != nil {
+			if config.NamespaceLabels != nil {
+				labels, err := config.NamespaceLabels.Get(ctx, podNamespace)
+				if err != nil {
+					log.Warn("Unable to get namespace labels", telemetry.Error, err)
+					return nil, status.Errorf(codes.Internal, "unable to get namespace labels: %v", err)
+				}
+				attestResponse.SelectorValues = append(attestResponse.SelectorValues, getSelectorValuesFromNamespaceLabels(labels)...)
+			}
 			return attestResponse, nil
 		}
 
@@ -297,6 +458,19 @@ func (p *Plugin) Configure(ctx context.Context, req *configv1.ConfigureRequest)
 		reloadInterval = defaultReloadInterval
 	}
 
+	// Determine the pod list refresh interval. The pod list is not cached
+	// unless set.
+	var podListRefreshInterval time.Duration
+	if config.PodListRefreshInterval != "" {
+		podListRefreshInterval, err = time.ParseDuration(config.PodListRefreshInterval)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unable to parse pod list refresh interval: %v", err)
+		}
+		if podListRefreshInterval <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "pod list refresh interval must be positive")
+		}
+	}
+
 	// Determine which kubelet port to hit. Default to the secure port if none
 	// is specified (this is backwards compatible because the read-only-port
 	// config value has always been required, so it should already be set in
@@ -304,6 +478,9 @@ func (p *Plugin) Configure(ctx context.Context, req *configv1.ConfigureRequest)
 	if config.KubeletSecurePort > 0 && config.KubeletReadOnlyPort > 0 {
 		return nil, status.Error(codes.InvalidArgument, "cannot use both the read-only and secure port")
 	}
+	if config.PodListFile != "" && (config.KubeletSecurePort > 0 || config.KubeletReadOnlyPort > 0) {
+		return nil, status.Error(codes.InvalidArgument, "cannot use both the pod list file and a kubelet port")
+	}
 
 	containerHelper, err := createHelper(p)
 	if err != nil {
@@ -324,6 +501,37 @@ func (p *Plugin) Configure(ctx context.Context, req *configv1.ConfigureRequest)
 	// Determine the node name
 	nodeName := p.getNodeName(config.NodeName, config.NodeNameEnv)
 
+	var apiServerClient apiserver.Client
+	if config.APIServerFallback {
+		if config.PodListFile != "" {
+			return nil, status.Error(codes.InvalidArgument, "cannot use both the pod list file and the API server fallback")
+		}
+		if nodeName == "" {
+			return nil, status.Error(codes.InvalidArgument, "the node name is required to use the API server fallback")
+		}
+	}
+	if config.APIServerFallback || config.NamespaceLabelSelectors {
+		apiServerClient = p.newAPIServerClient()
+	}
+
+	sandboxedRuntimeClasses := make(map[string]bool, len(config.SandboxedRuntimeClasses))
+	for _, runtimeClass := range config.SandboxedRuntimeClasses {
+		if runtimeClass == "" {
+			return nil, status.Error(codes.InvalidArgument, "sandboxed runtime class name cannot be empty")
+		}
+		sandboxedRuntimeClasses[runtimeClass] = true
+	}
+
+	for _, key := range config.PodAnnotationKeys {
+		if key == "" {
+			return nil, status.Error(codes.InvalidArgument, "pod annotation key cannot be empty")
+		}
+	}
+
+	if config.AttestEphemeralContainers && config.DenyEphemeralContainers {
+		return nil, status.Error(codes.InvalidArgument, "cannot both attest and deny ephemeral containers")
+	}
+
 	// Configure the kubelet client
 	c := &k8sConfig{
 		Secure:                     secure,
@@ -332,6 +540,7 @@ func (p *Plugin) Configure(ctx context.Context, req *configv1.ConfigureRequest)
 		PollRetryInterval:          pollRetryInterval,
 		SkipKubeletVerification:    config.SkipKubeletVerification,
 		TokenPath:                  config.TokenPath,
+		TokenAudience:              config.TokenAudience,
 		CertificatePath:            config.CertificatePath,
 		PrivateKeyPath:             config.PrivateKeyPath,
 		UseAnonymousAuthentication: config.UseAnonymousAuthentication,
@@ -339,17 +548,59 @@ func (p *Plugin) Configure(ctx context.Context, req *configv1.ConfigureRequest)
 		NodeName:                   nodeName,
 		ReloadInterval:             reloadInterval,
 		DisableContainerSelectors:  config.DisableContainerSelectors,
+		SandboxedRuntimeClasses:    sandboxedRuntimeClasses,
+		PodListFile:                config.PodListFile,
+		PodAnnotationKeys:          config.PodAnnotationKeys,
+		AttestEphemeralContainers:  config.AttestEphemeralContainers,
+		DenyEphemeralContainers:    config.DenyEphemeralContainers,
+		APIServer:                  apiServerClient,
+	}
+	if config.APIServerFallback {
+		c.APIServerPods = newAPIServerPodLister(p.clock, apiServerFallbackInterval, apiServerClient, nodeName)
+	}
+	if config.NamespaceLabelSelectors {
+		c.NamespaceLabels = newNamespaceLabelCache(p.clock, namespaceLabelsMaxAge, apiServerClient)
 	}
 	if err := p.reloadKubeletClient(c); err != nil {
 		return nil, err
 	}
+	if podListRefreshInterval > 0 {
+		c.PodListCache = newPodListCache(p.clock, podListRefreshInterval, func(ctx context.Context) (*nodePodList, error) {
+			// Reload the kubelet client if needed, since the cache can be
+			// refreshed while no attestation happens.
+			if _, err := p.getConfig(); err != nil {
+				return nil, err
+			}
+			return p.getPodList(ctx, c, p.log)
+		})
+	}
 
 	// Set the config
 	p.setConfig(c)
 	p.setContainerHelper(containerHelper)
+	p.startPodListRefresh(c.PodListCache)
 	return &configv1.ConfigureResponse{}, nil
 }
 
+// startPodListRefresh stops the background refresh of the previous pod list
+// cache, if any, and starts the one of the given cache, if any.
+func (p *Plugin) startPodListRefresh(cache *podListCache) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancelRefresh != nil {
+		p.cancelRefresh()
+		p.cancelRefresh = nil
+	}
+	if cache == nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, p.cancelRefresh = context.WithCancel(context.Background())
+	go cache.Run(ctx, p.log)
+}
+
 func (p *Plugin) setConfig(config *k8sConfig) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
@@ -376,6 +627,11 @@ func (p *Plugin) setContainerHelper(c ContainerHelper) {
 }
 
 func (p *Plugin) reloadKubeletClient(config *k8sConfig) (err error) {
+	// The kubelet is not contacted when the pods are read from a file.
+	if config.PodListFile != "" {
+		return nil
+	}
+
 	// The insecure client only needs to be loaded once.
 	if !config.Secure {
 		if config.Client == nil {
@@ -390,7 +646,9 @@ func (p *Plugin) reloadKubeletClient(config *k8sConfig) (err error) {
 	}
 
 	// Is the client still fresh?
-	if config.Client != nil && p.clock.Now().Sub(config.LastReload) < config.ReloadInterval {
+	now := p.clock.Now()
+	if config.Client != nil && now.Sub(config.LastReload) < config.ReloadInterval &&
+		(config.TokenRefreshAt.IsZero() || now.Before(config.TokenRefreshAt)) {
 		return nil
 	}
 
@@ -441,6 +699,7 @@ func (p *Plugin) reloadKubeletClient(config *k8sConfig) (err error) {
 	}
 
 	var token string
+	var tokenRefreshAt time.Time
 	switch {
 	case config.UseAnonymousAuthentication:
 	// Don't load credentials if using anonymous authentication
@@ -459,6 +718,10 @@ func (p *Plugin) reloadKubeletClient(config *k8sConfig) (err error) {
 		if err != nil {
 			return err
 		}
+		tokenRefreshAt, err = p.checkToken(token, config.TokenAudience)
+		if err != nil {
+			return err
+		}
 	}
 
 	host := config.NodeName
@@ -476,10 +739,56 @@ func (p *Plugin) reloadKubeletClient(config *k8sConfig) (err error) {
 		},
 		Token: token,
 	}
-	config.LastReload = p.clock.Now()
+	config.LastReload = now
+	config.TokenRefreshAt = tokenRefreshAt
 	return nil
 }
 
+// nodePodList is a list of the pods of the node. Only the kubelet reports
+// static pods themselves, with the annotations it sets; the annotations of
+// pods listed through the API server or from the pod list file can have been
+// set by anyone allowed to create pods.
+type nodePodList struct {
+	*corev1.PodList
+	fromKubelet bool
+}
+
+// getPodList returns the pods of the node, from the kubelet or from the pod
+// list file. The file is read on every attempt, since it is updated as pods
+// are created; the informer maintaining it is expected to replace it
+// atomically (e.g. by renaming a temporary file) so it is never read while
+// partially written. If the kubelet cannot be reached and the API server
+// fallback is enabled, the pods scheduled to the node are listed through the
+// API server instead, at most once per apiServerFallbackInterval.
+func (p *Plugin) getPodList(ctx context.Context, config *k8sConfig, log hclog.Logger) (*nodePodList, error) {
+	if config.PodListFile == "" {
+		list, err := config.Client.GetPodList(ctx)
+		if err == nil {
+			return &nodePodList{PodList: list, fromKubelet: true}, nil
+		}
+		if config.APIServerPods == nil {
+			return nil, err
+		}
+
+		log.Warn("Unable to list pods from the kubelet; falling back to the API server", telemetry.Error, err)
+		list, apiServerErr := config.APIServerPods.List(ctx)
+		if apiServerErr != nil {
+			return nil, status.Errorf(codes.Internal, "unable to list pods from the kubelet (%s) or the API server: %v", status.Convert(err).Message(), apiServerErr)
+		}
+		return &nodePodList{PodList: list}, nil
+	}
+
+	data, err := p.readFile(config.PodListFile)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to read pod list file: %v", err)
+	}
+	out := new(corev1.PodList)
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, status.Errorf(codes.Internal, "unable to decode pod list file: %v", err)
+	}
+	return &nodePodList{PodList: out}, nil
+}
+
 func (p *Plugin) loadKubeletCA(path string) (*x509.CertPool, error) {
 	if path == "" {
 		path = p.defaultKubeletCAPath()
@@ -523,6 +832,42 @@ func (p *Plugin) loadToken(path string) (string, error) {
 	return strings.TrimSpace(string(token)), nil
 }
 
+// checkToken verifies that the token is bound to the given audience, if any,
+// and returns when the token should be reloaded. Projected service account
+// tokens are rotated by the kubelet once 80% of their lifetime has elapsed,
+// so the token is reloaded after 90% of its lifetime, when the rotated token
+// is available and before the current one expires. The zero time is returned
+// when the token does not expire, has passed that point already or is not a
+// JWT, and the reload interval applies.
+func (p *Plugin) checkToken(token, audience string) (time.Time, error) {
+	claims := jwt.Claims{}
+	tok, err := jwt.ParseSigned(token)
+	if err == nil {
+		err = tok.UnsafeClaimsWithoutVerification(&claims)
+	}
+	switch {
+	case err != nil && audience != "":
+		return time.Time{}, status.Errorf(codes.InvalidArgument, "unable to parse token to verify its audience: %v", err)
+	case err != nil:
+		return time.Time{}, nil
+	case audience != "" && !claims.Audience.Contains(audience):
+		return time.Time{}, status.Errorf(codes.InvalidArgument, "token is not bound to audience %q", audience)
+	case claims.Expiry == nil:
+		return time.Time{}, nil
+	}
+
+	now := p.clock.Now()
+	issuedAt := now
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time()
+	}
+	refreshAt := issuedAt.Add(claims.Expiry.Time().Sub(issuedAt) * 9 / 10)
+	if !refreshAt.After(now) {
+		return time.Time{}, nil
+	}
+	return refreshAt, nil
+}
+
 // readFile reads the contents of a file through the filesystem interface
 func (p *Plugin) readFile(path string) ([]byte, error) {
 	f, err := p.fs.Open(path)
@@ -550,10 +895,10 @@ type kubeletClient struct {
 	Token     string
 }
 
-func (c *kubeletClient) GetPodList() (*corev1.PodList, error) {
+func (c *kubeletClient) GetPodList(ctx context.Context) (*corev1.PodList, error) {
 	url := c.URL
 	url.Path = "/pods"
-	req, err := http.NewRequest("GET", url.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", url.String(), nil)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "unable to create request: %v", err)
 	}
@@ -561,7 +906,9 @@ func (c *kubeletClient) GetPodList() (*corev1.PodList, error) {
 		req.Header.Set("Authorization", "Bearer "+c.Token)
 	}
 
-	client := &http.Client{}
+	client := &http.Client{
+		Timeout: kubeletRequestTimeout,
+	}
 	if c.Transport != nil {
 		client.Transport = c.Transport
 	}
@@ -584,7 +931,14 @@ func (c *kubeletClient) GetPodList() (*corev1.PodList, error) {
 }
 
 func lookUpContainerInPod(containerID string, status corev1.PodStatus, log hclog.Logger) (*corev1.ContainerStatus, bool) {
-	for _, status := range status.ContainerStatuses {
+	if status, ok := lookUpContainerInStatuses(containerID, status.ContainerStatuses, log); ok {
+		return status, true
+	}
+	return lookUpContainerInStatuses(containerID, status.InitContainerStatuses, log)
+}
+
+func lookUpContainerInStatuses(containerID string, statuses []corev1.ContainerStatus, log hclog.Logger) (*corev1.ContainerStatus, bool) {
+	for _, status := range statuses {
 		// TODO: should we be keying off of the status or is the lack of a
 		// container id sufficient to know the container is not ready?
 		if status.ContainerID == "" {
@@ -603,28 +957,59 @@ func lookUpContainerInPod(containerID string, status corev1.PodStatus, log hclog
 			return &status, true
 		}
 	}
+	return nil, false
+}
 
-	for _, status := range status.InitContainerStatuses {
-		// TODO: should we be keying off of the status or is the lack of a
-		// container id sufficient to know the container is not ready?
-		if status.ContainerID == "" {
-			continue
-		}
+// ephemeralContainerSelectorValue returns the selector value telling whether
+// the workload is, or may be, an ephemeral container.
+func ephemeralContainerSelectorValue(ephemeral bool) string {
+	return fmt.Sprintf("ephemeral-container:%t", ephemeral)
+}
 
-		containerURL, err := url.Parse(status.ContainerID)
-		if err != nil {
-			log.With(telemetry.Error, err).
-				With(telemetry.ContainerID, status.ContainerID).
-				Error("Malformed container id")
-			continue
-		}
+// podHasUID returns true if the pod has the given UID. Static pods are
+// identified in the cgroups by their config hash, which differs from the UID
+// of the mirror pod.
+func podHasUID(pod *corev1.Pod, uid types.UID, static bool) bool {
+	if pod.UID == uid {
+		return true
+	}
+	return static && pod.Annotations[configHashAnnotation] == string(uid)
+}
 
-		if containerID == containerURL.Host {
-			return &status, true
+// isStaticPod returns true if the pod is a static pod, or the mirror pod of
+// a static pod. The annotations can be set by anyone allowed to create pods
+// through the API server, so they are not trusted on their own: the kubelet
+// sets the config source of the pods it gets from the API server to "api",
+// and the mirror pods it creates are owned by the node and mirror the config
+// hash of the static pod. A pod without a mirror annotation is only the
+// static pod itself when the kubelet reported it.
+func isStaticPod(pod *corev1.Pod, fromKubelet bool) bool {
+	source, ok := pod.Annotations[configSourceAnnotation]
+	if !ok || source == configSourceAPI {
+		return false
+	}
+	mirror, ok := pod.Annotations[configMirrorAnnotation]
+	if !ok {
+		return fromKubelet
+	}
+	return mirror == pod.Annotations[configHashAnnotation] && isOwnedByNode(pod)
+}
+
+// isOwnedByNode returns true if the pod is owned by the node it runs on, as
+// mirror pods are.
+func isOwnedByNode(pod *corev1.Pod) bool {
+	for _, ownerReference := range pod.OwnerReferences {
+		if ownerReference.APIVersion == "v1" && ownerReference.Kind == "Node" && ownerReference.Name == pod.Spec.NodeName {
+			return true
 		}
 	}
+	return false
+}
 
-	return nil, false
+// isSandboxedPod returns true if the pod runs under one of the given
+// sandboxed runtime classes.
+func isSandboxedPod(pod *corev1.Pod, sandboxedRuntimeClasses map[string]bool) bool {
+	return pod.Spec.RuntimeClassName != nil && sandboxedRuntimeClasses[*pod.Spec.RuntimeClassName]
 }
 
 func getPodImageIdentifiers(containerStatuses ...corev1.ContainerStatus) map[string]struct{} {
@@ -645,7 +1030,7 @@ func getPodImageIdentifiers(containerStatuses ...corev1.ContainerStatus) map[str
 	return podImages
 }
 
-func getSelectorValuesFromPodInfo(pod *corev1.Pod) []string {
+func getSelectorValuesFromPodInfo(pod *corev1.Pod, static bool, annotationKeys []string) []string {
 	selectorValues := []string{
 		fmt.Sprintf("sa:%s", pod.Spec.ServiceAccountName),
 		fmt.Sprintf("ns:%s", pod.Namespace),
@@ -666,22 +1051,73 @@ func getSelectorValuesFromPodInfo(pod *corev1.Pod) []string {
 	for k, v := range pod.Labels {
 		selectorValues = append(selectorValues, fmt.Sprintf("pod-label:%s:%s", k, v))
 	}
+	for _, k := range annotationKeys {
+		if v, ok := pod.Annotations[k]; ok {
+			selectorValues = append(selectorValues, fmt.Sprintf("pod-annotation:%s:%s", k, v))
+		}
+	}
 	for _, ownerReference := range pod.OwnerReferences {
 		selectorValues = append(selectorValues, fmt.Sprintf("pod-owner:%s:%s", ownerReference.Kind, ownerReference.Name))
 		selectorValues = append(selectorValues, fmt.Sprintf("pod-owner-uid:%s:%s", ownerReference.Kind, ownerReference.UID))
 	}
+	if static {
+		selectorValues = append(selectorValues, "static-pod:true")
+	}
+	if pod.Spec.RuntimeClassName != nil {
+		selectorValues = append(selectorValues, fmt.Sprintf("runtime:%s", *pod.Spec.RuntimeClassName))
+	}
+	if pod.Status.QOSClass != "" {
+		selectorValues = append(selectorValues, fmt.Sprintf("qos-class:%s", pod.Status.QOSClass))
+	}
+
+	return selectorValues
+}
 
+func getSelectorValuesFromNamespaceLabels(labels map[string]string) []string {
+	var selectorValues []string
+	for k, v := range labels {
+		selectorValues = append(selectorValues, fmt.Sprintf("ns-label:%s:%s", k, v))
+	}
 	return selectorValues
 }
 
-func getSelectorValuesFromWorkloadContainerStatus(status *corev1.ContainerStatus) []string {
+func getSelectorValuesFromWorkloadContainerStatus(pod *corev1.Pod, status *corev1.ContainerStatus) []string {
 	selectorValues := []string{fmt.Sprintf("container-name:%s", status.Name)}
 	for containerImage := range getPodImageIdentifiers(*status) {
 		selectorValues = append(selectorValues, fmt.Sprintf("container-image:%s", containerImage))
 	}
+	if container, ok := lookUpContainerSpec(pod, status.Name); ok {
+		resources := container.Resources
+		_, hasCPURequest := resources.Requests[corev1.ResourceCPU]
+		_, hasMemoryRequest := resources.Requests[corev1.ResourceMemory]
+		_, hasCPULimit := resources.Limits[corev1.ResourceCPU]
+		_, hasMemoryLimit := resources.Limits[corev1.ResourceMemory]
+		selectorValues = append(selectorValues,
+			fmt.Sprintf("container-cpu-request:%t", hasCPURequest),
+			fmt.Sprintf("container-memory-request:%t", hasMemoryRequest),
+			fmt.Sprintf("container-cpu-limit:%t", hasCPULimit),
+			fmt.Sprintf("container-memory-limit:%t", hasMemoryLimit),
+		)
+	}
 	return selectorValues
 }
 
+// lookUpContainerSpec returns the spec of the container or init container
+// with the given name.
+func lookUpContainerSpec(pod *corev1.Pod, name string) (*corev1.Container, bool) {
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &pod.Spec.Containers[i], true
+		}
+	}
+	for i := range pod.Spec.InitContainers {
+		if pod.Spec.InitContainers[i].Name == name {
+			return &pod.Spec.InitContainers[i], true
+		}
+	}
+	return nil, false
+}
+
 func tryRead(r io.Reader) string {
 	buf := make([]byte, 1024)
 	n, _ := r.Read(buf)
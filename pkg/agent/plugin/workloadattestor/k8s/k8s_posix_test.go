@@ -23,6 +23,9 @@ const (
 	kindPodListFilePath                     = "testdata/kind_pod_list.json"
 	crioPodListFilePath                     = "testdata/crio_pod_list.json"
 	crioPodListDuplicateContainerIDFilePath = "testdata/crio_pod_list_duplicate_containerId.json"
+	staticPodListFilePath                   = "testdata/static_pod_list.json"
+	forgedStaticPodListFilePath             = "testdata/forged_static_pod_list.json"
+	sandboxedPodListFilePath                = "testdata/sandboxed_pod_list.json"
 
 	cgPidInPodFilePath        = "testdata/cgroups_pid_in_pod.txt"
 	cgPidInKindPodFilePath    = "testdata/cgroups_pid_in_kind_pod.txt"
@@ -30,15 +33,22 @@ const (
 	cgInitPidInPodFilePath    = "testdata/cgroups_init_pid_in_pod.txt"
 	cgPidNotInPodFilePath     = "testdata/cgroups_pid_not_in_pod.txt"
 	cgSystemdPidInPodFilePath = "testdata/systemd_cgroups_pid_in_pod.txt"
+	cgPidInStaticPodFilePath  = "testdata/cgroups_pid_in_static_pod.txt"
+	cgPidInSandboxedPodPath   = "testdata/cgroups_pid_in_sandboxed_pod.txt"
 )
 
 var (
 	pidCgroupPath = fmt.Sprintf("/proc/%v/cgroup", pid)
 
 	testKindPodSelectors = []*common.Selector{
+		{Type: "k8s", Value: "container-cpu-limit:false"},
+		{Type: "k8s", Value: "container-cpu-request:false"},
 		{Type: "k8s", Value: "container-image:gcr.io/spiffe-io/spire-agent:0.8.1"},
 		{Type: "k8s", Value: "container-image:gcr.io/spiffe-io/spire-agent@sha256:1e4c481d76e9ecbd3d8684891e0e46aa021a30920ca04936e1fdcc552747d941"},
+		{Type: "k8s", Value: "container-memory-limit:false"},
+		{Type: "k8s", Value: "container-memory-request:false"},
 		{Type: "k8s", Value: "container-name:workload-api-client"},
+		{Type: "k8s", Value: "ephemeral-container:false"},
 		{Type: "k8s", Value: "node-name:kind-control-plane"},
 		{Type: "k8s", Value: "ns:default"},
 		{Type: "k8s", Value: "pod-image-count:1"},
@@ -51,13 +61,19 @@ var (
 		{Type: "k8s", Value: "pod-owner-uid:ReplicaSet:349d135e-3781-43e3-bc25-c900aedf1d0c"},
 		{Type: "k8s", Value: "pod-owner:ReplicaSet:sample-workload-6658cb9566"},
 		{Type: "k8s", Value: "pod-uid:a2830d0d-b0f0-4ff0-81b5-0ee4e299cf80"},
+		{Type: "k8s", Value: "qos-class:BestEffort"},
 		{Type: "k8s", Value: "sa:default"},
 	}
 
 	testCrioPodSelectors = []*common.Selector{
+		{Type: "k8s", Value: "container-cpu-limit:false"},
+		{Type: "k8s", Value: "container-cpu-request:false"},
 		{Type: "k8s", Value: "container-image:gcr.io/spiffe-io/spire-agent:0.8.1"},
 		{Type: "k8s", Value: "container-image:gcr.io/spiffe-io/spire-agent@sha256:1e4c481d76e9ecbd3d8684891e0e46aa021a30920ca04936e1fdcc552747d941"},
+		{Type: "k8s", Value: "container-memory-limit:false"},
+		{Type: "k8s", Value: "container-memory-request:false"},
 		{Type: "k8s", Value: "container-name:workload-api-client"},
+		{Type: "k8s", Value: "ephemeral-container:false"},
 		{Type: "k8s", Value: "node-name:a37b7d23-d32a-4932-8f33-40950ac16ee9"},
 		{Type: "k8s", Value: "ns:sfh-199"},
 		{Type: "k8s", Value: "pod-image-count:1"},
@@ -70,13 +86,59 @@ var (
 		{Type: "k8s", Value: "pod-owner-uid:ReplicaSet:349d135e-3781-43e3-bc25-c900aedf1d0c"},
 		{Type: "k8s", Value: "pod-owner:ReplicaSet:sample-workload-6658cb9566"},
 		{Type: "k8s", Value: "pod-uid:a2830d0d-b0f0-4ff0-81b5-0ee4e299cf80"},
+		{Type: "k8s", Value: "qos-class:BestEffort"},
+		{Type: "k8s", Value: "sa:default"},
+	}
+
+	testStaticPodSelectors = []*common.Selector{
+		{Type: "k8s", Value: "node-name:k8s-control-plane"},
+		{Type: "k8s", Value: "ns:kube-system"},
+		{Type: "k8s", Value: "pod-image-count:0"},
+		{Type: "k8s", Value: "pod-init-image-count:0"},
+		{Type: "k8s", Value: "pod-label:component:kube-apiserver"},
+		{Type: "k8s", Value: "pod-label:tier:control-plane"},
+		{Type: "k8s", Value: "pod-name:kube-apiserver-k8s-control-plane"},
+		{Type: "k8s", Value: "pod-owner-uid:Node:d2d2a8c1-9b6a-4c07-8a0b-3f5e3a2b2c1d"},
+		{Type: "k8s", Value: "pod-owner:Node:k8s-control-plane"},
+		{Type: "k8s", Value: "pod-uid:6a4e4c1f-0ad2-4e2b-a1f4-6e2a4b6a9d5c"},
+		{Type: "k8s", Value: "ephemeral-container:false"},
+		{Type: "k8s", Value: "sa:"},
+		{Type: "k8s", Value: "static-pod:true"},
+	}
+
+	testSandboxedPodSelectors = []*common.Selector{
+		{Type: "k8s", Value: "container-cpu-limit:false"},
+		{Type: "k8s", Value: "container-cpu-request:false"},
+		{Type: "k8s", Value: "container-image:ghcr.io/example/workload:1.0"},
+		{Type: "k8s", Value: "container-image:ghcr.io/example/workload@sha256:5c0a9e3f1b7d2e4a6c8f0b2d4e6a8c0f1b3d5e7a9c2e4f6a8b0d2f4a6c8e0b2d"},
+		{Type: "k8s", Value: "container-memory-limit:false"},
+		{Type: "k8s", Value: "container-memory-request:false"},
+		{Type: "k8s", Value: "container-name:workload"},
+		{Type: "k8s", Value: "ephemeral-container:false"},
+		{Type: "k8s", Value: "node-name:k8s-node-1"},
+		{Type: "k8s", Value: "ns:default"},
+		{Type: "k8s", Value: "pod-image-count:1"},
+		{Type: "k8s", Value: "pod-image:ghcr.io/example/workload:1.0"},
+		{Type: "k8s", Value: "pod-image:ghcr.io/example/workload@sha256:5c0a9e3f1b7d2e4a6c8f0b2d4e6a8c0f1b3d5e7a9c2e4f6a8b0d2f4a6c8e0b2d"},
+		{Type: "k8s", Value: "pod-init-image-count:0"},
+		{Type: "k8s", Value: "pod-label:app:sandboxed-workload"},
+		{Type: "k8s", Value: "pod-name:sandboxed-workload-5d8f7c9b6-x2x9k"},
+		{Type: "k8s", Value: "pod-owner-uid:ReplicaSet:4a6c8e0f-2b4d-4f6a-8c0e-1b3d5f7a9c2e"},
+		{Type: "k8s", Value: "pod-owner:ReplicaSet:sandboxed-workload-5d8f7c9b6"},
+		{Type: "k8s", Value: "pod-uid:1b5a2c7e-3f4d-4e8a-9c6b-2d7f8e9a0b1c"},
+		{Type: "k8s", Value: "runtime:kata"},
 		{Type: "k8s", Value: "sa:default"},
 	}
 
 	testInitPodSelectors = []*common.Selector{
+		{Type: "k8s", Value: "container-cpu-limit:false"},
+		{Type: "k8s", Value: "container-cpu-request:false"},
 		{Type: "k8s", Value: "container-image:docker-pullable://quay.io/coreos/flannel@sha256:1b401bf0c30bada9a539389c3be652b58fe38463361edf488e6543c8761d4970"},
 		{Type: "k8s", Value: "container-image:quay.io/coreos/flannel:v0.9.0-amd64"},
+		{Type: "k8s", Value: "container-memory-limit:false"},
+		{Type: "k8s", Value: "container-memory-request:false"},
 		{Type: "k8s", Value: "container-name:install-cni"},
+		{Type: "k8s", Value: "ephemeral-container:false"},
 		{Type: "k8s", Value: "node-name:k8s-node-1"},
 		{Type: "k8s", Value: "ns:kube-system"},
 		{Type: "k8s", Value: "pod-image-count:1"},
@@ -93,6 +155,7 @@ var (
 		{Type: "k8s", Value: "pod-owner-uid:DaemonSet:2f0350fc-b29d-11e7-9350-020968147796"},
 		{Type: "k8s", Value: "pod-owner:DaemonSet:kube-flannel-ds"},
 		{Type: "k8s", Value: "pod-uid:d488cae9-b2a0-11e7-9350-020968147796"},
+		{Type: "k8s", Value: "qos-class:BestEffort"},
 		{Type: "k8s", Value: "sa:flannel"},
 	}
 )
@@ -142,6 +205,56 @@ func (s *Suite) TestAttestWithPidInPodSystemdCgroups() {
 	s.requireAttestSuccessWithPodSystemdCgroups(p)
 }
 
+func (s *Suite) TestAttestWithPidInStaticPod() {
+	s.startInsecureKubelet()
+	p := s.loadInsecurePlugin()
+
+	// The static pod is identified in the cgroups by its config hash and the
+	// kubelet does not report its container statuses.
+	s.addPodListResponse(staticPodListFilePath)
+	s.addCgroupsResponse(cgPidInStaticPodFilePath)
+	s.requireAttestSuccess(p, testStaticPodSelectors)
+}
+
+func (s *Suite) TestAttestWithPidInStaticPodFromAPIServerFallback() {
+	// The mirror pod of the static pod is listed through the API server
+	s.apiServer = &fakeAPIServerClient{podListPath: staticPodListFilePath}
+	p := s.loadUnreachableKubeletPlugin("api_server_fallback = true")
+	s.addCgroupsResponse(cgPidInStaticPodFilePath)
+	s.requireAttestSuccess(p, testStaticPodSelectors)
+}
+
+func (s *Suite) TestAttestWithPidInForgedStaticPodFromPodListFile() {
+	// Anyone allowed to create pods can set the static pod annotations, so
+	// a pod without a mirror annotation is not identified by its config
+	// hash unless the kubelet reported it.
+	s.writePodListFile(forgedStaticPodListFilePath)
+	p := s.loadPlugin(`
+		pod_list_file = "pods.json"
+		max_poll_attempts = 1
+`)
+	s.addCgroupsResponse(cgPidInStaticPodFilePath)
+	s.requireAttestFailure(p, codes.DeadlineExceeded, "no selectors found after max poll attempts")
+}
+
+func (s *Suite) TestAttestWithPidInForgedStaticPodFromAPIServerFallback() {
+	s.apiServer = &fakeAPIServerClient{podListPath: forgedStaticPodListFilePath}
+	p := s.loadUnreachableKubeletPlugin("api_server_fallback = true")
+	s.addCgroupsResponse(cgPidInStaticPodFilePath)
+	s.requireAttestFailure(p, codes.DeadlineExceeded, "no selectors found after max poll attempts")
+}
+
+func (s *Suite) TestAttestWithPidInSandboxedPod() {
+	s.startInsecureKubelet()
+	p := s.loadInsecurePluginWithExtra(`sandboxed_runtime_classes = ["kata"]`)
+
+	// The process belongs to the Kata sandbox, so the container ID in the
+	// cgroups does not match any of the pod containers.
+	s.addPodListResponse(sandboxedPodListFilePath)
+	s.addCgroupsResponse(cgPidInSandboxedPodPath)
+	s.requireAttestSuccess(p, testSandboxedPodSelectors)
+}
+
 func (s *Suite) TestAttestAgainstNodeOverride() {
 	s.startInsecureKubelet()
 	p := s.loadInsecurePlugin()
@@ -159,7 +272,9 @@ func (s *Suite) TestAttestWhenContainerNotReadyButContainerSelectorsDisabled() {
 	p := s.loadInsecurePluginWithExtra("disable_container_selectors = true")
 	s.addPodListResponse(podListNotRunningFilePath)
 	s.addGetContainerResponsePidInPod()
-	s.requireAttestSuccess(p, testPodSelectors)
+	s.requireAttestSuccess(p, append([]*common.Selector{
+		{Type: "k8s", Value: "ephemeral-container:false"},
+	}, testPodSelectors...))
 }
 
 func (s *Suite) addGetContainerResponsePidInPod() {
@@ -363,6 +478,18 @@ func TestGetPodUIDAndContainerIDFromCGroupPath(t *testing.T) {
 			expectPodUID:      "a2830d0d-b0f0-4ff0-81b5-0ee4e299cf80",
 			expectContainerID: "09bc3d7ade839efec32b6bec4ec79d099027a668ddba043083ec21d3c3b8f1e6",
 		},
+		{
+			name:              "kata sandbox",
+			cgroupPath:        "/kubepods/besteffort/pod1b5a2c7e-3f4d-4e8a-9c6b-2d7f8e9a0b1c/kata_7e3c1f9a2b4d6e8f0a1c3e5b7d9f2a4c6e8b0d1f3a5c7e9b2d4f6a8c0e1b3d5f",
+			expectPodUID:      "1b5a2c7e-3f4d-4e8a-9c6b-2d7f8e9a0b1c",
+			expectContainerID: "7e3c1f9a2b4d6e8f0a1c3e5b7d9f2a4c6e8b0d1f3a5c7e9b2d4f6a8c0e1b3d5f",
+		},
+		{
+			name:              "docker in docker",
+			cgroupPath:        "/kubepods/besteffort/pod1b5a2c7e-3f4d-4e8a-9c6b-2d7f8e9a0b1c/c2d4f6a8b0e1c3d5f7a9b1c3e5d7f9a0b2c4d6e8f0a1b3c5d7e9f1a3b5c7d9e0/docker/09bc3d7ade839efec32b6bec4ec79d099027a668ddba043083ec21d3c3b8f1e6",
+			expectPodUID:      "1b5a2c7e-3f4d-4e8a-9c6b-2d7f8e9a0b1c",
+			expectContainerID: "09bc3d7ade839efec32b6bec4ec79d099027a668ddba043083ec21d3c3b8f1e6",
+		},
 		{
 			name:       "just container segment",
 			cgroupPath: "/09bc3d7ade839efec32b6bec4ec79d099027a668ddba043083ec21d3c3b8f1e6",
@@ -7,6 +7,8 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/big"
@@ -20,6 +22,7 @@ import (
 
 	"github.com/spiffe/spire/pkg/agent/plugin/workloadattestor"
 	"github.com/spiffe/spire/pkg/common/pemutil"
+	"github.com/spiffe/spire/pkg/common/plugin/k8s/apiserver"
 	"github.com/spiffe/spire/pkg/common/util"
 	"github.com/spiffe/spire/proto/spire/common"
 	"github.com/spiffe/spire/test/clock"
@@ -28,6 +31,10 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -35,6 +42,7 @@ const (
 
 	podListFilePath           = "testdata/pod_list.json"
 	podListNotRunningFilePath = "testdata/pod_list_not_running.json"
+	ephemeralPodListFilePath  = "testdata/ephemeral_pod_list.json"
 
 	certPath = "cert.pem"
 	keyPath  = "key.pem"
@@ -70,12 +78,18 @@ FwOGLt+I3+9beT0vo+pn9Rq0squewFYe3aJbwpkyfP2xOovQCdm4PC8y
 		{Type: "k8s", Value: "pod-owner-uid:ReplicationController:2c401175-b29f-11e7-9350-020968147796"},
 		{Type: "k8s", Value: "pod-owner:ReplicationController:blog"},
 		{Type: "k8s", Value: "pod-uid:2c48913c-b29f-11e7-9350-020968147796"},
+		{Type: "k8s", Value: "qos-class:Burstable"},
 		{Type: "k8s", Value: "sa:default"},
 	}
 	testContainerSelectors = []*common.Selector{
+		{Type: "k8s", Value: "container-cpu-limit:true"},
+		{Type: "k8s", Value: "container-cpu-request:true"},
 		{Type: "k8s", Value: "container-image:docker-pullable://localhost/spiffe/blog@sha256:0cfdaced91cb46dd7af48309799a3c351e4ca2d5e1ee9737ca0cbd932cb79898"},
 		{Type: "k8s", Value: "container-image:localhost/spiffe/blog:latest"},
+		{Type: "k8s", Value: "container-memory-limit:true"},
+		{Type: "k8s", Value: "container-memory-request:true"},
 		{Type: "k8s", Value: "container-name:blog"},
+		{Type: "k8s", Value: "ephemeral-container:false"},
 	}
 	testPodAndContainerSelectors = append(testPodSelectors, testContainerSelectors...)
 )
@@ -95,8 +109,9 @@ type Suite struct {
 	dir   string
 	clock *clock.Mock
 
-	podList [][]byte
-	env     map[string]string
+	podList   [][]byte
+	apiServer *fakeAPIServerClient
+	env       map[string]string
 
 	// kubelet stuff
 	server      *httptest.Server
@@ -114,6 +129,7 @@ func (s *Suite) SetupTest() {
 	s.server = nil
 
 	s.podList = nil
+	s.apiServer = nil
 	s.env = map[string]string{}
 	s.oc = createOSConfig()
 }
@@ -155,6 +171,124 @@ func (s *Suite) TestAttestWithPidInPodAfterRetry() {
 	}
 }
 
+func (s *Suite) TestAttestWithPidInEphemeralContainer() {
+	s.startInsecureKubelet()
+	p := s.loadInsecurePlugin()
+	s.addPodListResponse(ephemeralPodListFilePath)
+	s.addGetContainerResponsePidInPod()
+
+	// Ephemeral containers are not attested by default, so they are not
+	// issued the identities of the pod
+	selectors, err := p.Attest(context.Background(), pid)
+	s.Require().NoError(err)
+	s.Require().Empty(selectors)
+}
+
+func (s *Suite) TestAttestWithPidInEphemeralContainerWhenDenied() {
+	s.startInsecureKubelet()
+	p := s.loadInsecurePluginWithExtra("deny_ephemeral_containers = true")
+	s.addPodListResponse(ephemeralPodListFilePath)
+	s.addGetContainerResponsePidInPod()
+
+	s.requireAttestFailure(p, codes.PermissionDenied, "workload is an ephemeral container and ephemeral containers are denied")
+}
+
+func (s *Suite) TestAttestWithPidInEphemeralContainerWhenEnabled() {
+	s.startInsecureKubelet()
+	p := s.loadInsecurePluginWithExtra("attest_ephemeral_containers = true")
+	s.addPodListResponse(ephemeralPodListFilePath)
+	s.addGetContainerResponsePidInPod()
+
+	// The debug container is attested as itself, and marked as ephemeral
+	s.requireAttestSuccess(p, append([]*common.Selector{
+		{Type: "k8s", Value: "container-image:busybox:1.36"},
+		{Type: "k8s", Value: "container-image:docker-pullable://busybox@sha256:7b3ccabffc97de872a30dfd234fd972a66d247c8cfc69b0550f276481852627c"},
+		{Type: "k8s", Value: "container-name:debugger"},
+		{Type: "k8s", Value: "ephemeral-container:true"},
+	}, testPodSelectors...))
+}
+
+func (s *Suite) TestAttestWithPodAnnotationKeys() {
+	s.startInsecureKubelet()
+	p := s.loadInsecurePluginWithExtra(`pod_annotation_keys = ["kubernetes.io/config.source", "example.org/missing"]`)
+	s.addPodListResponse(podListFilePath)
+	s.addGetContainerResponsePidInPod()
+
+	// Only the allowed annotations that are set are emitted
+	s.requireAttestSuccess(p, append([]*common.Selector{
+		{Type: "k8s", Value: "pod-annotation:kubernetes.io/config.source:api"},
+	}, testPodAndContainerSelectors...))
+}
+
+func (s *Suite) TestAttestWithNamespaceLabelSelectors() {
+	s.apiServer = &fakeAPIServerClient{
+		namespaceLabels: map[string]string{"env": "prod"},
+	}
+	s.startInsecureKubelet()
+	p := s.loadInsecurePluginWithExtra("namespace_label_selectors = true")
+	s.addGetContainerResponsePidInPod()
+
+	expected := append([]*common.Selector{
+		{Type: "k8s", Value: "ns-label:env:prod"},
+	}, testPodAndContainerSelectors...)
+
+	// The namespace labels are cached, so the namespace is fetched once
+	s.addPodListResponse(podListFilePath)
+	s.requireAttestSuccess(p, expected)
+	s.addPodListResponse(podListFilePath)
+	s.requireAttestSuccess(p, expected)
+	s.Equal([]string{"default"}, s.apiServer.namespaces)
+
+	// The namespace is fetched again once the labels are stale
+	s.clock.Add(namespaceLabelsMaxAge)
+	s.addPodListResponse(podListFilePath)
+	s.requireAttestSuccess(p, expected)
+	s.Equal([]string{"default", "default"}, s.apiServer.namespaces)
+}
+
+func (s *Suite) TestAttestWithNamespaceLabelSelectorsFailure() {
+	s.apiServer = &fakeAPIServerClient{err: errors.New("forbidden")}
+	s.startInsecureKubelet()
+	p := s.loadInsecurePluginWithExtra("namespace_label_selectors = true")
+	s.addPodListResponse(podListFilePath)
+	s.addGetContainerResponsePidInPod()
+	s.requireAttestFailure(p, codes.Internal, "unable to get namespace labels: forbidden")
+}
+
+func (s *Suite) TestAttestWithPodListCache() {
+	s.startInsecureKubelet()
+	p := s.loadInsecurePluginWithExtra(`pod_list_refresh_interval = "1m"`)
+	s.addGetContainerResponsePidInPod()
+
+	// The pod list is fetched once and shared by the attestations. The
+	// kubelet would fail a second query.
+	s.addPodListResponse(podListFilePath)
+	s.requireAttestSuccess(p, testPodAndContainerSelectors)
+	s.requireAttestSuccess(p, testPodAndContainerSelectors)
+}
+
+func (s *Suite) TestAttestWithPodListCacheAfterRetry() {
+	s.startInsecureKubelet()
+	p := s.loadInsecurePluginWithExtra(`pod_list_refresh_interval = "1m"`)
+	s.addPodListResponse(podListNotRunningFilePath)
+	s.addPodListResponse(podListFilePath)
+	s.addGetContainerResponsePidInPod()
+
+	resultCh := s.goAttest(p)
+
+	// The cached list is still fresh, but the retry fetches a newer one
+	s.clock.WaitForAfter(time.Minute, "waiting for retry timer")
+	s.clock.Add(time.Second)
+
+	select {
+	case result := <-resultCh:
+		s.Require().Nil(result.err)
+		s.requireSelectorsEqual(testPodAndContainerSelectors, result.selectors)
+	case <-time.After(time.Minute):
+		s.FailNow("timed out waiting for attest response")
+	}
+}
+
 func (s *Suite) TestAttestWithPidNotInPodCancelsEarly() {
 	s.startInsecureKubelet()
 	p := s.loadInsecurePlugin()
@@ -214,6 +348,31 @@ func (s *Suite) TestAttestOverSecurePortViaTokenAuth() {
 	s.requireAttestFailure(p, codes.Internal, `expected "Bearer default-token", got "Bearer bad-token"`)
 }
 
+func (s *Suite) TestAttestOverSecurePortViaProjectedToken() {
+	// the token expires in 10 minutes, so it is reloaded after 9 minutes,
+	// well before the reload interval
+	token := s.createToken("kubelet", s.clock.Now().Add(10*time.Minute))
+	s.writeFile("projected-token", token)
+	s.startSecureKubeletWithTokenAuth(true, token)
+
+	p := s.loadSecurePlugin(`
+		token_path = "projected-token"
+		token_audience = "kubelet"
+		reload_interval = "1h"
+	`)
+
+	s.requireAttestSuccessWithPod(p)
+
+	// write out a rotated token and make sure it is only picked up once the
+	// current one is close to its expiration
+	s.writeFile("projected-token", s.createToken("kubelet", s.clock.Now().Add(20*time.Minute)))
+	s.clock.Add(8 * time.Minute)
+	s.requireAttestSuccessWithPod(p)
+
+	s.clock.Add(time.Minute)
+	s.requireAttestFailure(p, codes.Internal, "expected \"Bearer "+token+"\"")
+}
+
 func (s *Suite) TestAttestOverSecurePortViaClientAuth() {
 	// start up the secure kubelet with host networking and require client certs
 	s.startSecureKubeletWithClientCertAuth()
@@ -270,7 +429,74 @@ func (s *Suite) TestAttestWhenContainerReadyButContainerSelectorsDisabled() {
 	p := s.loadInsecurePluginWithExtra("disable_container_selectors = true")
 	s.addPodListResponse(podListFilePath)
 	s.addGetContainerResponsePidInPod()
-	s.requireAttestSuccess(p, testPodSelectors)
+	s.requireAttestSuccess(p, append([]*common.Selector{
+		{Type: "k8s", Value: "ephemeral-container:false"},
+	}, testPodSelectors...))
+}
+
+func (s *Suite) TestAttestWithPodListFile() {
+	s.writePodListFile(podListFilePath)
+	p := s.loadPodListFilePlugin()
+	s.addGetContainerResponsePidInPod()
+	s.requireAttestSuccess(p, testPodAndContainerSelectors)
+}
+
+func (s *Suite) TestAttestWithPodListFileAfterRetry() {
+	s.writePodListFile(podListNotRunningFilePath)
+	p := s.loadPodListFilePlugin()
+	s.addGetContainerResponsePidInPod()
+
+	resultCh := s.goAttest(p)
+
+	// The file is read again on the next attempt, once the informer has
+	// noticed the pod.
+	s.clock.WaitForAfter(time.Minute, "waiting for retry timer")
+	s.writePodListFile(podListFilePath)
+	s.clock.Add(time.Second)
+
+	select {
+	case result := <-resultCh:
+		s.Require().Nil(result.err)
+		s.requireSelectorsEqual(testPodAndContainerSelectors, result.selectors)
+	case <-time.After(time.Minute):
+		s.FailNow("timed out waiting for attest response")
+	}
+}
+
+func (s *Suite) TestAttestWithMissingPodListFile() {
+	p := s.loadPodListFilePlugin()
+	s.addGetContainerResponsePidInPod()
+	s.requireAttestFailure(p, codes.Internal, "unable to read pod list file")
+}
+
+func (s *Suite) TestAttestWithMalformedPodListFile() {
+	s.writeFile("pods.json", "not json")
+	p := s.loadPodListFilePlugin()
+	s.addGetContainerResponsePidInPod()
+	s.requireAttestFailure(p, codes.Internal, "unable to decode pod list file")
+}
+
+func (s *Suite) TestAttestWithAPIServerFallback() {
+	s.apiServer = &fakeAPIServerClient{podListPath: podListFilePath}
+	p := s.loadUnreachableKubeletPlugin("api_server_fallback = true")
+	s.addGetContainerResponsePidInPod()
+	s.requireAttestSuccess(p, testPodAndContainerSelectors)
+	s.Equal([]string{"k8s-node-1"}, s.apiServer.nodeNames)
+}
+
+func (s *Suite) TestAttestWithAPIServerFallbackFailure() {
+	s.apiServer = &fakeAPIServerClient{err: errors.New("forbidden")}
+	p := s.loadUnreachableKubeletPlugin("api_server_fallback = true")
+	s.addGetContainerResponsePidInPod()
+	s.requireAttestFailure(p, codes.Internal, "or the API server: forbidden")
+}
+
+func (s *Suite) TestAttestWithoutAPIServerFallback() {
+	s.apiServer = &fakeAPIServerClient{podListPath: podListFilePath}
+	p := s.loadUnreachableKubeletPlugin("")
+	s.addGetContainerResponsePidInPod()
+	s.requireAttestFailure(p, codes.Internal, "unable to perform request")
+	s.Empty(s.apiServer.nodeNames)
 }
 
 func (s *Suite) TestConfigure() {
@@ -281,6 +507,8 @@ func (s *Suite) TestConfigure() {
 
 	s.writeFile(defaultTokenPath, "default-token")
 	s.writeFile("token", "other-token")
+	projectedToken := s.createToken("kubelet", s.clock.Now().Add(time.Hour))
+	s.writeFile("projected-token", projectedToken)
 	s.writeFile("bad-pem", "BAD PEM")
 	s.writeCert("some-other-ca", s.kubeletCert)
 
@@ -361,6 +589,39 @@ func (s *Suite) TestConfigure() {
 				ReloadInterval:    3 * time.Second,
 			},
 		},
+		{
+			name: "token audience",
+			hcl: `
+				token_path = "projected-token"
+				token_audience = "kubelet"
+			`,
+			config: &config{
+				VerifyKubelet:     true,
+				Token:             projectedToken,
+				KubeletURL:        "https://127.0.0.1:10250",
+				MaxPollAttempts:   defaultMaxPollAttempts,
+				PollRetryInterval: defaultPollRetryInterval,
+				ReloadInterval:    defaultReloadInterval,
+			},
+		},
+		{
+			name: "token not bound to the audience",
+			hcl: `
+				token_path = "projected-token"
+				token_audience = "other"
+			`,
+			errCode: codes.InvalidArgument,
+			errMsg:  `token is not bound to audience "other"`,
+		},
+		{
+			name: "token audience with a token that is not a JWT",
+			hcl: `
+				token_path = "token"
+				token_audience = "kubelet"
+			`,
+			errCode: codes.InvalidArgument,
+			errMsg:  "unable to parse token to verify its audience",
+		},
 		{
 			name: "secure with keypair",
 			hcl: `
@@ -449,6 +710,15 @@ func (s *Suite) TestConfigure() {
 			errCode: codes.InvalidArgument,
 			errMsg:  "unable to parse reload interval",
 		},
+		{
+			name: "empty sandboxed runtime class",
+			hcl: `
+				kubelet_read_only_port = 10255
+				sandboxed_runtime_classes = [""]
+			`,
+			errCode: codes.InvalidArgument,
+			errMsg:  "sandboxed runtime class name cannot be empty",
+		},
 		{
 			name: "cert but no key",
 			hcl: `
@@ -487,6 +757,62 @@ func (s *Suite) TestConfigure() {
 			errCode: codes.InvalidArgument,
 			errMsg:  "unable to load certificate",
 		},
+		{
+			name: "pod list file with a kubelet port",
+			hcl: `
+				pod_list_file = "pods.json"
+				kubelet_read_only_port = 12345
+			`,
+			errCode: codes.InvalidArgument,
+			errMsg:  "cannot use both the pod list file and a kubelet port",
+		},
+		{
+			name: "API server fallback with the pod list file",
+			hcl: `
+				pod_list_file = "pods.json"
+				api_server_fallback = true
+				node_name = "k8s-node-1"
+			`,
+			errCode: codes.InvalidArgument,
+			errMsg:  "cannot use both the pod list file and the API server fallback",
+		},
+		{
+			name: "API server fallback without node name",
+			hcl: `
+				kubelet_read_only_port = 12345
+				api_server_fallback = true
+			`,
+			errCode: codes.InvalidArgument,
+			errMsg:  "the node name is required to use the API server fallback",
+		},
+		{
+			name: "both attest and deny ephemeral containers",
+			hcl: `
+				kubelet_read_only_port = 12345
+				attest_ephemeral_containers = true
+				deny_ephemeral_containers = true
+			`,
+			errCode: codes.InvalidArgument,
+			errMsg:  "cannot both attest and deny ephemeral containers",
+		},
+		{
+			name: "invalid pod list refresh interval",
+			hcl: `
+				kubelet_read_only_port = 12345
+				pod_list_refresh_interval = "-1s"
+			`,
+			errCode: codes.InvalidArgument,
+			errMsg:  "pod list refresh interval must be positive",
+		},
+		{
+			name: "empty pod annotation key",
+			hcl: `
+				kubelet_read_only_port = 12345
+				pod_annotation_keys = [""]
+			`,
+			errCode: codes.InvalidArgument,
+			errMsg:  "pod annotation key cannot be empty",
+		},
 		{
 			name: "bad key",
 			hcl: `
@@ -563,6 +889,9 @@ func (s *Suite) newPlugin() *Plugin {
 	p.getenv = func(key string) string {
 		return s.env[key]
 	}
+	p.newAPIServerClient = func() apiserver.Client {
+		return s.apiServer
+	}
 	return p
 }
 
@@ -573,6 +902,18 @@ func (s *Suite) setServer(server *httptest.Server) {
 	s.server = server
 }
 
+func (s *Suite) createToken(audience string, expiresAt time.Time) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: clientKey}, nil)
+	s.Require().NoError(err)
+	token, err := jwt.Signed(signer).Claims(jwt.Claims{
+		Audience: jwt.Audience{audience},
+		IssuedAt: jwt.NewNumericDate(s.clock.Now()),
+		Expiry:   jwt.NewNumericDate(expiresAt),
+	}).CompactSerialize()
+	s.Require().NoError(err)
+	return token
+}
+
 func (s *Suite) writeFile(path, data string) {
 	realPath := filepath.Join(s.dir, path)
 	s.Require().NoError(os.MkdirAll(filepath.Dir(realPath), 0755))
@@ -627,6 +968,35 @@ func (s *Suite) loadInsecurePluginWithExtra(extraConfig string) workloadattestor
 `, s.kubeletPort(), extraConfig))
 }
 
+func (s *Suite) loadPodListFilePlugin() workloadattestor.WorkloadAttestor {
+	return s.loadPlugin(`
+		pod_list_file = "pods.json"
+		max_poll_attempts = 5
+		poll_retry_interval = "1s"
+`)
+}
+
+// loadUnreachableKubeletPlugin loads the plugin configured with the read-only
+// port of a kubelet that is no longer listening.
+func (s *Suite) loadUnreachableKubeletPlugin(extraConfig string) workloadattestor.WorkloadAttestor {
+	s.startInsecureKubelet()
+	port := s.kubeletPort()
+	s.setServer(nil)
+
+	return s.loadPlugin(fmt.Sprintf(`
+		kubelet_read_only_port = %d
+		node_name = "k8s-node-1"
+		max_poll_attempts = 1
+		%s
+`, port, extraConfig))
+}
+
+func (s *Suite) writePodListFile(fixturePath string) {
+	podList, err := os.ReadFile(fixturePath)
+	s.Require().NoError(err)
+	s.writeFile("pods.json", string(podList))
+}
+
 func (s *Suite) startInsecureKubelet() {
 	s.setServer(httptest.NewServer(http.HandlerFunc(s.serveHTTP)))
 }
@@ -809,8 +1179,113 @@ func (s *Suite) addPodListResponse(fixturePath string) {
 	s.podList = append(s.podList, podList)
 }
 
+// fakeAPIServerClient lists the pods of the node from a fixture and returns
+// namespaces with the given labels.
+type fakeAPIServerClient struct {
+	apiserver.Client
+
+	podListPath     string
+	namespaceLabels map[string]string
+	err             error
+	nodeNames       []string
+	namespaces      []string
+}
+
+func (c *fakeAPIServerClient) GetNamespace(ctx context.Context, namespace string) (*corev1.Namespace, error) {
+	c.namespaces = append(c.namespaces, namespace)
+	if c.err != nil {
+		return nil, c.err
+	}
+	ns := new(corev1.Namespace)
+	ns.Name = namespace
+	ns.Labels = c.namespaceLabels
+	return ns, nil
+}
+
+func (c *fakeAPIServerClient) ListNodePods(ctx context.Context, nodeName string) (*corev1.PodList, error) {
+	c.nodeNames = append(c.nodeNames, nodeName)
+	if c.err != nil {
+		return nil, c.err
+	}
+	data, err := os.ReadFile(c.podListPath)
+	if err != nil {
+		return nil, err
+	}
+	podList := new(corev1.PodList)
+	if err := json.Unmarshal(data, podList); err != nil {
+		return nil, err
+	}
+	return podList, nil
+}
+
 type testFS string
 
 func (fs testFS) Open(path string) (io.ReadCloser, error) {
 	return os.Open(filepath.Join(string(fs), path))
 }
+
+func TestIsStaticPod(t *testing.T) {
+	const hash = "5f0b4e1ea5d6d8dfb2f3e7a09c7bd8a1"
+	nodeOwner := metav1.OwnerReference{APIVersion: "v1", Kind: "Node", Name: "node"}
+
+	for _, tt := range []struct {
+		name        string
+		annotations map[string]string
+		owners      []metav1.OwnerReference
+		fromKubelet bool
+		static      bool
+	}{
+		{
+			name:        "static pod reported by the kubelet",
+			annotations: map[string]string{configSourceAnnotation: "file", configHashAnnotation: hash},
+			fromKubelet: true,
+			static:      true,
+		},
+		{
+			name:        "static pod not reported by the kubelet",
+			annotations: map[string]string{configSourceAnnotation: "file", configHashAnnotation: hash},
+		},
+		{
+			name:        "mirror pod",
+			annotations: map[string]string{configSourceAnnotation: "file", configHashAnnotation: hash, configMirrorAnnotation: hash},
+			owners:      []metav1.OwnerReference{nodeOwner},
+			static:      true,
+		},
+		{
+			name:        "pod from the API server",
+			annotations: map[string]string{configSourceAnnotation: "api", configHashAnnotation: hash, configMirrorAnnotation: hash},
+			owners:      []metav1.OwnerReference{nodeOwner},
+		},
+		{
+			name:        "mirror annotation without config source",
+			annotations: map[string]string{configHashAnnotation: hash, configMirrorAnnotation: hash},
+			owners:      []metav1.OwnerReference{nodeOwner},
+		},
+		{
+			name:        "mirror pod not owned by the node",
+			annotations: map[string]string{configSourceAnnotation: "file", configHashAnnotation: hash, configMirrorAnnotation: hash},
+			owners:      []metav1.OwnerReference{{APIVersion: "v1", Kind: "Node", Name: "other-node"}},
+		},
+		{
+			name:        "mirror annotation not matching the config hash",
+			annotations: map[string]string{configSourceAnnotation: "file", configHashAnnotation: hash, configMirrorAnnotation: "other-hash"},
+			owners:      []metav1.OwnerReference{nodeOwner},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					UID:             "6a4e4c1f-0ad2-4e2b-a1f4-6e2a4b6a9d5c",
+					Annotations:     tt.annotations,
+					OwnerReferences: tt.owners,
+				},
+				Spec: corev1.PodSpec{NodeName: "node"},
+			}
+			static := isStaticPod(pod, tt.fromKubelet)
+			assert.Equal(t, tt.static, static)
+			assert.Equal(t, tt.static, podHasUID(pod, hash, static))
+			assert.True(t, podHasUID(pod, pod.UID, static))
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"github.com/spiffe/spire/pkg/common/plugin/k8s/apiserver"
+	"golang.org/x/sync/singleflight"
+)
+
+// namespaceFetchTimeout bounds how long a namespace query can take.
+const namespaceFetchTimeout = 30 * time.Second
+
+// namespaceLabelCache caches the labels of the namespaces fetched from the
+// API server, so attestations of workloads in the same namespace do not each
+// query it. Labels are fetched again once older than maxAge.
+type namespaceLabelCache struct {
+	clock  clock.Clock
+	maxAge time.Duration
+	client apiserver.Client
+
+	group singleflight.Group
+
+	mtx     sync.Mutex
+	entries map[string]namespaceLabels
+}
+
+type namespaceLabels struct {
+	labels    map[string]string
+	fetchedAt time.Time
+}
+
+func newNamespaceLabelCache(clk clock.Clock, maxAge time.Duration, client apiserver.Client) *namespaceLabelCache {
+	return &namespaceLabelCache{
+		clock:   clk,
+		maxAge:  maxAge,
+		client:  client,
+		entries: make(map[string]namespaceLabels),
+	}
+}
+
+// Get returns the labels of the given namespace. Concurrent attestations of
+// workloads in a namespace that is not cached share a single query.
+func (c *namespaceLabelCache) Get(ctx context.Context, namespace string) (map[string]string, error) {
+	c.mtx.Lock()
+	entry, ok := c.entries[namespace]
+	c.mtx.Unlock()
+	if ok && c.clock.Now().Sub(entry.fetchedAt) < c.maxAge {
+		return entry.labels, nil
+	}
+
+	ch := c.group.DoChan(namespace, func() (interface{}, error) {
+		return c.fetch(namespace)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(map[string]string), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch queries the labels of the given namespace and caches them. Like the
+// pod list cache, the query is bound to its own timeout rather than to the
+// context of any of the attestations sharing it.
+func (c *namespaceLabelCache) fetch(namespace string) (map[string]string, error) {
+	now := c.clock.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), namespaceFetchTimeout)
+	defer cancel()
+
+	ns, err := c.client.GetNamespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	// Drop the expired entries, e.g. of deleted namespaces
+	for name, entry := range c.entries {
+		if now.Sub(entry.fetchedAt) >= c.maxAge {
+			delete(c.entries, name)
+		}
+	}
+	c.entries[namespace] = namespaceLabels{
+		labels:    ns.Labels,
+		fetchedAt: now,
+	}
+	return ns.Labels, nil
+}
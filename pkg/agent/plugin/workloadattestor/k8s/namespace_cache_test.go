@@ -0,0 +1,114 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire/pkg/common/plugin/k8s/apiserver"
+	"github.com/spiffe/spire/test/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNamespaceLabelCacheGet(t *testing.T) {
+	clk := clock.NewMock(t)
+	client := &countingNamespaceClient{labels: map[string]string{"env": "prod"}}
+	cache := newNamespaceLabelCache(clk, time.Minute, client)
+	ctx := context.Background()
+
+	labels, err := cache.Get(ctx, "default")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"env": "prod"}, labels)
+	require.EqualValues(t, 1, client.gets())
+
+	// Labels are cached until they are too old
+	_, err = cache.Get(ctx, "default")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, client.gets())
+
+	clk.Add(time.Minute)
+	_, err = cache.Get(ctx, "default")
+	require.NoError(t, err)
+	require.EqualValues(t, 2, client.gets())
+
+	// Failures are not cached
+	client.err = errors.New("oh no")
+	_, err = cache.Get(ctx, "other")
+	require.EqualError(t, err, "oh no")
+	_, err = cache.Get(ctx, "other")
+	require.EqualError(t, err, "oh no")
+	require.EqualValues(t, 4, client.gets())
+}
+
+func TestNamespaceLabelCacheSharesQueries(t *testing.T) {
+	release := make(chan struct{})
+	client := &countingNamespaceClient{
+		labels:  map[string]string{"env": "prod"},
+		release: release,
+	}
+	cache := newNamespaceLabelCache(clock.NewMock(t), time.Minute, client)
+
+	// Concurrent attestations in the same namespace wait on the same query
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = cache.Get(context.Background(), "default")
+		}(i)
+	}
+	require.Eventually(t, func() bool {
+		return client.gets() == 1
+	}, time.Minute, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, client.gets())
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+}
+
+func TestNamespaceLabelCacheGetCanceled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	cache := newNamespaceLabelCache(clock.NewMock(t), time.Minute, &countingNamespaceClient{release: release})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := cache.Get(ctx, "default")
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+type countingNamespaceClient struct {
+	apiserver.Client
+
+	labels  map[string]string
+	err     error
+	release <-chan struct{}
+	count   int32
+}
+
+func (c *countingNamespaceClient) GetNamespace(ctx context.Context, namespace string) (*corev1.Namespace, error) {
+	atomic.AddInt32(&c.count, 1)
+	if c.release != nil {
+		<-c.release
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	ns := new(corev1.Namespace)
+	ns.Name = namespace
+	ns.Labels = c.labels
+	return ns, nil
+}
+
+func (c *countingNamespaceClient) gets() int32 {
+	return atomic.LoadInt32(&c.count)
+}
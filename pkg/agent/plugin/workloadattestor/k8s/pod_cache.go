@@ -0,0 +1,128 @@
+package k8s
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"github.com/hashicorp/go-hclog"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// podListFetchTimeout bounds how long a pod list query can take, including
+// the fallback to the API server.
+const podListFetchTimeout = 30 * time.Second
+
+// podListCache holds the pods of the node, shared by concurrent attestations
+// so that a burst of workload starts does not translate into a burst of
+// kubelet queries. The list is refreshed periodically in the background and
+// on demand when an attestation needs a newer list, with a single query in
+// flight at a time.
+type podListCache struct {
+	clock  clock.Clock
+	maxAge time.Duration
+	fetch  func(ctx context.Context) (*nodePodList, error)
+
+	mtx       sync.Mutex
+	list      *nodePodList
+	gen       uint64
+	fetchedAt time.Time
+	inflight  *podListFetch
+}
+
+// podListFetch is a pod list query in flight. Its fields are set before done
+// is closed.
+type podListFetch struct {
+	done chan struct{}
+	list *nodePodList
+	gen  uint64
+	err  error
+}
+
+func newPodListCache(clk clock.Clock, maxAge time.Duration, fetch func(ctx context.Context) (*nodePodList, error)) *podListCache {
+	return &podListCache{
+		clock:  clk,
+		maxAge: maxAge,
+		fetch:  fetch,
+	}
+}
+
+// Get returns a pod list more recent than the one with the given generation,
+// and no older than the maximum age, along with its generation. Attestations
+// pass zero on the first attempt and the generation of the list they last
+// searched on retries, so they never search the same list twice.
+func (c *podListCache) Get(ctx context.Context, afterGen uint64) (*nodePodList, uint64, error) {
+	c.mtx.Lock()
+	if c.list != nil && c.gen > afterGen && c.clock.Now().Sub(c.fetchedAt) <= c.maxAge {
+		list, gen := c.list, c.gen
+		c.mtx.Unlock()
+		return list, gen, nil
+	}
+	f := c.startFetchLocked()
+	c.mtx.Unlock()
+
+	select {
+	case <-f.done:
+		return f.list, f.gen, f.err
+	case <-ctx.Done():
+		return nil, 0, status.Errorf(codes.Canceled, "no pod list fetched: %v", ctx.Err())
+	}
+}
+
+// Run refreshes the pod list at the maximum age interval until the context
+// is canceled.
+func (c *podListCache) Run(ctx context.Context, log hclog.Logger) {
+	ticker := c.clock.Ticker(c.maxAge)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.mtx.Lock()
+			f := c.startFetchLocked()
+			c.mtx.Unlock()
+
+			<-f.done
+			if f.err != nil {
+				log.Warn("Unable to refresh the pod list", telemetry.Error, f.err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// startFetchLocked starts a pod list query, unless one is already in flight,
+// and returns it. The query is not bound to the context of any attestation,
+// since they all share its outcome, but to its own timeout, so a hung query
+// does not block every following attestation.
+func (c *podListCache) startFetchLocked() *podListFetch {
+	if c.inflight != nil {
+		return c.inflight
+	}
+	f := &podListFetch{done: make(chan struct{})}
+	c.inflight = f
+
+	go func() {
+		fetchedAt := c.clock.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), podListFetchTimeout)
+		list, err := c.fetch(ctx)
+		cancel()
+
+		c.mtx.Lock()
+		c.inflight = nil
+		if err == nil {
+			c.gen++
+			c.list = list
+			c.fetchedAt = fetchedAt
+			f.list = list
+			f.gen = c.gen
+		}
+		f.err = err
+		c.mtx.Unlock()
+		close(f.done)
+	}()
+	return f
+}
@@ -0,0 +1,148 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/spiffe/spire/test/clock"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodListCacheGet(t *testing.T) {
+	clk := clock.NewMock(t)
+	var fetches int32
+	cache := newPodListCache(clk, time.Minute, func(ctx context.Context) (*nodePodList, error) {
+		atomic.AddInt32(&fetches, 1)
+		return &nodePodList{PodList: new(corev1.PodList)}, nil
+	})
+	ctx := context.Background()
+
+	// The first list is fetched on demand
+	list1, gen, err := cache.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), gen)
+	require.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+
+	// It is shared while fresh
+	list, gen, err := cache.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), gen)
+	require.Same(t, list1, list)
+	require.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+
+	// A newer list is fetched when asked for one
+	list2, gen, err := cache.Get(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), gen)
+	require.NotSame(t, list1, list2)
+	require.EqualValues(t, 2, atomic.LoadInt32(&fetches))
+
+	// Or when the list is too old
+	clk.Add(time.Minute + time.Second)
+	_, gen, err = cache.Get(ctx, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), gen)
+	require.EqualValues(t, 3, atomic.LoadInt32(&fetches))
+}
+
+func TestPodListCacheSharesFetches(t *testing.T) {
+	clk := clock.NewMock(t)
+	var fetches int32
+	release := make(chan struct{})
+	cache := newPodListCache(clk, time.Minute, func(ctx context.Context) (*nodePodList, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return nil, errors.New("oh no")
+	})
+
+	// Concurrent attestations wait on the same fetch and share its outcome
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, errs[i] = cache.Get(context.Background(), 0)
+		}(i)
+	}
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&fetches) == 1
+	}, time.Minute, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+	for _, err := range errs {
+		assert.EqualError(t, err, "oh no")
+	}
+}
+
+func TestPodListCacheGetCanceled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	cache := newPodListCache(clock.NewMock(t), time.Minute, func(ctx context.Context) (*nodePodList, error) {
+		<-release
+		return &nodePodList{PodList: new(corev1.PodList)}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := cache.Get(ctx, 0)
+	spiretest.RequireGRPCStatusContains(t, err, codes.Canceled, "no pod list fetched")
+}
+
+func TestPodListCacheFetchTimeout(t *testing.T) {
+	cache := newPodListCache(clock.NewMock(t), time.Minute, func(ctx context.Context) (*nodePodList, error) {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return nil, errors.New("pod list fetched without a deadline")
+		}
+		assert.WithinDuration(t, time.Now().Add(podListFetchTimeout), deadline, time.Second)
+		return &nodePodList{PodList: new(corev1.PodList)}, nil
+	})
+
+	_, _, err := cache.Get(context.Background(), 0)
+	require.NoError(t, err)
+}
+
+func TestPodListCacheRun(t *testing.T) {
+	clk := clock.NewMock(t)
+	var fetches int32
+	cache := newPodListCache(clk, time.Minute, func(ctx context.Context) (*nodePodList, error) {
+		atomic.AddInt32(&fetches, 1)
+		return &nodePodList{PodList: new(corev1.PodList)}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cache.Run(ctx, hclog.NewNullLogger())
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The list is refreshed in the background at the interval
+	clk.WaitForTicker(time.Minute, "waiting for the refresh ticker")
+	clk.Add(time.Minute)
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&fetches) == 1
+	}, time.Minute, time.Millisecond)
+
+	// Attestations use the refreshed list
+	_, gen, err := cache.Get(context.Background(), 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), gen)
+	require.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+}
@@ -10,5 +10,8 @@ import (
 type WorkloadAttestor interface {
 	catalog.PluginInfo
 
+	// Attest returns the selectors of the workload with the given PID.
+	// Plugins deny the workload by returning a PermissionDenied status with
+	// the reason, which aborts attestation regardless of other attestors.
 	Attest(ctx context.Context, pid int) ([]*common.Selector, error)
 }
@@ -0,0 +1,139 @@
+// Package adminapi provides the plumbing for the administrative gRPC
+// services of SPIRE that have no counterpart in the SPIRE API SDK. Instead of
+// being generated from protobuf definitions, the services are declared in Go.
+// Requests and responses are JSON objects carried in a google.protobuf.Struct
+// message, so the services travel over the same gRPC servers, and through the
+// same authentication, authorization, rate limiting and audit middleware, as
+// the generated ones.
+package adminapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sort"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Handler handles a call to a method. The decode function unmarshals the
+// request into the given value. The returned value is marshaled into the
+// response.
+type Handler func(ctx context.Context, decode func(interface{}) error) (interface{}, error)
+
+// Service is a gRPC service made of unary methods.
+type Service struct {
+	// Name is the fully qualified name of the service
+	// (e.g. spire.server.admin.Admin).
+	Name string
+
+	// Methods are the methods of the service, keyed by method name.
+	Methods map[string]Handler
+}
+
+// FullMethod returns the full gRPC method name of the given method.
+func FullMethod(serviceName, methodName string) string {
+	return "/" + serviceName + "/" + methodName
+}
+
+// Register registers the service on the gRPC server.
+func Register(s grpc.ServiceRegistrar, service Service) {
+	desc := &grpc.ServiceDesc{
+		ServiceName: service.Name,
+		HandlerType: (*interface{})(nil),
+	}
+
+	methodNames := make([]string, 0, len(service.Methods))
+	for methodName := range service.Methods {
+		methodNames = append(methodNames, methodName)
+	}
+	sort.Strings(methodNames)
+
+	for _, methodName := range methodNames {
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: methodName,
+			Handler:    methodHandler(FullMethod(service.Name, methodName), service.Methods[methodName]),
+		})
+	}
+	s.RegisterService(desc, service)
+}
+
+// Invoke calls a method over the given connection. The request is marshaled
+// into the request message and the response message is unmarshaled into
+// resp, if not nil.
+func Invoke(ctx context.Context, conn grpc.ClientConnInterface, serviceName, methodName string, req, resp interface{}) error {
+	in, err := Marshal(req)
+	if err != nil {
+		return err
+	}
+	out := new(structpb.Struct)
+	if err := conn.Invoke(ctx, FullMethod(serviceName, methodName), in, out); err != nil {
+		return err
+	}
+	if resp == nil {
+		return nil
+	}
+	return Unmarshal(out, resp)
+}
+
+// Marshal marshals a value into a message using its JSON encoding. A nil
+// value is marshaled into an empty message.
+func Marshal(v interface{}) (*structpb.Struct, error) {
+	msg := new(structpb.Struct)
+	if v == nil {
+		return msg, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to marshal message: %v", err)
+	}
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to marshal message: %v", err)
+	}
+	return msg, nil
+}
+
+// Unmarshal unmarshals a message into a value using its JSON encoding. Fields
+// of the message unknown to the value are rejected.
+func Unmarshal(msg *structpb.Struct, v interface{}) error {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "malformed message: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return status.Errorf(codes.InvalidArgument, "malformed message: %v", err)
+	}
+	return nil
+}
+
+func methodHandler(fullMethod string, handler Handler) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := new(structpb.Struct)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		call := func(ctx context.Context, req interface{}) (interface{}, error) {
+			resp, err := handler(ctx, func(v interface{}) error {
+				return Unmarshal(req.(*structpb.Struct), v)
+			})
+			if err != nil {
+				return nil, err
+			}
+			return Marshal(resp)
+		}
+		if interceptor == nil {
+			return call(ctx, in)
+		}
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: fullMethod,
+		}
+		return interceptor(ctx, in, info, call)
+	}
+}
@@ -0,0 +1,77 @@
+package adminapi_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+type echoRequest struct {
+	Message string `json:"message"`
+}
+
+type echoResponse struct {
+	Message string `json:"message"`
+	Method  string `json:"method"`
+}
+
+func TestInvoke(t *testing.T) {
+	var fullMethods []string
+	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		fullMethods = append(fullMethods, info.FullMethod)
+		return handler(ctx, req)
+	}
+
+	service := adminapi.Service{
+		Name: "spire.test.Test",
+		Methods: map[string]adminapi.Handler{
+			"Echo": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(echoRequest)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				method, _ := grpc.Method(ctx)
+				return &echoResponse{Message: req.Message, Method: method}, nil
+			},
+			"Fail": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				return nil, status.Error(codes.FailedPrecondition, "oh no")
+			},
+		},
+	}
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(interceptor))
+	adminapi.Register(server, service)
+	addr := spiretest.ServeGRPCServerOnTempUDSSocket(t, server)
+
+	conn, err := grpc.Dial("unix:"+addr.String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx := context.Background()
+
+	resp := new(echoResponse)
+	require.NoError(t, adminapi.Invoke(ctx, conn, service.Name, "Echo", &echoRequest{Message: "hello"}, resp))
+	require.Equal(t, &echoResponse{Message: "hello", Method: "/spire.test.Test/Echo"}, resp)
+
+	err = adminapi.Invoke(ctx, conn, service.Name, "Echo", map[string]string{"unknown": "field"}, resp)
+	spiretest.RequireGRPCStatus(t, err, codes.InvalidArgument, `malformed message: json: unknown field "unknown"`)
+
+	err = adminapi.Invoke(ctx, conn, service.Name, "Fail", nil, nil)
+	spiretest.RequireGRPCStatus(t, err, codes.FailedPrecondition, "oh no")
+
+	err = adminapi.Invoke(ctx, conn, service.Name, "Missing", nil, nil)
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	require.Equal(t, []string{
+		"/spire.test.Test/Echo",
+		"/spire.test.Test/Echo",
+		"/spire.test.Test/Fail",
+	}, fullMethods)
+}
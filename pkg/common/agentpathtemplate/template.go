@@ -3,6 +3,7 @@ package agentpathtemplate
 import (
 	"bytes"
 	"text/template"
+	"text/template/parse"
 )
 
 // Parse parses an agent path template. It changes the behavior for missing
@@ -40,3 +41,53 @@ func (t *Template) Execute(args interface{}) (string, error) {
 	}
 	return buf.String(), nil
 }
+
+// References returns whether the template references the given top-level
+// field of its data, e.g. "Selectors" for {{ .Selectors.zone }}.
+func (t *Template) References(field string) bool {
+	return referencesField(t.tmpl.Tree.Root, field)
+}
+
+func referencesField(node parse.Node, field string) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if referencesField(child, field) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return referencesField(n.Pipe, field)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if referencesField(cmd, field) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if referencesField(arg, field) {
+				return true
+			}
+		}
+	case *parse.FieldNode:
+		return len(n.Ident) > 0 && n.Ident[0] == field
+	case *parse.ChainNode:
+		return referencesField(n.Node, field)
+	case *parse.IfNode:
+		return referencesField(n.Pipe, field) || referencesField(n.List, field) || referencesField(n.ElseList, field)
+	case *parse.RangeNode:
+		return referencesField(n.Pipe, field) || referencesField(n.List, field) || referencesField(n.ElseList, field)
+	case *parse.WithNode:
+		return referencesField(n.Pipe, field) || referencesField(n.List, field) || referencesField(n.ElseList, field)
+	case *parse.TemplateNode:
+		return referencesField(n.Pipe, field)
+	}
+	return false
+}
@@ -38,3 +38,25 @@ func TestMustParse(t *testing.T) {
 		})
 	})
 }
+
+func TestReferences(t *testing.T) {
+	for _, tt := range []struct {
+		text       string
+		references bool
+	}{
+		{text: "/static", references: false},
+		{text: "/{{ .PluginName }}", references: false},
+		{text: "/{{ .Selectors.zone }}", references: true},
+		{text: `/{{ index .Selectors "instance-name" }}`, references: true},
+		{text: `/{{ if .Selectors.zone }}{{ .PluginName }}{{ end }}`, references: true},
+		{text: `/{{ .PluginName }}{{ with .Selectors }}/{{ .zone }}{{ end }}`, references: true},
+		{text: "/{{ .SelectorsCount }}", references: false},
+	} {
+		tt := tt
+		t.Run(tt.text, func(t *testing.T) {
+			tmpl, err := agentpathtemplate.Parse(tt.text)
+			require.NoError(t, err)
+			require.Equal(t, tt.references, tmpl.References("Selectors"))
+		})
+	}
+}
@@ -0,0 +1,55 @@
+// Package clockskew propagates the time of agents to the server in the gRPC
+// request metadata so that the server can detect clock skew between them.
+package clockskew
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataKey is the request metadata key holding the time of the agent
+// when the request was sent, in RFC 3339 format.
+const MetadataKey = "spire-agent-time"
+
+// AppendToOutgoingContext returns a context whose outgoing metadata reports
+// the given time as the time of the agent.
+func AppendToOutgoingContext(ctx context.Context, t time.Time) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, MetadataKey, t.UTC().Format(time.RFC3339Nano))
+}
+
+// FromIncomingContext returns the time of the agent reported in the
+// incoming metadata, if any.
+func FromIncomingContext(ctx context.Context) (time.Time, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return time.Time{}, false
+	}
+	values := md.Get(MetadataKey)
+	if len(values) != 1 {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, values[0])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// UnaryClientInterceptor returns an interceptor that reports the time
+// returned by now on every unary call.
+func UnaryClientInterceptor(now func() time.Time) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(AppendToOutgoingContext(ctx, now()), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor returns an interceptor that reports the time
+// returned by now on every streaming call.
+func StreamClientInterceptor(now func() time.Time) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(AppendToOutgoingContext(ctx, now()), desc, cc, method, opts...)
+	}
+}
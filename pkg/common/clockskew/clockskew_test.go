@@ -0,0 +1,84 @@
+package clockskew
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestFromIncomingContext(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 30, 15, 500, time.UTC)
+
+	for _, tt := range []struct {
+		name   string
+		md     metadata.MD
+		expect time.Time
+		ok     bool
+	}{
+		{
+			name: "no metadata",
+		},
+		{
+			name: "no agent time",
+			md:   metadata.Pairs("other", "value"),
+		},
+		{
+			name: "malformed agent time",
+			md:   metadata.Pairs(MetadataKey, "yesterday"),
+		},
+		{
+			name: "more than one agent time",
+			md:   metadata.Pairs(MetadataKey, now.Format(time.RFC3339Nano), MetadataKey, now.Format(time.RFC3339Nano)),
+		},
+		{
+			name:   "agent time",
+			md:     metadata.Pairs(MetadataKey, now.Format(time.RFC3339Nano)),
+			expect: now,
+			ok:     true,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			agentTime, ok := FromIncomingContext(ctx)
+			assert.Equal(t, tt.ok, ok)
+			assert.True(t, tt.expect.Equal(agentTime), "expected %s; got %s", tt.expect, agentTime)
+		})
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 30, 15, 500, time.UTC)
+	interceptor := UnaryClientInterceptor(func() time.Time { return now })
+
+	var md metadata.MD
+	err := interceptor(context.Background(), "/Service/Method", nil, nil, nil,
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			md, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, []string{"2022-06-01T12:30:15.0000005Z"}, md.Get(MetadataKey))
+}
+
+func TestStreamClientInterceptor(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 30, 15, 500, time.UTC)
+	interceptor := StreamClientInterceptor(func() time.Time { return now })
+
+	var md metadata.MD
+	_, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/Service/Method",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			md, _ = metadata.FromOutgoingContext(ctx)
+			return nil, nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, []string{"2022-06-01T12:30:15.0000005Z"}, md.Get(MetadataKey))
+}
@@ -0,0 +1,147 @@
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Event is an entry recorded by an EventBuffer.
+type Event struct {
+	Time    time.Time         `json:"time"`
+	Level   string            `json:"level"`
+	Message string            `json:"msg"`
+	Fields  map[string]string `json:"fields,omitempty"`
+}
+
+// String formats the event on a single line, like the text log format does:
+// the time, the level, the message and the fields sorted by key.
+func (e Event) String() string {
+	var b strings.Builder
+	b.WriteString(e.Time.Format(time.RFC3339))
+	b.WriteString(" [")
+	b.WriteString(e.Level)
+	b.WriteString("] ")
+	b.WriteString(e.Message)
+
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		b.WriteString(" ")
+		b.WriteString(key)
+		b.WriteString("=")
+		b.WriteString(quoteIfNeeded(e.Fields[key]))
+	}
+	return b.String()
+}
+
+func quoteIfNeeded(value string) string {
+	if value == "" || strings.ContainsAny(value, " \t\r\n\"=") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// EventBuffer is a logrus hook that keeps the most recent significant
+// entries in memory, i.e. those at the INFO level or above, such as
+// attestation failures, SVID rotations and plugin errors. It lets transient
+// issues be diagnosed after the fact even when the logger is configured with
+// a less verbose level.
+type EventBuffer struct {
+	mtx    sync.Mutex
+	events []Event
+	next   int
+	full   bool
+}
+
+// NewEventBuffer returns a buffer that keeps up to size events.
+func NewEventBuffer(size int) *EventBuffer {
+	return &EventBuffer{
+		events: make([]Event, size),
+	}
+}
+
+// Levels returns the levels of the entries recorded.
+func (b *EventBuffer) Levels() []logrus.Level {
+	return []logrus.Level{
+		logrus.PanicLevel,
+		logrus.FatalLevel,
+		logrus.ErrorLevel,
+		logrus.WarnLevel,
+		logrus.InfoLevel,
+	}
+}
+
+// Fire records the entry, evicting the oldest event if the buffer is full.
+func (b *EventBuffer) Fire(entry *logrus.Entry) error {
+	event := Event{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+	}
+	if len(entry.Data) > 0 {
+		// Values are formatted right away since they may be mutated after
+		// the entry is logged
+		event.Fields = make(map[string]string, len(entry.Data))
+		for key, value := range entry.Data {
+			if err, ok := value.(error); ok {
+				event.Fields[key] = err.Error()
+				continue
+			}
+			event.Fields[key] = fmt.Sprint(value)
+		}
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if len(b.events) == 0 {
+		return nil
+	}
+	b.events[b.next] = event
+	b.next = (b.next + 1) % len(b.events)
+	if b.next == 0 {
+		b.full = true
+	}
+	return nil
+}
+
+// Events returns the recorded events, oldest first.
+func (b *EventBuffer) Events() []Event {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if !b.full {
+		return append([]Event(nil), b.events[:b.next]...)
+	}
+	events := make([]Event, 0, len(b.events))
+	events = append(events, b.events[b.next:]...)
+	return append(events, b.events[:b.next]...)
+}
+
+// WithEventBuffer records the significant entries in the given buffer. When
+// the logger level is less verbose than INFO, the entries that are only
+// recorded are not output. Since it may take over the output of the entries
+// and depends on the base level, it must come after WithFormat and WithLevel.
+func WithEventBuffer(buffer *EventBuffer) Option {
+	return func(logger *Logger) error {
+		logger.AddHook(buffer)
+
+		h := logger.filterLevels()
+		h.mtx.Lock()
+		h.hooksMin = logrus.InfoLevel
+		h.mtx.Unlock()
+		if !logger.IsLevelEnabled(logrus.InfoLevel) {
+			logger.SetLevel(logrus.InfoLevel)
+		}
+		return nil
+	}
+}
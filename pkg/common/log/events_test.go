@@ -0,0 +1,108 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBuffer(t *testing.T) {
+	out := new(bytes.Buffer)
+	buffer := NewEventBuffer(3)
+	logger, err := NewLogger(
+		WithLevel("WARN"),
+		WithFormat(JSONFormat),
+		WithEventBuffer(buffer),
+	)
+	require.NoError(t, err)
+	logger.SetOutput(out)
+
+	// INFO entries are built for the buffer even if they are not output
+	assert.Equal(t, logrus.InfoLevel, logger.GetLevel())
+
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.WithError(errors.New("oh no")).Error("error")
+	assert.Equal(t, []string{"error"}, loggedMessages(t, out))
+
+	events := buffer.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "info", events[0].Level)
+	assert.Equal(t, "info", events[0].Message)
+	assert.Nil(t, events[0].Fields)
+	assert.Equal(t, "error", events[1].Level)
+	assert.Equal(t, "error", events[1].Message)
+	assert.Equal(t, map[string]string{"error": "oh no"}, events[1].Fields)
+
+	// The oldest events are evicted once the buffer is full
+	logger.WithField("n", 1).Warn("warn")
+	logger.Info("info 2")
+	assert.Equal(t, []string{"error", "warn", "info 2"}, eventMessages(buffer.Events()))
+	assert.Equal(t, map[string]string{"n": "1"}, buffer.Events()[1].Fields)
+}
+
+func TestEventBufferWithSubsystemLevels(t *testing.T) {
+	out := new(bytes.Buffer)
+	buffer := NewEventBuffer(10)
+	logger, err := NewLogger(
+		WithLevel("ERROR"),
+		WithFormat(JSONFormat),
+		WithSubsystemLevels(map[string]string{"manager": "WARN"}),
+		WithEventBuffer(buffer),
+	)
+	require.NoError(t, err)
+	logger.SetOutput(out)
+
+	// Replacing the overrides keeps INFO entries built for the buffer
+	require.NoError(t, logger.SetSubsystemLevels(map[string]logrus.Level{"manager": logrus.ErrorLevel}))
+	assert.Equal(t, logrus.InfoLevel, logger.GetLevel())
+
+	manager := logger.WithField("subsystem_name", "manager")
+	manager.Info("manager info")
+	manager.Warn("manager warn")
+	manager.Error("manager error")
+	assert.Equal(t, []string{"manager error"}, loggedMessages(t, out))
+	assert.Equal(t, []string{"manager info", "manager warn", "manager error"}, eventMessages(buffer.Events()))
+}
+
+func TestEventBufferFields(t *testing.T) {
+	buffer := NewEventBuffer(10)
+	logger, err := NewLogger(WithEventBuffer(buffer))
+	require.NoError(t, err)
+	logger.SetOutput(io.Discard)
+	logger.WithField("subsystem_name", "manager").Warn("warn")
+
+	events := buffer.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "warning", events[0].Level)
+	assert.Equal(t, "warn", events[0].Message)
+	assert.Equal(t, map[string]string{"subsystem_name": "manager"}, events[0].Fields)
+}
+
+func TestEventString(t *testing.T) {
+	event := Event{
+		Time:    time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC),
+		Level:   "warning",
+		Message: "Agent attestation failed",
+		Fields: map[string]string{
+			"subsystem_name": "api",
+			"error":          "oh no",
+			"empty":          "",
+		},
+	}
+	assert.Equal(t, `2022-01-01T12:00:00Z [warning] Agent attestation failed empty="" error="oh no" subsystem_name=api`, event.String())
+}
+
+func eventMessages(events []Event) []string {
+	var messages []string
+	for _, event := range events {
+		messages = append(messages, event.Message)
+	}
+	return messages
+}
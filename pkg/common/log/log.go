@@ -10,6 +10,8 @@ import (
 type Logger struct {
 	*logrus.Logger
 	io.Closer
+
+	subsystems *subsystemHook
 }
 
 func NewLogger(options ...Option) (*Logger, error) {
@@ -24,6 +26,9 @@ func NewLogger(options ...Option) (*Logger, error) {
 			return nil, err
 		}
 	}
+	if logger.subsystems != nil {
+		logger.subsystems.setReady()
+	}
 
 	return logger, nil
 }
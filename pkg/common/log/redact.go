@@ -0,0 +1,59 @@
+package log
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// redactionHook redacts the values of the given fields before the entries
+// are output or recorded by other hooks.
+type redactionHook struct {
+	fields map[string]bool
+	redact func(string) string
+}
+
+func (h *redactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *redactionHook) Fire(entry *logrus.Entry) error {
+	// The entry data is a copy owned by the entry being logged, so it can be
+	// modified in place.
+	for key, value := range entry.Data {
+		if !h.fields[key] {
+			continue
+		}
+		switch value := value.(type) {
+		case string:
+			entry.Data[key] = h.redact(value)
+		case []string:
+			redacted := make([]string, len(value))
+			for i, v := range value {
+				redacted[i] = h.redact(v)
+			}
+			entry.Data[key] = redacted
+		default:
+			entry.Data[key] = h.redact(fmt.Sprint(value))
+		}
+	}
+	return nil
+}
+
+// WithRedaction redacts the values of the given fields using the redact
+// function, e.g. to keep SPIFFE IDs out of the logs. Values that are not
+// strings are formatted first. Since hooks fire in order, it must come before
+// options that add hooks recording the entries, like WithEventBuffer.
+func WithRedaction(fields []string, redact func(string) string) Option {
+	return func(logger *Logger) error {
+		hook := &redactionHook{
+			fields: make(map[string]bool, len(fields)),
+			redact: redact,
+		}
+		for _, field := range fields {
+			hook.fields[field] = true
+		}
+		logger.AddHook(hook)
+		return nil
+	}
+}
@@ -0,0 +1,49 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithRedaction(t *testing.T) {
+	out := new(bytes.Buffer)
+	buffer := NewEventBuffer(10)
+	logger, err := NewLogger(
+		WithFormat(JSONFormat),
+		WithRedaction([]string{"spiffe_id", "selectors"}, strings.ToUpper),
+		WithEventBuffer(buffer),
+	)
+	require.NoError(t, err)
+	logger.SetOutput(out)
+
+	entry := logger.WithField("spiffe_id", "spiffe://example.org/workload")
+	entry.WithFields(logrus.Fields{
+		"selectors": []string{"unix:uid:0", "unix:gid:0"},
+		"other":     "value",
+	}).Info("Redacted")
+	entry.WithField("selectors", 42).Info("Formatted")
+
+	var data map[string]interface{}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &data))
+	assert.Equal(t, "SPIFFE://EXAMPLE.ORG/WORKLOAD", data["spiffe_id"])
+	assert.Equal(t, []interface{}{"UNIX:UID:0", "UNIX:GID:0"}, data["selectors"])
+	assert.Equal(t, "value", data["other"])
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &data))
+	assert.Equal(t, "42", data["selectors"])
+
+	// Hooks added afterwards record the redacted values
+	events := buffer.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "SPIFFE://EXAMPLE.ORG/WORKLOAD", events[0].Fields["spiffe_id"])
+
+	// The fields of the parent entry are left untouched
+	assert.Equal(t, "spiffe://example.org/workload", entry.Data["spiffe_id"])
+}
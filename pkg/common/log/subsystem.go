@@ -0,0 +1,226 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// subsystemHook is a logrus hook that outputs the entries that are at or
+// above the level configured for the subsystem that emitted them. Once it is
+// installed, the logger formatter is replaced with one that outputs nothing,
+// so that entries are only output by the hook. It is only installed when
+// entries need to be filtered, either at startup or when overrides are first
+// set at runtime.
+//
+// The level of the logger is set to the most verbose of the base level, the
+// subsystem levels and the level the hooks need (see WithEventBuffer) so that
+// logrus builds the entries that any of them needs. The entries of the
+// subsystems that are not configured are still only output at the base
+// level.
+type subsystemHook struct {
+	logger    *Logger
+	formatter logrus.Formatter
+
+	// ready is set once the logger options are applied, after which the
+	// hook is installed as soon as it is needed
+	ready       bool
+	installOnce sync.Once
+
+	// writeMtx serializes the writes to the logger output, since hooks
+	// fire concurrently
+	writeMtx sync.Mutex
+
+	mtx      sync.RWMutex
+	level    logrus.Level
+	levels   map[string]logrus.Level
+	hooksMin logrus.Level
+}
+
+// filterLevels makes the logger able to filter the entries it outputs
+// through a subsystemHook, which is returned.
+func (l *Logger) filterLevels() *subsystemHook {
+	if l.subsystems == nil {
+		l.subsystems = &subsystemHook{
+			logger:   l,
+			level:    l.GetLevel(),
+			hooksMin: logrus.PanicLevel,
+		}
+	}
+	return l.subsystems
+}
+
+// setReady is called by NewLogger once all the options are applied, so that
+// the hook fires after the hooks that modify the entries (e.g.
+// WithRedaction).
+func (h *subsystemHook) setReady() {
+	h.mtx.Lock()
+	h.ready = true
+	needed := len(h.levels) > 0 || h.hooksMin > h.level
+	h.mtx.Unlock()
+
+	if needed {
+		h.install()
+	}
+}
+
+func (h *subsystemHook) install() {
+	h.installOnce.Do(func() {
+		h.formatter = h.logger.Formatter
+		h.logger.AddHook(h)
+		h.logger.SetFormatter(discardFormatter{})
+	})
+}
+
+// Levels returns every level; the entries are filtered by Fire.
+func (h *subsystemHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire outputs the entry unless it is below the level of its subsystem.
+func (h *subsystemHook) Fire(entry *logrus.Entry) error {
+	if entry.Level > h.levelFor(entrySubsystem(entry.Data)) {
+		return nil
+	}
+	serialized, err := h.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	h.writeMtx.Lock()
+	defer h.writeMtx.Unlock()
+	_, err = entry.Logger.Out.Write(serialized)
+	return err
+}
+
+// levelFor returns the level of the most specific subsystem configured that
+// matches the given subsystem, i.e. "workloadattestor.k8s" is used for
+// "workloadattestor.k8s.sigstore" if the latter is not configured.
+func (h *subsystemHook) levelFor(subsystem string) logrus.Level {
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+
+	for subsystem != "" {
+		if level, ok := h.levels[subsystem]; ok {
+			return level
+		}
+		i := strings.LastIndex(subsystem, ".")
+		if i < 0 {
+			break
+		}
+		subsystem = subsystem[:i]
+	}
+	return h.level
+}
+
+// SubsystemLevels returns the base log level and the log level overrides of
+// the subsystems. It fails unless the logger was created using
+// WithSubsystemLevels.
+func (l *Logger) SubsystemLevels() (logrus.Level, map[string]logrus.Level, error) {
+	h := l.subsystems
+	if h == nil {
+		return 0, nil, errors.New("subsystem log levels are not enabled")
+	}
+
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+	levels := make(map[string]logrus.Level, len(h.levels))
+	for subsystem, level := range h.levels {
+		levels[subsystem] = level
+	}
+	return h.level, levels, nil
+}
+
+// SetSubsystemLevels replaces the log level overrides of the subsystems. It
+// fails unless the logger was created using WithSubsystemLevels.
+//
+// Subsystems are named after the "subsystem_name" field of the entries
+// (e.g. "manager"). Plugin subsystems are prefixed with the lowercase plugin
+// type and the plugin name (e.g. "workloadattestor.k8s" or
+// "workloadattestor.k8s.sigstore" for a logger named "sigstore" within the
+// plugin).
+func (l *Logger) SetSubsystemLevels(levels map[string]logrus.Level) error {
+	h := l.subsystems
+	if h == nil {
+		return errors.New("subsystem log levels are not enabled")
+	}
+
+	normalized := make(map[string]logrus.Level, len(levels))
+	maxLevel := h.level
+	if h.hooksMin > maxLevel {
+		maxLevel = h.hooksMin
+	}
+	for subsystem, level := range levels {
+		normalized[strings.ToLower(subsystem)] = level
+		if level > maxLevel {
+			maxLevel = level
+		}
+	}
+
+	h.mtx.Lock()
+	h.levels = normalized
+	ready := h.ready
+	h.mtx.Unlock()
+
+	if ready && len(normalized) > 0 {
+		h.install()
+	}
+	l.SetLevel(maxLevel)
+	return nil
+}
+
+// WithSubsystemLevels overrides the log level of the given subsystems. See
+// SetSubsystemLevels for how subsystems are named. The overrides can be
+// replaced at runtime even if none is given. Since it may take over the
+// formatter and depends on the base level, it must come after WithFormat and
+// WithLevel.
+func WithSubsystemLevels(levels map[string]string) Option {
+	return func(logger *Logger) error {
+		parsed, err := ParseSubsystemLevels(levels)
+		if err != nil {
+			return err
+		}
+
+		logger.filterLevels()
+		return logger.SetSubsystemLevels(parsed)
+	}
+}
+
+// ParseSubsystemLevels parses the log level of each subsystem.
+func ParseSubsystemLevels(levels map[string]string) (map[string]logrus.Level, error) {
+	parsed := make(map[string]logrus.Level, len(levels))
+	for subsystem, logLevel := range levels {
+		level, err := logrus.ParseLevel(logLevel)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level for subsystem %q: %w", subsystem, err)
+		}
+		parsed[subsystem] = level
+	}
+	return parsed, nil
+}
+
+func entrySubsystem(data logrus.Fields) string {
+	subsystem, _ := data["subsystem_name"].(string)
+
+	pluginType, _ := data["plugin_type"].(string)
+	pluginName, _ := data["plugin_name"].(string)
+	if pluginType != "" && pluginName != "" {
+		// Named loggers within plugins have the plugin name as prefix
+		if !strings.HasPrefix(subsystem, pluginName+".") {
+			subsystem = pluginName
+		}
+		subsystem = pluginType + "." + subsystem
+	}
+	return strings.ToLower(subsystem)
+}
+
+// discardFormatter formats every entry as nothing. Entries are output by the
+// subsystemHook instead.
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,149 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubsystemLevels(t *testing.T) {
+	out := new(bytes.Buffer)
+	logger, err := NewLogger(
+		WithLevel("INFO"),
+		WithFormat(JSONFormat),
+		WithSubsystemLevels(map[string]string{
+			"Manager":                       "WARN",
+			"workloadattestor.k8s.sigstore": "DEBUG",
+		}),
+	)
+	require.NoError(t, err)
+	logger.SetOutput(out)
+
+	// The logger level is lowered to the most verbose subsystem level
+	assert.Equal(t, logrus.DebugLevel, logger.GetLevel())
+
+	manager := logger.WithField("subsystem_name", "manager")
+	k8s := logger.WithFields(logrus.Fields{
+		"subsystem_name": "catalog",
+		"plugin_type":    "WorkloadAttestor",
+		"plugin_name":    "k8s",
+	})
+	sigstore := NewHCLogAdapter(k8s, "k8s").Named("sigstore")
+
+	logger.Debug("root debug")
+	logger.Info("root info")
+	manager.Info("manager info")
+	manager.Warn("manager warn")
+	k8s.Debug("k8s debug")
+	k8s.Info("k8s info")
+	sigstore.Debug("sigstore debug")
+
+	assert.Equal(t, []string{
+		"root info",
+		"manager warn",
+		"k8s info",
+		"sigstore debug",
+	}, loggedMessages(t, out))
+
+	// Overrides can be replaced at runtime
+	require.NoError(t, logger.SetSubsystemLevels(map[string]logrus.Level{
+		"workloadattestor.k8s": logrus.DebugLevel,
+	}))
+	assert.Equal(t, logrus.DebugLevel, logger.GetLevel())
+
+	out.Reset()
+	manager.Info("manager info")
+	k8s.Debug("k8s debug")
+	sigstore.Debug("sigstore debug")
+	logger.Debug("root debug")
+	assert.Equal(t, []string{
+		"manager info",
+		"k8s debug",
+		"sigstore debug",
+	}, loggedMessages(t, out))
+
+	level, levels, err := logger.SubsystemLevels()
+	require.NoError(t, err)
+	assert.Equal(t, logrus.InfoLevel, level)
+	assert.Equal(t, map[string]logrus.Level{"workloadattestor.k8s": logrus.DebugLevel}, levels)
+
+	// Removing the overrides restores the base level
+	require.NoError(t, logger.SetSubsystemLevels(nil))
+	assert.Equal(t, logrus.InfoLevel, logger.GetLevel())
+}
+
+func TestSubsystemLevelsWithoutOverrides(t *testing.T) {
+	out := new(bytes.Buffer)
+	logger, err := NewLogger(
+		WithLevel("INFO"),
+		WithFormat(JSONFormat),
+		WithSubsystemLevels(nil),
+	)
+	require.NoError(t, err)
+	logger.SetOutput(out)
+	assert.Equal(t, logrus.InfoLevel, logger.GetLevel())
+
+	// Entries are not filtered until overrides are set
+	assert.IsType(t, &logrus.JSONFormatter{}, logger.Formatter)
+
+	logger.Debug("root debug")
+	logger.Info("root info")
+	assert.Equal(t, []string{"root info"}, loggedMessages(t, out))
+
+	// Overrides can be added at runtime
+	require.NoError(t, logger.SetSubsystemLevels(map[string]logrus.Level{
+		"manager": logrus.DebugLevel,
+	}))
+	out.Reset()
+	logger.Debug("root debug")
+	logger.WithField("subsystem_name", "manager").Debug("manager debug")
+	assert.Equal(t, []string{"manager debug"}, loggedMessages(t, out))
+}
+
+func TestSubsystemLevelsOutputRedactedEntries(t *testing.T) {
+	out := new(bytes.Buffer)
+	logger, err := NewLogger(
+		WithFormat(JSONFormat),
+		WithSubsystemLevels(nil),
+		WithRedaction([]string{"spiffe_id"}, func(string) string { return "redacted" }),
+	)
+	require.NoError(t, err)
+	logger.SetOutput(out)
+
+	logger.WithField("spiffe_id", "spiffe://example.org/workload").Info("hello")
+	assert.Contains(t, out.String(), `"spiffe_id":"redacted"`)
+}
+
+func TestSubsystemLevelsInvalidLevel(t *testing.T) {
+	_, err := NewLogger(WithSubsystemLevels(map[string]string{"manager": "LOUD"}))
+	require.EqualError(t, err, `invalid log level for subsystem "manager": not a valid logrus Level: "LOUD"`)
+}
+
+func TestSubsystemLevelsNotEnabled(t *testing.T) {
+	logger, err := NewLogger()
+	require.NoError(t, err)
+
+	err = logger.SetSubsystemLevels(map[string]logrus.Level{"manager": logrus.DebugLevel})
+	require.EqualError(t, err, "subsystem log levels are not enabled")
+
+	_, _, err = logger.SubsystemLevels()
+	require.EqualError(t, err, "subsystem log levels are not enabled")
+}
+
+func loggedMessages(t *testing.T, out *bytes.Buffer) []string {
+	var msgs []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var data map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &data))
+		msgs = append(msgs, data["msg"].(string))
+	}
+	return msgs
+}
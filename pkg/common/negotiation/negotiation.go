@@ -0,0 +1,206 @@
+// Package negotiation lets agents and servers advertise their version, the
+// version of the sync protocol and the optional features they support in the
+// gRPC metadata, so that in fleets running mixed versions, e.g. during
+// upgrades, only the features both sides support are used.
+//
+// Agents advertise themselves in the request metadata and servers in the
+// response header. Peers that do not advertise themselves, like older
+// versions, are assumed to support no optional features.
+package negotiation
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spiffe/spire/pkg/common/version"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// SyncProtocolVersion is the version of the protocol agents use to
+	// sync entries and SVIDs with the server.
+	SyncProtocolVersion = 1
+
+	agentVersionKey       = "spire-agent-version"
+	agentSyncProtocolKey  = "spire-agent-sync-protocol"
+	agentFeaturesKey      = "spire-agent-features"
+	serverVersionKey      = "spire-server-version"
+	serverSyncProtocolKey = "spire-server-sync-protocol"
+	serverFeaturesKey     = "spire-server-features"
+)
+
+// Feature is an optional protocol feature.
+type Feature string
+
+const (
+	// Gzip is the compression of requests and responses with gzip.
+	Gzip Feature = Feature(gzip.Name)
+)
+
+// Supported returns the features supported by this build.
+func Supported() []Feature {
+	return []Feature{Gzip}
+}
+
+// Peer is what a peer advertised about itself.
+type Peer struct {
+	// Version is the SPIRE version of the peer.
+	Version string
+
+	// SyncProtocolVersion is the version of the sync protocol the peer
+	// speaks. Zero if the peer did not advertise it.
+	SyncProtocolVersion int
+
+	// Features is the set of optional features the peer supports.
+	Features map[Feature]bool
+}
+
+// Supports returns true if the peer supports the feature.
+func (p *Peer) Supports(feature Feature) bool {
+	return p != nil && p.Features[feature]
+}
+
+func self() Peer {
+	features := make(map[Feature]bool)
+	for _, feature := range Supported() {
+		features[feature] = true
+	}
+	return Peer{
+		Version:             version.Version(),
+		SyncProtocolVersion: SyncProtocolVersion,
+		Features:            features,
+	}
+}
+
+func (p Peer) pairs(versionKey, syncProtocolKey, featuresKey string) []string {
+	features := make([]string, 0, len(p.Features))
+	for feature, supported := range p.Features {
+		if supported {
+			features = append(features, string(feature))
+		}
+	}
+	sort.Strings(features)
+	return []string{
+		versionKey, p.Version,
+		syncProtocolKey, strconv.Itoa(p.SyncProtocolVersion),
+		featuresKey, strings.Join(features, ","),
+	}
+}
+
+func peerFromMD(md metadata.MD, versionKey, syncProtocolKey, featuresKey string) (*Peer, bool) {
+	versions := md.Get(versionKey)
+	if len(versions) != 1 {
+		return nil, false
+	}
+	peer := &Peer{
+		Version:  versions[0],
+		Features: make(map[Feature]bool),
+	}
+	if values := md.Get(syncProtocolKey); len(values) == 1 {
+		peer.SyncProtocolVersion, _ = strconv.Atoi(values[0])
+	}
+	for _, value := range md.Get(featuresKey) {
+		for _, feature := range strings.Split(value, ",") {
+			if feature != "" {
+				peer.Features[Feature(feature)] = true
+			}
+		}
+	}
+	return peer, true
+}
+
+// AgentFromIncomingContext returns what the agent advertised in the
+// incoming request metadata, if anything.
+func AgentFromIncomingContext(ctx context.Context) (*Peer, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return peerFromMD(md, agentVersionKey, agentSyncProtocolKey, agentFeaturesKey)
+}
+
+// ServerHeader returns the response header the server advertises itself
+// with.
+func ServerHeader() metadata.MD {
+	return metadata.Pairs(self().pairs(serverVersionKey, serverSyncProtocolKey, serverFeaturesKey)...)
+}
+
+// Client negotiates features with the servers on behalf of an agent. It
+// advertises the agent on every call and keeps what the servers answering
+// unary calls advertised. Since the calls of a connection can be balanced
+// across servers running different versions, calls that are refused because
+// of a negotiated feature are retried once without it.
+type Client struct {
+	pairs []string
+
+	mtx    sync.RWMutex
+	server *Peer
+}
+
+// NewClient returns a new client.
+func NewClient() *Client {
+	return &Client{
+		pairs: self().pairs(agentVersionKey, agentSyncProtocolKey, agentFeaturesKey),
+	}
+}
+
+// Server returns what the server last advertised, or nil if it advertised
+// nothing.
+func (c *Client) Server() *Peer {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.server
+}
+
+func (c *Client) setServer(server *Peer) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.server = server
+}
+
+func (c *Client) callOptions(opts []grpc.CallOption) ([]grpc.CallOption, bool) {
+	if !c.Server().Supports(Gzip) {
+		return opts, false
+	}
+	return append(opts[:len(opts):len(opts)], grpc.UseCompressor(gzip.Name)), true
+}
+
+// UnaryClientInterceptor returns an interceptor that negotiates features on
+// unary calls.
+func (c *Client) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, c.pairs...)
+
+		var header metadata.MD
+		callOpts, compressed := c.callOptions(append(opts[:len(opts):len(opts)], grpc.Header(&header)))
+		err := invoker(ctx, method, req, reply, cc, callOpts...)
+		if compressed && status.Code(err) == codes.Unimplemented {
+			// The server answering the call may not support compression
+			// even if the server that answered the previous call did.
+			header = nil
+			err = invoker(ctx, method, req, reply, cc, append(opts[:len(opts):len(opts)], grpc.Header(&header))...)
+		}
+
+		server, _ := peerFromMD(header, serverVersionKey, serverSyncProtocolKey, serverFeaturesKey)
+		if err == nil || server != nil {
+			c.setServer(server)
+		}
+		return err
+	}
+}
+
+// StreamClientInterceptor returns an interceptor that advertises the agent
+// on streaming calls. Since streams cannot be retried, negotiated features
+// are not used on them.
+func (c *Client) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(metadata.AppendToOutgoingContext(ctx, c.pairs...), desc, cc, method, opts...)
+	}
+}
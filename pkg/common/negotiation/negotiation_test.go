@@ -0,0 +1,102 @@
+package negotiation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spiffe/spire/pkg/common/version"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAgentFromIncomingContext(t *testing.T) {
+	_, ok := AgentFromIncomingContext(context.Background())
+	require.False(t, ok)
+
+	_, ok = AgentFromIncomingContext(metadata.NewIncomingContext(context.Background(), metadata.Pairs("other", "value")))
+	require.False(t, ok)
+
+	md := metadata.Pairs(agentVersionKey, "1.5.0", agentSyncProtocolKey, "2", agentFeaturesKey, "gzip,other")
+	agent, ok := AgentFromIncomingContext(metadata.NewIncomingContext(context.Background(), md))
+	require.True(t, ok)
+	require.Equal(t, &Peer{
+		Version:             "1.5.0",
+		SyncProtocolVersion: 2,
+		Features:            map[Feature]bool{Gzip: true, "other": true},
+	}, agent)
+	require.True(t, agent.Supports(Gzip))
+	require.False(t, agent.Supports("unknown"))
+}
+
+func TestClientUnaryInterceptor(t *testing.T) {
+	client := NewClient()
+	interceptor := client.UnaryClientInterceptor()
+
+	type call struct {
+		compressed bool
+		agent      *Peer
+	}
+	var calls []call
+	invoker := func(header metadata.MD, err func(compressed bool) error) grpc.UnaryInvoker {
+		return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			agent, _ := AgentFromIncomingContext(metadata.NewIncomingContext(ctx, md))
+			c := call{agent: agent}
+			for _, opt := range opts {
+				switch opt := opt.(type) {
+				case grpc.HeaderCallOption:
+					*opt.HeaderAddr = header
+				case grpc.CompressorCallOption:
+					c.compressed = opt.CompressorType == string(Gzip)
+				}
+			}
+			calls = append(calls, c)
+			if err != nil {
+				return err(c.compressed)
+			}
+			return nil
+		}
+	}
+	invoke := func(inv grpc.UnaryInvoker) error {
+		calls = nil
+		return interceptor(context.Background(), "/method", nil, nil, nil, inv)
+	}
+	self := self()
+
+	// The server does not advertise itself, so nothing is negotiated
+	require.NoError(t, invoke(invoker(nil, nil)))
+	require.Equal(t, []call{{agent: &self}}, calls)
+	require.Nil(t, client.Server())
+
+	// The server advertises compression support
+	require.NoError(t, invoke(invoker(ServerHeader(), nil)))
+	require.Equal(t, []call{{agent: &self}}, calls)
+	require.Equal(t, version.Version(), client.Server().Version)
+	require.Equal(t, SyncProtocolVersion, client.Server().SyncProtocolVersion)
+	require.True(t, client.Server().Supports(Gzip))
+
+	// Calls are compressed from then on
+	require.NoError(t, invoke(invoker(ServerHeader(), nil)))
+	require.Equal(t, []call{{agent: &self, compressed: true}}, calls)
+
+	// Errors that are not caused by the negotiated features are not retried
+	require.EqualError(t, invoke(invoker(nil, func(bool) error {
+		return status.Error(codes.Unavailable, "unavailable")
+	})), "rpc error: code = Unavailable desc = unavailable")
+	require.Equal(t, []call{{agent: &self, compressed: true}}, calls)
+	require.True(t, client.Server().Supports(Gzip))
+
+	// A server that does not support compression answers the call, which is
+	// retried uncompressed
+	require.NoError(t, invoke(invoker(nil, func(compressed bool) error {
+		if compressed {
+			return status.Error(codes.Unimplemented, "grpc: Decompressor is not installed for grpc-encoding \"gzip\"")
+		}
+		return nil
+	})))
+	require.Equal(t, []call{{agent: &self, compressed: true}, {agent: &self}}, calls)
+	require.Nil(t, client.Server())
+}
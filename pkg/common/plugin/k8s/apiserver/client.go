@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	authv1 "k8s.io/api/authentication/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -21,6 +23,12 @@ type Client interface {
 	// GetPod returns the pod object for the given pod name and namespace
 	GetPod(ctx context.Context, namespace, podName string) (*v1.Pod, error)
 
+	// ListNodePods returns the pods scheduled to the given node
+	ListNodePods(ctx context.Context, nodeName string) (*v1.PodList, error)
+
+	// GetNamespace returns the namespace object for the given namespace name
+	GetNamespace(ctx context.Context, namespace string) (*v1.Namespace, error)
+
 	// ValidateToken queries k8s token review API and returns information about the given token
 	ValidateToken(ctx context.Context, token string, audiences []string) (*authv1.TokenReviewStatus, error)
 }
@@ -43,6 +51,44 @@ func New(kubeConfigFilePath string) Client {
 	}
 }
 
+// NewInCluster creates a new Client using the in-cluster configuration.
+// Unlike New, the clientset is loaded once and reused, along with its
+// connections, for every query, which suits clients querying the API server
+// often. The service account token is still read again by the clientset as
+// it is rotated.
+func NewInCluster() Client {
+	return &client{
+		loadClientHook: reuseClientset(loadClient),
+	}
+}
+
+// APIServerConfig configures a Client that connects to an API server
+// directly, without a kubeconfig file.
+type APIServerConfig struct {
+	// URL is the URL of the API server.
+	URL string
+
+	// CAFile is the path to the CA certificates used to authenticate the
+	// API server. If empty, the system roots are used.
+	CAFile string
+
+	// TokenFile is the path to a bearer token used to authenticate to the API
+	// server. The token is read again as it changes, so short-lived tokens,
+	// like those obtained through workload identity federation, can be
+	// refreshed by an external process.
+	TokenFile string
+}
+
+// NewWithAPIServer creates a new Client that connects to the API server
+// described by the given configuration.
+func NewWithAPIServer(config APIServerConfig) Client {
+	return &client{
+		loadClientHook: func(string) (kubernetes.Interface, error) {
+			return loadAPIServerClient(config)
+		},
+	}
+}
+
 func (c *client) GetPod(ctx context.Context, namespace, podName string) (*v1.Pod, error) {
 	// Validate inputs
 	if namespace == "" {
@@ -71,6 +117,58 @@ func (c *client) GetPod(ctx context.Context, namespace, podName string) (*v1.Pod
 	return pod, nil
 }
 
+func (c *client) ListNodePods(ctx context.Context, nodeName string) (*v1.PodList, error) {
+	// Validate inputs
+	if nodeName == "" {
+		return nil, errors.New("empty node name")
+	}
+
+	// Reload config
+	clientset, err := c.loadClientHook(c.kubeConfigFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get clientset: %w", err)
+	}
+
+	// List the pods of the node only, across all namespaces
+	podList, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query pods API: %w", err)
+	}
+
+	if podList == nil {
+		return nil, fmt.Errorf("got nil pod list for node name: %v", nodeName)
+	}
+
+	return podList, nil
+}
+
+func (c *client) GetNamespace(ctx context.Context, namespace string) (*v1.Namespace, error) {
+	// Validate inputs
+	if namespace == "" {
+		return nil, errors.New("empty namespace")
+	}
+
+	// Reload config
+	clientset, err := c.loadClientHook(c.kubeConfigFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get clientset: %w", err)
+	}
+
+	// Get namespace
+	ns, err := clientset.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to query namespaces API: %w", err)
+	}
+
+	if ns == nil {
+		return nil, fmt.Errorf("got nil namespace for namespace name: %v", namespace)
+	}
+
+	return ns, nil
+}
+
 func (c *client) GetNode(ctx context.Context, nodeName string) (*v1.Node, error) {
 	// Validate inputs
 	if nodeName == "" {
@@ -129,6 +227,26 @@ func (c *client) ValidateToken(ctx context.Context, token string, audiences []st
 	return &resp.Status, nil
 }
 
+// reuseClientset wraps a clientset loader so the clientset is loaded once,
+// retrying until it loads successfully, and reused afterwards.
+func reuseClientset(load func(string) (kubernetes.Interface, error)) func(string) (kubernetes.Interface, error) {
+	var mtx sync.Mutex
+	var clientset kubernetes.Interface
+	return func(kubeConfigFilePath string) (kubernetes.Interface, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		if clientset == nil {
+			loaded, err := load(kubeConfigFilePath)
+			if err != nil {
+				return nil, err
+			}
+			clientset = loaded
+		}
+		return clientset, nil
+	}
+}
+
 func loadClient(kubeConfigFilePath string) (kubernetes.Interface, error) {
 	var config *rest.Config
 	var err error
@@ -149,3 +267,24 @@ func loadClient(kubeConfigFilePath string) (kubernetes.Interface, error) {
 
 	return clientset, nil
 }
+
+func loadAPIServerClient(apiServer APIServerConfig) (kubernetes.Interface, error) {
+	if apiServer.URL == "" {
+		return nil, errors.New("unable to create client config: API server URL is required")
+	}
+
+	config := &rest.Config{
+		Host:            apiServer.URL,
+		BearerTokenFile: apiServer.TokenFile,
+		TLSClientConfig: rest.TLSClientConfig{
+			CAFile: apiServer.CAFile,
+		},
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create clientset for the given config: %w", err)
+	}
+
+	return clientset, nil
+}
@@ -130,6 +130,33 @@ func (s *ClientSuite) SetupTest() {
 	s.dir = s.TempDir()
 }
 
+func (s *ClientSuite) TestReuseClientset() {
+	fakeClient := fake.NewSimpleClientset()
+	loads := 0
+	fail := true
+	load := reuseClientset(func(string) (kubernetes.Interface, error) {
+		loads++
+		if fail {
+			return nil, errors.New("oh no")
+		}
+		return fakeClient, nil
+	})
+
+	// Failures are not reused
+	_, err := load("")
+	s.EqualError(err, "oh no")
+
+	fail = false
+	clientset, err := load("")
+	s.NoError(err)
+	s.Same(fakeClient, clientset)
+
+	clientset, err = load("")
+	s.NoError(err)
+	s.Same(fakeClient, clientset)
+	s.Equal(2, loads)
+}
+
 func (s *ClientSuite) TestGetPodFailsIfNamespaceIsEmpty() {
 	client := New("")
 	pod, err := client.GetPod(ctx, "", "POD-NAME")
@@ -183,6 +210,83 @@ func (s *ClientSuite) TestGetPodSucceeds() {
 	s.Equal(expectedPod, pod)
 }
 
+func (s *ClientSuite) TestListNodePodsFailsIfNodeNameIsEmpty() {
+	client := New("")
+	podList, err := client.ListNodePods(ctx, "")
+	s.AssertErrorContains(err, "empty node name")
+	s.Nil(podList)
+}
+
+func (s *ClientSuite) TestListNodePodsFailsToLoadClient() {
+	client := s.createDefectiveClient("")
+	podList, err := client.ListNodePods(ctx, "NODENAME")
+	s.AssertErrorContains(err, "unable to get clientset")
+	s.Nil(podList)
+}
+
+func (s *ClientSuite) TestListNodePodsFailsIfGetsErrorFromAPIServer() {
+	fakeClient := fake.NewSimpleClientset()
+	fakeClient.CoreV1().(*fake_corev1.FakeCoreV1).PrependReactor("list", "pods",
+		func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
+			return true, nil, errors.New("an error")
+		})
+
+	client := s.createClient(fakeClient)
+	podList, err := client.ListNodePods(ctx, "NODENAME")
+	s.AssertErrorContains(err, "unable to query pods API")
+	s.Nil(podList)
+}
+
+func (s *ClientSuite) TestListNodePodsSucceeds() {
+	fakeClient := fake.NewSimpleClientset()
+	fakeClient.CoreV1().(*fake_corev1.FakeCoreV1).PrependReactor("list", "pods",
+		func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
+			// The pods are filtered by the API server using the field selector
+			restrictions := action.(k8stesting.ListAction).GetListRestrictions()
+			s.Equal("spec.nodeName=NODENAME", restrictions.Fields.String())
+			s.Equal("", action.GetNamespace())
+			return true, &v1.PodList{Items: []v1.Pod{*createPod("PODNAME", "NAMESPACE")}}, nil
+		})
+
+	client := s.createClient(fakeClient)
+	podList, err := client.ListNodePods(ctx, "NODENAME")
+	s.NoError(err)
+	s.Equal(&v1.PodList{Items: []v1.Pod{*createPod("PODNAME", "NAMESPACE")}}, podList)
+}
+
+func (s *ClientSuite) TestGetNamespaceFailsIfNamespaceIsEmpty() {
+	client := New("")
+	ns, err := client.GetNamespace(ctx, "")
+	s.AssertErrorContains(err, "empty namespace")
+	s.Nil(ns)
+}
+
+func (s *ClientSuite) TestGetNamespaceFailsToLoadClient() {
+	client := s.createDefectiveClient("")
+	ns, err := client.GetNamespace(ctx, "NAMESPACE")
+	s.AssertErrorContains(err, "unable to get clientset")
+	s.Nil(ns)
+}
+
+func (s *ClientSuite) TestGetNamespaceFailsIfGetsErrorFromAPIServer() {
+	fakeClient := fake.NewSimpleClientset()
+
+	client := s.createClient(fakeClient)
+	ns, err := client.GetNamespace(ctx, "NAMESPACE")
+	s.AssertErrorContains(err, "unable to query namespaces API")
+	s.Nil(ns)
+}
+
+func (s *ClientSuite) TestGetNamespaceSucceeds() {
+	fakeClient := fake.NewSimpleClientset(createNamespace("NAMESPACE"))
+	expectedNamespace := createNamespace("NAMESPACE")
+
+	client := s.createClient(fakeClient)
+	ns, err := client.GetNamespace(ctx, "NAMESPACE")
+	s.NoError(err)
+	s.Equal(expectedNamespace, ns)
+}
+
 func (s *ClientSuite) TestGetNodeFailsIfNodeNameIsEmpty() {
 	client := New("")
 	node, err := client.GetNode(ctx, "")
@@ -304,6 +408,27 @@ func (s *ClientSuite) TestLoadClientSucceds() {
 	s.NotNil(clientset)
 }
 
+func (s *ClientSuite) TestLoadAPIServerClientFailsWithoutURL() {
+	clientset, err := loadAPIServerClient(APIServerConfig{})
+	s.AssertErrorContains(err, "unable to create client config: API server URL is required")
+	s.Nil(clientset)
+}
+
+func (s *ClientSuite) TestLoadAPIServerClientSucceeds() {
+	caPath := filepath.Join(s.dir, "api-server-ca.crt")
+	s.Require().NoError(os.WriteFile(caPath, kubeConfigCA, 0600))
+	tokenPath := filepath.Join(s.dir, "token")
+	s.Require().NoError(os.WriteFile(tokenPath, []byte("TOKEN"), 0600))
+
+	clientset, err := loadAPIServerClient(APIServerConfig{
+		URL:       "https://api-server.example.org:6443",
+		CAFile:    caPath,
+		TokenFile: tokenPath,
+	})
+	s.NoError(err)
+	s.NotNil(clientset)
+}
+
 func (s *ClientSuite) createClient(fakeClient kubernetes.Interface) Client {
 	fakeLoadClient := func(kubeConfigFilePath string) (kubernetes.Interface, error) {
 		return fakeClient, nil
@@ -330,6 +455,12 @@ func createPod(podName, namespace string) *v1.Pod {
 	return p
 }
 
+func createNamespace(name string) *v1.Namespace {
+	ns := &v1.Namespace{}
+	ns.Name = name
+	return ns
+}
+
 func createNode(nodeName string) *v1.Node {
 	n := &v1.Node{}
 	n.Name = nodeName
@@ -30,9 +30,10 @@ var DefaultAgentPathTemplate = agentpathtemplate.MustParse("/{{ .PluginName }}/{
 
 type agentPathTemplateData struct {
 	*x509.Certificate
-	Fingerprint string
-	PluginName  string
-	TrustDomain string
+	Fingerprint     string
+	PluginName      string
+	SerialNumberHex string
+	TrustDomain     string
 }
 
 type AttestationData struct {
@@ -266,11 +267,16 @@ func Fingerprint(cert *x509.Certificate) string {
 
 // MakeAgentID creates an agent ID from X.509 certificate data.
 func MakeAgentID(td spiffeid.TrustDomain, agentPathTemplate *agentpathtemplate.Template, cert *x509.Certificate) (spiffeid.ID, error) {
-	agentPath, err := agentPathTemplate.Execute(agentPathTemplateData{
+	data := agentPathTemplateData{
 		Certificate: cert,
 		PluginName:  PluginName,
 		Fingerprint: Fingerprint(cert),
-	})
+		TrustDomain: td.String(),
+	}
+	if cert.SerialNumber != nil {
+		data.SerialNumberHex = cert.SerialNumber.Text(16)
+	}
+	agentPath, err := agentPathTemplate.Execute(data)
 	if err != nil {
 		return spiffeid.ID{}, err
 	}
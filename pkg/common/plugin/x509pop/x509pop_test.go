@@ -8,6 +8,7 @@ import (
 	"crypto/x509/pkix"
 	"encoding/pem"
 	"math/big"
+	"net/url"
 	"testing"
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
@@ -146,6 +147,21 @@ func TestMakeAgentID(t *testing.T) {
 			template: agentpathtemplate.MustParse("/foo/{{ .Subject.CommonName }}"),
 			expectID: "spiffe://example.org/spire/agent/foo/test-cert",
 		},
+		{
+			desc:     "custom template with serial number and trust domain",
+			template: agentpathtemplate.MustParse("/{{ .TrustDomain }}/{{ .SerialNumberHex }}/{{ .SerialNumber }}"),
+			expectID: "spiffe://example.org/spire/agent/example.org/3039/12345",
+		},
+		{
+			desc:     "custom template with SANs",
+			template: agentpathtemplate.MustParse("/{{ index .DNSNames 0 }}{{ (index .URIs 0).Path }}"),
+			expectID: "spiffe://example.org/spire/agent/node.example.org/rack/12",
+		},
+		{
+			desc:      "custom template with missing SANs",
+			template:  agentpathtemplate.MustParse("/{{ index .EmailAddresses 0 }}"),
+			expectErr: "error calling index",
+		},
 		{
 			desc:      "custom template with nonexistant fields",
 			template:  agentpathtemplate.MustParse("/{{ .Foo }}"),
@@ -160,6 +176,9 @@ func TestMakeAgentID(t *testing.T) {
 				Subject: pkix.Name{
 					CommonName: "test-cert",
 				},
+				SerialNumber: big.NewInt(12345),
+				DNSNames:     []string{"node.example.org"},
+				URIs:         []*url.URL{{Scheme: "urn", Path: "/rack/12"}},
 			}
 			id, err := MakeAgentID(spiffeid.RequireTrustDomainFromString("example.org"), tt.template, cert)
 			if tt.expectErr != "" {
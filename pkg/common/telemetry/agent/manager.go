@@ -45,4 +45,16 @@ func AddCacheManagerOutdatedSVIDsSample(m telemetry.Metrics, cacheType string, c
 	m.AddSample(key, count)
 }
 
+// AddCacheManagerShedSVIDPrefetchesSample count of SVIDs not prefetched by
+// the agent cache manager because the agent is under resource pressure
+func AddCacheManagerShedSVIDPrefetchesSample(m telemetry.Metrics, count float32) {
+	m.AddSample([]string{telemetry.CacheManager, telemetry.ShedSVIDPrefetches}, count)
+}
+
+// AddCacheManagerUnusedEntriesSample count of cached registration entries
+// whose SVIDs were never fetched by a workload since the agent started
+func AddCacheManagerUnusedEntriesSample(m telemetry.Metrics, count float32) {
+	m.AddSample([]string{telemetry.CacheManager, telemetry.UnusedEntries}, count)
+}
+
 // End Add Samples
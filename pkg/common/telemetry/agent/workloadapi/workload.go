@@ -1,6 +1,8 @@
 package workloadapi
 
 import (
+	"time"
+
 	"github.com/spiffe/spire/pkg/common/telemetry"
 )
 
@@ -37,6 +39,12 @@ func SetConnectionTotalGauge(m telemetry.Metrics, connections int32) {
 	m.SetGauge([]string{telemetry.WorkloadAPI, telemetry.Connections}, float32(connections))
 }
 
+// SetQueuedAttestationsGauge sets the number of workload attestations
+// waiting for the maximum number of concurrent attestations to allow them
+func SetQueuedAttestationsGauge(m telemetry.Metrics, queued int) {
+	m.SetGauge([]string{telemetry.WorkloadAPI, telemetry.WorkloadAttestation, telemetry.Queued}, float32(queued))
+}
+
 // End Counters
 
 // Add Samples (metric on count of some object, entries, event...)
@@ -48,3 +56,13 @@ func AddDiscoveredSelectorsSample(m telemetry.Metrics, count float32) {
 }
 
 // End Add Samples
+
+// Measure Since
+
+// MeasureAttestationQueueTime measures the time a workload attestation
+// waited for the maximum number of concurrent attestations to allow it
+func MeasureAttestationQueueTime(m telemetry.Metrics, start time.Time) {
+	m.MeasureSince([]string{telemetry.WorkloadAPI, telemetry.WorkloadAttestation, telemetry.QueueTime}, start)
+}
+
+// End Measure Since
@@ -23,6 +23,15 @@ type FileConfig struct {
 	AllowedLabels   []string `hcl:"AllowedLabels"`   // A list of metric labels to allow, with '.' as the separator
 	BlockedLabels   []string `hcl:"BlockedLabels"`   // A list of metric labels to block, with '.' as the separator
 
+	// RedactionMode, if set, redacts the SPIFFE IDs and selectors in the
+	// metric labels, and in the logs on the server, either by hashing
+	// (RedactHash) or truncating them (RedactTruncate).
+	RedactionMode string `hcl:"RedactionMode"`
+
+	// RedactionKeyFile is the path to the file holding the secret key the
+	// values are hashed with, required by RedactHash.
+	RedactionKeyFile string `hcl:"RedactionKeyFile"`
+
 	UnusedKeys []string `hcl:",unusedKeys"`
 }
 
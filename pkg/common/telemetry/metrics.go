@@ -42,8 +42,9 @@ type Metrics interface {
 type MetricsImpl struct {
 	*metrics.Metrics
 
-	c       *MetricsConfig
-	runners []sinkRunner
+	c        *MetricsConfig
+	redactor *Redactor
+	runners  []sinkRunner
 	// Each instance of metrics.Metrics in the slice corresponds to one metrics sink type
 	metricsSinks []*metrics.Metrics
 }
@@ -58,6 +59,14 @@ func NewMetrics(c *MetricsConfig) (*MetricsImpl, error) {
 
 	impl := &MetricsImpl{c: c}
 
+	if c.FileConfig.RedactionMode != "" {
+		redactor, err := NewRedactorFromConfig(&c.FileConfig)
+		if err != nil {
+			return nil, err
+		}
+		impl.redactor = redactor
+	}
+
 	for _, f := range sinkRunnerFactories {
 		runner, err := f(c)
 		if err != nil {
@@ -110,7 +119,7 @@ func (m *MetricsImpl) SetGauge(key []string, val float32) {
 
 // SetGaugeWithLabels delegates to embedded metrics, sanitizing labels
 func (m *MetricsImpl) SetGaugeWithLabels(key []string, val float32, labels []Label) {
-	sanitizedLabels := SanitizeLabels(labels)
+	sanitizedLabels := m.sanitizeLabels(labels)
 	for _, s := range m.metricsSinks {
 		s.SetGaugeWithLabels(key, val, sanitizedLabels)
 	}
@@ -130,7 +139,7 @@ func (m *MetricsImpl) IncrCounter(key []string, val float32) {
 
 // IncrCounterWithLabels delegates to embedded metrics, sanitizing labels
 func (m *MetricsImpl) IncrCounterWithLabels(key []string, val float32, labels []Label) {
-	sanitizedLabels := SanitizeLabels(labels)
+	sanitizedLabels := m.sanitizeLabels(labels)
 	for _, s := range m.metricsSinks {
 		s.IncrCounterWithLabels(key, val, sanitizedLabels)
 	}
@@ -144,7 +153,7 @@ func (m *MetricsImpl) AddSample(key []string, val float32) {
 
 // AddSampleWithLabels delegates to embedded metrics, sanitizing labels
 func (m *MetricsImpl) AddSampleWithLabels(key []string, val float32, labels []Label) {
-	sanitizedLabels := SanitizeLabels(labels)
+	sanitizedLabels := m.sanitizeLabels(labels)
 	for _, s := range m.metricsSinks {
 		s.AddSampleWithLabels(key, val, sanitizedLabels)
 	}
@@ -158,8 +167,17 @@ func (m *MetricsImpl) MeasureSince(key []string, start time.Time) {
 
 // MeasureSinceWithLabels delegates to embedded metrics, sanitizing labels
 func (m *MetricsImpl) MeasureSinceWithLabels(key []string, start time.Time, labels []Label) {
-	sanitizedLabels := SanitizeLabels(labels)
+	sanitizedLabels := m.sanitizeLabels(labels)
 	for _, s := range m.metricsSinks {
 		s.MeasureSinceWithLabels(key, start, sanitizedLabels)
 	}
 }
+
+// sanitizeLabels redacts the identifying labels, if configured, and
+// sanitizes the labels.
+func (m *MetricsImpl) sanitizeLabels(labels []Label) []Label {
+	if m.redactor != nil {
+		labels = m.redactor.RedactLabels(labels)
+	}
+	return SanitizeLabels(labels)
+}
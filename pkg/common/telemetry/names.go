@@ -18,6 +18,10 @@ const (
 	// should be used with other tags to add clarity
 	Append = "append"
 
+	// Approve functionality related to approving some entity; should be used
+	// with other tags to add clarity
+	Approve = "approve"
+
 	// Attest functionality related to attesting; should be used with other tags
 	// to add clarity
 	Attest = "attest"
@@ -34,6 +38,10 @@ const (
 	// to add clarity
 	Delete = "delete"
 
+	// Evict functionality related to evicting some entity; should be used with other tags
+	// to add clarity
+	Evict = "evict"
+
 	// Fetch functionality related to fetching some entity; should be used with other tags
 	// to add clarity
 	Fetch = "fetch"
@@ -86,6 +94,10 @@ const (
 	// to add clarity
 	Push = "push"
 
+	// Reject functionality related to rejecting a request for some entity; should be
+	// used with other tags to add clarity
+	Reject = "reject"
+
 	// Reload functionality related to reloading of a cache
 	Reload = "reload"
 
@@ -136,9 +148,15 @@ const (
 	// AdminIDs are admin IDs
 	AdminIDs = "admin_ids"
 
+	// Age tags the time elapsed since some event, in seconds
+	Age = "age"
+
 	// Agent SPIFFE ID
 	AgentID = "agent_id"
 
+	// AgentVersion tags the SPIRE version of an agent
+	AgentVersion = "agent_version"
+
 	// Attempt tags some count of attempts
 	Attempt = "attempt"
 
@@ -192,6 +210,10 @@ const (
 	// CGroupPath tags a linux CGroup path, most likely for use in attestation
 	CGroupPath = "cgroup_path"
 
+	// ClockSkew tags the skew between the clock of an agent and the clock of
+	// the server
+	ClockSkew = "clock_skew"
+
 	// Connection functionality related to some connection; should be used with other tags
 	// to add clarity
 	Connection = "connection"
@@ -230,6 +252,13 @@ const (
 	// Downstream tags if entry is a downstream
 	Downstream = "downstream"
 
+	// DownstreamDepth tags the 1-based level at which a downstream CA sits
+	// relative to the root server
+	DownstreamDepth = "downstream_depth"
+
+	// Duration tags some configured duration of time.
+	Duration = "duration"
+
 	// ElapsedTime tags some duration of time.
 	ElapsedTime = "elapsed_time"
 
@@ -240,6 +269,9 @@ const (
 	// non-error level.
 	Error = "error"
 
+	// Excess tags a count of items above a limit
+	Excess = "excess"
+
 	// Expect tags an expected value, as opposed to the one received. Message should clarify
 	// what kind of value was expected, and a different field should show the received value
 	Expect = "expect"
@@ -287,6 +319,9 @@ const (
 	// IDType tags some type of ID (eg. registration ID, SPIFFE ID...)
 	IDType = "id_type"
 
+	// IssuanceRecord tags a record of an issued certificate
+	IssuanceRecord = "issuance_record"
+
 	// IssuedAt tags an issuance timestamp
 	IssuedAt = "issued_at"
 
@@ -333,6 +368,9 @@ const (
 	// Peer ID is the SPIFFE ID of a peer
 	PeerID = "peer_id"
 
+	// Percentage tags some percentage
+	Percentage = "percentage"
+
 	// PID declares some process ID
 	PID = "pid"
 
@@ -357,6 +395,12 @@ const (
 	// Pruned flagging something has been pruned
 	Pruned = "pruned"
 
+	// Queued tags some count of things waiting in a queue
+	Queued = "queued"
+
+	// QueueTime tags the time something waited in a queue
+	QueueTime = "queue_time"
+
 	// ReadOnly tags something read-only
 	ReadOnly = "read_only"
 
@@ -420,6 +464,9 @@ const (
 	// Selectors tags some group of registration selector
 	Selectors = "selectors"
 
+	// SelectorSet tags a selector set referenced by registration entries
+	SelectorSet = "selector_set"
+
 	// SelectorsAdded labels some count of selectors that have been added to an entity
 	SelectorsAdded = "selectors_added"
 
@@ -435,6 +482,9 @@ const (
 	// SerialNumber tags a certificate serial number
 	SerialNumber = "serial_num"
 
+	// Size tags the size of some object, in bytes
+	Size = "size"
+
 	// Slot X509 CA Slot ID
 	Slot = "slot"
 
@@ -457,6 +507,9 @@ const (
 	// with other tags to add clarity
 	Subject = "subject"
 
+	// SubsystemLevels tags the log level overrides of the subsystems
+	SubsystemLevels = "subsystem_levels"
+
 	// SVIDResponseLatency tags latency for SVID response
 	SVIDResponseLatency = "svid_response_latency"
 
@@ -469,6 +522,12 @@ const (
 	// SVIDUpdated tags that for some entity the SVID was updated
 	SVIDUpdated = "svid_updated"
 
+	// Threshold tags a limit that, when exceeded, triggers some behavior
+	Threshold = "threshold"
+
+	// ThresholdExceeded tags that some limit was exceeded
+	ThresholdExceeded = "threshold_exceeded"
+
 	// TTL functionality related to a time-to-live field; should be used
 	// with other tags to add clarity
 	TTL = "ttl"
@@ -518,9 +577,19 @@ const (
 // module in their own right, rather than descriptive of other
 // entities or modules
 const (
+	// ACMECacheEntry tags data cached by the bundle endpoint ACME client
+	ACMECacheEntry = "acme_cache_entry"
+
+	// AgentEvictor functionality related to evicting agents when the number
+	// of attested agents exceeds the configured maximum
+	AgentEvictor = "agent_evictor"
+
 	// AgentSVID tag a node (agent) SVID
 	AgentSVID = "agent_svid"
 
+	// AttestationEvent tags a record of an agent attestation
+	AttestationEvent = "attestation_event"
+
 	// Attestor tags an attestor plugin/type (eg. gcp, aws...)
 	Attestor = "attestor"
 
@@ -553,6 +622,9 @@ const (
 	// Catalog functionality related to plugin catalog
 	Catalog = "catalog"
 
+	// Created tags something as created
+	Created = "created"
+
 	// Datastore functionality related to datastore plugin
 	Datastore = "datastore"
 
@@ -565,6 +637,10 @@ const (
 	// Entry tag for some stored entry
 	Entry = "entry"
 
+	// EntryMirror functionality related to mirroring entries to the server
+	// of a peer trust domain
+	EntryMirror = "entry_mirror"
+
 	// Event tag some event that has occurred, for a notifier, watcher, listener, etc.
 	Event = "event"
 
@@ -574,6 +650,14 @@ const (
 	// OutdatedSVIDs tags SVID with outdated attributes count/list
 	OutdatedSVIDs = "outdated_svids"
 
+	// ShedSVIDPrefetches tags SVIDs not prefetched because the agent is
+	// under resource pressure
+	ShedSVIDPrefetches = "shed_svid_prefetches"
+
+	// UnusedEntries tags cached entries whose SVIDs were never fetched by a
+	// workload
+	UnusedEntries = "unused_entries"
+
 	// FederatedBundle functionality related to a federated bundle; should be used
 	// with other tags to add clarity
 	FederatedBundle = "federated_bundle"
@@ -600,6 +684,10 @@ const (
 	// Method is the full name of the method invoked
 	Method = "method"
 
+	// MirroredEntry tags an entry mirrored to the server of a peer trust
+	// domain
+	MirroredEntry = "mirrored_entry"
+
 	// NewSVID functionality related to creation of a new SVID
 	NewSVID = "new_svid"
 
@@ -611,6 +699,9 @@ const (
 	// to add clarity
 	Notifier = "notifier"
 
+	// PendingAgent tags an agent awaiting approval by an administrator
+	PendingAgent = "pending_agent"
+
 	// ServerCA functionality related to a server CA; should be used with other tags
 	// to add clarity
 	ServerCA = "server_ca"
@@ -0,0 +1,147 @@
+package telemetry
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// RedactHash replaces identifying values with a truncated HMAC-SHA256
+	// keyed with a secret key, which keeps values distinguishable, e.g. to
+	// correlate log entries, without revealing them. The key prevents
+	// recovering values that are easy to guess by hashing the candidates.
+	RedactHash = "hash"
+
+	// RedactTruncate replaces identifying values with their least specific
+	// part: the trust domain of SPIFFE IDs and the type of selectors.
+	RedactTruncate = "truncate"
+
+	redactedSuffix  = "..."
+	redactHashBytes = 8
+
+	// minRedactionKeySize is the minimum size of the HMAC key, in bytes.
+	minRedactionKeySize = 32
+)
+
+// RedactedFields are the names of the log fields and metric labels holding
+// identifying workload data, i.e. SPIFFE IDs and selectors.
+var RedactedFields = []string{
+	AgentID,
+	BySelectors,
+	CallerID,
+	CsrSpiffeID,
+	DiscoveredSelectors,
+	EndpointSpiffeID,
+	ParentID,
+	PeerID,
+	Selector,
+	Selectors,
+	SPIFFEID,
+}
+
+// Redactor redacts identifying values in telemetry, for observability
+// pipelines that must not contain identifying workload data.
+type Redactor struct {
+	mode   string
+	key    []byte
+	fields map[string]bool
+}
+
+// NewRedactorFromConfig returns a redactor for the redaction mode of the
+// given configuration, reading the HMAC key from RedactionKeyFile.
+func NewRedactorFromConfig(c *FileConfig) (*Redactor, error) {
+	var key []byte
+	if c.RedactionKeyFile != "" {
+		var err error
+		key, err = os.ReadFile(c.RedactionKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read redaction key: %w", err)
+		}
+	}
+	return NewRedactor(c.RedactionMode, key)
+}
+
+// NewRedactor returns a redactor for the given mode, RedactHash or
+// RedactTruncate. The key is required by, and only used with, RedactHash.
+func NewRedactor(mode string, key []byte) (*Redactor, error) {
+	switch mode {
+	case RedactHash:
+		if len(key) < minRedactionKeySize {
+			return nil, fmt.Errorf("redaction mode %q requires a key of at least %d bytes", RedactHash, minRedactionKeySize)
+		}
+	case RedactTruncate:
+		if len(key) > 0 {
+			return nil, errors.New("a redaction key is only used by the hash redaction mode")
+		}
+	default:
+		return nil, fmt.Errorf("unknown redaction mode %q; must be one of %q or %q", mode, RedactHash, RedactTruncate)
+	}
+
+	fields := make(map[string]bool, len(RedactedFields))
+	for _, field := range RedactedFields {
+		fields[field] = true
+	}
+	return &Redactor{
+		mode:   mode,
+		key:    key,
+		fields: fields,
+	}, nil
+}
+
+// Redact returns the redacted value.
+func (r *Redactor) Redact(value string) string {
+	if value == "" {
+		return value
+	}
+	if r.mode == RedactHash {
+		mac := hmac.New(sha256.New, r.key)
+		_, _ = mac.Write([]byte(value))
+		return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil)[:redactHashBytes])
+	}
+	return truncate(value)
+}
+
+// IsRedacted returns true if values of the given log field or metric label
+// are redacted.
+func (r *Redactor) IsRedacted(name string) bool {
+	return r.fields[name]
+}
+
+// RedactLabels returns the labels with the values of the identifying labels
+// redacted. The labels are returned as is when none is identifying.
+func (r *Redactor) RedactLabels(labels []Label) []Label {
+	var redacted []Label
+	for i, label := range labels {
+		if !r.IsRedacted(label.Name) {
+			continue
+		}
+		if redacted == nil {
+			redacted = append([]Label(nil), labels...)
+		}
+		redacted[i].Value = r.Redact(label.Value)
+	}
+	if redacted == nil {
+		return labels
+	}
+	return redacted
+}
+
+// truncate keeps the trust domain of SPIFFE IDs, e.g. "spiffe://example.org/...",
+// and the type of selectors, e.g. "k8s:...".
+func truncate(value string) string {
+	if rest := strings.TrimPrefix(value, "spiffe://"); rest != value {
+		if i := strings.Index(rest, "/"); i >= 0 {
+			return "spiffe://" + rest[:i] + "/" + redactedSuffix
+		}
+		return value
+	}
+	if i := strings.Index(value, ":"); i >= 0 {
+		return value[:i+1] + redactedSuffix
+	}
+	return redactedSuffix
+}
@@ -0,0 +1,81 @@
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+
+	_, err := NewRedactor("encrypt", nil)
+	require.EqualError(t, err, `unknown redaction mode "encrypt"; must be one of "hash" or "truncate"`)
+
+	_, err = NewRedactor(RedactHash, nil)
+	require.EqualError(t, err, `redaction mode "hash" requires a key of at least 32 bytes`)
+
+	_, err = NewRedactor(RedactHash, key[:16])
+	require.EqualError(t, err, `redaction mode "hash" requires a key of at least 32 bytes`)
+
+	_, err = NewRedactor(RedactTruncate, key)
+	require.EqualError(t, err, "a redaction key is only used by the hash redaction mode")
+
+	hash, err := NewRedactor(RedactHash, key)
+	require.NoError(t, err)
+	assert.Equal(t, "hmac-sha256:cd1887dea5dd65c7", hash.Redact("spiffe://example.org/workload"))
+	assert.Equal(t, hash.Redact("spiffe://example.org/workload"), hash.Redact("spiffe://example.org/workload"))
+	assert.NotEqual(t, hash.Redact("spiffe://example.org/workload"), hash.Redact("spiffe://example.org/other"))
+	assert.Equal(t, "", hash.Redact(""))
+
+	otherKey, err := NewRedactor(RedactHash, []byte("fedcba9876543210fedcba9876543210"))
+	require.NoError(t, err)
+	assert.NotEqual(t, hash.Redact("spiffe://example.org/workload"), otherKey.Redact("spiffe://example.org/workload"))
+
+	truncate, err := NewRedactor(RedactTruncate, nil)
+	require.NoError(t, err)
+	for in, expect := range map[string]string{
+		"spiffe://example.org/ns/default/sa/workload": "spiffe://example.org/...",
+		"spiffe://example.org":                        "spiffe://example.org",
+		"k8s:ns:default":                              "k8s:...",
+		"no-separator":                                "...",
+		"":                                            "",
+	} {
+		assert.Equal(t, expect, truncate.Redact(in), "input: %q", in)
+	}
+}
+
+func TestNewRedactorFromConfig(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "redaction.key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("0123456789abcdef0123456789abcdef"), 0600))
+
+	redactor, err := NewRedactorFromConfig(&FileConfig{RedactionMode: RedactHash, RedactionKeyFile: keyFile})
+	require.NoError(t, err)
+	assert.Equal(t, "hmac-sha256:cd1887dea5dd65c7", redactor.Redact("spiffe://example.org/workload"))
+
+	_, err = NewRedactorFromConfig(&FileConfig{RedactionMode: RedactHash, RedactionKeyFile: filepath.Join(t.TempDir(), "missing")})
+	require.ErrorContains(t, err, "failed to read redaction key")
+}
+
+func TestRedactLabels(t *testing.T) {
+	redactor, err := NewRedactor(RedactTruncate, nil)
+	require.NoError(t, err)
+
+	labels := []Label{
+		{Name: CallerID, Value: "spiffe://example.org/agent/node1"},
+		{Name: Status, Value: "OK"},
+	}
+	assert.Equal(t, []Label{
+		{Name: CallerID, Value: "spiffe://example.org/..."},
+		{Name: Status, Value: "OK"},
+	}, redactor.RedactLabels(labels))
+
+	// The given labels are not modified
+	assert.Equal(t, "spiffe://example.org/agent/node1", labels[0].Value)
+
+	unidentifying := []Label{{Name: Status, Value: "OK"}}
+	assert.Equal(t, unidentifying, redactor.RedactLabels(unidentifying))
+}
@@ -0,0 +1,31 @@
+package server
+
+import "github.com/spiffe/spire/pkg/common/telemetry"
+
+// Counters (literal increments, not call counters)
+
+// IncrAgentEvictorEvictCounter indicates that agents were evicted for the
+// given reason
+func IncrAgentEvictorEvictCounter(m telemetry.Metrics, reason string, count int) {
+	m.IncrCounterWithLabels([]string{telemetry.AgentEvictor, telemetry.Evict}, float32(count), []telemetry.Label{
+		{Name: telemetry.Reason, Value: reason},
+	})
+}
+
+// End Counters
+
+// Gauge (remember previous value set)
+
+// SetAgentEvictorCountGauge sets the gauge for the number of attested agents
+// observed by the agent evictor
+func SetAgentEvictorCountGauge(m telemetry.Metrics, count int) {
+	m.SetGauge([]string{telemetry.AgentEvictor, telemetry.Count}, float32(count))
+}
+
+// SetAgentEvictorExcessGauge sets the gauge for the number of attested agents
+// above the configured maximum that could not be evicted
+func SetAgentEvictorExcessGauge(m telemetry.Metrics, count int) {
+	m.SetGauge([]string{telemetry.AgentEvictor, telemetry.Excess}, float32(count))
+}
+
+// End Gauge
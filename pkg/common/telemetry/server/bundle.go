@@ -0,0 +1,44 @@
+package server
+
+import (
+	"time"
+
+	"github.com/spiffe/spire/pkg/common/telemetry"
+)
+
+// Gauge (remember previous value set)
+
+// SetBundleSizeGauge sets the gauge for the size of the bundle of the given
+// trust domain, in bytes
+func SetBundleSizeGauge(m telemetry.Metrics, trustDomain string, size int) {
+	setBundleGauge(m, telemetry.Size, trustDomain, float32(size))
+}
+
+// SetBundleX509AuthoritiesGauge sets the gauge for the number of X.509
+// authorities in the bundle of the given trust domain
+func SetBundleX509AuthoritiesGauge(m telemetry.Metrics, trustDomain string, count int) {
+	setBundleGauge(m, telemetry.X509CAs, trustDomain, float32(count))
+}
+
+// SetBundleJWTAuthoritiesGauge sets the gauge for the number of JWT
+// authorities in the bundle of the given trust domain
+func SetBundleJWTAuthoritiesGauge(m telemetry.Metrics, trustDomain string, count int) {
+	setBundleGauge(m, telemetry.JWTKeys, trustDomain, float32(count))
+}
+
+// SetBundleAgeGauge sets the gauge for the time elapsed since the bundle of
+// the given trust domain was last updated, in seconds
+func SetBundleAgeGauge(m telemetry.Metrics, trustDomain string, age time.Duration) {
+	setBundleGauge(m, telemetry.Age, trustDomain, float32(age.Seconds()))
+}
+
+func setBundleGauge(m telemetry.Metrics, name string, trustDomain string, val float32) {
+	m.SetGaugeWithLabels(
+		[]string{telemetry.Bundle, name},
+		val,
+		[]telemetry.Label{
+			{Name: telemetry.TrustDomainID, Value: trustDomain},
+		})
+}
+
+// End Gauge
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"strconv"
+
 	"github.com/spiffe/spire/pkg/common/telemetry"
 )
 
@@ -74,6 +76,15 @@ func IncrServerCASignX509CACounter(m telemetry.Metrics) {
 	m.IncrCounter([]string{telemetry.ServerCA, telemetry.Sign, telemetry.X509CASVID}, 1)
 }
 
+// IncrServerCARejectX509CACounter indicate Server CA
+// rejected signing an X509 CA SVID because the resulting downstream
+// depth would exceed the configured maximum.
+func IncrServerCARejectX509CACounter(m telemetry.Metrics, depth int) {
+	m.IncrCounterWithLabels([]string{telemetry.ServerCA, telemetry.Sign, telemetry.X509CASVID, telemetry.Reject}, 1, []telemetry.Label{
+		{Name: telemetry.DownstreamDepth, Value: strconv.Itoa(depth)},
+	})
+}
+
 // IncrServerCASignX509Counter indicate Server CA
 // signed an X509 SVID.
 func IncrServerCASignX509Counter(m telemetry.Metrics) {
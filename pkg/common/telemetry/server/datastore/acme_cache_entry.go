@@ -0,0 +1,24 @@
+package datastore
+
+import "github.com/spiffe/spire/pkg/common/telemetry"
+
+// Call Counters (timing and success metrics)
+// Allows adding labels in-code
+
+// StartDeleteACMECacheEntryCall return metric
+// for server's datastore, on deleting an ACME cache entry.
+func StartDeleteACMECacheEntryCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.ACMECacheEntry, telemetry.Delete)
+}
+
+// StartFetchACMECacheEntryCall return metric
+// for server's datastore, on fetching an ACME cache entry.
+func StartFetchACMECacheEntryCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.ACMECacheEntry, telemetry.Fetch)
+}
+
+// StartSetACMECacheEntryCall return metric
+// for server's datastore, on setting an ACME cache entry.
+func StartSetACMECacheEntryCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.ACMECacheEntry, telemetry.Set)
+}
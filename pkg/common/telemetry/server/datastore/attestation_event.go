@@ -0,0 +1,24 @@
+package datastore
+
+import "github.com/spiffe/spire/pkg/common/telemetry"
+
+// Call Counters (timing and success metrics)
+// Allows adding labels in-code
+
+// StartCreateAttestationEventCall return metric
+// for server's datastore, on creating an attestation event.
+func StartCreateAttestationEventCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.AttestationEvent, telemetry.Create)
+}
+
+// StartListAttestationEventsCall return metric
+// for server's datastore, on listing attestation events.
+func StartListAttestationEventsCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.AttestationEvent, telemetry.List)
+}
+
+// StartPruneAttestationEventsCall return metric
+// for server's datastore, on pruning attestation events.
+func StartPruneAttestationEventsCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.AttestationEvent, telemetry.Prune)
+}
@@ -0,0 +1,24 @@
+package datastore
+
+import "github.com/spiffe/spire/pkg/common/telemetry"
+
+// Call Counters (timing and success metrics)
+// Allows adding labels in-code
+
+// StartCreateIssuanceRecordCall return metric
+// for server's datastore, on creating an issuance record.
+func StartCreateIssuanceRecordCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.IssuanceRecord, telemetry.Create)
+}
+
+// StartListIssuanceRecordsCall return metric
+// for server's datastore, on listing issuance records.
+func StartListIssuanceRecordsCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.IssuanceRecord, telemetry.List)
+}
+
+// StartPruneIssuanceRecordsCall return metric
+// for server's datastore, on pruning issuance records.
+func StartPruneIssuanceRecordsCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.IssuanceRecord, telemetry.Prune)
+}
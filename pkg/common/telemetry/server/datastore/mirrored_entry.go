@@ -0,0 +1,24 @@
+package datastore
+
+import "github.com/spiffe/spire/pkg/common/telemetry"
+
+// Call Counters (timing and success metrics)
+// Allows adding labels in-code
+
+// StartDeleteMirroredEntryCall return metric
+// for server's datastore, on deleting a mirrored entry.
+func StartDeleteMirroredEntryCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.MirroredEntry, telemetry.Delete)
+}
+
+// StartListMirroredEntriesCall return metric
+// for server's datastore, on listing mirrored entries.
+func StartListMirroredEntriesCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.MirroredEntry, telemetry.List)
+}
+
+// StartSetMirroredEntryCall return metric
+// for server's datastore, on setting a mirrored entry.
+func StartSetMirroredEntryCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.MirroredEntry, telemetry.Set)
+}
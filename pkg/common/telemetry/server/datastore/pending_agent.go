@@ -0,0 +1,36 @@
+package datastore
+
+import "github.com/spiffe/spire/pkg/common/telemetry"
+
+// Call Counters (timing and success metrics)
+// Allows adding labels in-code
+
+// StartApprovePendingAgentCall return metric
+// for server's datastore, on approving a pending agent.
+func StartApprovePendingAgentCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.PendingAgent, telemetry.Approve)
+}
+
+// StartDeletePendingAgentCall return metric
+// for server's datastore, on deleting a pending agent.
+func StartDeletePendingAgentCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.PendingAgent, telemetry.Delete)
+}
+
+// StartFetchPendingAgentCall return metric
+// for server's datastore, on fetching a pending agent.
+func StartFetchPendingAgentCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.PendingAgent, telemetry.Fetch)
+}
+
+// StartListPendingAgentsCall return metric
+// for server's datastore, on listing pending agents.
+func StartListPendingAgentsCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.PendingAgent, telemetry.List)
+}
+
+// StartSetPendingAgentCall return metric
+// for server's datastore, on setting a pending agent.
+func StartSetPendingAgentCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.PendingAgent, telemetry.Set)
+}
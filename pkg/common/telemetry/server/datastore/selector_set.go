@@ -0,0 +1,36 @@
+package datastore
+
+import "github.com/spiffe/spire/pkg/common/telemetry"
+
+// Call Counters (timing and success metrics)
+// Allows adding labels in-code
+
+// StartCreateSelectorSetCall return metric
+// for server's datastore, on creating a selector set.
+func StartCreateSelectorSetCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.SelectorSet, telemetry.Create)
+}
+
+// StartDeleteSelectorSetCall return metric
+// for server's datastore, on deleting a selector set.
+func StartDeleteSelectorSetCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.SelectorSet, telemetry.Delete)
+}
+
+// StartFetchSelectorSetCall return metric
+// for server's datastore, on fetching a selector set.
+func StartFetchSelectorSetCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.SelectorSet, telemetry.Fetch)
+}
+
+// StartListSelectorSetsCall return metric
+// for server's datastore, on listing selector sets.
+func StartListSelectorSetsCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.SelectorSet, telemetry.List)
+}
+
+// StartUpdateSelectorSetCall return metric
+// for server's datastore, on updating a selector set.
+func StartUpdateSelectorSetCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.Datastore, telemetry.SelectorSet, telemetry.Update)
+}
@@ -29,6 +29,24 @@ func (w metricsWrapper) AppendBundle(ctx context.Context, bundle *common.Bundle)
 	return w.ds.AppendBundle(ctx, bundle)
 }
 
+func (w metricsWrapper) ApprovePendingAgent(ctx context.Context, spiffeID string) (_ *datastore.PendingAgent, err error) {
+	callCounter := StartApprovePendingAgentCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.ApprovePendingAgent(ctx, spiffeID)
+}
+
+func (w metricsWrapper) CompareAndUpdateRegistrationEntry(ctx context.Context, entry *common.RegistrationEntry, mask *common.RegistrationEntryMask, expectedRevision int64) (_ *common.RegistrationEntry, err error) {
+	callCounter := StartUpdateRegistrationCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.CompareAndUpdateRegistrationEntry(ctx, entry, mask, expectedRevision)
+}
+
+func (w metricsWrapper) CreateAttestationEvent(ctx context.Context, event *datastore.AttestationEvent) (err error) {
+	callCounter := StartCreateAttestationEventCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.CreateAttestationEvent(ctx, event)
+}
+
 func (w metricsWrapper) CreateAttestedNode(ctx context.Context, node *common.AttestedNode) (_ *common.AttestedNode, err error) {
 	callCounter := StartCreateNodeCall(w.m)
 	defer callCounter.Done(&err)
@@ -59,18 +77,48 @@ func (w metricsWrapper) CreateOrReturnRegistrationEntry(ctx context.Context, ent
 	return w.ds.CreateOrReturnRegistrationEntry(ctx, entry)
 }
 
+func (w metricsWrapper) CreateSelectorSet(ctx context.Context, set *datastore.SelectorSet) (_ *datastore.SelectorSet, err error) {
+	callCounter := StartCreateSelectorSetCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.CreateSelectorSet(ctx, set)
+}
+
 func (w metricsWrapper) CreateFederationRelationship(ctx context.Context, fr *datastore.FederationRelationship) (_ *datastore.FederationRelationship, err error) {
 	callCounter := StartCreateFederationRelationshipCall(w.m)
 	defer callCounter.Done(&err)
 	return w.ds.CreateFederationRelationship(ctx, fr)
 }
 
+func (w metricsWrapper) CreateIssuanceRecord(ctx context.Context, record *datastore.IssuanceRecord) (err error) {
+	callCounter := StartCreateIssuanceRecordCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.CreateIssuanceRecord(ctx, record)
+}
+
+func (w metricsWrapper) ListAttestationEvents(ctx context.Context, req *datastore.ListAttestationEventsRequest) (_ *datastore.ListAttestationEventsResponse, err error) {
+	callCounter := StartListAttestationEventsCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.ListAttestationEvents(ctx, req)
+}
+
 func (w metricsWrapper) ListFederationRelationships(ctx context.Context, req *datastore.ListFederationRelationshipsRequest) (_ *datastore.ListFederationRelationshipsResponse, err error) {
 	callCounter := StartListFederationRelationshipsCall(w.m)
 	defer callCounter.Done(&err)
 	return w.ds.ListFederationRelationships(ctx, req)
 }
 
+func (w metricsWrapper) ListIssuanceRecords(ctx context.Context, req *datastore.ListIssuanceRecordsRequest) (_ *datastore.ListIssuanceRecordsResponse, err error) {
+	callCounter := StartListIssuanceRecordsCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.ListIssuanceRecords(ctx, req)
+}
+
+func (w metricsWrapper) DeleteACMECacheEntry(ctx context.Context, key string) (err error) {
+	callCounter := StartDeleteACMECacheEntryCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.DeleteACMECacheEntry(ctx, key)
+}
+
 func (w metricsWrapper) DeleteAttestedNode(ctx context.Context, spiffeID string) (_ *common.AttestedNode, err error) {
 	callCounter := StartDeleteNodeCall(w.m)
 	defer callCounter.Done(&err)
@@ -95,12 +143,36 @@ func (w metricsWrapper) DeleteJoinToken(ctx context.Context, token string) (err
 	return w.ds.DeleteJoinToken(ctx, token)
 }
 
+func (w metricsWrapper) DeleteMirroredEntry(ctx context.Context, entryID string) (err error) {
+	callCounter := StartDeleteMirroredEntryCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.DeleteMirroredEntry(ctx, entryID)
+}
+
+func (w metricsWrapper) DeletePendingAgent(ctx context.Context, spiffeID string) (err error) {
+	callCounter := StartDeletePendingAgentCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.DeletePendingAgent(ctx, spiffeID)
+}
+
 func (w metricsWrapper) DeleteRegistrationEntry(ctx context.Context, entryID string) (_ *common.RegistrationEntry, err error) {
 	callCounter := StartDeleteRegistrationCall(w.m)
 	defer callCounter.Done(&err)
 	return w.ds.DeleteRegistrationEntry(ctx, entryID)
 }
 
+func (w metricsWrapper) DeleteSelectorSet(ctx context.Context, name string) (err error) {
+	callCounter := StartDeleteSelectorSetCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.DeleteSelectorSet(ctx, name)
+}
+
+func (w metricsWrapper) FetchACMECacheEntry(ctx context.Context, key string) (_ []byte, err error) {
+	callCounter := StartFetchACMECacheEntryCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.FetchACMECacheEntry(ctx, key)
+}
+
 func (w metricsWrapper) FetchAttestedNode(ctx context.Context, spiffeID string) (_ *common.AttestedNode, err error) {
 	callCounter := StartFetchNodeCall(w.m)
 	defer callCounter.Done(&err)
@@ -119,6 +191,12 @@ func (w metricsWrapper) FetchJoinToken(ctx context.Context, token string) (_ *da
 	return w.ds.FetchJoinToken(ctx, token)
 }
 
+func (w metricsWrapper) FetchPendingAgent(ctx context.Context, spiffeID string) (_ *datastore.PendingAgent, err error) {
+	callCounter := StartFetchPendingAgentCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.FetchPendingAgent(ctx, spiffeID)
+}
+
 func (w metricsWrapper) FetchRegistrationEntry(ctx context.Context, entryID string) (_ *common.RegistrationEntry, err error) {
 	callCounter := StartFetchRegistrationCall(w.m)
 	defer callCounter.Done(&err)
@@ -131,6 +209,12 @@ func (w metricsWrapper) FetchFederationRelationship(ctx context.Context, trustDo
 	return w.ds.FetchFederationRelationship(ctx, trustDomain)
 }
 
+func (w metricsWrapper) FetchSelectorSet(ctx context.Context, name string) (_ *datastore.SelectorSet, err error) {
+	callCounter := StartFetchSelectorSetCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.FetchSelectorSet(ctx, name)
+}
+
 func (w metricsWrapper) GetNodeSelectors(ctx context.Context, spiffeID string, dataConsistency datastore.DataConsistency) (_ []*common.Selector, err error) {
 	callCounter := StartGetNodeSelectorsCall(w.m)
 	defer callCounter.Done(&err)
@@ -149,18 +233,36 @@ func (w metricsWrapper) ListBundles(ctx context.Context, req *datastore.ListBund
 	return w.ds.ListBundles(ctx, req)
 }
 
+func (w metricsWrapper) ListMirroredEntries(ctx context.Context) (_ []*datastore.MirroredEntry, err error) {
+	callCounter := StartListMirroredEntriesCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.ListMirroredEntries(ctx)
+}
+
 func (w metricsWrapper) ListNodeSelectors(ctx context.Context, req *datastore.ListNodeSelectorsRequest) (_ *datastore.ListNodeSelectorsResponse, err error) {
 	callCounter := StartListNodeSelectorsCall(w.m)
 	defer callCounter.Done(&err)
 	return w.ds.ListNodeSelectors(ctx, req)
 }
 
+func (w metricsWrapper) ListPendingAgents(ctx context.Context, req *datastore.ListPendingAgentsRequest) (_ *datastore.ListPendingAgentsResponse, err error) {
+	callCounter := StartListPendingAgentsCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.ListPendingAgents(ctx, req)
+}
+
 func (w metricsWrapper) ListRegistrationEntries(ctx context.Context, req *datastore.ListRegistrationEntriesRequest) (_ *datastore.ListRegistrationEntriesResponse, err error) {
 	callCounter := StartListRegistrationCall(w.m)
 	defer callCounter.Done(&err)
 	return w.ds.ListRegistrationEntries(ctx, req)
 }
 
+func (w metricsWrapper) ListSelectorSets(ctx context.Context) (_ []*datastore.SelectorSet, err error) {
+	callCounter := StartListSelectorSetsCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.ListSelectorSets(ctx)
+}
+
 func (w metricsWrapper) CountAttestedNodes(ctx context.Context) (_ int32, err error) {
 	callCounter := StartCountNodeCall(w.m)
 	defer callCounter.Done(&err)
@@ -179,6 +281,12 @@ func (w metricsWrapper) CountRegistrationEntries(ctx context.Context) (_ int32,
 	return w.ds.CountRegistrationEntries(ctx)
 }
 
+func (w metricsWrapper) PruneAttestationEvents(ctx context.Context, succeededBefore, failedBefore time.Time) (err error) {
+	callCounter := StartPruneAttestationEventsCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.PruneAttestationEvents(ctx, succeededBefore, failedBefore)
+}
+
 func (w metricsWrapper) PruneBundle(ctx context.Context, trustDomainID string, expiresBefore time.Time) (_ bool, err error) {
 	callCounter := StartPruneBundleCall(w.m)
 	defer callCounter.Done(&err)
@@ -191,24 +299,48 @@ func (w metricsWrapper) PruneJoinTokens(ctx context.Context, expiresBefore time.
 	return w.ds.PruneJoinTokens(ctx, expiresBefore)
 }
 
+func (w metricsWrapper) PruneIssuanceRecords(ctx context.Context, expiredBefore time.Time) (err error) {
+	callCounter := StartPruneIssuanceRecordsCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.PruneIssuanceRecords(ctx, expiredBefore)
+}
+
 func (w metricsWrapper) PruneRegistrationEntries(ctx context.Context, expiresBefore time.Time) (err error) {
 	callCounter := StartPruneRegistrationCall(w.m)
 	defer callCounter.Done(&err)
 	return w.ds.PruneRegistrationEntries(ctx, expiresBefore)
 }
 
+func (w metricsWrapper) SetACMECacheEntry(ctx context.Context, key string, data []byte) (err error) {
+	callCounter := StartSetACMECacheEntryCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.SetACMECacheEntry(ctx, key, data)
+}
+
 func (w metricsWrapper) SetBundle(ctx context.Context, bundle *common.Bundle) (_ *common.Bundle, err error) {
 	callCounter := StartSetBundleCall(w.m)
 	defer callCounter.Done(&err)
 	return w.ds.SetBundle(ctx, bundle)
 }
 
+func (w metricsWrapper) SetMirroredEntry(ctx context.Context, entry *datastore.MirroredEntry) (err error) {
+	callCounter := StartSetMirroredEntryCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.SetMirroredEntry(ctx, entry)
+}
+
 func (w metricsWrapper) SetNodeSelectors(ctx context.Context, spiffeID string, selectors []*common.Selector) (err error) {
 	callCounter := StartSetNodeSelectorsCall(w.m)
 	defer callCounter.Done(&err)
 	return w.ds.SetNodeSelectors(ctx, spiffeID, selectors)
 }
 
+func (w metricsWrapper) SetPendingAgent(ctx context.Context, agent *datastore.PendingAgent) (_ *datastore.PendingAgent, err error) {
+	callCounter := StartSetPendingAgentCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.SetPendingAgent(ctx, agent)
+}
+
 func (w metricsWrapper) UpdateAttestedNode(ctx context.Context, node *common.AttestedNode, mask *common.AttestedNodeMask) (_ *common.AttestedNode, err error) {
 	callCounter := StartUpdateNodeCall(w.m)
 	defer callCounter.Done(&err)
@@ -232,3 +364,9 @@ func (w metricsWrapper) UpdateFederationRelationship(ctx context.Context, fr *da
 	defer callCounter.Done(&err)
 	return w.ds.UpdateFederationRelationship(ctx, fr, mask)
 }
+
+func (w metricsWrapper) UpdateSelectorSet(ctx context.Context, set *datastore.SelectorSet) (_ *datastore.SelectorSet, err error) {
+	callCounter := StartUpdateSelectorSetCall(w.m)
+	defer callCounter.Done(&err)
+	return w.ds.UpdateSelectorSet(ctx, set)
+}
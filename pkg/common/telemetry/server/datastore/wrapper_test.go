@@ -41,10 +41,22 @@ func TestWithMetrics(t *testing.T) {
 			key:        "datastore.bundle.append",
 			methodName: "AppendBundle",
 		},
+		{
+			key:        "datastore.pending_agent.approve",
+			methodName: "ApprovePendingAgent",
+		},
+		{
+			key:        "datastore.registration_entry.update",
+			methodName: "CompareAndUpdateRegistrationEntry",
+		},
 		{
 			key:        "datastore.node.count",
 			methodName: "CountAttestedNodes",
 		},
+		{
+			key:        "datastore.attestation_event.create",
+			methodName: "CreateAttestationEvent",
+		},
 		{
 			key:        "datastore.bundle.count",
 			methodName: "CountBundles",
@@ -65,6 +77,10 @@ func TestWithMetrics(t *testing.T) {
 			key:        "datastore.federation_relationship.create",
 			methodName: "CreateFederationRelationship",
 		},
+		{
+			key:        "datastore.issuance_record.create",
+			methodName: "CreateIssuanceRecord",
+		},
 		{
 			key:        "datastore.join_token.create",
 			methodName: "CreateJoinToken",
@@ -77,6 +93,14 @@ func TestWithMetrics(t *testing.T) {
 			key:        "datastore.registration_entry.create",
 			methodName: "CreateOrReturnRegistrationEntry",
 		},
+		{
+			key:        "datastore.selector_set.create",
+			methodName: "CreateSelectorSet",
+		},
+		{
+			key:        "datastore.acme_cache_entry.delete",
+			methodName: "DeleteACMECacheEntry",
+		},
 		{
 			key:        "datastore.node.delete",
 			methodName: "DeleteAttestedNode",
@@ -93,10 +117,26 @@ func TestWithMetrics(t *testing.T) {
 			key:        "datastore.join_token.delete",
 			methodName: "DeleteJoinToken",
 		},
+		{
+			key:        "datastore.mirrored_entry.delete",
+			methodName: "DeleteMirroredEntry",
+		},
+		{
+			key:        "datastore.pending_agent.delete",
+			methodName: "DeletePendingAgent",
+		},
 		{
 			key:        "datastore.registration_entry.delete",
 			methodName: "DeleteRegistrationEntry",
 		},
+		{
+			key:        "datastore.selector_set.delete",
+			methodName: "DeleteSelectorSet",
+		},
+		{
+			key:        "datastore.acme_cache_entry.fetch",
+			methodName: "FetchACMECacheEntry",
+		},
 		{
 			key:        "datastore.node.fetch",
 			methodName: "FetchAttestedNode",
@@ -109,6 +149,10 @@ func TestWithMetrics(t *testing.T) {
 			key:        "datastore.join_token.fetch",
 			methodName: "FetchJoinToken",
 		},
+		{
+			key:        "datastore.pending_agent.fetch",
+			methodName: "FetchPendingAgent",
+		},
 		{
 			key:        "datastore.registration_entry.fetch",
 			methodName: "FetchRegistrationEntry",
@@ -117,6 +161,10 @@ func TestWithMetrics(t *testing.T) {
 			key:        "datastore.federation_relationship.fetch",
 			methodName: "FetchFederationRelationship",
 		},
+		{
+			key:        "datastore.selector_set.fetch",
+			methodName: "FetchSelectorSet",
+		},
 		{
 			key:        "datastore.node.selectors.fetch",
 			methodName: "GetNodeSelectors",
@@ -129,10 +177,18 @@ func TestWithMetrics(t *testing.T) {
 			key:        "datastore.bundle.list",
 			methodName: "ListBundles",
 		},
+		{
+			key:        "datastore.mirrored_entry.list",
+			methodName: "ListMirroredEntries",
+		},
 		{
 			key:        "datastore.node.selectors.list",
 			methodName: "ListNodeSelectors",
 		},
+		{
+			key:        "datastore.pending_agent.list",
+			methodName: "ListPendingAgents",
+		},
 		{
 			key:        "datastore.registration_entry.list",
 			methodName: "ListRegistrationEntries",
@@ -141,10 +197,30 @@ func TestWithMetrics(t *testing.T) {
 			key:        "datastore.federation_relationship.list",
 			methodName: "ListFederationRelationships",
 		},
+		{
+			key:        "datastore.issuance_record.list",
+			methodName: "ListIssuanceRecords",
+		},
+		{
+			key:        "datastore.attestation_event.list",
+			methodName: "ListAttestationEvents",
+		},
+		{
+			key:        "datastore.selector_set.list",
+			methodName: "ListSelectorSets",
+		},
+		{
+			key:        "datastore.attestation_event.prune",
+			methodName: "PruneAttestationEvents",
+		},
 		{
 			key:        "datastore.bundle.prune",
 			methodName: "PruneBundle",
 		},
+		{
+			key:        "datastore.issuance_record.prune",
+			methodName: "PruneIssuanceRecords",
+		},
 		{
 			key:        "datastore.join_token.prune",
 			methodName: "PruneJoinTokens",
@@ -153,14 +229,26 @@ func TestWithMetrics(t *testing.T) {
 			key:        "datastore.registration_entry.prune",
 			methodName: "PruneRegistrationEntries",
 		},
+		{
+			key:        "datastore.acme_cache_entry.set",
+			methodName: "SetACMECacheEntry",
+		},
 		{
 			key:        "datastore.bundle.set",
 			methodName: "SetBundle",
 		},
+		{
+			key:        "datastore.mirrored_entry.set",
+			methodName: "SetMirroredEntry",
+		},
 		{
 			key:        "datastore.node.selectors.set",
 			methodName: "SetNodeSelectors",
 		},
+		{
+			key:        "datastore.pending_agent.set",
+			methodName: "SetPendingAgent",
+		},
 		{
 			key:        "datastore.node.update",
 			methodName: "UpdateAttestedNode",
@@ -177,6 +265,10 @@ func TestWithMetrics(t *testing.T) {
 			key:        "datastore.registration_entry.update",
 			methodName: "UpdateRegistrationEntry",
 		},
+		{
+			key:        "datastore.selector_set.update",
+			methodName: "UpdateSelectorSet",
+		},
 	} {
 		tt := tt
 		methodType, ok := wt.MethodByName(tt.methodName)
@@ -261,6 +353,10 @@ func (ds *fakeDataStore) AppendBundle(context.Context, *common.Bundle) (*common.
 	return &common.Bundle{}, ds.err
 }
 
+func (ds *fakeDataStore) CompareAndUpdateRegistrationEntry(context.Context, *common.RegistrationEntry, *common.RegistrationEntryMask, int64) (*common.RegistrationEntry, error) {
+	return &common.RegistrationEntry{}, ds.err
+}
+
 func (ds *fakeDataStore) CountAttestedNodes(context.Context) (int32, error) {
 	return 0, ds.err
 }
@@ -361,6 +457,10 @@ func (ds *fakeDataStore) ListRegistrationEntries(context.Context, *datastore.Lis
 	return &datastore.ListRegistrationEntriesResponse{}, ds.err
 }
 
+func (ds *fakeDataStore) PruneAttestationEvents(context.Context, time.Time, time.Time) error {
+	return ds.err
+}
+
 func (ds *fakeDataStore) PruneBundle(context.Context, string, time.Time) (bool, error) {
 	return false, ds.err
 }
@@ -369,6 +469,10 @@ func (ds *fakeDataStore) PruneJoinTokens(context.Context, time.Time) error {
 	return ds.err
 }
 
+func (ds *fakeDataStore) PruneIssuanceRecords(context.Context, time.Time) error {
+	return ds.err
+}
+
 func (ds *fakeDataStore) PruneRegistrationEntries(context.Context, time.Time) error {
 	return ds.err
 }
@@ -396,3 +500,83 @@ func (ds *fakeDataStore) UpdateRegistrationEntry(context.Context, *common.Regist
 func (ds *fakeDataStore) UpdateFederationRelationship(context.Context, *datastore.FederationRelationship, *types.FederationRelationshipMask) (*datastore.FederationRelationship, error) {
 	return &datastore.FederationRelationship{}, ds.err
 }
+
+func (ds *fakeDataStore) CreateSelectorSet(context.Context, *datastore.SelectorSet) (*datastore.SelectorSet, error) {
+	return &datastore.SelectorSet{}, ds.err
+}
+
+func (ds *fakeDataStore) DeleteSelectorSet(context.Context, string) error {
+	return ds.err
+}
+
+func (ds *fakeDataStore) FetchSelectorSet(context.Context, string) (*datastore.SelectorSet, error) {
+	return &datastore.SelectorSet{}, ds.err
+}
+
+func (ds *fakeDataStore) ListSelectorSets(context.Context) ([]*datastore.SelectorSet, error) {
+	return []*datastore.SelectorSet{}, ds.err
+}
+
+func (ds *fakeDataStore) UpdateSelectorSet(context.Context, *datastore.SelectorSet) (*datastore.SelectorSet, error) {
+	return &datastore.SelectorSet{}, ds.err
+}
+
+func (ds *fakeDataStore) CreateIssuanceRecord(context.Context, *datastore.IssuanceRecord) error {
+	return ds.err
+}
+
+func (ds *fakeDataStore) ListIssuanceRecords(context.Context, *datastore.ListIssuanceRecordsRequest) (*datastore.ListIssuanceRecordsResponse, error) {
+	return &datastore.ListIssuanceRecordsResponse{}, ds.err
+}
+
+func (ds *fakeDataStore) CreateAttestationEvent(context.Context, *datastore.AttestationEvent) error {
+	return ds.err
+}
+
+func (ds *fakeDataStore) ListAttestationEvents(context.Context, *datastore.ListAttestationEventsRequest) (*datastore.ListAttestationEventsResponse, error) {
+	return &datastore.ListAttestationEventsResponse{}, ds.err
+}
+
+func (ds *fakeDataStore) DeleteACMECacheEntry(context.Context, string) error {
+	return ds.err
+}
+
+func (ds *fakeDataStore) FetchACMECacheEntry(context.Context, string) ([]byte, error) {
+	return nil, ds.err
+}
+
+func (ds *fakeDataStore) SetACMECacheEntry(context.Context, string, []byte) error {
+	return ds.err
+}
+
+func (ds *fakeDataStore) DeleteMirroredEntry(context.Context, string) error {
+	return ds.err
+}
+
+func (ds *fakeDataStore) ListMirroredEntries(context.Context) ([]*datastore.MirroredEntry, error) {
+	return []*datastore.MirroredEntry{}, ds.err
+}
+
+func (ds *fakeDataStore) SetMirroredEntry(context.Context, *datastore.MirroredEntry) error {
+	return ds.err
+}
+
+func (ds *fakeDataStore) ApprovePendingAgent(context.Context, string) (*datastore.PendingAgent, error) {
+	return &datastore.PendingAgent{}, ds.err
+}
+
+func (ds *fakeDataStore) DeletePendingAgent(context.Context, string) error {
+	return ds.err
+}
+
+func (ds *fakeDataStore) FetchPendingAgent(context.Context, string) (*datastore.PendingAgent, error) {
+	return &datastore.PendingAgent{}, ds.err
+}
+
+func (ds *fakeDataStore) ListPendingAgents(context.Context, *datastore.ListPendingAgentsRequest) (*datastore.ListPendingAgentsResponse, error) {
+	return &datastore.ListPendingAgentsResponse{}, ds.err
+}
+
+func (ds *fakeDataStore) SetPendingAgent(context.Context, *datastore.PendingAgent) (*datastore.PendingAgent, error) {
+	return &datastore.PendingAgent{}, ds.err
+}
@@ -0,0 +1,13 @@
+package server
+
+import "github.com/spiffe/spire/pkg/common/telemetry"
+
+// Counters (literal increments, not call counters)
+
+// IncrEntryMirrorCounter indicates that mirrored entries were created,
+// updated or deleted on the peer server, depending on the given operation
+func IncrEntryMirrorCounter(m telemetry.Metrics, op string, count int) {
+	m.IncrCounter([]string{telemetry.EntryMirror, op}, float32(count))
+}
+
+// End Counters
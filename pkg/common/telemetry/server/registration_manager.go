@@ -11,4 +11,16 @@ func StartRegistrationManagerPruneEntryCall(m telemetry.Metrics) *telemetry.Call
 	return telemetry.StartCall(m, telemetry.RegistrationEntry, telemetry.Manager, telemetry.Prune)
 }
 
+// StartRegistrationManagerPruneAttestationEventCall returns metric for
+// for server registration manager attestation event pruning
+func StartRegistrationManagerPruneAttestationEventCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.AttestationEvent, telemetry.Manager, telemetry.Prune)
+}
+
+// StartRegistrationManagerPruneIssuanceRecordCall returns metric for
+// for server registration manager issuance record pruning
+func StartRegistrationManagerPruneIssuanceRecordCall(m telemetry.Metrics) *telemetry.CallCounter {
+	return telemetry.StartCall(m, telemetry.IssuanceRecord, telemetry.Manager, telemetry.Prune)
+}
+
 // End Call Counters
@@ -1,9 +1,55 @@
 package server
 
-import "github.com/spiffe/spire/pkg/common/telemetry"
+import (
+	"time"
+
+	"github.com/spiffe/spire/pkg/common/telemetry"
+)
 
 // SetEntryDeletedGauge emits a gauge with the number of entries that will
 // be deleted in the entry cache.
 func SetEntryDeletedGauge(m telemetry.Metrics, deleted int) {
 	m.SetGauge([]string{telemetry.Entry, telemetry.Deleted}, float32(deleted))
 }
+
+// AddAgentClockSkewSample emits a sample with the skew, in seconds, between
+// the clock of an agent and the clock of the server. The skew is positive
+// when the agent clock is ahead of the server clock.
+func AddAgentClockSkewSample(m telemetry.Metrics, skew time.Duration) {
+	m.AddSample([]string{telemetry.Node, telemetry.ClockSkew}, float32(skew.Seconds()))
+}
+
+// IncrAgentVersionCounter indicates that an agent advertising the given
+// SPIRE version made a request, which exposes the version skew across the
+// fleet.
+func IncrAgentVersionCounter(m telemetry.Metrics, agentVersion string) {
+	m.IncrCounterWithLabels([]string{telemetry.Node, telemetry.Version}, 1, []telemetry.Label{
+		{Name: telemetry.AgentVersion, Value: agentVersion},
+	})
+}
+
+// AddNodeSelectorCountSample emits a sample with the number of selectors
+// produced when attesting an agent, labeled by node attestor type.
+func AddNodeSelectorCountSample(m telemetry.Metrics, attestorType string, count int) {
+	m.AddSampleWithLabels([]string{telemetry.Node, telemetry.Selectors, telemetry.Count}, float32(count), []telemetry.Label{
+		{Name: telemetry.NodeAttestorType, Value: attestorType},
+	})
+}
+
+// IncrNodeSelectorThresholdExceededCounter indicates that an attested agent
+// has more selectors than the configured warning threshold, labeled by node
+// attestor type.
+func IncrNodeSelectorThresholdExceededCounter(m telemetry.Metrics, attestorType string) {
+	m.IncrCounterWithLabels([]string{telemetry.Node, telemetry.Selectors, telemetry.ThresholdExceeded}, 1, []telemetry.Label{
+		{Name: telemetry.NodeAttestorType, Value: attestorType},
+	})
+}
+
+// IncrCSRKeyRejectedCounter indicates that a CSR was rejected because its
+// key, described by keyType (e.g. "rsa-1024"), is not allowed by the key
+// policy.
+func IncrCSRKeyRejectedCounter(m telemetry.Metrics, keyType string) {
+	m.IncrCounterWithLabels([]string{telemetry.Csr, telemetry.Reject}, 1, []telemetry.Label{
+		{Name: telemetry.Type, Value: keyType},
+	})
+}
@@ -4,25 +4,86 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"time"
+)
+
+// SerialNumberPolicy determines how certificate serial numbers are generated
+type SerialNumberPolicy string
+
+const (
+	// SerialNumberRandom generates random serial numbers of up to 128 bits.
+	// This is the default policy.
+	SerialNumberRandom SerialNumberPolicy = "random"
+
+	// SerialNumberRandom160 generates random serial numbers of up to 159
+	// bits, the largest positive integer that fits in the 20 octets allowed
+	// by RFC 5280.
+	SerialNumberRandom160 SerialNumberPolicy = "random_160"
+
+	// SerialNumberTimeOrdered generates serial numbers that sort by issuance
+	// time. The issuance time in nanoseconds makes up the high order bits
+	// and 64 random bits make up the low order bits.
+	SerialNumberTimeOrdered SerialNumberPolicy = "time_ordered"
 )
 
 var (
 	maxUint128 = getMaxUint128()
+	maxUint159 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 159), big.NewInt(1))
+	maxUint64  = new(big.Int).SetUint64(^uint64(0))
 	one        = big.NewInt(1)
 )
 
+// ParseSerialNumberPolicy parses a serial number policy. An empty string
+// returns the default policy.
+func ParseSerialNumberPolicy(s string) (SerialNumberPolicy, error) {
+	switch policy := SerialNumberPolicy(s); policy {
+	case "":
+		return SerialNumberRandom, nil
+	case SerialNumberRandom, SerialNumberRandom160, SerialNumberTimeOrdered:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unsupported serial number policy %q", s)
+	}
+}
+
+// NewSerialNumberForPolicy creates a certificate serial number following the
+// given policy. The time is only used by time ordered policies.
+func NewSerialNumberForPolicy(policy SerialNumberPolicy, now time.Time) (*big.Int, error) {
+	switch policy {
+	case "", SerialNumberRandom:
+		return NewSerialNumber()
+	case SerialNumberRandom160:
+		return newRandomSerialNumber(maxUint159)
+	case SerialNumberTimeOrdered:
+		// Random values are in range [0,MaxUint64), so the low order bits
+		// never overflow into the timestamp.
+		s, err := rand.Int(rand.Reader, maxUint64)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create random number: %w", err)
+		}
+		timestamp := new(big.Int).Lsh(big.NewInt(now.UnixNano()), 64)
+		return s.Add(s, timestamp).Add(s, one), nil
+	default:
+		return nil, fmt.Errorf("unsupported serial number policy %q", policy)
+	}
+}
+
 // NewSerialNumber creates a random certificate serial number according to CA/Browser forum spec
 // Section 7.1:
 // "Effective September 30, 2016, CAs SHALL generate non-sequential Certificate serial numbers greater than
 // zero (0) containing at least 64 bits of output from a CSPRNG"
 func NewSerialNumber() (*big.Int, error) {
-	// Creates random integer in range [0,MaxUint128)
-	s, err := rand.Int(rand.Reader, maxUint128)
+	return newRandomSerialNumber(maxUint128)
+}
+
+func newRandomSerialNumber(max *big.Int) (*big.Int, error) {
+	// Creates random integer in range [0,max)
+	s, err := rand.Int(rand.Reader, max)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create random number: %w", err)
 	}
 
-	// Adds 1 to return serial number [1,MaxUint128]
+	// Adds 1 to return serial number [1,max]
 	return s.Add(s, one), nil
 }
 
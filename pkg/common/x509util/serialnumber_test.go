@@ -3,6 +3,7 @@ package x509util
 import (
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,3 +24,48 @@ func TestMaxUint128IsMaxValueRepresentableWith128bits(t *testing.T) {
 	assert.Equal(t, 128, maxUint128.BitLen())
 	assert.Equal(t, 129, maxUint128.Add(maxUint128, one).BitLen())
 }
+
+func TestNewSerialNumberForPolicy(t *testing.T) {
+	now := time.Now()
+
+	for _, policy := range []SerialNumberPolicy{"", SerialNumberRandom, SerialNumberRandom160, SerialNumberTimeOrdered} {
+		policy := policy
+		t.Run(string(policy), func(t *testing.T) {
+			number1, err := NewSerialNumberForPolicy(policy, now)
+			require.NoError(t, err)
+			assert.Equal(t, 1, number1.Sign(), "Serial numbers must be positive")
+			// RFC 5280 limits serial numbers to 20 octets, including the sign
+			assert.LessOrEqual(t, number1.BitLen(), 159)
+
+			number2, err := NewSerialNumberForPolicy(policy, now)
+			require.NoError(t, err)
+			assert.NotEqual(t, number1, number2, "Successive serial numbers must be different")
+		})
+	}
+
+	t.Run("time ordered serial numbers sort by time", func(t *testing.T) {
+		earlier, err := NewSerialNumberForPolicy(SerialNumberTimeOrdered, now)
+		require.NoError(t, err)
+		later, err := NewSerialNumberForPolicy(SerialNumberTimeOrdered, now.Add(time.Nanosecond))
+		require.NoError(t, err)
+		assert.Equal(t, -1, earlier.Cmp(later))
+	})
+
+	t.Run("unsupported policy", func(t *testing.T) {
+		_, err := NewSerialNumberForPolicy("sequential", now)
+		require.EqualError(t, err, `unsupported serial number policy "sequential"`)
+	})
+}
+
+func TestParseSerialNumberPolicy(t *testing.T) {
+	policy, err := ParseSerialNumberPolicy("")
+	require.NoError(t, err)
+	assert.Equal(t, SerialNumberRandom, policy)
+
+	policy, err = ParseSerialNumberPolicy("time_ordered")
+	require.NoError(t, err)
+	assert.Equal(t, SerialNumberTimeOrdered, policy)
+
+	_, err = ParseSerialNumberPolicy("sequential")
+	require.EqualError(t, err, `unsupported serial number policy "sequential"`)
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	serverTelemetry "github.com/spiffe/spire/pkg/common/telemetry/server"
+	"github.com/spiffe/spire/pkg/server/datastore"
+)
+
+const (
+	// agentEvictionInterval is how often the number of attested agents is
+	// checked against the maximum.
+	agentEvictionInterval = 5 * time.Minute
+
+	// agentEvictionPageSize is the number of agents listed at once when
+	// looking for agents to evict.
+	agentEvictionPageSize = 500
+)
+
+// AgentEvictionConfig configures the eviction of agents when the number of
+// attested agents exceeds a maximum, e.g. because of a misconfigured fleet of
+// ephemeral nodes that never reuse their agent ID.
+type AgentEvictionConfig struct {
+	// MaxAgents is the number of attested agents above which agents are
+	// evicted. Eviction is disabled if zero.
+	MaxAgents int
+}
+
+// agentEvictionClass is a class of agents that can be evicted. Classes are
+// evicted in order until the number of attested agents is back to the
+// maximum. Banned agents are never evicted: deleting them would lift the
+// ban and allow them to attest again.
+type agentEvictionClass struct {
+	reason      string
+	canReattest bool
+}
+
+var agentEvictionClasses = []agentEvictionClass{
+	// Expired agents that cannot reattest can never renew their SVID.
+	{reason: "expired", canReattest: false},
+	// Expired agents that can reattest would do so with a new SVID anyway.
+	{reason: "expired_reattestable", canReattest: true},
+}
+
+// agentEvictor periodically checks the number of attested agents and, when
+// it exceeds the maximum, evicts the agents that are no longer usable, i.e.
+// agents with an expired SVID. Agents with a valid SVID and banned agents are
+// never evicted; if evicting the unusable ones is not enough, an error is
+// logged and the excess is reported as a metric, so operators can be
+// alerted.
+type agentEvictor struct {
+	c       AgentEvictionConfig
+	log     logrus.FieldLogger
+	metrics telemetry.Metrics
+	ds      datastore.DataStore
+	clock   clock.Clock
+}
+
+func newAgentEvictor(c AgentEvictionConfig, log logrus.FieldLogger, metrics telemetry.Metrics, ds datastore.DataStore, clk clock.Clock) *agentEvictor {
+	return &agentEvictor{
+		c:       c,
+		log:     log,
+		metrics: metrics,
+		ds:      ds,
+		clock:   clk,
+	}
+}
+
+func (e *agentEvictor) Run(ctx context.Context) error {
+	ticker := e.clock.Ticker(agentEvictionInterval)
+	defer ticker.Stop()
+
+	for {
+		// Log an error on failure unless we're shutting down
+		if err := e.evict(ctx); err != nil && ctx.Err() == nil {
+			e.log.WithError(err).Error("Failed to evict agents")
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (e *agentEvictor) evict(ctx context.Context) error {
+	count, err := e.ds.CountAttestedNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to count agents: %w", err)
+	}
+	serverTelemetry.SetAgentEvictorCountGauge(e.metrics, int(count))
+
+	excess := int(count) - e.c.MaxAgents
+	if excess <= 0 {
+		serverTelemetry.SetAgentEvictorExcessGauge(e.metrics, 0)
+		return nil
+	}
+
+	log := e.log.WithFields(logrus.Fields{
+		telemetry.Count: count,
+		telemetry.Limit: e.c.MaxAgents,
+	})
+	log.Warn("Number of attested agents exceeds the maximum; evicting unusable agents")
+
+	now := e.clock.Now()
+	for _, class := range agentEvictionClasses {
+		if excess <= 0 {
+			break
+		}
+		evicted, err := e.evictClass(ctx, class, now, excess)
+		if evicted > 0 {
+			serverTelemetry.IncrAgentEvictorEvictCounter(e.metrics, class.reason, evicted)
+			log.WithFields(logrus.Fields{
+				telemetry.Reason: class.reason,
+				telemetry.Count:  evicted,
+			}).Info("Evicted agents")
+		}
+		excess -= evicted
+		if err != nil {
+			return err
+		}
+	}
+
+	if excess > 0 {
+		log.WithField(telemetry.Excess, excess).Error("Number of attested agents still exceeds the maximum; agents with a valid SVID and banned agents are not evicted")
+	} else {
+		excess = 0
+	}
+	serverTelemetry.SetAgentEvictorExcessGauge(e.metrics, excess)
+	return nil
+}
+
+// evictClass evicts up to limit agents of the given class, returning how many
+// were evicted.
+func (e *agentEvictor) evictClass(ctx context.Context, class agentEvictionClass, now time.Time, limit int) (int, error) {
+	notBanned := false
+	req := &datastore.ListAttestedNodesRequest{
+		ByBanned:        &notBanned,
+		ByExpiresBefore: now,
+		ByCanReattest:   &class.canReattest,
+		Pagination:      &datastore.Pagination{},
+	}
+
+	evicted := 0
+	for evicted < limit {
+		req.Pagination.PageSize = int32(limit - evicted)
+		if req.Pagination.PageSize > agentEvictionPageSize {
+			req.Pagination.PageSize = agentEvictionPageSize
+		}
+
+		resp, err := e.ds.ListAttestedNodes(ctx, req)
+		if err != nil {
+			return evicted, fmt.Errorf("failed to list agents: %w", err)
+		}
+
+		for _, node := range resp.Nodes {
+			if _, err := e.ds.DeleteAttestedNode(ctx, node.SpiffeId); err != nil {
+				return evicted, fmt.Errorf("failed to evict agent %q: %w", node.SpiffeId, err)
+			}
+			evicted++
+		}
+
+		if len(resp.Nodes) == 0 || resp.Pagination == nil || resp.Pagination.Token == "" {
+			break
+		}
+		req.Pagination.Token = resp.Pagination.Token
+	}
+	return evicted, nil
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/pkg/server/datastore"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/spiffe/spire/test/clock"
+	"github.com/spiffe/spire/test/fakes/fakedatastore"
+	"github.com/spiffe/spire/test/fakes/fakemetrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentEvictor(t *testing.T) {
+	ctx := context.Background()
+	log, hook := test.NewNullLogger()
+	clk := clock.NewMock(t)
+	metrics := fakemetrics.New()
+	ds := fakedatastore.New(t)
+
+	createNode := func(id string, serialNumber string, expiresAt time.Time, canReattest bool) {
+		_, err := ds.CreateAttestedNode(ctx, &common.AttestedNode{
+			SpiffeId:            id,
+			AttestationDataType: "test",
+			CertSerialNumber:    serialNumber,
+			CertNotAfter:        expiresAt.Unix(),
+			CanReattest:         canReattest,
+		})
+		require.NoError(t, err)
+	}
+
+	expired := clk.Now().Add(-time.Hour)
+	valid := clk.Now().Add(time.Hour)
+	createNode("spiffe://domain.test/spire/agent/active1", "1", valid, false)
+	createNode("spiffe://domain.test/spire/agent/active2", "2", valid, true)
+	createNode("spiffe://domain.test/spire/agent/expired-reattestable", "3", expired, true)
+	createNode("spiffe://domain.test/spire/agent/expired", "4", expired, false)
+	createNode("spiffe://domain.test/spire/agent/banned", "", expired, false)
+
+	evictor := newAgentEvictor(AgentEvictionConfig{MaxAgents: 4}, log, metrics, ds, clk)
+
+	// Expired agents that cannot reattest are evicted first
+	require.NoError(t, evictor.evict(ctx))
+	assert.Equal(t, []string{
+		"spiffe://domain.test/spire/agent/active1",
+		"spiffe://domain.test/spire/agent/active2",
+		"spiffe://domain.test/spire/agent/banned",
+		"spiffe://domain.test/spire/agent/expired-reattestable",
+	}, listAgentIDs(t, ds))
+	assert.Equal(t, []fakemetrics.MetricItem{
+		agentEvictorGauge(telemetry.Count, 5),
+		agentEvictorEvictCounter("expired", 1),
+		agentEvictorGauge(telemetry.Excess, 0),
+	}, metrics.AllMetrics())
+
+	// Agents with a valid SVID and banned agents are never evicted, since
+	// evicting a banned agent would lift its ban
+	metrics.Reset()
+	hook.Reset()
+	evictor.c.MaxAgents = 1
+	require.NoError(t, evictor.evict(ctx))
+	assert.Equal(t, []string{
+		"spiffe://domain.test/spire/agent/active1",
+		"spiffe://domain.test/spire/agent/active2",
+		"spiffe://domain.test/spire/agent/banned",
+	}, listAgentIDs(t, ds))
+	assert.Equal(t, []fakemetrics.MetricItem{
+		agentEvictorGauge(telemetry.Count, 4),
+		agentEvictorEvictCounter("expired_reattestable", 1),
+		agentEvictorGauge(telemetry.Excess, 2),
+	}, metrics.AllMetrics())
+	require.NotNil(t, hook.LastEntry())
+	assert.Equal(t, logrus.ErrorLevel, hook.LastEntry().Level)
+	assert.Equal(t, 2, hook.LastEntry().Data[telemetry.Excess])
+
+	// Nothing is evicted while under the maximum
+	metrics.Reset()
+	evictor.c.MaxAgents = 3
+	require.NoError(t, evictor.evict(ctx))
+	assert.Len(t, listAgentIDs(t, ds), 3)
+	assert.Equal(t, []fakemetrics.MetricItem{
+		agentEvictorGauge(telemetry.Count, 3),
+		agentEvictorGauge(telemetry.Excess, 0),
+	}, metrics.AllMetrics())
+}
+
+func listAgentIDs(t *testing.T, ds datastore.DataStore) []string {
+	resp, err := ds.ListAttestedNodes(context.Background(), &datastore.ListAttestedNodesRequest{})
+	require.NoError(t, err)
+	var ids []string
+	for _, node := range resp.Nodes {
+		ids = append(ids, node.SpiffeId)
+	}
+	return ids
+}
+
+func agentEvictorGauge(name string, val float32) fakemetrics.MetricItem {
+	return fakemetrics.MetricItem{
+		Type: fakemetrics.SetGaugeType,
+		Key:  []string{telemetry.AgentEvictor, name},
+		Val:  val,
+	}
+}
+
+func agentEvictorEvictCounter(reason string, val float32) fakemetrics.MetricItem {
+	return fakemetrics.MetricItem{
+		Type:   fakemetrics.IncrCounterWithLabelsType,
+		Key:    []string{telemetry.AgentEvictor, telemetry.Evict},
+		Val:    val,
+		Labels: telemetry.SanitizeLabels([]telemetry.Label{{Name: telemetry.Reason, Value: reason}}),
+	}
+}
@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"context"
+
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	"google.golang.org/grpc"
+)
+
+// Client is a client of the admin service
+type Client struct {
+	conn grpc.ClientConnInterface
+}
+
+// NewClient creates a new admin service client
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{conn: conn}
+}
+
+func (c *Client) ListPendingAgents(ctx context.Context, req *ListPendingAgentsRequest) (*ListPendingAgentsResponse, error) {
+	resp := new(ListPendingAgentsResponse)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "ListPendingAgents", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) ApprovePendingAgent(ctx context.Context, req *ApprovePendingAgentRequest) (*PendingAgent, error) {
+	resp := new(PendingAgent)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "ApprovePendingAgent", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) DeletePendingAgent(ctx context.Context, req *DeletePendingAgentRequest) error {
+	return adminapi.Invoke(ctx, c.conn, ServiceName, "DeletePendingAgent", req, nil)
+}
+
+func (c *Client) ListAttestationEvents(ctx context.Context, req *ListAttestationEventsRequest) (*ListAttestationEventsResponse, error) {
+	resp := new(ListAttestationEventsResponse)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "ListAttestationEvents", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) ListIssuanceRecords(ctx context.Context, req *ListIssuanceRecordsRequest) (*ListIssuanceRecordsResponse, error) {
+	resp := new(ListIssuanceRecordsResponse)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "ListIssuanceRecords", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) GetEntryCacheStatus(ctx context.Context) (*EntryCacheStatus, error) {
+	resp := new(EntryCacheStatus)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "GetEntryCacheStatus", nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) ReloadEntryCache(ctx context.Context) (*EntryCacheStatus, error) {
+	resp := new(EntryCacheStatus)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "ReloadEntryCache", nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) GetUpstreamAuthorityStatus(ctx context.Context) (*UpstreamAuthorityStatus, error) {
+	resp := new(UpstreamAuthorityStatus)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "GetUpstreamAuthorityStatus", nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) ListEvents(ctx context.Context) (*ListEventsResponse, error) {
+	resp := new(ListEventsResponse)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "ListEvents", nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) ListSelectorSets(ctx context.Context) (*ListSelectorSetsResponse, error) {
+	resp := new(ListSelectorSetsResponse)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "ListSelectorSets", nil, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) GetSelectorSet(ctx context.Context, req *GetSelectorSetRequest) (*SelectorSet, error) {
+	resp := new(SelectorSet)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "GetSelectorSet", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) CreateSelectorSet(ctx context.Context, req *SelectorSet) (*SelectorSet, error) {
+	resp := new(SelectorSet)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "CreateSelectorSet", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) UpdateSelectorSet(ctx context.Context, req *SelectorSet) (*SelectorSet, error) {
+	resp := new(SelectorSet)
+	if err := adminapi.Invoke(ctx, c.conn, ServiceName, "UpdateSelectorSet", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) DeleteSelectorSet(ctx context.Context, req *DeleteSelectorSetRequest) error {
+	return adminapi.Invoke(ctx, c.conn, ServiceName, "DeleteSelectorSet", req, nil)
+}
@@ -0,0 +1,793 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	"github.com/spiffe/spire/pkg/common/log"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/pkg/server/api"
+	"github.com/spiffe/spire/pkg/server/api/rpccontext"
+	"github.com/spiffe/spire/pkg/server/datastore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName is the name of the admin service. It is served through the
+// adminapi package.
+const ServiceName = "spire.server.admin.Admin"
+
+// maxAttestationEventsLimit is the maximum number of attestation events
+// returned by ListAttestationEvents.
+const maxAttestationEventsLimit = 1000
+
+// EntryCache is the in-memory registration entry cache of the server.
+type EntryCache interface {
+	// Reload fully reloads the cache from the datastore.
+	Reload(ctx context.Context) error
+
+	// Status compares the cache to one freshly built from the datastore.
+	Status(ctx context.Context) (*EntryCacheStatus, error)
+}
+
+// UpstreamAuthority reports the status of the upstream authority of the
+// server CA.
+type UpstreamAuthority interface {
+	// UpstreamAuthorityStatus returns the status of the upstream authority,
+	// or false if no UpstreamAuthority plugin is configured.
+	UpstreamAuthorityStatus() (*UpstreamAuthorityStatus, bool)
+}
+
+// EventSource returns the recent significant log entries of the server.
+type EventSource interface {
+	Events() []log.Event
+}
+
+// PendingAgent is an agent held for approval by an administrator.
+type PendingAgent struct {
+	SpiffeID        string    `json:"spiffe_id"`
+	AttestationType string    `json:"attestation_type"`
+	Approved        bool      `json:"approved"`
+	RequestedAt     time.Time `json:"requested_at"`
+	LastAttemptAt   time.Time `json:"last_attempt_at"`
+}
+
+type ListPendingAgentsRequest struct {
+	// ByAttestationType, if set, only lists the agents that attested with
+	// the given node attestor.
+	ByAttestationType string `json:"by_attestation_type,omitempty"`
+
+	// ByApproved, if set, only lists the agents with the given approval
+	// state.
+	ByApproved *bool `json:"by_approved,omitempty"`
+}
+
+type ListPendingAgentsResponse struct {
+	Agents []*PendingAgent `json:"agents"`
+}
+
+type ApprovePendingAgentRequest struct {
+	SpiffeID string `json:"spiffe_id"`
+}
+
+type DeletePendingAgentRequest struct {
+	SpiffeID string `json:"spiffe_id"`
+}
+
+// AttestationEvent is an attestation of an agent, successful or not.
+type AttestationEvent struct {
+	// SpiffeID is the SPIFFE ID of the agent. It is empty for failed
+	// attestations that failed before the agent ID was known.
+	SpiffeID        string    `json:"spiffe_id,omitempty"`
+	AttestationType string    `json:"attestation_type"`
+	SerialNumber    string    `json:"serial_number,omitempty"`
+	CanReattest     bool      `json:"can_reattest"`
+	Reattestation   bool      `json:"reattestation"`
+	AttestedAt      time.Time `json:"attested_at"`
+
+	// Error is the reason the attestation failed. It is empty for
+	// successful attestations.
+	Error string `json:"error,omitempty"`
+
+	// Selectors are the selectors produced by the node attestor for failed
+	// attestations, formatted as type:value.
+	Selectors []string `json:"selectors,omitempty"`
+}
+
+type ListAttestationEventsRequest struct {
+	// BySpiffeID, if set, only lists the attestations of the given agent.
+	BySpiffeID string `json:"by_spiffe_id,omitempty"`
+
+	// FailedOnly, if true, only lists failed attestations.
+	FailedOnly bool `json:"failed_only,omitempty"`
+
+	// Limit is the maximum number of attestations listed, most recent
+	// first. It is capped at 1000, which is also the default.
+	Limit int32 `json:"limit,omitempty"`
+}
+
+type ListAttestationEventsResponse struct {
+	Events []*AttestationEvent `json:"events"`
+}
+
+// IssuanceRecord is a record of an X509 certificate signed by the server CA.
+type IssuanceRecord struct {
+	// SerialNumber is the serial number of the certificate, in decimal.
+	SerialNumber string `json:"serial_number"`
+	SpiffeID     string `json:"spiffe_id"`
+
+	// EntryID is the ID of the registration entry the certificate was
+	// issued for, if any.
+	EntryID   string    `json:"entry_id,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type ListIssuanceRecordsRequest struct {
+	// BySerialNumber, if set, only lists the record of the certificate with
+	// the given serial number, in decimal.
+	BySerialNumber string `json:"by_serial_number,omitempty"`
+
+	// BySpiffeID, if set, only lists the records of the certificates issued
+	// for the given SPIFFE ID.
+	BySpiffeID string `json:"by_spiffe_id,omitempty"`
+
+	// ByEntryID, if set, only lists the records of the certificates issued
+	// for the given registration entry.
+	ByEntryID string `json:"by_entry_id,omitempty"`
+
+	// PageSize, if greater than zero, is the maximum number of records
+	// listed, oldest first. The listing continues from PageToken.
+	PageSize  int32  `json:"page_size,omitempty"`
+	PageToken string `json:"page_token,omitempty"`
+}
+
+type ListIssuanceRecordsResponse struct {
+	Records       []*IssuanceRecord `json:"records"`
+	NextPageToken string            `json:"next_page_token,omitempty"`
+}
+
+// EntryCacheStatus compares the in-memory entry cache of the server to the
+// datastore.
+type EntryCacheStatus struct {
+	// EntryCount is the number of entries in the cache.
+	EntryCount int32 `json:"entry_count"`
+
+	// DataStoreEntryCount is the number of entries the cache would hold if
+	// it were reloaded now.
+	DataStoreEntryCount int32 `json:"datastore_entry_count"`
+
+	// StaleEntryIDs are the IDs of the entries that are missing from the
+	// cache, no longer exist, or differ from the datastore, including node
+	// aliases that now apply to different agents. At most 100 are listed.
+	StaleEntryIDs []string `json:"stale_entry_ids,omitempty"`
+
+	// Stale is true if the cache differs from the datastore.
+	Stale bool `json:"stale"`
+
+	LastReload time.Time `json:"last_reload"`
+
+	// LastReloadDuration is the time the last full reload took.
+	LastReloadDuration time.Duration `json:"last_reload_duration"`
+}
+
+// UpstreamAuthorityStatus describes the upstream authority of the server CA.
+type UpstreamAuthorityStatus struct {
+	// PluginName is the name of the UpstreamAuthority plugin.
+	PluginName string `json:"plugin_name"`
+
+	// X509CA is the active X509 CA certificate, or nil if none is active yet.
+	X509CA *Certificate `json:"x509_ca,omitempty"`
+
+	// UpstreamChain is the chain the upstream authority issued the active
+	// X509 CA with, starting with the X509 CA certificate.
+	UpstreamChain []*Certificate `json:"upstream_chain"`
+
+	// LastMint is the time an X509 CA was last minted by the upstream
+	// authority since the server started, or nil if none was.
+	LastMint *time.Time `json:"last_mint,omitempty"`
+
+	// RecentErrors are the most recent errors returned by the upstream
+	// authority, oldest first.
+	RecentErrors []*UpstreamAuthorityError `json:"recent_errors"`
+}
+
+// Certificate describes an X509 certificate.
+type Certificate struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"`
+}
+
+// UpstreamAuthorityError is an error returned by the upstream authority.
+type UpstreamAuthorityError struct {
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// SelectorSet is a named group of selectors. Registration entries reference
+// it with a selector of type "selector_set" whose value is the set name.
+type SelectorSet struct {
+	Name string `json:"name"`
+
+	// Selectors are formatted as type:value.
+	Selectors []string `json:"selectors"`
+}
+
+type ListSelectorSetsResponse struct {
+	SelectorSets []*SelectorSet `json:"selector_sets"`
+}
+
+type GetSelectorSetRequest struct {
+	Name string `json:"name"`
+}
+
+type DeleteSelectorSetRequest struct {
+	Name string `json:"name"`
+}
+
+type ListEventsResponse struct {
+	// Events are the recent log entries at the INFO level or above, oldest
+	// first.
+	Events []log.Event `json:"events"`
+}
+
+// RegisterService registers the admin service on the gRPC server.
+func RegisterService(s grpc.ServiceRegistrar, service *Service) {
+	adminapi.Register(s, adminapi.Service{
+		Name: ServiceName,
+		Methods: map[string]adminapi.Handler{
+			"ListPendingAgents": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(ListPendingAgentsRequest)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				return service.ListPendingAgents(ctx, req)
+			},
+			"ApprovePendingAgent": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(ApprovePendingAgentRequest)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				return service.ApprovePendingAgent(ctx, req)
+			},
+			"DeletePendingAgent": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(DeletePendingAgentRequest)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				return nil, service.DeletePendingAgent(ctx, req)
+			},
+			"ListAttestationEvents": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(ListAttestationEventsRequest)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				return service.ListAttestationEvents(ctx, req)
+			},
+			"ListIssuanceRecords": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(ListIssuanceRecordsRequest)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				return service.ListIssuanceRecords(ctx, req)
+			},
+			"GetEntryCacheStatus": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				if err := decode(&struct{}{}); err != nil {
+					return nil, err
+				}
+				return service.GetEntryCacheStatus(ctx)
+			},
+			"ReloadEntryCache": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				if err := decode(&struct{}{}); err != nil {
+					return nil, err
+				}
+				return service.ReloadEntryCache(ctx)
+			},
+			"GetUpstreamAuthorityStatus": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				if err := decode(&struct{}{}); err != nil {
+					return nil, err
+				}
+				return service.GetUpstreamAuthorityStatus(ctx)
+			},
+			"ListEvents": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				if err := decode(&struct{}{}); err != nil {
+					return nil, err
+				}
+				return service.ListEvents(ctx)
+			},
+			"ListSelectorSets": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				if err := decode(&struct{}{}); err != nil {
+					return nil, err
+				}
+				return service.ListSelectorSets(ctx)
+			},
+			"GetSelectorSet": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(GetSelectorSetRequest)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				return service.GetSelectorSet(ctx, req)
+			},
+			"CreateSelectorSet": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(SelectorSet)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				return service.CreateSelectorSet(ctx, req)
+			},
+			"UpdateSelectorSet": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(SelectorSet)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				return service.UpdateSelectorSet(ctx, req)
+			},
+			"DeleteSelectorSet": func(ctx context.Context, decode func(interface{}) error) (interface{}, error) {
+				req := new(DeleteSelectorSetRequest)
+				if err := decode(req); err != nil {
+					return nil, err
+				}
+				return nil, service.DeleteSelectorSet(ctx, req)
+			},
+		},
+	})
+}
+
+// Config is the configuration for the admin service
+type Config struct {
+	TrustDomain spiffeid.TrustDomain
+	DataStore   datastore.DataStore
+
+	// EntryCache is the in-memory entry cache. The entry cache methods are
+	// unavailable if it is not set.
+	EntryCache EntryCache
+
+	// UpstreamAuthority reports the status of the upstream authority. The
+	// upstream authority status is unavailable if it is not set.
+	UpstreamAuthority UpstreamAuthority
+
+	// Events provides the recent significant log entries. It is not set
+	// when the event buffer is disabled.
+	Events EventSource
+}
+
+// Service implements the admin service
+type Service struct {
+	td                spiffeid.TrustDomain
+	ds                datastore.DataStore
+	entryCache        EntryCache
+	upstreamAuthority UpstreamAuthority
+	events            EventSource
+}
+
+// New creates a new admin service
+func New(config Config) *Service {
+	return &Service{
+		td:                config.TrustDomain,
+		ds:                config.DataStore,
+		entryCache:        config.EntryCache,
+		upstreamAuthority: config.UpstreamAuthority,
+		events:            config.Events,
+	}
+}
+
+// ListPendingAgents lists the agents pending approval, oldest first.
+func (s *Service) ListPendingAgents(ctx context.Context, req *ListPendingAgentsRequest) (*ListPendingAgentsResponse, error) {
+	log := rpccontext.Logger(ctx)
+
+	resp, err := s.ds.ListPendingAgents(ctx, &datastore.ListPendingAgentsRequest{
+		ByAttestationType: req.ByAttestationType,
+		ByApproved:        req.ByApproved,
+	})
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to list pending agents", err)
+	}
+
+	agents := make([]*PendingAgent, 0, len(resp.Agents))
+	for _, agent := range resp.Agents {
+		agents = append(agents, pendingAgentFromDatastore(agent))
+	}
+	return &ListPendingAgentsResponse{Agents: agents}, nil
+}
+
+// ApprovePendingAgent approves an agent pending approval. The agent is
+// issued an SVID the next time it attests.
+func (s *Service) ApprovePendingAgent(ctx context.Context, req *ApprovePendingAgentRequest) (*PendingAgent, error) {
+	log := rpccontext.Logger(ctx)
+
+	id, err := s.agentID(req.SpiffeID)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.InvalidArgument, "invalid agent ID", err)
+	}
+	rpccontext.AddRPCAuditFields(ctx, logrus.Fields{telemetry.SPIFFEID: id.String()})
+	log = log.WithField(telemetry.SPIFFEID, id.String())
+
+	agent, err := s.ds.ApprovePendingAgent(ctx, id.String())
+	switch status.Code(err) {
+	case codes.OK:
+		log.Info("Pending agent approved")
+		rpccontext.AuditRPC(ctx)
+		return pendingAgentFromDatastore(agent), nil
+	case codes.NotFound:
+		return nil, api.MakeErr(log, codes.NotFound, "pending agent not found", err)
+	default:
+		return nil, api.MakeErr(log, codes.Internal, "failed to approve pending agent", err)
+	}
+}
+
+// DeletePendingAgent deletes an agent pending approval. If the agent
+// attests again, it is held for approval again.
+func (s *Service) DeletePendingAgent(ctx context.Context, req *DeletePendingAgentRequest) error {
+	log := rpccontext.Logger(ctx)
+
+	id, err := s.agentID(req.SpiffeID)
+	if err != nil {
+		return api.MakeErr(log, codes.InvalidArgument, "invalid agent ID", err)
+	}
+	rpccontext.AddRPCAuditFields(ctx, logrus.Fields{telemetry.SPIFFEID: id.String()})
+	log = log.WithField(telemetry.SPIFFEID, id.String())
+
+	agent, err := s.ds.FetchPendingAgent(ctx, id.String())
+	if err != nil {
+		return api.MakeErr(log, codes.Internal, "failed to fetch pending agent", err)
+	}
+	if agent == nil {
+		return api.MakeErr(log, codes.NotFound, "pending agent not found", nil)
+	}
+
+	if err := s.ds.DeletePendingAgent(ctx, id.String()); err != nil {
+		return api.MakeErr(log, codes.Internal, "failed to delete pending agent", err)
+	}
+	log.Info("Pending agent deleted")
+	rpccontext.AuditRPC(ctx)
+	return nil
+}
+
+// ListAttestationEvents lists the recorded attestations of agents, most
+// recent first.
+func (s *Service) ListAttestationEvents(ctx context.Context, req *ListAttestationEventsRequest) (*ListAttestationEventsResponse, error) {
+	log := rpccontext.Logger(ctx)
+
+	var bySpiffeID string
+	if req.BySpiffeID != "" {
+		id, err := s.agentID(req.BySpiffeID)
+		if err != nil {
+			return nil, api.MakeErr(log, codes.InvalidArgument, "invalid agent ID", err)
+		}
+		bySpiffeID = id.String()
+	}
+
+	limit := req.Limit
+	if limit <= 0 || limit > maxAttestationEventsLimit {
+		limit = maxAttestationEventsLimit
+	}
+
+	resp, err := s.ds.ListAttestationEvents(ctx, &datastore.ListAttestationEventsRequest{
+		BySpiffeID: bySpiffeID,
+		FailedOnly: req.FailedOnly,
+		Limit:      limit,
+	})
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to list attestation events", err)
+	}
+
+	events := make([]*AttestationEvent, 0, len(resp.Events))
+	for _, event := range resp.Events {
+		events = append(events, attestationEventFromDatastore(event))
+	}
+	return &ListAttestationEventsResponse{Events: events}, nil
+}
+
+// ListIssuanceRecords lists the records of the X509 certificates signed by
+// the server CA, oldest first.
+func (s *Service) ListIssuanceRecords(ctx context.Context, req *ListIssuanceRecordsRequest) (*ListIssuanceRecordsResponse, error) {
+	log := rpccontext.Logger(ctx)
+
+	if req.BySerialNumber != "" {
+		if _, ok := new(big.Int).SetString(req.BySerialNumber, 10); !ok {
+			return nil, api.MakeErr(log, codes.InvalidArgument, "invalid serial number", fmt.Errorf("%q is not a decimal number", req.BySerialNumber))
+		}
+	}
+	var bySpiffeID string
+	if req.BySpiffeID != "" {
+		id, err := spiffeid.FromString(req.BySpiffeID)
+		if err != nil {
+			return nil, api.MakeErr(log, codes.InvalidArgument, "invalid SPIFFE ID", err)
+		}
+		bySpiffeID = id.String()
+	}
+
+	listReq := &datastore.ListIssuanceRecordsRequest{
+		BySerialNumber: req.BySerialNumber,
+		BySpiffeID:     bySpiffeID,
+		ByEntryID:      req.ByEntryID,
+	}
+	if req.PageSize > 0 {
+		listReq.Pagination = &datastore.Pagination{
+			PageSize: req.PageSize,
+			Token:    req.PageToken,
+		}
+	}
+
+	dsResp, err := s.ds.ListIssuanceRecords(ctx, listReq)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to list issuance records", err)
+	}
+
+	resp := &ListIssuanceRecordsResponse{
+		Records: make([]*IssuanceRecord, 0, len(dsResp.Records)),
+	}
+	if dsResp.Pagination != nil {
+		resp.NextPageToken = dsResp.Pagination.Token
+	}
+	for _, record := range dsResp.Records {
+		resp.Records = append(resp.Records, &IssuanceRecord{
+			SerialNumber: record.SerialNumber,
+			SpiffeID:     record.SpiffeID,
+			EntryID:      record.EntryID,
+			ExpiresAt:    record.ExpiresAt.UTC(),
+		})
+	}
+	return resp, nil
+}
+
+// GetEntryCacheStatus compares the in-memory entry cache to the datastore.
+// It reads every entry and agent from the datastore, like a reload does.
+func (s *Service) GetEntryCacheStatus(ctx context.Context) (*EntryCacheStatus, error) {
+	log := rpccontext.Logger(ctx)
+
+	if s.entryCache == nil {
+		return nil, api.MakeErr(log, codes.Unavailable, "entry cache is not available", nil)
+	}
+	cacheStatus, err := s.entryCache.Status(ctx)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to compare entry cache to the datastore", err)
+	}
+	return cacheStatus, nil
+}
+
+// ReloadEntryCache fully reloads the in-memory entry cache from the
+// datastore and returns its status afterwards.
+func (s *Service) ReloadEntryCache(ctx context.Context) (*EntryCacheStatus, error) {
+	log := rpccontext.Logger(ctx)
+
+	if s.entryCache == nil {
+		return nil, api.MakeErr(log, codes.Unavailable, "entry cache is not available", nil)
+	}
+	if err := s.entryCache.Reload(ctx); err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to reload entry cache", err)
+	}
+	log.Info("Entry cache reloaded")
+	rpccontext.AuditRPC(ctx)
+
+	return s.GetEntryCacheStatus(ctx)
+}
+
+// GetUpstreamAuthorityStatus returns the status of the upstream authority:
+// the active X509 CA and the chain it was issued with, the last time an X509
+// CA was minted, and the recent errors.
+func (s *Service) GetUpstreamAuthorityStatus(ctx context.Context) (*UpstreamAuthorityStatus, error) {
+	log := rpccontext.Logger(ctx)
+
+	if s.upstreamAuthority == nil {
+		return nil, api.MakeErr(log, codes.Unavailable, "upstream authority status is not available", nil)
+	}
+	upstreamStatus, ok := s.upstreamAuthority.UpstreamAuthorityStatus()
+	if !ok {
+		return nil, api.MakeErr(log, codes.NotFound, "no upstream authority is configured", nil)
+	}
+	return upstreamStatus, nil
+}
+
+// ListEvents lists the recent significant log entries of the server, oldest
+// first.
+func (s *Service) ListEvents(ctx context.Context) (*ListEventsResponse, error) {
+	if s.events == nil {
+		return nil, api.MakeErr(rpccontext.Logger(ctx), codes.FailedPrecondition, "event buffer is disabled; set event_buffer_size to enable it", nil)
+	}
+	return &ListEventsResponse{Events: s.events.Events()}, nil
+}
+
+// ListSelectorSets lists the selector sets, by name.
+func (s *Service) ListSelectorSets(ctx context.Context) (*ListSelectorSetsResponse, error) {
+	log := rpccontext.Logger(ctx)
+
+	sets, err := s.ds.ListSelectorSets(ctx)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to list selector sets", err)
+	}
+
+	resp := &ListSelectorSetsResponse{
+		SelectorSets: make([]*SelectorSet, 0, len(sets)),
+	}
+	for _, set := range sets {
+		resp.SelectorSets = append(resp.SelectorSets, selectorSetFromDatastore(set))
+	}
+	return resp, nil
+}
+
+// GetSelectorSet returns the selector set with the given name.
+func (s *Service) GetSelectorSet(ctx context.Context, req *GetSelectorSetRequest) (*SelectorSet, error) {
+	log := rpccontext.Logger(ctx)
+
+	if req.Name == "" {
+		return nil, api.MakeErr(log, codes.InvalidArgument, "missing selector set name", nil)
+	}
+	log = log.WithField(telemetry.SelectorSet, req.Name)
+
+	set, err := s.ds.FetchSelectorSet(ctx, req.Name)
+	switch {
+	case err != nil:
+		return nil, api.MakeErr(log, codes.Internal, "failed to fetch selector set", err)
+	case set == nil:
+		return nil, api.MakeErr(log, codes.NotFound, "selector set not found", nil)
+	}
+	return selectorSetFromDatastore(set), nil
+}
+
+// CreateSelectorSet creates a selector set.
+func (s *Service) CreateSelectorSet(ctx context.Context, req *SelectorSet) (*SelectorSet, error) {
+	log := rpccontext.Logger(ctx)
+
+	set, err := selectorSetToDatastore(req)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.InvalidArgument, "invalid selector set", err)
+	}
+	rpccontext.AddRPCAuditFields(ctx, logrus.Fields{telemetry.SelectorSet: set.Name})
+	log = log.WithField(telemetry.SelectorSet, set.Name)
+
+	existing, err := s.ds.FetchSelectorSet(ctx, set.Name)
+	switch {
+	case err != nil:
+		return nil, api.MakeErr(log, codes.Internal, "failed to fetch selector set", err)
+	case existing != nil:
+		return nil, api.MakeErr(log, codes.AlreadyExists, "selector set already exists", nil)
+	}
+
+	created, err := s.ds.CreateSelectorSet(ctx, set)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.Internal, "failed to create selector set", err)
+	}
+	log.Info("Selector set created")
+	rpccontext.AuditRPC(ctx)
+	return selectorSetFromDatastore(created), nil
+}
+
+// UpdateSelectorSet replaces the selectors of a selector set. The change
+// applies to every registration entry that references the set.
+func (s *Service) UpdateSelectorSet(ctx context.Context, req *SelectorSet) (*SelectorSet, error) {
+	log := rpccontext.Logger(ctx)
+
+	set, err := selectorSetToDatastore(req)
+	if err != nil {
+		return nil, api.MakeErr(log, codes.InvalidArgument, "invalid selector set", err)
+	}
+	rpccontext.AddRPCAuditFields(ctx, logrus.Fields{telemetry.SelectorSet: set.Name})
+	log = log.WithField(telemetry.SelectorSet, set.Name)
+
+	updated, err := s.ds.UpdateSelectorSet(ctx, set)
+	switch status.Code(err) {
+	case codes.OK:
+		log.Info("Selector set updated")
+		rpccontext.AuditRPC(ctx)
+		return selectorSetFromDatastore(updated), nil
+	case codes.NotFound:
+		return nil, api.MakeErr(log, codes.NotFound, "selector set not found", err)
+	default:
+		return nil, api.MakeErr(log, codes.Internal, "failed to update selector set", err)
+	}
+}
+
+// DeleteSelectorSet deletes a selector set. Selector sets referenced by
+// registration entries cannot be deleted.
+func (s *Service) DeleteSelectorSet(ctx context.Context, req *DeleteSelectorSetRequest) error {
+	log := rpccontext.Logger(ctx)
+
+	if req.Name == "" {
+		return api.MakeErr(log, codes.InvalidArgument, "missing selector set name", nil)
+	}
+	rpccontext.AddRPCAuditFields(ctx, logrus.Fields{telemetry.SelectorSet: req.Name})
+	log = log.WithField(telemetry.SelectorSet, req.Name)
+
+	err := s.ds.DeleteSelectorSet(ctx, req.Name)
+	switch status.Code(err) {
+	case codes.OK:
+		log.Info("Selector set deleted")
+		rpccontext.AuditRPC(ctx)
+		return nil
+	case codes.NotFound:
+		return api.MakeErr(log, codes.NotFound, "selector set not found", err)
+	case codes.FailedPrecondition:
+		return api.MakeErr(log, codes.FailedPrecondition, "selector set is in use", err)
+	default:
+		return api.MakeErr(log, codes.Internal, "failed to delete selector set", err)
+	}
+}
+
+func (s *Service) agentID(rawID string) (spiffeid.ID, error) {
+	id, err := spiffeid.FromString(rawID)
+	if err != nil {
+		return spiffeid.ID{}, err
+	}
+	if !id.MemberOf(s.td) {
+		return spiffeid.ID{}, fmt.Errorf("%q is not a member of trust domain %q", id, s.td)
+	}
+	return id, nil
+}
+
+func pendingAgentFromDatastore(agent *datastore.PendingAgent) *PendingAgent {
+	return &PendingAgent{
+		SpiffeID:        agent.SpiffeID,
+		AttestationType: agent.AttestationType,
+		Approved:        agent.Approved,
+		RequestedAt:     agent.RequestedAt.UTC(),
+		LastAttemptAt:   agent.LastAttemptAt.UTC(),
+	}
+}
+
+func attestationEventFromDatastore(event *datastore.AttestationEvent) *AttestationEvent {
+	var selectors []string
+	for _, selector := range event.Selectors {
+		selectors = append(selectors, selector.Type+":"+selector.Value)
+	}
+	return &AttestationEvent{
+		SpiffeID:        event.SpiffeID,
+		AttestationType: event.AttestationType,
+		SerialNumber:    event.SerialNumber,
+		CanReattest:     event.CanReattest,
+		Reattestation:   event.Reattestation,
+		AttestedAt:      event.AttestedAt.UTC(),
+		Error:           event.Error,
+		Selectors:       selectors,
+	}
+}
+
+func selectorSetFromDatastore(set *datastore.SelectorSet) *SelectorSet {
+	selectors := make([]string, 0, len(set.Selectors))
+	for _, selector := range set.Selectors {
+		selectors = append(selectors, selector.Type+":"+selector.Value)
+	}
+	return &SelectorSet{
+		Name:      set.Name,
+		Selectors: selectors,
+	}
+}
+
+func selectorSetToDatastore(set *SelectorSet) (*datastore.SelectorSet, error) {
+	if set.Name == "" {
+		return nil, errors.New("missing name")
+	}
+	if len(set.Selectors) == 0 {
+		return nil, errors.New("at least one selector is required")
+	}
+
+	selectors := make([]*types.Selector, 0, len(set.Selectors))
+	for _, selector := range set.Selectors {
+		selectorType, value, ok := strings.Cut(selector, ":")
+		if !ok {
+			return nil, fmt.Errorf("selector %q must be formatted as type:value", selector)
+		}
+		if selectorType == datastore.SelectorSetType {
+			return nil, errors.New("selector sets cannot reference other selector sets")
+		}
+		selectors = append(selectors, &types.Selector{Type: selectorType, Value: value})
+	}
+	dsSelectors, err := api.SelectorsFromProto(selectors)
+	if err != nil {
+		return nil, err
+	}
+	return &datastore.SelectorSet{
+		Name:      set.Name,
+		Selectors: dsSelectors,
+	}, nil
+}
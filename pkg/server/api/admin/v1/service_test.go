@@ -0,0 +1,606 @@
+package admin_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/common/adminapi"
+	commonlog "github.com/spiffe/spire/pkg/common/log"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	admin "github.com/spiffe/spire/pkg/server/api/admin/v1"
+	"github.com/spiffe/spire/pkg/server/api/rpccontext"
+	"github.com/spiffe/spire/pkg/server/datastore"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/spiffe/spire/test/fakes/fakedatastore"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+var (
+	td      = spiffeid.RequireTrustDomainFromString("example.org")
+	agent1  = "spiffe://example.org/spire/agent/x509pop/agent1"
+	agent2  = "spiffe://example.org/spire/agent/join_token/agent2"
+	unknown = "spiffe://example.org/spire/agent/x509pop/unknown"
+)
+
+func TestPendingAgents(t *testing.T) {
+	test := setupServiceTest(t)
+	ctx := context.Background()
+
+	requestedAt := time.Unix(1000, 0).UTC()
+	_, err := test.ds.SetPendingAgent(ctx, &datastore.PendingAgent{
+		SpiffeID:        agent1,
+		AttestationType: "x509pop",
+		LastAttemptAt:   requestedAt,
+	})
+	require.NoError(t, err)
+	_, err = test.ds.SetPendingAgent(ctx, &datastore.PendingAgent{
+		SpiffeID:        agent2,
+		AttestationType: "join_token",
+		LastAttemptAt:   requestedAt.Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	pending1 := &admin.PendingAgent{
+		SpiffeID:        agent1,
+		AttestationType: "x509pop",
+		RequestedAt:     requestedAt,
+		LastAttemptAt:   requestedAt,
+	}
+	pending2 := &admin.PendingAgent{
+		SpiffeID:        agent2,
+		AttestationType: "join_token",
+		RequestedAt:     requestedAt.Add(time.Minute),
+		LastAttemptAt:   requestedAt.Add(time.Minute),
+	}
+
+	resp, err := test.client.ListPendingAgents(ctx, &admin.ListPendingAgentsRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []*admin.PendingAgent{pending1, pending2}, resp.Agents)
+
+	resp, err = test.client.ListPendingAgents(ctx, &admin.ListPendingAgentsRequest{ByAttestationType: "join_token"})
+	require.NoError(t, err)
+	require.Equal(t, []*admin.PendingAgent{pending2}, resp.Agents)
+
+	approved, err := test.client.ApprovePendingAgent(ctx, &admin.ApprovePendingAgentRequest{SpiffeID: agent1})
+	require.NoError(t, err)
+	pending1.Approved = true
+	require.Equal(t, pending1, approved)
+	spiretest.AssertLastLogs(t, test.logHook.AllEntries(), []spiretest.LogEntry{
+		{
+			Level:   logrus.InfoLevel,
+			Message: "Pending agent approved",
+			Data: logrus.Fields{
+				telemetry.SPIFFEID: agent1,
+			},
+		},
+	})
+
+	approvedOnly := true
+	resp, err = test.client.ListPendingAgents(ctx, &admin.ListPendingAgentsRequest{ByApproved: &approvedOnly})
+	require.NoError(t, err)
+	require.Equal(t, []*admin.PendingAgent{pending1}, resp.Agents)
+
+	require.NoError(t, test.client.DeletePendingAgent(ctx, &admin.DeletePendingAgentRequest{SpiffeID: agent2}))
+	resp, err = test.client.ListPendingAgents(ctx, &admin.ListPendingAgentsRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []*admin.PendingAgent{pending1}, resp.Agents)
+}
+
+func TestApprovePendingAgentErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		id      string
+		code    codes.Code
+		message string
+	}{
+		{
+			name:    "missing ID",
+			code:    codes.InvalidArgument,
+			message: "invalid agent ID: cannot be empty",
+		},
+		{
+			name:    "foreign trust domain",
+			id:      "spiffe://other.org/spire/agent/x509pop/agent1",
+			code:    codes.InvalidArgument,
+			message: `invalid agent ID: "spiffe://other.org/spire/agent/x509pop/agent1" is not a member of trust domain "example.org"`,
+		},
+		{
+			name:    "not pending",
+			id:      unknown,
+			code:    codes.NotFound,
+			message: "pending agent not found",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			test := setupServiceTest(t)
+
+			_, err := test.client.ApprovePendingAgent(context.Background(), &admin.ApprovePendingAgentRequest{SpiffeID: tt.id})
+			spiretest.RequireGRPCStatusHasPrefix(t, err, tt.code, tt.message)
+
+			err = test.client.DeletePendingAgent(context.Background(), &admin.DeletePendingAgentRequest{SpiffeID: tt.id})
+			spiretest.RequireGRPCStatusHasPrefix(t, err, tt.code, tt.message)
+		})
+	}
+}
+
+func TestListAttestationEvents(t *testing.T) {
+	test := setupServiceTest(t)
+	ctx := context.Background()
+
+	attestedAt := time.Unix(1000, 0).UTC()
+	for _, event := range []*datastore.AttestationEvent{
+		{
+			SpiffeID:        agent1,
+			AttestationType: "x509pop",
+			SerialNumber:    "1",
+			CanReattest:     true,
+			AttestedAt:      attestedAt,
+		},
+		{
+			AttestationType: "x509pop",
+			Error:           "failed to attest: certificate is expired",
+			Selectors:       []*common.Selector{{Type: "x509pop", Value: "subject:cn:agent1"}},
+			AttestedAt:      attestedAt.Add(time.Minute),
+		},
+		{
+			SpiffeID:        agent1,
+			AttestationType: "x509pop",
+			SerialNumber:    "2",
+			CanReattest:     true,
+			Reattestation:   true,
+			AttestedAt:      attestedAt.Add(2 * time.Minute),
+		},
+	} {
+		require.NoError(t, test.ds.CreateAttestationEvent(ctx, event))
+	}
+
+	reattested := &admin.AttestationEvent{
+		SpiffeID:        agent1,
+		AttestationType: "x509pop",
+		SerialNumber:    "2",
+		CanReattest:     true,
+		Reattestation:   true,
+		AttestedAt:      attestedAt.Add(2 * time.Minute),
+	}
+	failed := &admin.AttestationEvent{
+		AttestationType: "x509pop",
+		Error:           "failed to attest: certificate is expired",
+		Selectors:       []string{"x509pop:subject:cn:agent1"},
+		AttestedAt:      attestedAt.Add(time.Minute),
+	}
+	attested := &admin.AttestationEvent{
+		SpiffeID:        agent1,
+		AttestationType: "x509pop",
+		SerialNumber:    "1",
+		CanReattest:     true,
+		AttestedAt:      attestedAt,
+	}
+
+	for _, tt := range []struct {
+		name         string
+		req          *admin.ListAttestationEventsRequest
+		expectEvents []*admin.AttestationEvent
+		expectCode   codes.Code
+		expectMsg    string
+	}{
+		{
+			name:         "all",
+			req:          &admin.ListAttestationEventsRequest{},
+			expectEvents: []*admin.AttestationEvent{reattested, failed, attested},
+		},
+		{
+			name:         "by SPIFFE ID",
+			req:          &admin.ListAttestationEventsRequest{BySpiffeID: agent1},
+			expectEvents: []*admin.AttestationEvent{reattested, attested},
+		},
+		{
+			name:         "failed only",
+			req:          &admin.ListAttestationEventsRequest{FailedOnly: true},
+			expectEvents: []*admin.AttestationEvent{failed},
+		},
+		{
+			name:         "limit",
+			req:          &admin.ListAttestationEventsRequest{Limit: 1},
+			expectEvents: []*admin.AttestationEvent{reattested},
+		},
+		{
+			name:       "invalid SPIFFE ID",
+			req:        &admin.ListAttestationEventsRequest{BySpiffeID: "spiffe://other.org/spire/agent/x509pop/agent1"},
+			expectCode: codes.InvalidArgument,
+			expectMsg:  "invalid agent ID",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := test.client.ListAttestationEvents(ctx, tt.req)
+			if tt.expectCode != codes.OK {
+				spiretest.RequireGRPCStatusHasPrefix(t, err, tt.expectCode, tt.expectMsg)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectEvents, resp.Events)
+		})
+	}
+}
+
+func TestListIssuanceRecords(t *testing.T) {
+	test := setupServiceTest(t)
+	ctx := context.Background()
+
+	expiresAt := time.Unix(1000, 0).UTC()
+	for _, record := range []*datastore.IssuanceRecord{
+		{SerialNumber: "1", SpiffeID: "spiffe://example.org/workload1", EntryID: "entry1", ExpiresAt: expiresAt},
+		{SerialNumber: "2", SpiffeID: "spiffe://example.org/workload2", EntryID: "entry2", ExpiresAt: expiresAt},
+		{SerialNumber: "3", SpiffeID: "spiffe://example.org/workload1", ExpiresAt: expiresAt},
+	} {
+		require.NoError(t, test.ds.CreateIssuanceRecord(ctx, record))
+	}
+
+	record1 := &admin.IssuanceRecord{SerialNumber: "1", SpiffeID: "spiffe://example.org/workload1", EntryID: "entry1", ExpiresAt: expiresAt}
+	record2 := &admin.IssuanceRecord{SerialNumber: "2", SpiffeID: "spiffe://example.org/workload2", EntryID: "entry2", ExpiresAt: expiresAt}
+	record3 := &admin.IssuanceRecord{SerialNumber: "3", SpiffeID: "spiffe://example.org/workload1", ExpiresAt: expiresAt}
+
+	for _, tt := range []struct {
+		name          string
+		req           *admin.ListIssuanceRecordsRequest
+		expectRecords []*admin.IssuanceRecord
+		expectCode    codes.Code
+		expectMsg     string
+	}{
+		{
+			name:          "all",
+			req:           &admin.ListIssuanceRecordsRequest{},
+			expectRecords: []*admin.IssuanceRecord{record1, record2, record3},
+		},
+		{
+			name:          "by serial number",
+			req:           &admin.ListIssuanceRecordsRequest{BySerialNumber: "2"},
+			expectRecords: []*admin.IssuanceRecord{record2},
+		},
+		{
+			name:          "by SPIFFE ID",
+			req:           &admin.ListIssuanceRecordsRequest{BySpiffeID: "spiffe://example.org/workload1"},
+			expectRecords: []*admin.IssuanceRecord{record1, record3},
+		},
+		{
+			name:          "by entry ID",
+			req:           &admin.ListIssuanceRecordsRequest{ByEntryID: "entry2"},
+			expectRecords: []*admin.IssuanceRecord{record2},
+		},
+		{
+			name:       "invalid serial number",
+			req:        &admin.ListIssuanceRecordsRequest{BySerialNumber: "0x2"},
+			expectCode: codes.InvalidArgument,
+			expectMsg:  `invalid serial number: "0x2" is not a decimal number`,
+		},
+		{
+			name:       "invalid SPIFFE ID",
+			req:        &admin.ListIssuanceRecordsRequest{BySpiffeID: "workload1"},
+			expectCode: codes.InvalidArgument,
+			expectMsg:  "invalid SPIFFE ID",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := test.client.ListIssuanceRecords(ctx, tt.req)
+			if tt.expectCode != codes.OK {
+				spiretest.RequireGRPCStatusHasPrefix(t, err, tt.expectCode, tt.expectMsg)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectRecords, resp.Records)
+		})
+	}
+
+	// Paginated
+	resp, err := test.client.ListIssuanceRecords(ctx, &admin.ListIssuanceRecordsRequest{PageSize: 2})
+	require.NoError(t, err)
+	require.Equal(t, []*admin.IssuanceRecord{record1, record2}, resp.Records)
+	require.NotEmpty(t, resp.NextPageToken)
+	resp, err = test.client.ListIssuanceRecords(ctx, &admin.ListIssuanceRecordsRequest{PageSize: 2, PageToken: resp.NextPageToken})
+	require.NoError(t, err)
+	require.Equal(t, []*admin.IssuanceRecord{record3}, resp.Records)
+}
+
+func TestEntryCache(t *testing.T) {
+	ctx := context.Background()
+
+	// The entry cache methods are unavailable without an entry cache
+	log, _ := test.NewNullLogger()
+	_, err := admin.New(admin.Config{}).GetEntryCacheStatus(rpccontext.WithLogger(ctx, log))
+	spiretest.RequireGRPCStatus(t, err, codes.Unavailable, "entry cache is not available")
+
+	test := setupServiceTest(t)
+
+	lastReload := time.Unix(1000, 0).UTC()
+	test.entryCache.status = &admin.EntryCacheStatus{
+		EntryCount:          3,
+		DataStoreEntryCount: 3,
+		StaleEntryIDs:       []string{"entry1"},
+		Stale:               true,
+		LastReload:          lastReload,
+		LastReloadDuration:  250 * time.Millisecond,
+	}
+
+	status, err := test.client.GetEntryCacheStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, test.entryCache.status, status)
+	require.Zero(t, test.entryCache.reloads)
+
+	status, err = test.client.ReloadEntryCache(ctx)
+	require.NoError(t, err)
+	require.Equal(t, test.entryCache.status, status)
+	require.Equal(t, 1, test.entryCache.reloads)
+	spiretest.AssertLastLogs(t, test.logHook.AllEntries(), []spiretest.LogEntry{
+		{Level: logrus.InfoLevel, Message: "Entry cache reloaded"},
+	})
+
+	test.entryCache.err = errors.New("oh no")
+	_, err = test.client.ReloadEntryCache(ctx)
+	spiretest.RequireGRPCStatus(t, err, codes.Internal, "failed to reload entry cache: oh no")
+	_, err = test.client.GetEntryCacheStatus(ctx)
+	spiretest.RequireGRPCStatus(t, err, codes.Internal, "failed to compare entry cache to the datastore: oh no")
+}
+
+func TestGetUpstreamAuthorityStatus(t *testing.T) {
+	ctx := context.Background()
+
+	// The upstream authority status is unavailable without a source
+	log, _ := test.NewNullLogger()
+	_, err := admin.New(admin.Config{}).GetUpstreamAuthorityStatus(rpccontext.WithLogger(ctx, log))
+	spiretest.RequireGRPCStatus(t, err, codes.Unavailable, "upstream authority status is not available")
+
+	test := setupServiceTest(t)
+
+	_, err = test.client.GetUpstreamAuthorityStatus(ctx)
+	spiretest.RequireGRPCStatus(t, err, codes.NotFound, "no upstream authority is configured")
+
+	lastMint := time.Unix(1000, 0).UTC()
+	test.upstreamAuthority.status = &admin.UpstreamAuthorityStatus{
+		PluginName: "disk",
+		X509CA:     &admin.Certificate{Subject: "O=SPIRE", Issuer: "CN=upstream", NotAfter: lastMint.Add(time.Hour)},
+		UpstreamChain: []*admin.Certificate{
+			{Subject: "O=SPIRE", Issuer: "CN=upstream", NotAfter: lastMint.Add(time.Hour)},
+		},
+		LastMint:     &lastMint,
+		RecentErrors: []*admin.UpstreamAuthorityError{{Time: lastMint, Message: "oh no"}},
+	}
+
+	status, err := test.client.GetUpstreamAuthorityStatus(ctx)
+	require.NoError(t, err)
+	require.Equal(t, test.upstreamAuthority.status, status)
+}
+
+func TestListEvents(t *testing.T) {
+	ctx := context.Background()
+
+	// Events are unavailable when the event buffer is disabled
+	log, _ := test.NewNullLogger()
+	_, err := admin.New(admin.Config{}).ListEvents(rpccontext.WithLogger(ctx, log))
+	spiretest.RequireGRPCStatus(t, err, codes.FailedPrecondition, "event buffer is disabled; set event_buffer_size to enable it")
+
+	test := setupServiceTest(t)
+
+	resp, err := test.client.ListEvents(ctx)
+	require.NoError(t, err)
+	require.Empty(t, resp.Events)
+
+	test.events.events = []commonlog.Event{
+		{
+			Time:    time.Unix(1000, 0).UTC(),
+			Level:   "warning",
+			Message: "Agent attestation failed",
+			Fields:  map[string]string{"subsystem_name": "api"},
+		},
+	}
+	resp, err = test.client.ListEvents(ctx)
+	require.NoError(t, err)
+	require.Equal(t, test.events.events, resp.Events)
+}
+
+func TestSelectorSets(t *testing.T) {
+	test := setupServiceTest(t)
+	ctx := context.Background()
+
+	resp, err := test.client.ListSelectorSets(ctx)
+	require.NoError(t, err)
+	require.Empty(t, resp.SelectorSets)
+
+	baseImages := &admin.SelectorSet{
+		Name:      "base-images",
+		Selectors: []string{"docker:image_id:base1", "docker:image_id:base2"},
+	}
+	created, err := test.client.CreateSelectorSet(ctx, baseImages)
+	require.NoError(t, err)
+	require.Equal(t, baseImages, created)
+	spiretest.AssertLastLogs(t, test.logHook.AllEntries(), []spiretest.LogEntry{
+		{
+			Level:   logrus.InfoLevel,
+			Message: "Selector set created",
+			Data: logrus.Fields{
+				telemetry.SelectorSet: "base-images",
+			},
+		},
+	})
+
+	_, err = test.client.CreateSelectorSet(ctx, baseImages)
+	spiretest.RequireGRPCStatus(t, err, codes.AlreadyExists, "selector set already exists")
+
+	baseImages.Selectors = []string{"docker:image_id:base3"}
+	updated, err := test.client.UpdateSelectorSet(ctx, baseImages)
+	require.NoError(t, err)
+	require.Equal(t, baseImages, updated)
+
+	fetched, err := test.client.GetSelectorSet(ctx, &admin.GetSelectorSetRequest{Name: "base-images"})
+	require.NoError(t, err)
+	require.Equal(t, baseImages, fetched)
+
+	resp, err = test.client.ListSelectorSets(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []*admin.SelectorSet{baseImages}, resp.SelectorSets)
+
+	// Sets referenced by entries cannot be deleted
+	entry, err := test.ds.CreateRegistrationEntry(ctx, &common.RegistrationEntry{
+		ParentId:  "spiffe://example.org/parent",
+		SpiffeId:  "spiffe://example.org/workload",
+		Selectors: []*common.Selector{{Type: datastore.SelectorSetType, Value: "base-images"}},
+	})
+	require.NoError(t, err)
+	err = test.client.DeleteSelectorSet(ctx, &admin.DeleteSelectorSetRequest{Name: "base-images"})
+	spiretest.RequireGRPCStatusHasPrefix(t, err, codes.FailedPrecondition, "selector set is in use")
+
+	_, err = test.ds.DeleteRegistrationEntry(ctx, entry.EntryId)
+	require.NoError(t, err)
+	require.NoError(t, test.client.DeleteSelectorSet(ctx, &admin.DeleteSelectorSetRequest{Name: "base-images"}))
+
+	_, err = test.client.GetSelectorSet(ctx, &admin.GetSelectorSetRequest{Name: "base-images"})
+	spiretest.RequireGRPCStatus(t, err, codes.NotFound, "selector set not found")
+	_, err = test.client.UpdateSelectorSet(ctx, baseImages)
+	spiretest.RequireGRPCStatusHasPrefix(t, err, codes.NotFound, "selector set not found")
+	err = test.client.DeleteSelectorSet(ctx, &admin.DeleteSelectorSetRequest{Name: "base-images"})
+	spiretest.RequireGRPCStatusHasPrefix(t, err, codes.NotFound, "selector set not found")
+}
+
+func TestInvalidSelectorSet(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		set     *admin.SelectorSet
+		message string
+	}{
+		{
+			name:    "missing name",
+			set:     &admin.SelectorSet{Selectors: []string{"unix:uid:1000"}},
+			message: "invalid selector set: missing name",
+		},
+		{
+			name:    "missing selectors",
+			set:     &admin.SelectorSet{Name: "empty"},
+			message: "invalid selector set: at least one selector is required",
+		},
+		{
+			name:    "malformed selector",
+			set:     &admin.SelectorSet{Name: "malformed", Selectors: []string{"unix"}},
+			message: `invalid selector set: selector "unix" must be formatted as type:value`,
+		},
+		{
+			name:    "missing selector value",
+			set:     &admin.SelectorSet{Name: "malformed", Selectors: []string{"unix:"}},
+			message: "invalid selector set: missing selector value",
+		},
+		{
+			name:    "nested selector set",
+			set:     &admin.SelectorSet{Name: "nested", Selectors: []string{"selector_set:base-images"}},
+			message: "invalid selector set: selector sets cannot reference other selector sets",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			test := setupServiceTest(t)
+
+			_, err := test.client.CreateSelectorSet(context.Background(), tt.set)
+			spiretest.RequireGRPCStatus(t, err, codes.InvalidArgument, tt.message)
+
+			_, err = test.client.UpdateSelectorSet(context.Background(), tt.set)
+			spiretest.RequireGRPCStatus(t, err, codes.InvalidArgument, tt.message)
+		})
+	}
+}
+
+func TestMalformedRequest(t *testing.T) {
+	test := setupServiceTest(t)
+
+	err := adminapi.Invoke(context.Background(), test.conn, admin.ServiceName, "ListPendingAgents", map[string]interface{}{"by_spiffe_id": agent1}, nil)
+	spiretest.RequireGRPCStatusHasPrefix(t, err, codes.InvalidArgument, `malformed message: json: unknown field "by_spiffe_id"`)
+}
+
+type serviceTest struct {
+	ds                datastore.DataStore
+	entryCache        *fakeEntryCache
+	upstreamAuthority *fakeUpstreamAuthority
+	events            *fakeEventSource
+	logHook           *test.Hook
+	conn              grpc.ClientConnInterface
+	client            *admin.Client
+}
+
+func setupServiceTest(t *testing.T) *serviceTest {
+	ds := fakedatastore.New(t)
+	entryCache := new(fakeEntryCache)
+	upstreamAuthority := new(fakeUpstreamAuthority)
+	events := new(fakeEventSource)
+	log, logHook := test.NewNullLogger()
+
+	service := admin.New(admin.Config{
+		TrustDomain:       td,
+		DataStore:         ds,
+		EntryCache:        entryCache,
+		UpstreamAuthority: upstreamAuthority,
+		Events:            events,
+	})
+
+	registerFn := func(s *grpc.Server) {
+		admin.RegisterService(s, service)
+	}
+	contextFn := func(ctx context.Context) context.Context {
+		return rpccontext.WithLogger(ctx, log)
+	}
+
+	conn, done := spiretest.NewAPIServer(t, registerFn, contextFn)
+	t.Cleanup(done)
+
+	return &serviceTest{
+		ds:                ds,
+		entryCache:        entryCache,
+		upstreamAuthority: upstreamAuthority,
+		events:            events,
+		logHook:           logHook,
+		conn:              conn,
+		client:            admin.NewClient(conn),
+	}
+}
+
+type fakeEntryCache struct {
+	status  *admin.EntryCacheStatus
+	reloads int
+	err     error
+}
+
+func (c *fakeEntryCache) Reload(context.Context) error {
+	if c.err != nil {
+		return c.err
+	}
+	c.reloads++
+	return nil
+}
+
+func (c *fakeEntryCache) Status(context.Context) (*admin.EntryCacheStatus, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.status, nil
+}
+
+type fakeUpstreamAuthority struct {
+	status *admin.UpstreamAuthorityStatus
+}
+
+func (a *fakeUpstreamAuthority) UpstreamAuthorityStatus() (*admin.UpstreamAuthorityStatus, bool) {
+	return a.status, a.status != nil
+}
+
+type fakeEventSource struct {
+	events []commonlog.Event
+}
+
+func (s *fakeEventSource) Events() []commonlog.Event {
+	return s.events
+}
@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// failureSampleInterval is the interval at which failed attestations
+	// of unauthenticated agents are sampled.
+	failureSampleInterval = time.Minute
+
+	// maxSampledFailures is the maximum number of failed attestations of
+	// unauthenticated agents recorded per sampling interval, across all
+	// sources.
+	maxSampledFailures = 100
+)
+
+// failureSampler samples the failed attestations of agents that could not
+// be authenticated, so that unauthenticated callers cannot fill the
+// datastore. At most one failure is recorded per source and interval, and
+// no more than maxSampledFailures across all sources.
+type failureSampler struct {
+	mtx sync.Mutex
+
+	// sources holds, per source, when a failure was last recorded and how
+	// many were suppressed since.
+	sources map[string]*sampledSource
+
+	// recorded is the number of failures recorded since the interval
+	// started
+	recorded int
+
+	// intervalStart is when the current interval started
+	intervalStart time.Time
+}
+
+type sampledSource struct {
+	recordedAt time.Time
+	suppressed int
+}
+
+func newFailureSampler() *failureSampler {
+	return &failureSampler{
+		sources: make(map[string]*sampledSource),
+	}
+}
+
+// Sample returns whether the failure from the given source should be
+// recorded, along with how many failures from the source were suppressed
+// since the last one recorded.
+func (s *failureSampler) Sample(now time.Time, source string) (bool, int) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if now.Sub(s.intervalStart) >= failureSampleInterval {
+		// Forget the sources that have not been recorded during the last
+		// two intervals, which keeps the number of tracked sources bounded
+		// while still reporting the failures suppressed for the sources
+		// that keep failing.
+		for key, src := range s.sources {
+			if now.Sub(src.recordedAt) >= 2*failureSampleInterval {
+				delete(s.sources, key)
+			}
+		}
+		s.recorded = 0
+		s.intervalStart = now
+	}
+
+	src, ok := s.sources[source]
+	switch {
+	case ok && now.Sub(src.recordedAt) < failureSampleInterval:
+		src.suppressed++
+		return false, 0
+	case s.recorded >= maxSampledFailures:
+		return false, 0
+	case !ok:
+		src = new(sampledSource)
+		s.sources[source] = src
+	}
+
+	suppressed := src.suppressed
+	src.recordedAt = now
+	src.suppressed = 0
+	s.recorded++
+	return true, suppressed
+}
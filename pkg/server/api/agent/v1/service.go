@@ -5,6 +5,8 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
+	"strings"
 	"time"
 
 	"github.com/andres-erbsen/clock"
@@ -13,12 +15,14 @@ import (
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	agentv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
 	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/pkg/common/agentpathtemplate"
 	"github.com/spiffe/spire/pkg/common/errorutil"
 	"github.com/spiffe/spire/pkg/common/fflag"
 	"github.com/spiffe/spire/pkg/common/idutil"
 	"github.com/spiffe/spire/pkg/common/nodeutil"
 	"github.com/spiffe/spire/pkg/common/selector"
 	"github.com/spiffe/spire/pkg/common/telemetry"
+	telemetry_server "github.com/spiffe/spire/pkg/common/telemetry/server"
 	"github.com/spiffe/spire/pkg/common/x509util"
 	"github.com/spiffe/spire/pkg/server/api"
 	"github.com/spiffe/spire/pkg/server/api/rpccontext"
@@ -34,6 +38,10 @@ import (
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
+// DefaultSelectorWarningThreshold is the default number of selectors an
+// agent can be attested with before a warning is logged.
+const DefaultSelectorWarningThreshold = 1000
+
 // Config is the service configuration
 type Config struct {
 	Catalog     catalog.Catalog
@@ -42,6 +50,28 @@ type Config struct {
 	ServerCA    ca.ServerCA
 	AgentTTL    time.Duration
 	TrustDomain spiffeid.TrustDomain
+	Metrics     telemetry.Metrics
+
+	// SelectorWarningThreshold is the number of selectors an agent can be
+	// attested with before a warning is logged. Large numbers of selectors
+	// degrade the performance of entry matching. Defaults to
+	// DefaultSelectorWarningThreshold.
+	SelectorWarningThreshold int
+
+	// AgentPathTemplates overrides, per node attestor, the path of the agent
+	// ID produced by the attestor. The templates are evaluated against the
+	// attestation result.
+	AgentPathTemplates map[string]*agentpathtemplate.Template
+
+	// ApprovalRequiredAttestorTypes are the node attestors whose new agents
+	// must be approved by an administrator before they are issued an SVID.
+	// Until then, they are stored as pending agents and their attestations
+	// fail.
+	ApprovalRequiredAttestorTypes []string
+
+	// KeyPolicy restricts the keys agents can be issued an SVID for. Any
+	// key is allowed by default.
+	KeyPolicy api.KeyPolicy
 }
 
 // Service implements the v1 agent service
@@ -54,10 +84,27 @@ type Service struct {
 	ca       ca.ServerCA
 	td       spiffeid.TrustDomain
 	agentTTL time.Duration
+	metrics  telemetry.Metrics
+
+	selectorWarningThreshold int
+	agentPathTemplates       map[string]*agentpathtemplate.Template
+	approvalRequired         map[string]bool
+	keyPolicy                api.KeyPolicy
+	failureSampler           *failureSampler
 }
 
 // New creates a new agent service
 func New(config Config) *Service {
+	if config.Metrics == nil {
+		config.Metrics = telemetry.Blackhole{}
+	}
+	if config.SelectorWarningThreshold <= 0 {
+		config.SelectorWarningThreshold = DefaultSelectorWarningThreshold
+	}
+	approvalRequired := make(map[string]bool)
+	for _, attestorType := range config.ApprovalRequiredAttestorTypes {
+		approvalRequired[attestorType] = true
+	}
 	return &Service{
 		cat:      config.Catalog,
 		clk:      config.Clock,
@@ -65,6 +112,13 @@ func New(config Config) *Service {
 		ca:       config.ServerCA,
 		td:       config.TrustDomain,
 		agentTTL: config.AgentTTL,
+		metrics:  config.Metrics,
+
+		selectorWarningThreshold: config.SelectorWarningThreshold,
+		agentPathTemplates:       config.AgentPathTemplates,
+		approvalRequired:         approvalRequired,
+		keyPolicy:                config.KeyPolicy,
+		failureSampler:           newFailureSampler(),
 	}
 }
 
@@ -248,7 +302,7 @@ func (s *Service) BanAgent(ctx context.Context, req *agentv1.BanAgentRequest) (*
 }
 
 // AttestAgent attests the authenticity of the given agent.
-func (s *Service) AttestAgent(stream agentv1.Agent_AttestAgentServer) error {
+func (s *Service) AttestAgent(stream agentv1.Agent_AttestAgentServer) (err error) {
 	ctx := stream.Context()
 	log := rpccontext.Logger(ctx)
 
@@ -272,6 +326,18 @@ func (s *Service) AttestAgent(stream agentv1.Agent_AttestAgentServer) error {
 
 	log = log.WithField(telemetry.NodeAttestorType, params.Data.Type)
 
+	// record failed attestations so operators can find out why an agent
+	// cannot join without enabling debug logs. The failure is cleared once
+	// the attestation has been recorded as successful.
+	failure := &datastore.AttestationEvent{
+		AttestationType: params.Data.Type,
+	}
+	defer func() {
+		if err != nil && failure != nil {
+			s.recordAttestationFailure(ctx, log, failure, err)
+		}
+	}()
+
 	// attest
 	var attestResult *nodeattestor.AttestResult
 	if params.Data.Type == "join_token" {
@@ -286,13 +352,24 @@ func (s *Service) AttestAgent(stream agentv1.Agent_AttestAgentServer) error {
 		}
 	}
 
+	failure.Selectors = attestResult.Selectors
+
 	agentID, err := spiffeid.FromString(attestResult.AgentID)
 	if err != nil {
 		return api.MakeErr(log, codes.Internal, "invalid agent ID", err)
 	}
 
+	tmpl, templated := s.agentPathTemplates[params.Data.Type]
+	if templated {
+		agentID, err = s.agentIDFromTemplate(tmpl, params.Data.Type, agentID, attestResult.Selectors)
+		if err != nil {
+			return api.MakeErr(log.WithField(telemetry.AgentID, attestResult.AgentID), codes.Internal, "failed to apply agent path template", err)
+		}
+	}
+
 	log = log.WithField(telemetry.AgentID, agentID)
 	rpccontext.AddRPCAuditFields(ctx, logrus.Fields{telemetry.AgentID: agentID})
+	failure.SpiffeID = agentID.String()
 
 	// Ideally we'd do stronger validation that the ID is within the Node
 	// Attestors scoped area of the reserved agent namespace, but historically
@@ -317,6 +394,23 @@ func (s *Service) AttestAgent(stream agentv1.Agent_AttestAgentServer) error {
 		return api.MakeErr(log, codes.PermissionDenied, "failed to attest: agent is banned", nil)
 	}
 
+	// Node attestors that trust on first use check that the agent ID they
+	// produce has not been attested yet. That check is done against the ID
+	// before the path template is applied, which is never stored, so it is
+	// repeated here against the templated ID.
+	if attestedNode != nil && templated && tofuNodeAttestors[params.Data.Type] {
+		return api.MakeErr(log, codes.PermissionDenied, "failed to attest: attestation data has already been used to attest an agent", nil)
+	}
+
+	// new agents that require approval are held as pending until an
+	// administrator approves them
+	pendingApproval := attestedNode == nil && s.approvalRequired[params.Data.Type]
+	if pendingApproval {
+		if err := s.checkAgentApproved(ctx, log, agentID, params.Data.Type); err != nil {
+			return err
+		}
+	}
+
 	// parse and sign CSR
 	svid, err := s.signSvid(ctx, agentID, params.Params.Csr, log)
 	if err != nil {
@@ -324,7 +418,9 @@ func (s *Service) AttestAgent(stream agentv1.Agent_AttestAgentServer) error {
 	}
 
 	// dedupe and store node selectors
-	err = s.ds.SetNodeSelectors(ctx, agentID.String(), selector.Dedupe(attestResult.Selectors))
+	selectors := selector.Dedupe(attestResult.Selectors)
+	s.observeSelectorCount(log, params.Data.Type, len(selectors))
+	err = s.ds.SetNodeSelectors(ctx, agentID.String(), selectors)
 	if err != nil {
 		return api.MakeErr(log, codes.Internal, "failed to update selectors", err)
 	}
@@ -341,6 +437,11 @@ func (s *Service) AttestAgent(stream agentv1.Agent_AttestAgentServer) error {
 		if _, err := s.ds.CreateAttestedNode(ctx, node); err != nil {
 			return api.MakeErr(log, codes.Internal, "failed to create attested agent", err)
 		}
+		if pendingApproval {
+			if err := s.ds.DeletePendingAgent(ctx, agentID.String()); err != nil {
+				log.WithError(err).Warn("Failed to delete approved pending agent")
+			}
+		}
 	} else {
 		node := &common.AttestedNode{
 			SpiffeId:         agentID.String(),
@@ -353,6 +454,20 @@ func (s *Service) AttestAgent(stream agentv1.Agent_AttestAgentServer) error {
 		}
 	}
 
+	// keep a history of the attestations of the agent. Failing to record it
+	// does not fail the attestation.
+	if err := s.ds.CreateAttestationEvent(ctx, &datastore.AttestationEvent{
+		SpiffeID:        agentID.String(),
+		AttestationType: params.Data.Type,
+		SerialNumber:    svid[0].SerialNumber.String(),
+		CanReattest:     attestResult.CanReattest,
+		Reattestation:   attestedNode != nil,
+		AttestedAt:      s.clk.Now(),
+	}); err != nil {
+		log.WithError(err).Warn("Failed to record attestation event")
+	}
+	failure = nil
+
 	// build and send response
 	response := getAttestAgentResponse(agentID, svid, attestResult.CanReattest)
 
@@ -369,6 +484,53 @@ func (s *Service) AttestAgent(stream agentv1.Agent_AttestAgentServer) error {
 	return nil
 }
 
+// recordAttestationFailure keeps a record of a failed attestation. The
+// failures of agents that were not authenticated by the node attestor are
+// sampled per source. Failing to record it is only logged.
+func (s *Service) recordAttestationFailure(ctx context.Context, log logrus.FieldLogger, failure *datastore.AttestationEvent, attestErr error) {
+	failure.Error = status.Convert(attestErr).Message()
+	failure.AttestedAt = s.clk.Now()
+	if failure.SpiffeID == "" {
+		record, suppressed := s.failureSampler.Sample(failure.AttestedAt, callerSource(ctx))
+		if !record {
+			return
+		}
+		if suppressed > 0 {
+			failure.Error = fmt.Sprintf("%s (%d other failures from the same source were not recorded)", failure.Error, suppressed)
+		}
+	}
+	if err := s.ds.CreateAttestationEvent(ctx, failure); err != nil {
+		log.WithError(err).Warn("Failed to record attestation failure")
+	}
+}
+
+// callerSource returns the source of the call used to sample failed
+// attestations, which is the IP address of the caller when it is known.
+func callerSource(ctx context.Context) string {
+	addr := rpccontext.CallerAddr(ctx)
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return tcpAddr.IP.String()
+	}
+	return addr.String()
+}
+
+// checkAgentApproved records the attestation of an agent that requires
+// approval, failing unless the agent has been approved.
+func (s *Service) checkAgentApproved(ctx context.Context, log logrus.FieldLogger, agentID spiffeid.ID, attestationType string) error {
+	pending, err := s.ds.SetPendingAgent(ctx, &datastore.PendingAgent{
+		SpiffeID:        agentID.String(),
+		AttestationType: attestationType,
+		LastAttemptAt:   s.clk.Now(),
+	})
+	if err != nil {
+		return api.MakeErr(log, codes.Internal, "failed to store pending agent", err)
+	}
+	if !pending.Approved {
+		return api.MakeErr(log, codes.PermissionDenied, "failed to attest: agent is pending approval", nil)
+	}
+	return nil
+}
+
 // RenewAgent renews the SVID of the agent with the given SpiffeID.
 func (s *Service) RenewAgent(ctx context.Context, req *agentv1.RenewAgentRequest) (*agentv1.RenewAgentResponse, error) {
 	log := rpccontext.Logger(ctx)
@@ -526,12 +688,32 @@ func (s *Service) updateAttestedNode(ctx context.Context, node *common.AttestedN
 	}
 }
 
+// observeSelectorCount tracks the number of selectors an agent is attested
+// with, warning when it exceeds the threshold, since attestors emitting very
+// large numbers of selectors silently degrade entry matching.
+func (s *Service) observeSelectorCount(log logrus.FieldLogger, attestorType string, count int) {
+	telemetry_server.AddNodeSelectorCountSample(s.metrics, attestorType, count)
+	if count <= s.selectorWarningThreshold {
+		return
+	}
+	telemetry_server.IncrNodeSelectorThresholdExceededCounter(s.metrics, attestorType)
+	log.WithFields(logrus.Fields{
+		telemetry.Count:     count,
+		telemetry.Threshold: s.selectorWarningThreshold,
+	}).Warn("Agent was attested with a large number of selectors; this degrades entry matching performance")
+}
+
 func (s *Service) signSvid(ctx context.Context, agentID spiffeid.ID, csr []byte, log logrus.FieldLogger) ([]*x509.Certificate, error) {
 	parsedCsr, err := x509.ParseCertificateRequest(csr)
 	if err != nil {
 		return nil, api.MakeErr(log, codes.InvalidArgument, "failed to parse CSR", err)
 	}
 
+	if err := s.keyPolicy.CheckKey(parsedCsr.PublicKey); err != nil {
+		telemetry_server.IncrCSRKeyRejectedCounter(s.metrics, api.KeyDescription(parsedCsr.PublicKey))
+		return nil, api.MakeErr(log, codes.InvalidArgument, "CSR key rejected by policy", err)
+	}
+
 	// Sign a new X509 SVID
 	x509Svid, err := s.ca.SignX509SVID(ctx, ca.X509SVIDParams{
 		SpiffeID:  agentID,
@@ -548,6 +730,73 @@ func (s *Service) signSvid(ctx context.Context, agentID spiffeid.ID, csr []byte,
 	return x509Svid, nil
 }
 
+// tofuNodeAttestors are the built-in node attestors that trust on first use,
+// i.e. refuse to attest an agent that has already been attested.
+var tofuNodeAttestors = map[string]bool{
+	"aws_iid":   true,
+	"azure_msi": true,
+	"gcp_iit":   true,
+	"k8s_sat":   true,
+}
+
+// agentPathTemplateData is the data agent path templates are evaluated
+// against.
+type agentPathTemplateData struct {
+	// PluginName is the name of the node attestor.
+	PluginName string
+
+	// TrustDomain is the trust domain name.
+	TrustDomain string
+
+	// AgentPath is the path of the agent ID produced by the node attestor,
+	// relative to the /spire/agent namespace (e.g. "/x509pop/<fingerprint>").
+	AgentPath string
+
+	// Selectors holds the selectors produced by the node attestor, keyed by
+	// everything up to the last colon of the selector value (e.g. the
+	// "tag:Name:web" selector is available as "web" under "tag:Name").
+	// Keys with more than one value are left out since they are ambiguous.
+	Selectors map[string]string
+}
+
+func (s *Service) agentIDFromTemplate(tmpl *agentpathtemplate.Template, pluginName string, agentID spiffeid.ID, selectors []*common.Selector) (spiffeid.ID, error) {
+	// The rendered path is placed under the /spire/agent namespace, so the
+	// path of the attestor is made relative to it. Attestors that mint IDs
+	// outside of the namespace have their path passed as is.
+	agentPath := agentID.Path()
+	if idutil.IsAgentPath(agentPath) {
+		agentPath = strings.TrimPrefix(agentPath, "/spire/agent")
+	}
+
+	data := agentPathTemplateData{
+		PluginName:  pluginName,
+		TrustDomain: s.td.String(),
+		AgentPath:   agentPath,
+		Selectors:   make(map[string]string),
+	}
+
+	ambiguous := make(map[string]bool)
+	for _, sel := range selectors {
+		i := strings.LastIndex(sel.Value, ":")
+		if i < 0 {
+			continue
+		}
+		key, value := sel.Value[:i], sel.Value[i+1:]
+		if existing, ok := data.Selectors[key]; (ok && existing != value) || ambiguous[key] {
+			ambiguous[key] = true
+			delete(data.Selectors, key)
+			continue
+		}
+		data.Selectors[key] = value
+	}
+
+	templatedPath, err := tmpl.Execute(data)
+	if err != nil {
+		return spiffeid.ID{}, err
+	}
+	return idutil.AgentID(s.td, templatedPath)
+}
+
 func (s *Service) getSelectorsFromAgentID(ctx context.Context, agentID string) ([]*types.Selector, error) {
 	selectors, err := s.ds.GetNodeSelectors(ctx, agentID, datastore.RequireCurrent)
 	if err != nil {
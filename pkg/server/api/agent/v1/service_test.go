@@ -6,6 +6,7 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"testing"
 	"time"
@@ -15,8 +16,10 @@ import (
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	agentv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
 	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/pkg/common/agentpathtemplate"
 	"github.com/spiffe/spire/pkg/common/idutil"
 	"github.com/spiffe/spire/pkg/common/telemetry"
+	telemetry_server "github.com/spiffe/spire/pkg/common/telemetry/server"
 	"github.com/spiffe/spire/pkg/common/x509util"
 	"github.com/spiffe/spire/pkg/server/api"
 	agent "github.com/spiffe/spire/pkg/server/api/agent/v1"
@@ -26,6 +29,7 @@ import (
 	"github.com/spiffe/spire/proto/spire/common"
 	"github.com/spiffe/spire/test/clock"
 	"github.com/spiffe/spire/test/fakes/fakedatastore"
+	"github.com/spiffe/spire/test/fakes/fakemetrics"
 	"github.com/spiffe/spire/test/fakes/fakeserverca"
 	"github.com/spiffe/spire/test/fakes/fakeservercatalog"
 	"github.com/spiffe/spire/test/fakes/fakeservernodeattestor"
@@ -44,6 +48,10 @@ import (
 const (
 	agent1 = "spiffe://example.org/spire/agent/agent-1"
 	agent2 = "spiffe://example.org/spire/agent/agent-2"
+
+	// selectorWarningThreshold is low enough for the agents attested with
+	// duplicate selectors to exceed it
+	selectorWarningThreshold = 3
 )
 
 var (
@@ -51,6 +59,8 @@ var (
 	td      = spiffeid.RequireTrustDomainFromString("example.org")
 	agentID = spiffeid.RequireFromPath(td, "/agent")
 
+	callerAddr = &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8081}
+
 	testNodes = map[string]*common.AttestedNode{
 		agent1: {
 			SpiffeId:            agent1,
@@ -2972,6 +2982,16 @@ func TestAttestAgent(t *testing.T) {
 				{Type: "test_type", Value: "D"},
 			},
 			expectLogs: []spiretest.LogEntry{
+				{
+					Level:   logrus.WarnLevel,
+					Message: "Agent was attested with a large number of selectors; this degrades entry matching performance",
+					Data: logrus.Fields{
+						telemetry.AgentID:          "spiffe://example.org/spire/agent/test_type/id_selector_dups",
+						telemetry.NodeAttestorType: "test_type",
+						telemetry.Count:            "4",
+						telemetry.Threshold:        "3",
+					},
+				},
 				{
 					Level:   logrus.InfoLevel,
 					Message: "Agent attestation request completed",
@@ -3026,6 +3046,7 @@ func TestAttestAgent(t *testing.T) {
 				require.NotNil(t, result)
 				// clear entries from the previous run
 				test.logHook.Reset()
+				test.metrics.Reset()
 
 				// attest once more
 				stream, err = test.client.AttestAgent(ctx)
@@ -3050,20 +3071,271 @@ func TestAttestAgent(t *testing.T) {
 				require.NotNil(t, result)
 				test.assertAttestAgentResult(t, tt.expectedID, result)
 				test.assertAgentWasStored(t, tt.expectedID.String(), tt.expectedSelectors)
+
+				attestorType := tt.request.GetParams().Data.Type
+				expectedMetrics := fakemetrics.New()
+				telemetry_server.AddNodeSelectorCountSample(expectedMetrics, attestorType, len(tt.expectedSelectors))
+				if len(tt.expectedSelectors) > selectorWarningThreshold {
+					telemetry_server.IncrNodeSelectorThresholdExceededCounter(expectedMetrics, attestorType)
+				}
+				require.Equal(t, expectedMetrics.AllMetrics(), test.metrics.AllMetrics())
 			}
 			spiretest.AssertLogs(t, test.logHook.AllEntries(), tt.expectLogs)
 		})
 	}
 }
 
+func TestAttestAgentWithPathTemplate(t *testing.T) {
+	testCsr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, testkey.MustEC256())
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name       string
+		template   string
+		expectedID spiffeid.ID
+		expectCode codes.Code
+		expectMsg  string
+	}{
+		{
+			name:       "success",
+			template:   `/{{ .PluginName }}/{{ .Selectors.region }}/{{ index .Selectors "tag:Name" }}`,
+			expectedID: spiffeid.RequireFromPath(td, "/spire/agent/test_type/us-east-1/web"),
+		},
+		{
+			name:       "attestor path",
+			template:   `/custom{{ .AgentPath }}`,
+			expectedID: spiffeid.RequireFromPath(td, "/spire/agent/custom/test_type/id_templated"),
+		},
+		{
+			name:       "ambiguous selector",
+			template:   `/{{ .PluginName }}/{{ .Selectors.sg }}`,
+			expectCode: codes.Internal,
+			expectMsg:  "failed to apply agent path template",
+		},
+		{
+			name:       "invalid path",
+			template:   `{{ .PluginName }}`,
+			expectCode: codes.Internal,
+			expectMsg:  "failed to apply agent path template",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := agentpathtemplate.Parse(tt.template)
+			require.NoError(t, err)
+
+			test := setupServiceTest(t, 0, func(c *agent.Config) {
+				c.AgentPathTemplates = map[string]*agentpathtemplate.Template{"test_type": tmpl}
+			})
+			defer test.Cleanup()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			test.setupAttestor(t)
+			test.rateLimiter.count = 1
+
+			stream, err := test.client.AttestAgent(ctx)
+			require.NoError(t, err)
+			result, err := attest(t, stream, getAttestAgentRequest("test_type", []byte("payload_templated"), testCsr))
+			require.NoError(t, stream.CloseSend())
+
+			spiretest.RequireGRPCStatusContains(t, err, tt.expectCode, tt.expectMsg)
+			if tt.expectCode != codes.OK {
+				require.Nil(t, result)
+				return
+			}
+
+			require.NotNil(t, result)
+			test.assertAttestAgentResult(t, tt.expectedID, result)
+			test.assertAgentWasStored(t, tt.expectedID.String(), []*common.Selector{
+				{Type: "test_type", Value: "region:us-east-1"},
+				{Type: "test_type", Value: "sg:a"},
+				{Type: "test_type", Value: "sg:b"},
+				{Type: "test_type", Value: "tag:Name:web"},
+			})
+		})
+	}
+}
+
+func TestAttestAgentPendingApproval(t *testing.T) {
+	testCsr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, testkey.MustEC256())
+	require.NoError(t, err)
+
+	test := setupServiceTest(t, 0, func(c *agent.Config) {
+		c.ApprovalRequiredAttestorTypes = []string{"test_type"}
+	})
+	defer test.Cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	test.setupAttestor(t)
+	test.setupNodes(ctx, t)
+	test.rateLimiter.count = 1
+
+	attestAgent := func(payload string) (*agentv1.AttestAgentResponse_Result, error) {
+		stream, err := test.client.AttestAgent(ctx)
+		require.NoError(t, err)
+		result, err := attest(t, stream, getAttestAgentRequest("test_type", []byte(payload), testCsr))
+		require.NoError(t, stream.CloseSend())
+		return result, err
+	}
+
+	pendingID := spiffeid.RequireFromPath(td, "/spire/agent/test_type/id_with_result")
+
+	// New agents are held as pending until they are approved
+	result, err := attestAgent("payload_with_result")
+	spiretest.RequireGRPCStatus(t, err, codes.PermissionDenied, "failed to attest: agent is pending approval")
+	require.Nil(t, result)
+
+	pending, err := test.ds.FetchPendingAgent(ctx, pendingID.String())
+	require.NoError(t, err)
+	require.Equal(t, &datastore.PendingAgent{
+		SpiffeID:        pendingID.String(),
+		AttestationType: "test_type",
+		RequestedAt:     test.clk.Now().UTC(),
+		LastAttemptAt:   test.clk.Now().UTC(),
+	}, pending)
+
+	node, err := test.ds.FetchAttestedNode(ctx, pendingID.String())
+	require.NoError(t, err)
+	require.Nil(t, node)
+
+	// The failure is recorded, along with the selectors of the agent
+	failures, err := test.ds.ListAttestationEvents(ctx, &datastore.ListAttestationEventsRequest{
+		BySpiffeID: pendingID.String(),
+		FailedOnly: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, failures.Events, 1)
+	require.Equal(t, "test_type", failures.Events[0].AttestationType)
+	require.Equal(t, "failed to attest: agent is pending approval", failures.Events[0].Error)
+	require.Equal(t, test.clk.Now().UTC(), failures.Events[0].AttestedAt)
+	spiretest.RequireProtoListEqual(t, []*common.Selector{
+		{Type: "test_type", Value: "result"},
+	}, failures.Events[0].Selectors)
+
+	// Approved agents are attested and no longer pending
+	_, err = test.ds.ApprovePendingAgent(ctx, pendingID.String())
+	require.NoError(t, err)
+
+	result, err = attestAgent("payload_with_result")
+	require.NoError(t, err)
+	test.assertAttestAgentResult(t, pendingID, result)
+	test.assertAgentWasStored(t, pendingID.String(), []*common.Selector{
+		{Type: "test_type", Value: "result"},
+	})
+
+	pending, err = test.ds.FetchPendingAgent(ctx, pendingID.String())
+	require.NoError(t, err)
+	require.Nil(t, pending)
+
+	// Agents that were already attested do not need approval
+	result, err = attestAgent("payload_attested_before")
+	require.NoError(t, err)
+	test.assertAttestAgentResult(t, spiffeid.RequireFromPath(td, "/spire/agent/test_type/id_attested_before"), result)
+}
+
+func TestAttestAgentFailuresSampled(t *testing.T) {
+	testCsr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, testkey.MustEC256())
+	require.NoError(t, err)
+
+	test := setupServiceTest(t, 0)
+	defer test.Cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	test.rateLimiter.count = 1
+
+	attestAgent := func() {
+		stream, err := test.client.AttestAgent(ctx)
+		require.NoError(t, err)
+		_, err = attest(t, stream, getAttestAgentRequest("join_token", []byte("bad_token"), testCsr))
+		require.NoError(t, stream.CloseSend())
+		spiretest.RequireGRPCStatus(t, err, codes.InvalidArgument, "failed to attest: join token does not exist or has already been used")
+	}
+	listFailures := func() []*datastore.AttestationEvent {
+		resp, err := test.ds.ListAttestationEvents(ctx, &datastore.ListAttestationEventsRequest{FailedOnly: true})
+		require.NoError(t, err)
+		return resp.Events
+	}
+
+	// Only the first failure from the caller is recorded during the
+	// sampling interval, since the agent is not authenticated
+	for i := 0; i < 3; i++ {
+		attestAgent()
+	}
+	failures := listFailures()
+	require.Len(t, failures, 1)
+	require.Equal(t, "failed to attest: join token does not exist or has already been used", failures[0].Error)
+
+	// The next failure records how many were not
+	test.clk.Add(time.Minute)
+	attestAgent()
+	failures = listFailures()
+	require.Len(t, failures, 2)
+	require.Equal(t, "failed to attest: join token does not exist or has already been used (2 other failures from the same source were not recorded)", failures[0].Error)
+}
+
+func TestAttestAgentWithPathTemplateTOFU(t *testing.T) {
+	testCsr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{}, testkey.MustEC256())
+	require.NoError(t, err)
+
+	tmpl, err := agentpathtemplate.Parse(`/{{ .PluginName }}/{{ index .Selectors "instance-name" }}`)
+	require.NoError(t, err)
+
+	test := setupServiceTest(t, 0, func(c *agent.Config) {
+		c.AgentPathTemplates = map[string]*agentpathtemplate.Template{"gcp_iit": tmpl}
+	})
+	defer test.Cleanup()
+
+	// gcp_iit trusts on first use, so the same attestation data cannot be
+	// used to attest an agent twice
+	test.cat.SetNodeAttestor(fakeservernodeattestor.New(t, "gcp_iit", fakeservernodeattestor.Config{
+		ReturnLiteral: true,
+		Payloads: map[string]string{
+			"payload_tofu": "spiffe://example.org/spire/agent/gcp_iit/project/instance",
+		},
+		Selectors: map[string][]string{
+			"spiffe://example.org/spire/agent/gcp_iit/project/instance": {"instance-name:instance"},
+		},
+	}))
+	test.rateLimiter.count = 1
+
+	attestTOFU := func() (*agentv1.AttestAgentResponse_Result, error) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stream, err := test.client.AttestAgent(ctx)
+		require.NoError(t, err)
+		result, err := attest(t, stream, getAttestAgentRequest("gcp_iit", []byte("payload_tofu"), testCsr))
+		require.NoError(t, stream.CloseSend())
+		return result, err
+	}
+
+	result, err := attestTOFU()
+	require.NoError(t, err)
+	expectedID := spiffeid.RequireFromPath(td, "/spire/agent/gcp_iit/instance")
+	test.assertAttestAgentResult(t, expectedID, result)
+
+	// Replaying the attestation data fails, even though the ID produced by
+	// the attestor before the template is applied was never attested
+	result, err = attestTOFU()
+	spiretest.RequireGRPCStatus(t, err, codes.PermissionDenied, "failed to attest: attestation data has already been used to attest an agent")
+	require.Nil(t, result)
+}
+
 type serviceTest struct {
 	client       agentv1.AgentClient
 	done         func()
 	ds           *fakedatastore.DataStore
 	ca           *fakeserverca.CA
 	cat          *fakeservercatalog.Catalog
-	clk          clock.Clock
+	clk          *clock.Mock
 	logHook      *test.Hook
+	metrics      *fakemetrics.FakeMetrics
 	rateLimiter  *fakeRateLimiter
 	withCallerID bool
 	pluginCloser func()
@@ -3076,20 +3348,28 @@ func (s *serviceTest) Cleanup() {
 	}
 }
 
-func setupServiceTest(t *testing.T, agentTTL time.Duration) *serviceTest {
+func setupServiceTest(t *testing.T, agentTTL time.Duration, configure ...func(*agent.Config)) *serviceTest {
 	ca := fakeserverca.New(t, td, &fakeserverca.Options{})
 	ds := fakedatastore.New(t)
 	cat := fakeservercatalog.New()
 	clk := clock.NewMock(t)
+	metrics := fakemetrics.New()
 
-	service := agent.New(agent.Config{
+	config := agent.Config{
 		ServerCA:    ca,
 		DataStore:   ds,
 		TrustDomain: td,
 		Clock:       clk,
 		Catalog:     cat,
 		AgentTTL:    agentTTL,
-	})
+		Metrics:     metrics,
+
+		SelectorWarningThreshold: selectorWarningThreshold,
+	}
+	for _, fn := range configure {
+		fn(&config)
+	}
+	service := agent.New(config)
 
 	log, logHook := test.NewNullLogger()
 	log.Level = logrus.DebugLevel
@@ -3105,12 +3385,14 @@ func setupServiceTest(t *testing.T, agentTTL time.Duration) *serviceTest {
 		cat:         cat,
 		clk:         clk,
 		logHook:     logHook,
+		metrics:     metrics,
 		rateLimiter: rateLimiter,
 	}
 
 	ppMiddleware := middleware.Preprocess(func(ctx context.Context, fullMethod string, req interface{}) (context.Context, error) {
 		ctx = rpccontext.WithLogger(ctx, log)
 		ctx = rpccontext.WithRateLimiter(ctx, rateLimiter)
+		ctx = rpccontext.WithCallerAddr(ctx, callerAddr)
 		if test.withCallerID {
 			ctx = rpccontext.WithCallerID(ctx, agentID)
 		}
@@ -3144,6 +3426,7 @@ func (s *serviceTest) setupAttestor(t *testing.T) {
 			"payload_return_server_id":            "spiffe://example.org/spire/server",
 			"payload_return_id_outside_namespace": "spiffe://example.org/id_outside_namespace",
 			"payload_selector_dups":               "spiffe://example.org/spire/agent/test_type/id_selector_dups",
+			"payload_templated":                   "spiffe://example.org/spire/agent/test_type/id_templated",
 		},
 		Selectors: map[string][]string{
 			"spiffe://example.org/spire/agent/test_type/id_with_result":     {"result"},
@@ -3151,6 +3434,7 @@ func (s *serviceTest) setupAttestor(t *testing.T) {
 			"spiffe://example.org/spire/agent/test_type/id_with_challenge":  {"challenge"},
 			"spiffe://example.org/spire/agent/test_type/id_banned":          {"banned"},
 			"spiffe://example.org/spire/agent/test_type/id_selector_dups":   {"A", "B", "C", "A", "D"},
+			"spiffe://example.org/spire/agent/test_type/id_templated":       {"region:us-east-1", "tag:Name:web", "sg:a", "sg:b"},
 		},
 		Challenges: map[string][]string{
 			"id_with_challenge": {"challenge_response"},
@@ -3249,6 +3533,15 @@ func (s *serviceTest) assertAgentWasStored(t *testing.T, expectedID string, expe
 	agentSelectors, err := s.ds.GetNodeSelectors(ctx, expectedID, datastore.RequireCurrent)
 	require.NoError(t, err)
 	require.EqualValues(t, expectedSelectors, agentSelectors)
+
+	events, err := s.ds.ListAttestationEvents(ctx, &datastore.ListAttestationEventsRequest{
+		BySpiffeID: expectedID,
+		Limit:      1,
+	})
+	require.NoError(t, err)
+	require.Len(t, events.Events, 1)
+	require.Equal(t, attestedAgent.CertSerialNumber, events.Events[0].SerialNumber)
+	require.Equal(t, attestedAgent.CanReattest, events.Events[0].CanReattest)
 }
 
 type fakeRateLimiter struct {
@@ -13,34 +13,49 @@ import (
 	"github.com/spiffe/spire/pkg/server/api"
 	"github.com/spiffe/spire/pkg/server/api/rpccontext"
 	"github.com/spiffe/spire/pkg/server/datastore"
+	"github.com/spiffe/spire/pkg/server/entryadmission"
 	"github.com/spiffe/spire/proto/spire/common"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
+// Admission reviews entries before they are created or updated. It returns
+// the entry to use, possibly mutated, or an *entryadmission.Rejection if the
+// entry is rejected.
+type Admission interface {
+	Review(ctx context.Context, op entryadmission.Operation, entry *types.Entry, inputMask *types.EntryMask) (*types.Entry, error)
+}
+
 // Config defines the service configuration.
 type Config struct {
 	TrustDomain  spiffeid.TrustDomain
 	EntryFetcher api.AuthorizedEntryFetcher
 	DataStore    datastore.DataStore
+
+	// Admission, if set, reviews the entries before they are created or
+	// updated.
+	Admission Admission
 }
 
 // Service defines the v1 entry service.
 type Service struct {
 	entryv1.UnsafeEntryServer
 
-	td spiffeid.TrustDomain
-	ds datastore.DataStore
-	ef api.AuthorizedEntryFetcher
+	td        spiffeid.TrustDomain
+	ds        datastore.DataStore
+	ef        api.AuthorizedEntryFetcher
+	admission Admission
 }
 
 // New creates a new v1 entry service.
 func New(config Config) *Service {
 	return &Service{
-		td: config.TrustDomain,
-		ds: config.DataStore,
-		ef: config.EntryFetcher,
+		td:        config.TrustDomain,
+		ds:        config.DataStore,
+		ef:        config.EntryFetcher,
+		admission: config.Admission,
 	}
 }
 
@@ -197,6 +212,13 @@ func (s *Service) BatchCreateEntry(ctx context.Context, req *entryv1.BatchCreate
 func (s *Service) createEntry(ctx context.Context, e *types.Entry, outputMask *types.EntryMask) *entryv1.BatchCreateEntryResponse_Result {
 	log := rpccontext.Logger(ctx)
 
+	e, reviewStatus := s.reviewEntry(ctx, log, entryadmission.Create, e, nil)
+	if reviewStatus != nil {
+		return &entryv1.BatchCreateEntryResponse_Result{
+			Status: reviewStatus,
+		}
+	}
+
 	cEntry, err := api.ProtoToRegistrationEntry(ctx, s.td, e)
 	if err != nil {
 		return &entryv1.BatchCreateEntryResponse_Result{
@@ -209,6 +231,10 @@ func (s *Service) createEntry(ctx context.Context, e *types.Entry, outputMask *t
 	resultStatus := api.OK()
 	regEntry, existing, err := s.ds.CreateOrReturnRegistrationEntry(ctx, cEntry)
 	switch {
+	case status.Code(err) == codes.InvalidArgument:
+		return &entryv1.BatchCreateEntryResponse_Result{
+			Status: api.MakeStatus(log, codes.InvalidArgument, "failed to create entry", err),
+		}
 	case err != nil:
 		return &entryv1.BatchCreateEntryResponse_Result{
 			Status: api.MakeStatus(log, codes.Internal, "failed to create entry", err),
@@ -387,6 +413,13 @@ func (s *Service) updateEntry(ctx context.Context, e *types.Entry, inputMask *ty
 	log := rpccontext.Logger(ctx)
 	log = log.WithField(telemetry.RegistrationID, e.Id)
 
+	e, reviewStatus := s.reviewEntryUpdate(ctx, log, e, inputMask)
+	if reviewStatus != nil {
+		return &entryv1.BatchUpdateEntryResponse_Result{
+			Status: reviewStatus,
+		}
+	}
+
 	convEntry, err := api.ProtoToRegistrationEntryWithMask(ctx, s.td, e, inputMask)
 	if err != nil {
 		return &entryv1.BatchUpdateEntryResponse_Result{
@@ -409,8 +442,26 @@ func (s *Service) updateEntry(ctx context.Context, e *types.Entry, inputMask *ty
 			StoreSvid:     inputMask.StoreSvid,
 		}
 	}
-	dsEntry, err := s.ds.UpdateRegistrationEntry(ctx, convEntry, mask)
-	if err != nil {
+
+	// When the revision number is part of the input mask, the caller expects
+	// the entry to be at that revision, and the update is rejected if it was
+	// modified in the meantime
+	var dsEntry *common.RegistrationEntry
+	if inputMask != nil && inputMask.RevisionNumber {
+		dsEntry, err = s.ds.CompareAndUpdateRegistrationEntry(ctx, convEntry, mask, e.RevisionNumber)
+	} else {
+		dsEntry, err = s.ds.UpdateRegistrationEntry(ctx, convEntry, mask)
+	}
+	switch {
+	case status.Code(err) == codes.Aborted:
+		return &entryv1.BatchUpdateEntryResponse_Result{
+			Status: api.MakeStatus(log, codes.Aborted, "entry was modified concurrently", err),
+		}
+	case status.Code(err) == codes.InvalidArgument:
+		return &entryv1.BatchUpdateEntryResponse_Result{
+			Status: api.MakeStatus(log, codes.InvalidArgument, "failed to update entry", err),
+		}
+	case err != nil:
 		return &entryv1.BatchUpdateEntryResponse_Result{
 			Status: api.MakeStatus(log, codes.Internal, "failed to update entry", err),
 		}
@@ -431,6 +482,93 @@ func (s *Service) updateEntry(ctx context.Context, e *types.Entry, inputMask *ty
 	}
 }
 
+// reviewEntry submits the entry to admission, if configured. It returns the
+// entry to use, or the status to return to the caller if the entry was not
+// admitted.
+func (s *Service) reviewEntry(ctx context.Context, log logrus.FieldLogger, op entryadmission.Operation, e *types.Entry, inputMask *types.EntryMask) (*types.Entry, *types.Status) {
+	if s.admission == nil {
+		return e, nil
+	}
+
+	reviewed, err := s.admission.Review(ctx, op, e, inputMask)
+	var rejection *entryadmission.Rejection
+	switch {
+	case errors.As(err, &rejection):
+		return nil, api.MakeStatus(log, codes.InvalidArgument, "entry rejected by admission webhook", err)
+	case err != nil:
+		return nil, api.MakeStatus(log, codes.Internal, "failed to review entry", err)
+	}
+	return reviewed, nil
+}
+
+// reviewEntryUpdate submits the entry to admission, if configured, as it will
+// be stored once updated, so the fields that are not updated are reviewed
+// too.
+func (s *Service) reviewEntryUpdate(ctx context.Context, log logrus.FieldLogger, e *types.Entry, inputMask *types.EntryMask) (*types.Entry, *types.Status) {
+	if s.admission == nil {
+		return e, nil
+	}
+
+	stored, err := s.ds.FetchRegistrationEntry(ctx, e.Id)
+	switch {
+	case err != nil:
+		return nil, api.MakeStatus(log, codes.Internal, "failed to fetch entry", err)
+	case stored == nil:
+		return nil, api.MakeStatus(log, codes.NotFound, "entry not found", nil)
+	}
+
+	storedEntry, err := api.RegistrationEntryToProto(stored)
+	if err != nil {
+		return nil, api.MakeStatus(log, codes.Internal, "failed to convert entry", err)
+	}
+
+	return s.reviewEntry(ctx, log, entryadmission.Update, mergeEntry(storedEntry, e, inputMask), inputMask)
+}
+
+// mergeEntry returns the stored entry with the fields in the mask set from
+// the given entry. All of the fields are set when the mask is nil.
+func mergeEntry(stored, e *types.Entry, mask *types.EntryMask) *types.Entry {
+	if mask == nil {
+		return e
+	}
+
+	merged := proto.Clone(stored).(*types.Entry)
+	if mask.SpiffeId {
+		merged.SpiffeId = e.SpiffeId
+	}
+	if mask.ParentId {
+		merged.ParentId = e.ParentId
+	}
+	if mask.Selectors {
+		merged.Selectors = e.Selectors
+	}
+	if mask.Ttl {
+		merged.Ttl = e.Ttl
+	}
+	if mask.FederatesWith {
+		merged.FederatesWith = e.FederatesWith
+	}
+	if mask.Admin {
+		merged.Admin = e.Admin
+	}
+	if mask.Downstream {
+		merged.Downstream = e.Downstream
+	}
+	if mask.ExpiresAt {
+		merged.ExpiresAt = e.ExpiresAt
+	}
+	if mask.DnsNames {
+		merged.DnsNames = e.DnsNames
+	}
+	if mask.RevisionNumber {
+		merged.RevisionNumber = e.RevisionNumber
+	}
+	if mask.StoreSvid {
+		merged.StoreSvid = e.StoreSvid
+	}
+	return merged
+}
+
 func fieldsFromEntryProto(ctx context.Context, proto *types.Entry, inputMask *types.EntryMask) logrus.Fields {
 	fields := logrus.Fields{}
 
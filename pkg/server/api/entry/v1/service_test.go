@@ -19,6 +19,7 @@ import (
 	"github.com/spiffe/spire/pkg/server/api/middleware"
 	"github.com/spiffe/spire/pkg/server/api/rpccontext"
 	"github.com/spiffe/spire/pkg/server/datastore"
+	"github.com/spiffe/spire/pkg/server/entryadmission"
 	"github.com/spiffe/spire/proto/spire/common"
 	"github.com/spiffe/spire/test/fakes/fakedatastore"
 	"github.com/spiffe/spire/test/spiretest"
@@ -1988,6 +1989,54 @@ func TestBatchCreateEntry(t *testing.T) {
 			dsError:         errors.New("creating error"),
 			dsResults:       map[string]*common.RegistrationEntry{"entry1": nil},
 		},
+		{
+			name: "entry references unknown selector set",
+			expectLogs: []spiretest.LogEntry{
+				{
+					Level:   logrus.ErrorLevel,
+					Message: "Invalid argument: failed to create entry",
+					Data: logrus.Fields{
+						logrus.ErrorKey:    `rpc error: code = InvalidArgument desc = invalid registration entry: selector set "unknown" does not exist`,
+						telemetry.SPIFFEID: "spiffe://example.org/workload",
+					},
+				},
+				{
+					Level:   logrus.InfoLevel,
+					Message: "API accessed",
+					Data: logrus.Fields{
+						telemetry.Status:         "error",
+						telemetry.Type:           "audit",
+						telemetry.Admin:          "true",
+						telemetry.DNSName:        "dns1",
+						telemetry.Downstream:     "true",
+						telemetry.RegistrationID: "entry1",
+						telemetry.ExpiresAt:      strconv.FormatInt(testEntry.ExpiresAt, 10),
+						telemetry.FederatesWith:  "domain1.org",
+						telemetry.ParentID:       "spiffe://example.org/host",
+						telemetry.RevisionNumber: "0",
+						telemetry.Selectors:      "type:value1,type:value2",
+						telemetry.SPIFFEID:       "spiffe://example.org/workload",
+						telemetry.TTL:            "60",
+						telemetry.StoreSvid:      "false",
+						telemetry.StatusCode:     "InvalidArgument",
+						telemetry.StatusMessage:  `failed to create entry: invalid registration entry: selector set "unknown" does not exist`,
+					},
+				},
+			},
+			expectResults: []*entryv1.BatchCreateEntryResponse_Result{
+				{
+					Status: &types.Status{
+						Code:    int32(codes.InvalidArgument),
+						Message: `failed to create entry: invalid registration entry: selector set "unknown" does not exist`,
+					},
+				},
+			},
+
+			reqEntries:      []*types.Entry{testEntry},
+			expectDsEntries: map[string]*common.RegistrationEntry{"entry1": testDSEntry},
+			dsError:         status.Error(codes.InvalidArgument, `invalid registration entry: selector set "unknown" does not exist`),
+			dsResults:       map[string]*common.RegistrationEntry{"entry1": nil},
+		},
 		{
 			name: "ds returns malformed entry",
 			expectLogs: []spiretest.LogEntry{
@@ -2579,6 +2628,115 @@ func createTestEntries(t *testing.T, ds datastore.DataStore, entry ...*common.Re
 	return entriesMap
 }
 
+func TestEntryAdmission(t *testing.T) {
+	ds := fakedatastore.New(t)
+	admission := &fakeAdmission{}
+	test := setupServiceTest(t, ds, func(c *entry.Config) {
+		c.Admission = admission
+	})
+	defer test.Cleanup()
+
+	newEntry := func(path string) *types.Entry {
+		return &types.Entry{
+			ParentId:  &types.SPIFFEID{TrustDomain: "example.org", Path: "/agent"},
+			SpiffeId:  &types.SPIFFEID{TrustDomain: "example.org", Path: path},
+			Selectors: []*types.Selector{{Type: "unix", Value: "uid:1000"}},
+		}
+	}
+
+	createResp, err := test.client.BatchCreateEntry(ctx, &entryv1.BatchCreateEntryRequest{
+		Entries: []*types.Entry{
+			newEntry("/workload"),
+			newEntry("/forbidden"),
+			newEntry("/broken"),
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, createResp.Results, 3)
+
+	// The admitted entry is created as mutated by the admission
+	require.Equal(t, int32(codes.OK), createResp.Results[0].Status.Code)
+	require.Equal(t, []string{"mutated.example.org"}, createResp.Results[0].Entry.DnsNames)
+	created, err := ds.FetchRegistrationEntry(ctx, createResp.Results[0].Entry.Id)
+	require.NoError(t, err)
+	require.Equal(t, []string{"mutated.example.org"}, created.DnsNames)
+
+	spiretest.AssertProtoEqual(t, &types.Status{
+		Code:    int32(codes.InvalidArgument),
+		Message: "entry rejected by admission webhook: policy violated (spiffe_id: path is forbidden)",
+	}, createResp.Results[1].Status)
+	spiretest.AssertProtoEqual(t, &types.Status{
+		Code:    int32(codes.Internal),
+		Message: "failed to review entry: webhook unavailable",
+	}, createResp.Results[2].Status)
+
+	updateResp, err := test.client.BatchUpdateEntry(ctx, &entryv1.BatchUpdateEntryRequest{
+		Entries: []*types.Entry{
+			{Id: created.EntryId, DnsNames: []string{"other.example.org"}},
+		},
+		InputMask: &types.EntryMask{DnsNames: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, updateResp.Results, 1)
+	require.Equal(t, int32(codes.OK), updateResp.Results[0].Status.Code)
+	require.Equal(t, []string{"mutated.example.org"}, updateResp.Results[0].Entry.DnsNames)
+
+	require.Equal(t, []entryadmission.Operation{
+		entryadmission.Create,
+		entryadmission.Create,
+		entryadmission.Create,
+		entryadmission.Update,
+	}, admission.ops)
+	spiretest.AssertProtoEqual(t, &types.EntryMask{DnsNames: true}, admission.lastInputMask)
+
+	// The update is reviewed against the stored entry with the updated
+	// fields applied
+	require.Equal(t, created.EntryId, admission.lastEntry.Id)
+	require.Equal(t, "/workload", admission.lastEntry.SpiffeId.Path)
+	require.Equal(t, []string{"other.example.org"}, admission.lastEntry.DnsNames)
+
+	// Updates of missing entries are not reviewed
+	updateResp, err = test.client.BatchUpdateEntry(ctx, &entryv1.BatchUpdateEntryRequest{
+		Entries: []*types.Entry{
+			{Id: "missing", DnsNames: []string{"other.example.org"}},
+		},
+		InputMask: &types.EntryMask{DnsNames: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, updateResp.Results, 1)
+	spiretest.AssertProtoEqual(t, &types.Status{
+		Code:    int32(codes.NotFound),
+		Message: "entry not found",
+	}, updateResp.Results[0].Status)
+	require.Len(t, admission.ops, 4)
+}
+
+type fakeAdmission struct {
+	ops           []entryadmission.Operation
+	lastInputMask *types.EntryMask
+	lastEntry     *types.Entry
+}
+
+func (a *fakeAdmission) Review(ctx context.Context, op entryadmission.Operation, e *types.Entry, inputMask *types.EntryMask) (*types.Entry, error) {
+	a.ops = append(a.ops, op)
+	a.lastInputMask = inputMask
+	a.lastEntry = e
+
+	switch e.GetSpiffeId().GetPath() {
+	case "/forbidden":
+		return nil, &entryadmission.Rejection{
+			Reason:     "policy violated",
+			Violations: []entryadmission.Violation{{Field: "spiffe_id", Message: "path is forbidden"}},
+		}
+	case "/broken":
+		return nil, errors.New("webhook unavailable")
+	}
+
+	mutated := proto.Clone(e).(*types.Entry)
+	mutated.DnsNames = []string{"mutated.example.org"}
+	return mutated, nil
+}
+
 type serviceTest struct {
 	client       entryv1.EntryClient
 	ef           *entryFetcher
@@ -2592,13 +2750,17 @@ func (s *serviceTest) Cleanup() {
 	s.done()
 }
 
-func setupServiceTest(t *testing.T, ds datastore.DataStore) *serviceTest {
+func setupServiceTest(t *testing.T, ds datastore.DataStore, configure ...func(*entry.Config)) *serviceTest {
 	ef := &entryFetcher{}
-	service := entry.New(entry.Config{
+	config := entry.Config{
 		TrustDomain:  td,
 		DataStore:    ds,
 		EntryFetcher: ef,
-	})
+	}
+	for _, fn := range configure {
+		fn(&config)
+	}
+	service := entry.New(config)
 
 	log, logHook := test.NewNullLogger()
 	registerFn := func(s *grpc.Server) {
@@ -3576,6 +3738,97 @@ func TestBatchUpdateEntry(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:           "Success Expected Revision Number",
+			initialEntries: []*types.Entry{initialEntry},
+			inputMask: &types.EntryMask{
+				Ttl:            true,
+				RevisionNumber: true,
+			},
+			outputMask: &types.EntryMask{
+				Ttl:            true,
+				RevisionNumber: true,
+			},
+			updateEntries: []*types.Entry{
+				{
+					Ttl:            500000,
+					RevisionNumber: 0,
+				},
+			},
+			expectResults: []*entryv1.BatchUpdateEntryResponse_Result{
+				{
+					Status: &types.Status{Code: int32(codes.OK), Message: "OK"},
+					Entry: &types.Entry{
+						Ttl:            500000,
+						RevisionNumber: 1,
+					},
+				},
+			},
+			expectLogs: func(m map[string]string) []spiretest.LogEntry {
+				return []spiretest.LogEntry{
+					{
+						Level:   logrus.InfoLevel,
+						Message: "API accessed",
+						Data: logrus.Fields{
+							telemetry.Status:         "success",
+							telemetry.Type:           "audit",
+							telemetry.RegistrationID: m[entry1SpiffeID.Path],
+							telemetry.RevisionNumber: "0",
+							telemetry.TTL:            "500000",
+						},
+					},
+				}
+			},
+		},
+		{
+			name:           "Fail Stale Revision Number",
+			initialEntries: []*types.Entry{initialEntry},
+			inputMask: &types.EntryMask{
+				Ttl:            true,
+				RevisionNumber: true,
+			},
+			updateEntries: []*types.Entry{
+				{
+					Ttl:            500000,
+					RevisionNumber: 1,
+				},
+			},
+			expectDsEntries: func(id string) []*types.Entry {
+				unmodifiedEntry := proto.Clone(initialEntry).(*types.Entry)
+				unmodifiedEntry.Id = id
+				return []*types.Entry{unmodifiedEntry}
+			},
+			expectResults: []*entryv1.BatchUpdateEntryResponse_Result{
+				{
+					Status: &types.Status{Code: int32(codes.Aborted), Message: "entry was modified concurrently: expected revision number 1 but found 0"},
+				},
+			},
+			expectLogs: func(m map[string]string) []spiretest.LogEntry {
+				return []spiretest.LogEntry{
+					{
+						Level:   logrus.ErrorLevel,
+						Message: "Entry was modified concurrently",
+						Data: logrus.Fields{
+							telemetry.RegistrationID: m[entry1SpiffeID.Path],
+							logrus.ErrorKey:          "rpc error: code = Aborted desc = expected revision number 1 but found 0",
+						},
+					},
+					{
+						Level:   logrus.InfoLevel,
+						Message: "API accessed",
+						Data: logrus.Fields{
+							telemetry.Status:         "error",
+							telemetry.Type:           "audit",
+							telemetry.RegistrationID: m[entry1SpiffeID.Path],
+							telemetry.StatusCode:     "Aborted",
+							telemetry.StatusMessage:  "entry was modified concurrently: expected revision number 1 but found 0",
+							telemetry.RevisionNumber: "1",
+							telemetry.TTL:            "500000",
+						},
+					},
+				}
+			},
+		},
 		{
 			name:           "Success Nil Input Mask",
 			initialEntries: []*types.Entry{initialEntry},
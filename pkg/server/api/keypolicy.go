@@ -0,0 +1,78 @@
+package api
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"strconv"
+)
+
+const (
+	KeyTypeRSA     = "rsa"
+	KeyTypeECDSA   = "ecdsa"
+	KeyTypeEd25519 = "ed25519"
+)
+
+// KeyPolicy restricts the public keys the server signs certificates for,
+// e.g. to meet a compliance profile. The zero value allows any key.
+type KeyPolicy struct {
+	// AllowedKeyTypes are the allowed key types, among KeyTypeRSA,
+	// KeyTypeECDSA and KeyTypeEd25519. Any type is allowed if empty.
+	AllowedKeyTypes []string
+
+	// MinRSAKeySize is the minimum size of RSA keys, in bits.
+	MinRSAKeySize int
+
+	// AllowedCurves are the names of the allowed ECDSA curves, e.g. "P-256".
+	// Any curve is allowed if empty.
+	AllowedCurves []string
+}
+
+// CheckKey returns an error if the key is not allowed by the policy.
+func (p KeyPolicy) CheckKey(key crypto.PublicKey) error {
+	keyType := KeyDescription(key)
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		keyType = KeyTypeRSA
+		if size := key.N.BitLen(); size < p.MinRSAKeySize {
+			return fmt.Errorf("RSA key size of %d bits is below the minimum of %d bits", size, p.MinRSAKeySize)
+		}
+	case *ecdsa.PublicKey:
+		keyType = KeyTypeECDSA
+		if curve := key.Curve.Params().Name; len(p.AllowedCurves) > 0 && !containsString(p.AllowedCurves, curve) {
+			return fmt.Errorf("ECDSA curve %s is not allowed", curve)
+		}
+	case ed25519.PublicKey:
+		keyType = KeyTypeEd25519
+	}
+	if len(p.AllowedKeyTypes) > 0 && !containsString(p.AllowedKeyTypes, keyType) {
+		return fmt.Errorf("%s keys are not allowed", keyType)
+	}
+	return nil
+}
+
+// KeyDescription describes the type and size of a public key, e.g.
+// "rsa-2048" or "ecdsa-P-256", for metrics and logs.
+func KeyDescription(key crypto.PublicKey) string {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return KeyTypeRSA + "-" + strconv.Itoa(key.N.BitLen())
+	case *ecdsa.PublicKey:
+		return KeyTypeECDSA + "-" + key.Curve.Params().Name
+	case ed25519.PublicKey:
+		return KeyTypeEd25519
+	default:
+		return fmt.Sprintf("%T", key)
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package api_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/spiffe/spire/pkg/server/api"
+	"github.com/spiffe/spire/test/testkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPolicy(t *testing.T) {
+	rsa1024, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	ec224, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+	ed25519Key, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsa1024Key := rsa1024.Public()
+	rsa2048Key := testkey.NewRSA2048(t).Public()
+	ec224Key := ec224.Public()
+	ec256Key := testkey.NewEC256(t).Public()
+
+	strict := api.KeyPolicy{
+		AllowedKeyTypes: []string{api.KeyTypeRSA, api.KeyTypeECDSA},
+		MinRSAKeySize:   2048,
+		AllowedCurves:   []string{"P-256", "P-384"},
+	}
+
+	for _, tt := range []struct {
+		name      string
+		policy    api.KeyPolicy
+		key       crypto.PublicKey
+		expectErr string
+	}{
+		{name: "zero policy allows weak RSA key", key: rsa1024Key},
+		{name: "zero policy allows any curve", key: ec224Key},
+		{name: "zero policy allows ed25519", key: ed25519Key},
+		{name: "RSA key at minimum size", policy: strict, key: rsa2048Key},
+		{name: "RSA key below minimum size", policy: strict, key: rsa1024Key, expectErr: "RSA key size of 1024 bits is below the minimum of 2048 bits"},
+		{name: "allowed curve", policy: strict, key: ec256Key},
+		{name: "curve not allowed", policy: strict, key: ec224Key, expectErr: "ECDSA curve P-224 is not allowed"},
+		{name: "key type not allowed", policy: strict, key: ed25519Key, expectErr: "ed25519 keys are not allowed"},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.CheckKey(tt.key)
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	assert.Equal(t, "rsa-1024", api.KeyDescription(rsa1024Key))
+	assert.Equal(t, "ecdsa-P-256", api.KeyDescription(ec256Key))
+	assert.Equal(t, "ed25519", api.KeyDescription(ed25519Key))
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"github.com/spiffe/spire/pkg/common/clockskew"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	telemetry_server "github.com/spiffe/spire/pkg/common/telemetry/server"
+	"github.com/spiffe/spire/pkg/server/api/rpccontext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const attestAgentFullMethod = "/spire.api.server.agent.v1.Agent/AttestAgent"
+
+// WithAgentClockSkew returns a middleware that measures the skew between the
+// clock of the agents, as reported in the request metadata, and the server
+// clock. Only the skew of authenticated agents is recorded, since anyone can
+// report an arbitrary time. If maxSkew is greater than zero, attestation is
+// refused to agents that do not report their time or whose clock is skewed
+// by more than maxSkew, and a warning is logged for the requests of
+// authenticated agents whose clock is skewed by more than maxSkew.
+func WithAgentClockSkew(metrics telemetry.Metrics, clk clock.Clock, maxSkew time.Duration) Middleware {
+	return Preprocess(func(ctx context.Context, fullMethod string, req interface{}) (context.Context, error) {
+		if fullMethod == attestAgentFullMethod {
+			return ctx, checkAttestingAgentClockSkew(ctx, clk, maxSkew)
+		}
+		if !rpccontext.CallerIsAgent(ctx) {
+			return ctx, nil
+		}
+
+		agentTime, ok := clockskew.FromIncomingContext(ctx)
+		if !ok {
+			return ctx, nil
+		}
+
+		skew := agentTime.Sub(clk.Now())
+		telemetry_server.AddAgentClockSkewSample(metrics, skew)
+
+		if exceedsMaxSkew(skew, maxSkew) {
+			rpccontext.Logger(ctx).WithField(telemetry.ClockSkew, skew.String()).Warn("Agent clock is skewed")
+		}
+		return ctx, nil
+	})
+}
+
+// checkAttestingAgentClockSkew fails if maxSkew is set and the attesting
+// agent either did not report its time or its clock is skewed by more than
+// maxSkew.
+func checkAttestingAgentClockSkew(ctx context.Context, clk clock.Clock, maxSkew time.Duration) error {
+	if maxSkew <= 0 {
+		return nil
+	}
+
+	log := rpccontext.Logger(ctx)
+	agentTime, ok := clockskew.FromIncomingContext(ctx)
+	if !ok {
+		log.Error("Refusing attestation of agent that did not report its time")
+		return status.Error(codes.FailedPrecondition, "agent did not report its time, which is required to check its clock skew")
+	}
+
+	skew := agentTime.Sub(clk.Now())
+	if exceedsMaxSkew(skew, maxSkew) {
+		log.WithField(telemetry.ClockSkew, skew.String()).Error("Refusing attestation of agent with skewed clock")
+		return status.Errorf(codes.FailedPrecondition, "agent clock is skewed by %s, which exceeds the maximum of %s", skew, maxSkew)
+	}
+	return nil
+}
+
+func exceedsMaxSkew(skew, maxSkew time.Duration) bool {
+	return maxSkew > 0 && (skew > maxSkew || skew < -maxSkew)
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/spire/pkg/common/clockskew"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/pkg/server/api/rpccontext"
+	"github.com/spiffe/spire/test/clock"
+	"github.com/spiffe/spire/test/fakes/fakemetrics"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestWithAgentClockSkew(t *testing.T) {
+	const syncMethod = "/spire.api.server.entry.v1.Entry/GetAuthorizedEntries"
+
+	for _, tt := range []struct {
+		name          string
+		fullMethod    string
+		agentCaller   bool
+		maxSkew       time.Duration
+		skew          *time.Duration
+		expectCode    codes.Code
+		expectMsg     string
+		expectLogs    []spiretest.LogEntry
+		expectMetrics bool
+	}{
+		{
+			name:       "no agent time on attestation",
+			fullMethod: attestAgentFullMethod,
+			maxSkew:    time.Minute,
+			expectCode: codes.FailedPrecondition,
+			expectMsg:  "agent did not report its time, which is required to check its clock skew",
+			expectLogs: []spiretest.LogEntry{
+				{
+					Level:   logrus.ErrorLevel,
+					Message: "Refusing attestation of agent that did not report its time",
+				},
+			},
+		},
+		{
+			name:       "no agent time on attestation without bound",
+			fullMethod: attestAgentFullMethod,
+		},
+		{
+			name:       "skew within bound on attestation",
+			fullMethod: attestAgentFullMethod,
+			maxSkew:    time.Minute,
+			skew:       durationPtr(-time.Minute),
+		},
+		{
+			name:       "skew exceeds bound on attestation",
+			fullMethod: attestAgentFullMethod,
+			maxSkew:    time.Minute,
+			skew:       durationPtr(2 * time.Minute),
+			expectCode: codes.FailedPrecondition,
+			expectMsg:  "agent clock is skewed by 2m0s, which exceeds the maximum of 1m0s",
+			expectLogs: []spiretest.LogEntry{
+				{
+					Level:   logrus.ErrorLevel,
+					Message: "Refusing attestation of agent with skewed clock",
+					Data: logrus.Fields{
+						telemetry.ClockSkew: "2m0s",
+					},
+				},
+			},
+		},
+		{
+			name:       "skew not checked on attestation without bound",
+			fullMethod: attestAgentFullMethod,
+			skew:       durationPtr(time.Hour),
+		},
+		{
+			name:          "skew within bound on sync",
+			fullMethod:    syncMethod,
+			agentCaller:   true,
+			maxSkew:       time.Minute,
+			skew:          durationPtr(time.Minute),
+			expectMetrics: true,
+		},
+		{
+			name:          "skew exceeds bound on sync",
+			fullMethod:    syncMethod,
+			agentCaller:   true,
+			maxSkew:       time.Minute,
+			skew:          durationPtr(-2 * time.Minute),
+			expectMetrics: true,
+			expectLogs: []spiretest.LogEntry{
+				{
+					Level:   logrus.WarnLevel,
+					Message: "Agent clock is skewed",
+					Data: logrus.Fields{
+						telemetry.ClockSkew: "-2m0s",
+					},
+				},
+			},
+		},
+		{
+			name:          "no bound on sync",
+			fullMethod:    syncMethod,
+			agentCaller:   true,
+			skew:          durationPtr(time.Hour),
+			expectMetrics: true,
+		},
+		{
+			name:        "no agent time on sync",
+			fullMethod:  syncMethod,
+			agentCaller: true,
+			maxSkew:     time.Minute,
+		},
+		{
+			name:       "caller is not an agent",
+			fullMethod: syncMethod,
+			maxSkew:    time.Minute,
+			skew:       durationPtr(time.Hour),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			log, hook := test.NewNullLogger()
+			clk := clock.NewMock(t)
+			metrics := fakemetrics.New()
+
+			ctx := rpccontext.WithLogger(context.Background(), log)
+			if tt.agentCaller {
+				ctx = rpccontext.WithAgentCaller(ctx)
+			}
+			if tt.skew != nil {
+				md := metadata.Pairs(clockskew.MetadataKey, clk.Now().Add(*tt.skew).Format(time.RFC3339Nano))
+				ctx = metadata.NewIncomingContext(ctx, md)
+			}
+
+			m := WithAgentClockSkew(metrics, clk, tt.maxSkew)
+			_, err := m.Preprocess(ctx, tt.fullMethod, nil)
+			if tt.expectCode != codes.OK {
+				spiretest.RequireGRPCStatus(t, err, tt.expectCode, tt.expectMsg)
+			} else {
+				require.NoError(t, err)
+			}
+			spiretest.AssertLogs(t, hook.AllEntries(), tt.expectLogs)
+
+			var expectMetrics []fakemetrics.MetricItem
+			if tt.expectMetrics {
+				expectMetrics = []fakemetrics.MetricItem{
+					{
+						Type: fakemetrics.AddSampleType,
+						Key:  []string{telemetry.Node, telemetry.ClockSkew},
+						Val:  float32(tt.skew.Seconds()),
+					},
+				}
+			}
+			require.Equal(t, expectMetrics, metrics.AllMetrics())
+		})
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/blang/semver/v4"
+	"github.com/spiffe/spire/pkg/common/negotiation"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	telemetry_server "github.com/spiffe/spire/pkg/common/telemetry/server"
+	"github.com/spiffe/spire/pkg/server/api/rpccontext"
+	"google.golang.org/grpc"
+)
+
+// agentVersionOther is the version agents advertising something else than a
+// semantic version are counted under.
+const agentVersionOther = "other"
+
+// WithFeatureNegotiation returns a middleware that advertises the version
+// and features of the server in the response header. For agents that
+// advertise themselves in the request metadata, the agent version is added
+// to the request logger. The version is counted in the node.version metric
+// only for authenticated agents, since anyone can advertise a version, e.g.
+// when attesting, and versions that are not semantic versions are counted as
+// "other", to keep the cardinality of the metric bounded.
+func WithFeatureNegotiation(metrics telemetry.Metrics) Middleware {
+	return Preprocess(func(ctx context.Context, fullMethod string, req interface{}) (context.Context, error) {
+		// Advertising the server is best effort; it fails when there is no
+		// transport stream, e.g. in tests calling handlers directly.
+		_ = grpc.SetHeader(ctx, negotiation.ServerHeader())
+
+		agent, ok := negotiation.AgentFromIncomingContext(ctx)
+		if !ok {
+			return ctx, nil
+		}
+		if rpccontext.CallerIsAgent(ctx) {
+			telemetry_server.IncrAgentVersionCounter(metrics, agentVersionLabel(agent.Version))
+		}
+		return rpccontext.WithLogger(ctx, rpccontext.Logger(ctx).WithField(telemetry.AgentVersion, agent.Version)), nil
+	})
+}
+
+func agentVersionLabel(version string) string {
+	if _, err := semver.Parse(version); err != nil {
+		return agentVersionOther
+	}
+	return version
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/spire/pkg/common/negotiation"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/pkg/server/api/rpccontext"
+	"github.com/spiffe/spire/test/fakes/fakemetrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestWithFeatureNegotiation(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	metrics := fakemetrics.New()
+	m := WithFeatureNegotiation(metrics)
+
+	// Requests from callers that do not advertise themselves are left alone
+	ctx := rpccontext.WithLogger(context.Background(), log)
+	_, err := m.Preprocess(ctx, "/method", nil)
+	require.NoError(t, err)
+	require.Empty(t, metrics.AllMetrics())
+
+	// The version of unauthenticated agents, e.g. attesting, is only added
+	// to the logger
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("spire-agent-version", "1.5.0"))
+	unauthenticatedCtx, err := m.Preprocess(ctx, "/method", nil)
+	require.NoError(t, err)
+	require.Empty(t, metrics.AllMetrics())
+	rpccontext.Logger(unauthenticatedCtx).Info("Hello")
+	require.Equal(t, "1.5.0", hook.LastEntry().Data[telemetry.AgentVersion])
+
+	// The version of authenticated agents is counted and added to the logger
+	ctx, err = m.Preprocess(rpccontext.WithAgentCaller(ctx), "/method", nil)
+	require.NoError(t, err)
+	require.Equal(t, []fakemetrics.MetricItem{
+		{
+			Type:   fakemetrics.IncrCounterWithLabelsType,
+			Key:    []string{telemetry.Node, telemetry.Version},
+			Val:    1,
+			Labels: []telemetry.Label{{Name: telemetry.AgentVersion, Value: "1_5_0"}},
+		},
+	}, metrics.AllMetrics())
+
+	rpccontext.Logger(ctx).Info("Hello")
+	require.Equal(t, "1.5.0", hook.LastEntry().Data[telemetry.AgentVersion])
+
+	agent, ok := negotiation.AgentFromIncomingContext(ctx)
+	require.True(t, ok)
+	require.Equal(t, "1.5.0", agent.Version)
+
+	// Versions that are not semantic versions are counted as "other"
+	metrics.Reset()
+	ctx = metadata.NewIncomingContext(rpccontext.WithAgentCaller(context.Background()), metadata.Pairs("spire-agent-version", "not-a-version"))
+	_, err = m.Preprocess(rpccontext.WithLogger(ctx, log), "/method", nil)
+	require.NoError(t, err)
+	require.Equal(t, []fakemetrics.MetricItem{
+		{
+			Type:   fakemetrics.IncrCounterWithLabelsType,
+			Key:    []string{telemetry.Node, telemetry.Version},
+			Val:    1,
+			Labels: []telemetry.Label{{Name: telemetry.AgentVersion, Value: "other"}},
+		},
+	}, metrics.AllMetrics())
+}
@@ -0,0 +1,113 @@
+package svid
+
+import (
+	"sync"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// minQuotaGCInterval is the minimum interval at which unused quota
+	// buckets are garbage collected.
+	minQuotaGCInterval = time.Minute
+)
+
+// JWTSVIDQuota is a token bucket quota on the JWT-SVIDs minted for a single
+// entry or agent.
+type JWTSVIDQuota struct {
+	// Rate is the number of JWT-SVIDs per second the bucket is refilled
+	// with. Zero disables the quota.
+	Rate float64
+
+	// Burst is the size of the bucket, i.e. the number of JWT-SVIDs that
+	// can be minted at once. Defaults to the rate, rounded up.
+	Burst int
+}
+
+// quota keeps a token bucket per key. Buckets are created on demand and
+// dropped once they have not been used for a GC interval, which is long
+// enough for them to have been refilled, so dropping them does not reset
+// the quota.
+type quota struct {
+	limit      rate.Limit
+	burst      int
+	gcInterval time.Duration
+	clk        clock.Clock
+
+	mtx sync.Mutex
+
+	// previous holds the buckets that were current at the last GC
+	previous map[string]*rate.Limiter
+
+	// current holds the buckets that have been created or moved from the
+	// previous buckets since the last GC.
+	current map[string]*rate.Limiter
+
+	// lastGC is the last GC
+	lastGC time.Time
+}
+
+// newQuota returns a quota enforcing the given configuration, or nil if the
+// quota is disabled.
+func newQuota(config JWTSVIDQuota, clk clock.Clock) *quota {
+	if config.Rate <= 0 {
+		return nil
+	}
+	burst := config.Burst
+	if burst <= 0 {
+		burst = int(config.Rate)
+		if float64(burst) < config.Rate {
+			burst++
+		}
+	}
+	gcInterval := time.Duration(float64(burst) / config.Rate * float64(time.Second))
+	if gcInterval < minQuotaGCInterval {
+		gcInterval = minQuotaGCInterval
+	}
+	return &quota{
+		limit:      rate.Limit(config.Rate),
+		burst:      burst,
+		gcInterval: gcInterval,
+		clk:        clk,
+		current:    make(map[string]*rate.Limiter),
+		lastGC:     clk.Now(),
+	}
+}
+
+// reserve takes a token from the bucket for the key, returning false if the
+// bucket is empty. The returned function gives the token back, e.g. when
+// another quota rejects the request. A nil quota allows everything.
+func (q *quota) reserve(key string) (func(), bool) {
+	if q == nil {
+		return func() {}, true
+	}
+
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	now := q.clk.Now()
+	limiter, ok := q.current[key]
+	if !ok {
+		limiter, ok = q.previous[key]
+		if ok {
+			delete(q.previous, key)
+		} else {
+			if now.Sub(q.lastGC) >= q.gcInterval {
+				q.previous = q.current
+				q.current = make(map[string]*rate.Limiter)
+				q.lastGC = now
+			}
+			limiter = rate.NewLimiter(q.limit, q.burst)
+		}
+		q.current[key] = limiter
+	}
+
+	reservation := limiter.ReserveN(now, 1)
+	if !reservation.OK() || reservation.DelayFrom(now) > 0 {
+		reservation.CancelAt(now)
+		return nil, false
+	}
+	return func() { reservation.CancelAt(now) }, true
+}
@@ -3,15 +3,18 @@ package svid
 import (
 	"context"
 	"crypto/x509"
+	"errors"
 	"strings"
 	"time"
 
+	"github.com/andres-erbsen/clock"
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	svidv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/svid/v1"
 	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"github.com/spiffe/spire/pkg/common/jwtsvid"
 	"github.com/spiffe/spire/pkg/common/telemetry"
+	telemetry_server "github.com/spiffe/spire/pkg/common/telemetry/server"
 	"github.com/spiffe/spire/pkg/common/x509util"
 	"github.com/spiffe/spire/pkg/server/api"
 	"github.com/spiffe/spire/pkg/server/api/rpccontext"
@@ -33,15 +36,42 @@ type Config struct {
 	ServerCA     ca.ServerCA
 	TrustDomain  spiffeid.TrustDomain
 	DataStore    datastore.DataStore
+	Metrics      telemetry.Metrics
+
+	// KeyPolicy restricts the keys X509-SVIDs and downstream CAs can be
+	// signed for. Any key is allowed by default.
+	KeyPolicy api.KeyPolicy
+
+	// JWTSVIDQuotaPerEntry bounds the rate JWT-SVIDs are minted for each
+	// entry through NewJWTSVID. Disabled by default.
+	JWTSVIDQuotaPerEntry JWTSVIDQuota
+
+	// JWTSVIDQuotaPerAgent bounds the rate JWT-SVIDs are minted for each
+	// agent through NewJWTSVID. Disabled by default.
+	JWTSVIDQuotaPerAgent JWTSVIDQuota
+
+	// Clock is used to refill the JWT-SVID quotas. Defaults to the real
+	// clock.
+	Clock clock.Clock
 }
 
 // New creates a new SVID service
 func New(config Config) *Service {
+	if config.Clock == nil {
+		config.Clock = clock.New()
+	}
+	if config.Metrics == nil {
+		config.Metrics = telemetry.Blackhole{}
+	}
 	return &Service{
-		ca: config.ServerCA,
-		ef: config.EntryFetcher,
-		td: config.TrustDomain,
-		ds: config.DataStore,
+		ca:            config.ServerCA,
+		ef:            config.EntryFetcher,
+		td:            config.TrustDomain,
+		ds:            config.DataStore,
+		metrics:       config.Metrics,
+		keyPolicy:     config.KeyPolicy,
+		entryJWTQuota: newQuota(config.JWTSVIDQuotaPerEntry, config.Clock),
+		agentJWTQuota: newQuota(config.JWTSVIDQuotaPerAgent, config.Clock),
 	}
 }
 
@@ -53,6 +83,12 @@ type Service struct {
 	ef api.AuthorizedEntryFetcher
 	td spiffeid.TrustDomain
 	ds datastore.DataStore
+
+	metrics   telemetry.Metrics
+	keyPolicy api.KeyPolicy
+
+	entryJWTQuota *quota
+	agentJWTQuota *quota
 }
 
 func (s *Service) MintX509SVID(ctx context.Context, req *svidv1.MintX509SVIDRequest) (*svidv1.MintX509SVIDResponse, error) {
@@ -75,6 +111,10 @@ func (s *Service) MintX509SVID(ctx context.Context, req *svidv1.MintX509SVIDRequ
 		return nil, api.MakeErr(log, codes.InvalidArgument, "failed to verify CSR signature", err)
 	}
 
+	if err := s.checkCSRKey(csr); err != nil {
+		return nil, api.MakeErr(log, codes.InvalidArgument, "CSR key rejected by policy", err)
+	}
+
 	switch {
 	case len(csr.URIs) == 0:
 		return nil, api.MakeErr(log, codes.InvalidArgument, "CSR URI SAN is required", nil)
@@ -241,6 +281,12 @@ func (s *Service) newX509SVID(ctx context.Context, param *svidv1.NewX509SVIDPara
 		}
 	}
 
+	if err := s.checkCSRKey(csr); err != nil {
+		return &svidv1.BatchNewX509SVIDResponse_Result{
+			Status: api.MakeStatus(log, codes.InvalidArgument, "CSR key rejected by policy", err),
+		}
+	}
+
 	spiffeID, err := api.TrustDomainMemberIDFromProto(ctx, s.td, entry.SpiffeId)
 	if err != nil {
 		// This shouldn't be the case unless there is invalid data in the datastore
@@ -250,11 +296,21 @@ func (s *Service) newX509SVID(ctx context.Context, param *svidv1.NewX509SVIDPara
 	}
 	log = log.WithField(telemetry.SPIFFEID, spiffeID.String())
 
+	// SVIDs requested by an agent are signed by the X509 CA selected for
+	// that agent, so that all of its workloads move together during a staged
+	// rollout of a new X509 CA.
+	var agentID spiffeid.ID
+	if rpccontext.CallerIsAgent(ctx) {
+		agentID, _ = rpccontext.CallerID(ctx)
+	}
+
 	x509Svid, err := s.ca.SignX509SVID(ctx, ca.X509SVIDParams{
 		SpiffeID:  spiffeID,
 		PublicKey: csr.PublicKey,
 		DNSList:   entry.DnsNames,
 		TTL:       time.Duration(entry.Ttl) * time.Second,
+		EntryID:   entry.Id,
+		AgentID:   agentID,
 	})
 	if err != nil {
 		return &svidv1.BatchNewX509SVIDResponse_Result{
@@ -289,10 +345,18 @@ func (s *Service) mintJWTSVID(ctx context.Context, protoID *types.SPIFFEID, audi
 		return nil, api.MakeErr(log, codes.InvalidArgument, "at least one audience is required", nil)
 	}
 
+	// Like X509-SVIDs, JWT-SVIDs requested by an agent are signed by the JWT
+	// key selected for that agent during a staged rollout.
+	var agentID spiffeid.ID
+	if rpccontext.CallerIsAgent(ctx) {
+		agentID, _ = rpccontext.CallerID(ctx)
+	}
+
 	token, err := s.ca.SignJWTSVID(ctx, ca.JWTSVIDParams{
 		SpiffeID: id,
 		TTL:      time.Duration(ttl) * time.Second,
 		Audience: audience,
+		AgentID:  agentID,
 	})
 	if err != nil {
 		return nil, api.MakeErr(log, codes.Internal, "failed to sign JWT-SVID", err)
@@ -338,6 +402,19 @@ func (s *Service) NewJWTSVID(ctx context.Context, req *svidv1.NewJWTSVIDRequest)
 		return nil, api.MakeErr(log, codes.NotFound, "entry not found or not authorized", nil)
 	}
 
+	// Both quotas must allow the request for either to be consumed
+	releaseAgentQuota := func() {}
+	if callerID, ok := rpccontext.CallerID(ctx); ok {
+		releaseAgentQuota, ok = s.agentJWTQuota.reserve(callerID.String())
+		if !ok {
+			return nil, api.MakeErr(log, codes.ResourceExhausted, "JWT-SVID minting quota exceeded for agent", nil)
+		}
+	}
+	if _, ok := s.entryJWTQuota.reserve(entry.Id); !ok {
+		releaseAgentQuota()
+		return nil, api.MakeErr(log, codes.ResourceExhausted, "JWT-SVID minting quota exceeded for entry", nil)
+	}
+
 	jwtsvid, err := s.mintJWTSVID(ctx, entry.SpiffeId, req.Audience, entry.Ttl)
 	if err != nil {
 		return nil, err
@@ -374,12 +451,19 @@ func (s *Service) NewDownstreamX509CA(ctx context.Context, req *svidv1.NewDownst
 		return nil, err
 	}
 
+	if err := s.checkCSRKey(csr); err != nil {
+		return nil, api.MakeErr(log, codes.InvalidArgument, "CSR key rejected by policy", err)
+	}
+
 	x509CASvid, err := s.ca.SignX509CASVID(ctx, ca.X509CASVIDParams{
 		SpiffeID:  s.td.ID(),
 		PublicKey: csr.PublicKey,
 		TTL:       time.Duration(entry.Ttl) * time.Second,
 	})
-	if err != nil {
+	switch {
+	case errors.Is(err, ca.ErrMaxDownstreamDepthExceeded):
+		return nil, api.MakeErr(log, codes.FailedPrecondition, "refusing to sign downstream X.509 CA", err)
+	case err != nil:
 		return nil, api.MakeErr(log, codes.Internal, "failed to sign downstream X.509 CA", err)
 	}
 
@@ -430,6 +514,16 @@ func (s Service) fieldsFromJWTSvidParams(ctx context.Context, protoID *types.SPI
 	return fields
 }
 
+// checkCSRKey checks the key of the CSR against the key policy, counting
+// rejections.
+func (s *Service) checkCSRKey(csr *x509.CertificateRequest) error {
+	if err := s.keyPolicy.CheckKey(csr.PublicKey); err != nil {
+		telemetry_server.IncrCSRKeyRejectedCounter(s.metrics, api.KeyDescription(csr.PublicKey))
+		return err
+	}
+	return nil
+}
+
 func parseAndCheckCSR(ctx context.Context, csrBytes []byte) (*x509.CertificateRequest, error) {
 	log := rpccontext.Logger(ctx)
 
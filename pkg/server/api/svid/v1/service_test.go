@@ -2,6 +2,7 @@ package svid_test
 
 import (
 	"context"
+	"crypto"
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
@@ -20,6 +21,7 @@ import (
 	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"github.com/spiffe/spire/pkg/common/idutil"
 	"github.com/spiffe/spire/pkg/common/telemetry"
+	telemetry_server "github.com/spiffe/spire/pkg/common/telemetry/server"
 	"github.com/spiffe/spire/pkg/common/x509svid"
 	"github.com/spiffe/spire/pkg/common/x509util"
 	"github.com/spiffe/spire/pkg/server/api"
@@ -28,7 +30,9 @@ import (
 	svid "github.com/spiffe/spire/pkg/server/api/svid/v1"
 	"github.com/spiffe/spire/pkg/server/datastore"
 	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/spiffe/spire/test/clock"
 	"github.com/spiffe/spire/test/fakes/fakedatastore"
+	"github.com/spiffe/spire/test/fakes/fakemetrics"
 	"github.com/spiffe/spire/test/fakes/fakeserverca"
 	"github.com/spiffe/spire/test/spiretest"
 	"github.com/spiffe/spire/test/testkey"
@@ -585,6 +589,38 @@ func TestServiceMintX509SVID(t *testing.T) {
 	}
 }
 
+func TestServiceMintX509SVIDKeyPolicy(t *testing.T) {
+	metrics := fakemetrics.New()
+	test := setupServiceTest(t, func(c *svid.Config) {
+		c.Metrics = metrics
+		c.KeyPolicy = api.KeyPolicy{
+			AllowedKeyTypes: []string{api.KeyTypeECDSA},
+			AllowedCurves:   []string{"P-384"},
+		}
+	})
+	defer test.Cleanup()
+
+	mintX509SVID := func(key crypto.Signer) error {
+		csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+			URIs: []*url.URL{workloadID.URL()},
+		}, key)
+		require.NoError(t, err)
+		_, err = test.client.MintX509SVID(context.Background(), &svidv1.MintX509SVIDRequest{
+			Csr: csr,
+		})
+		return err
+	}
+
+	require.NoError(t, mintX509SVID(testkey.NewEC384(t)))
+	spiretest.RequireGRPCStatus(t, mintX509SVID(testkey.NewEC256(t)), codes.InvalidArgument, "CSR key rejected by policy: ECDSA curve P-256 is not allowed")
+	spiretest.RequireGRPCStatus(t, mintX509SVID(testkey.NewRSA2048(t)), codes.InvalidArgument, "CSR key rejected by policy: rsa keys are not allowed")
+
+	expectedMetrics := fakemetrics.New()
+	telemetry_server.IncrCSRKeyRejectedCounter(expectedMetrics, "ecdsa-P-256")
+	telemetry_server.IncrCSRKeyRejectedCounter(expectedMetrics, "rsa-2048")
+	require.Equal(t, expectedMetrics.AllMetrics(), metrics.AllMetrics())
+}
+
 func TestServiceMintJWTSVID(t *testing.T) {
 	test := setupServiceTest(t)
 	defer test.Cleanup()
@@ -1107,6 +1143,56 @@ func TestServiceNewJWTSVID(t *testing.T) {
 	}
 }
 
+func TestServiceNewJWTSVIDQuota(t *testing.T) {
+	clk := clock.NewMock(t)
+	test := setupServiceTest(t, func(c *svid.Config) {
+		c.JWTSVIDQuotaPerEntry = svid.JWTSVIDQuota{Rate: 1, Burst: 2}
+		c.JWTSVIDQuotaPerAgent = svid.JWTSVIDQuota{Rate: 1, Burst: 3}
+		c.Clock = clk
+	})
+	defer test.Cleanup()
+
+	test.ef.entries = []*types.Entry{
+		{
+			Id:       "entry-1",
+			ParentId: api.ProtoFromID(agentID),
+			SpiffeId: &types.SPIFFEID{TrustDomain: "example.org", Path: "/workload1"},
+		},
+		{
+			Id:       "entry-2",
+			ParentId: api.ProtoFromID(agentID),
+			SpiffeId: &types.SPIFFEID{TrustDomain: "example.org", Path: "/workload2"},
+		},
+	}
+	test.rateLimiter.count = 1
+	test.withCallerID = true
+
+	newJWTSVID := func(entryID string) error {
+		_, err := test.client.NewJWTSVID(context.Background(), &svidv1.NewJWTSVIDRequest{
+			EntryId:  entryID,
+			Audience: []string{"AUDIENCE"},
+		})
+		return err
+	}
+
+	// The entry quota is exhausted first
+	require.NoError(t, newJWTSVID("entry-1"))
+	require.NoError(t, newJWTSVID("entry-1"))
+	spiretest.RequireGRPCStatus(t, newJWTSVID("entry-1"), codes.ResourceExhausted, "JWT-SVID minting quota exceeded for entry")
+
+	// Then the agent quota, shared by the entries of the agent. The request
+	// rejected above did not take a token from the agent bucket.
+	require.NoError(t, newJWTSVID("entry-2"))
+	spiretest.RequireGRPCStatus(t, newJWTSVID("entry-2"), codes.ResourceExhausted, "JWT-SVID minting quota exceeded for agent")
+
+	// The buckets are refilled over time
+	clk.Add(time.Second)
+	require.NoError(t, newJWTSVID("entry-2"))
+	spiretest.RequireGRPCStatus(t, newJWTSVID("entry-1"), codes.ResourceExhausted, "JWT-SVID minting quota exceeded for agent")
+	clk.Add(time.Second)
+	require.NoError(t, newJWTSVID("entry-1"))
+}
+
 func TestServiceBatchNewX509SVID(t *testing.T) {
 	test := setupServiceTest(t)
 	defer test.Cleanup()
@@ -2021,7 +2107,7 @@ func (c *serviceTest) Cleanup() {
 	c.done()
 }
 
-func setupServiceTest(t *testing.T) *serviceTest {
+func setupServiceTest(t *testing.T, configure ...func(*svid.Config)) *serviceTest {
 	trustDomain := spiffeid.RequireTrustDomainFromString("example.org")
 	ca := fakeserverca.New(t, trustDomain, &fakeserverca.Options{})
 	ef := &entryFetcher{}
@@ -2029,12 +2115,16 @@ func setupServiceTest(t *testing.T) *serviceTest {
 	ds := fakedatastore.New(t)
 
 	rateLimiter := &fakeRateLimiter{}
-	service := svid.New(svid.Config{
+	config := svid.Config{
 		EntryFetcher: ef,
 		ServerCA:     ca,
 		TrustDomain:  trustDomain,
 		DataStore:    ds,
-	})
+	}
+	for _, fn := range configure {
+		fn(&config)
+	}
+	service := svid.New(config)
 
 	log, logHook := test.NewNullLogger()
 	registerFn := func(s *grpc.Server) {
@@ -1,18 +1,35 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"crypto/x509"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"time"
 
 	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
 	"github.com/spiffe/spire/pkg/common/bundleutil"
 	"github.com/zeebo/errs"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+const (
+	// BundleSignatureHeader is the response header carrying the detached
+	// JWS (RFC 7515, Appendix F) over the bundle served by the endpoint.
+	BundleSignatureHeader = "Spiffe-Bundle-Signature"
+
+	// maxBundleSize limits how much of the response is read to verify the
+	// signature of the bundle.
+	maxBundleSize = 4 << 20
 )
 
 type SPIFFEAuthConfig struct {
@@ -37,6 +54,21 @@ type ClientConfig struct { //revive:disable-line:exported name stutter is intent
 	// is authenticated via Web PKI.
 	SPIFFEAuth *SPIFFEAuthConfig
 
+	// ProxyURL is the URL of the HTTP proxy used to reach the endpoint. If
+	// unset, the proxy is taken from the HTTPS_PROXY and NO_PROXY environment
+	// variables.
+	ProxyURL *url.URL
+
+	// DialTimeout is the maximum amount of time a dial to the endpoint (or
+	// proxy) will wait for a connect to complete. If unset, the default
+	// timeout of the HTTP transport is used.
+	DialTimeout time.Duration
+
+	// SignatureKeys, if set, are the keys pinned to verify the detached
+	// signature over the bundle, independently of the TLS channel. Bundles
+	// that are not signed by one of them are rejected.
+	SignatureKeys *jose.JSONWebKeySet
+
 	// mutateTransportHook is a hook to influence the transport used during
 	// tests.
 	mutateTransportHook func(*http.Transport)
@@ -59,6 +91,9 @@ func NewClient(config ClientConfig) (Client, error) {
 		if endpointID.IsZero() {
 			return nil, fmt.Errorf("no SPIFFE ID specified for federation with %q", config.TrustDomain.String())
 		}
+		if len(config.SPIFFEAuth.RootCAs) == 0 {
+			return nil, fmt.Errorf("no root CAs available to authenticate %q for federation with %q", endpointID, config.TrustDomain.String())
+		}
 
 		bundle := x509bundle.FromX509Authorities(endpointID.TrustDomain(), config.SPIFFEAuth.RootCAs)
 
@@ -66,6 +101,15 @@ func NewClient(config ClientConfig) (Client, error) {
 
 		transport.TLSClientConfig = tlsconfig.TLSClientConfig(bundle, authorizer)
 	}
+	if config.ProxyURL != nil {
+		transport.Proxy = http.ProxyURL(config.ProxyURL)
+	}
+	if config.DialTimeout > 0 {
+		transport.DialContext = (&net.Dialer{
+			Timeout:   config.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
 	if config.mutateTransportHook != nil {
 		config.mutateTransportHook(transport)
 	}
@@ -92,7 +136,19 @@ func (c *client) FetchBundle(ctx context.Context) (*bundleutil.Bundle, error) {
 		return nil, errs.New("unexpected status %d fetching bundle: %s", resp.StatusCode, tryRead(resp.Body))
 	}
 
-	b, err := bundleutil.Decode(c.c.TrustDomain, resp.Body)
+	if c.c.SignatureKeys == nil {
+		return bundleutil.Decode(c.c.TrustDomain, resp.Body)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBundleSize))
+	if err != nil {
+		return nil, errs.New("failed to read bundle: %v", err)
+	}
+	if err := verifyBundleSignature(c.c.SignatureKeys, resp.Header.Get(BundleSignatureHeader), body); err != nil {
+		return nil, err
+	}
+
+	b, err := bundleutil.Decode(c.c.TrustDomain, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
@@ -100,6 +156,61 @@ func (c *client) FetchBundle(ctx context.Context) (*bundleutil.Bundle, error) {
 	return b, nil
 }
 
+// LoadSignatureKeys loads the JWK Set with the keys pinned to verify the
+// signature over the bundle of a federated trust domain.
+func LoadSignatureKeys(path string) (*jose.JSONWebKeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle signature keys: %w", err)
+	}
+	keys := new(jose.JSONWebKeySet)
+	if err := json.Unmarshal(data, keys); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle signature keys: %w", err)
+	}
+	if len(keys.Keys) == 0 {
+		return nil, fmt.Errorf("no bundle signature keys found in %q", path)
+	}
+	for i, key := range keys.Keys {
+		// Only the public part is needed to verify signatures
+		public := key.Public()
+		if !public.Valid() {
+			return nil, fmt.Errorf("bundle signature key %d in %q is not a valid asymmetric key", i, path)
+		}
+		keys.Keys[i] = public
+	}
+	return keys, nil
+}
+
+// verifyBundleSignature verifies that the detached JWS is a signature over
+// the bundle by one of the pinned keys. If the JWS names a key, only that key
+// is tried.
+func verifyBundleSignature(keys *jose.JSONWebKeySet, signature string, bundle []byte) error {
+	if signature == "" {
+		return errs.New("bundle is not signed: missing %s header", BundleSignatureHeader)
+	}
+	jws, err := jose.ParseDetached(signature, bundle)
+	if err != nil {
+		return errs.New("failed to parse bundle signature: %v", err)
+	}
+	if len(jws.Signatures) != 1 {
+		return errs.New("expected one bundle signature; got %d", len(jws.Signatures))
+	}
+
+	candidates := keys.Keys
+	if kid := jws.Signatures[0].Header.KeyID; kid != "" {
+		candidates = keys.Key(kid)
+		if len(candidates) == 0 {
+			return errs.New("bundle is signed with key %q, which is not pinned", kid)
+		}
+	}
+	for _, key := range candidates {
+		if err := jws.DetachedVerify(bundle, key.Key); err == nil {
+			return nil
+		}
+	}
+	return errs.New("bundle signature is not valid for any pinned key")
+}
+
 func tryRead(r io.Reader) string {
 	b := make([]byte, 1024)
 	n, _ := r.Read(b)
@@ -5,17 +5,22 @@ import (
 	"crypto"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/spiffe/spire/test/spiretest"
+	"github.com/spiffe/spire/test/testkey"
 	"github.com/stretchr/testify/require"
+	jose "gopkg.in/square/go-jose.v2"
 )
 
 var (
@@ -52,6 +57,17 @@ func TestClient(t *testing.T) {
 			serverID:     serverID,
 			newClientErr: `no SPIFFE ID specified for federation with "domain.test"`,
 		},
+		{
+			name:         "no root CAs",
+			status:       http.StatusOK,
+			body:         `{"spiffe_refresh_hint": 10}`,
+			serverID:     serverID,
+			expectedID:   serverID,
+			newClientErr: `no root CAs available to authenticate "spiffe://domain.test/spiffe-bundle-endpoint-server" for federation with "domain.test"`,
+			mutateConfig: func(c *ClientConfig) {
+				c.SPIFFEAuth.RootCAs = nil
+			},
+		},
 		{
 			name:           "SPIFFE ID override",
 			serverID:       spiffeid.RequireFromString("spiffe://domain.test/my-spiffe-bundle-endpoint-server"),
@@ -154,6 +170,202 @@ func TestClient(t *testing.T) {
 	}
 }
 
+func TestClientUsesProxy(t *testing.T) {
+	serverCert, _ := createServerCertificate(t, serverID)
+
+	connectCh := make(chan string, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodConnect {
+			select {
+			case connectCh <- req.Host:
+			default:
+			}
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	client, err := NewClient(ClientConfig{
+		TrustDomain: trustDomain,
+		EndpointURL: "https://bundle-endpoint.test:8443",
+		SPIFFEAuth: &SPIFFEAuthConfig{
+			EndpointSpiffeID: serverID,
+			RootCAs:          []*x509.Certificate{serverCert},
+		},
+		ProxyURL:    proxyURL,
+		DialTimeout: time.Second,
+	})
+	require.NoError(t, err)
+
+	_, err = client.FetchBundle(context.Background())
+	require.Error(t, err)
+
+	select {
+	case host := <-connectCh:
+		require.Equal(t, "bundle-endpoint.test:8443", host)
+	default:
+		require.FailNow(t, "proxy was not used to reach the bundle endpoint")
+	}
+}
+
+func TestClientVerifiesBundleSignature(t *testing.T) {
+	const body = `{"spiffe_refresh_hint": 10}`
+	signerKey := testkey.NewEC256(t)
+	otherKey := testkey.NewEC384(t)
+
+	pinned := &jose.JSONWebKeySet{
+		Keys: []jose.JSONWebKey{
+			{Key: otherKey.Public(), KeyID: "other"},
+			{Key: signerKey.Public(), KeyID: "signer"},
+		},
+	}
+
+	sign := func(key interface{}, alg jose.SignatureAlgorithm, kid string, payload string) string {
+		opts := new(jose.SignerOptions)
+		if kid != "" {
+			opts = opts.WithHeader("kid", kid)
+		}
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, opts)
+		require.NoError(t, err)
+		jws, err := signer.Sign([]byte(payload))
+		require.NoError(t, err)
+		signature, err := jws.DetachedCompactSerialize()
+		require.NoError(t, err)
+		return signature
+	}
+
+	for _, tt := range []struct {
+		name      string
+		keys      *jose.JSONWebKeySet
+		signature string
+		expectErr string
+	}{
+		{
+			name:      "signature is not required",
+			signature: "",
+		},
+		{
+			name:      "signed by a pinned key",
+			keys:      pinned,
+			signature: sign(signerKey, jose.ES256, "signer", body),
+		},
+		{
+			name:      "signed by a pinned key without key ID",
+			keys:      pinned,
+			signature: sign(signerKey, jose.ES256, "", body),
+		},
+		{
+			name:      "not signed",
+			keys:      pinned,
+			signature: "",
+			expectErr: "bundle is not signed: missing Spiffe-Bundle-Signature header",
+		},
+		{
+			name:      "malformed signature",
+			keys:      pinned,
+			signature: "not-a-jws",
+			expectErr: "failed to parse bundle signature",
+		},
+		{
+			name:      "signed by a key that is not pinned",
+			keys:      pinned,
+			signature: sign(testkey.NewEC256(t), jose.ES256, "unknown", body),
+			expectErr: `bundle is signed with key "unknown", which is not pinned`,
+		},
+		{
+			name:      "signed by another pinned key than the one named",
+			keys:      pinned,
+			signature: sign(signerKey, jose.ES256, "other", body),
+			expectErr: "bundle signature is not valid for any pinned key",
+		},
+		{
+			name:      "signature over another bundle",
+			keys:      pinned,
+			signature: sign(signerKey, jose.ES256, "signer", `{"spiffe_refresh_hint": 20}`),
+			expectErr: "bundle signature is not valid for any pinned key",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			serverCert, serverKey := createServerCertificate(t, serverID)
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if tt.signature != "" {
+					w.Header().Set(BundleSignatureHeader, tt.signature)
+				}
+				_, _ = w.Write([]byte(body))
+			}))
+			server.TLS = &tls.Config{
+				Certificates: []tls.Certificate{
+					{
+						Certificate: [][]byte{serverCert.Raw},
+						PrivateKey:  serverKey,
+					},
+				},
+				MinVersion: tls.VersionTLS12,
+			}
+			server.StartTLS()
+			defer server.Close()
+
+			client, err := NewClient(ClientConfig{
+				TrustDomain: trustDomain,
+				EndpointURL: server.URL,
+				SPIFFEAuth: &SPIFFEAuthConfig{
+					EndpointSpiffeID: serverID,
+					RootCAs:          []*x509.Certificate{serverCert},
+				},
+				SignatureKeys: tt.keys,
+			})
+			require.NoError(t, err)
+
+			bundle, err := client.FetchBundle(context.Background())
+			if tt.expectErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, 10*time.Second, bundle.RefreshHint())
+		})
+	}
+}
+
+func TestLoadSignatureKeys(t *testing.T) {
+	dir := spiretest.TempDir(t)
+	writeKeys := func(name, keys string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(keys), 0600))
+		return path
+	}
+
+	key := testkey.NewEC256(t)
+	privateKeys, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key, KeyID: "signer"}}})
+	require.NoError(t, err)
+
+	// Only the public part of the keys is kept
+	keys, err := LoadSignatureKeys(writeKeys("private.jwks", string(privateKeys)))
+	require.NoError(t, err)
+	require.Len(t, keys.Keys, 1)
+	require.Equal(t, "signer", keys.Keys[0].KeyID)
+	require.Equal(t, key.Public(), keys.Keys[0].Key)
+
+	_, err = LoadSignatureKeys(filepath.Join(dir, "missing.jwks"))
+	require.ErrorContains(t, err, "failed to read bundle signature keys")
+
+	_, err = LoadSignatureKeys(writeKeys("invalid.jwks", "{"))
+	require.ErrorContains(t, err, "failed to parse bundle signature keys")
+
+	path := writeKeys("empty.jwks", `{"keys":[]}`)
+	_, err = LoadSignatureKeys(path)
+	require.EqualError(t, err, fmt.Sprintf("no bundle signature keys found in %q", path))
+
+	path = writeKeys("symmetric.jwks", `{"keys":[{"kty":"oct","k":"c2VjcmV0"}]}`)
+	_, err = LoadSignatureKeys(path)
+	require.EqualError(t, err, fmt.Sprintf("bundle signature key 0 in %q is not a valid asymmetric key", path))
+}
+
 func createServerCertificate(t *testing.T, serverID spiffeid.ID) (*x509.Certificate, crypto.Signer) {
 	return spiretest.SelfSignCertificate(t, &x509.Certificate{
 		SerialNumber: big.NewInt(0),
@@ -35,6 +35,19 @@ type TrustDomainConfig struct {
 	// EndpointProfile is the bundle endpoint profile used by the
 	// SPIFFE bundle endpoint server.
 	EndpointProfile EndpointProfileInfo
+
+	// ProxyURL is the URL of the HTTP proxy used to reach the endpoint. If
+	// empty, the proxy is taken from the environment.
+	ProxyURL string
+
+	// DialTimeout is the maximum amount of time to wait for a connection to
+	// the endpoint to be established. If zero, the default is used.
+	DialTimeout time.Duration
+
+	// SignatureKeysPath is the path to a JWK Set with the keys pinned to
+	// verify the signature over the bundle. If empty, the bundle does not
+	// need to be signed.
+	SignatureKeysPath string
 }
 
 type EndpointProfileInfo interface {
@@ -87,6 +100,11 @@ type Manager struct {
 	updatersMtx      sync.RWMutex
 	updaters         map[spiffeid.TrustDomain]*managedBundleUpdater
 
+	// refreshes holds when the bundle of each managed trust domain was last
+	// refreshed successfully, whether it changed or not
+	refreshesMtx sync.Mutex
+	refreshes    map[spiffeid.TrustDomain]time.Time
+
 	// test hooks
 	newBundleUpdater  func(BundleUpdaterConfig) BundleUpdater
 	configRefreshedCh chan time.Duration
@@ -125,6 +143,7 @@ func NewManager(config ManagerConfig) *Manager {
 		configRefreshedCh: config.configRefreshedCh,
 		bundleRefreshedCh: config.bundleRefreshedCh,
 		updaters:          make(map[spiffeid.TrustDomain]*managedBundleUpdater),
+		refreshes:         make(map[spiffeid.TrustDomain]time.Time),
 	}
 }
 
@@ -175,9 +194,29 @@ func (m *Manager) RefreshBundleFor(ctx context.Context, td spiffeid.TrustDomain)
 	}
 
 	_, _, err := updater.UpdateBundle(ctx)
+	if err == nil {
+		m.bundleRefreshed(td)
+	}
 	return true, err
 }
 
+// LastBundleRefresh returns when the bundle of the given trust domain was
+// last fetched successfully from its bundle endpoint, whether it changed or
+// not. It returns false if the trust domain is not managed by the manager or
+// its bundle was not fetched yet.
+func (m *Manager) LastBundleRefresh(td spiffeid.TrustDomain) (time.Time, bool) {
+	m.refreshesMtx.Lock()
+	defer m.refreshesMtx.Unlock()
+	refreshedAt, ok := m.refreshes[td]
+	return refreshedAt, ok
+}
+
+func (m *Manager) bundleRefreshed(td spiffeid.TrustDomain) {
+	m.refreshesMtx.Lock()
+	defer m.refreshesMtx.Unlock()
+	m.refreshes[td] = m.clock.Now()
+}
+
 func (m *Manager) refreshConfigs(ctx context.Context) error {
 	m.configRefreshMtx.Lock()
 	defer m.configRefreshMtx.Unlock()
@@ -222,7 +261,15 @@ func (m *Manager) refreshConfigs(ctx context.Context) error {
 			// Updater no longer needed. Stage it to be stopped and remove it
 			// from the updaters list.
 			tdLog.Info("Trust domain no longer managed")
-			toStop = append(toStop, updater.Stop)
+			td, updater := td, updater
+			toStop = append(toStop, func() {
+				updater.Stop()
+				// Forget the last refresh once the updater can no
+				// longer record one
+				m.refreshesMtx.Lock()
+				delete(m.refreshes, td)
+				m.refreshesMtx.Unlock()
+			})
 			delete(m.updaters, td)
 		}
 	}
@@ -268,6 +315,8 @@ func (m *Manager) runUpdater(ctx context.Context, trustDomain spiffeid.TrustDoma
 		localBundle, endpointBundle, err := updater.UpdateBundle(ctx)
 		if err != nil {
 			log.WithError(err).Error("Error updating bundle")
+		} else {
+			m.bundleRefreshed(trustDomain)
 		}
 
 		switch {
@@ -115,6 +115,52 @@ func TestManagerOnDemandBundleRefresh(t *testing.T) {
 	assert.Equal(t, 1, test.UpdateCount(trustDomain))
 }
 
+func TestManagerLastBundleRefresh(t *testing.T) {
+	localBundle := bundleutil.BundleFromRootCA(trustDomain, createCACertificate(t, "local"))
+	source := TrustDomainConfigMap{
+		trustDomain: TrustDomainConfig{
+			EndpointURL:     "https://example.org/bundle",
+			EndpointProfile: HTTPSWebProfile{},
+		},
+	}
+	test := newManagerTest(t, source,
+		func(spiffeid.TrustDomain) *bundleutil.Bundle {
+			return localBundle
+		},
+		nil,
+	)
+	nextRefresh := calculateNextUpdate(localBundle)
+
+	test.WaitForConfigRefresh()
+	test.WaitForBundleRefresh(nextRefresh)
+
+	// Failed refreshes are not recorded
+	_, ok := test.manager.LastBundleRefresh(trustDomain)
+	require.False(t, ok)
+
+	// Refreshes that do not change the bundle are recorded
+	updater, ok := test.bundleUpdaterFor(trustDomain)
+	require.True(t, ok)
+	updater.SetUpdateError(nil)
+	test.AdvanceTime(nextRefresh + time.Millisecond)
+	test.WaitForBundleRefresh(nextRefresh)
+
+	// The mock clock fires the timer at its deadline, before the extra
+	// millisecond is added
+	refreshedAt, ok := test.manager.LastBundleRefresh(trustDomain)
+	require.True(t, ok)
+	require.Equal(t, test.clock.Now().Add(-time.Millisecond), refreshedAt)
+
+	// The last refresh is kept when a later refresh fails
+	updater.SetUpdateError(errors.New("OHNO"))
+	test.AdvanceTime(nextRefresh + time.Millisecond)
+	test.WaitForBundleRefresh(nextRefresh)
+
+	lastRefreshedAt, ok := test.manager.LastBundleRefresh(trustDomain)
+	require.True(t, ok)
+	require.Equal(t, refreshedAt, lastRefreshedAt)
+}
+
 func TestManagerConfigPeriodicRefresh(t *testing.T) {
 	td1 := spiffeid.RequireTrustDomainFromString("domain1.test")
 	td2 := spiffeid.RequireTrustDomainFromString("domain2.test")
@@ -355,16 +401,24 @@ type fakeBundleUpdater struct {
 	mtx            sync.Mutex
 	localBundle    *bundleutil.Bundle
 	endpointBundle *bundleutil.Bundle
+	updateErr      error
 	updateCount    int
 	config         BundleUpdaterConfig
 }
 
 func newFakeBundleUpdater(config BundleUpdaterConfig) *fakeBundleUpdater {
 	return &fakeBundleUpdater{
-		config: config,
+		config:    config,
+		updateErr: errors.New("OHNO"),
 	}
 }
 
+func (u *fakeBundleUpdater) SetUpdateError(err error) {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	u.updateErr = err
+}
+
 func (u *fakeBundleUpdater) SetBundles(localBundle, endpointBundle *bundleutil.Bundle) {
 	u.mtx.Lock()
 	defer u.mtx.Unlock()
@@ -382,7 +436,7 @@ func (u *fakeBundleUpdater) UpdateBundle(context.Context) (*bundleutil.Bundle, *
 	u.mtx.Lock()
 	defer u.mtx.Unlock()
 	u.updateCount++
-	return u.localBundle, u.endpointBundle, errors.New("OHNO")
+	return u.localBundle, u.endpointBundle, u.updateErr
 }
 
 func (u *fakeBundleUpdater) GetTrustDomainConfig() TrustDomainConfig {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"sync"
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
@@ -112,6 +113,23 @@ func (u *bundleUpdater) newClient(ctx context.Context, trustDomainConfig TrustDo
 	clientConfig := ClientConfig{
 		TrustDomain: u.td,
 		EndpointURL: trustDomainConfig.EndpointURL,
+		DialTimeout: trustDomainConfig.DialTimeout,
+	}
+
+	if trustDomainConfig.ProxyURL != "" {
+		proxyURL, err := url.Parse(trustDomainConfig.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
+		}
+		clientConfig.ProxyURL = proxyURL
+	}
+
+	if trustDomainConfig.SignatureKeysPath != "" {
+		keys, err := LoadSignatureKeys(trustDomainConfig.SignatureKeysPath)
+		if err != nil {
+			return nil, err
+		}
+		clientConfig.SignatureKeys = keys
 	}
 
 	if spiffeAuth, ok := trustDomainConfig.EndpointProfile.(HTTPSSPIFFEProfile); ok {
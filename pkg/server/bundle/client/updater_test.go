@@ -132,6 +132,103 @@ func TestBundleUpdaterUpdateBundle(t *testing.T) {
 	}
 }
 
+func TestBundleUpdaterClientConfig(t *testing.T) {
+	ds := fakedatastore.New(t)
+	_, err := ds.CreateBundle(context.Background(), bundleutil.BundleFromRootCA(trustDomain, createCACertificate(t, "bundle")).Proto())
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name     string
+		proxyURL string
+		err      string
+	}{
+		{
+			name:     "with proxy",
+			proxyURL: "http://proxy.test:3128",
+		},
+		{
+			name: "without proxy",
+		},
+		{
+			name:     "invalid proxy URL",
+			proxyURL: "http://proxy.test:port",
+			err:      "failed to parse proxy URL",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var clientConfig ClientConfig
+			updater := NewBundleUpdater(BundleUpdaterConfig{
+				DataStore:   ds,
+				TrustDomain: trustDomain,
+				TrustDomainConfig: TrustDomainConfig{
+					EndpointURL: "ENDPOINT_ADDRESS",
+					EndpointProfile: HTTPSSPIFFEProfile{
+						EndpointSPIFFEID: trustDomain.ID(),
+					},
+					ProxyURL:    tt.proxyURL,
+					DialTimeout: 5 * time.Second,
+				},
+				newClientHook: func(config ClientConfig) (Client, error) {
+					clientConfig = config
+					return fakeClient{err: errors.New("ohno")}, nil
+				},
+			})
+
+			_, _, err := updater.UpdateBundle(context.Background())
+			if tt.err != "" {
+				spiretest.RequireErrorContains(t, err, tt.err)
+				return
+			}
+			spiretest.RequireErrorContains(t, err, "ohno")
+
+			assert.Equal(t, 5*time.Second, clientConfig.DialTimeout)
+			if tt.proxyURL == "" {
+				assert.Nil(t, clientConfig.ProxyURL)
+			} else {
+				require.NotNil(t, clientConfig.ProxyURL)
+				assert.Equal(t, tt.proxyURL, clientConfig.ProxyURL.String())
+			}
+		})
+	}
+}
+
+func TestBundleUpdaterFollowsEndpointRootRotation(t *testing.T) {
+	oldRoot := createCACertificate(t, "old")
+	newRoot := createCACertificate(t, "new")
+
+	ds := fakedatastore.New(t)
+	_, err := ds.CreateBundle(context.Background(), bundleutil.BundleFromRootCA(trustDomain, oldRoot).Proto())
+	require.NoError(t, err)
+
+	var rootCAs [][]*x509.Certificate
+	updater := NewBundleUpdater(BundleUpdaterConfig{
+		DataStore:   ds,
+		TrustDomain: trustDomain,
+		TrustDomainConfig: TrustDomainConfig{
+			EndpointURL: "ENDPOINT_ADDRESS",
+			EndpointProfile: HTTPSSPIFFEProfile{
+				EndpointSPIFFEID: trustDomain.ID(),
+			},
+		},
+		newClientHook: func(config ClientConfig) (Client, error) {
+			rootCAs = append(rootCAs, config.SPIFFEAuth.RootCAs)
+			return fakeClient{bundle: bundleutil.BundleFromRootCA(trustDomain, newRoot)}, nil
+		},
+	})
+
+	// The endpoint is authenticated with the roots of the stored bundle...
+	_, _, err = updater.UpdateBundle(context.Background())
+	require.NoError(t, err)
+
+	// ...which, once the endpoint publishes a rotated bundle, are the new
+	// ones on the following refresh
+	_, _, err = updater.UpdateBundle(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, [][]*x509.Certificate{{oldRoot}, {newRoot}}, rootCAs)
+}
+
 func TestBundleUpdaterConfiguration(t *testing.T) {
 	configs := []TrustDomainConfig{
 		{
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	serverTelemetry "github.com/spiffe/spire/pkg/common/telemetry/server"
+	"github.com/spiffe/spire/pkg/server/datastore"
+	"github.com/spiffe/spire/proto/spire/common"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// bundleMetricsInterval is how often the bundle metrics are emitted.
+	bundleMetricsInterval = time.Minute
+)
+
+// bundleRefreshSource returns when the bundle of a federated trust domain was
+// last fetched successfully from its bundle endpoint.
+type bundleRefreshSource interface {
+	LastBundleRefresh(td spiffeid.TrustDomain) (time.Time, bool)
+}
+
+// bundleMetricsReporter periodically emits metrics about the bundles of the
+// local and federated trust domains, so stale bundles can be detected.
+type bundleMetricsReporter struct {
+	log     logrus.FieldLogger
+	metrics telemetry.Metrics
+	ds      datastore.DataStore
+	clock   clock.Clock
+
+	// refreshes provides the last successful refresh of the bundles fetched
+	// from bundle endpoints.
+	refreshes bundleRefreshSource
+
+	// observed holds the last observed bundle of each trust domain and when
+	// it was last seen to change.
+	observed map[string]bundleObservation
+}
+
+type bundleObservation struct {
+	bundle    *common.Bundle
+	updatedAt time.Time
+}
+
+func newBundleMetricsReporter(log logrus.FieldLogger, metrics telemetry.Metrics, ds datastore.DataStore, refreshes bundleRefreshSource, clk clock.Clock) *bundleMetricsReporter {
+	return &bundleMetricsReporter{
+		log:       log,
+		metrics:   metrics,
+		ds:        ds,
+		clock:     clk,
+		refreshes: refreshes,
+		observed:  make(map[string]bundleObservation),
+	}
+}
+
+func (r *bundleMetricsReporter) Run(ctx context.Context) error {
+	ticker := r.clock.Ticker(bundleMetricsInterval)
+	defer ticker.Stop()
+
+	for {
+		r.report(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// report emits the metrics of every bundle in the datastore. The age of a
+// bundle is the time elapsed since it was last refreshed from its bundle
+// endpoint, even if it did not change, or since it was last seen to change,
+// whichever is more recent. Bundles that are not fetched from a bundle
+// endpoint, like the local bundle, are only updated when they change. The
+// first time a bundle is observed, the time it last changed is estimated
+// with the most recent issuance of its X.509 authorities.
+func (r *bundleMetricsReporter) report(ctx context.Context) {
+	resp, err := r.ds.ListBundles(ctx, &datastore.ListBundlesRequest{})
+	if err != nil {
+		r.log.WithError(err).Warn("Failed to list bundles to report metrics")
+		return
+	}
+
+	now := r.clock.Now()
+	observed := make(map[string]bundleObservation, len(resp.Bundles))
+	for _, bundle := range resp.Bundles {
+		observation, ok := r.observed[bundle.TrustDomainId]
+		switch {
+		case !ok:
+			observation.updatedAt = lastAuthorityIssuance(bundle, now)
+		case !proto.Equal(observation.bundle, bundle):
+			observation.updatedAt = now
+		}
+		observation.bundle = bundle
+		observed[bundle.TrustDomainId] = observation
+
+		updatedAt := observation.updatedAt
+		if refreshedAt, ok := r.lastRefresh(bundle.TrustDomainId); ok && refreshedAt.After(updatedAt) {
+			updatedAt = refreshedAt
+		}
+
+		serverTelemetry.SetBundleSizeGauge(r.metrics, bundle.TrustDomainId, proto.Size(bundle))
+		serverTelemetry.SetBundleX509AuthoritiesGauge(r.metrics, bundle.TrustDomainId, len(bundle.RootCas))
+		serverTelemetry.SetBundleJWTAuthoritiesGauge(r.metrics, bundle.TrustDomainId, len(bundle.JwtSigningKeys))
+		serverTelemetry.SetBundleAgeGauge(r.metrics, bundle.TrustDomainId, now.Sub(updatedAt))
+	}
+	r.observed = observed
+}
+
+func (r *bundleMetricsReporter) lastRefresh(trustDomainID string) (time.Time, bool) {
+	td, err := spiffeid.TrustDomainFromString(trustDomainID)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return r.refreshes.LastBundleRefresh(td)
+}
+
+// lastAuthorityIssuance returns the most recent NotBefore of the X.509
+// authorities in the bundle, or the given default if it has none that can be
+// parsed.
+func lastAuthorityIssuance(bundle *common.Bundle, def time.Time) time.Time {
+	var last time.Time
+	for _, rootCA := range bundle.RootCas {
+		cert, err := x509.ParseCertificate(rootCA.DerBytes)
+		if err != nil {
+			continue
+		}
+		if cert.NotBefore.After(last) {
+			last = cert.NotBefore
+		}
+	}
+	if last.IsZero() || last.After(def) {
+		return def
+	}
+	return last
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/spiffe/spire/test/clock"
+	"github.com/spiffe/spire/test/fakes/fakedatastore"
+	"github.com/spiffe/spire/test/fakes/fakemetrics"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestBundleMetricsReporter(t *testing.T) {
+	ctx := context.Background()
+	log, _ := test.NewNullLogger()
+	clk := clock.NewMock(t)
+	metrics := fakemetrics.New()
+	ds := fakedatastore.New(t)
+
+	rootCA, _ := spiretest.SelfSignCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    clk.Now().Add(-time.Hour),
+		NotAfter:     clk.Now().Add(time.Hour),
+	})
+
+	bundle := &common.Bundle{
+		TrustDomainId:  "spiffe://domain.test",
+		RootCas:        []*common.Certificate{{DerBytes: rootCA.Raw}},
+		JwtSigningKeys: []*common.PublicKey{{Kid: "KID1", PkixBytes: []byte("KEY1")}},
+	}
+	_, err := ds.CreateBundle(ctx, bundle)
+	require.NoError(t, err)
+
+	refreshes := make(fakeBundleRefreshes)
+	reporter := newBundleMetricsReporter(log, metrics, ds, refreshes, clk)
+
+	// The first time the bundle is observed, its age is estimated from the
+	// most recent X.509 authority.
+	reporter.report(ctx)
+	assert.Equal(t, expectedBundleMetrics("spiffe://domain.test", float32(proto.Size(bundle)), 1, 1, 3600), metrics.AllMetrics())
+
+	// The age grows while the bundle does not change.
+	metrics.Reset()
+	clk.Add(10 * time.Minute)
+	reporter.report(ctx)
+	assert.Equal(t, expectedBundleMetrics("spiffe://domain.test", float32(proto.Size(bundle)), 1, 1, 4200), metrics.AllMetrics())
+
+	// The age is reset when the bundle changes.
+	bundle.JwtSigningKeys = append(bundle.JwtSigningKeys, &common.PublicKey{Kid: "KID2", PkixBytes: []byte("KEY2")})
+	_, err = ds.SetBundle(ctx, bundle)
+	require.NoError(t, err)
+
+	metrics.Reset()
+	clk.Add(time.Minute)
+	reporter.report(ctx)
+	assert.Equal(t, expectedBundleMetrics("spiffe://domain.test", float32(proto.Size(bundle)), 1, 2, 0), metrics.AllMetrics())
+
+	metrics.Reset()
+	clk.Add(time.Minute)
+	reporter.report(ctx)
+	assert.Equal(t, expectedBundleMetrics("spiffe://domain.test", float32(proto.Size(bundle)), 1, 2, 60), metrics.AllMetrics())
+
+	// The age is reset when the bundle is refreshed from its bundle
+	// endpoint, even if it did not change.
+	clk.Add(time.Minute)
+	refreshes[spiffeid.RequireTrustDomainFromString("domain.test")] = clk.Now()
+
+	metrics.Reset()
+	clk.Add(30 * time.Second)
+	reporter.report(ctx)
+	assert.Equal(t, expectedBundleMetrics("spiffe://domain.test", float32(proto.Size(bundle)), 1, 2, 30), metrics.AllMetrics())
+}
+
+type fakeBundleRefreshes map[spiffeid.TrustDomain]time.Time
+
+func (f fakeBundleRefreshes) LastBundleRefresh(td spiffeid.TrustDomain) (time.Time, bool) {
+	refreshedAt, ok := f[td]
+	return refreshedAt, ok
+}
+
+func expectedBundleMetrics(trustDomain string, size, x509Authorities, jwtAuthorities, age float32) []fakemetrics.MetricItem {
+	labels := telemetry.SanitizeLabels([]telemetry.Label{{Name: telemetry.TrustDomainID, Value: trustDomain}})
+	gauge := func(name string, val float32) fakemetrics.MetricItem {
+		return fakemetrics.MetricItem{
+			Type:   fakemetrics.SetGaugeWithLabelsType,
+			Key:    []string{telemetry.Bundle, name},
+			Val:    val,
+			Labels: labels,
+		}
+	}
+	return []fakemetrics.MetricItem{
+		gauge(telemetry.Size, size),
+		gauge(telemetry.X509CAs, x509Authorities),
+		gauge(telemetry.JWTKeys, jwtAuthorities),
+		gauge(telemetry.Age, age),
+	}
+}
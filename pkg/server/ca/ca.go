@@ -6,7 +6,12 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"math/big"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,6 +25,7 @@ import (
 	"github.com/spiffe/spire/pkg/common/x509svid"
 	"github.com/spiffe/spire/pkg/common/x509util"
 	"github.com/spiffe/spire/pkg/server/api"
+	"github.com/spiffe/spire/pkg/server/datastore"
 	"github.com/zeebo/errs"
 )
 
@@ -33,6 +39,16 @@ const (
 	DefaultJWTSVIDTTL = time.Minute * 5
 )
 
+// ErrMaxDownstreamDepthExceeded is returned when signing an X509 CA SVID
+// would result in a chain of downstream CAs deeper than the configured
+// maximum.
+var ErrMaxDownstreamDepthExceeded = errors.New("maximum downstream depth exceeded")
+
+// IssuanceLog records the X509 certificates issued by the CA
+type IssuanceLog interface {
+	CreateIssuanceRecord(ctx context.Context, record *datastore.IssuanceRecord) error
+}
+
 // ServerCA is an interface for Server CAs
 type ServerCA interface {
 	SignX509SVID(ctx context.Context, params X509SVIDParams) ([]*x509.Certificate, error)
@@ -58,6 +74,15 @@ type X509SVIDParams struct {
 
 	// Subject of the SVID. Default subject is used if it is empty.
 	Subject pkix.Name
+
+	// EntryID is the ID of the registration entry the SVID is issued for,
+	// if any. It is only used to record the issuance.
+	EntryID string
+
+	// AgentID is the SPIFFE ID of the agent the SVID is issued to, if any.
+	// It is used to select the signing X509 CA during a staged rollout. When
+	// unset, the SPIFFE ID of the SVID is used instead.
+	AgentID spiffeid.ID
 }
 
 // X509CASVIDParams are parameters relevant to X509 CA SVID creation
@@ -84,6 +109,11 @@ type JWTSVIDParams struct {
 
 	// Audience is used for audience claims
 	Audience []string
+
+	// AgentID is the SPIFFE ID of the agent the SVID is issued to, if any.
+	// It is used to select the signing JWT key during a staged rollout. When
+	// unset, the SPIFFE ID of the SVID is used instead.
+	AgentID spiffeid.ID
 }
 
 type X509CA struct {
@@ -121,6 +151,49 @@ type Config struct {
 	CASubject       pkix.Name
 	HealthChecker   health.Checker
 	OmitX509SVIDUID bool
+
+	// MaxDownstreamDepth, if greater than zero, limits how many levels of
+	// downstream CAs may be chained below this server. Requests that would
+	// produce a deeper chain are rejected, and the CA certificates that are
+	// issued carry a path length constraint that enforces the limit.
+	MaxDownstreamDepth int
+
+	// SerialNumberPolicy determines how the serial numbers of the X509
+	// certificates signed by the CA are generated.
+	SerialNumberPolicy x509util.SerialNumberPolicy
+
+	// IssuanceLog, if set, records every X509 certificate signed by the CA.
+	// Signing fails if the issuance cannot be recorded.
+	IssuanceLog IssuanceLog
+
+	// StagedRollout, if enabled, gradually moves the signing of SVIDs to a
+	// newly activated X509 CA or JWT key instead of switching all at once.
+	StagedRollout StagedRolloutConfig
+}
+
+// StagedRolloutConfig configures the staged rollout of newly activated X509
+// CAs and JWT keys. Each agent is assigned a stable bucket from a hash of its
+// ID. When a new X509 CA or JWT key is activated, agents in the first
+// InitialPercentage buckets are signed by it right away, and the percentage
+// grows linearly until every agent is signed by it after Duration. Until
+// then, the remaining agents keep being signed by the previous one. The
+// rollout is cut short when the previous one would otherwise have to sign
+// SVIDs with less than the SVID TTL left.
+type StagedRolloutConfig struct {
+	// InitialPercentage is the percentage of agents, between 0 and 100,
+	// that are signed by the new X509 CA or JWT key as soon as it is
+	// activated.
+	InitialPercentage int
+
+	// Duration is how long it takes for the new X509 CA or JWT key to be
+	// used for every agent. The staged rollout is disabled if it is zero.
+	Duration time.Duration
+}
+
+// Enabled returns true if newly activated X509 CAs and JWT keys are rolled
+// out gradually.
+func (c StagedRolloutConfig) Enabled() bool {
+	return c.Duration > 0 && c.InitialPercentage < 100
 }
 
 type CA struct {
@@ -130,6 +203,18 @@ type CA struct {
 	x509CA *X509CA
 	jwtKey *JWTKey
 
+	// prevX509CA is the X509 CA that was replaced by x509CA and is still used
+	// for part of the agents while a staged rollout is in progress.
+	prevX509CA            *X509CA
+	x509CAActivatedAt     time.Time
+	x509CARolloutDuration time.Duration
+
+	// prevJWTKey is the JWT key that was replaced by jwtKey and is still used
+	// for part of the agents while a staged rollout is in progress.
+	prevJWTKey            *JWTKey
+	jwtKeyActivatedAt     time.Time
+	jwtKeyRolloutDuration time.Duration
+
 	jwtSigner *jwtsvid.Signer
 }
 
@@ -169,7 +254,122 @@ func (ca *CA) X509CA() *X509CA {
 func (ca *CA) SetX509CA(x509CA *X509CA) {
 	ca.mu.Lock()
 	defer ca.mu.Unlock()
+
+	now := ca.c.Clock.Now()
+	ca.prevX509CA = nil
+	if ca.c.StagedRollout.Enabled() && ca.x509CA != nil && x509CA != nil {
+		duration := ca.stagedRolloutDuration(now, ca.x509CA.Certificate.NotAfter, ca.c.X509SVIDTTL)
+		if duration > 0 {
+			ca.prevX509CA = ca.x509CA
+			ca.x509CARolloutDuration = duration
+			ca.c.Log.WithFields(logrus.Fields{
+				telemetry.Percentage: ca.c.StagedRollout.InitialPercentage,
+				telemetry.Duration:   duration.String(),
+			}).Info("Started staged rollout of X509 CA")
+		}
+	}
 	ca.x509CA = x509CA
+	ca.x509CAActivatedAt = now
+}
+
+// X509CAs returns the X509 CAs SVIDs are currently signed with, i.e. the
+// current X509 CA and, while a staged rollout is in progress, the previous
+// one.
+func (ca *CA) X509CAs() []*X509CA {
+	ca.mu.RLock()
+	defer ca.mu.RUnlock()
+
+	var x509CAs []*X509CA
+	if ca.x509CA != nil {
+		x509CAs = append(x509CAs, ca.x509CA)
+	}
+	if ca.prevX509CA != nil && ca.c.Clock.Now().Before(ca.prevX509CA.Certificate.NotAfter) {
+		x509CAs = append(x509CAs, ca.prevX509CA)
+	}
+	return x509CAs
+}
+
+// RolloutPercentage returns the percentage of agents that are signed by the
+// current X509 CA. It is always 100 unless a staged rollout is in progress.
+func (ca *CA) RolloutPercentage() int {
+	ca.mu.RLock()
+	defer ca.mu.RUnlock()
+	return ca.rolloutPercentage(ca.c.Clock.Now())
+}
+
+func (ca *CA) rolloutPercentage(now time.Time) int {
+	if ca.prevX509CA == nil {
+		return 100
+	}
+	return ca.stagedRolloutPercentage(now, ca.x509CAActivatedAt, ca.x509CARolloutDuration)
+}
+
+// jwtKeyRolloutPercentage returns the percentage of agents that are signed
+// by the current JWT key. It is always 100 unless a staged rollout is in
+// progress.
+func (ca *CA) jwtKeyRolloutPercentage(now time.Time) int {
+	if ca.prevJWTKey == nil {
+		return 100
+	}
+	return ca.stagedRolloutPercentage(now, ca.jwtKeyActivatedAt, ca.jwtKeyRolloutDuration)
+}
+
+// stagedRolloutDuration returns how long the staged rollout of an X509 CA
+// or JWT key activated now lasts, when the previous one expires at
+// prevNotAfter. The previous one stops signing once it has less than the
+// SVID TTL left, since the SVIDs it signs would otherwise be cut short by
+// its expiration. The rollout is skipped if it returns zero or less.
+func (ca *CA) stagedRolloutDuration(now, prevNotAfter time.Time, svidTTL time.Duration) time.Duration {
+	duration := ca.c.StagedRollout.Duration
+	if remaining := prevNotAfter.Sub(now) - svidTTL; remaining < duration {
+		if remaining > 0 {
+			ca.c.Log.WithFields(logrus.Fields{
+				telemetry.Duration:   remaining.String(),
+				telemetry.Expiration: prevNotAfter.Format(time.RFC3339),
+			}).Warn("Shortened staged rollout since the previous authority expires before the configured rollout duration ends")
+		}
+		duration = remaining
+	}
+	return duration
+}
+
+// stagedRolloutPercentage returns the percentage of agents that are signed
+// by an X509 CA or JWT key activated at the given time, when its rollout
+// lasts for the given duration.
+func (ca *CA) stagedRolloutPercentage(now, activatedAt time.Time, duration time.Duration) int {
+	elapsed := now.Sub(activatedAt)
+	if elapsed >= duration {
+		return 100
+	}
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	initial := ca.c.StagedRollout.InitialPercentage
+	if initial < 0 {
+		initial = 0
+	}
+	return initial + int(int64(100-initial)*int64(elapsed)/int64(duration))
+}
+
+// x509CAFor returns the X509 CA that signs SVIDs for the given ID. While a
+// staged rollout is in progress, IDs that are not part of the rollout yet
+// keep being signed by the previous X509 CA.
+func (ca *CA) x509CAFor(id spiffeid.ID) *X509CA {
+	ca.mu.RLock()
+	defer ca.mu.RUnlock()
+
+	percentage := ca.rolloutPercentage(ca.c.Clock.Now())
+	if percentage >= 100 || rolloutBucket(id) < percentage {
+		return ca.x509CA
+	}
+	return ca.prevX509CA
+}
+
+// rolloutBucket returns a stable bucket, between 0 and 99, for the given ID.
+func rolloutBucket(id spiffeid.ID) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id.String()))
+	return int(h.Sum32() % 100)
 }
 
 func (ca *CA) JWTKey() *JWTKey {
@@ -181,11 +381,44 @@ func (ca *CA) JWTKey() *JWTKey {
 func (ca *CA) SetJWTKey(jwtKey *JWTKey) {
 	ca.mu.Lock()
 	defer ca.mu.Unlock()
+
+	now := ca.c.Clock.Now()
+	ca.prevJWTKey = nil
+	if ca.c.StagedRollout.Enabled() && ca.jwtKey != nil && jwtKey != nil {
+		duration := ca.stagedRolloutDuration(now, ca.jwtKey.NotAfter, ca.c.JWTSVIDTTL)
+		if duration > 0 {
+			ca.prevJWTKey = ca.jwtKey
+			ca.jwtKeyRolloutDuration = duration
+			ca.c.Log.WithFields(logrus.Fields{
+				telemetry.Percentage: ca.c.StagedRollout.InitialPercentage,
+				telemetry.Duration:   duration.String(),
+			}).Info("Started staged rollout of JWT key")
+		}
+	}
 	ca.jwtKey = jwtKey
+	ca.jwtKeyActivatedAt = now
+}
+
+// jwtKeyFor returns the JWT key that signs SVIDs for the given ID. While a
+// staged rollout is in progress, IDs that are not part of the rollout yet
+// keep being signed by the previous JWT key.
+func (ca *CA) jwtKeyFor(id spiffeid.ID) *JWTKey {
+	ca.mu.RLock()
+	defer ca.mu.RUnlock()
+
+	percentage := ca.jwtKeyRolloutPercentage(ca.c.Clock.Now())
+	if percentage >= 100 || rolloutBucket(id) < percentage {
+		return ca.jwtKey
+	}
+	return ca.prevJWTKey
 }
 
 func (ca *CA) SignX509SVID(ctx context.Context, params X509SVIDParams) ([]*x509.Certificate, error) {
-	x509CA := ca.X509CA()
+	rolloutID := params.AgentID
+	if rolloutID.IsZero() {
+		rolloutID = params.SpiffeID
+	}
+	x509CA := ca.x509CAFor(rolloutID)
 	if x509CA == nil {
 		return nil, errs.New("X509 CA is not available for signing")
 	}
@@ -195,12 +428,20 @@ func (ca *CA) SignX509SVID(ctx context.Context, params X509SVIDParams) ([]*x509.
 	}
 
 	notBefore, notAfter := ca.capLifetime(params.TTL, x509CA.Certificate.NotAfter)
+	serialNumber, err := ca.newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
 
-	x509SVID, err := signX509SVID(ca.c.TrustDomain, x509CA, params, notBefore, notAfter, ca.c.OmitX509SVIDUID)
+	x509SVID, err := signX509SVID(ca.c.TrustDomain, x509CA, params, notBefore, notAfter, serialNumber, ca.c.OmitX509SVIDUID)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := ca.recordIssuance(ctx, x509SVID[0], params.SpiffeID, params.EntryID); err != nil {
+		return nil, err
+	}
+
 	telemetry_server.IncrServerCASignX509Counter(ca.c.Metrics)
 	return x509SVID, nil
 }
@@ -215,8 +456,21 @@ func (ca *CA) SignX509CASVID(ctx context.Context, params X509CASVIDParams) ([]*x
 		params.TTL = ca.c.X509SVIDTTL
 	}
 
+	// The downstream "level" is 1-based and counts the CA certificates
+	// between the new CA and the root of trust.
+	level := 1 + len(x509CA.UpstreamChain)
+
+	// The depth only counts the downstream CAs issued by SPIRE servers, so
+	// that the intermediates of an UpstreamAuthority do not count towards
+	// the maximum.
+	depth := 1 + countDownstreamCAs(x509CA)
+	if ca.c.MaxDownstreamDepth > 0 && depth > ca.c.MaxDownstreamDepth {
+		telemetry_server.IncrServerCARejectX509CACounter(ca.c.Metrics, depth)
+		return nil, fmt.Errorf("%w: depth %d exceeds maximum of %d", ErrMaxDownstreamDepthExceeded, depth, ca.c.MaxDownstreamDepth)
+	}
+
 	notBefore, notAfter := ca.capLifetime(params.TTL, x509CA.Certificate.NotAfter)
-	serialNumber, err := x509util.NewSerialNumber()
+	serialNumber, err := ca.newSerialNumber()
 	if err != nil {
 		return nil, err
 	}
@@ -225,7 +479,7 @@ func (ca *CA) SignX509CASVID(ctx context.Context, params X509CASVIDParams) ([]*x
 	// certificate. Additionally, set the OU to a 1-based downstream "level"
 	// for soft debugging support.
 	subject := x509CA.Certificate.Subject
-	subject.OrganizationalUnit = []string{fmt.Sprintf("DOWNSTREAM-%d", 1+len(x509CA.UpstreamChain))}
+	subject.OrganizationalUnit = []string{fmt.Sprintf("DOWNSTREAM-%d", level)}
 
 	template, err := CreateServerCATemplate(params.SpiffeID, params.PublicKey, ca.c.TrustDomain, notBefore, notAfter, serialNumber, subject)
 	if err != nil {
@@ -236,18 +490,33 @@ func (ca *CA) SignX509CASVID(ctx context.Context, params X509CASVIDParams) ([]*x
 	// OU override below, but just to be safe).
 	template.AuthorityKeyId = x509CA.Certificate.SubjectKeyId
 
+	// Constrain the path length so that clients validating the chain also
+	// enforce the maximum depth, even if a downstream server does not.
+	if ca.c.MaxDownstreamDepth > 0 {
+		template.MaxPathLen = ca.c.MaxDownstreamDepth - depth
+		template.MaxPathLenZero = template.MaxPathLen == 0
+	}
+
 	cert, err := createCertificate(template, x509CA.Certificate, template.PublicKey, x509CA.Signer)
 	if err != nil {
 		return nil, errs.New("unable to create X509 CA SVID: %v", err)
 	}
 
+	if err := ca.recordIssuance(ctx, cert, params.SpiffeID, ""); err != nil {
+		return nil, err
+	}
+
 	telemetry_server.IncrServerCASignX509CACounter(ca.c.Metrics)
 
 	return makeSVIDCertChain(x509CA, cert), nil
 }
 
 func (ca *CA) SignJWTSVID(ctx context.Context, params JWTSVIDParams) (string, error) {
-	jwtKey := ca.JWTKey()
+	rolloutID := params.AgentID
+	if rolloutID.IsZero() {
+		rolloutID = params.SpiffeID
+	}
+	jwtKey := ca.jwtKeyFor(rolloutID)
 	if jwtKey == nil {
 		return "", errs.New("JWT key is not available for signing")
 	}
@@ -271,6 +540,28 @@ func (ca *CA) SignJWTSVID(ctx context.Context, params JWTSVIDParams) (string, er
 	return token, nil
 }
 
+func (ca *CA) newSerialNumber() (*big.Int, error) {
+	return x509util.NewSerialNumberForPolicy(ca.c.SerialNumberPolicy, ca.c.Clock.Now())
+}
+
+// recordIssuance records the issued certificate in the issuance log, if
+// configured. Certificates signed for health checks are not recorded.
+func (ca *CA) recordIssuance(ctx context.Context, cert *x509.Certificate, spiffeID spiffeid.ID, entryID string) error {
+	if ca.c.IssuanceLog == nil || health.IsCheck(ctx) {
+		return nil
+	}
+
+	if err := ca.c.IssuanceLog.CreateIssuanceRecord(ctx, &datastore.IssuanceRecord{
+		SerialNumber: cert.SerialNumber.String(),
+		SpiffeID:     spiffeID.String(),
+		EntryID:      entryID,
+		ExpiresAt:    cert.NotAfter,
+	}); err != nil {
+		return errs.New("unable to record X509 certificate issuance: %v", err)
+	}
+	return nil
+}
+
 func (ca *CA) capLifetime(ttl time.Duration, expirationCap time.Time) (notBefore, notAfter time.Time) {
 	now := ca.c.Clock.Now()
 	notBefore = now.Add(-backdate)
@@ -281,16 +572,11 @@ func (ca *CA) capLifetime(ttl time.Duration, expirationCap time.Time) (notBefore
 	return notBefore, notAfter
 }
 
-func signX509SVID(td spiffeid.TrustDomain, x509CA *X509CA, params X509SVIDParams, notBefore, notAfter time.Time, omitUID bool) ([]*x509.Certificate, error) {
+func signX509SVID(td spiffeid.TrustDomain, x509CA *X509CA, params X509SVIDParams, notBefore, notAfter time.Time, serialNumber *big.Int, omitUID bool) ([]*x509.Certificate, error) {
 	if x509CA == nil {
 		return nil, errs.New("X509 CA is not available for signing")
 	}
 
-	serialNumber, err := x509util.NewSerialNumber()
-	if err != nil {
-		return nil, err
-	}
-
 	template, err := CreateX509SVIDTemplate(params.SpiffeID, params.PublicKey, td, notBefore, notAfter, serialNumber)
 	if err != nil {
 		return nil, err
@@ -341,3 +627,32 @@ func createCertificate(template, parent *x509.Certificate, pub, priv interface{}
 
 	return x509.ParseCertificate(certDER)
 }
+
+// countDownstreamCAs returns the number of downstream CA certificates issued
+// by SPIRE servers in the chain of the given CA, which are recognized by the
+// DOWNSTREAM organizational unit set by SignX509CASVID.
+func countDownstreamCAs(x509CA *X509CA) int {
+	chain := x509CA.UpstreamChain
+	if len(chain) == 0 || !chain[0].Equal(x509CA.Certificate) {
+		chain = append([]*x509.Certificate{x509CA.Certificate}, chain...)
+	}
+
+	count := 0
+	for _, cert := range chain {
+		for _, ou := range cert.Subject.OrganizationalUnit {
+			if isDownstreamOU(ou) {
+				count++
+				break
+			}
+		}
+	}
+	return count
+}
+
+func isDownstreamOU(ou string) bool {
+	if !strings.HasPrefix(ou, "DOWNSTREAM-") {
+		return false
+	}
+	_, err := strconv.Atoi(strings.TrimPrefix(ou, "DOWNSTREAM-"))
+	return err == nil
+}
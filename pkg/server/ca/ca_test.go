@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"errors"
 	"math/big"
 	"testing"
 	"time"
@@ -16,10 +17,12 @@ import (
 	"github.com/spiffe/spire/pkg/common/pemutil"
 	"github.com/spiffe/spire/pkg/common/telemetry"
 	"github.com/spiffe/spire/pkg/common/x509util"
+	"github.com/spiffe/spire/pkg/server/datastore"
 	"github.com/spiffe/spire/test/clock"
 	"github.com/spiffe/spire/test/fakes/fakehealthchecker"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	"gopkg.in/square/go-jose.v2/jwt"
 )
 
 var (
@@ -261,6 +264,54 @@ func (s *CATestSuite) TestSignX509SVIDChangesSerialNumber() {
 	s.Require().NotEqual(0, svid2[0].SerialNumber.Cmp(svid1[0].SerialNumber))
 }
 
+func (s *CATestSuite) TestSignX509SVIDUsesSerialNumberPolicy() {
+	s.ca.c.SerialNumberPolicy = x509util.SerialNumberTimeOrdered
+
+	svid, err := s.ca.SignX509SVID(ctx, s.createX509SVIDParams())
+	s.Require().NoError(err)
+	s.Require().Len(svid, 1)
+
+	// The issuance time makes up the high order bits of the serial number
+	timestamp := new(big.Int).Rsh(svid[0].SerialNumber, 64)
+	s.Require().Equal(s.clock.Now().UnixNano(), timestamp.Int64())
+}
+
+func (s *CATestSuite) TestSignX509SVIDRecordsIssuance() {
+	issuanceLog := new(fakeIssuanceLog)
+	s.ca.c.IssuanceLog = issuanceLog
+
+	params := s.createX509SVIDParams()
+	params.EntryID = "entry1"
+	svid, err := s.ca.SignX509SVID(ctx, params)
+	s.Require().NoError(err)
+
+	caSVID, err := s.ca.SignX509CASVID(ctx, s.createX509CASVIDParams(trustDomainExample))
+	s.Require().NoError(err)
+
+	// SVIDs signed for health checks are not recorded
+	_, err = s.ca.SignX509SVID(health.CheckContext(ctx), s.createX509SVIDParams())
+	s.Require().NoError(err)
+
+	s.Require().Equal([]*datastore.IssuanceRecord{
+		{
+			SerialNumber: svid[0].SerialNumber.String(),
+			SpiffeID:     "spiffe://example.org/workload",
+			EntryID:      "entry1",
+			ExpiresAt:    svid[0].NotAfter,
+		},
+		{
+			SerialNumber: caSVID[0].SerialNumber.String(),
+			SpiffeID:     "spiffe://example.org",
+			ExpiresAt:    caSVID[0].NotAfter,
+		},
+	}, issuanceLog.records)
+
+	// Signing fails if the issuance cannot be recorded
+	issuanceLog.err = errors.New("oh no")
+	_, err = s.ca.SignX509SVID(ctx, s.createX509SVIDParams())
+	s.Require().EqualError(err, "unable to record X509 certificate issuance: oh no")
+}
+
 func (s *CATestSuite) TestNoJWTKeySet() {
 	s.ca.SetJWTKey(nil)
 	_, err := s.ca.SignJWTSVID(ctx, s.createJWTSVIDParams(trustDomainExample, 0))
@@ -457,6 +508,364 @@ func TestOmitX509SVIDUID(t *testing.T) {
 	require.Equal(t, "O=SPIRE,C=US", certs[0].Subject.String())
 }
 
+func TestMaxDownstreamDepth(t *testing.T) {
+	clk := clock.NewMock(t)
+	log, _ := test.NewNullLogger()
+
+	ca := NewCA(Config{
+		Log:         log,
+		Metrics:     telemetry.Blackhole{},
+		TrustDomain: trustDomainExample,
+		X509SVIDTTL: time.Minute,
+		Clock:       clk,
+		CASubject: pkix.Name{
+			CommonName: "TESTCA",
+		},
+		HealthChecker:      fakehealthchecker.New(),
+		MaxDownstreamDepth: 2,
+	})
+
+	rootCert := createCACertificate(t, clk, "ROOT", nil)
+	params := X509CASVIDParams{
+		SpiffeID:  trustDomainExample.ID(),
+		PublicKey: testSigner.Public(),
+	}
+
+	// Level 1 downstream may have one more downstream below it.
+	ca.SetX509CA(&X509CA{
+		Signer:      testSigner,
+		Certificate: rootCert,
+	})
+	certs, err := ca.SignX509CASVID(context.Background(), params)
+	require.NoError(t, err)
+	require.Len(t, certs, 1)
+	require.Equal(t, 1, certs[0].MaxPathLen)
+	require.False(t, certs[0].MaxPathLenZero)
+
+	// Level 2 downstream is the last level allowed.
+	level1Cert := certs[0]
+	ca.SetX509CA(&X509CA{
+		Signer:        testSigner,
+		Certificate:   level1Cert,
+		UpstreamChain: []*x509.Certificate{level1Cert},
+	})
+	certs, err = ca.SignX509CASVID(context.Background(), params)
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	require.Equal(t, 0, certs[0].MaxPathLen)
+	require.True(t, certs[0].MaxPathLenZero)
+
+	// Level 3 downstream exceeds the maximum.
+	ca.SetX509CA(&X509CA{
+		Signer:        testSigner,
+		Certificate:   certs[0],
+		UpstreamChain: certs,
+	})
+	_, err = ca.SignX509CASVID(context.Background(), params)
+	require.ErrorIs(t, err, ErrMaxDownstreamDepthExceeded)
+	require.EqualError(t, err, "maximum downstream depth exceeded: depth 3 exceeds maximum of 2")
+}
+
+func TestMaxDownstreamDepthIgnoresUpstreamIntermediates(t *testing.T) {
+	clk := clock.NewMock(t)
+	log, _ := test.NewNullLogger()
+
+	ca := NewCA(Config{
+		Log:         log,
+		Metrics:     telemetry.Blackhole{},
+		TrustDomain: trustDomainExample,
+		X509SVIDTTL: time.Minute,
+		Clock:       clk,
+		CASubject: pkix.Name{
+			CommonName: "TESTCA",
+		},
+		HealthChecker:      fakehealthchecker.New(),
+		MaxDownstreamDepth: 1,
+	})
+
+	// The server CA is signed by an UpstreamAuthority through two
+	// intermediates, which do not count towards the maximum depth.
+	rootCert := createCACertificate(t, clk, "ROOT", nil)
+	intermediate1 := createCACertificate(t, clk, "INTERMEDIATE1", rootCert)
+	intermediate2 := createCACertificate(t, clk, "INTERMEDIATE2", intermediate1)
+	serverCA := createCACertificate(t, clk, "SERVERCA", intermediate2)
+	ca.SetX509CA(&X509CA{
+		Signer:        testSigner,
+		Certificate:   serverCA,
+		UpstreamChain: []*x509.Certificate{serverCA, intermediate2, intermediate1},
+	})
+
+	certs, err := ca.SignX509CASVID(context.Background(), X509CASVIDParams{
+		SpiffeID:  trustDomainExample.ID(),
+		PublicKey: testSigner.Public(),
+	})
+	require.NoError(t, err)
+	require.Len(t, certs, 4)
+	require.Equal(t, 0, certs[0].MaxPathLen)
+	require.True(t, certs[0].MaxPathLenZero)
+}
+
+func TestStagedRollout(t *testing.T) {
+	clk := clock.NewMock(t)
+	log, _ := test.NewNullLogger()
+
+	ca := NewCA(Config{
+		Log:         log,
+		Metrics:     telemetry.Blackhole{},
+		TrustDomain: trustDomainExample,
+		X509SVIDTTL: time.Minute,
+		Clock:       clk,
+		CASubject: pkix.Name{
+			CommonName: "TESTCA",
+		},
+		HealthChecker: fakehealthchecker.New(),
+		StagedRollout: StagedRolloutConfig{
+			InitialPercentage: 20,
+			Duration:          4 * time.Minute,
+		},
+	})
+
+	oldCA := &X509CA{
+		Signer:      testSigner,
+		Certificate: createCACertificate(t, clk, "OLD", nil),
+	}
+	newCA := &X509CA{
+		Signer:      testSigner,
+		Certificate: createCACertificate(t, clk, "NEW", nil),
+	}
+
+	// Find agents that fall in and out of the initial rollout percentage.
+	var inRollout, outOfRollout spiffeid.ID
+	for i := 0; inRollout.IsZero() || outOfRollout.IsZero(); i++ {
+		id := spiffeid.RequireFromPathf(trustDomainExample, "/spire/agent/test/%d", i)
+		switch bucket := rolloutBucket(id); {
+		case bucket < 20:
+			inRollout = id
+		case bucket >= 80:
+			outOfRollout = id
+		}
+	}
+
+	issuerOf := func(agentID spiffeid.ID) string {
+		certs, err := ca.SignX509SVID(context.Background(), X509SVIDParams{
+			SpiffeID:  spiffeid.RequireFromString("spiffe://example.org/workload"),
+			PublicKey: testSigner.Public(),
+			AgentID:   agentID,
+		})
+		require.NoError(t, err)
+		return certs[0].Issuer.CommonName
+	}
+
+	// The first X509 CA is used for everyone right away.
+	ca.SetX509CA(oldCA)
+	require.Equal(t, 100, ca.RolloutPercentage())
+	require.Equal(t, "OLD", issuerOf(inRollout))
+	require.Equal(t, "OLD", issuerOf(outOfRollout))
+	require.Equal(t, []*X509CA{oldCA}, ca.X509CAs())
+
+	// Only the agents in the initial percentage use the new X509 CA.
+	ca.SetX509CA(newCA)
+	require.Equal(t, 20, ca.RolloutPercentage())
+	require.Equal(t, "NEW", issuerOf(inRollout))
+	require.Equal(t, "OLD", issuerOf(outOfRollout))
+	require.Equal(t, newCA, ca.X509CA())
+	require.Equal(t, []*X509CA{newCA, oldCA}, ca.X509CAs())
+
+	// The percentage grows linearly over the rollout duration.
+	clk.Add(2 * time.Minute)
+	require.Equal(t, 60, ca.RolloutPercentage())
+	require.Equal(t, "OLD", issuerOf(outOfRollout))
+
+	// Everyone uses the new X509 CA once the rollout is complete.
+	clk.Add(2 * time.Minute)
+	require.Equal(t, 100, ca.RolloutPercentage())
+	require.Equal(t, "NEW", issuerOf(inRollout))
+	require.Equal(t, "NEW", issuerOf(outOfRollout))
+}
+
+func TestStagedRolloutJWTKey(t *testing.T) {
+	clk := clock.NewMock(t)
+	log, _ := test.NewNullLogger()
+
+	ca := NewCA(Config{
+		Log:           log,
+		Metrics:       telemetry.Blackhole{},
+		TrustDomain:   trustDomainExample,
+		Clock:         clk,
+		HealthChecker: fakehealthchecker.New(),
+		StagedRollout: StagedRolloutConfig{
+			InitialPercentage: 20,
+			Duration:          4 * time.Minute,
+		},
+	})
+
+	oldKey := &JWTKey{
+		Signer:   testSigner,
+		Kid:      "OLD",
+		NotAfter: clk.Now().Add(10 * time.Minute),
+	}
+	newKey := &JWTKey{
+		Signer:   testSigner,
+		Kid:      "NEW",
+		NotAfter: clk.Now().Add(20 * time.Minute),
+	}
+
+	// Find agents that fall in and out of the initial rollout percentage.
+	var inRollout, outOfRollout spiffeid.ID
+	for i := 0; inRollout.IsZero() || outOfRollout.IsZero(); i++ {
+		id := spiffeid.RequireFromPathf(trustDomainExample, "/spire/agent/test/%d", i)
+		switch bucket := rolloutBucket(id); {
+		case bucket < 20:
+			inRollout = id
+		case bucket >= 80:
+			outOfRollout = id
+		}
+	}
+
+	signerOf := func(agentID spiffeid.ID) string {
+		token, err := ca.SignJWTSVID(context.Background(), JWTSVIDParams{
+			SpiffeID: spiffeid.RequireFromString("spiffe://example.org/workload"),
+			Audience: []string{"AUDIENCE"},
+			AgentID:  agentID,
+		})
+		require.NoError(t, err)
+		parsed, err := jwt.ParseSigned(token)
+		require.NoError(t, err)
+		require.Len(t, parsed.Headers, 1)
+		return parsed.Headers[0].KeyID
+	}
+
+	// The first JWT key is used for everyone right away.
+	ca.SetJWTKey(oldKey)
+	require.Equal(t, "OLD", signerOf(inRollout))
+	require.Equal(t, "OLD", signerOf(outOfRollout))
+
+	// Only the agents in the initial percentage use the new JWT key.
+	ca.SetJWTKey(newKey)
+	require.Equal(t, "NEW", signerOf(inRollout))
+	require.Equal(t, "OLD", signerOf(outOfRollout))
+	require.Equal(t, newKey, ca.JWTKey())
+
+	// Everyone uses the new JWT key once the rollout is complete.
+	clk.Add(4 * time.Minute)
+	require.Equal(t, "NEW", signerOf(inRollout))
+	require.Equal(t, "NEW", signerOf(outOfRollout))
+}
+
+func TestStagedRolloutSkipsExpiredX509CA(t *testing.T) {
+	clk := clock.NewMock(t)
+	log, _ := test.NewNullLogger()
+
+	ca := NewCA(Config{
+		Log:           log,
+		Metrics:       telemetry.Blackhole{},
+		TrustDomain:   trustDomainExample,
+		Clock:         clk,
+		HealthChecker: fakehealthchecker.New(),
+		StagedRollout: StagedRolloutConfig{
+			InitialPercentage: 0,
+			Duration:          time.Hour,
+		},
+	})
+
+	ca.SetX509CA(&X509CA{
+		Signer:      testSigner,
+		Certificate: createCACertificate(t, clk, "OLD", nil),
+	})
+	clk.Add(20 * time.Minute)
+	newCert := createCACertificate(t, clk, "NEW", nil)
+	ca.SetX509CA(&X509CA{
+		Signer:      testSigner,
+		Certificate: newCert,
+	})
+
+	// The previous X509 CA has already expired, so it cannot be used to
+	// sign SVIDs for agents that are not part of the rollout yet.
+	require.Equal(t, 100, ca.RolloutPercentage())
+	certs, err := ca.SignX509SVID(context.Background(), X509SVIDParams{
+		SpiffeID:  spiffeid.RequireFromString("spiffe://example.org/workload"),
+		PublicKey: testSigner.Public(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "NEW", certs[0].Issuer.CommonName)
+	require.Len(t, ca.X509CAs(), 1)
+}
+
+func TestStagedRolloutShortenedNearExpiration(t *testing.T) {
+	clk := clock.NewMock(t)
+	log, _ := test.NewNullLogger()
+
+	ca := NewCA(Config{
+		Log:           log,
+		Metrics:       telemetry.Blackhole{},
+		TrustDomain:   trustDomainExample,
+		X509SVIDTTL:   time.Minute,
+		JWTSVIDTTL:    time.Minute,
+		Clock:         clk,
+		HealthChecker: fakehealthchecker.New(),
+		StagedRollout: StagedRolloutConfig{
+			InitialPercentage: 0,
+			Duration:          time.Hour,
+		},
+	})
+
+	oldCA := &X509CA{
+		Signer:      testSigner,
+		Certificate: createCACertificate(t, clk, "OLD", nil),
+	}
+	ca.SetX509CA(oldCA)
+	ca.SetJWTKey(&JWTKey{
+		Signer:   testSigner,
+		Kid:      "OLD",
+		NotAfter: oldCA.Certificate.NotAfter,
+	})
+
+	// The previous X509 CA expires in 5 minutes, long before the configured
+	// rollout duration ends. The rollout is shortened so that the previous
+	// X509 CA stops signing once it has less than the SVID TTL left.
+	clk.Add(5 * time.Minute)
+	ca.SetX509CA(&X509CA{
+		Signer:      testSigner,
+		Certificate: createCACertificate(t, clk, "NEW", nil),
+	})
+	require.Equal(t, 0, ca.RolloutPercentage())
+
+	clk.Add(2 * time.Minute)
+	require.Equal(t, 50, ca.RolloutPercentage())
+
+	// SVIDs signed by the previous X509 CA are not cut short by its
+	// expiration.
+	var outOfRollout spiffeid.ID
+	for i := 0; outOfRollout.IsZero(); i++ {
+		id := spiffeid.RequireFromPathf(trustDomainExample, "/spire/agent/test/%d", i)
+		if rolloutBucket(id) >= 80 {
+			outOfRollout = id
+		}
+	}
+	certs, err := ca.SignX509SVID(context.Background(), X509SVIDParams{
+		SpiffeID:  spiffeid.RequireFromString("spiffe://example.org/workload"),
+		PublicKey: testSigner.Public(),
+		AgentID:   outOfRollout,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "OLD", certs[0].Issuer.CommonName)
+	require.Equal(t, clk.Now().Add(time.Minute).Unix(), certs[0].NotAfter.Unix())
+
+	clk.Add(2 * time.Minute)
+	require.Equal(t, 100, ca.RolloutPercentage())
+	require.Len(t, ca.X509CAs(), 2)
+
+	// A JWT key activated when the previous one has less than the SVID TTL
+	// left is used for everyone right away.
+	clk.Add(30 * time.Second)
+	ca.SetJWTKey(&JWTKey{
+		Signer:   testSigner,
+		Kid:      "NEW",
+		NotAfter: clk.Now().Add(10 * time.Minute),
+	})
+	require.Equal(t, 100, ca.jwtKeyRolloutPercentage(clk.Now()))
+}
+
 func createCACertificate(t *testing.T, clk clock.Clock, cn string, parent *x509.Certificate) *x509.Certificate {
 	keyID, err := x509util.GetSubjectKeyID(testSigner.Public())
 	require.NoError(t, err)
@@ -482,3 +891,16 @@ func createCACertificate(t *testing.T, clk clock.Clock, cn string, parent *x509.
 	require.NoError(t, err)
 	return cert
 }
+
+type fakeIssuanceLog struct {
+	records []*datastore.IssuanceRecord
+	err     error
+}
+
+func (l *fakeIssuanceLog) CreateIssuanceRecord(ctx context.Context, record *datastore.IssuanceRecord) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.records = append(l.records, record)
+	return nil
+}
@@ -91,6 +91,9 @@ type Manager struct {
 
 	// Used to log a warning only once when the UpstreamAuthority does not support JWT-SVIDs.
 	jwtUnimplementedWarnOnce sync.Once
+
+	// Status of the upstream authority, reported by UpstreamStatus.
+	upstream upstreamStatusTracker
 }
 
 func NewManager(c ManagerConfig) *Manager {
@@ -247,8 +250,10 @@ func (m *Manager) prepareX509CA(ctx context.Context, slot *x509CASlot) (err erro
 	if m.upstreamClient != nil {
 		x509CA, err = UpstreamSignX509CA(ctx, signer, m.c.TrustDomain, m.c.CASubject, m.upstreamClient, m.c.CATTL)
 		if err != nil {
+			m.recordUpstreamError(err)
 			return err
 		}
+		m.recordUpstreamMint()
 	} else {
 		notBefore := now.Add(-backdate)
 		notAfter := now.Add(m.c.CATTL)
@@ -294,6 +299,7 @@ func (m *Manager) activateX509CA() {
 	}).Debug("Successfully rotated X.509 CA")
 
 	m.c.CA.SetX509CA(m.currentX509CA.x509CA)
+	m.recordActiveX509CA(m.currentX509CA.x509CA)
 }
 
 func (m *Manager) rotateJWTKey(ctx context.Context) error {
@@ -402,6 +408,7 @@ func (m *Manager) PublishJWTKey(ctx context.Context, jwtKey *common.PublicKey) (
 					"this cluster when using JWT-SVIDs.")
 			})
 		case err != nil:
+			m.recordUpstreamError(err)
 			return nil, err
 		default:
 			return upstreamJWTKeys, nil
@@ -585,7 +592,13 @@ func (m *Manager) loadJournal(ctx context.Context) error {
 }
 
 func (m *Manager) journalPath() string {
-	return filepath.Join(m.c.Dir, "journal.pem")
+	return JournalPath(m.c.Dir)
+}
+
+// JournalPath returns the path of the journal kept by the manager in the
+// given directory.
+func JournalPath(dir string) string {
+	return filepath.Join(dir, "journal.pem")
 }
 
 func (m *Manager) tryLoadX509CASlotFromEntry(ctx context.Context, entry *X509CAEntry) (*x509CASlot, error) {
@@ -150,6 +150,10 @@ func (s *ManagerSuite) TestSelfSigning() {
 
 	// Assert that the self-signed X.509 CA produces a valid certificate chain
 	validateSelfSignedX509CA(s.T(), x509CA.Certificate, x509CA.Signer)
+
+	// There is no upstream authority to report the status of
+	_, ok := s.m.UpstreamStatus()
+	s.False(ok)
 }
 
 func (s *ManagerSuite) TestUpstreamSigned() {
@@ -205,6 +209,16 @@ func (s *ManagerSuite) TestUpstreamSignedProducesInvalidChain() {
 	s.cat.SetUpstreamAuthority(upstreamAuthority)
 	s.m = NewManager(s.selfSignedConfig())
 	s.RequireGRPCStatus(s.m.Initialize(context.Background()), codes.InvalidArgument, `X509 CA minted by upstream authority is invalid: X509 CA produced an invalid X509-SVID chain: x509svid: could not verify leaf certificate: x509: certificate signed by unknown authority (possibly because of "x509: invalid signature: parent certificate cannot sign this kind of certificate" while trying to verify candidate authority certificate "FAKEUPSTREAMAUTHORITY-ROOT")`)
+
+	// The error is reported in the upstream authority status
+	status, ok := s.m.UpstreamStatus()
+	s.Require().True(ok)
+	s.Nil(status.X509CA)
+	s.True(status.LastMint.IsZero())
+	if s.Len(status.RecentErrors, 1) {
+		s.Equal(s.clock.Now(), status.RecentErrors[0].Time)
+		s.Contains(status.RecentErrors[0].Message, "X509 CA minted by upstream authority is invalid")
+	}
 }
 
 func (s *ManagerSuite) TestUpstreamIntermediateSigned() {
@@ -227,6 +241,15 @@ func (s *ManagerSuite) TestUpstreamIntermediateSigned() {
 		s.Equal(fakeUA.X509Intermediate(), x509CA.UpstreamChain[1])
 	}
 
+	// The upstream authority status reports the chain and the mint
+	status, ok := s.m.UpstreamStatus()
+	s.Require().True(ok)
+	s.Equal(upstreamAuthority.Name(), status.PluginName)
+	s.Equal(x509CA.Certificate, status.X509CA)
+	s.Equal(x509CA.UpstreamChain, status.UpstreamChain)
+	s.Equal(s.clock.Now(), status.LastMint)
+	s.Empty(status.RecentErrors)
+
 	// The trust bundle should contain the upstream root
 	s.requireBundleRootCAs(fakeUA.X509Root())
 
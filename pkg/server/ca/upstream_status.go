@@ -0,0 +1,90 @@
+package ca
+
+import (
+	"crypto/x509"
+	"sync"
+	"time"
+)
+
+// maxUpstreamErrors is the number of recent upstream authority errors kept
+// in the upstream status.
+const maxUpstreamErrors = 5
+
+// UpstreamStatus describes the upstream authority of the server CA.
+type UpstreamStatus struct {
+	// PluginName is the name of the UpstreamAuthority plugin.
+	PluginName string
+
+	// X509CA is the active X509 CA certificate, or nil if none is active yet.
+	X509CA *x509.Certificate
+
+	// UpstreamChain is the chain the upstream authority issued the active
+	// X509 CA with, starting with the X509 CA certificate.
+	UpstreamChain []*x509.Certificate
+
+	// LastMint is the time an X509 CA was last minted by the upstream
+	// authority since the server started, or zero if none was.
+	LastMint time.Time
+
+	// RecentErrors are the most recent errors returned by the upstream
+	// authority, oldest first.
+	RecentErrors []UpstreamError
+}
+
+// UpstreamError is an error returned by the upstream authority.
+type UpstreamError struct {
+	Time    time.Time
+	Message string
+}
+
+type upstreamStatusTracker struct {
+	mtx    sync.Mutex
+	status UpstreamStatus
+}
+
+// UpstreamStatus returns the status of the upstream authority, or false if
+// no UpstreamAuthority plugin is configured.
+func (m *Manager) UpstreamStatus() (UpstreamStatus, bool) {
+	if m.upstreamClient == nil {
+		return UpstreamStatus{}, false
+	}
+
+	m.upstream.mtx.Lock()
+	defer m.upstream.mtx.Unlock()
+	status := m.upstream.status
+	status.PluginName = m.upstreamPluginName
+	status.UpstreamChain = append([]*x509.Certificate(nil), status.UpstreamChain...)
+	status.RecentErrors = append([]UpstreamError(nil), status.RecentErrors...)
+	return status, true
+}
+
+func (m *Manager) recordUpstreamMint() {
+	m.upstream.mtx.Lock()
+	defer m.upstream.mtx.Unlock()
+	m.upstream.status.LastMint = m.c.Clock.Now()
+}
+
+func (m *Manager) recordUpstreamError(err error) {
+	m.upstream.mtx.Lock()
+	defer m.upstream.mtx.Unlock()
+	errs := append(m.upstream.status.RecentErrors, UpstreamError{
+		Time:    m.c.Clock.Now(),
+		Message: err.Error(),
+	})
+	if len(errs) > maxUpstreamErrors {
+		errs = errs[len(errs)-maxUpstreamErrors:]
+	}
+	m.upstream.status.RecentErrors = errs
+}
+
+func (m *Manager) recordActiveX509CA(x509CA *X509CA) {
+	m.upstream.mtx.Lock()
+	defer m.upstream.mtx.Unlock()
+	m.upstream.status.X509CA = x509CA.Certificate
+	m.upstream.status.UpstreamChain = x509CA.UpstreamChain
+}
+
+// UpstreamStatusSource returns the status of the upstream authority.
+type UpstreamStatusSource interface {
+	UpstreamStatus() (UpstreamStatus, bool)
+}
@@ -0,0 +1,30 @@
+package ca
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/spiffe/spire/test/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordUpstreamErrorKeepsRecentErrors(t *testing.T) {
+	clk := clock.NewMock(t)
+	m := &Manager{
+		c:              ManagerConfig{Clock: clk},
+		upstreamClient: new(UpstreamClient),
+	}
+
+	for i := 0; i < maxUpstreamErrors+2; i++ {
+		m.recordUpstreamError(fmt.Errorf("error %d", i))
+		clk.Add(time.Minute)
+	}
+
+	status, ok := m.UpstreamStatus()
+	require.True(t, ok)
+	require.Len(t, status.RecentErrors, maxUpstreamErrors)
+	assert.Equal(t, "error 2", status.RecentErrors[0].Message)
+	assert.Equal(t, fmt.Sprintf("error %d", maxUpstreamErrors+1), status.RecentErrors[maxUpstreamErrors-1].Message)
+}
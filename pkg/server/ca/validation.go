@@ -9,6 +9,7 @@ import (
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
 	"github.com/spiffe/spire/pkg/common/pemutil"
+	"github.com/spiffe/spire/pkg/common/x509util"
 )
 
 var (
@@ -42,11 +43,16 @@ func (v X509CAValidator) validateX509CA(x509CA *x509.Certificate, x509Roots, ups
 
 	bundle := x509bundle.FromX509Authorities(v.TrustDomain, x509Roots)
 
+	serialNumber, err := x509util.NewSerialNumber()
+	if err != nil {
+		return err
+	}
+
 	svid, err := signX509SVID(v.TrustDomain, &X509CA{
 		Signer:        v.Signer,
 		Certificate:   x509CA,
 		UpstreamChain: upstreamChain,
-	}, params, x509CA.NotBefore, x509CA.NotAfter, false)
+	}, params, x509CA.NotBefore, x509CA.NotAfter, serialNumber, false)
 	if err != nil {
 		return fmt.Errorf("unable to sign throwaway SVID for X509 CA validation: %w", err)
 	}
@@ -0,0 +1,175 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/common/bundleutil"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/pkg/server/datastore"
+)
+
+const (
+	// DefaultCABackupInterval is how often the CA is backed up by default.
+	DefaultCABackupInterval = 24 * time.Hour
+
+	// DefaultCABackupRetention is how many CA backups are kept by default.
+	DefaultCABackupRetention = 7
+
+	// caBackupPrefix is the prefix of the name of the backup directories. It
+	// is followed by the time of the backup in caBackupTimeFormat, so the
+	// names sort chronologically.
+	caBackupPrefix     = "ca-backup-"
+	caBackupTimeFormat = "20060102T150405Z"
+
+	caBackupJournalFile = "journal.pem"
+	caBackupBundleFile  = "bundle.json"
+)
+
+// CABackupConfig configures periodic backups of the CA journal and the
+// bundle of the trust domain.
+type CABackupConfig struct {
+	// Dir is the directory the backups are written to. Backups are disabled
+	// if empty.
+	Dir string
+
+	// Interval is the time between backups.
+	Interval time.Duration
+
+	// Retention is how many backups are kept. Older backups are removed.
+	Retention int
+}
+
+// caBackup periodically writes the CA journal and the bundle of the trust
+// domain to a directory, so that the CA chain can be recovered if the
+// datastore is lost. Each backup is written to its own directory.
+type caBackup struct {
+	c           CABackupConfig
+	log         logrus.FieldLogger
+	ds          datastore.DataStore
+	td          spiffeid.TrustDomain
+	journalPath string
+	clock       clock.Clock
+}
+
+func newCABackup(c CABackupConfig, log logrus.FieldLogger, ds datastore.DataStore, td spiffeid.TrustDomain, journalPath string, clk clock.Clock) *caBackup {
+	if c.Interval <= 0 {
+		c.Interval = DefaultCABackupInterval
+	}
+	if c.Retention <= 0 {
+		c.Retention = DefaultCABackupRetention
+	}
+	return &caBackup{
+		c:           c,
+		log:         log,
+		ds:          ds,
+		td:          td,
+		journalPath: journalPath,
+		clock:       clk,
+	}
+}
+
+func (b *caBackup) Run(ctx context.Context) error {
+	ticker := b.clock.Ticker(b.c.Interval)
+	defer ticker.Stop()
+
+	for {
+		// Log an error on failure unless we're shutting down
+		if err := b.backup(ctx); err != nil && ctx.Err() == nil {
+			b.log.WithError(err).Error("Failed to back up the CA")
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// backup writes a new backup and removes the backups that are no longer
+// retained. The backup is written to a temporary directory that is renamed
+// once complete, so partial backups are never left behind.
+func (b *caBackup) backup(ctx context.Context) error {
+	bundle, err := b.ds.FetchBundle(ctx, b.td.IDString())
+	if err != nil {
+		return fmt.Errorf("failed to fetch bundle: %w", err)
+	}
+	if bundle == nil {
+		return errors.New("bundle not found")
+	}
+	parsedBundle, err := bundleutil.BundleFromProto(bundle)
+	if err != nil {
+		return fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	bundleBytes, err := bundleutil.Marshal(parsedBundle)
+	if err != nil {
+		return fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+
+	// The journal does not exist until the first CA is prepared
+	journalBytes, err := os.ReadFile(b.journalPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	if err := os.MkdirAll(b.c.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	name := caBackupPrefix + b.clock.Now().UTC().Format(caBackupTimeFormat)
+	tmpDir, err := os.MkdirTemp(b.c.Dir, "."+name)
+	if err != nil {
+		return fmt.Errorf("failed to create temporary backup directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	if err := os.WriteFile(filepath.Join(tmpDir, caBackupBundleFile), bundleBytes, 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	if journalBytes != nil {
+		if err := os.WriteFile(filepath.Join(tmpDir, caBackupJournalFile), journalBytes, 0600); err != nil {
+			return fmt.Errorf("failed to write journal: %w", err)
+		}
+	}
+
+	path := filepath.Join(b.c.Dir, name)
+	if err := os.Rename(tmpDir, path); err != nil {
+		return fmt.Errorf("failed to move backup into place: %w", err)
+	}
+	b.log.WithField(telemetry.Path, path).Info("CA backed up")
+
+	return b.prune()
+}
+
+// prune removes the oldest backups beyond the retention.
+func (b *caBackup) prune() error {
+	dirEntries, err := os.ReadDir(b.c.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var names []string
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() && strings.HasPrefix(dirEntry.Name(), caBackupPrefix) {
+			names = append(names, dirEntry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for len(names) > b.c.Retention {
+		if err := os.RemoveAll(filepath.Join(b.c.Dir, names[0])); err != nil {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+		names = names[1:]
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/common/bundleutil"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/spiffe/spire/test/clock"
+	"github.com/spiffe/spire/test/fakes/fakedatastore"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCABackup(t *testing.T) {
+	ctx := context.Background()
+	log, _ := test.NewNullLogger()
+	clk := clock.NewMock(t)
+	ds := fakedatastore.New(t)
+	td := spiffeid.RequireTrustDomainFromString("domain.test")
+
+	dataDir := t.TempDir()
+	backupDir := filepath.Join(t.TempDir(), "backups")
+	journalPath := filepath.Join(dataDir, "journal.pem")
+
+	backup := newCABackup(CABackupConfig{
+		Dir:       backupDir,
+		Retention: 2,
+	}, log, ds, td, journalPath, clk)
+	require.Equal(t, DefaultCABackupInterval, backup.c.Interval)
+
+	// The bundle is required
+	require.EqualError(t, backup.backup(ctx), "bundle not found")
+
+	rootCA, _ := spiretest.SelfSignCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    clk.Now().Add(-time.Hour),
+		NotAfter:     clk.Now().Add(time.Hour),
+	})
+	_, err := ds.CreateBundle(ctx, &common.Bundle{
+		TrustDomainId: td.IDString(),
+		RootCas:       []*common.Certificate{{DerBytes: rootCA.Raw}},
+	})
+	require.NoError(t, err)
+
+	// The journal does not exist yet, so only the bundle is backed up
+	require.NoError(t, backup.backup(ctx))
+	firstBackup := filepath.Join(backupDir, "ca-backup-"+clk.Now().UTC().Format(caBackupTimeFormat))
+	requireBackupFiles(t, firstBackup, "bundle.json")
+
+	bundleBytes, err := os.ReadFile(filepath.Join(firstBackup, "bundle.json"))
+	require.NoError(t, err)
+	bundle, err := bundleutil.Unmarshal(td, bundleBytes)
+	require.NoError(t, err)
+	require.Len(t, bundle.RootCAs(), 1)
+	require.Equal(t, rootCA.Raw, bundle.RootCAs()[0].Raw)
+
+	require.NoError(t, os.WriteFile(journalPath, []byte("JOURNAL"), 0600))
+
+	clk.Add(time.Hour)
+	require.NoError(t, backup.backup(ctx))
+	secondBackup := filepath.Join(backupDir, "ca-backup-"+clk.Now().UTC().Format(caBackupTimeFormat))
+	requireBackupFiles(t, secondBackup, "bundle.json", "journal.pem")
+
+	journalBytes, err := os.ReadFile(filepath.Join(secondBackup, "journal.pem"))
+	require.NoError(t, err)
+	require.Equal(t, "JOURNAL", string(journalBytes))
+
+	// The oldest backup is removed once the retention is exceeded
+	clk.Add(time.Hour)
+	require.NoError(t, backup.backup(ctx))
+	thirdBackup := filepath.Join(backupDir, "ca-backup-"+clk.Now().UTC().Format(caBackupTimeFormat))
+	requireBackupFiles(t, thirdBackup, "bundle.json", "journal.pem")
+
+	dirEntries, err := os.ReadDir(backupDir)
+	require.NoError(t, err)
+	var names []string
+	for _, dirEntry := range dirEntries {
+		names = append(names, dirEntry.Name())
+	}
+	require.Equal(t, []string{filepath.Base(secondBackup), filepath.Base(thirdBackup)}, names)
+}
+
+func TestCABackupRun(t *testing.T) {
+	log, hook := test.NewNullLogger()
+	clk := clock.NewMock(t)
+	ds := fakedatastore.New(t)
+	td := spiffeid.RequireTrustDomainFromString("domain.test")
+
+	backup := newCABackup(CABackupConfig{
+		Dir: filepath.Join(t.TempDir(), "backups"),
+	}, log, ds, td, filepath.Join(t.TempDir(), "journal.pem"), clk)
+
+	// Run returns nil on shutdown, without logging the failed backup
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	require.NoError(t, backup.Run(ctx))
+	require.Empty(t, hook.AllEntries())
+}
+
+func requireBackupFiles(t *testing.T, dir string, expected ...string) {
+	dirEntries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, dirEntry := range dirEntries {
+		names = append(names, dirEntry.Name())
+	}
+	require.Equal(t, expected, names)
+}
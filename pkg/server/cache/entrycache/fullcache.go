@@ -2,6 +2,8 @@ package entrycache
 
 import (
 	"context"
+	"crypto/sha256"
+	"sort"
 	"sync"
 
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
@@ -88,8 +90,9 @@ type Agent struct {
 }
 
 type FullEntryCache struct {
-	aliases map[spiffeID][]aliasEntry
-	entries map[spiffeID][]*types.Entry
+	aliases    map[spiffeID][]aliasEntry
+	entries    map[spiffeID][]*types.Entry
+	entryCount int
 }
 
 type selectorSet map[Selector]struct{}
@@ -118,8 +121,10 @@ func Build(ctx context.Context, entryIter EntryIterator, agentIter AgentIterator
 	bysel := make(map[Selector][]aliasInfo)
 
 	entries := make(map[spiffeID][]*types.Entry)
+	entryCount := 0
 	for entryIter.Next(ctx) {
 		entry := entryIter.Entry()
+		entryCount++
 		parentID := spiffeIDFromProto(entry.ParentId)
 		if parentID.Path == "/spire/server" {
 			alias := aliasInfo{
@@ -168,11 +173,46 @@ func Build(ctx context.Context, entryIter EntryIterator, agentIter AgentIterator
 	}
 
 	return &FullEntryCache{
-		aliases: aliases,
-		entries: entries,
+		aliases:    aliases,
+		entries:    entries,
+		entryCount: entryCount,
 	}, nil
 }
 
+// EntryCount returns the number of registration entries in the cache.
+func (c *FullEntryCache) EntryCount() int {
+	return c.entryCount
+}
+
+// EntryDigests returns a digest of each registration entry served by the
+// cache, keyed by entry ID. The digest of an entry parented by the server
+// also covers the agents it applies to, so two caches built from the same
+// entries and agent selectors have the same digests. It is computed on
+// demand, which makes it suited to troubleshooting only.
+func (c *FullEntryCache) EntryDigests() map[string][sha256.Size]byte {
+	digests := make(map[string][sha256.Size]byte, c.entryCount)
+	for _, entries := range c.entries {
+		for _, entry := range entries {
+			digests[entry.Id] = entryDigest(entry, nil)
+		}
+	}
+
+	aliasEntries := make(map[string]*types.Entry)
+	aliasAgents := make(map[string][]string)
+	for agentID, aliases := range c.aliases {
+		for _, alias := range aliases {
+			aliasEntries[alias.entry.Id] = alias.entry
+			aliasAgents[alias.entry.Id] = append(aliasAgents[alias.entry.Id], agentID.TrustDomain+agentID.Path)
+		}
+	}
+	for entryID, entry := range aliasEntries {
+		agentIDs := aliasAgents[entryID]
+		sort.Strings(agentIDs)
+		digests[entryID] = entryDigest(entry, agentIDs)
+	}
+	return digests
+}
+
 // GetAuthorizedEntries gets all authorized registration entries for a given Agent SPIFFE ID.
 func (c *FullEntryCache) GetAuthorizedEntries(agentID spiffeid.ID) []*types.Entry {
 	seen := allocSeenSet()
@@ -272,6 +312,31 @@ func isSubset(sub, whole selectorSet) bool {
 	return true
 }
 
+// entryDigest hashes the entry, with its selectors sorted so the order in
+// which the data source returns them does not matter, along with the IDs of
+// the agents a node alias applies to.
+func entryDigest(entry *types.Entry, agentIDs []string) [sha256.Size]byte {
+	entry = proto.Clone(entry).(*types.Entry)
+	sort.Slice(entry.Selectors, func(i, j int) bool {
+		if entry.Selectors[i].Type != entry.Selectors[j].Type {
+			return entry.Selectors[i].Type < entry.Selectors[j].Type
+		}
+		return entry.Selectors[i].Value < entry.Selectors[j].Value
+	})
+
+	// Marshaling does not fail for the entries built from the data source
+	data, _ := proto.MarshalOptions{Deterministic: true}.Marshal(entry)
+	h := sha256.New()
+	_, _ = h.Write(data)
+	for _, agentID := range agentIDs {
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(agentID))
+	}
+	var digest [sha256.Size]byte
+	copy(digest[:], h.Sum(nil))
+	return digest
+}
+
 func cloneEntries(entries []*types.Entry) []*types.Entry {
 	if len(entries) == 0 {
 		return entries
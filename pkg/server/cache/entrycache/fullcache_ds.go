@@ -51,6 +51,12 @@ func (it *entryIteratorDS) Next(ctx context.Context) bool {
 
 		resp.Entries = it.filterEntries(resp.Entries)
 
+		resp.Entries, err = it.expandSelectorSets(ctx, resp.Entries)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
 		it.next = 0
 		it.entries, err = api.RegistrationEntriesToProto(resp.Entries)
 		if err != nil {
@@ -82,6 +88,65 @@ func (it *entryIteratorDS) filterEntries(in []*common.RegistrationEntry) []*comm
 	return out
 }
 
+// expandSelectorSets replaces the selectors that reference a selector set
+// with the selectors of the set. Entries referencing a selector set that does
+// not exist are dropped, since matching them with the remaining selectors
+// would widen the set of workloads they apply to.
+func (it *entryIteratorDS) expandSelectorSets(ctx context.Context, in []*common.RegistrationEntry) ([]*common.RegistrationEntry, error) {
+	var selectorSets map[string][]*common.Selector
+	out := make([]*common.RegistrationEntry, 0, len(in))
+	for _, entry := range in {
+		if !referencesSelectorSet(entry) {
+			out = append(out, entry)
+			continue
+		}
+
+		if selectorSets == nil {
+			sets, err := it.ds.ListSelectorSets(ctx)
+			if err != nil {
+				return nil, err
+			}
+			selectorSets = make(map[string][]*common.Selector, len(sets))
+			for _, set := range sets {
+				selectorSets[set.Name] = set.Selectors
+			}
+		}
+
+		selectors, ok := resolveSelectorSets(entry.Selectors, selectorSets)
+		if !ok {
+			continue
+		}
+		entry.Selectors = selectors
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+func resolveSelectorSets(in []*common.Selector, selectorSets map[string][]*common.Selector) ([]*common.Selector, bool) {
+	out := make([]*common.Selector, 0, len(in))
+	for _, selector := range in {
+		if selector.Type != datastore.SelectorSetType {
+			out = append(out, selector)
+			continue
+		}
+		setSelectors, ok := selectorSets[selector.Value]
+		if !ok {
+			return nil, false
+		}
+		out = append(out, setSelectors...)
+	}
+	return out, true
+}
+
+func referencesSelectorSet(entry *common.RegistrationEntry) bool {
+	for _, selector := range entry.Selectors {
+		if selector.Type == datastore.SelectorSetType {
+			return true
+		}
+	}
+	return false
+}
+
 func (it *entryIteratorDS) Entry() *types.Entry {
 	return it.entries[it.next-1]
 }
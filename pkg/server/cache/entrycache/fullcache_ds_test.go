@@ -78,6 +78,77 @@ func TestEntryIteratorDS(t *testing.T) {
 	})
 }
 
+func TestEntryIteratorDSExpandsSelectorSets(t *testing.T) {
+	ds := fakedatastore.New(t)
+	ctx := context.Background()
+
+	_, err := ds.CreateSelectorSet(ctx, &datastore.SelectorSet{
+		Name: "base-images",
+		Selectors: []*common.Selector{
+			{Type: "docker", Value: "image_id:base1"},
+			{Type: "docker", Value: "image_id:base2"},
+		},
+	})
+	require.NoError(t, err)
+
+	referencing := createRegistrationEntry(ctx, t, ds, &common.RegistrationEntry{
+		ParentId: "spiffe://example.org/parent",
+		SpiffeId: "spiffe://example.org/referencing",
+		Selectors: []*common.Selector{
+			{Type: datastore.SelectorSetType, Value: "base-images"},
+			{Type: "unix", Value: "uid:1000"},
+		},
+	})
+	_, err = ds.CreateSelectorSet(ctx, &datastore.SelectorSet{
+		Name:      "deleted",
+		Selectors: []*common.Selector{{Type: "docker", Value: "image_id:deleted"}},
+	})
+	require.NoError(t, err)
+	createRegistrationEntry(ctx, t, ds, &common.RegistrationEntry{
+		ParentId: "spiffe://example.org/parent",
+		SpiffeId: "spiffe://example.org/dangling",
+		Selectors: []*common.Selector{
+			{Type: datastore.SelectorSetType, Value: "deleted"},
+		},
+	})
+
+	// Entries can only be written with references to existing sets, so the
+	// set is hidden from the iterator as if it had been deleted concurrently
+	it := makeEntryIteratorDS(hiddenSelectorSetDataStore{DataStore: ds, hidden: "deleted"})
+	require.True(t, it.Next(ctx))
+	require.NoError(t, it.Err())
+	entry := it.Entry()
+	assert.Equal(t, referencing.EntryId, entry.Id)
+	assert.ElementsMatch(t, []*types.Selector{
+		{Type: "docker", Value: "image_id:base1"},
+		{Type: "docker", Value: "image_id:base2"},
+		{Type: "unix", Value: "uid:1000"},
+	}, entry.Selectors)
+
+	// The entry referencing an unknown selector set is dropped
+	assert.False(t, it.Next(ctx))
+	assert.NoError(t, it.Err())
+}
+
+type hiddenSelectorSetDataStore struct {
+	datastore.DataStore
+	hidden string
+}
+
+func (ds hiddenSelectorSetDataStore) ListSelectorSets(ctx context.Context) ([]*datastore.SelectorSet, error) {
+	sets, err := ds.DataStore.ListSelectorSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var visible []*datastore.SelectorSet
+	for _, set := range sets {
+		if set.Name != ds.hidden {
+			visible = append(visible, set)
+		}
+	}
+	return visible, nil
+}
+
 func TestAgentIteratorDS(t *testing.T) {
 	ds := fakedatastore.New(t)
 	ctx := context.Background()
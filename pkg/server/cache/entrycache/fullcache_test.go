@@ -2,6 +2,7 @@ package entrycache
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -127,6 +128,7 @@ func TestCache(t *testing.T) {
 
 	cache, err := BuildFromDataStore(context.Background(), ds)
 	assert.NoError(t, err)
+	assert.Equal(t, numEntries, cache.EntryCount())
 
 	actual := cache.GetAuthorizedEntries(spiffeid.RequireFromString(rootID))
 
@@ -257,6 +259,73 @@ func TestFullCacheNodeAliasing(t *testing.T) {
 	assertAuthorizedEntries(agentIDs[2], workloadEntries[2])
 }
 
+func TestEntryDigests(t *testing.T) {
+	ds := fakedatastore.New(t)
+	ctx := context.Background()
+
+	const serverID = "spiffe://example.org/spire/server"
+	agentID := "spiffe://example.org/spire/agent/agent1"
+	agent2ID := "spiffe://example.org/spire/agent/agent2"
+	s1 := &common.Selector{Type: "s", Value: "1"}
+	s2 := &common.Selector{Type: "s", Value: "2"}
+
+	alias := createRegistrationEntry(ctx, t, ds, &common.RegistrationEntry{
+		ParentId:  serverID,
+		SpiffeId:  "spiffe://example.org/alias",
+		Selectors: []*common.Selector{s1},
+	})
+	workload := createRegistrationEntry(ctx, t, ds, &common.RegistrationEntry{
+		ParentId:  alias.SpiffeId,
+		SpiffeId:  "spiffe://example.org/workload",
+		Selectors: []*common.Selector{{Type: "unix", Value: "uid:1000"}, {Type: "unix", Value: "gid:1000"}},
+	})
+	createAttestedNode(t, ds, &common.AttestedNode{
+		SpiffeId:            agentID,
+		AttestationDataType: testNodeAttestor,
+		CertSerialNumber:    "1",
+		CertNotAfter:        time.Now().Add(24 * time.Hour).Unix(),
+	})
+	createAttestedNode(t, ds, &common.AttestedNode{
+		SpiffeId:            agent2ID,
+		AttestationDataType: testNodeAttestor,
+		CertSerialNumber:    "2",
+		CertNotAfter:        time.Now().Add(24 * time.Hour).Unix(),
+	})
+	setNodeSelectors(ctx, t, ds, agentID, s1)
+	setNodeSelectors(ctx, t, ds, agent2ID, s2)
+
+	build := func() map[string][sha256.Size]byte {
+		cache, err := BuildFromDataStore(ctx, ds)
+		require.NoError(t, err)
+		return cache.EntryDigests()
+	}
+
+	// Caches built from the same data have the same digests
+	digests := build()
+	require.Len(t, digests, 2)
+	require.Equal(t, digests, build())
+
+	// Updating an entry only changes its own digest
+	workload.Selectors = []*common.Selector{{Type: "unix", Value: "uid:1001"}}
+	_, err := ds.UpdateRegistrationEntry(ctx, workload, nil)
+	require.NoError(t, err)
+	updated := build()
+	require.Equal(t, digests[alias.EntryId], updated[alias.EntryId])
+	require.NotEqual(t, digests[workload.EntryId], updated[workload.EntryId])
+
+	// Changing the agents a node alias applies to changes its digest, even
+	// though the entry itself is unchanged
+	setNodeSelectors(ctx, t, ds, agent2ID, s1, s2)
+	realiased := build()
+	require.NotEqual(t, updated[alias.EntryId], realiased[alias.EntryId])
+	require.Equal(t, updated[workload.EntryId], realiased[workload.EntryId])
+
+	// Node aliases that apply to no agent are not served
+	setNodeSelectors(ctx, t, ds, agentID, s2)
+	setNodeSelectors(ctx, t, ds, agent2ID, s2)
+	require.NotContains(t, build(), alias.EntryId)
+}
+
 func TestFullCacheExcludesNodeSelectorMappedEntriesForExpiredAgents(t *testing.T) {
 	// This test verifies that the cache contains no workloads parented to alias entries
 	// that are only associated with an expired agent.
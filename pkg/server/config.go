@@ -8,13 +8,20 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/common/agentpathtemplate"
 	common "github.com/spiffe/spire/pkg/common/catalog"
 	"github.com/spiffe/spire/pkg/common/health"
+	"github.com/spiffe/spire/pkg/common/log"
 	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/pkg/common/x509util"
+	"github.com/spiffe/spire/pkg/server/api"
 	"github.com/spiffe/spire/pkg/server/authpolicy"
 	bundle_client "github.com/spiffe/spire/pkg/server/bundle/client"
+	"github.com/spiffe/spire/pkg/server/ca"
 	"github.com/spiffe/spire/pkg/server/endpoints"
 	"github.com/spiffe/spire/pkg/server/endpoints/bundle"
+	"github.com/spiffe/spire/pkg/server/endpoints/ocsp"
+	"github.com/spiffe/spire/pkg/server/entryadmission"
 	"github.com/spiffe/spire/pkg/server/plugin/keymanager"
 )
 
@@ -27,6 +34,10 @@ type Config struct {
 	// LogReopener facilitates handling a signal to rotate log file.
 	LogReopener func(context.Context) error
 
+	// EventBuffer, if set, keeps the recent significant log entries, which
+	// are served by the admin API
+	EventBuffer *log.EventBuffer
+
 	// If true enables audit logs
 	AuditLogEnabled bool
 
@@ -105,6 +116,88 @@ type Config struct {
 	// OmitX509SVIDUID, if true, omits the X.500 Unique Identifier from being
 	// calculated and added to the Subject DN on X509-SVIDs.
 	OmitX509SVIDUID bool
+
+	// RESTGatewayAddr, if set, is the address the REST gateway to the server
+	// APIs listens on.
+	RESTGatewayAddr *net.TCPAddr
+
+	// OCSPResponder, if set, configures the experimental OCSP responder for
+	// the X509-SVIDs signed by the server CA. It relies on the issuance
+	// records enabled by RecordX509Issuances.
+	OCSPResponder *ocsp.ResponderConfig
+
+	// MaxDownstreamDepth limits how many levels of downstream CAs may be
+	// chained below this server. Zero means unlimited.
+	MaxDownstreamDepth int
+
+	// CASerialNumberPolicy determines how the serial numbers of the X509
+	// certificates signed by the server CA are generated.
+	CASerialNumberPolicy x509util.SerialNumberPolicy
+
+	// RecordX509Issuances, if true, persists a record of every X509
+	// certificate signed by the server CA in the datastore.
+	RecordX509Issuances bool
+
+	// IssuanceRecordRetention is how long the issuance records are kept in
+	// the datastore after the certificates expire. If zero, a default
+	// retention is used.
+	IssuanceRecordRetention time.Duration
+
+	// MaxAgentClockSkew, if greater than zero, is the maximum skew between
+	// the clock of an agent and the server clock for the agent to be
+	// attested.
+	MaxAgentClockSkew time.Duration
+
+	// AgentSelectorWarningThreshold is the number of selectors an agent can
+	// be attested with before a warning is logged. If zero, a default
+	// threshold is used.
+	AgentSelectorWarningThreshold int
+
+	// AgentPathTemplates overrides, per node attestor, the path of the agent
+	// ID produced by the attestor.
+	AgentPathTemplates map[string]*agentpathtemplate.Template
+
+	// ApprovalRequiredAttestorTypes are the node attestors whose new agents
+	// must be approved by an administrator before they are issued an SVID.
+	ApprovalRequiredAttestorTypes []string
+
+	// CSRKeyPolicy restricts the public keys of the CSRs signed by the
+	// server APIs. The zero value allows any key.
+	CSRKeyPolicy api.KeyPolicy
+
+	// EntryAdmissionWebhook, if set, configures the webhook that reviews
+	// entries before they are created or updated.
+	EntryAdmissionWebhook *entryadmission.Config
+
+	// AttestationEventRetention is how long successful node attestations are
+	// kept in the datastore. If zero, a default retention is used.
+	AttestationEventRetention time.Duration
+
+	// DrainDelay is the time the server keeps accepting connections after
+	// being drained, while it is reported as not ready.
+	DrainDelay time.Duration
+
+	// DrainTimeout is the time in-flight RPCs are given to finish when the
+	// server is drained.
+	DrainTimeout time.Duration
+
+	// CAStagedRollout configures the staged rollout of newly activated X509
+	// CAs and JWT keys across agents.
+	CAStagedRollout ca.StagedRolloutConfig
+
+	// CABackup configures periodic backups of the CA journal and the bundle
+	// of the trust domain. Backups are disabled if its Dir is empty.
+	CABackup CABackupConfig
+
+	// AgentEviction configures the eviction of agents when the number of
+	// attested agents exceeds a maximum. Eviction is disabled if its
+	// MaxAgents is zero.
+	AgentEviction AgentEvictionConfig
+
+	// EntryMirror configures the mirroring of entries to the server of a
+	// peer trust domain. Mirroring is disabled if its PeerTrustDomain is
+	// zero.
+	EntryMirror EntryMirrorConfig
 }
 
 type ExperimentalConfig struct {
@@ -121,5 +214,6 @@ type FederationConfig struct {
 func New(config Config) *Server {
 	return &Server{
 		config: config,
+		drain:  make(chan struct{}),
 	}
 }
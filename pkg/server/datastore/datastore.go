@@ -24,6 +24,7 @@ type DataStore interface {
 	UpdateBundle(context.Context, *common.Bundle, *common.BundleMask) (*common.Bundle, error)
 
 	// Entries
+	CompareAndUpdateRegistrationEntry(ctx context.Context, entry *common.RegistrationEntry, mask *common.RegistrationEntryMask, expectedRevision int64) (*common.RegistrationEntry, error)
 	CountRegistrationEntries(context.Context) (int32, error)
 	CreateRegistrationEntry(context.Context, *common.RegistrationEntry) (*common.RegistrationEntry, error)
 	CreateOrReturnRegistrationEntry(context.Context, *common.RegistrationEntry) (*common.RegistrationEntry, bool, error)
@@ -41,6 +42,28 @@ type DataStore interface {
 	ListAttestedNodes(context.Context, *ListAttestedNodesRequest) (*ListAttestedNodesResponse, error)
 	UpdateAttestedNode(context.Context, *common.AttestedNode, *common.AttestedNodeMask) (*common.AttestedNode, error)
 
+	// ACME cache entries
+	DeleteACMECacheEntry(ctx context.Context, key string) error
+	FetchACMECacheEntry(ctx context.Context, key string) ([]byte, error)
+	SetACMECacheEntry(ctx context.Context, key string, data []byte) error
+
+	// Attestation events
+	CreateAttestationEvent(context.Context, *AttestationEvent) error
+	ListAttestationEvents(context.Context, *ListAttestationEventsRequest) (*ListAttestationEventsResponse, error)
+	PruneAttestationEvents(ctx context.Context, succeededBefore, failedBefore time.Time) error
+
+	// Mirrored entries
+	DeleteMirroredEntry(ctx context.Context, entryID string) error
+	ListMirroredEntries(context.Context) ([]*MirroredEntry, error)
+	SetMirroredEntry(context.Context, *MirroredEntry) error
+
+	// Pending agents
+	ApprovePendingAgent(ctx context.Context, spiffeID string) (*PendingAgent, error)
+	DeletePendingAgent(ctx context.Context, spiffeID string) error
+	FetchPendingAgent(ctx context.Context, spiffeID string) (*PendingAgent, error)
+	ListPendingAgents(context.Context, *ListPendingAgentsRequest) (*ListPendingAgentsResponse, error)
+	SetPendingAgent(context.Context, *PendingAgent) (*PendingAgent, error)
+
 	// Node selectors
 	GetNodeSelectors(ctx context.Context, spiffeID string, dataConsistency DataConsistency) ([]*common.Selector, error)
 	ListNodeSelectors(context.Context, *ListNodeSelectorsRequest) (*ListNodeSelectorsResponse, error)
@@ -58,6 +81,18 @@ type DataStore interface {
 	ListFederationRelationships(context.Context, *ListFederationRelationshipsRequest) (*ListFederationRelationshipsResponse, error)
 	DeleteFederationRelationship(context.Context, spiffeid.TrustDomain) error
 	UpdateFederationRelationship(context.Context, *FederationRelationship, *types.FederationRelationshipMask) (*FederationRelationship, error)
+
+	// Issuance records
+	CreateIssuanceRecord(context.Context, *IssuanceRecord) error
+	ListIssuanceRecords(context.Context, *ListIssuanceRecordsRequest) (*ListIssuanceRecordsResponse, error)
+	PruneIssuanceRecords(ctx context.Context, expiredBefore time.Time) error
+
+	// Selector sets
+	CreateSelectorSet(context.Context, *SelectorSet) (*SelectorSet, error)
+	DeleteSelectorSet(ctx context.Context, name string) error
+	FetchSelectorSet(ctx context.Context, name string) (*SelectorSet, error)
+	ListSelectorSets(context.Context) ([]*SelectorSet, error)
+	UpdateSelectorSet(context.Context, *SelectorSet) (*SelectorSet, error)
 }
 
 // DataConsistency indicates the required data consistency for a read operation.
@@ -184,6 +219,41 @@ type ListFederationRelationshipsResponse struct {
 	Pagination              *Pagination
 }
 
+// ListAttestationEventsRequest lists attestation events, most recent first
+type ListAttestationEventsRequest struct {
+	BySpiffeID string
+	// FailedOnly, if true, only lists failed attestations
+	FailedOnly bool
+	// Limit, when greater than zero, is the maximum number of events returned
+	Limit int32
+}
+
+type ListAttestationEventsResponse struct {
+	Events []*AttestationEvent
+}
+
+// ListPendingAgentsRequest lists pending agents, oldest first
+type ListPendingAgentsRequest struct {
+	ByAttestationType string
+	ByApproved        *bool
+}
+
+type ListPendingAgentsResponse struct {
+	Agents []*PendingAgent
+}
+
+type ListIssuanceRecordsRequest struct {
+	BySerialNumber string
+	BySpiffeID     string
+	ByEntryID      string
+	Pagination     *Pagination
+}
+
+type ListIssuanceRecordsResponse struct {
+	Records    []*IssuanceRecord
+	Pagination *Pagination
+}
+
 type BundleEndpointType string
 
 const (
@@ -200,3 +270,105 @@ type FederationRelationship struct {
 	// Fields only used for 'https_spiffe' bundle endpoint profile
 	EndpointSPIFFEID spiffeid.ID
 }
+
+// AttestationEvent records an attestation of an agent, successful or not
+type AttestationEvent struct {
+	// SpiffeID is the SPIFFE ID of the agent. It can be empty for failed
+	// attestations, when the attestation failed before the agent ID was
+	// known.
+	SpiffeID string
+
+	// AttestationType is the type of the node attestor used
+	AttestationType string
+
+	// SerialNumber is the serial number of the SVID issued to the agent, in
+	// decimal
+	SerialNumber string
+
+	// CanReattest is true if the agent can reattest to renew its SVID
+	CanReattest bool
+
+	// Reattestation is true if the agent was already attested before
+	Reattestation bool
+
+	// AttestedAt is the time of the attestation
+	AttestedAt time.Time
+
+	// Error is the reason the attestation failed. It is empty for
+	// successful attestations.
+	Error string
+
+	// Selectors are the selectors produced by the node attestor. They are
+	// only recorded for failed attestations, since the selectors of
+	// attested agents are stored as node selectors.
+	Selectors []*common.Selector
+}
+
+// MirroredEntry records the entry of the server of a peer trust domain a
+// registration entry was mirrored to
+type MirroredEntry struct {
+	// EntryID is the ID of the mirrored registration entry
+	EntryID string
+
+	// PeerEntryID is the ID of the entry on the peer server
+	PeerEntryID string
+
+	// RevisionNumber is the revision of the registration entry that was
+	// last mirrored
+	RevisionNumber int64
+
+	// Mirror is the entry last sent to the peer server
+	Mirror *types.Entry
+}
+
+// PendingAgent is an agent that attested successfully but has to be approved
+// by an administrator before it is issued an SVID
+type PendingAgent struct {
+	// SpiffeID is the SPIFFE ID of the agent
+	SpiffeID string
+
+	// AttestationType is the type of the node attestor used
+	AttestationType string
+
+	// Approved is true once the agent has been approved. The agent is
+	// issued an SVID the next time it attests.
+	Approved bool
+
+	// RequestedAt is the time of the first attestation of the agent
+	RequestedAt time.Time
+
+	// LastAttemptAt is the time of the last attestation of the agent
+	LastAttemptAt time.Time
+}
+
+// IssuanceRecord records an X.509 certificate issued by the server CA
+type IssuanceRecord struct {
+	// SerialNumber is the serial number of the certificate, in decimal
+	SerialNumber string
+
+	// SpiffeID is the SPIFFE ID of the certificate
+	SpiffeID string
+
+	// EntryID is the ID of the registration entry the certificate was
+	// issued for, if any
+	EntryID string
+
+	// ExpiresAt is the expiration time of the certificate
+	ExpiresAt time.Time
+
+	// RevokedAt is when the registration entry the certificate was issued
+	// for was deleted, if it was
+	RevokedAt time.Time
+}
+
+// SelectorSetType is the selector type used by registration entries to
+// reference a selector set. The value of the selector is the name of the set.
+const SelectorSetType = "selector_set"
+
+// SelectorSet is a named group of selectors that registration entries can
+// reference instead of repeating the selectors. Changing the selectors of a
+// set changes the selectors of every entry that references it.
+type SelectorSet struct {
+	Name      string
+	Selectors []*common.Selector
+}
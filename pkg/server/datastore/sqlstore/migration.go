@@ -138,11 +138,25 @@ import (
 // | v1.4.0  |        |                                                                           |
 // | v1.4.1  |        |                                                                           |
 // | v1.4.2  |        |                                                                           |
+// |---------|--------|---------------------------------------------------------------------------|
+// | v1.4.3  | 20     | Replaced selectors type/value index with a covering index on entry ID     |
+// |         |--------|---------------------------------------------------------------------------|
+// |         | 21     | Added selector_sets and selector_set_selectors tables                     |
+// |         |--------|---------------------------------------------------------------------------|
+// |         | 22     | Added issuance_records table                                              |
+// |         |--------|---------------------------------------------------------------------------|
+// |         | 23     | Added attestation_events table                                            |
+// |         |--------|---------------------------------------------------------------------------|
+// |         | 24     | Added acme_cache_entries table                                            |
+// |         |--------|---------------------------------------------------------------------------|
+// |         | 25     | Added pending_agents table                                                |
+// |         |--------|---------------------------------------------------------------------------|
+// |         | 26     | Added mirrored_entries table                                              |
 // ================================================================================================
 
 const (
 	// the latest schema version of the database in the code
-	latestSchemaVersion = 19
+	latestSchemaVersion = 26
 
 	// lastMinorReleaseSchemaVersion is the schema version supported by the
 	// last minor release. When the migrations are opportunistically pruned
@@ -304,6 +318,13 @@ func initDB(db *gorm.DB, dbType string, log logrus.FieldLogger) (err error) {
 		&Migration{},
 		&DNSName{},
 		&FederatedTrustDomain{},
+		&SelectorSet{},
+		&SelectorSetSelector{},
+		&IssuanceRecord{},
+		&AttestationEvent{},
+		&ACMECacheEntry{},
+		&PendingAgent{},
+		&MirroredEntry{},
 	}
 
 	if err := tableOptionsForDialect(tx, dbType).AutoMigrate(tables...).Error; err != nil {
@@ -323,6 +344,10 @@ func initDB(db *gorm.DB, dbType string, log logrus.FieldLogger) (err error) {
 		return err
 	}
 
+	if err := addSelectorsTypeValueEntryIndex(tx); err != nil {
+		return err
+	}
+
 	if err := tx.Commit().Error; err != nil {
 		return sqlError.Wrap(err)
 	}
@@ -362,6 +387,27 @@ func migrateVersion(tx *gorm.DB, currVersion int, log logrus.FieldLogger) (versi
 	case 18:
 		// DEPRECATED: remove this migration in 1.5.0
 		err = migrateToV19(tx)
+	case 19:
+		// DEPRECATED: remove this migration in 1.6.0
+		err = migrateToV20(tx)
+	case 20:
+		// DEPRECATED: remove this migration in 1.6.0
+		err = migrateToV21(tx)
+	case 21:
+		// DEPRECATED: remove this migration in 1.6.0
+		err = migrateToV22(tx)
+	case 22:
+		// DEPRECATED: remove this migration in 1.6.0
+		err = migrateToV23(tx)
+	case 23:
+		// DEPRECATED: remove this migration in 1.6.0
+		err = migrateToV24(tx)
+	case 24:
+		// DEPRECATED: remove this migration in 1.6.0
+		err = migrateToV25(tx)
+	case 25:
+		// DEPRECATED: remove this migration in 1.6.0
+		err = migrateToV26(tx)
 	default:
 		err = sqlError.New("no migration support for unknown schema version %d", currVersion)
 	}
@@ -379,6 +425,55 @@ func migrateToV19(tx *gorm.DB) error {
 	return nil
 }
 
+func migrateToV20(tx *gorm.DB) error {
+	if err := tx.Model(&Selector{}).RemoveIndex("idx_selectors_type_value").Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return addSelectorsTypeValueEntryIndex(tx)
+}
+
+func migrateToV21(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&SelectorSet{}, &SelectorSetSelector{}).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func migrateToV22(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&IssuanceRecord{}).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func migrateToV23(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&AttestationEvent{}).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func migrateToV24(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&ACMECacheEntry{}).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func migrateToV25(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&PendingAgent{}).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func migrateToV26(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&MirroredEntry{}).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
 func addFederatedRegistrationEntriesRegisteredEntryIDIndex(tx *gorm.DB) error {
 	// GORM creates the federated_registration_entries implicitly with a primary
 	// key tuple (bundle_id, registered_entry_id). Unfortunately, MySQL5 does
@@ -391,3 +486,16 @@ func addFederatedRegistrationEntriesRegisteredEntryIDIndex(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+func addSelectorsTypeValueEntryIndex(tx *gorm.DB) error {
+	// Entries are looked up by selector using the type and value, and only
+	// the registered entry ID is needed from the matching rows. Including it
+	// in the index lets the databases answer those lookups from the index
+	// alone. GORM orders the columns of composite indexes by field order,
+	// which would put the registered entry ID first, so the index has to be
+	// created manually.
+	if err := tx.Model(&Selector{}).AddIndex("idx_selectors_type_value_entry", "type", "value", "registered_entry_id").Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
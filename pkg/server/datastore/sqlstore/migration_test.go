@@ -51,6 +51,310 @@ var (
 			CREATE INDEX idx_federated_registration_entries_registered_entry_id ON "federated_registration_entries"(registered_entry_id) ;
 			COMMIT;
 			`,
+		19: `
+			PRAGMA foreign_keys=OFF;
+			BEGIN TRANSACTION;
+			CREATE TABLE IF NOT EXISTS "federated_registration_entries" ("bundle_id" integer,"registered_entry_id" integer, PRIMARY KEY ("bundle_id","registered_entry_id"));
+			CREATE TABLE IF NOT EXISTS "bundles" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"data" blob );
+			CREATE TABLE IF NOT EXISTS "attested_node_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"data_type" varchar(255),"serial_number" varchar(255),"expires_at" datetime,"new_serial_number" varchar(255),"new_expires_at" datetime , "can_reattest" bool);
+			CREATE TABLE IF NOT EXISTS "node_resolver_map_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"type" varchar(255),"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "registered_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"entry_id" varchar(255),"spiffe_id" varchar(255),"parent_id" varchar(255),"ttl" integer,"admin" bool,"downstream" bool,"expiry" bigint,"revision_number" bigint,"store_svid" bool , "hint" varchar(255), "x509_svid_ttl" integer, "jwt_svid_ttl" integer);
+			INSERT INTO registered_entries VALUES(1,'2022-09-20 14:22:10.582617+00:00','2022-09-20 14:22:10.582617+00:00','0e7ff54e-d7a8-4eec-a09e-3c5dd19a4e1b','spiffe://example.org/workload','spiffe://example.org/agent',0,0,0,0,0,0,'',0,0);
+			CREATE TABLE IF NOT EXISTS "join_tokens" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"token" varchar(255),"expiry" bigint );
+			CREATE TABLE IF NOT EXISTS "selectors" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"type" varchar(255),"value" varchar(255) );
+			INSERT INTO selectors VALUES(1,'2022-09-20 14:22:10.583041+00:00','2022-09-20 14:22:10.583041+00:00',1,'unix','uid:1000');
+			CREATE TABLE IF NOT EXISTS "migrations" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"version" integer,"code_version" varchar(255) );
+			INSERT INTO migrations VALUES(1,'2022-09-20 14:21:54.417127+00:00','2022-09-20 14:21:54.417127+00:00',19,'1.4.2');
+			CREATE TABLE IF NOT EXISTS "dns_names" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "federated_trust_domains" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"bundle_endpoint_url" varchar(255),"bundle_endpoint_profile" varchar(255),"endpoint_spiffe_id" varchar(255),"implicit" bool );
+			DELETE FROM sqlite_sequence;
+			INSERT INTO sqlite_sequence VALUES('migrations',1);
+			INSERT INTO sqlite_sequence VALUES('registered_entries',1);
+			INSERT INTO sqlite_sequence VALUES('selectors',1);
+			CREATE UNIQUE INDEX uix_bundles_trust_domain ON "bundles"(trust_domain) ;
+			CREATE INDEX idx_attested_node_entries_expires_at ON "attested_node_entries"(expires_at) ;
+			CREATE UNIQUE INDEX uix_attested_node_entries_spiffe_id ON "attested_node_entries"(spiffe_id) ;
+			CREATE UNIQUE INDEX idx_node_resolver_map ON "node_resolver_map_entries"(spiffe_id, "type", "value") ;
+			CREATE INDEX idx_registered_entries_spiffe_id ON "registered_entries"(spiffe_id) ;
+			CREATE INDEX idx_registered_entries_parent_id ON "registered_entries"(parent_id) ;
+			CREATE INDEX idx_registered_entries_expiry ON "registered_entries"("expiry") ;
+			CREATE UNIQUE INDEX uix_registered_entries_entry_id ON "registered_entries"(entry_id) ;
+			CREATE UNIQUE INDEX uix_join_tokens_token ON "join_tokens"("token") ;
+			CREATE INDEX idx_selectors_type_value ON "selectors"("type", "value") ;
+			CREATE UNIQUE INDEX idx_selector_entry ON "selectors"(registered_entry_id, "type", "value") ;
+			CREATE UNIQUE INDEX idx_dns_entry ON "dns_names"(registered_entry_id, "value") ;
+			CREATE UNIQUE INDEX uix_federated_trust_domains_trust_domain ON "federated_trust_domains"(trust_domain) ;
+			CREATE INDEX idx_federated_registration_entries_registered_entry_id ON "federated_registration_entries"(registered_entry_id) ;
+			COMMIT;
+			`,
+		20: `
+			PRAGMA foreign_keys=OFF;
+			BEGIN TRANSACTION;
+			CREATE TABLE IF NOT EXISTS "federated_registration_entries" ("bundle_id" integer,"registered_entry_id" integer, PRIMARY KEY ("bundle_id","registered_entry_id"));
+			CREATE TABLE IF NOT EXISTS "bundles" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"data" blob );
+			CREATE TABLE IF NOT EXISTS "attested_node_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"data_type" varchar(255),"serial_number" varchar(255),"expires_at" datetime,"new_serial_number" varchar(255),"new_expires_at" datetime , "can_reattest" bool);
+			CREATE TABLE IF NOT EXISTS "node_resolver_map_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"type" varchar(255),"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "registered_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"entry_id" varchar(255),"spiffe_id" varchar(255),"parent_id" varchar(255),"ttl" integer,"admin" bool,"downstream" bool,"expiry" bigint,"revision_number" bigint,"store_svid" bool , "hint" varchar(255), "x509_svid_ttl" integer, "jwt_svid_ttl" integer);
+			INSERT INTO registered_entries VALUES(1,'2022-09-20 14:22:10.582617+00:00','2022-09-20 14:22:10.582617+00:00','0e7ff54e-d7a8-4eec-a09e-3c5dd19a4e1b','spiffe://example.org/workload','spiffe://example.org/agent',0,0,0,0,0,0,'',0,0);
+			CREATE TABLE IF NOT EXISTS "join_tokens" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"token" varchar(255),"expiry" bigint );
+			CREATE TABLE IF NOT EXISTS "selectors" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"type" varchar(255),"value" varchar(255) );
+			INSERT INTO selectors VALUES(1,'2022-09-20 14:22:10.583041+00:00','2022-09-20 14:22:10.583041+00:00',1,'unix','uid:1000');
+			CREATE TABLE IF NOT EXISTS "migrations" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"version" integer,"code_version" varchar(255) );
+			INSERT INTO migrations VALUES(1,'2022-09-20 14:21:54.417127+00:00','2022-09-20 14:21:54.417127+00:00',20,'1.4.3');
+			CREATE TABLE IF NOT EXISTS "dns_names" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "federated_trust_domains" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"bundle_endpoint_url" varchar(255),"bundle_endpoint_profile" varchar(255),"endpoint_spiffe_id" varchar(255),"implicit" bool );
+			DELETE FROM sqlite_sequence;
+			INSERT INTO sqlite_sequence VALUES('migrations',1);
+			INSERT INTO sqlite_sequence VALUES('registered_entries',1);
+			INSERT INTO sqlite_sequence VALUES('selectors',1);
+			CREATE UNIQUE INDEX uix_bundles_trust_domain ON "bundles"(trust_domain) ;
+			CREATE INDEX idx_attested_node_entries_expires_at ON "attested_node_entries"(expires_at) ;
+			CREATE UNIQUE INDEX uix_attested_node_entries_spiffe_id ON "attested_node_entries"(spiffe_id) ;
+			CREATE UNIQUE INDEX idx_node_resolver_map ON "node_resolver_map_entries"(spiffe_id, "type", "value") ;
+			CREATE INDEX idx_registered_entries_spiffe_id ON "registered_entries"(spiffe_id) ;
+			CREATE INDEX idx_registered_entries_parent_id ON "registered_entries"(parent_id) ;
+			CREATE INDEX idx_registered_entries_expiry ON "registered_entries"("expiry") ;
+			CREATE UNIQUE INDEX uix_registered_entries_entry_id ON "registered_entries"(entry_id) ;
+			CREATE UNIQUE INDEX uix_join_tokens_token ON "join_tokens"("token") ;
+			CREATE INDEX idx_selectors_type_value_entry ON "selectors"("type", "value", registered_entry_id) ;
+			CREATE UNIQUE INDEX idx_selector_entry ON "selectors"(registered_entry_id, "type", "value") ;
+			CREATE UNIQUE INDEX idx_dns_entry ON "dns_names"(registered_entry_id, "value") ;
+			CREATE UNIQUE INDEX uix_federated_trust_domains_trust_domain ON "federated_trust_domains"(trust_domain) ;
+			CREATE INDEX idx_federated_registration_entries_registered_entry_id ON "federated_registration_entries"(registered_entry_id) ;
+			COMMIT;
+			`,
+		21: `
+			PRAGMA foreign_keys=OFF;
+			BEGIN TRANSACTION;
+			CREATE TABLE IF NOT EXISTS "federated_registration_entries" ("bundle_id" integer,"registered_entry_id" integer, PRIMARY KEY ("bundle_id","registered_entry_id"));
+			CREATE TABLE IF NOT EXISTS "bundles" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"data" blob );
+			CREATE TABLE IF NOT EXISTS "attested_node_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"data_type" varchar(255),"serial_number" varchar(255),"expires_at" datetime,"new_serial_number" varchar(255),"new_expires_at" datetime , "can_reattest" bool);
+			CREATE TABLE IF NOT EXISTS "node_resolver_map_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"type" varchar(255),"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "registered_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"entry_id" varchar(255),"spiffe_id" varchar(255),"parent_id" varchar(255),"ttl" integer,"admin" bool,"downstream" bool,"expiry" bigint,"revision_number" bigint,"store_svid" bool , "hint" varchar(255), "x509_svid_ttl" integer, "jwt_svid_ttl" integer);
+			INSERT INTO registered_entries VALUES(1,'2022-09-20 14:22:10.582617+00:00','2022-09-20 14:22:10.582617+00:00','0e7ff54e-d7a8-4eec-a09e-3c5dd19a4e1b','spiffe://example.org/workload','spiffe://example.org/agent',0,0,0,0,0,0,'',0,0);
+			CREATE TABLE IF NOT EXISTS "join_tokens" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"token" varchar(255),"expiry" bigint );
+			CREATE TABLE IF NOT EXISTS "selectors" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"type" varchar(255),"value" varchar(255) );
+			INSERT INTO selectors VALUES(1,'2022-09-20 14:22:10.583041+00:00','2022-09-20 14:22:10.583041+00:00',1,'unix','uid:1000');
+			CREATE TABLE IF NOT EXISTS "migrations" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"version" integer,"code_version" varchar(255) );
+			INSERT INTO migrations VALUES(1,'2022-09-20 14:21:54.417127+00:00','2022-09-20 14:21:54.417127+00:00',21,'1.4.3');
+			CREATE TABLE IF NOT EXISTS "dns_names" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "federated_trust_domains" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"bundle_endpoint_url" varchar(255),"bundle_endpoint_profile" varchar(255),"endpoint_spiffe_id" varchar(255),"implicit" bool );
+			CREATE TABLE IF NOT EXISTS "selector_sets" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"name" varchar(255) NOT NULL );
+			CREATE TABLE IF NOT EXISTS "selector_set_selectors" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"selector_set_id" integer,"type" varchar(255),"value" varchar(255) );
+			DELETE FROM sqlite_sequence;
+			INSERT INTO sqlite_sequence VALUES('migrations',1);
+			INSERT INTO sqlite_sequence VALUES('registered_entries',1);
+			INSERT INTO sqlite_sequence VALUES('selectors',1);
+			CREATE UNIQUE INDEX uix_bundles_trust_domain ON "bundles"(trust_domain) ;
+			CREATE INDEX idx_attested_node_entries_expires_at ON "attested_node_entries"(expires_at) ;
+			CREATE UNIQUE INDEX uix_attested_node_entries_spiffe_id ON "attested_node_entries"(spiffe_id) ;
+			CREATE UNIQUE INDEX idx_node_resolver_map ON "node_resolver_map_entries"(spiffe_id, "type", "value") ;
+			CREATE INDEX idx_registered_entries_spiffe_id ON "registered_entries"(spiffe_id) ;
+			CREATE INDEX idx_registered_entries_parent_id ON "registered_entries"(parent_id) ;
+			CREATE INDEX idx_registered_entries_expiry ON "registered_entries"("expiry") ;
+			CREATE UNIQUE INDEX uix_registered_entries_entry_id ON "registered_entries"(entry_id) ;
+			CREATE UNIQUE INDEX uix_join_tokens_token ON "join_tokens"("token") ;
+			CREATE INDEX idx_selectors_type_value_entry ON "selectors"("type", "value", registered_entry_id) ;
+			CREATE UNIQUE INDEX idx_selector_entry ON "selectors"(registered_entry_id, "type", "value") ;
+			CREATE UNIQUE INDEX idx_dns_entry ON "dns_names"(registered_entry_id, "value") ;
+			CREATE UNIQUE INDEX uix_federated_trust_domains_trust_domain ON "federated_trust_domains"(trust_domain) ;
+			CREATE UNIQUE INDEX uix_selector_sets_name ON "selector_sets"("name") ;
+			CREATE UNIQUE INDEX idx_selector_set_selector ON "selector_set_selectors"(selector_set_id, "type", "value") ;
+			CREATE INDEX idx_federated_registration_entries_registered_entry_id ON "federated_registration_entries"(registered_entry_id) ;
+			COMMIT;
+			`,
+		22: `
+			PRAGMA foreign_keys=OFF;
+			BEGIN TRANSACTION;
+			CREATE TABLE IF NOT EXISTS "federated_registration_entries" ("bundle_id" integer,"registered_entry_id" integer, PRIMARY KEY ("bundle_id","registered_entry_id"));
+			CREATE TABLE IF NOT EXISTS "bundles" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"data" blob );
+			CREATE TABLE IF NOT EXISTS "attested_node_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"data_type" varchar(255),"serial_number" varchar(255),"expires_at" datetime,"new_serial_number" varchar(255),"new_expires_at" datetime , "can_reattest" bool);
+			CREATE TABLE IF NOT EXISTS "node_resolver_map_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"type" varchar(255),"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "registered_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"entry_id" varchar(255),"spiffe_id" varchar(255),"parent_id" varchar(255),"ttl" integer,"admin" bool,"downstream" bool,"expiry" bigint,"revision_number" bigint,"store_svid" bool , "hint" varchar(255), "x509_svid_ttl" integer, "jwt_svid_ttl" integer);
+			INSERT INTO registered_entries VALUES(1,'2022-09-20 14:22:10.582617+00:00','2022-09-20 14:22:10.582617+00:00','0e7ff54e-d7a8-4eec-a09e-3c5dd19a4e1b','spiffe://example.org/workload','spiffe://example.org/agent',0,0,0,0,0,0,'',0,0);
+			CREATE TABLE IF NOT EXISTS "join_tokens" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"token" varchar(255),"expiry" bigint );
+			CREATE TABLE IF NOT EXISTS "selectors" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"type" varchar(255),"value" varchar(255) );
+			INSERT INTO selectors VALUES(1,'2022-09-20 14:22:10.583041+00:00','2022-09-20 14:22:10.583041+00:00',1,'unix','uid:1000');
+			CREATE TABLE IF NOT EXISTS "migrations" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"version" integer,"code_version" varchar(255) );
+			INSERT INTO migrations VALUES(1,'2022-09-20 14:21:54.417127+00:00','2022-09-20 14:21:54.417127+00:00',22,'1.4.3');
+			CREATE TABLE IF NOT EXISTS "dns_names" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "federated_trust_domains" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"bundle_endpoint_url" varchar(255),"bundle_endpoint_profile" varchar(255),"endpoint_spiffe_id" varchar(255),"implicit" bool );
+			CREATE TABLE IF NOT EXISTS "selector_sets" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"name" varchar(255) NOT NULL );
+			CREATE TABLE IF NOT EXISTS "selector_set_selectors" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"selector_set_id" integer,"type" varchar(255),"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "issuance_records" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"serial_number" varchar(255),"spiffe_id" varchar(255),"entry_id" varchar(255),"expires_at" datetime,"revoked_at" datetime );
+			DELETE FROM sqlite_sequence;
+			INSERT INTO sqlite_sequence VALUES('migrations',1);
+			INSERT INTO sqlite_sequence VALUES('registered_entries',1);
+			INSERT INTO sqlite_sequence VALUES('selectors',1);
+			CREATE UNIQUE INDEX uix_bundles_trust_domain ON "bundles"(trust_domain) ;
+			CREATE INDEX idx_attested_node_entries_expires_at ON "attested_node_entries"(expires_at) ;
+			CREATE UNIQUE INDEX uix_attested_node_entries_spiffe_id ON "attested_node_entries"(spiffe_id) ;
+			CREATE UNIQUE INDEX idx_node_resolver_map ON "node_resolver_map_entries"(spiffe_id, "type", "value") ;
+			CREATE INDEX idx_registered_entries_spiffe_id ON "registered_entries"(spiffe_id) ;
+			CREATE INDEX idx_registered_entries_parent_id ON "registered_entries"(parent_id) ;
+			CREATE INDEX idx_registered_entries_expiry ON "registered_entries"("expiry") ;
+			CREATE UNIQUE INDEX uix_registered_entries_entry_id ON "registered_entries"(entry_id) ;
+			CREATE UNIQUE INDEX uix_join_tokens_token ON "join_tokens"("token") ;
+			CREATE INDEX idx_selectors_type_value_entry ON "selectors"("type", "value", registered_entry_id) ;
+			CREATE UNIQUE INDEX idx_selector_entry ON "selectors"(registered_entry_id, "type", "value") ;
+			CREATE UNIQUE INDEX idx_dns_entry ON "dns_names"(registered_entry_id, "value") ;
+			CREATE UNIQUE INDEX uix_federated_trust_domains_trust_domain ON "federated_trust_domains"(trust_domain) ;
+			CREATE UNIQUE INDEX uix_selector_sets_name ON "selector_sets"("name") ;
+			CREATE UNIQUE INDEX idx_selector_set_selector ON "selector_set_selectors"(selector_set_id, "type", "value") ;
+			CREATE INDEX idx_federated_registration_entries_registered_entry_id ON "federated_registration_entries"(registered_entry_id) ;
+			CREATE INDEX idx_issuance_records_serial_number ON "issuance_records"(serial_number) ;
+			CREATE INDEX idx_issuance_records_spiffe_id ON "issuance_records"(spiffe_id) ;
+			CREATE INDEX idx_issuance_records_entry_id ON "issuance_records"(entry_id) ;
+			CREATE INDEX idx_issuance_records_expires_at ON "issuance_records"(expires_at) ;
+			COMMIT;
+			`,
+		23: `
+			PRAGMA foreign_keys=OFF;
+			BEGIN TRANSACTION;
+			CREATE TABLE IF NOT EXISTS "federated_registration_entries" ("bundle_id" integer,"registered_entry_id" integer, PRIMARY KEY ("bundle_id","registered_entry_id"));
+			CREATE TABLE IF NOT EXISTS "bundles" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"data" blob );
+			CREATE TABLE IF NOT EXISTS "attested_node_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"data_type" varchar(255),"serial_number" varchar(255),"expires_at" datetime,"new_serial_number" varchar(255),"new_expires_at" datetime , "can_reattest" bool);
+			CREATE TABLE IF NOT EXISTS "node_resolver_map_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"type" varchar(255),"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "registered_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"entry_id" varchar(255),"spiffe_id" varchar(255),"parent_id" varchar(255),"ttl" integer,"admin" bool,"downstream" bool,"expiry" bigint,"revision_number" bigint,"store_svid" bool , "hint" varchar(255), "x509_svid_ttl" integer, "jwt_svid_ttl" integer);
+			INSERT INTO registered_entries VALUES(1,'2022-09-20 14:22:10.582617+00:00','2022-09-20 14:22:10.582617+00:00','0e7ff54e-d7a8-4eec-a09e-3c5dd19a4e1b','spiffe://example.org/workload','spiffe://example.org/agent',0,0,0,0,0,0,'',0,0);
+			CREATE TABLE IF NOT EXISTS "join_tokens" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"token" varchar(255),"expiry" bigint );
+			CREATE TABLE IF NOT EXISTS "selectors" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"type" varchar(255),"value" varchar(255) );
+			INSERT INTO selectors VALUES(1,'2022-09-20 14:22:10.583041+00:00','2022-09-20 14:22:10.583041+00:00',1,'unix','uid:1000');
+			CREATE TABLE IF NOT EXISTS "migrations" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"version" integer,"code_version" varchar(255) );
+			INSERT INTO migrations VALUES(1,'2022-09-20 14:21:54.417127+00:00','2022-09-20 14:21:54.417127+00:00',23,'1.4.3');
+			CREATE TABLE IF NOT EXISTS "dns_names" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "federated_trust_domains" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"bundle_endpoint_url" varchar(255),"bundle_endpoint_profile" varchar(255),"endpoint_spiffe_id" varchar(255),"implicit" bool );
+			CREATE TABLE IF NOT EXISTS "selector_sets" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"name" varchar(255) NOT NULL );
+			CREATE TABLE IF NOT EXISTS "selector_set_selectors" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"selector_set_id" integer,"type" varchar(255),"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "issuance_records" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"serial_number" varchar(255),"spiffe_id" varchar(255),"entry_id" varchar(255),"expires_at" datetime,"revoked_at" datetime );
+			CREATE TABLE IF NOT EXISTS "attestation_events" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"attestation_type" varchar(255),"serial_number" varchar(255),"can_reattest" bool,"reattestation" bool,"attested_at" datetime,"error" varchar(255),"selectors" blob );
+			DELETE FROM sqlite_sequence;
+			INSERT INTO sqlite_sequence VALUES('migrations',1);
+			INSERT INTO sqlite_sequence VALUES('registered_entries',1);
+			INSERT INTO sqlite_sequence VALUES('selectors',1);
+			CREATE UNIQUE INDEX uix_bundles_trust_domain ON "bundles"(trust_domain) ;
+			CREATE INDEX idx_attested_node_entries_expires_at ON "attested_node_entries"(expires_at) ;
+			CREATE UNIQUE INDEX uix_attested_node_entries_spiffe_id ON "attested_node_entries"(spiffe_id) ;
+			CREATE UNIQUE INDEX idx_node_resolver_map ON "node_resolver_map_entries"(spiffe_id, "type", "value") ;
+			CREATE INDEX idx_registered_entries_spiffe_id ON "registered_entries"(spiffe_id) ;
+			CREATE INDEX idx_registered_entries_parent_id ON "registered_entries"(parent_id) ;
+			CREATE INDEX idx_registered_entries_expiry ON "registered_entries"("expiry") ;
+			CREATE UNIQUE INDEX uix_registered_entries_entry_id ON "registered_entries"(entry_id) ;
+			CREATE UNIQUE INDEX uix_join_tokens_token ON "join_tokens"("token") ;
+			CREATE INDEX idx_selectors_type_value_entry ON "selectors"("type", "value", registered_entry_id) ;
+			CREATE UNIQUE INDEX idx_selector_entry ON "selectors"(registered_entry_id, "type", "value") ;
+			CREATE UNIQUE INDEX idx_dns_entry ON "dns_names"(registered_entry_id, "value") ;
+			CREATE UNIQUE INDEX uix_federated_trust_domains_trust_domain ON "federated_trust_domains"(trust_domain) ;
+			CREATE UNIQUE INDEX uix_selector_sets_name ON "selector_sets"("name") ;
+			CREATE UNIQUE INDEX idx_selector_set_selector ON "selector_set_selectors"(selector_set_id, "type", "value") ;
+			CREATE INDEX idx_federated_registration_entries_registered_entry_id ON "federated_registration_entries"(registered_entry_id) ;
+			CREATE INDEX idx_issuance_records_serial_number ON "issuance_records"(serial_number) ;
+			CREATE INDEX idx_issuance_records_spiffe_id ON "issuance_records"(spiffe_id) ;
+			CREATE INDEX idx_issuance_records_entry_id ON "issuance_records"(entry_id) ;
+			CREATE INDEX idx_issuance_records_expires_at ON "issuance_records"(expires_at) ;
+			CREATE INDEX idx_attestation_events_spiffe_id ON "attestation_events"(spiffe_id) ;
+			COMMIT;
+			`,
+		24: `
+			PRAGMA foreign_keys=OFF;
+			BEGIN TRANSACTION;
+			CREATE TABLE IF NOT EXISTS "federated_registration_entries" ("bundle_id" integer,"registered_entry_id" integer, PRIMARY KEY ("bundle_id","registered_entry_id"));
+			CREATE TABLE IF NOT EXISTS "bundles" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"data" blob );
+			CREATE TABLE IF NOT EXISTS "attested_node_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"data_type" varchar(255),"serial_number" varchar(255),"expires_at" datetime,"new_serial_number" varchar(255),"new_expires_at" datetime , "can_reattest" bool);
+			CREATE TABLE IF NOT EXISTS "node_resolver_map_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"type" varchar(255),"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "registered_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"entry_id" varchar(255),"spiffe_id" varchar(255),"parent_id" varchar(255),"ttl" integer,"admin" bool,"downstream" bool,"expiry" bigint,"revision_number" bigint,"store_svid" bool , "hint" varchar(255), "x509_svid_ttl" integer, "jwt_svid_ttl" integer);
+			INSERT INTO registered_entries VALUES(1,'2022-09-20 14:22:10.582617+00:00','2022-09-20 14:22:10.582617+00:00','0e7ff54e-d7a8-4eec-a09e-3c5dd19a4e1b','spiffe://example.org/workload','spiffe://example.org/agent',0,0,0,0,0,0,'',0,0);
+			CREATE TABLE IF NOT EXISTS "join_tokens" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"token" varchar(255),"expiry" bigint );
+			CREATE TABLE IF NOT EXISTS "selectors" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"type" varchar(255),"value" varchar(255) );
+			INSERT INTO selectors VALUES(1,'2022-09-20 14:22:10.583041+00:00','2022-09-20 14:22:10.583041+00:00',1,'unix','uid:1000');
+			CREATE TABLE IF NOT EXISTS "migrations" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"version" integer,"code_version" varchar(255) );
+			INSERT INTO migrations VALUES(1,'2022-09-20 14:21:54.417127+00:00','2022-09-20 14:21:54.417127+00:00',24,'1.4.3');
+			CREATE TABLE IF NOT EXISTS "dns_names" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "federated_trust_domains" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"bundle_endpoint_url" varchar(255),"bundle_endpoint_profile" varchar(255),"endpoint_spiffe_id" varchar(255),"implicit" bool );
+			CREATE TABLE IF NOT EXISTS "selector_sets" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"name" varchar(255) NOT NULL );
+			CREATE TABLE IF NOT EXISTS "selector_set_selectors" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"selector_set_id" integer,"type" varchar(255),"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "issuance_records" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"serial_number" varchar(255),"spiffe_id" varchar(255),"entry_id" varchar(255),"expires_at" datetime,"revoked_at" datetime );
+			CREATE TABLE IF NOT EXISTS "attestation_events" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"attestation_type" varchar(255),"serial_number" varchar(255),"can_reattest" bool,"reattestation" bool,"attested_at" datetime,"error" varchar(255),"selectors" blob );
+			CREATE TABLE IF NOT EXISTS "acme_cache_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"name" varchar(255) NOT NULL,"data" blob );
+			DELETE FROM sqlite_sequence;
+			INSERT INTO sqlite_sequence VALUES('migrations',1);
+			INSERT INTO sqlite_sequence VALUES('registered_entries',1);
+			INSERT INTO sqlite_sequence VALUES('selectors',1);
+			CREATE UNIQUE INDEX uix_bundles_trust_domain ON "bundles"(trust_domain) ;
+			CREATE INDEX idx_attested_node_entries_expires_at ON "attested_node_entries"(expires_at) ;
+			CREATE UNIQUE INDEX uix_attested_node_entries_spiffe_id ON "attested_node_entries"(spiffe_id) ;
+			CREATE UNIQUE INDEX idx_node_resolver_map ON "node_resolver_map_entries"(spiffe_id, "type", "value") ;
+			CREATE INDEX idx_registered_entries_spiffe_id ON "registered_entries"(spiffe_id) ;
+			CREATE INDEX idx_registered_entries_parent_id ON "registered_entries"(parent_id) ;
+			CREATE INDEX idx_registered_entries_expiry ON "registered_entries"("expiry") ;
+			CREATE UNIQUE INDEX uix_registered_entries_entry_id ON "registered_entries"(entry_id) ;
+			CREATE UNIQUE INDEX uix_join_tokens_token ON "join_tokens"("token") ;
+			CREATE INDEX idx_selectors_type_value_entry ON "selectors"("type", "value", registered_entry_id) ;
+			CREATE UNIQUE INDEX idx_selector_entry ON "selectors"(registered_entry_id, "type", "value") ;
+			CREATE UNIQUE INDEX idx_dns_entry ON "dns_names"(registered_entry_id, "value") ;
+			CREATE UNIQUE INDEX uix_federated_trust_domains_trust_domain ON "federated_trust_domains"(trust_domain) ;
+			CREATE UNIQUE INDEX uix_selector_sets_name ON "selector_sets"("name") ;
+			CREATE UNIQUE INDEX idx_selector_set_selector ON "selector_set_selectors"(selector_set_id, "type", "value") ;
+			CREATE INDEX idx_federated_registration_entries_registered_entry_id ON "federated_registration_entries"(registered_entry_id) ;
+			CREATE INDEX idx_issuance_records_serial_number ON "issuance_records"(serial_number) ;
+			CREATE INDEX idx_issuance_records_spiffe_id ON "issuance_records"(spiffe_id) ;
+			CREATE INDEX idx_issuance_records_entry_id ON "issuance_records"(entry_id) ;
+			CREATE INDEX idx_issuance_records_expires_at ON "issuance_records"(expires_at) ;
+			CREATE INDEX idx_attestation_events_spiffe_id ON "attestation_events"(spiffe_id) ;
+			CREATE UNIQUE INDEX uix_acme_cache_entries_name ON "acme_cache_entries"("name") ;
+			COMMIT;
+			`,
+		25: `
+			PRAGMA foreign_keys=OFF;
+			BEGIN TRANSACTION;
+			CREATE TABLE IF NOT EXISTS "federated_registration_entries" ("bundle_id" integer,"registered_entry_id" integer, PRIMARY KEY ("bundle_id","registered_entry_id"));
+			CREATE TABLE IF NOT EXISTS "bundles" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"data" blob );
+			CREATE TABLE IF NOT EXISTS "attested_node_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"data_type" varchar(255),"serial_number" varchar(255),"expires_at" datetime,"new_serial_number" varchar(255),"new_expires_at" datetime , "can_reattest" bool);
+			CREATE TABLE IF NOT EXISTS "node_resolver_map_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"type" varchar(255),"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "registered_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"entry_id" varchar(255),"spiffe_id" varchar(255),"parent_id" varchar(255),"ttl" integer,"admin" bool,"downstream" bool,"expiry" bigint,"revision_number" bigint,"store_svid" bool , "hint" varchar(255), "x509_svid_ttl" integer, "jwt_svid_ttl" integer);
+			INSERT INTO registered_entries VALUES(1,'2022-09-20 14:22:10.582617+00:00','2022-09-20 14:22:10.582617+00:00','0e7ff54e-d7a8-4eec-a09e-3c5dd19a4e1b','spiffe://example.org/workload','spiffe://example.org/agent',0,0,0,0,0,0,'',0,0);
+			CREATE TABLE IF NOT EXISTS "join_tokens" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"token" varchar(255),"expiry" bigint );
+			CREATE TABLE IF NOT EXISTS "selectors" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"type" varchar(255),"value" varchar(255) );
+			INSERT INTO selectors VALUES(1,'2022-09-20 14:22:10.583041+00:00','2022-09-20 14:22:10.583041+00:00',1,'unix','uid:1000');
+			CREATE TABLE IF NOT EXISTS "migrations" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"version" integer,"code_version" varchar(255) );
+			INSERT INTO migrations VALUES(1,'2022-09-20 14:21:54.417127+00:00','2022-09-20 14:21:54.417127+00:00',25,'1.4.3');
+			CREATE TABLE IF NOT EXISTS "dns_names" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"registered_entry_id" integer,"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "federated_trust_domains" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"trust_domain" varchar(255) NOT NULL,"bundle_endpoint_url" varchar(255),"bundle_endpoint_profile" varchar(255),"endpoint_spiffe_id" varchar(255),"implicit" bool );
+			CREATE TABLE IF NOT EXISTS "selector_sets" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"name" varchar(255) NOT NULL );
+			CREATE TABLE IF NOT EXISTS "selector_set_selectors" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"selector_set_id" integer,"type" varchar(255),"value" varchar(255) );
+			CREATE TABLE IF NOT EXISTS "issuance_records" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"serial_number" varchar(255),"spiffe_id" varchar(255),"entry_id" varchar(255),"expires_at" datetime,"revoked_at" datetime );
+			CREATE TABLE IF NOT EXISTS "attestation_events" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255),"attestation_type" varchar(255),"serial_number" varchar(255),"can_reattest" bool,"reattestation" bool,"attested_at" datetime,"error" varchar(255),"selectors" blob );
+			CREATE TABLE IF NOT EXISTS "acme_cache_entries" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"name" varchar(255) NOT NULL,"data" blob );
+			CREATE TABLE IF NOT EXISTS "pending_agents" ("id" integer primary key autoincrement,"created_at" datetime,"updated_at" datetime,"spiffe_id" varchar(255) NOT NULL,"attestation_type" varchar(255),"approved" bool,"requested_at" datetime,"last_attempt_at" datetime );
+			DELETE FROM sqlite_sequence;
+			INSERT INTO sqlite_sequence VALUES('migrations',1);
+			INSERT INTO sqlite_sequence VALUES('registered_entries',1);
+			INSERT INTO sqlite_sequence VALUES('selectors',1);
+			CREATE UNIQUE INDEX uix_bundles_trust_domain ON "bundles"(trust_domain) ;
+			CREATE INDEX idx_attested_node_entries_expires_at ON "attested_node_entries"(expires_at) ;
+			CREATE UNIQUE INDEX uix_attested_node_entries_spiffe_id ON "attested_node_entries"(spiffe_id) ;
+			CREATE UNIQUE INDEX idx_node_resolver_map ON "node_resolver_map_entries"(spiffe_id, "type", "value") ;
+			CREATE INDEX idx_registered_entries_spiffe_id ON "registered_entries"(spiffe_id) ;
+			CREATE INDEX idx_registered_entries_parent_id ON "registered_entries"(parent_id) ;
+			CREATE INDEX idx_registered_entries_expiry ON "registered_entries"("expiry") ;
+			CREATE UNIQUE INDEX uix_registered_entries_entry_id ON "registered_entries"(entry_id) ;
+			CREATE UNIQUE INDEX uix_join_tokens_token ON "join_tokens"("token") ;
+			CREATE INDEX idx_selectors_type_value_entry ON "selectors"("type", "value", registered_entry_id) ;
+			CREATE UNIQUE INDEX idx_selector_entry ON "selectors"(registered_entry_id, "type", "value") ;
+			CREATE UNIQUE INDEX idx_dns_entry ON "dns_names"(registered_entry_id, "value") ;
+			CREATE UNIQUE INDEX uix_federated_trust_domains_trust_domain ON "federated_trust_domains"(trust_domain) ;
+			CREATE UNIQUE INDEX uix_selector_sets_name ON "selector_sets"("name") ;
+			CREATE UNIQUE INDEX idx_selector_set_selector ON "selector_set_selectors"(selector_set_id, "type", "value") ;
+			CREATE INDEX idx_federated_registration_entries_registered_entry_id ON "federated_registration_entries"(registered_entry_id) ;
+			CREATE INDEX idx_issuance_records_serial_number ON "issuance_records"(serial_number) ;
+			CREATE INDEX idx_issuance_records_spiffe_id ON "issuance_records"(spiffe_id) ;
+			CREATE INDEX idx_issuance_records_entry_id ON "issuance_records"(entry_id) ;
+			CREATE INDEX idx_issuance_records_expires_at ON "issuance_records"(expires_at) ;
+			CREATE INDEX idx_attestation_events_spiffe_id ON "attestation_events"(spiffe_id) ;
+			CREATE UNIQUE INDEX uix_acme_cache_entries_name ON "acme_cache_entries"("name") ;
+			CREATE UNIQUE INDEX uix_pending_agents_spiffe_id ON "pending_agents"(spiffe_id) ;
+			COMMIT;
+			`,
 	}
 )
 
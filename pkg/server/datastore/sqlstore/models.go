@@ -113,12 +113,14 @@ type JoinToken struct {
 	Expiry int64
 }
 
+// Selector holds a selector of a registration entry. The lookup index on
+// (type, value, registered_entry_id) is created by the migration code.
 type Selector struct {
 	Model
 
 	RegisteredEntryID uint   `gorm:"unique_index:idx_selector_entry"`
-	Type              string `gorm:"unique_index:idx_selector_entry;index:idx_selectors_type_value"`
-	Value             string `gorm:"unique_index:idx_selector_entry;index:idx_selectors_type_value"`
+	Type              string `gorm:"unique_index:idx_selector_entry"`
+	Value             string `gorm:"unique_index:idx_selector_entry"`
 }
 
 // DNSName holds a DNS for a registration entry
@@ -165,6 +167,89 @@ func (FederatedTrustDomain) TableName() string {
 	return "federated_trust_domains"
 }
 
+// ACMECacheEntry holds data cached by the bundle endpoint ACME client, like
+// the account registration and the obtained certificates
+type ACMECacheEntry struct {
+	Model
+
+	Name string `gorm:"not null;unique_index"`
+	Data []byte `gorm:"size:16777215"` // make MySQL to use MEDIUMBLOB (max 16MB) - doesn't affect PostgreSQL/SQLite
+}
+
+// TableName gets table name of ACMECacheEntry
+func (ACMECacheEntry) TableName() string {
+	return "acme_cache_entries"
+}
+
+// AttestationEvent holds a record of an agent attestation, successful or not
+type AttestationEvent struct {
+	Model
+
+	SpiffeID        string `gorm:"index"`
+	AttestationType string
+	SerialNumber    string
+	CanReattest     bool
+	Reattestation   bool
+	AttestedAt      time.Time
+	Error           string
+	Selectors       []byte `gorm:"size:16777215"` // make MySQL to use MEDIUMBLOB (max 16MB) - doesn't affect PostgreSQL/SQLite
+}
+
+// MirroredEntry holds the entry of the server of a peer trust domain a
+// registration entry was mirrored to
+type MirroredEntry struct {
+	Model
+
+	EntryID        string `gorm:"not null;unique_index"`
+	PeerEntryID    string
+	RevisionNumber int64
+	Mirror         []byte `gorm:"size:16777215"` // make MySQL to use MEDIUMBLOB (max 16MB) - doesn't affect PostgreSQL/SQLite
+}
+
+// PendingAgent holds an agent awaiting approval by an administrator
+type PendingAgent struct {
+	Model
+
+	SpiffeID        string `gorm:"not null;unique_index"`
+	AttestationType string
+	Approved        bool
+	RequestedAt     time.Time
+	LastAttemptAt   time.Time
+}
+
+// IssuanceRecord holds a record of an X.509 certificate issued by the
+// server CA
+type IssuanceRecord struct {
+	Model
+
+	SerialNumber string    `gorm:"index"`
+	SpiffeID     string    `gorm:"index"`
+	EntryID      string    `gorm:"index"`
+	ExpiresAt    time.Time `gorm:"index"`
+
+	// RevokedAt is when the registration entry the certificate was issued
+	// for was deleted, or nil if it was not
+	RevokedAt *time.Time
+}
+
+// SelectorSet holds a named group of selectors referenced by registration
+// entries
+type SelectorSet struct {
+	Model
+
+	Name      string `gorm:"not null;unique_index"`
+	Selectors []SelectorSetSelector
+}
+
+// SelectorSetSelector holds a selector of a selector set
+type SelectorSetSelector struct {
+	Model
+
+	SelectorSetID uint   `gorm:"unique_index:idx_selector_set_selector"`
+	Type          string `gorm:"unique_index:idx_selector_set_selector"`
+	Value         string `gorm:"unique_index:idx_selector_set_selector"`
+}
+
 // Migration holds database schema version number, and
 // the SPIRE Code version number
 type Migration struct {
@@ -1,8 +1,12 @@
 package sqlstore
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/jackc/pgconn"
 	"github.com/jinzhu/gorm"
 	"github.com/lib/pq"
 
@@ -13,7 +17,7 @@ import (
 type postgresDB struct{}
 
 func (p postgresDB) connect(cfg *configuration, isReadOnly bool) (db *gorm.DB, version string, supportsCTE bool, err error) {
-	db, err = gorm.Open("postgres", getConnectionString(cfg, isReadOnly))
+	db, err = openPostgres(cfg, isReadOnly)
 	if err != nil {
 		return nil, "", false, sqlError.Wrap(err)
 	}
@@ -34,3 +38,78 @@ func (p postgresDB) isConstraintViolation(err error) bool {
 	// "23xxx" is the constraint violation class for PostgreSQL
 	return ok && e.Code.Class() == "23"
 }
+
+func openPostgres(cfg *configuration, isReadOnly bool) (*gorm.DB, error) {
+	if !hasTLSConfig(cfg) && cfg.IAMAuth == nil {
+		// connection string doesn't have to be modified
+		return gorm.Open("postgres", getConnectionString(cfg, isReadOnly))
+	}
+
+	connectionString, err := postgresConnectionString(cfg, isReadOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.IAMAuth == nil {
+		return gorm.Open("postgres", connectionString)
+	}
+
+	pgConfig, err := pgconn.ParseConfig(getConnectionString(cfg, isReadOnly))
+	if err != nil {
+		return nil, err
+	}
+	connector, err := newIAMConnector(connectionString, pgConfig, cfg.IAMAuth)
+	if err != nil {
+		return nil, err
+	}
+	return gorm.Open("postgres", sql.OpenDB(connector))
+}
+
+// postgresConnectionString returns the connection string in the key/value
+// format, with the TLS files configured through the plugin configuration.
+func postgresConnectionString(cfg *configuration, isReadOnly bool) (string, error) {
+	connectionString := getConnectionString(cfg, isReadOnly)
+	if strings.HasPrefix(connectionString, "postgres://") || strings.HasPrefix(connectionString, "postgresql://") {
+		var err error
+		connectionString, err = pq.ParseURL(connectionString)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if len(cfg.RootCAPath) > 0 {
+		connectionString = appendPostgresOption(connectionString, "sslrootcert", cfg.RootCAPath)
+	}
+	if len(cfg.ClientCertPath) > 0 && len(cfg.ClientKeyPath) > 0 {
+		connectionString = appendPostgresOption(connectionString, "sslcert", cfg.ClientCertPath)
+		connectionString = appendPostgresOption(connectionString, "sslkey", cfg.ClientKeyPath)
+	}
+	return connectionString, nil
+}
+
+// appendPostgresOption appends an option to a connection string in the
+// key/value format. lib/pq uses the last value of a repeated option, so the
+// appended option overrides any value already in the connection string.
+func appendPostgresOption(connectionString, key, value string) string {
+	return fmt.Sprintf("%s %s='%s'", connectionString, key, postgresValueEscaper.Replace(value))
+}
+
+var postgresValueEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+func validatePostgresConfig(cfg *configuration, isReadOnly bool) error {
+	if !hasTLSConfig(cfg) && cfg.IAMAuth == nil {
+		return nil
+	}
+
+	pgConfig, err := pgconn.ParseConfig(getConnectionString(cfg, isReadOnly))
+	if err != nil {
+		return sqlError.New("invalid postgres config: %v", err)
+	}
+
+	if cfg.IAMAuth != nil {
+		if err := cfg.IAMAuth.validate(pgConfig); err != nil {
+			return sqlError.New("invalid postgres config: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/rds/auth"
+	"github.com/jackc/pgconn"
+	"github.com/lib/pq"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	// AWSRDS authenticates to AWS RDS using IAM database authentication
+	AWSRDS = "aws_rds"
+	// GCPCloudSQL authenticates to GCP Cloud SQL using IAM database
+	// authentication
+	GCPCloudSQL = "gcp_cloudsql"
+
+	cloudSQLLoginScope = "https://www.googleapis.com/auth/sqlservice.login"
+)
+
+// iamAuthConfig configures the connections to authenticate with short-lived
+// IAM tokens instead of a static password.
+type iamAuthConfig struct {
+	Provider string `hcl:"provider" json:"provider"`
+
+	// Region of the AWS RDS instance
+	Region string `hcl:"region" json:"region"`
+}
+
+func (c *iamAuthConfig) validate(pgConfig *pgconn.Config) error {
+	if pgConfig.Password != "" {
+		return errors.New("password cannot be set in the connection string when using IAM authentication")
+	}
+
+	switch c.Provider {
+	case AWSRDS:
+		if c.Region == "" {
+			return errors.New("region is required to use AWS RDS IAM authentication")
+		}
+		if strings.HasPrefix(pgConfig.Host, "/") {
+			return errors.New("a TCP host is required in the connection string to use AWS RDS IAM authentication")
+		}
+	case GCPCloudSQL:
+		if c.Region != "" {
+			return errors.New("region is only supported by AWS RDS IAM authentication")
+		}
+	default:
+		return fmt.Errorf("unsupported IAM authentication provider %q", c.Provider)
+	}
+	return nil
+}
+
+// iamConnector connects to PostgreSQL using a fresh IAM token as password
+// for every new connection, since the tokens are short-lived.
+type iamConnector struct {
+	connectionString string
+	getToken         func(context.Context) (string, error)
+}
+
+// newIAMConnector returns a connector for the given connection string, in
+// the key/value format. The parsed connection string provides the user and
+// the endpoint the AWS RDS tokens are issued for.
+func newIAMConnector(connectionString string, pgConfig *pgconn.Config, cfg *iamAuthConfig) (*iamConnector, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var getToken func(context.Context) (string, error)
+	switch cfg.Provider {
+	case AWSRDS:
+		awsConfig, err := config.LoadDefaultConfig(ctx, config.WithRegion(cfg.Region))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+		}
+		endpoint := net.JoinHostPort(pgConfig.Host, strconv.Itoa(int(pgConfig.Port)))
+		getToken = func(ctx context.Context) (string, error) {
+			return auth.BuildAuthToken(ctx, endpoint, cfg.Region, pgConfig.User, awsConfig.Credentials)
+		}
+	case GCPCloudSQL:
+		// The token source caches the token and refreshes it when it
+		// expires
+		tokenSource, err := google.DefaultTokenSource(ctx, cloudSQLLoginScope)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load GCP credentials: %w", err)
+		}
+		getToken = func(context.Context) (string, error) {
+			token, err := tokenSource.Token()
+			if err != nil {
+				return "", err
+			}
+			return token.AccessToken, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported IAM authentication provider %q", cfg.Provider)
+	}
+
+	return &iamConnector{
+		connectionString: connectionString,
+		getToken:         getToken,
+	}, nil
+}
+
+func (c *iamConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	token, err := c.getToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get IAM authentication token: %w", err)
+	}
+
+	connector, err := pq.NewConnector(appendPostgresOption(c.connectionString, "password", token))
+	if err != nil {
+		return nil, err
+	}
+	return connector.Connect(ctx)
+}
+
+func (c *iamConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
@@ -0,0 +1,172 @@
+package sqlstore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresConnectionString(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		cfg       *configuration
+		readOnly  bool
+		expect    string
+		expectErr string
+	}{
+		{
+			name: "key/value",
+			cfg: &configuration{
+				ConnectionString: "dbname=spire user=spire host=primary",
+				RootCAPath:       "/ca.pem",
+			},
+			expect: "dbname=spire user=spire host=primary sslrootcert='/ca.pem'",
+		},
+		{
+			name: "read only with client certificate",
+			cfg: &configuration{
+				ConnectionString:   "dbname=spire user=spire host=primary",
+				RoConnectionString: "dbname=spire user=spire host=replica",
+				RootCAPath:         "/ca.pem",
+				ClientCertPath:     "/client.pem",
+				ClientKeyPath:      "/client.key",
+			},
+			readOnly: true,
+			expect:   "dbname=spire user=spire host=replica sslrootcert='/ca.pem' sslcert='/client.pem' sslkey='/client.key'",
+		},
+		{
+			name: "URL",
+			cfg: &configuration{
+				ConnectionString: "postgres://spire@db.example.org:5433/spire?sslmode=verify-full",
+				RootCAPath:       "/ca.pem",
+			},
+			expect: "dbname='spire' host='db.example.org' port='5433' sslmode='verify-full' user='spire' sslrootcert='/ca.pem'",
+		},
+		{
+			name: "escaped values",
+			cfg: &configuration{
+				ConnectionString: "dbname=spire",
+				RootCAPath:       `/it's\ca.pem`,
+			},
+			expect: `dbname=spire sslrootcert='/it\'s\\ca.pem'`,
+		},
+		{
+			name: "invalid URL",
+			cfg: &configuration{
+				ConnectionString: "postgres://%zz",
+				RootCAPath:       "/ca.pem",
+			},
+			expectErr: `parse "postgres://%zz": invalid URL escape "%zz"`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			connectionString, err := postgresConnectionString(tt.cfg, tt.readOnly)
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expect, connectionString)
+		})
+	}
+}
+
+func TestValidateIAMAuth(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		cfg       *configuration
+		expectErr string
+	}{
+		{
+			name: "AWS RDS",
+			cfg: &configuration{
+				DatabaseType:     PostgreSQL,
+				ConnectionString: "dbname=spire user=spire host=db.example.org",
+				IAMAuth:          &iamAuthConfig{Provider: AWSRDS, Region: "us-east-1"},
+			},
+		},
+		{
+			name: "GCP Cloud SQL",
+			cfg: &configuration{
+				DatabaseType:     PostgreSQL,
+				ConnectionString: "dbname=spire user=spire@project.iam host=127.0.0.1",
+				IAMAuth:          &iamAuthConfig{Provider: GCPCloudSQL},
+			},
+		},
+		{
+			name: "not postgres",
+			cfg: &configuration{
+				DatabaseType:     MySQL,
+				ConnectionString: "spire@tcp(127.0.0.1)/spire?parseTime=true",
+				IAMAuth:          &iamAuthConfig{Provider: AWSRDS, Region: "us-east-1"},
+			},
+			expectErr: "datastore-sql: iam_auth is only supported by postgres",
+		},
+		{
+			name: "unsupported provider",
+			cfg: &configuration{
+				DatabaseType:     PostgreSQL,
+				ConnectionString: "dbname=spire user=spire host=db.example.org",
+				IAMAuth:          &iamAuthConfig{Provider: "azure"},
+			},
+			expectErr: `datastore-sql: invalid postgres config: unsupported IAM authentication provider "azure"`,
+		},
+		{
+			name: "static password",
+			cfg: &configuration{
+				DatabaseType:     PostgreSQL,
+				ConnectionString: "dbname=spire user=spire password=secret host=db.example.org",
+				IAMAuth:          &iamAuthConfig{Provider: AWSRDS, Region: "us-east-1"},
+			},
+			expectErr: "datastore-sql: invalid postgres config: password cannot be set in the connection string when using IAM authentication",
+		},
+		{
+			name: "AWS RDS without region",
+			cfg: &configuration{
+				DatabaseType:     PostgreSQL,
+				ConnectionString: "dbname=spire user=spire host=db.example.org",
+				IAMAuth:          &iamAuthConfig{Provider: AWSRDS},
+			},
+			expectErr: "datastore-sql: invalid postgres config: region is required to use AWS RDS IAM authentication",
+		},
+		{
+			name: "AWS RDS with unix socket",
+			cfg: &configuration{
+				DatabaseType:     PostgreSQL,
+				ConnectionString: "dbname=spire user=spire host=/var/run/postgresql",
+				IAMAuth:          &iamAuthConfig{Provider: AWSRDS, Region: "us-east-1"},
+			},
+			expectErr: "datastore-sql: invalid postgres config: a TCP host is required in the connection string to use AWS RDS IAM authentication",
+		},
+		{
+			name: "GCP Cloud SQL with region",
+			cfg: &configuration{
+				DatabaseType:     PostgreSQL,
+				ConnectionString: "dbname=spire user=spire@project.iam host=127.0.0.1",
+				IAMAuth:          &iamAuthConfig{Provider: GCPCloudSQL, Region: "us-east1"},
+			},
+			expectErr: "datastore-sql: invalid postgres config: region is only supported by AWS RDS IAM authentication",
+		},
+		{
+			name: "invalid read only connection string",
+			cfg: &configuration{
+				DatabaseType:       PostgreSQL,
+				ConnectionString:   "dbname=spire user=spire host=db.example.org",
+				RoConnectionString: "dbname=spire user",
+				IAMAuth:            &iamAuthConfig{Provider: AWSRDS, Region: "us-east-1"},
+			},
+			expectErr: "datastore-sql: invalid postgres config: cannot parse `dbname=spire user`: failed to parse as DSN (invalid dsn)",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -3,7 +3,9 @@ package sqlstore
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"net/url"
@@ -43,6 +45,10 @@ const (
 	PostgreSQL = "postgres"
 	// SQLite database type
 	SQLite = "sqlite3"
+
+	// maxAttestationErrorLength is the size of the error column of the
+	// attestation events
+	maxAttestationErrorLength = 255
 )
 
 // Configuration for the sql datastore implementation.
@@ -59,6 +65,9 @@ type configuration struct {
 	MaxIdleConns       *int    `hcl:"max_idle_conns" json:"max_idle_conns"`
 	DisableMigration   bool    `hcl:"disable_migration" json:"disable_migration"`
 
+	// IAMAuth enables IAM authentication for PostgreSQL
+	IAMAuth *iamAuthConfig `hcl:"iam_auth" json:"iam_auth"`
+
 	// Undocumented flags
 	LogSQL bool `hcl:"log_sql" json:"log_sql"`
 }
@@ -365,7 +374,20 @@ func (ds *Plugin) ListRegistrationEntries(ctx context.Context,
 // UpdateRegistrationEntry updates an existing registration entry
 func (ds *Plugin) UpdateRegistrationEntry(ctx context.Context, e *common.RegistrationEntry, mask *common.RegistrationEntryMask) (entry *common.RegistrationEntry, err error) {
 	if err = ds.withReadModifyWriteTx(ctx, func(tx *gorm.DB) (err error) {
-		entry, err = updateRegistrationEntry(tx, e, mask)
+		entry, err = updateRegistrationEntry(tx, e, mask, nil)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// CompareAndUpdateRegistrationEntry updates an existing registration entry
+// only if its current revision number is the expected one. Otherwise, it
+// fails with codes.Aborted.
+func (ds *Plugin) CompareAndUpdateRegistrationEntry(ctx context.Context, e *common.RegistrationEntry, mask *common.RegistrationEntryMask, expectedRevision int64) (entry *common.RegistrationEntry, err error) {
+	if err = ds.withReadModifyWriteTx(ctx, func(tx *gorm.DB) (err error) {
+		entry, err = updateRegistrationEntry(tx, e, mask, &expectedRevision)
 		return err
 	}); err != nil {
 		return nil, err
@@ -506,6 +528,275 @@ func (ds *Plugin) UpdateFederationRelationship(ctx context.Context, fr *datastor
 	})
 }
 
+// DeleteACMECacheEntry deletes the ACME cache entry with the given key. It
+// is not an error if the entry does not exist.
+func (ds *Plugin) DeleteACMECacheEntry(ctx context.Context, key string) error {
+	return ds.withWriteTx(ctx, func(tx *gorm.DB) error {
+		return deleteACMECacheEntry(tx, key)
+	})
+}
+
+// FetchACMECacheEntry fetches the data of the ACME cache entry with the given
+// key. It returns nil if the entry does not exist.
+func (ds *Plugin) FetchACMECacheEntry(ctx context.Context, key string) (data []byte, err error) {
+	if err = ds.withReadTx(ctx, func(tx *gorm.DB) (err error) {
+		data, err = fetchACMECacheEntry(tx, key)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SetACMECacheEntry creates or replaces the ACME cache entry with the given
+// key
+func (ds *Plugin) SetACMECacheEntry(ctx context.Context, key string, data []byte) error {
+	if key == "" {
+		return status.Error(codes.InvalidArgument, "ACME cache entry key is required")
+	}
+
+	return ds.withWriteTx(ctx, func(tx *gorm.DB) error {
+		return setACMECacheEntry(tx, key, data)
+	})
+}
+
+// DeleteMirroredEntry deletes the record of the mirroring of the
+// registration entry with the given ID. It is not an error if there is no
+// record.
+func (ds *Plugin) DeleteMirroredEntry(ctx context.Context, entryID string) error {
+	return ds.withWriteTx(ctx, func(tx *gorm.DB) error {
+		return deleteMirroredEntry(tx, entryID)
+	})
+}
+
+// ListMirroredEntries lists the records of the mirrored registration entries
+func (ds *Plugin) ListMirroredEntries(ctx context.Context) (entries []*datastore.MirroredEntry, err error) {
+	if err = ds.withReadTx(ctx, func(tx *gorm.DB) (err error) {
+		entries, err = listMirroredEntries(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// SetMirroredEntry creates or replaces the record of the mirroring of a
+// registration entry
+func (ds *Plugin) SetMirroredEntry(ctx context.Context, entry *datastore.MirroredEntry) error {
+	switch {
+	case entry == nil:
+		return status.Error(codes.InvalidArgument, "invalid request: missing mirrored entry")
+	case entry.EntryID == "":
+		return status.Error(codes.InvalidArgument, "invalid request: missing entry ID")
+	case entry.PeerEntryID == "":
+		return status.Error(codes.InvalidArgument, "invalid request: missing peer entry ID")
+	}
+
+	return ds.withWriteTx(ctx, func(tx *gorm.DB) error {
+		return setMirroredEntry(tx, entry)
+	})
+}
+
+// ApprovePendingAgent approves the pending agent with the given SPIFFE ID
+func (ds *Plugin) ApprovePendingAgent(ctx context.Context, spiffeID string) (agent *datastore.PendingAgent, err error) {
+	if err = ds.withReadModifyWriteTx(ctx, func(tx *gorm.DB) (err error) {
+		agent, err = approvePendingAgent(tx, spiffeID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
+
+// DeletePendingAgent deletes the pending agent with the given SPIFFE ID. It
+// is not an error if the agent does not exist.
+func (ds *Plugin) DeletePendingAgent(ctx context.Context, spiffeID string) error {
+	return ds.withWriteTx(ctx, func(tx *gorm.DB) error {
+		return deletePendingAgent(tx, spiffeID)
+	})
+}
+
+// FetchPendingAgent fetches the pending agent with the given SPIFFE ID. It
+// returns nil if the agent does not exist.
+func (ds *Plugin) FetchPendingAgent(ctx context.Context, spiffeID string) (agent *datastore.PendingAgent, err error) {
+	if err = ds.withReadTx(ctx, func(tx *gorm.DB) (err error) {
+		agent, err = fetchPendingAgent(tx, spiffeID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return agent, nil
+}
+
+// ListPendingAgents lists pending agents, oldest first
+func (ds *Plugin) ListPendingAgents(ctx context.Context, req *datastore.ListPendingAgentsRequest) (resp *datastore.ListPendingAgentsResponse, err error) {
+	if err = ds.withReadTx(ctx, func(tx *gorm.DB) (err error) {
+		resp, err = listPendingAgents(tx, req)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SetPendingAgent creates the pending agent, requested at its last attempt,
+// or, if it already exists, updates its attestation type and last attempt.
+// Whether the agent was approved is left unchanged.
+func (ds *Plugin) SetPendingAgent(ctx context.Context, agent *datastore.PendingAgent) (newAgent *datastore.PendingAgent, err error) {
+	if agent == nil {
+		return nil, status.Error(codes.InvalidArgument, "pending agent is nil")
+	}
+	if agent.SpiffeID == "" {
+		return nil, status.Error(codes.InvalidArgument, "pending agent SPIFFE ID is required")
+	}
+
+	if err = ds.withReadModifyWriteTx(ctx, func(tx *gorm.DB) (err error) {
+		newAgent, err = setPendingAgent(tx, agent)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return newAgent, nil
+}
+
+// CreateAttestationEvent records an agent attestation, successful or not
+func (ds *Plugin) CreateAttestationEvent(ctx context.Context, event *datastore.AttestationEvent) error {
+	if event == nil {
+		return status.Error(codes.InvalidArgument, "attestation event is nil")
+	}
+	if event.SpiffeID == "" && event.Error == "" {
+		return status.Error(codes.InvalidArgument, "attestation event SPIFFE ID is required")
+	}
+
+	return ds.withWriteTx(ctx, func(tx *gorm.DB) error {
+		return createAttestationEvent(tx, event)
+	})
+}
+
+// ListAttestationEvents lists agent attestations, most recent first
+func (ds *Plugin) ListAttestationEvents(ctx context.Context, req *datastore.ListAttestationEventsRequest) (resp *datastore.ListAttestationEventsResponse, err error) {
+	if err = ds.withReadTx(ctx, func(tx *gorm.DB) (err error) {
+		resp, err = listAttestationEvents(tx, req)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// PruneAttestationEvents deletes the successful agent attestations that
+// happened before succeededBefore and the failed ones that happened before
+// failedBefore
+func (ds *Plugin) PruneAttestationEvents(ctx context.Context, succeededBefore, failedBefore time.Time) error {
+	return ds.withWriteTx(ctx, func(tx *gorm.DB) error {
+		return pruneAttestationEvents(tx, succeededBefore, failedBefore)
+	})
+}
+
+// CreateIssuanceRecord records an X.509 certificate issued by the server CA
+func (ds *Plugin) CreateIssuanceRecord(ctx context.Context, record *datastore.IssuanceRecord) error {
+	if record == nil {
+		return status.Error(codes.InvalidArgument, "issuance record is nil")
+	}
+	if record.SerialNumber == "" {
+		return status.Error(codes.InvalidArgument, "issuance record serial number is required")
+	}
+
+	return ds.withWriteTx(ctx, func(tx *gorm.DB) error {
+		return createIssuanceRecord(tx, record)
+	})
+}
+
+// ListIssuanceRecords lists the X.509 certificates issued by the server CA
+func (ds *Plugin) ListIssuanceRecords(ctx context.Context, req *datastore.ListIssuanceRecordsRequest) (resp *datastore.ListIssuanceRecordsResponse, err error) {
+	if err = ds.withReadTx(ctx, func(tx *gorm.DB) (err error) {
+		resp, err = listIssuanceRecords(tx, req)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// PruneIssuanceRecords deletes the records of the X.509 certificates that
+// expired before the given time
+func (ds *Plugin) PruneIssuanceRecords(ctx context.Context, expiredBefore time.Time) error {
+	return ds.withWriteTx(ctx, func(tx *gorm.DB) error {
+		return pruneIssuanceRecords(tx, expiredBefore)
+	})
+}
+
+// CreateSelectorSet creates a new selector set. Registration entries
+// reference the set by name using a selector of type datastore.SelectorSetType.
+func (ds *Plugin) CreateSelectorSet(ctx context.Context, set *datastore.SelectorSet) (newSet *datastore.SelectorSet, err error) {
+	if err := validateSelectorSet(set); err != nil {
+		return nil, err
+	}
+
+	if err = ds.withWriteTx(ctx, func(tx *gorm.DB) (err error) {
+		newSet, err = createSelectorSet(tx, set)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return newSet, nil
+}
+
+// DeleteSelectorSet deletes the selector set with the given name. Selector
+// sets that are still referenced by registration entries cannot be deleted.
+func (ds *Plugin) DeleteSelectorSet(ctx context.Context, name string) error {
+	if name == "" {
+		return status.Error(codes.InvalidArgument, "selector set name is required")
+	}
+
+	return ds.withWriteTx(ctx, func(tx *gorm.DB) error {
+		return deleteSelectorSet(tx, name)
+	})
+}
+
+// FetchSelectorSet fetches the selector set with the given name. If the
+// selector set is not found, nil is returned.
+func (ds *Plugin) FetchSelectorSet(ctx context.Context, name string) (set *datastore.SelectorSet, err error) {
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "selector set name is required")
+	}
+
+	if err = ds.withReadTx(ctx, func(tx *gorm.DB) (err error) {
+		set, err = fetchSelectorSet(tx, name)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// ListSelectorSets lists all selector sets
+func (ds *Plugin) ListSelectorSets(ctx context.Context) (sets []*datastore.SelectorSet, err error) {
+	if err = ds.withReadTx(ctx, func(tx *gorm.DB) (err error) {
+		sets, err = listSelectorSets(tx)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return sets, nil
+}
+
+// UpdateSelectorSet replaces the selectors of an existing selector set. The
+// change applies to every registration entry that references the set.
+func (ds *Plugin) UpdateSelectorSet(ctx context.Context, set *datastore.SelectorSet) (newSet *datastore.SelectorSet, err error) {
+	if err := validateSelectorSet(set); err != nil {
+		return nil, err
+	}
+
+	if err = ds.withReadModifyWriteTx(ctx, func(tx *gorm.DB) (err error) {
+		newSet, err = updateSelectorSet(tx, set)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return newSet, nil
+}
+
 // Configure parses HCL config payload into config struct, opens new DB based on the result, and
 // prunes all orphaned records
 func (ds *Plugin) Configure(ctx context.Context, hclConfiguration string) error {
@@ -1773,6 +2064,10 @@ func buildListNodeSelectorsQuery(req *datastore.ListNodeSelectorsRequest) (query
 }
 
 func createRegistrationEntry(tx *gorm.DB, entry *common.RegistrationEntry) (*common.RegistrationEntry, error) {
+	if err := validateSelectorSetReferences(tx, entry.Selectors); err != nil {
+		return nil, err
+	}
+
 	entryID, err := newRegistrationEntryID()
 	if err != nil {
 		return nil, err
@@ -2725,43 +3020,12 @@ func appendListRegistrationEntriesFilterQuery(filterExp string, builder *strings
 	}
 
 	if req.BySelectors != nil && len(req.BySelectors.Selectors) > 0 {
-		switch req.BySelectors.Match {
-		case datastore.Subset, datastore.MatchAny:
-			// subset needs a union, so we need to group them and add the group
-			// as a child to the root.
-			if len(req.BySelectors.Selectors) < 2 {
-				root.children = append(root.children, idFilterNode{
-					idColumn: "registered_entry_id",
-					query:    []string{"SELECT registered_entry_id AS e_id FROM selectors WHERE type = ? AND value = ?"},
-				})
-			} else {
-				group := idFilterNode{
-					idColumn: "e_id",
-					union:    true,
-				}
-				for range req.BySelectors.Selectors {
-					group.children = append(group.children, idFilterNode{
-						idColumn: "registered_entry_id",
-						query:    []string{"SELECT registered_entry_id AS e_id FROM selectors WHERE type = ? AND value = ?"},
-					})
-				}
-				root.children = append(root.children, group)
-			}
-		case datastore.Exact, datastore.Superset:
-			// exact match does uses an intersection, so we can just add these
-			// directly to the root idFilterNode, since it is already an intersection
-			for range req.BySelectors.Selectors {
-				root.children = append(root.children, idFilterNode{
-					idColumn: "registered_entry_id",
-					query:    []string{"SELECT registered_entry_id AS e_id FROM selectors WHERE type = ? AND value = ?"},
-				})
-			}
-		default:
-			return false, nil, errs.New("unhandled selectors match behavior %q", req.BySelectors.Match)
-		}
-		for _, selector := range req.BySelectors.Selectors {
-			args = append(args, selector.Type, selector.Value)
+		selectorsNode, selectorsArgs, err := buildSelectorsFilterNode(req.BySelectors)
+		if err != nil {
+			return false, nil, err
 		}
+		root.children = append(root.children, selectorsNode)
+		args = append(args, selectorsArgs...)
 	}
 
 	if req.ByFederatesWith != nil && len(req.ByFederatesWith.TrustDomains) > 0 {
@@ -2897,6 +3161,69 @@ func appendListRegistrationEntriesFilterQuery(filterExp string, builder *strings
 	return filtered, args, nil
 }
 
+// buildSelectorsFilterNode builds a filter node that selects the IDs of the
+// entries matching the selectors in the request. All of the selectors are
+// matched in a single pass over the selectors table, which can be answered
+// entirely from the (type, value, registered_entry_id) index, instead of
+// combining one subquery per selector. The final exact/subset semantics are
+// applied by filterEntriesBySelectorSet once the entries are fetched.
+func buildSelectorsFilterNode(bySelectors *datastore.BySelectors) (idFilterNode, []interface{}, error) {
+	type selectorKey struct {
+		Type  string
+		Value string
+	}
+	seen := make(map[selectorKey]struct{}, len(bySelectors.Selectors))
+	var args []interface{}
+	for _, selector := range bySelectors.Selectors {
+		key := selectorKey{Type: selector.Type, Value: selector.Value}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		args = append(args, selector.Type, selector.Value)
+	}
+	count := len(seen)
+
+	switch bySelectors.Match {
+	case datastore.Subset, datastore.MatchAny, datastore.Exact, datastore.Superset:
+	default:
+		return idFilterNode{}, nil, errs.New("unhandled selectors match behavior %q", bySelectors.Match)
+	}
+
+	node := idFilterNode{
+		idColumn: "registered_entry_id",
+	}
+	switch {
+	case count == 1:
+		node.query = []string{"SELECT registered_entry_id AS e_id FROM selectors WHERE type = ? AND value = ?"}
+	case bySelectors.Match == datastore.Subset, bySelectors.Match == datastore.MatchAny:
+		// Entries having any of the selectors. The conditions are wrapped in
+		// parenthesis since pagination may append further conditions.
+		node.query = append(node.query, "SELECT DISTINCT registered_entry_id AS e_id FROM selectors WHERE (")
+		appendSelectorConditions(&node, count)
+		node.query = append(node.query, ")")
+	case bySelectors.Match == datastore.Exact, bySelectors.Match == datastore.Superset:
+		// Entries having all of the selectors. Since selectors are unique per
+		// entry, an entry has all of them when every one of them matched.
+		node.query = append(node.query, "SELECT registered_entry_id AS e_id FROM selectors WHERE")
+		appendSelectorConditions(&node, count)
+		node.query = append(node.query, "GROUP BY registered_entry_id")
+		node.query = append(node.query, "HAVING COUNT(registered_entry_id) = ?")
+		args = append(args, count)
+	}
+	return node, args, nil
+}
+
+func appendSelectorConditions(node *idFilterNode, count int) {
+	for i := 0; i < count; i++ {
+		condition := "\t(type = ? AND value = ?)"
+		if i+1 < count {
+			condition += " OR"
+		}
+		node.query = append(node.query, condition)
+	}
+}
+
 func buildSliceArg(length int) string {
 	strBuilder := new(strings.Builder)
 	strBuilder.WriteString("(?")
@@ -3107,7 +3434,7 @@ func applyPagination(p *datastore.Pagination, entryTx *gorm.DB) (*gorm.DB, error
 	return entryTx, nil
 }
 
-func updateRegistrationEntry(tx *gorm.DB, e *common.RegistrationEntry, mask *common.RegistrationEntryMask) (*common.RegistrationEntry, error) {
+func updateRegistrationEntry(tx *gorm.DB, e *common.RegistrationEntry, mask *common.RegistrationEntryMask, expectedRevision *int64) (*common.RegistrationEntry, error) {
 	if err := validateRegistrationEntryForUpdate(e, mask); err != nil {
 		return nil, err
 	}
@@ -3117,10 +3444,20 @@ func updateRegistrationEntry(tx *gorm.DB, e *common.RegistrationEntry, mask *com
 	if err := tx.Find(&entry, "entry_id = ?", e.EntryId).Error; err != nil {
 		return nil, sqlError.Wrap(err)
 	}
+
+	// The entry row is locked by the transaction, so no other update can
+	// happen between this check and the save below
+	if expectedRevision != nil && *expectedRevision != entry.RevisionNumber {
+		return nil, status.Errorf(codes.Aborted, "expected revision number %d but found %d", *expectedRevision, entry.RevisionNumber)
+	}
 	if mask == nil || mask.StoreSvid {
 		entry.StoreSvid = e.StoreSvid
 	}
 	if mask == nil || mask.Selectors {
+		if err := validateSelectorSetReferences(tx, e.Selectors); err != nil {
+			return nil, err
+		}
+
 		// Delete existing selectors - we will write new ones
 		if err := tx.Exec("DELETE FROM selectors WHERE registered_entry_id = ?", entry.ID).Error; err != nil {
 			return nil, sqlError.Wrap(err)
@@ -3244,6 +3581,11 @@ func deleteRegistrationEntrySupport(tx *gorm.DB, entry RegisteredEntry) error {
 		return sqlError.Wrap(err)
 	}
 
+	// Record when the certificates issued for the entry were revoked
+	if err := tx.Exec("UPDATE issuance_records SET revoked_at = ? WHERE entry_id = ? AND revoked_at IS NULL", time.Now().UTC(), entry.EntryID).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+
 	return nil
 }
 
@@ -3509,6 +3851,489 @@ func modelToFederationRelationship(tx *gorm.DB, model *FederatedTrustDomain) (*d
 	return fr, nil
 }
 
+func deleteACMECacheEntry(tx *gorm.DB, key string) error {
+	if err := tx.Where("name = ?", key).Delete(&ACMECacheEntry{}).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func fetchACMECacheEntry(tx *gorm.DB, key string) ([]byte, error) {
+	var model ACMECacheEntry
+	err := tx.Find(&model, "name = ?", key).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, sqlError.Wrap(err)
+	}
+	return model.Data, nil
+}
+
+func setACMECacheEntry(tx *gorm.DB, key string, data []byte) error {
+	var model ACMECacheEntry
+	err := tx.Find(&model, "name = ?", key).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		model = ACMECacheEntry{Name: key, Data: data}
+		if err := tx.Create(&model).Error; err != nil {
+			return sqlError.Wrap(err)
+		}
+		return nil
+	case err != nil:
+		return sqlError.Wrap(err)
+	}
+
+	model.Data = data
+	if err := tx.Save(&model).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func deleteMirroredEntry(tx *gorm.DB, entryID string) error {
+	if err := tx.Where("entry_id = ?", entryID).Delete(&MirroredEntry{}).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func listMirroredEntries(tx *gorm.DB) ([]*datastore.MirroredEntry, error) {
+	var models []MirroredEntry
+	if err := tx.Order("id").Find(&models).Error; err != nil {
+		return nil, sqlError.Wrap(err)
+	}
+
+	entries := make([]*datastore.MirroredEntry, 0, len(models))
+	for _, model := range models {
+		mirror := new(types.Entry)
+		if err := proto.Unmarshal(model.Mirror, mirror); err != nil {
+			return nil, sqlError.Wrap(err)
+		}
+		entries = append(entries, &datastore.MirroredEntry{
+			EntryID:        model.EntryID,
+			PeerEntryID:    model.PeerEntryID,
+			RevisionNumber: model.RevisionNumber,
+			Mirror:         mirror,
+		})
+	}
+	return entries, nil
+}
+
+func setMirroredEntry(tx *gorm.DB, entry *datastore.MirroredEntry) error {
+	mirror, err := proto.Marshal(entry.Mirror)
+	if err != nil {
+		return sqlError.Wrap(err)
+	}
+
+	var model MirroredEntry
+	err = tx.Find(&model, "entry_id = ?", entry.EntryID).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		model = MirroredEntry{EntryID: entry.EntryID}
+	case err != nil:
+		return sqlError.Wrap(err)
+	}
+
+	model.PeerEntryID = entry.PeerEntryID
+	model.RevisionNumber = entry.RevisionNumber
+	model.Mirror = mirror
+	if err := tx.Save(&model).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func approvePendingAgent(tx *gorm.DB, spiffeID string) (*datastore.PendingAgent, error) {
+	var model PendingAgent
+	if err := tx.Find(&model, "spiffe_id = ?", spiffeID).Error; err != nil {
+		return nil, sqlError.Wrap(err)
+	}
+
+	model.Approved = true
+	if err := tx.Save(&model).Error; err != nil {
+		return nil, sqlError.Wrap(err)
+	}
+	return modelToPendingAgent(model), nil
+}
+
+func deletePendingAgent(tx *gorm.DB, spiffeID string) error {
+	if err := tx.Where("spiffe_id = ?", spiffeID).Delete(&PendingAgent{}).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func fetchPendingAgent(tx *gorm.DB, spiffeID string) (*datastore.PendingAgent, error) {
+	var model PendingAgent
+	err := tx.Find(&model, "spiffe_id = ?", spiffeID).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, sqlError.Wrap(err)
+	}
+	return modelToPendingAgent(model), nil
+}
+
+func listPendingAgents(tx *gorm.DB, req *datastore.ListPendingAgentsRequest) (*datastore.ListPendingAgentsResponse, error) {
+	tx = tx.Order("id")
+	if req.ByAttestationType != "" {
+		tx = tx.Where("attestation_type = ?", req.ByAttestationType)
+	}
+	if req.ByApproved != nil {
+		tx = tx.Where("approved = ?", *req.ByApproved)
+	}
+
+	var models []PendingAgent
+	if err := tx.Find(&models).Error; err != nil {
+		return nil, sqlError.Wrap(err)
+	}
+
+	resp := &datastore.ListPendingAgentsResponse{
+		Agents: make([]*datastore.PendingAgent, 0, len(models)),
+	}
+	for _, model := range models {
+		resp.Agents = append(resp.Agents, modelToPendingAgent(model))
+	}
+	return resp, nil
+}
+
+func setPendingAgent(tx *gorm.DB, agent *datastore.PendingAgent) (*datastore.PendingAgent, error) {
+	var model PendingAgent
+	err := tx.Find(&model, "spiffe_id = ?", agent.SpiffeID).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		model = PendingAgent{
+			SpiffeID:        agent.SpiffeID,
+			AttestationType: agent.AttestationType,
+			RequestedAt:     agent.LastAttemptAt,
+			LastAttemptAt:   agent.LastAttemptAt,
+		}
+		if err := tx.Create(&model).Error; err != nil {
+			return nil, sqlError.Wrap(err)
+		}
+		return modelToPendingAgent(model), nil
+	case err != nil:
+		return nil, sqlError.Wrap(err)
+	}
+
+	model.AttestationType = agent.AttestationType
+	model.LastAttemptAt = agent.LastAttemptAt
+	if err := tx.Save(&model).Error; err != nil {
+		return nil, sqlError.Wrap(err)
+	}
+	return modelToPendingAgent(model), nil
+}
+
+func modelToPendingAgent(model PendingAgent) *datastore.PendingAgent {
+	return &datastore.PendingAgent{
+		SpiffeID:        model.SpiffeID,
+		AttestationType: model.AttestationType,
+		Approved:        model.Approved,
+		RequestedAt:     model.RequestedAt.UTC(),
+		LastAttemptAt:   model.LastAttemptAt.UTC(),
+	}
+}
+
+func createAttestationEvent(tx *gorm.DB, event *datastore.AttestationEvent) error {
+	model := AttestationEvent{
+		SpiffeID:        event.SpiffeID,
+		AttestationType: event.AttestationType,
+		SerialNumber:    event.SerialNumber,
+		CanReattest:     event.CanReattest,
+		Reattestation:   event.Reattestation,
+		AttestedAt:      event.AttestedAt,
+		Error:           truncateAttestationError(event.Error),
+	}
+	if len(event.Selectors) > 0 {
+		selectors, err := proto.Marshal(&common.Selectors{Entries: event.Selectors})
+		if err != nil {
+			return sqlError.Wrap(err)
+		}
+		model.Selectors = selectors
+	}
+
+	if err := tx.Create(&model).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+// truncateAttestationError truncates the reason of a failed attestation to
+// the size of the error column.
+func truncateAttestationError(reason string) string {
+	if len(reason) <= maxAttestationErrorLength {
+		return reason
+	}
+	return strings.ToValidUTF8(reason[:maxAttestationErrorLength], "")
+}
+
+func listAttestationEvents(tx *gorm.DB, req *datastore.ListAttestationEventsRequest) (*datastore.ListAttestationEventsResponse, error) {
+	tx = tx.Order("id desc")
+	if req.BySpiffeID != "" {
+		tx = tx.Where("spiffe_id = ?", req.BySpiffeID)
+	}
+	if req.FailedOnly {
+		tx = tx.Where("error <> ''")
+	}
+	if req.Limit > 0 {
+		tx = tx.Limit(req.Limit)
+	}
+
+	var models []AttestationEvent
+	if err := tx.Find(&models).Error; err != nil {
+		return nil, sqlError.Wrap(err)
+	}
+
+	resp := &datastore.ListAttestationEventsResponse{
+		Events: make([]*datastore.AttestationEvent, 0, len(models)),
+	}
+	for _, model := range models {
+		event := &datastore.AttestationEvent{
+			SpiffeID:        model.SpiffeID,
+			AttestationType: model.AttestationType,
+			SerialNumber:    model.SerialNumber,
+			CanReattest:     model.CanReattest,
+			Reattestation:   model.Reattestation,
+			AttestedAt:      model.AttestedAt.UTC(),
+			Error:           model.Error,
+		}
+		if len(model.Selectors) > 0 {
+			selectors := new(common.Selectors)
+			if err := proto.Unmarshal(model.Selectors, selectors); err != nil {
+				return nil, sqlError.Wrap(err)
+			}
+			event.Selectors = selectors.Entries
+		}
+		resp.Events = append(resp.Events, event)
+	}
+	return resp, nil
+}
+
+func pruneAttestationEvents(tx *gorm.DB, succeededBefore, failedBefore time.Time) error {
+	if err := tx.Where("(error = '' AND attested_at < ?) OR (error <> '' AND attested_at < ?)", succeededBefore, failedBefore).Delete(&AttestationEvent{}).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func createIssuanceRecord(tx *gorm.DB, record *datastore.IssuanceRecord) error {
+	model := IssuanceRecord{
+		SerialNumber: record.SerialNumber,
+		SpiffeID:     record.SpiffeID,
+		EntryID:      record.EntryID,
+		ExpiresAt:    record.ExpiresAt,
+	}
+
+	if err := tx.Create(&model).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func listIssuanceRecords(tx *gorm.DB, req *datastore.ListIssuanceRecordsRequest) (*datastore.ListIssuanceRecordsResponse, error) {
+	p := req.Pagination
+	var err error
+	if p != nil {
+		tx, err = applyPagination(p, tx)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		tx = tx.Order("id asc")
+	}
+
+	if req.BySerialNumber != "" {
+		tx = tx.Where("serial_number = ?", req.BySerialNumber)
+	}
+	if req.BySpiffeID != "" {
+		tx = tx.Where("spiffe_id = ?", req.BySpiffeID)
+	}
+	if req.ByEntryID != "" {
+		tx = tx.Where("entry_id = ?", req.ByEntryID)
+	}
+
+	var models []IssuanceRecord
+	if err := tx.Find(&models).Error; err != nil {
+		return nil, sqlError.Wrap(err)
+	}
+
+	if p != nil {
+		p.Token = ""
+		if len(models) > 0 {
+			p.Token = fmt.Sprint(models[len(models)-1].ID)
+		}
+	}
+
+	resp := &datastore.ListIssuanceRecordsResponse{
+		Pagination: p,
+		Records:    make([]*datastore.IssuanceRecord, 0, len(models)),
+	}
+	for _, model := range models {
+		record := &datastore.IssuanceRecord{
+			SerialNumber: model.SerialNumber,
+			SpiffeID:     model.SpiffeID,
+			EntryID:      model.EntryID,
+			ExpiresAt:    model.ExpiresAt.UTC(),
+		}
+		if model.RevokedAt != nil {
+			record.RevokedAt = model.RevokedAt.UTC()
+		}
+		resp.Records = append(resp.Records, record)
+	}
+	return resp, nil
+}
+
+func pruneIssuanceRecords(tx *gorm.DB, expiredBefore time.Time) error {
+	if err := tx.Where("expires_at < ?", expiredBefore).Delete(&IssuanceRecord{}).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func createSelectorSet(tx *gorm.DB, set *datastore.SelectorSet) (*datastore.SelectorSet, error) {
+	model := SelectorSet{
+		Name:      set.Name,
+		Selectors: selectorSetSelectorsToModel(set.Selectors),
+	}
+
+	if err := tx.Create(&model).Error; err != nil {
+		return nil, sqlError.Wrap(err)
+	}
+
+	return modelToSelectorSet(model), nil
+}
+
+func deleteSelectorSet(tx *gorm.DB, name string) error {
+	model := new(SelectorSet)
+	if err := tx.Find(model, "name = ?", name).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+
+	var refCount int
+	if err := tx.Model(&Selector{}).Where("type = ? AND value = ?", datastore.SelectorSetType, name).Count(&refCount).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	if refCount > 0 {
+		return status.Errorf(codes.FailedPrecondition, "cannot delete selector set; it is referenced by %d registration entries", refCount)
+	}
+
+	if err := tx.Where(&SelectorSetSelector{SelectorSetID: model.ID}).Delete(&SelectorSetSelector{}).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	if err := tx.Delete(model).Error; err != nil {
+		return sqlError.Wrap(err)
+	}
+	return nil
+}
+
+func fetchSelectorSet(tx *gorm.DB, name string) (*datastore.SelectorSet, error) {
+	var model SelectorSet
+	err := tx.Preload("Selectors").Find(&model, "name = ?", name).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, sqlError.Wrap(err)
+	}
+
+	return modelToSelectorSet(model), nil
+}
+
+func listSelectorSets(tx *gorm.DB) ([]*datastore.SelectorSet, error) {
+	var models []SelectorSet
+	if err := tx.Preload("Selectors").Order("name").Find(&models).Error; err != nil {
+		return nil, sqlError.Wrap(err)
+	}
+
+	sets := make([]*datastore.SelectorSet, 0, len(models))
+	for _, model := range models {
+		sets = append(sets, modelToSelectorSet(model))
+	}
+	return sets, nil
+}
+
+func updateSelectorSet(tx *gorm.DB, set *datastore.SelectorSet) (*datastore.SelectorSet, error) {
+	var model SelectorSet
+	if err := tx.Find(&model, "name = ?", set.Name).Error; err != nil {
+		return nil, sqlError.Wrap(err)
+	}
+
+	// Replace the selectors of the set as a whole, so the entries that
+	// reference it never observe a partial update.
+	if err := tx.Where(&SelectorSetSelector{SelectorSetID: model.ID}).Delete(&SelectorSetSelector{}).Error; err != nil {
+		return nil, sqlError.Wrap(err)
+	}
+	model.Selectors = selectorSetSelectorsToModel(set.Selectors)
+	if err := tx.Save(&model).Error; err != nil {
+		return nil, sqlError.Wrap(err)
+	}
+
+	return modelToSelectorSet(model), nil
+}
+
+func validateSelectorSet(set *datastore.SelectorSet) error {
+	switch {
+	case set == nil:
+		return status.Error(codes.InvalidArgument, "selector set is nil")
+	case set.Name == "":
+		return status.Error(codes.InvalidArgument, "selector set name is required")
+	case len(set.Selectors) == 0:
+		return status.Error(codes.InvalidArgument, "selector set must have at least one selector")
+	}
+	for _, selector := range set.Selectors {
+		if selector.Type == datastore.SelectorSetType {
+			return status.Error(codes.InvalidArgument, "selector set cannot reference another selector set")
+		}
+	}
+	return nil
+}
+
+// validateSelectorSetReferences checks that the selector sets referenced by
+// the selectors of a registration entry exist. It runs in the transaction
+// writing the entry, so an entry cannot be written with a reference to a set
+// that is not there.
+func validateSelectorSetReferences(tx *gorm.DB, selectors []*common.Selector) error {
+	for _, selector := range selectors {
+		if selector.Type != datastore.SelectorSetType {
+			continue
+		}
+		var count int
+		if err := tx.Model(&SelectorSet{}).Where("name = ?", selector.Value).Count(&count).Error; err != nil {
+			return sqlError.Wrap(err)
+		}
+		if count == 0 {
+			return status.Errorf(codes.InvalidArgument, "invalid registration entry: selector set %q does not exist", selector.Value)
+		}
+	}
+	return nil
+}
+
+func selectorSetSelectorsToModel(selectors []*common.Selector) []SelectorSetSelector {
+	models := make([]SelectorSetSelector, 0, len(selectors))
+	for _, selector := range selectors {
+		models = append(models, SelectorSetSelector{
+			Type:  selector.Type,
+			Value: selector.Value,
+		})
+	}
+	return models
+}
+
+func modelToSelectorSet(model SelectorSet) *datastore.SelectorSet {
+	set := &datastore.SelectorSet{
+		Name: model.Name,
+	}
+	for _, selector := range model.Selectors {
+		set.Selectors = append(set.Selectors, &common.Selector{
+			Type:  selector.Type,
+			Value: selector.Value,
+		})
+	}
+	return set
+}
+
 // modelToBundle converts the given bundle model to a Protobuf bundle message. It will also
 // include any embedded CACert models.
 func modelToBundle(model *Bundle) (*common.Bundle, error) {
@@ -3660,11 +4485,26 @@ func modelToEntry(tx *gorm.DB, model RegisteredEntry) (*common.RegistrationEntry
 	}, nil
 }
 
+// newRegistrationEntryID returns a new registration entry ID. IDs are laid
+// out as version 7 UUIDs: they start with the creation time, so they sort by
+// creation time, and are otherwise random.
 func newRegistrationEntryID() (string, error) {
-	u, err := uuid.NewV4()
-	if err != nil {
+	return newTimeOrderedID(time.Now())
+}
+
+func newTimeOrderedID(now time.Time) (string, error) {
+	var u uuid.UUID
+
+	// 48 bits of milliseconds since the Unix epoch, followed by random bits
+	// and the version and variant.
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(now.UnixMilli()))
+	copy(u[:6], ts[2:])
+	if _, err := rand.Read(u[6:]); err != nil {
 		return "", err
 	}
+	u.SetVersion(7)
+	u.SetVariant(uuid.VariantRFC4122)
 	return u.String(), nil
 }
 
@@ -3739,7 +4579,12 @@ func (cfg *configuration) Validate() error {
 		return sqlError.New("connection_string must be set")
 	}
 
-	if cfg.DatabaseType == MySQL {
+	if cfg.IAMAuth != nil && cfg.DatabaseType != PostgreSQL {
+		return sqlError.New("iam_auth is only supported by %s", PostgreSQL)
+	}
+
+	switch cfg.DatabaseType {
+	case MySQL:
 		if err := validateMySQLConfig(cfg, false); err != nil {
 			return err
 		}
@@ -3749,6 +4594,16 @@ func (cfg *configuration) Validate() error {
 				return err
 			}
 		}
+	case PostgreSQL:
+		if err := validatePostgresConfig(cfg, false); err != nil {
+			return err
+		}
+
+		if cfg.RoConnectionString != "" {
+			if err := validatePostgresConfig(cfg, true); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
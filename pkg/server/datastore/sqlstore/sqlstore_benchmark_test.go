@@ -0,0 +1,91 @@
+package sqlstore
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/spire/pkg/server/datastore"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/stretchr/testify/require"
+)
+
+// BenchmarkListRegistrationEntriesBySelectors measures selector based entry
+// lookups, the most common query issued by the server when agents sync.
+func BenchmarkListRegistrationEntriesBySelectors(b *testing.B) {
+	for _, numEntries := range []int{1000, 10000} {
+		ds := newBenchmarkPlugin(b, numEntries)
+
+		for _, numSelectors := range []int{1, 3, 10} {
+			selectors := benchmarkSelectors(numEntries/2, numSelectors)
+			for _, match := range []struct {
+				name     string
+				behavior datastore.MatchBehavior
+			}{
+				{name: "exact", behavior: datastore.Exact},
+				{name: "subset", behavior: datastore.Subset},
+				{name: "superset", behavior: datastore.Superset},
+				{name: "any", behavior: datastore.MatchAny},
+			} {
+				name := fmt.Sprintf("entries=%d/selectors=%d/match=%s", numEntries, numSelectors, match.name)
+				b.Run(name, func(b *testing.B) {
+					req := &datastore.ListRegistrationEntriesRequest{
+						BySelectors: &datastore.BySelectors{
+							Selectors: selectors,
+							Match:     match.behavior,
+						},
+					}
+					b.ResetTimer()
+					for i := 0; i < b.N; i++ {
+						_, err := ds.ListRegistrationEntries(ctx, req)
+						if err != nil {
+							b.Fatal(err)
+						}
+					}
+				})
+			}
+		}
+
+		ds.Close()
+	}
+}
+
+func newBenchmarkPlugin(b *testing.B, numEntries int) *Plugin {
+	log, _ := test.NewNullLogger()
+	ds := New(log)
+
+	dbPath := filepath.ToSlash(filepath.Join(b.TempDir(), "bench.sqlite3"))
+	err := ds.Configure(ctx, fmt.Sprintf(`
+		database_type = "sqlite3"
+		connection_string = "file://%s"
+	`, dbPath))
+	require.NoError(b, err)
+
+	for i := 0; i < numEntries; i++ {
+		_, err := ds.CreateRegistrationEntry(ctx, &common.RegistrationEntry{
+			SpiffeId:  fmt.Sprintf("spiffe://example.org/workload-%d", i),
+			ParentId:  fmt.Sprintf("spiffe://example.org/agent-%d", i%100),
+			Selectors: benchmarkSelectors(i, 10),
+		})
+		require.NoError(b, err)
+	}
+	return ds
+}
+
+// benchmarkSelectors returns the selectors for the nth entry. The first
+// selectors are shared with other entries, while the last one is unique to
+// the entry, which mimics common Kubernetes deployments.
+func benchmarkSelectors(n, count int) []*common.Selector {
+	selectors := make([]*common.Selector, 0, count)
+	for i := 0; i < count-1; i++ {
+		selectors = append(selectors, &common.Selector{
+			Type:  "k8s",
+			Value: fmt.Sprintf("label-%d:%d", i, n%(10*(i+1))),
+		})
+	}
+	return append(selectors, &common.Selector{
+		Type:  "k8s",
+		Value: fmt.Sprintf("pod-uid:%d", n),
+	})
+}
@@ -17,6 +17,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
@@ -1298,6 +1299,28 @@ func (s *PluginSuite) TestSetNodeSelectorsUnderLoad() {
 	}
 }
 
+func TestNewTimeOrderedID(t *testing.T) {
+	now := time.Now()
+
+	first, err := newTimeOrderedID(now)
+	require.NoError(t, err)
+	second, err := newTimeOrderedID(now)
+	require.NoError(t, err)
+	later, err := newTimeOrderedID(now.Add(time.Millisecond))
+	require.NoError(t, err)
+
+	// IDs created at the same time are still unique, and later IDs sort
+	// after earlier ones
+	require.NotEqual(t, first, second)
+	require.Less(t, first, later)
+	require.Less(t, second, later)
+
+	u, err := uuid.FromString(first)
+	require.NoError(t, err)
+	require.Equal(t, byte(7), u.Version())
+	require.Equal(t, uuid.VariantRFC4122, u.Variant())
+}
+
 func (s *PluginSuite) TestCreateRegistrationEntry() {
 	var validRegistrationEntries []*common.RegistrationEntry
 	s.getTestDataFromJSONFile(filepath.Join("testdata", "valid_registration_entries.json"), &validRegistrationEntries)
@@ -2227,6 +2250,40 @@ func (s *PluginSuite) TestUpdateRegistrationEntry() {
 	s.RequireGRPCStatus(err, codes.NotFound, _notFoundErrMsg)
 }
 
+func (s *PluginSuite) TestCompareAndUpdateRegistrationEntry() {
+	entry := s.createRegistrationEntry(&common.RegistrationEntry{
+		Selectors: []*common.Selector{
+			{Type: "Type1", Value: "Value1"},
+		},
+		SpiffeId: "spiffe://example.org/foo",
+		ParentId: "spiffe://example.org/bar",
+		Ttl:      1,
+	})
+	s.Require().Equal(int64(0), entry.RevisionNumber)
+
+	mask := &common.RegistrationEntryMask{Ttl: true}
+
+	// Update with the current revision number succeeds
+	entry.Ttl = 2
+	updated, err := s.ds.CompareAndUpdateRegistrationEntry(ctx, entry, mask, 0)
+	s.Require().NoError(err)
+	s.Require().Equal(int32(2), updated.Ttl)
+	s.Require().Equal(int64(1), updated.RevisionNumber)
+
+	// Update with a stale revision number is aborted and the entry is left untouched
+	entry.Ttl = 3
+	_, err = s.ds.CompareAndUpdateRegistrationEntry(ctx, entry, mask, 0)
+	s.RequireGRPCStatus(err, codes.Aborted, "expected revision number 0 but found 1")
+
+	fetched, err := s.ds.FetchRegistrationEntry(ctx, entry.EntryId)
+	s.Require().NoError(err)
+	s.RequireProtoEqual(updated, fetched)
+
+	entry.EntryId = "badid"
+	_, err = s.ds.CompareAndUpdateRegistrationEntry(ctx, entry, mask, 1)
+	s.RequireGRPCStatus(err, codes.NotFound, _notFoundErrMsg)
+}
+
 func (s *PluginSuite) TestUpdateRegistrationEntryWithStoreSvid() {
 	entry := s.createRegistrationEntry(&common.RegistrationEntry{
 		Selectors: []*common.Selector{
@@ -2593,6 +2650,17 @@ func (s *PluginSuite) TestListSelectorEntries() {
 			},
 			expectedList: []*common.RegistrationEntry{allEntries[0]},
 		},
+		{
+			name:                "entries_by_duplicated_selectors_found",
+			registrationEntries: allEntries,
+			selectors: []*common.Selector{
+				{Type: "a", Value: "1"},
+				{Type: "b", Value: "2"},
+				{Type: "a", Value: "1"},
+				{Type: "c", Value: "3"},
+			},
+			expectedList: []*common.RegistrationEntry{allEntries[0]},
+		},
 		{
 			name:                "entries_by_selector_not_found",
 			registrationEntries: allEntries,
@@ -3888,6 +3956,553 @@ func (s *PluginSuite) TestUpdateFederationRelationship() {
 	}
 }
 
+func (s *PluginSuite) TestIssuanceRecords() {
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second).UTC()
+	records := []*datastore.IssuanceRecord{
+		{SerialNumber: "1", SpiffeID: "spiffe://example.org/workload1", EntryID: "entry1", ExpiresAt: expiresAt},
+		{SerialNumber: "2", SpiffeID: "spiffe://example.org/workload2", EntryID: "entry2", ExpiresAt: expiresAt},
+		{SerialNumber: "3", SpiffeID: "spiffe://example.org/workload1", ExpiresAt: expiresAt},
+	}
+	for _, record := range records {
+		s.Require().NoError(s.ds.CreateIssuanceRecord(ctx, record))
+	}
+
+	err := s.ds.CreateIssuanceRecord(ctx, &datastore.IssuanceRecord{SpiffeID: "spiffe://example.org/workload1"})
+	s.RequireGRPCStatus(err, codes.InvalidArgument, "issuance record serial number is required")
+
+	for _, tt := range []struct {
+		name       string
+		req        *datastore.ListIssuanceRecordsRequest
+		expRecords []*datastore.IssuanceRecord
+	}{
+		{
+			name:       "all",
+			req:        &datastore.ListIssuanceRecordsRequest{},
+			expRecords: records,
+		},
+		{
+			name:       "by serial number",
+			req:        &datastore.ListIssuanceRecordsRequest{BySerialNumber: "2"},
+			expRecords: records[1:2],
+		},
+		{
+			name:       "by SPIFFE ID",
+			req:        &datastore.ListIssuanceRecordsRequest{BySpiffeID: "spiffe://example.org/workload1"},
+			expRecords: []*datastore.IssuanceRecord{records[0], records[2]},
+		},
+		{
+			name:       "by entry ID",
+			req:        &datastore.ListIssuanceRecordsRequest{ByEntryID: "entry1"},
+			expRecords: records[:1],
+		},
+	} {
+		tt := tt
+		s.T().Run(tt.name, func(t *testing.T) {
+			resp, err := s.ds.ListIssuanceRecords(ctx, tt.req)
+			require.NoError(t, err)
+			require.Equal(t, tt.expRecords, resp.Records)
+		})
+	}
+
+	// Paginated
+	pagination := &datastore.Pagination{PageSize: 2}
+	resp, err := s.ds.ListIssuanceRecords(ctx, &datastore.ListIssuanceRecordsRequest{Pagination: pagination})
+	s.Require().NoError(err)
+	s.Require().Equal(records[:2], resp.Records)
+	resp, err = s.ds.ListIssuanceRecords(ctx, &datastore.ListIssuanceRecordsRequest{Pagination: resp.Pagination})
+	s.Require().NoError(err)
+	s.Require().Equal(records[2:], resp.Records)
+
+	// Pruning only deletes the records of the certificates that expired
+	// before the given time
+	expired := &datastore.IssuanceRecord{SerialNumber: "4", SpiffeID: "spiffe://example.org/workload1", ExpiresAt: expiresAt.Add(-2 * time.Hour)}
+	s.Require().NoError(s.ds.CreateIssuanceRecord(ctx, expired))
+	s.Require().NoError(s.ds.PruneIssuanceRecords(ctx, expiresAt))
+	resp, err = s.ds.ListIssuanceRecords(ctx, &datastore.ListIssuanceRecordsRequest{})
+	s.Require().NoError(err)
+	s.Require().Equal(records, resp.Records)
+
+	// Deleting an entry revokes the certificates issued for it
+	entry := s.createRegistrationEntry(&common.RegistrationEntry{
+		Selectors: []*common.Selector{{Type: "Type1", Value: "Value1"}},
+		SpiffeId:  "spiffe://example.org/workload3",
+		ParentId:  "spiffe://example.org/agent",
+	})
+	s.Require().NoError(s.ds.CreateIssuanceRecord(ctx, &datastore.IssuanceRecord{SerialNumber: "5", SpiffeID: entry.SpiffeId, EntryID: entry.EntryId, ExpiresAt: expiresAt}))
+	beforeDelete := time.Now().Add(-time.Second)
+	_, err = s.ds.DeleteRegistrationEntry(ctx, entry.EntryId)
+	s.Require().NoError(err)
+	resp, err = s.ds.ListIssuanceRecords(ctx, &datastore.ListIssuanceRecordsRequest{ByEntryID: entry.EntryId})
+	s.Require().NoError(err)
+	s.Require().Len(resp.Records, 1)
+	s.Require().True(resp.Records[0].RevokedAt.After(beforeDelete))
+	resp, err = s.ds.ListIssuanceRecords(ctx, &datastore.ListIssuanceRecordsRequest{})
+	s.Require().NoError(err)
+	for _, record := range resp.Records[:3] {
+		s.Require().True(record.RevokedAt.IsZero())
+	}
+}
+
+func (s *PluginSuite) TestACMECacheEntries() {
+	// Fetching or deleting a missing entry is not an error
+	data, err := s.ds.FetchACMECacheEntry(ctx, "example.org")
+	s.Require().NoError(err)
+	s.Require().Nil(data)
+	s.Require().NoError(s.ds.DeleteACMECacheEntry(ctx, "example.org"))
+
+	err = s.ds.SetACMECacheEntry(ctx, "", []byte("DATA"))
+	s.RequireGRPCStatus(err, codes.InvalidArgument, "ACME cache entry key is required")
+
+	s.Require().NoError(s.ds.SetACMECacheEntry(ctx, "example.org", []byte("CERT1")))
+	s.Require().NoError(s.ds.SetACMECacheEntry(ctx, "acme_account+key", []byte("ACCOUNT")))
+
+	data, err = s.ds.FetchACMECacheEntry(ctx, "example.org")
+	s.Require().NoError(err)
+	s.Require().Equal([]byte("CERT1"), data)
+
+	// Setting an existing entry replaces the data
+	s.Require().NoError(s.ds.SetACMECacheEntry(ctx, "example.org", []byte("CERT2")))
+	data, err = s.ds.FetchACMECacheEntry(ctx, "example.org")
+	s.Require().NoError(err)
+	s.Require().Equal([]byte("CERT2"), data)
+
+	s.Require().NoError(s.ds.DeleteACMECacheEntry(ctx, "example.org"))
+	data, err = s.ds.FetchACMECacheEntry(ctx, "example.org")
+	s.Require().NoError(err)
+	s.Require().Nil(data)
+
+	// Other entries are left alone
+	data, err = s.ds.FetchACMECacheEntry(ctx, "acme_account+key")
+	s.Require().NoError(err)
+	s.Require().Equal([]byte("ACCOUNT"), data)
+}
+
+func (s *PluginSuite) TestMirroredEntries() {
+	// Deleting a missing record is not an error
+	s.Require().NoError(s.ds.DeleteMirroredEntry(ctx, "entry-1"))
+
+	err := s.ds.SetMirroredEntry(ctx, &datastore.MirroredEntry{PeerEntryID: "peer-1"})
+	s.RequireGRPCStatus(err, codes.InvalidArgument, "invalid request: missing entry ID")
+	err = s.ds.SetMirroredEntry(ctx, &datastore.MirroredEntry{EntryID: "entry-1"})
+	s.RequireGRPCStatus(err, codes.InvalidArgument, "invalid request: missing peer entry ID")
+
+	entry1 := &datastore.MirroredEntry{
+		EntryID:        "entry-1",
+		PeerEntryID:    "peer-1",
+		RevisionNumber: 1,
+		Mirror: &types.Entry{
+			Id:       "peer-1",
+			SpiffeId: &types.SPIFFEID{TrustDomain: "peer.test", Path: "/workload"},
+			ParentId: &types.SPIFFEID{TrustDomain: "peer.test", Path: "/spire/agent/node"},
+			Ttl:      60,
+		},
+	}
+	entry2 := &datastore.MirroredEntry{
+		EntryID:     "entry-2",
+		PeerEntryID: "peer-2",
+		Mirror:      &types.Entry{Id: "peer-2"},
+	}
+	s.Require().NoError(s.ds.SetMirroredEntry(ctx, entry1))
+	s.Require().NoError(s.ds.SetMirroredEntry(ctx, entry2))
+
+	entries, err := s.ds.ListMirroredEntries(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(entries, 2)
+	spiretest.AssertProtoEqual(s.T(), entry1.Mirror, entries[0].Mirror)
+	s.Require().Equal("peer-1", entries[0].PeerEntryID)
+	s.Require().Equal(int64(1), entries[0].RevisionNumber)
+	s.Require().Equal("entry-2", entries[1].EntryID)
+
+	// Setting an existing record replaces it
+	entry1.RevisionNumber = 2
+	entry1.Mirror.Ttl = 120
+	s.Require().NoError(s.ds.SetMirroredEntry(ctx, entry1))
+	s.Require().NoError(s.ds.DeleteMirroredEntry(ctx, "entry-2"))
+
+	entries, err = s.ds.ListMirroredEntries(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(entries, 1)
+	s.Require().Equal(int64(2), entries[0].RevisionNumber)
+	spiretest.AssertProtoEqual(s.T(), entry1.Mirror, entries[0].Mirror)
+}
+
+func (s *PluginSuite) TestAttestationEvents() {
+	attestedAt := time.Now().Truncate(time.Second).UTC()
+	events := []*datastore.AttestationEvent{
+		{SpiffeID: "spiffe://example.org/spire/agent/a", AttestationType: "join_token", SerialNumber: "1", AttestedAt: attestedAt},
+		{SpiffeID: "spiffe://example.org/spire/agent/b", AttestationType: "x509pop", SerialNumber: "2", CanReattest: true, AttestedAt: attestedAt},
+		{SpiffeID: "spiffe://example.org/spire/agent/b", AttestationType: "x509pop", SerialNumber: "3", CanReattest: true, Reattestation: true, AttestedAt: attestedAt.Add(time.Minute)},
+	}
+	for _, event := range events {
+		s.Require().NoError(s.ds.CreateAttestationEvent(ctx, event))
+	}
+
+	err := s.ds.CreateAttestationEvent(ctx, &datastore.AttestationEvent{AttestationType: "join_token"})
+	s.RequireGRPCStatus(err, codes.InvalidArgument, "attestation event SPIFFE ID is required")
+
+	for _, tt := range []struct {
+		name      string
+		req       *datastore.ListAttestationEventsRequest
+		expEvents []*datastore.AttestationEvent
+	}{
+		{
+			name:      "all",
+			req:       &datastore.ListAttestationEventsRequest{},
+			expEvents: []*datastore.AttestationEvent{events[2], events[1], events[0]},
+		},
+		{
+			name:      "by SPIFFE ID",
+			req:       &datastore.ListAttestationEventsRequest{BySpiffeID: "spiffe://example.org/spire/agent/b"},
+			expEvents: []*datastore.AttestationEvent{events[2], events[1]},
+		},
+		{
+			name:      "with limit",
+			req:       &datastore.ListAttestationEventsRequest{BySpiffeID: "spiffe://example.org/spire/agent/b", Limit: 1},
+			expEvents: []*datastore.AttestationEvent{events[2]},
+		},
+	} {
+		tt := tt
+		s.T().Run(tt.name, func(t *testing.T) {
+			resp, err := s.ds.ListAttestationEvents(ctx, tt.req)
+			require.NoError(t, err)
+			require.Equal(t, tt.expEvents, resp.Events)
+		})
+	}
+}
+
+func (s *PluginSuite) TestAttestationFailures() {
+	failedAt := time.Now().Truncate(time.Second).UTC()
+	success := &datastore.AttestationEvent{SpiffeID: "spiffe://example.org/spire/agent/a", AttestationType: "x509pop", SerialNumber: "1", AttestedAt: failedAt.Add(-time.Hour)}
+	oldFailure := &datastore.AttestationEvent{AttestationType: "join_token", Error: "failed to attest: join token does not exist or has already been used", AttestedAt: failedAt.Add(-time.Hour)}
+	failure := &datastore.AttestationEvent{
+		SpiffeID:        "spiffe://example.org/spire/agent/a",
+		AttestationType: "x509pop",
+		Error:           "failed to attest: agent is banned",
+		AttestedAt:      failedAt,
+		Selectors:       []*common.Selector{{Type: "x509pop", Value: "subject:cn:a"}},
+	}
+	for _, event := range []*datastore.AttestationEvent{success, oldFailure, failure} {
+		s.Require().NoError(s.ds.CreateAttestationEvent(ctx, event))
+	}
+
+	// Long reasons are truncated to fit the column
+	s.Require().NoError(s.ds.CreateAttestationEvent(ctx, &datastore.AttestationEvent{
+		AttestationType: "join_token",
+		Error:           strings.Repeat("x", 300),
+		AttestedAt:      failedAt,
+	}))
+
+	resp, err := s.ds.ListAttestationEvents(ctx, &datastore.ListAttestationEventsRequest{FailedOnly: true})
+	s.Require().NoError(err)
+	s.Require().Len(resp.Events, 3)
+	s.Require().Equal(strings.Repeat("x", 255), resp.Events[0].Error)
+	s.Require().Equal(failure.Error, resp.Events[1].Error)
+	s.Require().Equal(failure.SpiffeID, resp.Events[1].SpiffeID)
+	s.RequireProtoListEqual(failure.Selectors, resp.Events[1].Selectors)
+	s.Require().Equal(oldFailure, resp.Events[2])
+
+	resp, err = s.ds.ListAttestationEvents(ctx, &datastore.ListAttestationEventsRequest{
+		BySpiffeID: "spiffe://example.org/spire/agent/a",
+	})
+	s.Require().NoError(err)
+	s.Require().Len(resp.Events, 2)
+	s.Require().Equal(success, resp.Events[1])
+
+	// Successes and failures are pruned with their own retention
+	s.Require().NoError(s.ds.PruneAttestationEvents(ctx, failedAt.Add(-2*time.Hour), failedAt.Add(-time.Minute)))
+	resp, err = s.ds.ListAttestationEvents(ctx, &datastore.ListAttestationEventsRequest{})
+	s.Require().NoError(err)
+	s.Require().Len(resp.Events, 3)
+	s.Require().Equal(success, resp.Events[2])
+
+	s.Require().NoError(s.ds.PruneAttestationEvents(ctx, failedAt, failedAt.Add(-time.Minute)))
+	resp, err = s.ds.ListAttestationEvents(ctx, &datastore.ListAttestationEventsRequest{})
+	s.Require().NoError(err)
+	s.Require().Len(resp.Events, 2)
+	for _, event := range resp.Events {
+		s.Require().NotEmpty(event.Error)
+	}
+}
+
+func (s *PluginSuite) TestPendingAgents() {
+	requestedAt := time.Now().Truncate(time.Second).UTC()
+	agentA := &datastore.PendingAgent{
+		SpiffeID:        "spiffe://example.org/spire/agent/a",
+		AttestationType: "join_token",
+		RequestedAt:     requestedAt,
+		LastAttemptAt:   requestedAt,
+	}
+	agentB := &datastore.PendingAgent{
+		SpiffeID:        "spiffe://example.org/spire/agent/b",
+		AttestationType: "x509pop",
+		RequestedAt:     requestedAt,
+		LastAttemptAt:   requestedAt,
+	}
+
+	agent, err := s.ds.FetchPendingAgent(ctx, agentA.SpiffeID)
+	s.Require().NoError(err)
+	s.Require().Nil(agent)
+
+	_, err = s.ds.SetPendingAgent(ctx, &datastore.PendingAgent{AttestationType: "join_token"})
+	s.RequireGRPCStatus(err, codes.InvalidArgument, "pending agent SPIFFE ID is required")
+
+	_, err = s.ds.ApprovePendingAgent(ctx, agentA.SpiffeID)
+	s.RequireGRPCStatus(err, codes.NotFound, _notFoundErrMsg)
+
+	for _, pending := range []*datastore.PendingAgent{agentA, agentB} {
+		agent, err = s.ds.SetPendingAgent(ctx, &datastore.PendingAgent{
+			SpiffeID:        pending.SpiffeID,
+			AttestationType: pending.AttestationType,
+			LastAttemptAt:   pending.LastAttemptAt,
+		})
+		s.Require().NoError(err)
+		s.Require().Equal(pending, agent)
+	}
+
+	// Approving an agent is kept when it attests again
+	agentA.Approved = true
+	agent, err = s.ds.ApprovePendingAgent(ctx, agentA.SpiffeID)
+	s.Require().NoError(err)
+	s.Require().Equal(agentA, agent)
+
+	agentA.LastAttemptAt = requestedAt.Add(time.Minute)
+	agent, err = s.ds.SetPendingAgent(ctx, &datastore.PendingAgent{
+		SpiffeID:        agentA.SpiffeID,
+		AttestationType: agentA.AttestationType,
+		LastAttemptAt:   agentA.LastAttemptAt,
+	})
+	s.Require().NoError(err)
+	s.Require().Equal(agentA, agent)
+
+	agent, err = s.ds.FetchPendingAgent(ctx, agentA.SpiffeID)
+	s.Require().NoError(err)
+	s.Require().Equal(agentA, agent)
+
+	approvedFalse := false
+	for _, tt := range []struct {
+		name      string
+		req       *datastore.ListPendingAgentsRequest
+		expAgents []*datastore.PendingAgent
+	}{
+		{
+			name:      "all",
+			req:       &datastore.ListPendingAgentsRequest{},
+			expAgents: []*datastore.PendingAgent{agentA, agentB},
+		},
+		{
+			name:      "by attestation type",
+			req:       &datastore.ListPendingAgentsRequest{ByAttestationType: "x509pop"},
+			expAgents: []*datastore.PendingAgent{agentB},
+		},
+		{
+			name:      "by approved",
+			req:       &datastore.ListPendingAgentsRequest{ByApproved: &approvedFalse},
+			expAgents: []*datastore.PendingAgent{agentB},
+		},
+	} {
+		tt := tt
+		s.T().Run(tt.name, func(t *testing.T) {
+			resp, err := s.ds.ListPendingAgents(ctx, tt.req)
+			require.NoError(t, err)
+			require.Equal(t, tt.expAgents, resp.Agents)
+		})
+	}
+
+	s.Require().NoError(s.ds.DeletePendingAgent(ctx, agentA.SpiffeID))
+	s.Require().NoError(s.ds.DeletePendingAgent(ctx, agentA.SpiffeID))
+	agent, err = s.ds.FetchPendingAgent(ctx, agentA.SpiffeID)
+	s.Require().NoError(err)
+	s.Require().Nil(agent)
+}
+
+func (s *PluginSuite) TestCreateSelectorSet() {
+	selectors := []*common.Selector{
+		{Type: "docker", Value: "image_id:base1"},
+		{Type: "docker", Value: "image_id:base2"},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		set    *datastore.SelectorSet
+		expErr string
+	}{
+		{
+			name: "success",
+			set:  &datastore.SelectorSet{Name: "base-images", Selectors: selectors},
+		},
+		{
+			name:   "nil selector set",
+			expErr: "rpc error: code = InvalidArgument desc = selector set is nil",
+		},
+		{
+			name:   "missing name",
+			set:    &datastore.SelectorSet{Selectors: selectors},
+			expErr: "rpc error: code = InvalidArgument desc = selector set name is required",
+		},
+		{
+			name:   "missing selectors",
+			set:    &datastore.SelectorSet{Name: "empty"},
+			expErr: "rpc error: code = InvalidArgument desc = selector set must have at least one selector",
+		},
+		{
+			name: "nested selector set",
+			set: &datastore.SelectorSet{
+				Name:      "nested",
+				Selectors: []*common.Selector{{Type: datastore.SelectorSetType, Value: "base-images"}},
+			},
+			expErr: "rpc error: code = InvalidArgument desc = selector set cannot reference another selector set",
+		},
+	} {
+		tt := tt
+		s.T().Run(tt.name, func(t *testing.T) {
+			created, err := s.ds.CreateSelectorSet(ctx, tt.set)
+			if tt.expErr != "" {
+				require.EqualError(t, err, tt.expErr)
+				require.Nil(t, created)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.set.Name, created.Name)
+			spiretest.RequireProtoListEqual(t, tt.set.Selectors, created.Selectors)
+
+			fetched, err := s.ds.FetchSelectorSet(ctx, tt.set.Name)
+			require.NoError(t, err)
+			require.Equal(t, tt.set.Name, fetched.Name)
+			spiretest.RequireProtoListEqual(t, tt.set.Selectors, fetched.Selectors)
+		})
+	}
+
+	// Names are unique
+	_, err := s.ds.CreateSelectorSet(ctx, &datastore.SelectorSet{Name: "base-images", Selectors: selectors})
+	s.Require().Error(err)
+}
+
+func (s *PluginSuite) TestFetchSelectorSet() {
+	set, err := s.ds.FetchSelectorSet(ctx, "unknown")
+	s.Require().NoError(err)
+	s.Require().Nil(set)
+
+	_, err = s.ds.FetchSelectorSet(ctx, "")
+	s.Require().EqualError(err, "rpc error: code = InvalidArgument desc = selector set name is required")
+}
+
+func (s *PluginSuite) TestListSelectorSets() {
+	sets, err := s.ds.ListSelectorSets(ctx)
+	s.Require().NoError(err)
+	s.Require().Empty(sets)
+
+	for _, name := range []string{"b", "a"} {
+		_, err := s.ds.CreateSelectorSet(ctx, &datastore.SelectorSet{
+			Name:      name,
+			Selectors: []*common.Selector{{Type: "unix", Value: "uid:" + name}},
+		})
+		s.Require().NoError(err)
+	}
+
+	sets, err = s.ds.ListSelectorSets(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(sets, 2)
+	s.Require().Equal("a", sets[0].Name)
+	s.RequireProtoListEqual([]*common.Selector{{Type: "unix", Value: "uid:a"}}, sets[0].Selectors)
+	s.Require().Equal("b", sets[1].Name)
+	s.RequireProtoListEqual([]*common.Selector{{Type: "unix", Value: "uid:b"}}, sets[1].Selectors)
+}
+
+func (s *PluginSuite) TestUpdateSelectorSet() {
+	_, err := s.ds.CreateSelectorSet(ctx, &datastore.SelectorSet{
+		Name:      "base-images",
+		Selectors: []*common.Selector{{Type: "docker", Value: "image_id:old"}},
+	})
+	s.Require().NoError(err)
+
+	updated, err := s.ds.UpdateSelectorSet(ctx, &datastore.SelectorSet{
+		Name: "base-images",
+		Selectors: []*common.Selector{
+			{Type: "docker", Value: "image_id:new1"},
+			{Type: "docker", Value: "image_id:new2"},
+		},
+	})
+	s.Require().NoError(err)
+
+	fetched, err := s.ds.FetchSelectorSet(ctx, "base-images")
+	s.Require().NoError(err)
+	s.Require().Equal("base-images", fetched.Name)
+	s.RequireProtoListEqual(updated.Selectors, fetched.Selectors)
+	s.RequireProtoListEqual([]*common.Selector{
+		{Type: "docker", Value: "image_id:new1"},
+		{Type: "docker", Value: "image_id:new2"},
+	}, fetched.Selectors)
+
+	_, err = s.ds.UpdateSelectorSet(ctx, &datastore.SelectorSet{
+		Name:      "unknown",
+		Selectors: []*common.Selector{{Type: "docker", Value: "image_id:new1"}},
+	})
+	s.RequireGRPCStatus(err, codes.NotFound, _notFoundErrMsg)
+}
+
+func (s *PluginSuite) TestDeleteSelectorSet() {
+	_, err := s.ds.CreateSelectorSet(ctx, &datastore.SelectorSet{
+		Name:      "base-images",
+		Selectors: []*common.Selector{{Type: "docker", Value: "image_id:base"}},
+	})
+	s.Require().NoError(err)
+
+	entry, err := s.ds.CreateRegistrationEntry(ctx, &common.RegistrationEntry{
+		ParentId:  "spiffe://example.org/parent",
+		SpiffeId:  "spiffe://example.org/workload",
+		Selectors: []*common.Selector{{Type: datastore.SelectorSetType, Value: "base-images"}},
+	})
+	s.Require().NoError(err)
+
+	// Referenced selector sets cannot be deleted
+	err = s.ds.DeleteSelectorSet(ctx, "base-images")
+	s.RequireGRPCStatus(err, codes.FailedPrecondition, "cannot delete selector set; it is referenced by 1 registration entries")
+
+	_, err = s.ds.DeleteRegistrationEntry(ctx, entry.EntryId)
+	s.Require().NoError(err)
+
+	s.Require().NoError(s.ds.DeleteSelectorSet(ctx, "base-images"))
+	set, err := s.ds.FetchSelectorSet(ctx, "base-images")
+	s.Require().NoError(err)
+	s.Require().Nil(set)
+
+	err = s.ds.DeleteSelectorSet(ctx, "base-images")
+	s.RequireGRPCStatus(err, codes.NotFound, _notFoundErrMsg)
+}
+
+func (s *PluginSuite) TestRegistrationEntrySelectorSetReferences() {
+	_, err := s.ds.CreateSelectorSet(ctx, &datastore.SelectorSet{
+		Name:      "base-images",
+		Selectors: []*common.Selector{{Type: "docker", Value: "image_id:base"}},
+	})
+	s.Require().NoError(err)
+
+	// Entries can only reference existing selector sets
+	_, err = s.ds.CreateRegistrationEntry(ctx, &common.RegistrationEntry{
+		ParentId:  "spiffe://example.org/parent",
+		SpiffeId:  "spiffe://example.org/workload",
+		Selectors: []*common.Selector{{Type: datastore.SelectorSetType, Value: "unknown"}},
+	})
+	s.RequireGRPCStatus(err, codes.InvalidArgument, `invalid registration entry: selector set "unknown" does not exist`)
+
+	entry, err := s.ds.CreateRegistrationEntry(ctx, &common.RegistrationEntry{
+		ParentId:  "spiffe://example.org/parent",
+		SpiffeId:  "spiffe://example.org/workload",
+		Selectors: []*common.Selector{{Type: datastore.SelectorSetType, Value: "base-images"}},
+	})
+	s.Require().NoError(err)
+
+	entry.Selectors = []*common.Selector{{Type: datastore.SelectorSetType, Value: "unknown"}}
+	_, err = s.ds.UpdateRegistrationEntry(ctx, entry, &common.RegistrationEntryMask{Selectors: true})
+	s.RequireGRPCStatus(err, codes.InvalidArgument, `invalid registration entry: selector set "unknown" does not exist`)
+
+	// Updates that leave the selectors alone are not checked
+	entry.Selectors = nil
+	entry.Ttl = 60
+	_, err = s.ds.UpdateRegistrationEntry(ctx, entry, &common.RegistrationEntryMask{Ttl: true})
+	s.Require().NoError(err)
+}
+
 func (s *PluginSuite) TestMigration() {
 	for schemaVersion := 0; schemaVersion < latestSchemaVersion; schemaVersion++ {
 		s.T().Run(fmt.Sprintf("migration_from_schema_version_%d", schemaVersion), func(t *testing.T) {
@@ -3940,6 +4555,69 @@ func (s *PluginSuite) TestMigration() {
 				prepareDB(true)
 				require.True(s.ds.db.Dialect().HasColumn("registered_entries", "x509_svid_ttl"))
 				require.True(s.ds.db.Dialect().HasColumn("registered_entries", "jwt_svid_ttl"))
+			case 19:
+				prepareDB(true)
+				require.False(s.ds.db.Dialect().HasIndex("selectors", "idx_selectors_type_value"))
+				require.True(s.ds.db.Dialect().HasIndex("selectors", "idx_selectors_type_value_entry"))
+
+				entries, err := s.ds.ListRegistrationEntries(ctx, &datastore.ListRegistrationEntriesRequest{
+					BySelectors: &datastore.BySelectors{
+						Selectors: []*common.Selector{{Type: "unix", Value: "uid:1000"}},
+						Match:     datastore.Exact,
+					},
+				})
+				require.NoError(err)
+				require.Len(entries.Entries, 1)
+				require.Equal("0e7ff54e-d7a8-4eec-a09e-3c5dd19a4e1b", entries.Entries[0].EntryId)
+			case 20:
+				prepareDB(true)
+				require.True(s.ds.db.Dialect().HasTable("selector_sets"))
+				require.True(s.ds.db.Dialect().HasTable("selector_set_selectors"))
+
+				set, err := s.ds.CreateSelectorSet(ctx, &datastore.SelectorSet{
+					Name:      "set",
+					Selectors: []*common.Selector{{Type: "unix", Value: "uid:1000"}},
+				})
+				require.NoError(err)
+				require.Equal("set", set.Name)
+			case 21:
+				prepareDB(true)
+				require.True(s.ds.db.Dialect().HasTable("issuance_records"))
+				require.True(s.ds.db.Dialect().HasColumn("issuance_records", "revoked_at"))
+
+				resp, err := s.ds.ListIssuanceRecords(ctx, &datastore.ListIssuanceRecordsRequest{})
+				require.NoError(err)
+				require.Empty(resp.Records)
+			case 22:
+				prepareDB(true)
+				require.True(s.ds.db.Dialect().HasTable("attestation_events"))
+				require.True(s.ds.db.Dialect().HasColumn("attestation_events", "error"))
+				require.True(s.ds.db.Dialect().HasColumn("attestation_events", "selectors"))
+
+				resp, err := s.ds.ListAttestationEvents(ctx, &datastore.ListAttestationEventsRequest{})
+				require.NoError(err)
+				require.Empty(resp.Events)
+			case 23:
+				prepareDB(true)
+				require.True(s.ds.db.Dialect().HasTable("acme_cache_entries"))
+
+				data, err := s.ds.FetchACMECacheEntry(ctx, "domain.test")
+				require.NoError(err)
+				require.Nil(data)
+			case 24:
+				prepareDB(true)
+				require.True(s.ds.db.Dialect().HasTable("pending_agents"))
+
+				resp, err := s.ds.ListPendingAgents(ctx, &datastore.ListPendingAgentsRequest{})
+				require.NoError(err)
+				require.Empty(resp.Agents)
+			case 25:
+				prepareDB(true)
+				require.True(s.ds.db.Dialect().HasTable("mirrored_entries"))
+
+				entries, err := s.ds.ListMirroredEntries(ctx)
+				require.NoError(err)
+				require.Empty(entries)
 			default:
 				t.Fatalf("no migration test added for schema version %d", schemaVersion)
 			}
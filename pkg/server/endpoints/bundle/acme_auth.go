@@ -7,6 +7,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/spire/pkg/common/version"
+	"github.com/spiffe/spire/pkg/server/datastore"
 	"github.com/spiffe/spire/pkg/server/endpoints/bundle/internal/autocert"
 	"github.com/spiffe/spire/pkg/server/plugin/keymanager"
 	"github.com/zeebo/errs"
@@ -19,12 +20,10 @@ const (
 	acmeKeyPrefix = "bundle-acme-"
 )
 
-// ACMECache implements a cache for the autocert manager. It makes some
-// simplifying assumptions based on our usage for the bundle endpoint. Namely,
-// it assumes there is going to be a single cache entry, since we only support
-// a single domain. It assumes PEM encoded blocks of data and strips out the
-// private key to be stored in the key manager instead of on disk with the rest
-// of the data.
+// ACMEConfig configures the bundle endpoint to serve a certificate obtained
+// via ACME. Private keys are stored in the key manager and the certificates
+// in the datastore, scoped by the ACME account key, so only servers sharing
+// their keys share the certificates.
 type ACMEConfig struct {
 	// DirectoryURL is the ACME directory URL
 	DirectoryURL string
@@ -32,7 +31,9 @@ type ACMEConfig struct {
 	// DomainName is the domain name of the certificate to obtain.
 	DomainName string
 
-	// CacheDir is the directory on disk where we cache certificates.
+	// CacheDir is the directory on disk where certificates were cached by
+	// previous versions. Certificates found there are migrated into the
+	// datastore. Optional.
 	CacheDir string
 
 	// Email is the email address of the account to register with ACME
@@ -44,7 +45,7 @@ type ACMEConfig struct {
 	ToSAccepted bool
 }
 
-func ACMEAuth(log logrus.FieldLogger, km keymanager.KeyManager, config ACMEConfig) ServerAuth {
+func ACMEAuth(log logrus.FieldLogger, km keymanager.KeyManager, ds datastore.DataStore, config ACMEConfig) ServerAuth {
 	// The acme client already defaulting to Let's Encrypt if the URL is unset
 	// but we want it populated for logging purposes.
 	if config.DirectoryURL == "" {
@@ -55,6 +56,15 @@ func ACMEAuth(log logrus.FieldLogger, km keymanager.KeyManager, config ACMEConfi
 		log.Warn("ACME Terms of Service have not been accepted. See the `tos_accepted` configurable")
 	}
 
+	cache := &acmeCache{
+		log: log,
+		ds:  ds,
+		km:  km,
+	}
+	if config.CacheDir != "" {
+		cache.legacy = autocert.DirCache(config.CacheDir)
+	}
+
 	return &acmeAuth{
 		m: &autocert.Manager{
 			Prompt: func(tosURL string) bool {
@@ -71,7 +81,7 @@ func ACMEAuth(log logrus.FieldLogger, km keymanager.KeyManager, config ACMEConfi
 				return false
 			},
 			Email:      config.Email,
-			Cache:      autocert.DirCache(config.CacheDir),
+			Cache:      cache,
 			HostPolicy: autocert.HostWhitelist(config.DomainName),
 			Client: &acme.Client{
 				DirectoryURL: config.DirectoryURL,
@@ -0,0 +1,131 @@
+package bundle
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/spire/pkg/server/datastore"
+	"github.com/spiffe/spire/pkg/server/endpoints/bundle/internal/autocert"
+	"github.com/spiffe/spire/pkg/server/plugin/keymanager"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// acmeAccountKeyID is the ID of the ACME account key in the key store
+	acmeAccountKeyID = "acme_account+key"
+)
+
+// acmeCache implements a cache for the autocert manager backed by the
+// datastore. Private keys are not part of the cached data; they are held by
+// the key manager via the acmeKeyStore, so a cached certificate is only
+// usable by the servers holding its key. The entries are therefore scoped by
+// the fingerprint of the ACME account key of the server: servers sharing a
+// datastore but not their keys each keep their own certificates, instead of
+// replacing each other's certificates on every renewal, while servers whose
+// key manager shares the keys share the certificates.
+//
+// Entries previously cached on disk are migrated into the datastore the first
+// time they are read.
+type acmeCache struct {
+	log    logrus.FieldLogger
+	ds     datastore.DataStore
+	km     keymanager.KeyManager
+	legacy autocert.Cache
+
+	mtx   sync.Mutex
+	scope string
+}
+
+func (c *acmeCache) Get(ctx context.Context, key string) ([]byte, error) {
+	dsKey, err := c.datastoreKey(ctx, key)
+	switch {
+	case status.Code(err) == codes.NotFound:
+		// Without an account key, nothing was cached by this server
+		return nil, autocert.ErrCacheMiss
+	case err != nil:
+		return nil, err
+	}
+
+	data, err := c.ds.FetchACMECacheEntry(ctx, dsKey)
+	switch {
+	case err != nil:
+		return nil, err
+	case data != nil:
+		return data, nil
+	case c.legacy == nil:
+		return nil, autocert.ErrCacheMiss
+	}
+
+	data, err = c.legacy.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.ds.SetACMECacheEntry(ctx, dsKey, data); err != nil {
+		return nil, err
+	}
+	c.log.WithField("key", key).Info("Migrated ACME cache entry from disk to the datastore")
+	if err := c.legacy.Delete(ctx, key); err != nil {
+		c.log.WithError(err).WithField("key", key).Warn("Failed to remove migrated ACME cache entry from disk")
+	}
+	return data, nil
+}
+
+func (c *acmeCache) Put(ctx context.Context, key string, data []byte) error {
+	dsKey, err := c.datastoreKey(ctx, key)
+	if err != nil {
+		return err
+	}
+	return c.ds.SetACMECacheEntry(ctx, dsKey, data)
+}
+
+func (c *acmeCache) Delete(ctx context.Context, key string) error {
+	dsKey, err := c.datastoreKey(ctx, key)
+	switch {
+	case err == nil:
+		if err := c.ds.DeleteACMECacheEntry(ctx, dsKey); err != nil {
+			return err
+		}
+	case status.Code(err) != codes.NotFound:
+		return err
+	}
+	if c.legacy != nil {
+		return c.legacy.Delete(ctx, key)
+	}
+	return nil
+}
+
+// datastoreKey returns the key of the datastore entry caching the given
+// key. It fails with NotFound if the server has no ACME account key yet.
+func (c *acmeCache) datastoreKey(ctx context.Context, key string) (string, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.scope == "" {
+		accountKey, err := c.km.GetKey(ctx, acmeKeyPrefix+acmeAccountKeyID)
+		if err != nil {
+			return "", err
+		}
+		c.scope, err = acmeCacheScope(accountKey.Public())
+		if err != nil {
+			return "", err
+		}
+	}
+	return c.scope + "/" + key, nil
+}
+
+// acmeCacheScope returns the scope of the cache entries of a server with the
+// given ACME account public key.
+func acmeCacheScope(accountKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(accountKey)
+	if err != nil {
+		return "", status.Errorf(codes.Internal, "failed to marshal ACME account key: %v", err)
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:16]), nil
+}
@@ -0,0 +1,86 @@
+package bundle
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/spire/pkg/server/endpoints/bundle/internal/autocert"
+	"github.com/spiffe/spire/pkg/server/plugin/keymanager"
+	"github.com/spiffe/spire/test/fakes/fakedatastore"
+	"github.com/spiffe/spire/test/fakes/fakeserverkeymanager"
+	"github.com/stretchr/testify/require"
+)
+
+func TestACMECache(t *testing.T) {
+	ctx := context.Background()
+	log, _ := test.NewNullLogger()
+	ds := fakedatastore.New(t)
+	km := fakeserverkeymanager.New(t)
+	dir := t.TempDir()
+
+	cache := &acmeCache{
+		log:    log,
+		ds:     ds,
+		km:     km,
+		legacy: autocert.DirCache(dir),
+	}
+
+	// Nothing is cached before the account key exists
+	_, err := cache.Get(ctx, "domain.test")
+	require.Equal(t, autocert.ErrCacheMiss, err)
+	require.NoError(t, cache.Delete(ctx, "domain.test"))
+
+	accountKey, err := km.GenerateKey(ctx, "bundle-acme-acme_account+key", keymanager.ECP256)
+	require.NoError(t, err)
+	scope, err := acmeCacheScope(accountKey.Public())
+	require.NoError(t, err)
+
+	_, err = cache.Get(ctx, "domain.test")
+	require.Equal(t, autocert.ErrCacheMiss, err)
+
+	require.NoError(t, cache.Put(ctx, "domain.test", []byte("CERT")))
+	data, err := cache.Get(ctx, "domain.test")
+	require.NoError(t, err)
+	require.Equal(t, []byte("CERT"), data)
+
+	// Entries are scoped by the account key
+	data, err = ds.FetchACMECacheEntry(ctx, scope+"/domain.test")
+	require.NoError(t, err)
+	require.Equal(t, []byte("CERT"), data)
+
+	// Nothing is written to disk
+	dirEntries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, dirEntries)
+
+	// Servers with other keys do not see the entries, since they do not
+	// hold the private keys of the certificates
+	otherKM := fakeserverkeymanager.New(t)
+	_, err = otherKM.GenerateKey(ctx, "bundle-acme-acme_account+key", keymanager.RSA2048)
+	require.NoError(t, err)
+	otherCache := &acmeCache{
+		log: log,
+		ds:  ds,
+		km:  otherKM,
+	}
+	_, err = otherCache.Get(ctx, "domain.test")
+	require.Equal(t, autocert.ErrCacheMiss, err)
+
+	require.NoError(t, cache.Delete(ctx, "domain.test"))
+	_, err = cache.Get(ctx, "domain.test")
+	require.Equal(t, autocert.ErrCacheMiss, err)
+
+	// Entries cached on disk are migrated into the datastore
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "legacy.test"), []byte("LEGACY"), 0600))
+	data, err = cache.Get(ctx, "legacy.test")
+	require.NoError(t, err)
+	require.Equal(t, []byte("LEGACY"), data)
+
+	data, err = ds.FetchACMECacheEntry(ctx, scope+"/legacy.test")
+	require.NoError(t, err)
+	require.Equal(t, []byte("LEGACY"), data)
+	require.NoFileExists(t, filepath.Join(dir, "legacy.test"))
+}
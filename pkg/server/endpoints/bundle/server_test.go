@@ -13,6 +13,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"testing"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/spiffe/spire/pkg/common/bundleutil"
 	"github.com/spiffe/spire/pkg/server/endpoints/bundle/internal/acmetest"
+	"github.com/spiffe/spire/test/fakes/fakedatastore"
 	"github.com/spiffe/spire/test/fakes/fakeserverkeymanager"
 	"github.com/spiffe/spire/test/spiretest"
 	"github.com/stretchr/testify/assert"
@@ -158,6 +160,7 @@ func TestACMEAuth(t *testing.T) {
 	trustDomain := spiffeid.RequireTrustDomainFromString("domain.test")
 	bundle := bundleutil.New(trustDomain)
 	km := fakeserverkeymanager.New(t)
+	ds := fakedatastore.New(t)
 
 	ca := acmetest.NewCAServer([]string{"tls-alpn-01"}, []string{"domain.test"})
 
@@ -177,7 +180,7 @@ func TestACMEAuth(t *testing.T) {
 	t.Run("new-account-tos-not-accepted", func(t *testing.T) {
 		log, hook := test.NewNullLogger()
 		addr, done := newTestServer(t, testGetter(bundle),
-			ACMEAuth(log, km, ACMEConfig{
+			ACMEAuth(log, km, ds, ACMEConfig{
 				DirectoryURL: ca.URL,
 				DomainName:   "domain.test",
 				CacheDir:     dir,
@@ -209,7 +212,7 @@ func TestACMEAuth(t *testing.T) {
 	t.Run("initial", func(t *testing.T) {
 		log, hook := test.NewNullLogger()
 		addr, done := newTestServer(t, testGetter(bundle),
-			ACMEAuth(log, km, ACMEConfig{
+			ACMEAuth(log, km, ds, ACMEConfig{
 				DirectoryURL: ca.URL,
 				DomainName:   "domain.test",
 				CacheDir:     dir,
@@ -239,6 +242,19 @@ func TestACMEAuth(t *testing.T) {
 			"bundle-acme-domain.test",
 		}, actualIDs)
 
+		// Assert that the certificate has been cached in the datastore,
+		// scoped by the account key, and not on disk.
+		accountKey, err := km.GetKey(context.Background(), "bundle-acme-acme_account+key")
+		require.NoError(t, err)
+		scope, err := acmeCacheScope(accountKey.Public())
+		require.NoError(t, err)
+		data, err := ds.FetchACMECacheEntry(context.Background(), scope+"/domain.test")
+		require.NoError(t, err)
+		assert.NotEmpty(t, data)
+		dirEntries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, dirEntries)
+
 		// Make sure we logged the ToS details
 		if entry := hook.LastEntry(); assert.NotNil(t, entry) {
 			assert.Equal(t, "ACME Terms of Service accepted", entry.Message)
@@ -257,7 +273,7 @@ func TestACMEAuth(t *testing.T) {
 	t.Run("cached", func(t *testing.T) {
 		log, _ := test.NewNullLogger()
 		addr, done := newTestServer(t, testGetter(bundle),
-			ACMEAuth(log, km, ACMEConfig{
+			ACMEAuth(log, km, ds, ACMEConfig{
 				DirectoryURL: ca.URL,
 				DomainName:   "domain.test",
 				CacheDir:     dir,
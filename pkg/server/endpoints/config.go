@@ -10,9 +10,11 @@ import (
 	"github.com/andres-erbsen/clock"
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/common/agentpathtemplate"
 	"github.com/spiffe/spire/pkg/common/bundleutil"
 	"github.com/spiffe/spire/pkg/common/telemetry"
 	"github.com/spiffe/spire/pkg/server/api"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
 	agentv1 "github.com/spiffe/spire/pkg/server/api/agent/v1"
 	bundlev1 "github.com/spiffe/spire/pkg/server/api/bundle/v1"
 	debugv1 "github.com/spiffe/spire/pkg/server/api/debug/v1"
@@ -26,6 +28,8 @@ import (
 	"github.com/spiffe/spire/pkg/server/cache/dscache"
 	"github.com/spiffe/spire/pkg/server/catalog"
 	"github.com/spiffe/spire/pkg/server/endpoints/bundle"
+	"github.com/spiffe/spire/pkg/server/endpoints/ocsp"
+	"github.com/spiffe/spire/pkg/server/entryadmission"
 	"github.com/spiffe/spire/pkg/server/svid"
 	"golang.org/x/net/context"
 )
@@ -82,6 +86,60 @@ type Config struct {
 	AdminIDs []spiffeid.ID
 
 	BundleManager *bundle_client.Manager
+
+	// RESTGatewayAddr, if set, is the address the REST gateway to the server
+	// APIs listens on.
+	RESTGatewayAddr *net.TCPAddr
+
+	// OCSPResponder, if set, configures the OCSP responder for the X509-SVIDs
+	// signed by the server CA.
+	OCSPResponder *ocsp.ResponderConfig
+
+	// X509CASource provides the X509 CAs the OCSP responder answers for.
+	X509CASource ocsp.X509CASource
+
+	// MaxAgentClockSkew, if greater than zero, is the maximum skew between
+	// the clock of an agent and the server clock for the agent to be
+	// attested.
+	MaxAgentClockSkew time.Duration
+
+	// AgentSelectorWarningThreshold is the number of selectors an agent can
+	// be attested with before a warning is logged.
+	AgentSelectorWarningThreshold int
+
+	// AgentPathTemplates overrides, per node attestor, the path of the agent
+	// ID produced by the attestor.
+	AgentPathTemplates map[string]*agentpathtemplate.Template
+
+	// ApprovalRequiredAttestorTypes are the node attestors whose new agents
+	// must be approved by an administrator before they are issued an SVID.
+	ApprovalRequiredAttestorTypes []string
+
+	// CSRKeyPolicy restricts the public keys of the CSRs signed by the
+	// server APIs.
+	CSRKeyPolicy api.KeyPolicy
+
+	// EntryAdmissionWebhook, if set, configures the webhook that reviews
+	// entries before they are created or updated.
+	EntryAdmissionWebhook *entryadmission.Config
+
+	// Drain, when closed, drains the server APIs: after DrainDelay, new
+	// connections are no longer accepted and in-flight RPCs are given
+	// DrainTimeout to finish.
+	Drain <-chan struct{}
+
+	// DrainDelay is the time the server APIs keep accepting connections
+	// after being drained, so that load balancers notice the server is no
+	// longer ready before connections are refused.
+	DrainDelay time.Duration
+
+	// DrainTimeout is the time in-flight RPCs are given to finish when the
+	// server APIs are drained.
+	DrainTimeout time.Duration
+
+	// Events, if set, provides the recent significant log entries served by
+	// the admin API.
+	Events adminv1.EventSource
 }
 
 func (c *Config) maybeMakeBundleEndpointServer() Server {
@@ -92,7 +150,7 @@ func (c *Config) maybeMakeBundleEndpointServer() Server {
 
 	var serverAuth bundle.ServerAuth
 	if c.BundleEndpoint.ACME != nil {
-		serverAuth = bundle.ACMEAuth(c.Log.WithField(telemetry.SubsystemName, "bundle_acme"), c.Catalog.GetKeyManager(), *c.BundleEndpoint.ACME)
+		serverAuth = bundle.ACMEAuth(c.Log.WithField(telemetry.SubsystemName, "bundle_acme"), c.Catalog.GetKeyManager(), c.Catalog.GetDataStore(), *c.BundleEndpoint.ACME)
 	} else {
 		serverAuth = bundle.SPIFFEAuth(func() ([]*x509.Certificate, crypto.PrivateKey, error) {
 			state := c.SVIDObserver.State()
@@ -118,11 +176,46 @@ func (c *Config) maybeMakeBundleEndpointServer() Server {
 	})
 }
 
-func (c *Config) makeAPIServers(entryFetcher api.AuthorizedEntryFetcher) APIServers {
+func (c *Config) maybeMakeOCSPResponder() Server {
+	if c.OCSPResponder == nil {
+		return nil
+	}
+	c.Log.WithField("addr", c.OCSPResponder.Address).Info("Serving OCSP responder")
+
+	return ocsp.NewServer(ocsp.ServerConfig{
+		Log:           c.Log.WithField(telemetry.SubsystemName, "ocsp_responder"),
+		Address:       c.OCSPResponder.Address.String(),
+		TrustDomain:   c.TrustDomain,
+		DataStore:     c.Catalog.GetDataStore(),
+		X509CAs:       c.X509CASource,
+		CacheTTL:      c.OCSPResponder.CacheTTL,
+		MaxLookupRate: c.OCSPResponder.MaxLookupRate,
+		Clock:         c.Clock,
+	})
+}
+
+func (c *Config) makeAPIServers(entryFetcher *AuthorizedEntryFetcherWithFullCache) APIServers {
 	ds := c.Catalog.GetDataStore()
 	upstreamPublisher := UpstreamPublisher(c.Manager)
 
+	var entryAdmission entryv1.Admission
+	if c.EntryAdmissionWebhook != nil {
+		entryAdmission = entryadmission.New(*c.EntryAdmissionWebhook, c.Log.WithField(telemetry.SubsystemName, "entry_admission"))
+	}
+
+	var upstreamAuthority adminv1.UpstreamAuthority
+	if c.Manager != nil {
+		upstreamAuthority = upstreamAuthorityStatus{source: c.Manager}
+	}
+
 	return APIServers{
+		AdminServer: adminv1.New(adminv1.Config{
+			TrustDomain:       c.TrustDomain,
+			DataStore:         ds,
+			EntryCache:        entryFetcher,
+			UpstreamAuthority: upstreamAuthority,
+			Events:            c.Events,
+		}),
 		AgentServer: agentv1.New(agentv1.Config{
 			DataStore:   ds,
 			ServerCA:    c.ServerCA,
@@ -130,6 +223,13 @@ func (c *Config) makeAPIServers(entryFetcher api.AuthorizedEntryFetcher) APIServ
 			TrustDomain: c.TrustDomain,
 			Catalog:     c.Catalog,
 			Clock:       c.Clock,
+			Metrics:     c.Metrics,
+
+			SelectorWarningThreshold: c.AgentSelectorWarningThreshold,
+			AgentPathTemplates:       c.AgentPathTemplates,
+			KeyPolicy:                c.CSRKeyPolicy,
+
+			ApprovalRequiredAttestorTypes: c.ApprovalRequiredAttestorTypes,
 		}),
 		BundleServer: bundlev1.New(bundlev1.Config{
 			TrustDomain:       c.TrustDomain,
@@ -147,6 +247,7 @@ func (c *Config) makeAPIServers(entryFetcher api.AuthorizedEntryFetcher) APIServ
 			TrustDomain:  c.TrustDomain,
 			DataStore:    ds,
 			EntryFetcher: entryFetcher,
+			Admission:    entryAdmission,
 		}),
 		HealthServer: healthv1.New(healthv1.Config{
 			TrustDomain: c.TrustDomain,
@@ -157,6 +258,11 @@ func (c *Config) makeAPIServers(entryFetcher api.AuthorizedEntryFetcher) APIServ
 			EntryFetcher: entryFetcher,
 			ServerCA:     c.ServerCA,
 			DataStore:    ds,
+			Metrics:      c.Metrics,
+			KeyPolicy:    c.CSRKeyPolicy,
+
+			JWTSVIDQuotaPerEntry: c.RateLimit.JWTSVIDPerEntry,
+			JWTSVIDQuotaPerAgent: c.RateLimit.JWTSVIDPerAgent,
 		}),
 		TrustDomainServer: trustdomainv1.New(trustdomainv1.Config{
 			TrustDomain:     c.TrustDomain,
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"time"
 
@@ -30,10 +31,13 @@ import (
 	"github.com/spiffe/spire/pkg/common/peertracker"
 	"github.com/spiffe/spire/pkg/common/telemetry"
 	"github.com/spiffe/spire/pkg/common/util"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
 	"github.com/spiffe/spire/pkg/server/api/middleware"
+	svidapi "github.com/spiffe/spire/pkg/server/api/svid/v1"
 	"github.com/spiffe/spire/pkg/server/authpolicy"
 	"github.com/spiffe/spire/pkg/server/cache/dscache"
 	"github.com/spiffe/spire/pkg/server/datastore"
+	"github.com/spiffe/spire/pkg/server/endpoints/gateway"
 	"github.com/spiffe/spire/pkg/server/svid"
 )
 
@@ -46,8 +50,20 @@ const (
 	// This is the default amount of time between two reloads of the in-memory
 	// entry cache.
 	defaultCacheReloadInterval = 5 * time.Second
+
+	// This is the default amount of time in-flight RPCs are given to finish
+	// when the server APIs are drained.
+	defaultDrainTimeout = 30 * time.Second
+
+	// This is the default amount of time the server APIs keep accepting
+	// connections after being drained.
+	defaultDrainDelay = 5 * time.Second
 )
 
+// ErrDrained is returned by ListenAndServe when the server APIs stop after
+// being drained.
+var ErrDrained = errors.New("server APIs drained")
+
 // Server manages gRPC and HTTP endpoint lifecycle
 type Server interface {
 	// ListenAndServe starts all endpoint servers and blocks until the context
@@ -65,6 +81,7 @@ type Endpoints struct {
 	DataStore                    datastore.DataStore
 	APIServers                   APIServers
 	BundleEndpointServer         Server
+	OCSPResponder                Server
 	Log                          logrus.FieldLogger
 	Metrics                      telemetry.Metrics
 	RateLimit                    RateLimitConfig
@@ -72,9 +89,15 @@ type Endpoints struct {
 	AuditLogEnabled              bool
 	AuthPolicyEngine             *authpolicy.Engine
 	AdminIDs                     []spiffeid.ID
+	RESTGatewayAddr              *net.TCPAddr
+	MaxAgentClockSkew            time.Duration
+	Drain                        <-chan struct{}
+	DrainDelay                   time.Duration
+	DrainTimeout                 time.Duration
 }
 
 type APIServers struct {
+	AdminServer       *adminv1.Service
 	AgentServer       agentv1.AgentServer
 	BundleServer      bundlev1.BundleServer
 	DebugServer       debugv1_pb.DebugServer
@@ -91,6 +114,12 @@ type RateLimitConfig struct {
 
 	// Signing, if true, rate limits JWT and X509 signing requests
 	Signing bool
+
+	// JWTSVIDPerEntry is the quota on JWT-SVIDs minted for each entry
+	JWTSVIDPerEntry svidapi.JWTSVIDQuota
+
+	// JWTSVIDPerAgent is the quota on JWT-SVIDs minted for each agent
+	JWTSVIDPerAgent svidapi.JWTSVIDQuota
 }
 
 // New creates new endpoints struct
@@ -113,6 +142,14 @@ func New(ctx context.Context, c Config) (*Endpoints, error) {
 		c.CacheReloadInterval = defaultCacheReloadInterval
 	}
 
+	if c.DrainDelay == 0 {
+		c.DrainDelay = defaultDrainDelay
+	}
+
+	if c.DrainTimeout == 0 {
+		c.DrainTimeout = defaultDrainTimeout
+	}
+
 	ef, err := NewAuthorizedEntryFetcherWithFullCache(ctx, buildCacheFn, c.Log, c.Clock, c.CacheReloadInterval)
 	if err != nil {
 		return nil, err
@@ -126,6 +163,7 @@ func New(ctx context.Context, c Config) (*Endpoints, error) {
 		DataStore:                    c.Catalog.GetDataStore(),
 		APIServers:                   c.makeAPIServers(ef),
 		BundleEndpointServer:         c.maybeMakeBundleEndpointServer(),
+		OCSPResponder:                c.maybeMakeOCSPResponder(),
 		Log:                          c.Log,
 		Metrics:                      c.Metrics,
 		RateLimit:                    c.RateLimit,
@@ -133,6 +171,11 @@ func New(ctx context.Context, c Config) (*Endpoints, error) {
 		AuditLogEnabled:              c.AuditLogEnabled,
 		AuthPolicyEngine:             c.AuthPolicyEngine,
 		AdminIDs:                     c.AdminIDs,
+		RESTGatewayAddr:              c.RESTGatewayAddr,
+		MaxAgentClockSkew:            c.MaxAgentClockSkew,
+		Drain:                        c.Drain,
+		DrainDelay:                   c.DrainDelay,
+		DrainTimeout:                 c.DrainTimeout,
 	}, nil
 }
 
@@ -158,6 +201,8 @@ func (e *Endpoints) ListenAndServe(ctx context.Context) error {
 	svidv1.RegisterSVIDServer(udsServer, e.APIServers.SVIDServer)
 	trustdomainv1.RegisterTrustDomainServer(tcpServer, e.APIServers.TrustDomainServer)
 	trustdomainv1.RegisterTrustDomainServer(udsServer, e.APIServers.TrustDomainServer)
+	adminv1.RegisterService(tcpServer, e.APIServers.AdminServer)
+	adminv1.RegisterService(udsServer, e.APIServers.AdminServer)
 
 	// Register Health and Debug only on UDS server
 	grpc_health_v1.RegisterHealthServer(udsServer, e.APIServers.HealthServer)
@@ -177,6 +222,16 @@ func (e *Endpoints) ListenAndServe(ctx context.Context) error {
 		tasks = append(tasks, e.BundleEndpointServer.ListenAndServe)
 	}
 
+	if e.OCSPResponder != nil {
+		tasks = append(tasks, e.OCSPResponder.ListenAndServe)
+	}
+
+	if e.RESTGatewayAddr != nil {
+		tasks = append(tasks, func(ctx context.Context) error {
+			return e.runRESTGateway(ctx, unaryInterceptor)
+		})
+	}
+
 	err := util.RunTasks(ctx, tasks...)
 	if errors.Is(err, context.Canceled) {
 		err = nil
@@ -240,6 +295,103 @@ func (e *Endpoints) runTCPServer(ctx context.Context, server *grpc.Server) error
 		<-errChan
 		log.Info("Server APIs have stopped")
 		return nil
+	case <-e.Drain:
+		// The server is reported as not ready as soon as it is drained. Keep
+		// accepting connections for a while so that load balancers notice it
+		// before connections are refused.
+		log.WithField(telemetry.Duration, e.DrainDelay).Info("Draining Server APIs")
+		select {
+		case <-time.After(e.DrainDelay):
+		case <-ctx.Done():
+			log.Info("Stopping Server APIs")
+			server.Stop()
+			<-errChan
+			log.Info("Server APIs have stopped")
+			return nil
+		}
+		gracefulStop(server, e.DrainTimeout)
+		<-errChan
+		log.Info("Server APIs have been drained")
+		return ErrDrained
+	}
+}
+
+// gracefulStop stops the server from accepting new connections, which makes
+// clients move to other servers, and waits for in-flight RPCs to finish. The
+// RPCs still running after the timeout are canceled.
+func gracefulStop(server *grpc.Server, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		server.Stop()
+		<-done
+	}
+}
+
+// runRESTGateway will start the REST gateway and block until it exits or we
+// are dying. The gateway uses the same TLS configuration and interceptors as
+// the TCP gRPC server, so callers are authenticated and authorized the same
+// way.
+func (e *Endpoints) runRESTGateway(ctx context.Context, unaryInterceptor grpc.UnaryServerInterceptor) error {
+	handler, err := gateway.New(gateway.Config{
+		Log: e.Log,
+		RegisterServices: func(s grpc.ServiceRegistrar) {
+			agentv1.RegisterAgentServer(s, e.APIServers.AgentServer)
+			bundlev1.RegisterBundleServer(s, e.APIServers.BundleServer)
+			entryv1.RegisterEntryServer(s, e.APIServers.EntryServer)
+			trustdomainv1.RegisterTrustDomainServer(s, e.APIServers.TrustDomainServer)
+		},
+		UnaryInterceptor: unaryInterceptor,
+	})
+	if err != nil {
+		return err
+	}
+
+	getTLSConfig := e.getTLSConfig(ctx)
+	server := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig: &tls.Config{ //nolint: gosec // False positive, getTLSConfig is setting MinVersion
+			GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+				config, err := getTLSConfig(hello)
+				if err != nil {
+					return nil, err
+				}
+				config.NextProtos = []string{http2.NextProtoTLS, "http/1.1"}
+				return config, nil
+			},
+		},
+	}
+
+	l, err := net.Listen(e.RESTGatewayAddr.Network(), e.RESTGatewayAddr.String())
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	log := e.Log.WithFields(logrus.Fields{
+		telemetry.Network: l.Addr().Network(),
+		telemetry.Address: l.Addr().String()})
+
+	log.Info("Starting REST gateway")
+	errChan := make(chan error)
+	go func() { errChan <- server.Serve(tls.NewListener(l, server.TLSConfig)) }()
+
+	select {
+	case err = <-errChan:
+		log.WithError(err).Error("REST gateway stopped prematurely")
+		return err
+	case <-ctx.Done():
+		log.Info("Stopping REST gateway")
+		server.Close()
+		<-errChan
+		log.Info("REST gateway has stopped")
+		return nil
 	}
 }
 
@@ -353,5 +505,5 @@ func (e *Endpoints) getCerts(ctx context.Context) ([]tls.Certificate, *x509.Cert
 func (e *Endpoints) makeInterceptors() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor) {
 	log := e.Log.WithField(telemetry.SubsystemName, "api")
 
-	return middleware.Interceptors(Middleware(log, e.Metrics, e.DataStore, clock.New(), e.RateLimit, e.AuthPolicyEngine, e.AuditLogEnabled, e.AdminIDs))
+	return middleware.Interceptors(Middleware(log, e.Metrics, e.DataStore, clock.New(), e.RateLimit, e.AuthPolicyEngine, e.AuditLogEnabled, e.AdminIDs, e.MaxAgentClockSkew))
 }
@@ -20,12 +20,15 @@ import (
 	entryv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
 	svidv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/svid/v1"
 	trustdomainv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/trustdomain/v1"
+	"github.com/spiffe/spire/pkg/common/adminapi"
 	"github.com/spiffe/spire/pkg/common/util"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
 	"github.com/spiffe/spire/pkg/server/authpolicy"
 	"github.com/spiffe/spire/pkg/server/ca"
 	"github.com/spiffe/spire/pkg/server/cache/entrycache"
 	"github.com/spiffe/spire/pkg/server/datastore"
 	"github.com/spiffe/spire/pkg/server/endpoints/bundle"
+	"github.com/spiffe/spire/pkg/server/endpoints/ocsp"
 	"github.com/spiffe/spire/pkg/server/svid"
 	"github.com/spiffe/spire/proto/spire/common"
 	"github.com/spiffe/spire/test/clock"
@@ -96,6 +99,8 @@ func TestNew(t *testing.T) {
 		Catalog:          cat,
 		ServerCA:         serverCA,
 		BundleEndpoint:   bundle.EndpointConfig{Address: tcpAddr},
+		OCSPResponder:    &ocsp.ResponderConfig{Address: tcpAddr},
+		X509CASource:     serverCA,
 		Manager:          manager,
 		Log:              log,
 		Metrics:          metrics,
@@ -108,6 +113,7 @@ func TestNew(t *testing.T) {
 	assert.Equal(t, localAddr, endpoints.LocalAddr)
 	assert.Equal(t, svidObserver, endpoints.SVIDObserver)
 	assert.Equal(t, testTD, endpoints.TrustDomain)
+	assert.NotNil(t, endpoints.APIServers.AdminServer)
 	assert.NotNil(t, endpoints.APIServers.AgentServer)
 	assert.NotNil(t, endpoints.APIServers.BundleServer)
 	assert.NotNil(t, endpoints.APIServers.DebugServer)
@@ -115,6 +121,7 @@ func TestNew(t *testing.T) {
 	assert.NotNil(t, endpoints.APIServers.HealthServer)
 	assert.NotNil(t, endpoints.APIServers.SVIDServer)
 	assert.NotNil(t, endpoints.BundleEndpointServer)
+	assert.NotNil(t, endpoints.OCSPResponder)
 	assert.Equal(t, cat.GetDataStore(), endpoints.DataStore)
 	assert.Equal(t, log, endpoints.Log)
 	assert.Equal(t, metrics, endpoints.Metrics)
@@ -209,6 +216,7 @@ func TestListenAndServe(t *testing.T) {
 		TrustDomain:  testTD,
 		DataStore:    ds,
 		APIServers: APIServers{
+			AdminServer:       adminv1.New(adminv1.Config{TrustDomain: testTD, DataStore: ds}),
 			AgentServer:       &agentv1.UnimplementedAgentServer{},
 			BundleServer:      &bundlev1.UnimplementedBundleServer{},
 			DebugServer:       &debugv1.UnimplementedDebugServer{},
@@ -284,6 +292,9 @@ func TestListenAndServe(t *testing.T) {
 		}
 	})
 
+	t.Run("Admin", func(t *testing.T) {
+		testAdminAPI(ctx, t, localConn, noauthConn, agentConn, adminConn, downstreamConn)
+	})
 	t.Run("Agent", func(t *testing.T) {
 		testAgentAPI(ctx, t, localConn, noauthConn, agentConn, adminConn, downstreamConn)
 	})
@@ -324,6 +335,115 @@ func TestListenAndServe(t *testing.T) {
 	}
 }
 
+func TestListenAndServeDrain(t *testing.T) {
+	ca := testca.New(t, testTD)
+	serverSVID := ca.CreateX509SVID(serverID)
+
+	// startDraining serves the server APIs, waits for them to be served and
+	// drains them.
+	startDraining := func(ctx context.Context, t *testing.T, drainDelay time.Duration) (*Endpoints, <-chan error) {
+		listener, err := net.Listen("tcp", "localhost:0")
+		require.NoError(t, err)
+		require.NoError(t, listener.Close())
+
+		ds := fakedatastore.New(t)
+		log, _ := test.NewNullLogger()
+
+		pe, err := authpolicy.DefaultAuthPolicy(ctx)
+		require.NoError(t, err)
+
+		drain := make(chan struct{})
+		endpoints := &Endpoints{
+			TCPAddr:      listener.Addr().(*net.TCPAddr),
+			LocalAddr:    getLocalAddr(t),
+			SVIDObserver: newSVIDObserver(serverSVID),
+			TrustDomain:  testTD,
+			DataStore:    ds,
+			APIServers: APIServers{
+				AgentServer:       &agentv1.UnimplementedAgentServer{},
+				BundleServer:      &bundlev1.UnimplementedBundleServer{},
+				DebugServer:       &debugv1.UnimplementedDebugServer{},
+				EntryServer:       &entryv1.UnimplementedEntryServer{},
+				HealthServer:      &grpc_health_v1.UnimplementedHealthServer{},
+				SVIDServer:        &svidv1.UnimplementedSVIDServer{},
+				TrustDomainServer: &trustdomainv1.UnimplementedTrustDomainServer{},
+			},
+			Log:       log,
+			Metrics:   fakemetrics.New(),
+			RateLimit: rateLimit,
+			EntryFetcherCacheRebuildTask: func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			},
+			AuthPolicyEngine: pe,
+			Drain:            drain,
+			DrainDelay:       drainDelay,
+			DrainTimeout:     time.Minute,
+		}
+
+		_, err = ds.CreateBundle(ctx, makeBundle(ca))
+		require.NoError(t, err)
+
+		errCh := make(chan error, 1)
+		go func() {
+			errCh <- endpoints.ListenAndServe(ctx)
+		}()
+
+		// Wait for the server APIs to be served
+		conn := dialEndpoints(ctx, t, ca, endpoints)
+		require.NoError(t, conn.Close())
+
+		close(drain)
+		return endpoints, errCh
+	}
+
+	t.Run("stops after the delay", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		_, errCh := startDraining(ctx, t, time.Millisecond)
+
+		// Draining stops the endpoints without canceling the context
+		select {
+		case err := <-errCh:
+			require.Equal(t, ErrDrained, err)
+		case <-time.After(time.Minute):
+			require.FailNow(t, "timed out waiting for ListenAndServe to drain")
+		}
+	})
+
+	t.Run("accepts connections during the delay", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		endpoints, errCh := startDraining(ctx, t, time.Hour)
+
+		conn := dialEndpoints(ctx, t, ca, endpoints)
+		require.NoError(t, conn.Close())
+
+		// Canceling the context during the delay stops the endpoints
+		cancel()
+		select {
+		case err := <-errCh:
+			require.NoError(t, err)
+		case <-time.After(time.Minute):
+			require.FailNow(t, "timed out waiting for ListenAndServe to stop")
+		}
+	})
+}
+
+func dialEndpoints(ctx context.Context, t *testing.T, ca *testca.CA, endpoints *Endpoints) *grpc.ClientConn {
+	dialCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(dialCtx, endpoints.TCPAddr.String(),
+		grpc.WithBlock(),
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsconfig.TLSClientConfig(ca.X509Bundle(), tlsconfig.AuthorizeID(serverID)))),
+	)
+	require.NoError(t, err)
+	return conn
+}
+
 func prepareDataStore(t *testing.T, ds datastore.DataStore, ca *testca.CA, agentSVID *x509svid.SVID) {
 	// Prepare the bundle
 	_, err := ds.CreateBundle(context.Background(), makeBundle(ca))
@@ -355,6 +475,43 @@ func prepareDataStore(t *testing.T, ds datastore.DataStore, ca *testca.CA, agent
 	require.NoError(t, err)
 }
 
+func testAdminAPI(ctx context.Context, t *testing.T, udsConn, noauthConn, agentConn, adminConn, downstreamConn *grpc.ClientConn) {
+	methods := []string{
+		"ListPendingAgents",
+		"ApprovePendingAgent",
+		"DeletePendingAgent",
+		"ListAttestationEvents",
+		"ListIssuanceRecords",
+		"GetEntryCacheStatus",
+		"ReloadEntryCache",
+		"GetUpstreamAuthorityStatus",
+		"ListEvents",
+	}
+	for _, tt := range []struct {
+		name       string
+		conn       *grpc.ClientConn
+		authorized bool
+	}{
+		{name: "UDS", conn: udsConn, authorized: true},
+		{name: "NoAuth", conn: noauthConn},
+		{name: "Agent", conn: agentConn},
+		{name: "Admin", conn: adminConn, authorized: true},
+		{name: "Downstream", conn: downstreamConn},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			for _, method := range methods {
+				err := adminapi.Invoke(ctx, tt.conn, adminv1.ServiceName, method, nil, nil)
+				if tt.authorized {
+					assert.NotEqual(t, codes.PermissionDenied, status.Code(err), "%s should have been authorized", method)
+				} else {
+					assert.Equal(t, codes.PermissionDenied, status.Code(err), "%s should not have been authorized", method)
+				}
+			}
+		})
+	}
+}
+
 func testAgentAPI(ctx context.Context, t *testing.T, udsConn, noauthConn, agentConn, adminConn, downstreamConn *grpc.ClientConn) {
 	t.Run("UDS", func(t *testing.T) {
 		testAuthorization(ctx, t, agentv1.NewAgentClient(udsConn), map[string]bool{
@@ -2,6 +2,9 @@ package endpoints
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,6 +13,7 @@ import (
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"github.com/spiffe/spire/pkg/server/api"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
 	"github.com/spiffe/spire/pkg/server/cache/entrycache"
 )
 
@@ -17,6 +21,17 @@ var _ api.AuthorizedEntryFetcher = (*AuthorizedEntryFetcherWithFullCache)(nil)
 
 type entryCacheBuilderFn func(ctx context.Context) (entrycache.Cache, error)
 
+// maxStaleEntryIDs is the maximum number of stale entries listed by the
+// status of the entry cache.
+const maxStaleEntryIDs = 100
+
+// entryDigester is implemented by caches able to digest the entries they
+// serve, so they can be compared.
+type entryDigester interface {
+	EntryCount() int
+	EntryDigests() map[string][sha256.Size]byte
+}
+
 type AuthorizedEntryFetcherWithFullCache struct {
 	buildCache          entryCacheBuilderFn
 	cache               entrycache.Cache
@@ -24,22 +39,32 @@ type AuthorizedEntryFetcherWithFullCache struct {
 	log                 logrus.FieldLogger
 	mu                  sync.RWMutex
 	cacheReloadInterval time.Duration
+
+	// reloadMu serializes reloads, so a forced reload cannot be overwritten
+	// by an older cache built by the rebuild task.
+	reloadMu           sync.Mutex
+	lastReload         time.Time
+	lastReloadDuration time.Duration
 }
 
 func NewAuthorizedEntryFetcherWithFullCache(ctx context.Context, buildCache entryCacheBuilderFn, log logrus.FieldLogger, clk clock.Clock, cacheReloadInterval time.Duration) (*AuthorizedEntryFetcherWithFullCache, error) {
 	log.Info("Building in-memory entry cache")
+	start := clk.Now()
 	cache, err := buildCache(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	log.Info("Completed building in-memory entry cache")
+	end := clk.Now()
 	return &AuthorizedEntryFetcherWithFullCache{
 		buildCache:          buildCache,
 		cache:               cache,
 		clk:                 clk,
 		log:                 log,
 		cacheReloadInterval: cacheReloadInterval,
+		lastReload:          end,
+		lastReloadDuration:  end.Sub(start),
 	}, nil
 }
 
@@ -51,24 +76,87 @@ func (a *AuthorizedEntryFetcherWithFullCache) FetchAuthorizedEntries(ctx context
 
 // RunRebuildCacheTask starts a ticker which rebuilds the in-memory entry cache.
 func (a *AuthorizedEntryFetcherWithFullCache) RunRebuildCacheTask(ctx context.Context) error {
-	rebuild := func() {
-		cache, err := a.buildCache(ctx)
-		if err != nil {
-			a.log.WithError(err).Error("Failed to reload entry cache")
-		} else {
-			a.mu.Lock()
-			a.cache = cache
-			a.mu.Unlock()
-		}
-	}
-
 	for {
 		select {
 		case <-ctx.Done():
 			a.log.Debug("Stopping in-memory entry cache hydrator")
 			return nil
 		case <-a.clk.After(a.cacheReloadInterval):
-			rebuild()
+			if err := a.Reload(ctx); err != nil {
+				a.log.WithError(err).Error("Failed to reload entry cache")
+			}
 		}
 	}
 }
+
+// Reload fully reloads the in-memory entry cache from the datastore. The
+// current cache is kept if the reload fails.
+func (a *AuthorizedEntryFetcherWithFullCache) Reload(ctx context.Context) error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	start := a.clk.Now()
+	cache, err := a.buildCache(ctx)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cache = cache
+	a.lastReload = a.clk.Now()
+	a.lastReloadDuration = a.lastReload.Sub(start)
+	return nil
+}
+
+// Status compares the in-memory entry cache to a cache freshly built from
+// the datastore, without replacing it. Entries are compared by content, so
+// an entry updated in the datastore is reported even if the number of
+// entries did not change.
+func (a *AuthorizedEntryFetcherWithFullCache) Status(ctx context.Context) (*adminv1.EntryCacheStatus, error) {
+	fresh, err := a.buildCache(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	cache, lastReload, lastReloadDuration := a.cache, a.lastReload, a.lastReloadDuration
+	a.mu.RUnlock()
+
+	current, ok := cache.(entryDigester)
+	if !ok {
+		return nil, errors.New("entry cache cannot be compared")
+	}
+	latest, ok := fresh.(entryDigester)
+	if !ok {
+		return nil, errors.New("entry cache cannot be compared")
+	}
+
+	currentDigests := current.EntryDigests()
+	latestDigests := latest.EntryDigests()
+	var staleEntryIDs []string
+	for entryID, digest := range currentDigests {
+		if latestDigest, ok := latestDigests[entryID]; !ok || latestDigest != digest {
+			staleEntryIDs = append(staleEntryIDs, entryID)
+		}
+	}
+	for entryID := range latestDigests {
+		if _, ok := currentDigests[entryID]; !ok {
+			staleEntryIDs = append(staleEntryIDs, entryID)
+		}
+	}
+	sort.Strings(staleEntryIDs)
+
+	status := &adminv1.EntryCacheStatus{
+		EntryCount:          int32(current.EntryCount()),
+		DataStoreEntryCount: int32(latest.EntryCount()),
+		Stale:               len(staleEntryIDs) > 0 || current.EntryCount() != latest.EntryCount(),
+		LastReload:          lastReload.UTC(),
+		LastReloadDuration:  lastReloadDuration,
+	}
+	if len(staleEntryIDs) > maxStaleEntryIDs {
+		staleEntryIDs = staleEntryIDs[:maxStaleEntryIDs]
+	}
+	status.StaleEntryIDs = staleEntryIDs
+	return status, nil
+}
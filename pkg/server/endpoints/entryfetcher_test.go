@@ -2,7 +2,9 @@ package endpoints
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
+	"strconv"
 	"testing"
 	"time"
 
@@ -10,6 +12,7 @@ import (
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
 	"github.com/spiffe/spire/pkg/server/api"
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
 	"github.com/spiffe/spire/pkg/server/cache/entrycache"
 	"github.com/spiffe/spire/proto/spire/common"
 	"github.com/spiffe/spire/test/clock"
@@ -37,6 +40,19 @@ func newStaticEntryCache(entries map[spiffeid.ID][]*types.Entry) *staticEntryCac
 	}
 }
 
+type digestedEntryCache struct {
+	*staticEntryCache
+	digests map[string][sha256.Size]byte
+}
+
+func (c *digestedEntryCache) EntryCount() int {
+	return len(c.digests)
+}
+
+func (c *digestedEntryCache) EntryDigests() map[string][sha256.Size]byte {
+	return c.digests
+}
+
 func TestNewAuthorizedEntryFetcherWithFullCache(t *testing.T) {
 	ctx := context.Background()
 	log, _ := test.NewNullLogger()
@@ -215,6 +231,99 @@ func TestRunRebuildCacheTask(t *testing.T) {
 	sendResult(req, entryMap, nil)
 }
 
+func TestReloadEntryCache(t *testing.T) {
+	ctx := context.Background()
+	log, _ := test.NewNullLogger()
+	clk := clock.NewMock(t)
+	agentID := spiffeid.RequireFromPath(trustDomain, "/root")
+
+	var entries []*types.Entry
+	var buildErr error
+	buildCache := func(context.Context) (entrycache.Cache, error) {
+		if buildErr != nil {
+			return nil, buildErr
+		}
+		clk.Add(time.Second)
+		digests := make(map[string][sha256.Size]byte)
+		for _, entry := range entries {
+			digests[entry.Id] = sha256.Sum256([]byte(entry.SpiffeId.Path))
+		}
+		return &digestedEntryCache{
+			staticEntryCache: newStaticEntryCache(map[spiffeid.ID][]*types.Entry{agentID: entries}),
+			digests:          digests,
+		}, nil
+	}
+
+	newEntries := func() []*types.Entry {
+		entries := setupExpectedEntriesData(t, agentID)
+		for i, entry := range entries {
+			entry.Id = "entry" + strconv.Itoa(i)
+		}
+		return entries
+	}
+
+	entries = newEntries()
+	ef, err := NewAuthorizedEntryFetcherWithFullCache(ctx, buildCache, log, clk, defaultCacheReloadInterval)
+	require.NoError(t, err)
+	built := clk.Now()
+
+	status, err := ef.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &adminv1.EntryCacheStatus{
+		EntryCount:          2,
+		DataStoreEntryCount: 2,
+		LastReload:          built.UTC(),
+		LastReloadDuration:  time.Second,
+	}, status)
+
+	// An entry updated in the datastore makes the cache stale, even though
+	// the number of entries is unchanged
+	entries = newEntries()
+	entries[1].SpiffeId.Path = "/updated"
+	status, err = ef.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &adminv1.EntryCacheStatus{
+		EntryCount:          2,
+		DataStoreEntryCount: 2,
+		StaleEntryIDs:       []string{entries[1].Id},
+		Stale:               true,
+		LastReload:          built.UTC(),
+		LastReloadDuration:  time.Second,
+	}, status)
+
+	// Reloading picks up the new entries
+	require.NoError(t, ef.Reload(ctx))
+	fetched, err := ef.FetchAuthorizedEntries(ctx, agentID)
+	require.NoError(t, err)
+	assert.Equal(t, entries, fetched)
+	status, err = ef.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, status.Stale)
+	assert.Empty(t, status.StaleEntryIDs)
+	assert.Equal(t, time.Second, status.LastReloadDuration)
+
+	// The current cache is kept when the reload fails
+	buildErr = errors.New("some cache build error")
+	require.EqualError(t, ef.Reload(ctx), "some cache build error")
+	fetched, err = ef.FetchAuthorizedEntries(ctx, agentID)
+	require.NoError(t, err)
+	assert.Equal(t, entries, fetched)
+	_, err = ef.Status(ctx)
+	require.EqualError(t, err, "some cache build error")
+}
+
+func TestStatusWithoutDigests(t *testing.T) {
+	log, _ := test.NewNullLogger()
+	buildCache := func(context.Context) (entrycache.Cache, error) {
+		return newStaticEntryCache(nil), nil
+	}
+
+	ef, err := NewAuthorizedEntryFetcherWithFullCache(context.Background(), buildCache, log, clock.NewMock(t), defaultCacheReloadInterval)
+	require.NoError(t, err)
+	_, err = ef.Status(context.Background())
+	require.EqualError(t, err, "entry cache cannot be compared")
+}
+
 func setupExpectedEntriesData(t *testing.T, agentID spiffeid.ID) []*types.Entry {
 	const numEntries = 2
 	entryIDs := make([]spiffeid.ID, numEntries)
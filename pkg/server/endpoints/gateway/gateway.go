@@ -0,0 +1,288 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// OpenAPIPath is the path the OpenAPI document describing the gateway is
+	// served on.
+	OpenAPIPath = "/openapi.json"
+
+	// OpenAPIMethod is the method name the OpenAPI document requests are
+	// authorized, rate limited and audited as, so the document is only
+	// served to callers the authorization policy allows.
+	OpenAPIMethod = "/spire.server.gateway.Gateway/GetOpenAPI"
+
+	// maxRequestBodySize limits the size of the JSON request bodies.
+	maxRequestBodySize = 4 << 20
+)
+
+// Config is the configuration for the gateway handler.
+type Config struct {
+	Log logrus.FieldLogger
+
+	// RegisterServices registers the gRPC services exposed by the gateway,
+	// using the generated Register*Server functions. Only the unary methods
+	// of the services are exposed.
+	RegisterServices func(s grpc.ServiceRegistrar)
+
+	// UnaryInterceptor is invoked for every call, exactly as it would be for
+	// the gRPC server. It is responsible for authentication, authorization,
+	// rate limiting, audit logging, etc.
+	UnaryInterceptor grpc.UnaryServerInterceptor
+}
+
+type method struct {
+	fullMethod string
+	impl       interface{}
+	handler    func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error)
+}
+
+// Handler transcodes JSON over HTTP requests into calls to gRPC services.
+// Each unary method is exposed with a POST on a path that matches the gRPC
+// method name (e.g. /spire.api.server.entry.v1.Entry/ListEntries). The body
+// of the request and response are the protobuf messages encoded using the
+// canonical protobuf JSON mapping.
+type Handler struct {
+	log         logrus.FieldLogger
+	interceptor grpc.UnaryServerInterceptor
+	methods     map[string]method
+	openAPI     []byte
+}
+
+// New returns a new gateway handler.
+func New(config Config) (*Handler, error) {
+	h := &Handler{
+		log:         config.Log,
+		interceptor: config.UnaryInterceptor,
+		methods:     make(map[string]method),
+	}
+
+	registrar := &serviceRegistrar{methods: h.methods}
+	config.RegisterServices(registrar)
+
+	openAPI, err := buildOpenAPI(registrar.serviceNames)
+	if err != nil {
+		return nil, err
+	}
+	h.openAPI = openAPI
+	return h, nil
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == OpenAPIPath {
+		h.serveOpenAPI(w, r)
+		return
+	}
+
+	m, ok := h.methods[r.URL.Path]
+	if !ok {
+		writeError(w, status.Errorf(codes.Unimplemented, "unknown method %q", r.URL.Path))
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, status.Error(codes.Unimplemented, "method not allowed"))
+		return
+	}
+
+	ctx, err := peerContext(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ctx = grpc.NewContextWithServerTransportStream(ctx, &serverTransportStream{method: m.fullMethod})
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBodySize+1))
+	switch {
+	case err != nil:
+		writeError(w, status.Errorf(codes.InvalidArgument, "failed to read request body: %v", err))
+		return
+	case len(body) > maxRequestBodySize:
+		writeError(w, status.Error(codes.ResourceExhausted, "request body is too large"))
+		return
+	}
+
+	dec := func(req interface{}) error {
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return status.Errorf(codes.Internal, "unexpected request type %T", req)
+		}
+		if len(body) == 0 {
+			return nil
+		}
+		if err := protojson.Unmarshal(body, msg); err != nil {
+			return status.Errorf(codes.InvalidArgument, "malformed request: %v", err)
+		}
+		return nil
+	}
+
+	resp, err := m.handler(m.impl, ctx, dec, h.interceptor)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	msg, ok := resp.(proto.Message)
+	if !ok {
+		writeError(w, status.Errorf(codes.Internal, "unexpected response type %T", resp))
+		return
+	}
+	out, err := protojson.Marshal(msg)
+	if err != nil {
+		h.log.WithError(err).WithField(telemetry.Method, m.fullMethod).Error("Failed to marshal gateway response")
+		writeError(w, status.Error(codes.Internal, "failed to marshal response"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(out)
+}
+
+// serveOpenAPI serves the OpenAPI document, once the caller is authorized by
+// the interceptor for OpenAPIMethod.
+func (h *Handler) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, status.Error(codes.Unimplemented, "method not allowed"))
+		return
+	}
+
+	ctx, err := peerContext(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	ctx = grpc.NewContextWithServerTransportStream(ctx, &serverTransportStream{method: OpenAPIMethod})
+
+	info := &grpc.UnaryServerInfo{FullMethod: OpenAPIMethod}
+	if _, err := h.interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(h.openAPI)
+}
+
+// peerContext returns a context carrying the same peer information the gRPC
+// server would provide for the connection, so that callers are authenticated
+// by the exact same middleware.
+func peerContext(r *http.Request) (context.Context, error) {
+	addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "invalid remote address: %v", err)
+	}
+
+	p := &peer.Peer{
+		Addr: addr,
+	}
+	if r.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{
+			State: *r.TLS,
+			CommonAuthInfo: credentials.CommonAuthInfo{
+				SecurityLevel: credentials.PrivacyAndIntegrity,
+			},
+		}
+	}
+	return peer.NewContext(r.Context(), p), nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	out, marshalErr := protojson.Marshal(st.Proto())
+	if marshalErr != nil {
+		out = []byte(`{"code":13,"message":"failed to marshal error"}`)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(HTTPStatusFromCode(st.Code()))
+	_, _ = w.Write(out)
+}
+
+// HTTPStatusFromCode maps a gRPC status code to the HTTP status code used by
+// the gateway, following the mapping documented in google/rpc/code.proto.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// serviceRegistrar collects the unary methods of the services registered
+// with the gateway.
+type serviceRegistrar struct {
+	methods      map[string]method
+	serviceNames []string
+}
+
+func (r *serviceRegistrar) RegisterService(desc *grpc.ServiceDesc, impl interface{}) {
+	r.serviceNames = append(r.serviceNames, desc.ServiceName)
+	for _, methodDesc := range desc.Methods {
+		fullMethod := "/" + desc.ServiceName + "/" + methodDesc.MethodName
+		r.methods[fullMethod] = method{
+			fullMethod: fullMethod,
+			impl:       impl,
+			handler:    methodDesc.Handler,
+		}
+	}
+}
+
+// serverTransportStream provides the method name to code that relies on
+// grpc.Method() and silently drops headers and trailers, which have no
+// meaning for the gateway.
+type serverTransportStream struct {
+	method string
+}
+
+func (s *serverTransportStream) Method() string {
+	return s.method
+}
+
+func (s *serverTransportStream) SetHeader(md metadata.MD) error {
+	return nil
+}
+
+func (s *serverTransportStream) SendHeader(md metadata.MD) error {
+	return nil
+}
+
+func (s *serverTransportStream) SetTrailer(md metadata.MD) error {
+	return nil
+}
@@ -0,0 +1,188 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	entryv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestGateway(t *testing.T) {
+	for _, tt := range []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		interceptorErr error
+		expectStatus   int
+		expectBody     string
+	}{
+		{
+			name:         "success",
+			method:       http.MethodPost,
+			path:         "/spire.api.server.entry.v1.Entry/CountEntries",
+			body:         `{}`,
+			expectStatus: http.StatusOK,
+			expectBody:   `{"count":3}`,
+		},
+		{
+			name:         "success with empty body",
+			method:       http.MethodPost,
+			path:         "/spire.api.server.entry.v1.Entry/CountEntries",
+			expectStatus: http.StatusOK,
+			expectBody:   `{"count":3}`,
+		},
+		{
+			name:         "service error",
+			method:       http.MethodPost,
+			path:         "/spire.api.server.entry.v1.Entry/GetEntry",
+			body:         `{"id":"missing"}`,
+			expectStatus: http.StatusNotFound,
+			expectBody:   `{"code":5,"message":"entry not found"}`,
+		},
+		{
+			name:           "rejected by interceptor",
+			method:         http.MethodPost,
+			path:           "/spire.api.server.entry.v1.Entry/CountEntries",
+			interceptorErr: status.Error(codes.PermissionDenied, "authorization denied"),
+			expectStatus:   http.StatusForbidden,
+			expectBody:     `{"code":7,"message":"authorization denied"}`,
+		},
+		{
+			name:         "malformed request",
+			method:       http.MethodPost,
+			path:         "/spire.api.server.entry.v1.Entry/GetEntry",
+			body:         `{"unknown":true}`,
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown method",
+			method:       http.MethodPost,
+			path:         "/spire.api.server.entry.v1.Entry/Unknown",
+			expectStatus: http.StatusNotImplemented,
+			expectBody:   `{"code":12,"message":"unknown method \"/spire.api.server.entry.v1.Entry/Unknown\""}`,
+		},
+		{
+			name:         "wrong HTTP method",
+			method:       http.MethodGet,
+			path:         "/spire.api.server.entry.v1.Entry/CountEntries",
+			expectStatus: http.StatusNotImplemented,
+			expectBody:   `{"code":12,"message":"method not allowed"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var fullMethod string
+			interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				fullMethod = info.FullMethod
+				p, ok := peer.FromContext(ctx)
+				require.True(t, ok, "peer is missing from context")
+				require.Equal(t, "tcp", p.Addr.Network())
+				if tt.interceptorErr != nil {
+					return nil, tt.interceptorErr
+				}
+				return handler(ctx, req)
+			}
+
+			h := newHandler(t, interceptor)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectStatus, rec.Code)
+			require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			if tt.expectBody != "" {
+				require.JSONEq(t, tt.expectBody, rec.Body.String())
+			}
+			if tt.expectStatus == http.StatusOK {
+				require.Equal(t, tt.path, fullMethod)
+			}
+		})
+	}
+}
+
+func TestGatewayOpenAPI(t *testing.T) {
+	var fullMethod string
+	h := newHandler(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		fullMethod = info.FullMethod
+		return handler(ctx, req)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, OpenAPIPath, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, OpenAPIMethod, fullMethod)
+
+	var doc openAPIDocument
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	path, ok := doc.Paths["/spire.api.server.entry.v1.Entry/CountEntries"]
+	require.True(t, ok, "path for CountEntries is missing")
+	assert.Equal(t, "Entry_CountEntries", path.Post.OperationID)
+	assert.Equal(t, "#/components/schemas/spire.api.server.entry.v1.CountEntriesRequest", path.Post.RequestBody.Content["application/json"].Schema.Ref)
+
+	schema, ok := doc.Components.Schemas["spire.api.server.entry.v1.CountEntriesResponse"]
+	require.True(t, ok, "schema for CountEntriesResponse is missing")
+	assert.Equal(t, "integer", schema.Properties["count"].Type)
+}
+
+func TestGatewayOpenAPIUnauthorized(t *testing.T) {
+	h := newHandler(t, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return nil, status.Error(codes.PermissionDenied, "authorization denied")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, OpenAPIPath, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.JSONEq(t, `{"code":7,"message":"authorization denied"}`, rec.Body.String())
+}
+
+func TestHTTPStatusFromCode(t *testing.T) {
+	assert.Equal(t, http.StatusOK, HTTPStatusFromCode(codes.OK))
+	assert.Equal(t, http.StatusBadRequest, HTTPStatusFromCode(codes.InvalidArgument))
+	assert.Equal(t, http.StatusUnauthorized, HTTPStatusFromCode(codes.Unauthenticated))
+	assert.Equal(t, http.StatusForbidden, HTTPStatusFromCode(codes.PermissionDenied))
+	assert.Equal(t, http.StatusTooManyRequests, HTTPStatusFromCode(codes.ResourceExhausted))
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatusFromCode(codes.DataLoss))
+}
+
+func newHandler(t *testing.T, interceptor grpc.UnaryServerInterceptor) *Handler {
+	log, _ := test.NewNullLogger()
+	h, err := New(Config{
+		Log: log,
+		RegisterServices: func(s grpc.ServiceRegistrar) {
+			entryv1.RegisterEntryServer(s, fakeEntryServer{})
+		},
+		UnaryInterceptor: interceptor,
+	})
+	require.NoError(t, err)
+	return h
+}
+
+type fakeEntryServer struct {
+	entryv1.UnimplementedEntryServer
+}
+
+func (fakeEntryServer) CountEntries(context.Context, *entryv1.CountEntriesRequest) (*entryv1.CountEntriesResponse, error) {
+	return &entryv1.CountEntriesResponse{Count: 3}, nil
+}
+
+func (fakeEntryServer) GetEntry(context.Context, *entryv1.GetEntryRequest) (*types.Entry, error) {
+	return nil, status.Error(codes.NotFound, "entry not found")
+}
@@ -0,0 +1,218 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/spiffe/spire/pkg/common/version"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// The OpenAPI document is generated from the protobuf descriptors registered
+// by the generated API code, so it always reflects the protos the server was
+// built with.
+
+type openAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       openAPIInfo                `json:"info"`
+	Paths      map[string]openAPIPathItem `json:"paths"`
+	Components openAPIComponents          `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIPathItem struct {
+	Post openAPIOperation `json:"post"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Tags        []string                   `json:"tags"`
+	RequestBody openAPIRequestBody         `json:"requestBody"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*openAPISchema `json:"schemas"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+func buildOpenAPI(serviceNames []string) ([]byte, error) {
+	doc := openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:   "SPIRE Server REST Gateway",
+			Version: version.Version(),
+		},
+		Paths: make(map[string]openAPIPathItem),
+		Components: openAPIComponents{
+			Schemas: make(map[string]*openAPISchema),
+		},
+	}
+
+	sort.Strings(serviceNames)
+	for _, serviceName := range serviceNames {
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(serviceName))
+		if err != nil {
+			return nil, fmt.Errorf("unable to find descriptor for service %q: %w", serviceName, err)
+		}
+		sd, ok := d.(protoreflect.ServiceDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("descriptor for %q is not a service", serviceName)
+		}
+
+		methods := sd.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			md := methods.Get(i)
+			if md.IsStreamingClient() || md.IsStreamingServer() {
+				continue
+			}
+			addSchema(doc.Components.Schemas, md.Input())
+			addSchema(doc.Components.Schemas, md.Output())
+			doc.Paths["/"+serviceName+"/"+string(md.Name())] = openAPIPathItem{
+				Post: openAPIOperation{
+					OperationID: string(sd.Name()) + "_" + string(md.Name()),
+					Tags:        []string{string(sd.Name())},
+					RequestBody: openAPIRequestBody{
+						Required: true,
+						Content:  jsonContent(md.Input()),
+					},
+					Responses: map[string]openAPIResponse{
+						"200": {
+							Description: "A successful response.",
+							Content:     jsonContent(md.Output()),
+						},
+						"default": {
+							Description: "An error response.",
+							Content: map[string]openAPIMediaType{
+								"application/json": {Schema: &openAPISchema{Ref: "#/components/schemas/google.rpc.Status"}},
+							},
+						},
+					},
+				},
+			}
+		}
+	}
+
+	doc.Components.Schemas["google.rpc.Status"] = &openAPISchema{
+		Type: "object",
+		Properties: map[string]*openAPISchema{
+			"code":    {Type: "integer", Format: "int32"},
+			"message": {Type: "string"},
+			"details": {Type: "array", Items: &openAPISchema{Type: "object"}},
+		},
+	}
+
+	return json.Marshal(doc)
+}
+
+func jsonContent(md protoreflect.MessageDescriptor) map[string]openAPIMediaType {
+	return map[string]openAPIMediaType{
+		"application/json": {Schema: schemaRef(md)},
+	}
+}
+
+func schemaRef(md protoreflect.MessageDescriptor) *openAPISchema {
+	return &openAPISchema{Ref: "#/components/schemas/" + string(md.FullName())}
+}
+
+func addSchema(schemas map[string]*openAPISchema, md protoreflect.MessageDescriptor) {
+	name := string(md.FullName())
+	if _, ok := schemas[name]; ok {
+		return
+	}
+
+	schema := &openAPISchema{
+		Type:       "object",
+		Properties: make(map[string]*openAPISchema),
+	}
+	// Register the schema before visiting the fields to handle recursive
+	// messages.
+	schemas[name] = schema
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		schema.Properties[fd.JSONName()] = fieldSchema(schemas, fd)
+	}
+}
+
+func fieldSchema(schemas map[string]*openAPISchema, fd protoreflect.FieldDescriptor) *openAPISchema {
+	switch {
+	case fd.IsMap():
+		return &openAPISchema{
+			Type:                 "object",
+			AdditionalProperties: singularFieldSchema(schemas, fd.MapValue()),
+		}
+	case fd.IsList():
+		return &openAPISchema{
+			Type:  "array",
+			Items: singularFieldSchema(schemas, fd),
+		}
+	default:
+		return singularFieldSchema(schemas, fd)
+	}
+}
+
+func singularFieldSchema(schemas map[string]*openAPISchema, fd protoreflect.FieldDescriptor) *openAPISchema {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return &openAPISchema{Type: "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return &openAPISchema{Type: "integer", Format: "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// 64-bit integers are encoded as strings by the protobuf JSON mapping
+		return &openAPISchema{Type: "string", Format: "int64"}
+	case protoreflect.FloatKind:
+		return &openAPISchema{Type: "number", Format: "float"}
+	case protoreflect.DoubleKind:
+		return &openAPISchema{Type: "number", Format: "double"}
+	case protoreflect.StringKind:
+		return &openAPISchema{Type: "string"}
+	case protoreflect.BytesKind:
+		return &openAPISchema{Type: "string", Format: "byte"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		enum := make([]string, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			enum = append(enum, string(values.Get(i).Name()))
+		}
+		return &openAPISchema{Type: "string", Enum: enum}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		addSchema(schemas, fd.Message())
+		return schemaRef(fd.Message())
+	default:
+		return &openAPISchema{}
+	}
+}
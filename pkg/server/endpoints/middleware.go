@@ -2,6 +2,7 @@ package endpoints
 
 import (
 	"crypto/x509"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/go-spiffe/v2/spiffeid"
@@ -23,11 +24,13 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-func Middleware(log logrus.FieldLogger, metrics telemetry.Metrics, ds datastore.DataStore, clk clock.Clock, rlConf RateLimitConfig, policyEngine *authpolicy.Engine, auditLogEnabled bool, adminIDs []spiffeid.ID) middleware.Middleware {
+func Middleware(log logrus.FieldLogger, metrics telemetry.Metrics, ds datastore.DataStore, clk clock.Clock, rlConf RateLimitConfig, policyEngine *authpolicy.Engine, auditLogEnabled bool, adminIDs []spiffeid.ID, maxAgentClockSkew time.Duration) middleware.Middleware {
 	chain := []middleware.Middleware{
 		middleware.WithLogger(log),
 		middleware.WithMetrics(metrics),
 		middleware.WithAuthorization(policyEngine, EntryFetcher(ds), AgentAuthorizer(log, ds, clk), adminIDs),
+		middleware.WithAgentClockSkew(metrics, clk, maxAgentClockSkew),
+		middleware.WithFeatureNegotiation(metrics),
 		middleware.WithRateLimits(RateLimits(rlConf), metrics),
 	}
 
@@ -166,6 +169,21 @@ func RateLimits(config RateLimitConfig) map[string]api.RateLimiter {
 		"/spire.api.server.trustdomain.v1.TrustDomain/BatchUpdateFederationRelationship": noLimit,
 		"/spire.api.server.trustdomain.v1.TrustDomain/BatchDeleteFederationRelationship": noLimit,
 		"/spire.api.server.trustdomain.v1.TrustDomain/RefreshBundle":                     noLimit,
+		"/spire.server.admin.Admin/ListPendingAgents":                                    noLimit,
+		"/spire.server.admin.Admin/ApprovePendingAgent":                                  noLimit,
+		"/spire.server.admin.Admin/DeletePendingAgent":                                   noLimit,
+		"/spire.server.admin.Admin/ListAttestationEvents":                                noLimit,
+		"/spire.server.admin.Admin/ListIssuanceRecords":                                  noLimit,
+		"/spire.server.admin.Admin/GetEntryCacheStatus":                                  noLimit,
+		"/spire.server.admin.Admin/ReloadEntryCache":                                     noLimit,
+		"/spire.server.admin.Admin/GetUpstreamAuthorityStatus":                           noLimit,
+		"/spire.server.admin.Admin/ListEvents":                                           noLimit,
+		"/spire.server.admin.Admin/ListSelectorSets":                                     noLimit,
+		"/spire.server.admin.Admin/GetSelectorSet":                                       noLimit,
+		"/spire.server.admin.Admin/CreateSelectorSet":                                    noLimit,
+		"/spire.server.admin.Admin/UpdateSelectorSet":                                    noLimit,
+		"/spire.server.admin.Admin/DeleteSelectorSet":                                    noLimit,
+		"/spire.server.gateway.Gateway/GetOpenAPI":                                       noLimit,
 		"/grpc.health.v1.Health/Check":                                                   noLimit,
 		"/grpc.health.v1.Health/Watch":                                                   noLimit,
 	}
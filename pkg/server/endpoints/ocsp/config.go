@@ -0,0 +1,31 @@
+package ocsp
+
+import (
+	"net"
+	"time"
+)
+
+const (
+	// DefaultCacheTTL is how long the status of an SVID is cached when no
+	// cache TTL is configured.
+	DefaultCacheTTL = 5 * time.Minute
+
+	// DefaultMaxLookupRate is the maximum number of statuses looked up in
+	// the datastore per second when no maximum is configured.
+	DefaultMaxLookupRate = 100
+)
+
+type ResponderConfig struct {
+	// Address is the address on which to serve the OCSP responder.
+	Address *net.TCPAddr
+
+	// CacheTTL is how long the status of an SVID is cached by the responder.
+	// It is also the validity period of the responses, so relying parties
+	// and HTTP caches do not ask again before it has elapsed.
+	CacheTTL time.Duration
+
+	// MaxLookupRate is the maximum number of statuses looked up in the
+	// datastore per second. Requests that miss the cache beyond that rate
+	// are asked to try later.
+	MaxLookupRate int
+}
@@ -0,0 +1,472 @@
+// Package ocsp implements an OCSP responder (RFC 6960) for the X509-SVIDs
+// signed by the server CA, so relying parties that require OCSP, e.g. for
+// stapling, can check their status.
+//
+// The status is derived from the issuance records kept in the datastore: an
+// SVID without a record is unknown, and an SVID issued for a registration
+// entry that has since been deleted is revoked as of the deletion. Responses
+// are cached, and valid, for the configured TTL, which bounds the load on the
+// datastore and how long a deletion takes to be reflected. Lookups that miss
+// the cache are rate limited, and unknown statuses are cached apart, so
+// requests for random serial numbers can neither overload the datastore nor
+// evict the cached statuses of actual SVIDs.
+//
+// SVIDs signed by the X509 CAs of the server are answered with a response
+// signed by that CA. SVIDs signed by other X509 authorities of the trust
+// domain bundle, e.g. the CAs of the other servers of an HA deployment, are
+// answered with a response signed by the current X509 CA of the server, which
+// is included in the response. Such a responder is not delegated by the
+// issuer, as RFC 6960 describes, so relying parties have to trust the X509
+// authorities of the bundle as OCSP responders.
+package ocsp
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/pkg/server/ca"
+	"github.com/spiffe/spire/pkg/server/datastore"
+	"github.com/zeebo/errs"
+	cryptoocsp "golang.org/x/crypto/ocsp"
+	"golang.org/x/time/rate"
+)
+
+const (
+	requestContentType  = "application/ocsp-request"
+	responseContentType = "application/ocsp-response"
+
+	// maxRequestSize limits the size of the requests. Requests for a
+	// single certificate are around a hundred bytes.
+	maxRequestSize = 4 << 10
+
+	// maxCacheSize bounds the number of cached responses.
+	maxCacheSize = 100000
+
+	// maxUnknownCacheSize bounds the number of cached responses for SVIDs
+	// with an unknown status, which are kept apart so that requests for
+	// random serial numbers do not evict the responses for actual SVIDs.
+	maxUnknownCacheSize = 10000
+)
+
+var (
+	errUnauthorized = errors.New("certificate not issued by the trust domain")
+	errTryLater     = errors.New("too many status lookups")
+)
+
+// X509CASource provides the X509 CAs that SVIDs are currently signed with.
+type X509CASource interface {
+	X509CAs() []*ca.X509CA
+}
+
+type ServerConfig struct {
+	Log           logrus.FieldLogger
+	Address       string
+	TrustDomain   spiffeid.TrustDomain
+	DataStore     datastore.DataStore
+	X509CAs       X509CASource
+	CacheTTL      time.Duration
+	MaxLookupRate int
+	Clock         clock.Clock
+
+	// test hooks
+	listen func(network, address string) (net.Listener, error)
+}
+
+type Server struct {
+	c       ServerConfig
+	lookups *rate.Limiter
+
+	mtx     sync.Mutex
+	cache   *responseCache
+	unknown *responseCache
+
+	// bundleAuthorities are the X509 authorities of the trust domain
+	// bundle, refreshed once they are older than the cache TTL.
+	bundleAuthorities []*x509.Certificate
+	bundleFetchedAt   time.Time
+}
+
+// issuer is an X509 authority the responder answers for, along with the
+// certificate and key the responses are signed with.
+type issuer struct {
+	certificate *x509.Certificate
+	responder   *x509.Certificate
+	signer      crypto.Signer
+
+	// separateResponder is set when the responses are signed by a responder
+	// certificate other than the issuer, i.e. the current X509 CA of the
+	// server answering for another authority of the bundle, in which case
+	// the responder certificate is included in the responses. The responder
+	// is not a delegated responder in the RFC 6960 sense, since the issuer
+	// did not sign its certificate.
+	separateResponder bool
+}
+
+type cacheKey struct {
+	issuerKeyHash string
+	serialNumber  string
+}
+
+type response struct {
+	der        []byte
+	thisUpdate time.Time
+	nextUpdate time.Time
+}
+
+func NewServer(config ServerConfig) *Server {
+	if config.listen == nil {
+		config.listen = net.Listen
+	}
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultCacheTTL
+	}
+	if config.MaxLookupRate <= 0 {
+		config.MaxLookupRate = DefaultMaxLookupRate
+	}
+	if config.Clock == nil {
+		config.Clock = clock.New()
+	}
+	return &Server{
+		c:       config,
+		lookups: rate.NewLimiter(rate.Limit(config.MaxLookupRate), config.MaxLookupRate),
+		cache:   newResponseCache(maxCacheSize),
+		unknown: newResponseCache(maxUnknownCacheSize),
+	}
+}
+
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	listener, err := s.c.listen("tcp", s.c.Address)
+	if err != nil {
+		return errs.Wrap(err)
+	}
+
+	// Responses are signed, so they are served over plain HTTP, which is
+	// what relying parties expect.
+	server := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: time.Second * 10,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- errs.Wrap(server.Serve(listener))
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		server.Close()
+		return nil
+	}
+}
+
+// ServeHTTP answers OCSP requests sent with GET, base64 encoded in the path,
+// or with POST, in the body.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var der []byte
+	var err error
+	switch req.Method {
+	case http.MethodGet:
+		der, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(req.URL.Path, "/"))
+	case http.MethodPost:
+		if req.Header.Get("Content-Type") != requestContentType {
+			http.Error(w, "415 unsupported media type", http.StatusUnsupportedMediaType)
+			return
+		}
+		der, err = io.ReadAll(io.LimitReader(req.Body, maxRequestSize+1))
+		if err == nil && len(der) > maxRequestSize {
+			err = errors.New("request too large")
+		}
+	default:
+		http.Error(w, "405 method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var ocspReq *cryptoocsp.Request
+	if err == nil {
+		ocspReq, err = cryptoocsp.ParseRequest(der)
+	}
+	if err != nil {
+		s.c.Log.WithError(err).Debug("Malformed OCSP request")
+		writeResponse(w, http.StatusBadRequest, cryptoocsp.MalformedRequestErrorResponse)
+		return
+	}
+
+	resp, err := s.respond(req.Context(), ocspReq)
+	switch {
+	case errors.Is(err, errUnauthorized):
+		writeResponse(w, http.StatusOK, cryptoocsp.UnauthorizedErrorResponse)
+		return
+	case errors.Is(err, errTryLater):
+		writeResponse(w, http.StatusOK, cryptoocsp.TryLaterErrorResponse)
+		return
+	case err != nil:
+		s.c.Log.WithError(err).WithField(telemetry.SerialNumber, ocspReq.SerialNumber.String()).Error("Unable to answer OCSP request")
+		writeResponse(w, http.StatusInternalServerError, cryptoocsp.InternalErrorErrorResponse)
+		return
+	}
+
+	// Let HTTP caches serve the response until it is no longer valid, as
+	// recommended by RFC 5019.
+	maxAge := resp.nextUpdate.Sub(s.c.Clock.Now()) / time.Second
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	w.Header().Set("Cache-Control", "max-age="+strconv.FormatInt(int64(maxAge), 10)+", public, no-transform, must-revalidate")
+	w.Header().Set("Last-Modified", resp.thisUpdate.UTC().Format(http.TimeFormat))
+	w.Header().Set("Expires", resp.nextUpdate.UTC().Format(http.TimeFormat))
+	writeResponse(w, http.StatusOK, resp.der)
+}
+
+func (s *Server) respond(ctx context.Context, req *cryptoocsp.Request) (*response, error) {
+	now := s.c.Clock.Now()
+	issuer, err := s.findIssuer(ctx, req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	key := cacheKey{
+		issuerKeyHash: string(req.IssuerKeyHash),
+		serialNumber:  req.SerialNumber.String(),
+	}
+	if resp := s.getCached(key, now); resp != nil {
+		return resp, nil
+	}
+
+	if !s.lookups.AllowN(now, 1) {
+		return nil, errTryLater
+	}
+	template, err := s.status(ctx, req.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+	template.IssuerHash = req.HashAlgorithm
+	template.ThisUpdate = now
+	template.NextUpdate = now.Add(s.c.CacheTTL)
+	if issuer.separateResponder {
+		template.Certificate = issuer.responder
+	}
+
+	der, err := cryptoocsp.CreateResponse(issuer.certificate, issuer.responder, template, issuer.signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCSP response: %w", err)
+	}
+
+	resp := &response{
+		der:        der,
+		thisUpdate: template.ThisUpdate,
+		nextUpdate: template.NextUpdate,
+	}
+	s.setCached(key, resp, template.Status == cryptoocsp.Unknown, now)
+	return resp, nil
+}
+
+// findIssuer returns the X509 authority identified by the name and key
+// hashes of the request. The X509 CAs of the server sign the responses for
+// the SVIDs they issued. The current X509 CA signs the responses for the
+// SVIDs issued by the other X509 authorities of the trust domain bundle.
+func (s *Server) findIssuer(ctx context.Context, req *cryptoocsp.Request, now time.Time) (*issuer, error) {
+	if !req.HashAlgorithm.Available() {
+		return nil, errUnauthorized
+	}
+
+	x509CAs := s.c.X509CAs.X509CAs()
+	for _, x509CA := range x509CAs {
+		ok, err := matchesIssuer(req, x509CA.Certificate)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &issuer{
+				certificate: x509CA.Certificate,
+				responder:   x509CA.Certificate,
+				signer:      x509CA.Signer,
+			}, nil
+		}
+	}
+	if len(x509CAs) == 0 {
+		return nil, errUnauthorized
+	}
+
+	authorities, err := s.getBundleAuthorities(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, authority := range authorities {
+		ok, err := matchesIssuer(req, authority)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &issuer{
+				certificate:       authority,
+				responder:         x509CAs[0].Certificate,
+				signer:            x509CAs[0].Signer,
+				separateResponder: true,
+			}, nil
+		}
+	}
+	return nil, errUnauthorized
+}
+
+// getBundleAuthorities returns the X509 authorities of the trust domain
+// bundle, fetching them from the datastore when the ones held are older
+// than the cache TTL.
+func (s *Server) getBundleAuthorities(ctx context.Context, now time.Time) ([]*x509.Certificate, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if !s.bundleFetchedAt.IsZero() && now.Sub(s.bundleFetchedAt) < s.c.CacheTTL {
+		return s.bundleAuthorities, nil
+	}
+
+	bundle, err := s.c.DataStore.FetchBundle(ctx, s.c.TrustDomain.IDString())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trust domain bundle: %w", err)
+	}
+	var authorities []*x509.Certificate
+	if bundle != nil {
+		for _, rootCA := range bundle.RootCas {
+			authority, err := x509.ParseCertificate(rootCA.DerBytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse trust domain bundle X509 authority: %w", err)
+			}
+			authorities = append(authorities, authority)
+		}
+	}
+	s.bundleAuthorities = authorities
+	s.bundleFetchedAt = now
+	return authorities, nil
+}
+
+// matchesIssuer returns whether the certificate is the issuer identified by
+// the name and key hashes of the request.
+func matchesIssuer(req *cryptoocsp.Request, cert *x509.Certificate) (bool, error) {
+	var publicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(cert.RawSubjectPublicKeyInfo, &publicKeyInfo); err != nil {
+		return false, fmt.Errorf("failed to parse X509 authority public key: %w", err)
+	}
+
+	h := req.HashAlgorithm.New()
+	h.Write(publicKeyInfo.PublicKey.RightAlign())
+	if !bytes.Equal(h.Sum(nil), req.IssuerKeyHash) {
+		return false, nil
+	}
+	h.Reset()
+	h.Write(cert.RawSubject)
+	return bytes.Equal(h.Sum(nil), req.IssuerNameHash), nil
+}
+
+// status returns the status of the SVID with the given serial number.
+func (s *Server) status(ctx context.Context, serialNumber *big.Int) (cryptoocsp.Response, error) {
+	template := cryptoocsp.Response{
+		SerialNumber: serialNumber,
+		Status:       cryptoocsp.Unknown,
+	}
+
+	resp, err := s.c.DataStore.ListIssuanceRecords(ctx, &datastore.ListIssuanceRecordsRequest{
+		BySerialNumber: serialNumber.String(),
+	})
+	if err != nil {
+		return template, fmt.Errorf("failed to list issuance records: %w", err)
+	}
+	if len(resp.Records) == 0 {
+		return template, nil
+	}
+	record := resp.Records[0]
+
+	template.Status = cryptoocsp.Good
+	if !record.RevokedAt.IsZero() {
+		template.Status = cryptoocsp.Revoked
+		template.RevokedAt = record.RevokedAt
+		template.RevocationReason = cryptoocsp.CessationOfOperation
+	}
+	return template, nil
+}
+
+func (s *Server) getCached(key cacheKey, now time.Time) *response {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if resp := s.cache.get(key, now); resp != nil {
+		return resp
+	}
+	return s.unknown.get(key, now)
+}
+
+func (s *Server) setCached(key cacheKey, resp *response, unknown bool, now time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if unknown {
+		s.unknown.set(key, resp, now)
+	} else {
+		s.cache.set(key, resp, now)
+	}
+}
+
+// responseCache holds up to a maximum number of responses. Once reached,
+// expired responses are pruned, and the whole cache is dropped if that is
+// not enough.
+type responseCache struct {
+	max     int
+	entries map[cacheKey]*response
+}
+
+func newResponseCache(max int) *responseCache {
+	return &responseCache{
+		max:     max,
+		entries: make(map[cacheKey]*response),
+	}
+}
+
+func (c *responseCache) get(key cacheKey, now time.Time) *response {
+	resp, ok := c.entries[key]
+	if !ok || !now.Before(resp.nextUpdate) {
+		return nil
+	}
+	return resp
+}
+
+func (c *responseCache) set(key cacheKey, resp *response, now time.Time) {
+	if len(c.entries) >= c.max {
+		for k, v := range c.entries {
+			if !now.Before(v.nextUpdate) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.max {
+			c.entries = make(map[cacheKey]*response)
+		}
+	}
+	c.entries[key] = resp
+}
+
+func writeResponse(w http.ResponseWriter, statusCode int, der []byte) {
+	w.Header().Set("Content-Type", responseContentType)
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(der)
+}
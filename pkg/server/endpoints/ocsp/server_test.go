@@ -0,0 +1,261 @@
+package ocsp
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/spire/pkg/server/ca"
+	"github.com/spiffe/spire/pkg/server/datastore"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/spiffe/spire/test/clock"
+	"github.com/spiffe/spire/test/fakes/fakedatastore"
+	"github.com/spiffe/spire/test/fakes/fakeserverca"
+	"github.com/spiffe/spire/test/testca"
+	"github.com/spiffe/spire/test/testkey"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cryptoocsp "golang.org/x/crypto/ocsp"
+	"golang.org/x/time/rate"
+)
+
+var (
+	td         = spiffeid.RequireTrustDomainFromString("example.org")
+	workloadID = spiffeid.RequireFromPath(td, "/workload")
+)
+
+func TestServerStatus(t *testing.T) {
+	st := setupTest(t)
+
+	entry, err := st.ds.CreateRegistrationEntry(context.Background(), &common.RegistrationEntry{
+		ParentId:  "spiffe://example.org/agent",
+		SpiffeId:  workloadID.String(),
+		Selectors: []*common.Selector{{Type: "unix", Value: "uid:1000"}},
+	})
+	require.NoError(t, err)
+
+	issued := st.signSVID(t)
+	st.recordIssuance(t, issued, entry.EntryId)
+	unrecorded := st.signSVID(t)
+
+	resp := st.post(t, issued)
+	require.Equal(t, cryptoocsp.Good, resp.Status)
+	require.Equal(t, st.clk.Now().UTC(), resp.ThisUpdate)
+	require.Equal(t, st.clk.Now().Add(time.Minute).UTC(), resp.NextUpdate)
+
+	resp = st.post(t, unrecorded)
+	require.Equal(t, cryptoocsp.Unknown, resp.Status)
+
+	// The status is cached, so deleting the entry is not noticed until
+	// the cached response expires.
+	deletedAt := time.Now()
+	_, err = st.ds.DeleteRegistrationEntry(context.Background(), entry.EntryId)
+	require.NoError(t, err)
+	lookups := st.ds.lookups
+	resp = st.post(t, issued)
+	require.Equal(t, cryptoocsp.Good, resp.Status)
+	require.Equal(t, lookups, st.ds.lookups)
+
+	st.clk.Add(time.Minute)
+	resp = st.post(t, issued)
+	require.Equal(t, cryptoocsp.Revoked, resp.Status)
+	require.Equal(t, cryptoocsp.CessationOfOperation, resp.RevocationReason)
+	require.WithinDuration(t, deletedAt, resp.RevokedAt, time.Second)
+	require.Equal(t, lookups+1, st.ds.lookups)
+}
+
+func TestServerBundleIssuer(t *testing.T) {
+	st := setupTest(t)
+
+	// The X509 CA of another server of the trust domain
+	otherCA := testca.New(t, td)
+	_, err := st.ds.CreateBundle(context.Background(), &common.Bundle{
+		TrustDomainId: td.IDString(),
+		RootCas:       []*common.Certificate{{DerBytes: otherCA.X509Authorities()[0].Raw}},
+	})
+	require.NoError(t, err)
+
+	svid := otherCA.CreateX509SVID(workloadID).Certificates[0]
+	st.recordIssuance(t, svid, "")
+	der, err := cryptoocsp.CreateRequest(svid, otherCA.X509Authorities()[0], nil)
+	require.NoError(t, err)
+
+	w := st.do(http.MethodPost, "application/ocsp-request", der)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// The response is signed by the current X509 CA of the server, which
+	// is included in the response.
+	resp, err := cryptoocsp.ParseResponse(w.Body.Bytes(), nil)
+	require.NoError(t, err)
+	require.Equal(t, cryptoocsp.Good, resp.Status)
+	require.Equal(t, svid.SerialNumber, resp.SerialNumber)
+	require.Equal(t, st.issuer(), resp.Certificate)
+}
+
+func TestServerLookupRate(t *testing.T) {
+	st := setupTest(t)
+	st.server.lookups = rate.NewLimiter(1, 1)
+
+	unrecorded := st.signSVID(t)
+	resp := st.post(t, unrecorded)
+	require.Equal(t, cryptoocsp.Unknown, resp.Status)
+	require.Equal(t, 1, st.ds.lookups)
+
+	// Unknown statuses are cached too
+	resp = st.post(t, unrecorded)
+	require.Equal(t, cryptoocsp.Unknown, resp.Status)
+	require.Equal(t, 1, st.ds.lookups)
+
+	// Beyond the lookup rate, uncached statuses are not looked up
+	other := st.signSVID(t)
+	der, err := cryptoocsp.CreateRequest(other, st.issuer(), nil)
+	require.NoError(t, err)
+	w := st.do(http.MethodPost, "application/ocsp-request", der)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, cryptoocsp.TryLaterErrorResponse, w.Body.Bytes())
+	require.Equal(t, 1, st.ds.lookups)
+
+	st.clk.Add(time.Second)
+	resp = st.post(t, other)
+	require.Equal(t, cryptoocsp.Unknown, resp.Status)
+	require.Equal(t, 2, st.ds.lookups)
+}
+
+func TestServerGET(t *testing.T) {
+	st := setupTest(t)
+	svid := st.signSVID(t)
+	st.recordIssuance(t, svid, "")
+
+	der, err := cryptoocsp.CreateRequest(svid, st.issuer(), nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	st.server.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/"+base64.StdEncoding.EncodeToString(der), nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/ocsp-response", w.Header().Get("Content-Type"))
+	assert.Equal(t, "max-age=60, public, no-transform, must-revalidate", w.Header().Get("Cache-Control"))
+
+	resp, err := cryptoocsp.ParseResponseForCert(w.Body.Bytes(), svid, st.issuer())
+	require.NoError(t, err)
+	require.Equal(t, cryptoocsp.Good, resp.Status)
+}
+
+func TestServerUnknownIssuer(t *testing.T) {
+	st := setupTest(t)
+
+	otherCA := testca.New(t, td)
+	svid := otherCA.CreateX509SVID(workloadID)
+	der, err := cryptoocsp.CreateRequest(svid.Certificates[0], otherCA.X509Authorities()[0], nil)
+	require.NoError(t, err)
+
+	w := st.do(http.MethodPost, "application/ocsp-request", der)
+	require.Equal(t, http.StatusOK, w.Code)
+	_, err = cryptoocsp.ParseResponse(w.Body.Bytes(), nil)
+	require.Equal(t, cryptoocsp.ResponseError{Status: cryptoocsp.Unauthorized}, err)
+}
+
+func TestServerBadRequests(t *testing.T) {
+	st := setupTest(t)
+
+	w := st.do(http.MethodPost, "application/ocsp-request", []byte("not a request"))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, cryptoocsp.MalformedRequestErrorResponse, w.Body.Bytes())
+
+	w = st.do(http.MethodPost, "application/json", []byte("{}"))
+	require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = st.do(http.MethodPut, "application/ocsp-request", nil)
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = st.do(http.MethodPost, "application/ocsp-request", make([]byte, maxRequestSize+1))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+type serverTest struct {
+	clk      *clock.Mock
+	serverCA *fakeserverca.CA
+	ds       *countingDataStore
+	server   *Server
+}
+
+func setupTest(t *testing.T) *serverTest {
+	clk := clock.NewMock(t)
+	log, _ := test.NewNullLogger()
+	ds := &countingDataStore{DataStore: fakedatastore.New(t)}
+	serverCA := fakeserverca.New(t, td, &fakeserverca.Options{Clock: clk})
+
+	return &serverTest{
+		clk:      clk,
+		serverCA: serverCA,
+		ds:       ds,
+		server: NewServer(ServerConfig{
+			Log:         log,
+			TrustDomain: td,
+			DataStore:   ds,
+			X509CAs:     serverCA,
+			CacheTTL:    time.Minute,
+			Clock:       clk,
+		}),
+	}
+}
+
+func (s *serverTest) issuer() *x509.Certificate {
+	return s.serverCA.X509CA().Certificate
+}
+
+func (s *serverTest) signSVID(t *testing.T) *x509.Certificate {
+	svid, err := s.serverCA.SignX509SVID(context.Background(), ca.X509SVIDParams{
+		SpiffeID:  workloadID,
+		PublicKey: testkey.NewEC256(t).Public(),
+	})
+	require.NoError(t, err)
+	return svid[0]
+}
+
+// recordIssuance records the issuance of the SVID, as the server CA does
+// when issuance records are enabled.
+func (s *serverTest) recordIssuance(t *testing.T, svid *x509.Certificate, entryID string) {
+	require.NoError(t, s.ds.CreateIssuanceRecord(context.Background(), &datastore.IssuanceRecord{
+		SerialNumber: svid.SerialNumber.String(),
+		SpiffeID:     workloadID.String(),
+		EntryID:      entryID,
+		ExpiresAt:    svid.NotAfter,
+	}))
+}
+
+func (s *serverTest) post(t *testing.T, svid *x509.Certificate) *cryptoocsp.Response {
+	der, err := cryptoocsp.CreateRequest(svid, s.issuer(), nil)
+	require.NoError(t, err)
+
+	w := s.do(http.MethodPost, "application/ocsp-request", der)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	resp, err := cryptoocsp.ParseResponseForCert(w.Body.Bytes(), svid, s.issuer())
+	require.NoError(t, err)
+	return resp
+}
+
+func (s *serverTest) do(method, contentType string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	s.server.ServeHTTP(w, req)
+	return w
+}
+
+type countingDataStore struct {
+	datastore.DataStore
+	lookups int
+}
+
+func (ds *countingDataStore) ListIssuanceRecords(ctx context.Context, req *datastore.ListIssuanceRecordsRequest) (*datastore.ListIssuanceRecordsResponse, error) {
+	ds.lookups++
+	return ds.DataStore.ListIssuanceRecords(ctx, req)
+}
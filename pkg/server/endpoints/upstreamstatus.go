@@ -0,0 +1,53 @@
+package endpoints
+
+import (
+	"crypto/x509"
+
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+	"github.com/spiffe/spire/pkg/server/ca"
+)
+
+// upstreamAuthorityStatus reports the upstream authority status tracked by
+// the CA manager through the admin API.
+type upstreamAuthorityStatus struct {
+	source ca.UpstreamStatusSource
+}
+
+func (s upstreamAuthorityStatus) UpstreamAuthorityStatus() (*adminv1.UpstreamAuthorityStatus, bool) {
+	status, ok := s.source.UpstreamStatus()
+	if !ok {
+		return nil, false
+	}
+
+	resp := &adminv1.UpstreamAuthorityStatus{
+		PluginName:    status.PluginName,
+		UpstreamChain: make([]*adminv1.Certificate, 0, len(status.UpstreamChain)),
+		RecentErrors:  make([]*adminv1.UpstreamAuthorityError, 0, len(status.RecentErrors)),
+	}
+	if status.X509CA != nil {
+		resp.X509CA = certificateStatus(status.X509CA)
+	}
+	for _, cert := range status.UpstreamChain {
+		resp.UpstreamChain = append(resp.UpstreamChain, certificateStatus(cert))
+	}
+	if !status.LastMint.IsZero() {
+		lastMint := status.LastMint.UTC()
+		resp.LastMint = &lastMint
+	}
+	for _, upstreamErr := range status.RecentErrors {
+		resp.RecentErrors = append(resp.RecentErrors, &adminv1.UpstreamAuthorityError{
+			Time:    upstreamErr.Time.UTC(),
+			Message: upstreamErr.Message,
+		})
+	}
+	return resp, true
+}
+
+func certificateStatus(cert *x509.Certificate) *adminv1.Certificate {
+	return &adminv1.Certificate{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		NotBefore: cert.NotBefore.UTC(),
+		NotAfter:  cert.NotAfter.UTC(),
+	}
+}
@@ -0,0 +1,93 @@
+package endpoints
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	adminv1 "github.com/spiffe/spire/pkg/server/api/admin/v1"
+	"github.com/spiffe/spire/pkg/server/ca"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpstreamAuthorityStatus(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	x509CA := &x509.Certificate{
+		Subject:   pkix.Name{Organization: []string{"SPIRE"}},
+		Issuer:    pkix.Name{CommonName: "upstream"},
+		NotBefore: now,
+		NotAfter:  now.Add(time.Hour),
+	}
+	root := &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "upstream"},
+		Issuer:    pkix.Name{CommonName: "upstream"},
+		NotBefore: now,
+		NotAfter:  now.Add(24 * time.Hour),
+	}
+
+	for _, tt := range []struct {
+		name         string
+		status       ca.UpstreamStatus
+		configured   bool
+		expectStatus *adminv1.UpstreamAuthorityStatus
+	}{
+		{
+			name: "not configured",
+		},
+		{
+			name: "no X509 CA minted yet",
+			status: ca.UpstreamStatus{
+				PluginName:   "disk",
+				RecentErrors: []ca.UpstreamError{{Time: now, Message: "oh no"}},
+			},
+			configured: true,
+			expectStatus: &adminv1.UpstreamAuthorityStatus{
+				PluginName:    "disk",
+				UpstreamChain: []*adminv1.Certificate{},
+				RecentErrors:  []*adminv1.UpstreamAuthorityError{{Time: now, Message: "oh no"}},
+			},
+		},
+		{
+			name: "X509 CA minted",
+			status: ca.UpstreamStatus{
+				PluginName:    "disk",
+				X509CA:        x509CA,
+				UpstreamChain: []*x509.Certificate{x509CA, root},
+				LastMint:      now,
+			},
+			configured: true,
+			expectStatus: &adminv1.UpstreamAuthorityStatus{
+				PluginName: "disk",
+				X509CA:     &adminv1.Certificate{Subject: "O=SPIRE", Issuer: "CN=upstream", NotBefore: now, NotAfter: now.Add(time.Hour)},
+				UpstreamChain: []*adminv1.Certificate{
+					{Subject: "O=SPIRE", Issuer: "CN=upstream", NotBefore: now, NotAfter: now.Add(time.Hour)},
+					{Subject: "CN=upstream", Issuer: "CN=upstream", NotBefore: now, NotAfter: now.Add(24 * time.Hour)},
+				},
+				LastMint:     &now,
+				RecentErrors: []*adminv1.UpstreamAuthorityError{},
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			source := upstreamAuthorityStatus{
+				source: fakeUpstreamStatusSource{status: tt.status, configured: tt.configured},
+			}
+
+			status, ok := source.UpstreamAuthorityStatus()
+			require.Equal(t, tt.configured, ok)
+			assert.Equal(t, tt.expectStatus, status)
+		})
+	}
+}
+
+type fakeUpstreamStatusSource struct {
+	status     ca.UpstreamStatus
+	configured bool
+}
+
+func (s fakeUpstreamStatusSource) UpstreamStatus() (ca.UpstreamStatus, bool) {
+	return s.status, s.configured
+}
@@ -0,0 +1,274 @@
+// Package entryadmission implements the admission webhook the entry API
+// invokes before registration entries are created or updated. The webhook
+// can reject entries that do not conform to an organization policy, or
+// mutate them, e.g. to add default values.
+package entryadmission
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// DefaultTimeout is the default time a review request can take.
+	DefaultTimeout = 5 * time.Second
+
+	// maxResponseSize bounds the size of the review response read
+	maxResponseSize = 1 << 20
+)
+
+// Operation is the entry API operation an entry is reviewed for.
+type Operation string
+
+const (
+	Create Operation = "CREATE"
+	Update Operation = "UPDATE"
+)
+
+// Config configures the admission webhook.
+type Config struct {
+	// URL is the HTTPS URL the entries are posted to for review.
+	URL string
+
+	// RootCAs, if set, are used to verify the certificate of the webhook
+	// instead of the system roots.
+	RootCAs *x509.CertPool
+
+	// Timeout bounds each review request. Defaults to DefaultTimeout.
+	Timeout time.Duration
+
+	// FailOpen, when true, admits entries unchanged when the webhook cannot
+	// be reached or fails to review them. By default, the entries are not
+	// admitted.
+	FailOpen bool
+}
+
+// Violation describes why a field of an entry does not conform to policy.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Rejection is returned when the webhook rejects an entry.
+type Rejection struct {
+	Reason     string
+	Violations []Violation
+}
+
+func (r *Rejection) Error() string {
+	reason := r.Reason
+	if reason == "" {
+		reason = "entry rejected"
+	}
+	if len(r.Violations) == 0 {
+		return reason
+	}
+
+	violations := make([]string, 0, len(r.Violations))
+	for _, violation := range r.Violations {
+		violations = append(violations, fmt.Sprintf("%s: %s", violation.Field, violation.Message))
+	}
+	return fmt.Sprintf("%s (%s)", reason, strings.Join(violations, "; "))
+}
+
+// Webhook reviews entries by posting them to an admission webhook.
+type Webhook struct {
+	c      Config
+	log    logrus.FieldLogger
+	client *http.Client
+}
+
+// New returns a new admission webhook.
+func New(config Config, log logrus.FieldLogger) *Webhook {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	return &Webhook{
+		c:   config,
+		log: log,
+		client: &http.Client{
+			Timeout: config.Timeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					RootCAs:    config.RootCAs,
+					MinVersion: tls.VersionTLS12,
+				},
+			},
+		},
+	}
+}
+
+type reviewRequest struct {
+	Operation Operation       `json:"operation"`
+	Entry     json.RawMessage `json:"entry"`
+	InputMask json.RawMessage `json:"input_mask,omitempty"`
+}
+
+type reviewResponse struct {
+	Allowed    bool            `json:"allowed"`
+	Reason     string          `json:"reason"`
+	Violations []Violation     `json:"violations"`
+	Entry      json.RawMessage `json:"entry"`
+}
+
+// Review posts the entry to the webhook for review. It returns the entry to
+// use, which is the entry mutated by the webhook, if it did, or a *Rejection
+// if the webhook rejected the entry. For updates, the entry is the stored
+// entry with the updated fields applied, so policies spanning several fields
+// are checked against the entry as it will be stored, and the input mask is
+// posted alongside it. Mutations of fields outside of the input mask are
+// rejected, since they would not be applied.
+func (w *Webhook) Review(ctx context.Context, op Operation, entry *types.Entry, inputMask *types.EntryMask) (*types.Entry, error) {
+	reviewed, err := w.review(ctx, op, entry, inputMask)
+	var rejection *Rejection
+	switch {
+	case err == nil:
+		return reviewed, nil
+	case errors.As(err, &rejection):
+		return nil, err
+	case w.c.FailOpen:
+		w.log.WithError(err).Warn("Admission webhook failed; admitting entry unchanged")
+		return entry, nil
+	default:
+		return nil, err
+	}
+}
+
+func (w *Webhook) review(ctx context.Context, op Operation, entry *types.Entry, inputMask *types.EntryMask) (*types.Entry, error) {
+	marshaler := protojson.MarshalOptions{UseProtoNames: true}
+
+	req := reviewRequest{Operation: op}
+	var err error
+	req.Entry, err = marshaler.Marshal(entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal entry: %w", err)
+	}
+	if inputMask != nil {
+		req.InputMask, err = marshaler.Marshal(inputMask)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal input mask: %w", err)
+		}
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal review request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, w.c.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := w.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post review request: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected admission webhook response status %d", httpResp.StatusCode)
+	}
+
+	var resp reviewResponse
+	if err := json.NewDecoder(io.LimitReader(httpResp.Body, maxResponseSize)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode review response: %w", err)
+	}
+
+	if !resp.Allowed {
+		return nil, &Rejection{
+			Reason:     resp.Reason,
+			Violations: resp.Violations,
+		}
+	}
+
+	if len(resp.Entry) == 0 || string(resp.Entry) == "null" {
+		return entry, nil
+	}
+
+	mutated := new(types.Entry)
+	if err := protojson.Unmarshal(resp.Entry, mutated); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal mutated entry: %w", err)
+	}
+	if op == Update && mutated.Id != entry.Id {
+		return nil, errors.New("admission webhook must not change the ID of the entry")
+	}
+	if op == Update && inputMask != nil {
+		if fields := fieldsChangedOutsideMask(entry, mutated, inputMask); len(fields) > 0 {
+			violations := make([]Violation, 0, len(fields))
+			for _, field := range fields {
+				violations = append(violations, Violation{Field: field, Message: "not in the input mask"})
+			}
+			return nil, &Rejection{
+				Reason:     "admission webhook mutated fields outside of the input mask",
+				Violations: violations,
+			}
+		}
+	}
+	if proto.Equal(mutated, entry) {
+		return entry, nil
+	}
+	w.log.Debug("Entry mutated by admission webhook")
+	return mutated, nil
+}
+
+// fieldsChangedOutsideMask returns the names of the fields that differ
+// between the entries and are not in the mask.
+func fieldsChangedOutsideMask(before, after *types.Entry, mask *types.EntryMask) []string {
+	var fields []string
+	check := func(inMask bool, field string, equal bool) {
+		if !inMask && !equal {
+			fields = append(fields, field)
+		}
+	}
+	check(mask.SpiffeId, "spiffe_id", proto.Equal(before.SpiffeId, after.SpiffeId))
+	check(mask.ParentId, "parent_id", proto.Equal(before.ParentId, after.ParentId))
+	check(mask.Selectors, "selectors", selectorsEqual(before.Selectors, after.Selectors))
+	check(mask.Ttl, "ttl", before.Ttl == after.Ttl)
+	check(mask.FederatesWith, "federates_with", stringsEqual(before.FederatesWith, after.FederatesWith))
+	check(mask.Admin, "admin", before.Admin == after.Admin)
+	check(mask.Downstream, "downstream", before.Downstream == after.Downstream)
+	check(mask.ExpiresAt, "expires_at", before.ExpiresAt == after.ExpiresAt)
+	check(mask.DnsNames, "dns_names", stringsEqual(before.DnsNames, after.DnsNames))
+	check(mask.RevisionNumber, "revision_number", before.RevisionNumber == after.RevisionNumber)
+	check(mask.StoreSvid, "store_svid", before.StoreSvid == after.StoreSvid)
+	return fields
+}
+
+func selectorsEqual(a, b []*types.Selector) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !proto.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
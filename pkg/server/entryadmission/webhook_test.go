@@ -0,0 +1,157 @@
+package entryadmission
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/test/spiretest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestReview(t *testing.T) {
+	entry := &types.Entry{
+		Id:       "ENTRYID",
+		SpiffeId: &types.SPIFFEID{TrustDomain: "example.org", Path: "/workload"},
+		ParentId: &types.SPIFFEID{TrustDomain: "example.org", Path: "/agent"},
+		Selectors: []*types.Selector{
+			{Type: "unix", Value: "uid:1000"},
+		},
+	}
+	mutated := proto.Clone(entry).(*types.Entry)
+	mutated.Ttl = 3600
+
+	for _, tt := range []struct {
+		name        string
+		op          Operation
+		inputMask   *types.EntryMask
+		failOpen    bool
+		response    string
+		statusCode  int
+		expectEntry *types.Entry
+		expectErr   string
+		expectLogs  []spiretest.LogEntry
+	}{
+		{
+			name:        "allowed",
+			op:          Create,
+			response:    `{"allowed": true}`,
+			expectEntry: entry,
+		},
+		{
+			name:        "mutated",
+			op:          Create,
+			response:    `{"allowed": true, "entry": {"id": "ENTRYID", "spiffe_id": {"trust_domain": "example.org", "path": "/workload"}, "parent_id": {"trust_domain": "example.org", "path": "/agent"}, "selectors": [{"type": "unix", "value": "uid:1000"}], "ttl": 3600}}`,
+			expectEntry: mutated,
+		},
+		{
+			name:      "ID changed on update",
+			op:        Update,
+			inputMask: &types.EntryMask{Selectors: true},
+			response:  `{"allowed": true, "entry": {"id": "OTHER"}}`,
+			expectErr: "admission webhook must not change the ID of the entry",
+		},
+		{
+			name:        "mutated on update within the input mask",
+			op:          Update,
+			inputMask:   &types.EntryMask{Ttl: true},
+			response:    `{"allowed": true, "entry": {"id": "ENTRYID", "spiffe_id": {"trust_domain": "example.org", "path": "/workload"}, "parent_id": {"trust_domain": "example.org", "path": "/agent"}, "selectors": [{"type": "unix", "value": "uid:1000"}], "ttl": 3600}}`,
+			expectEntry: mutated,
+		},
+		{
+			name:      "mutated on update outside of the input mask",
+			op:        Update,
+			inputMask: &types.EntryMask{Selectors: true},
+			response:  `{"allowed": true, "entry": {"id": "ENTRYID", "spiffe_id": {"trust_domain": "example.org", "path": "/other"}, "parent_id": {"trust_domain": "example.org", "path": "/agent"}, "selectors": [{"type": "unix", "value": "uid:1000"}], "ttl": 3600}}`,
+			expectErr: "admission webhook mutated fields outside of the input mask (spiffe_id: not in the input mask; ttl: not in the input mask)",
+		},
+		{
+			name:      "rejected",
+			op:        Create,
+			response:  `{"allowed": false, "reason": "policy violated", "violations": [{"field": "spiffe_id", "message": "must be under /ns/"}, {"field": "selectors", "message": "unix:uid:0 is forbidden"}]}`,
+			expectErr: "policy violated (spiffe_id: must be under /ns/; selectors: unix:uid:0 is forbidden)",
+		},
+		{
+			name:      "rejected without reason",
+			op:        Create,
+			response:  `{"allowed": false}`,
+			expectErr: "entry rejected",
+		},
+		{
+			name:       "webhook failure",
+			op:         Create,
+			statusCode: http.StatusInternalServerError,
+			expectErr:  "unexpected admission webhook response status 500",
+		},
+		{
+			name:        "webhook failure with fail open",
+			op:          Create,
+			failOpen:    true,
+			statusCode:  http.StatusInternalServerError,
+			expectEntry: entry,
+			expectLogs: []spiretest.LogEntry{
+				{
+					Level:   logrus.WarnLevel,
+					Message: "Admission webhook failed; admitting entry unchanged",
+					Data: logrus.Fields{
+						logrus.ErrorKey: "unexpected admission webhook response status 500",
+					},
+				},
+			},
+		},
+		{
+			name:      "rejected with fail open",
+			op:        Create,
+			failOpen:  true,
+			response:  `{"allowed": false, "reason": "policy violated"}`,
+			expectErr: "policy violated",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var gotReq reviewRequest
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&gotReq); err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				if tt.statusCode != 0 {
+					w.WriteHeader(tt.statusCode)
+					return
+				}
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			rootCAs := x509.NewCertPool()
+			rootCAs.AddCert(server.Certificate())
+
+			log, hook := test.NewNullLogger()
+			webhook := New(Config{
+				URL:      server.URL,
+				RootCAs:  rootCAs,
+				FailOpen: tt.failOpen,
+			}, log)
+
+			reviewed, err := webhook.Review(context.Background(), tt.op, entry, tt.inputMask)
+			spiretest.AssertLogs(t, hook.AllEntries(), tt.expectLogs)
+			require.Equal(t, tt.op, gotReq.Operation)
+			require.NotEmpty(t, gotReq.Entry)
+			require.Equal(t, tt.inputMask != nil, len(gotReq.InputMask) > 0)
+			if tt.expectErr != "" {
+				require.EqualError(t, err, tt.expectErr)
+				require.Nil(t, reviewed)
+				return
+			}
+			require.NoError(t, err)
+			spiretest.AssertProtoEqual(t, tt.expectEntry, reviewed)
+		})
+	}
+}
@@ -0,0 +1,585 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andres-erbsen/clock"
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+	entryv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/pkg/common/idutil"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	serverTelemetry "github.com/spiffe/spire/pkg/common/telemetry/server"
+	"github.com/spiffe/spire/pkg/common/util"
+	"github.com/spiffe/spire/pkg/server/api"
+	"github.com/spiffe/spire/pkg/server/datastore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// defaultEntryMirrorInterval is how often entries are mirrored when no
+	// interval is configured.
+	defaultEntryMirrorInterval = time.Minute
+
+	// entryMirrorPageSize is the number of local entries listed at once.
+	entryMirrorPageSize = 500
+
+	// entryMirrorBatchSize is the number of entries sent to the peer server
+	// in a single batch request.
+	entryMirrorBatchSize = 50
+
+	// entryMirrorSPIFFEIDSelectorType is the type of the selectors whose
+	// value is a SPIFFE ID.
+	entryMirrorSPIFFEIDSelectorType = "spiffe_id"
+)
+
+// entryMirrorUpdateMask is the mask of the fields kept in sync on the
+// mirrored entries. Admin and downstream entries are never mirrored.
+var entryMirrorUpdateMask = &types.EntryMask{
+	SpiffeId:      true,
+	ParentId:      true,
+	Selectors:     true,
+	Ttl:           true,
+	FederatesWith: true,
+	ExpiresAt:     true,
+	DnsNames:      true,
+	StoreSvid:     true,
+}
+
+// EntryMirrorConfig configures the mirroring of registration entries to the
+// server of a peer trust domain, e.g. to run active-active trust domains
+// with mostly identical workloads.
+type EntryMirrorConfig struct {
+	// PeerTrustDomain is the trust domain entries are mirrored to. Mirroring
+	// is disabled if zero.
+	PeerTrustDomain spiffeid.TrustDomain
+
+	// PeerAddress is the address of the server API of the peer server.
+	PeerAddress string
+
+	// WorkloadAPIAddr is the address of the Workload API of an agent of the
+	// peer trust domain, providing the X509-SVID used to authenticate to the
+	// peer server.
+	WorkloadAPIAddr net.Addr
+
+	// TagSelectors selects the entries to mirror by tag. Registration
+	// entries have no metadata, so entries are tagged with a selector, e.g.
+	// a label of the workload, and are mirrored when they have one of these
+	// selectors.
+	TagSelectors []*types.Selector
+
+	// PathPrefixes selects the entries to mirror by the path of their SPIFFE
+	// ID.
+	PathPrefixes []string
+
+	// PathRewrites maps path prefixes of the mirrored SPIFFE IDs and parent
+	// IDs to the prefixes they are replaced with. The longest matching
+	// prefix is rewritten.
+	PathRewrites map[string]string
+
+	// Interval is how often entries are mirrored.
+	Interval time.Duration
+}
+
+// pendingEntry is an entry to create or update on the peer server.
+type pendingEntry struct {
+	localID        string
+	revisionNumber int64
+	mirror         *types.Entry
+}
+
+// entryMirror periodically mirrors the selected entries to the peer server
+// using its entry API, rewriting their SPIFFE IDs and parent IDs into the
+// peer trust domain. The server authenticates to the peer server with an
+// X509-SVID of the peer trust domain obtained from the Workload API of a
+// peer agent, the same way the spire upstream authority does, so the server
+// must be registered as an admin workload in the peer trust domain.
+//
+// Entries that were mirrored are updated when they change, and deleted from
+// the peer when they are deleted or no longer selected. The peer entries
+// are recorded in the datastore, so entries deleted while the server is
+// down are deleted from the peer once it is back, and the servers sharing
+// the datastore keep the same peer entries in sync.
+type entryMirror struct {
+	c       EntryMirrorConfig
+	log     logrus.FieldLogger
+	metrics telemetry.Metrics
+	ds      datastore.DataStore
+	td      spiffeid.TrustDomain
+	clock   clock.Clock
+
+	// mirrored maps the local entry IDs to the peer entries they were
+	// mirrored to. It is loaded from the datastore on every run.
+	mirrored map[string]*datastore.MirroredEntry
+}
+
+func newEntryMirror(c EntryMirrorConfig, log logrus.FieldLogger, metrics telemetry.Metrics, ds datastore.DataStore, td spiffeid.TrustDomain, clk clock.Clock) *entryMirror {
+	if c.Interval <= 0 {
+		c.Interval = defaultEntryMirrorInterval
+	}
+	return &entryMirror{
+		c:       c,
+		log:     log,
+		metrics: metrics,
+		ds:      ds,
+		td:      td,
+		clock:   clk,
+	}
+}
+
+// Run dials the peer server and mirrors the entries until the context is
+// canceled.
+func (m *entryMirror) Run(ctx context.Context) error {
+	peerServerID, err := idutil.ServerID(m.c.PeerTrustDomain)
+	if err != nil {
+		return err
+	}
+	clientOption, err := util.GetWorkloadAPIClientOption(m.c.WorkloadAPIAddr)
+	if err != nil {
+		return fmt.Errorf("could not get Workload API client options: %w", err)
+	}
+	source, err := workloadapi.NewX509Source(ctx, workloadapi.WithClientOptions(clientOption))
+	if err != nil {
+		return fmt.Errorf("unable to create X509Source: %w", err)
+	}
+	defer source.Close()
+
+	tlsConfig := tlsconfig.MTLSClientConfig(source, source, tlsconfig.AuthorizeID(peerServerID))
+	conn, err := grpc.DialContext(ctx, m.c.PeerAddress, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	if err != nil {
+		return fmt.Errorf("failed to dial peer server %s: %w", m.c.PeerAddress, err)
+	}
+	defer conn.Close()
+
+	return m.run(ctx, entryv1.NewEntryClient(conn))
+}
+
+func (m *entryMirror) run(ctx context.Context, client entryv1.EntryClient) error {
+	ticker := m.clock.Ticker(m.c.Interval)
+	defer ticker.Stop()
+
+	for {
+		// Log an error on failure unless we're shutting down
+		if err := m.mirror(ctx, client); err != nil && ctx.Err() == nil {
+			m.log.WithError(err).Error("Failed to mirror entries")
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (m *entryMirror) mirror(ctx context.Context, client entryv1.EntryClient) error {
+	if err := m.loadMirrored(ctx); err != nil {
+		return err
+	}
+	entries, err := m.listEntries(ctx)
+	if err != nil {
+		return err
+	}
+
+	var toCreate, toUpdate []pendingEntry
+	selected := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		mirror, ok := m.mirrorEntry(entry)
+		if !ok {
+			continue
+		}
+		selected[entry.Id] = true
+
+		pending := pendingEntry{
+			localID:        entry.Id,
+			revisionNumber: entry.RevisionNumber,
+			mirror:         mirror,
+		}
+		mirrored, ok := m.mirrored[entry.Id]
+		switch {
+		case !ok:
+			toCreate = append(toCreate, pending)
+		case mirrored.RevisionNumber != entry.RevisionNumber:
+			mirror.Id = mirrored.PeerEntryID
+			if proto.Equal(mirror, mirrored.Mirror) {
+				// The change does not affect the mirrored entry, e.g.
+				// because the peer server mirrored the entry back. Not
+				// updating it keeps both servers from updating each
+				// other's entries endlessly.
+				if err := m.setMirrored(ctx, pending); err != nil {
+					return err
+				}
+				continue
+			}
+			toUpdate = append(toUpdate, pending)
+		}
+	}
+
+	var toDelete []string
+	for id := range m.mirrored {
+		if !selected[id] {
+			toDelete = append(toDelete, id)
+		}
+	}
+	sort.Strings(toDelete)
+
+	// Entries that already exist on the peer server but differ are updated
+	differing, err := m.createEntries(ctx, client, toCreate)
+	if err != nil {
+		return err
+	}
+	if err := m.updateEntries(ctx, client, append(toUpdate, differing...)); err != nil {
+		return err
+	}
+	return m.deleteEntries(ctx, client, toDelete)
+}
+
+// loadMirrored loads the peer entries the local entries were mirrored to.
+func (m *entryMirror) loadMirrored(ctx context.Context) error {
+	entries, err := m.ds.ListMirroredEntries(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list mirrored entries: %w", err)
+	}
+	m.mirrored = make(map[string]*datastore.MirroredEntry, len(entries))
+	for _, entry := range entries {
+		m.mirrored[entry.EntryID] = entry
+	}
+	return nil
+}
+
+// setMirrored records that the pending entry was mirrored to the peer entry
+// it holds the ID of.
+func (m *entryMirror) setMirrored(ctx context.Context, p pendingEntry) error {
+	mirrored := &datastore.MirroredEntry{
+		EntryID:        p.localID,
+		PeerEntryID:    p.mirror.Id,
+		RevisionNumber: p.revisionNumber,
+		Mirror:         p.mirror,
+	}
+	if err := m.ds.SetMirroredEntry(ctx, mirrored); err != nil {
+		return fmt.Errorf("failed to record mirrored entry: %w", err)
+	}
+	m.mirrored[p.localID] = mirrored
+	return nil
+}
+
+// deleteMirrored deletes the record of the peer entry the local entry was
+// mirrored to.
+func (m *entryMirror) deleteMirrored(ctx context.Context, localID string) error {
+	if err := m.ds.DeleteMirroredEntry(ctx, localID); err != nil {
+		return fmt.Errorf("failed to delete mirrored entry record: %w", err)
+	}
+	delete(m.mirrored, localID)
+	return nil
+}
+
+// listEntries lists the local entries.
+func (m *entryMirror) listEntries(ctx context.Context) ([]*types.Entry, error) {
+	req := &datastore.ListRegistrationEntriesRequest{
+		DataConsistency: datastore.TolerateStale,
+		Pagination: &datastore.Pagination{
+			PageSize: entryMirrorPageSize,
+		},
+	}
+
+	var entries []*types.Entry
+	for {
+		resp, err := m.ds.ListRegistrationEntries(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list entries: %w", err)
+		}
+		for _, regEntry := range resp.Entries {
+			entry, err := api.RegistrationEntryToProto(regEntry)
+			if err != nil {
+				m.log.WithError(err).WithField(telemetry.RegistrationID, regEntry.EntryId).Warn("Failed to convert entry; not mirroring it")
+				continue
+			}
+			entries = append(entries, entry)
+		}
+		if len(resp.Entries) == 0 || resp.Pagination == nil || resp.Pagination.Token == "" {
+			return entries, nil
+		}
+		req.Pagination.Token = resp.Pagination.Token
+	}
+}
+
+// mirrorEntry returns the entry to create on the peer server for the given
+// entry, or false if the entry is not mirrored.
+func (m *entryMirror) mirrorEntry(entry *types.Entry) (*types.Entry, bool) {
+	if entry.Admin || entry.Downstream || !m.isSelected(entry) {
+		return nil, false
+	}
+
+	var federatesWith []string
+	for _, td := range entry.FederatesWith {
+		// Entries federating with the peer trust domain federate with this
+		// trust domain once mirrored.
+		if td == m.c.PeerTrustDomain.String() {
+			td = m.td.String()
+		}
+		federatesWith = append(federatesWith, td)
+	}
+
+	return &types.Entry{
+		SpiffeId: &types.SPIFFEID{
+			TrustDomain: m.c.PeerTrustDomain.String(),
+			Path:        m.rewritePath(entry.SpiffeId.Path),
+		},
+		ParentId: &types.SPIFFEID{
+			TrustDomain: m.c.PeerTrustDomain.String(),
+			Path:        m.rewritePath(entry.ParentId.Path),
+		},
+		Selectors:     m.mirrorSelectors(entry.Selectors),
+		Ttl:           entry.Ttl,
+		FederatesWith: federatesWith,
+		ExpiresAt:     entry.ExpiresAt,
+		DnsNames:      entry.DnsNames,
+		StoreSvid:     entry.StoreSvid,
+	}, true
+}
+
+func (m *entryMirror) isSelected(entry *types.Entry) bool {
+	for _, tag := range m.c.TagSelectors {
+		for _, selector := range entry.Selectors {
+			if selector.Type == tag.Type && selector.Value == tag.Value {
+				return true
+			}
+		}
+	}
+	for _, prefix := range m.c.PathPrefixes {
+		if strings.HasPrefix(entry.SpiffeId.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// mirrorSelectors returns the selectors of the mirrored entry. The SPIFFE
+// IDs of spiffe_id selectors in this trust domain are moved to the peer
+// trust domain and rewritten like the SPIFFE IDs of the entries.
+func (m *entryMirror) mirrorSelectors(selectors []*types.Selector) []*types.Selector {
+	mirrored := make([]*types.Selector, 0, len(selectors))
+	for _, selector := range selectors {
+		if selector.Type == entryMirrorSPIFFEIDSelectorType {
+			if id, err := spiffeid.FromString(selector.Value); err == nil && id.TrustDomain() == m.td {
+				selector = &types.Selector{
+					Type:  selector.Type,
+					Value: m.c.PeerTrustDomain.IDString() + m.rewritePath(id.Path()),
+				}
+			}
+		}
+		mirrored = append(mirrored, selector)
+	}
+	return mirrored
+}
+
+func (m *entryMirror) rewritePath(path string) string {
+	var from string
+	for prefix := range m.c.PathRewrites {
+		if strings.HasPrefix(path, prefix) && len(prefix) > len(from) {
+			from = prefix
+		}
+	}
+	if from == "" {
+		return path
+	}
+	return m.c.PathRewrites[from] + strings.TrimPrefix(path, from)
+}
+
+// createEntries creates the entries on the peer server, returning the
+// entries that already exist there but differ.
+func (m *entryMirror) createEntries(ctx context.Context, client entryv1.EntryClient, pending []pendingEntry) ([]pendingEntry, error) {
+	var differing []pendingEntry
+	created := 0
+	defer func() {
+		if created > 0 {
+			serverTelemetry.IncrEntryMirrorCounter(m.metrics, telemetry.Created, created)
+		}
+	}()
+
+	for _, batch := range pendingBatches(pending) {
+		req := &entryv1.BatchCreateEntryRequest{}
+		for _, p := range batch {
+			req.Entries = append(req.Entries, p.mirror)
+		}
+		resp, err := client.BatchCreateEntry(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create entries on peer server: %w", err)
+		}
+		for i, result := range resp.Results {
+			if i >= len(batch) {
+				break
+			}
+			p := batch[i]
+			switch codes.Code(result.Status.Code) {
+			case codes.OK:
+				created++
+			case codes.AlreadyExists:
+				if entryMirrorDiffers(result.Entry, p.mirror) {
+					p.mirror.Id = result.Entry.Id
+					differing = append(differing, p)
+					continue
+				}
+			default:
+				m.logResult(p.localID, result.Status).Warn("Failed to create mirrored entry on peer server")
+				continue
+			}
+			p.mirror.Id = result.Entry.Id
+			if err := m.setMirrored(ctx, p); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return differing, nil
+}
+
+// updateEntries updates the entries on the peer server. The mirrored
+// entries hold the ID of the peer entry to update.
+func (m *entryMirror) updateEntries(ctx context.Context, client entryv1.EntryClient, pending []pendingEntry) error {
+	updated := 0
+	defer func() {
+		if updated > 0 {
+			serverTelemetry.IncrEntryMirrorCounter(m.metrics, telemetry.Updated, updated)
+		}
+	}()
+
+	for _, batch := range pendingBatches(pending) {
+		req := &entryv1.BatchUpdateEntryRequest{
+			InputMask: entryMirrorUpdateMask,
+		}
+		for _, p := range batch {
+			req.Entries = append(req.Entries, p.mirror)
+		}
+		resp, err := client.BatchUpdateEntry(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to update entries on peer server: %w", err)
+		}
+		for i, result := range resp.Results {
+			if i >= len(batch) {
+				break
+			}
+			p := batch[i]
+			switch codes.Code(result.Status.Code) {
+			case codes.OK:
+				updated++
+				if err := m.setMirrored(ctx, p); err != nil {
+					return err
+				}
+			case codes.NotFound:
+				// The peer entry was deleted; it is created again on the
+				// next run.
+				if err := m.deleteMirrored(ctx, p.localID); err != nil {
+					return err
+				}
+			default:
+				m.logResult(p.localID, result.Status).Warn("Failed to update mirrored entry on peer server")
+			}
+		}
+	}
+	return nil
+}
+
+// deleteEntries deletes the peer entries the given local entries were
+// mirrored to.
+func (m *entryMirror) deleteEntries(ctx context.Context, client entryv1.EntryClient, ids []string) error {
+	deleted := 0
+	defer func() {
+		if deleted > 0 {
+			serverTelemetry.IncrEntryMirrorCounter(m.metrics, telemetry.Deleted, deleted)
+		}
+	}()
+
+	for start := 0; start < len(ids); start += entryMirrorBatchSize {
+		batch := ids[start:]
+		if len(batch) > entryMirrorBatchSize {
+			batch = batch[:entryMirrorBatchSize]
+		}
+		req := &entryv1.BatchDeleteEntryRequest{}
+		for _, id := range batch {
+			req.Ids = append(req.Ids, m.mirrored[id].PeerEntryID)
+		}
+		resp, err := client.BatchDeleteEntry(ctx, req)
+		if err != nil {
+			return fmt.Errorf("failed to delete entries on peer server: %w", err)
+		}
+		for i, result := range resp.Results {
+			if i >= len(batch) {
+				break
+			}
+			id := batch[i]
+			switch codes.Code(result.Status.Code) {
+			case codes.OK:
+				deleted++
+			case codes.NotFound:
+			default:
+				m.logResult(id, result.Status).Warn("Failed to delete mirrored entry on peer server")
+				continue
+			}
+			if err := m.deleteMirrored(ctx, id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pendingBatches splits the pending entries in batches of at most
+// entryMirrorBatchSize entries.
+func pendingBatches(pending []pendingEntry) [][]pendingEntry {
+	var batches [][]pendingEntry
+	for len(pending) > entryMirrorBatchSize {
+		batches = append(batches, pending[:entryMirrorBatchSize])
+		pending = pending[entryMirrorBatchSize:]
+	}
+	if len(pending) > 0 {
+		batches = append(batches, pending)
+	}
+	return batches
+}
+
+func (m *entryMirror) logResult(id string, status *types.Status) logrus.FieldLogger {
+	return m.log.WithFields(logrus.Fields{
+		telemetry.RegistrationID: id,
+		telemetry.Status:         codes.Code(status.Code).String(),
+		telemetry.StatusMessage:  status.Message,
+	})
+}
+
+// entryMirrorDiffers returns true if the existing peer entry differs from
+// the mirrored entry in the fields that are not compared to find similar
+// entries.
+func entryMirrorDiffers(existing, mirror *types.Entry) bool {
+	return existing.Ttl != mirror.Ttl ||
+		existing.ExpiresAt != mirror.ExpiresAt ||
+		existing.StoreSvid != mirror.StoreSvid ||
+		!stringsEqual(existing.DnsNames, mirror.DnsNames) ||
+		!stringsEqual(sortedStrings(existing.FederatesWith), sortedStrings(mirror.FederatesWith))
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedStrings(s []string) []string {
+	sorted := append([]string(nil), s...)
+	sort.Strings(sorted)
+	return sorted
+}
@@ -0,0 +1,306 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	entryv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/spiffe/spire/pkg/common/telemetry"
+	"github.com/spiffe/spire/pkg/server/api"
+	"github.com/spiffe/spire/proto/spire/common"
+	"github.com/spiffe/spire/test/clock"
+	"github.com/spiffe/spire/test/fakes/fakedatastore"
+	"github.com/spiffe/spire/test/fakes/fakemetrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEntryMirror(t *testing.T) {
+	ctx := context.Background()
+	log, _ := test.NewNullLogger()
+	metrics := fakemetrics.New()
+	ds := fakedatastore.New(t)
+	peer := newFakePeerEntryClient()
+
+	createEntry := func(entry *common.RegistrationEntry) *common.RegistrationEntry {
+		entry, err := ds.CreateRegistrationEntry(ctx, entry)
+		require.NoError(t, err)
+		return entry
+	}
+
+	// Entries can only federate with trust domains with a bundle
+	_, err := ds.CreateBundle(ctx, &common.Bundle{
+		TrustDomainId: "spiffe://peer.test",
+		RootCas:       []*common.Certificate{{DerBytes: []byte("ROOT")}},
+	})
+	require.NoError(t, err)
+
+	job := createEntry(&common.RegistrationEntry{
+		SpiffeId:      "spiffe://domain.test/batch/east/job",
+		ParentId:      "spiffe://domain.test/spire/agent/node",
+		Selectors:     []*common.Selector{{Type: "unix", Value: "uid:1000"}},
+		Ttl:           60,
+		FederatesWith: []string{"spiffe://peer.test"},
+	})
+	// Not selected
+	createEntry(&common.RegistrationEntry{
+		SpiffeId:  "spiffe://domain.test/api",
+		ParentId:  "spiffe://domain.test/spire/agent/node",
+		Selectors: []*common.Selector{{Type: "unix", Value: "uid:1001"}},
+	})
+	// Selected by tag, with a spiffe_id selector in this trust domain
+	tagged := createEntry(&common.RegistrationEntry{
+		SpiffeId: "spiffe://domain.test/web",
+		ParentId: "spiffe://domain.test/spire/agent/node",
+		Selectors: []*common.Selector{
+			{Type: "k8s", Value: "pod-label:mirror:true"},
+			{Type: "spiffe_id", Value: "spiffe://domain.test/batch/east/node"},
+		},
+	})
+	// Admin entries are never mirrored
+	createEntry(&common.RegistrationEntry{
+		SpiffeId:  "spiffe://domain.test/batch/admin",
+		ParentId:  "spiffe://domain.test/spire/agent/node",
+		Selectors: []*common.Selector{{Type: "unix", Value: "uid:0"}},
+		Admin:     true,
+	})
+
+	config := EntryMirrorConfig{
+		PeerTrustDomain: spiffeid.RequireTrustDomainFromString("peer.test"),
+		PeerAddress:     "spire-server.peer.test:8081",
+		TagSelectors:    []*types.Selector{{Type: "k8s", Value: "pod-label:mirror:true"}},
+		PathPrefixes:    []string{"/batch/"},
+		PathRewrites: map[string]string{
+			"/batch/":      "/jobs/",
+			"/batch/east/": "/jobs/west/",
+		},
+	}
+	mirror := newEntryMirror(config, log, metrics, ds, spiffeid.RequireTrustDomainFromString("domain.test"), clock.NewMock(t))
+
+	// The selected entries are created on the peer, in the peer trust domain
+	require.NoError(t, mirror.mirror(ctx, peer))
+	assertPeerEntries(t, peer, &types.Entry{
+		Id:            "peer-1",
+		SpiffeId:      &types.SPIFFEID{TrustDomain: "peer.test", Path: "/jobs/west/job"},
+		ParentId:      &types.SPIFFEID{TrustDomain: "peer.test", Path: "/spire/agent/node"},
+		Selectors:     []*types.Selector{{Type: "unix", Value: "uid:1000"}},
+		Ttl:           60,
+		FederatesWith: []string{"domain.test"},
+	}, &types.Entry{
+		Id:       "peer-2",
+		SpiffeId: &types.SPIFFEID{TrustDomain: "peer.test", Path: "/web"},
+		ParentId: &types.SPIFFEID{TrustDomain: "peer.test", Path: "/spire/agent/node"},
+		Selectors: []*types.Selector{
+			{Type: "k8s", Value: "pod-label:mirror:true"},
+			{Type: "spiffe_id", Value: "spiffe://peer.test/jobs/west/node"},
+		},
+	})
+	assert.Equal(t, []fakemetrics.MetricItem{entryMirrorCounter(telemetry.Created, 2)}, metrics.AllMetrics())
+
+	// Unchanged entries are not sent again
+	metrics.Reset()
+	peer.calls = 0
+	require.NoError(t, mirror.mirror(ctx, peer))
+	assert.Zero(t, peer.calls)
+	assert.Empty(t, metrics.AllMetrics())
+
+	// Changed entries are updated
+	job.Ttl = 120
+	_, err = ds.UpdateRegistrationEntry(ctx, job, &common.RegistrationEntryMask{Ttl: true})
+	require.NoError(t, err)
+	require.NoError(t, mirror.mirror(ctx, peer))
+	require.Len(t, peer.entries, 2)
+	assert.Equal(t, int32(120), peer.entries["peer-1"].Ttl)
+	assert.Equal(t, []fakemetrics.MetricItem{entryMirrorCounter(telemetry.Updated, 1)}, metrics.AllMetrics())
+
+	// Changes that do not affect the mirrored entry, e.g. when the peer
+	// server mirrors it back, are not sent
+	metrics.Reset()
+	peer.calls = 0
+	_, err = ds.UpdateRegistrationEntry(ctx, job, &common.RegistrationEntryMask{Ttl: true})
+	require.NoError(t, err)
+	require.NoError(t, mirror.mirror(ctx, peer))
+	assert.Zero(t, peer.calls)
+	assert.Empty(t, metrics.AllMetrics())
+
+	// Deleted entries are deleted from the peer
+	_, err = ds.DeleteRegistrationEntry(ctx, job.EntryId)
+	require.NoError(t, err)
+	require.NoError(t, mirror.mirror(ctx, peer))
+	require.Len(t, peer.entries, 1)
+	assert.Equal(t, []fakemetrics.MetricItem{entryMirrorCounter(telemetry.Deleted, 1)}, metrics.AllMetrics())
+
+	// The peer entries are recorded in the datastore, so entries deleted
+	// while the server is down, or by another server sharing the datastore,
+	// are deleted from the peer by a new mirror
+	metrics.Reset()
+	_, err = ds.DeleteRegistrationEntry(ctx, tagged.EntryId)
+	require.NoError(t, err)
+	mirror = newEntryMirror(config, log, metrics, ds, spiffeid.RequireTrustDomainFromString("domain.test"), clock.NewMock(t))
+	require.NoError(t, mirror.mirror(ctx, peer))
+	assertPeerEntries(t, peer)
+	assert.Equal(t, []fakemetrics.MetricItem{entryMirrorCounter(telemetry.Deleted, 1)}, metrics.AllMetrics())
+
+	mirrored, err := ds.ListMirroredEntries(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, mirrored)
+}
+
+func TestEntryMirrorUpdatesExistingEntries(t *testing.T) {
+	ctx := context.Background()
+	log, _ := test.NewNullLogger()
+	metrics := fakemetrics.New()
+	ds := fakedatastore.New(t)
+	peer := newFakePeerEntryClient()
+
+	_, err := ds.CreateRegistrationEntry(ctx, &common.RegistrationEntry{
+		SpiffeId:  "spiffe://domain.test/batch/job",
+		ParentId:  "spiffe://domain.test/spire/agent/node",
+		Selectors: []*common.Selector{{Type: "unix", Value: "uid:1000"}},
+		Ttl:       60,
+	})
+	require.NoError(t, err)
+
+	// A similar entry already exists on the peer, with another TTL
+	existing := &types.Entry{
+		SpiffeId:  &types.SPIFFEID{TrustDomain: "peer.test", Path: "/batch/job"},
+		ParentId:  &types.SPIFFEID{TrustDomain: "peer.test", Path: "/spire/agent/node"},
+		Selectors: []*types.Selector{{Type: "unix", Value: "uid:1000"}},
+		Ttl:       30,
+	}
+	_, err = peer.BatchCreateEntry(ctx, &entryv1.BatchCreateEntryRequest{Entries: []*types.Entry{existing}})
+	require.NoError(t, err)
+
+	mirror := newEntryMirror(EntryMirrorConfig{
+		PeerTrustDomain: spiffeid.RequireTrustDomainFromString("peer.test"),
+		PeerAddress:     "spire-server.peer.test:8081",
+		PathPrefixes:    []string{"/batch/"},
+	}, log, metrics, ds, spiffeid.RequireTrustDomainFromString("domain.test"), clock.NewMock(t))
+
+	require.NoError(t, mirror.mirror(ctx, peer))
+	assertPeerEntries(t, peer, &types.Entry{
+		Id:        "peer-1",
+		SpiffeId:  &types.SPIFFEID{TrustDomain: "peer.test", Path: "/batch/job"},
+		ParentId:  &types.SPIFFEID{TrustDomain: "peer.test", Path: "/spire/agent/node"},
+		Selectors: []*types.Selector{{Type: "unix", Value: "uid:1000"}},
+		Ttl:       60,
+	})
+	assert.Equal(t, []fakemetrics.MetricItem{entryMirrorCounter(telemetry.Updated, 1)}, metrics.AllMetrics())
+}
+
+func assertPeerEntries(t *testing.T, peer *fakePeerEntryClient, expected ...*types.Entry) {
+	require.Len(t, peer.entries, len(expected))
+	for _, entry := range expected {
+		actual, ok := peer.entries[entry.Id]
+		if assert.True(t, ok, "missing peer entry %q", entry.Id) {
+			assert.True(t, proto.Equal(entry, actual), "expected %v, got %v", entry, actual)
+		}
+	}
+}
+
+func entryMirrorCounter(op string, val float32) fakemetrics.MetricItem {
+	return fakemetrics.MetricItem{
+		Type: fakemetrics.IncrCounterType,
+		Key:  []string{telemetry.EntryMirror, op},
+		Val:  val,
+	}
+}
+
+// fakePeerEntryClient is an entry API client keeping the entries of the peer
+// server in memory.
+type fakePeerEntryClient struct {
+	entryv1.EntryClient
+
+	entries map[string]*types.Entry
+	nextID  int
+	calls   int
+}
+
+func newFakePeerEntryClient() *fakePeerEntryClient {
+	return &fakePeerEntryClient{
+		entries: make(map[string]*types.Entry),
+	}
+}
+
+func (c *fakePeerEntryClient) BatchCreateEntry(ctx context.Context, req *entryv1.BatchCreateEntryRequest, opts ...grpc.CallOption) (*entryv1.BatchCreateEntryResponse, error) {
+	c.calls++
+	resp := &entryv1.BatchCreateEntryResponse{}
+	for _, entry := range req.Entries {
+		if existing := c.findSimilar(entry); existing != nil {
+			resp.Results = append(resp.Results, &entryv1.BatchCreateEntryResponse_Result{
+				Status: api.CreateStatus(codes.AlreadyExists, "similar entry already exists"),
+				Entry:  proto.Clone(existing).(*types.Entry),
+			})
+			continue
+		}
+		c.nextID++
+		created := proto.Clone(entry).(*types.Entry)
+		created.Id = fmt.Sprintf("peer-%d", c.nextID)
+		c.entries[created.Id] = created
+		resp.Results = append(resp.Results, &entryv1.BatchCreateEntryResponse_Result{
+			Status: api.OK(),
+			Entry:  proto.Clone(created).(*types.Entry),
+		})
+	}
+	return resp, nil
+}
+
+func (c *fakePeerEntryClient) BatchUpdateEntry(ctx context.Context, req *entryv1.BatchUpdateEntryRequest, opts ...grpc.CallOption) (*entryv1.BatchUpdateEntryResponse, error) {
+	c.calls++
+	resp := &entryv1.BatchUpdateEntryResponse{}
+	for _, entry := range req.Entries {
+		if _, ok := c.entries[entry.Id]; !ok {
+			resp.Results = append(resp.Results, &entryv1.BatchUpdateEntryResponse_Result{
+				Status: api.CreateStatus(codes.NotFound, "entry not found"),
+			})
+			continue
+		}
+		c.entries[entry.Id] = proto.Clone(entry).(*types.Entry)
+		resp.Results = append(resp.Results, &entryv1.BatchUpdateEntryResponse_Result{
+			Status: api.OK(),
+			Entry:  proto.Clone(entry).(*types.Entry),
+		})
+	}
+	return resp, nil
+}
+
+func (c *fakePeerEntryClient) BatchDeleteEntry(ctx context.Context, req *entryv1.BatchDeleteEntryRequest, opts ...grpc.CallOption) (*entryv1.BatchDeleteEntryResponse, error) {
+	c.calls++
+	resp := &entryv1.BatchDeleteEntryResponse{}
+	for _, id := range req.Ids {
+		status := api.OK()
+		if _, ok := c.entries[id]; !ok {
+			status = api.CreateStatus(codes.NotFound, "entry not found")
+		}
+		delete(c.entries, id)
+		resp.Results = append(resp.Results, &entryv1.BatchDeleteEntryResponse_Result{
+			Status: status,
+			Id:     id,
+		})
+	}
+	return resp, nil
+}
+
+func (c *fakePeerEntryClient) findSimilar(entry *types.Entry) *types.Entry {
+	for _, existing := range c.entries {
+		if proto.Equal(existing.SpiffeId, entry.SpiffeId) &&
+			proto.Equal(existing.ParentId, entry.ParentId) &&
+			len(existing.Selectors) == len(entry.Selectors) {
+			similar := true
+			for i := range existing.Selectors {
+				similar = similar && proto.Equal(existing.Selectors[i], entry.Selectors[i])
+			}
+			if similar {
+				return existing
+			}
+		}
+	}
+	return nil
+}
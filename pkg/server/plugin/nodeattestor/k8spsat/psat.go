@@ -4,7 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/hcl"
@@ -13,6 +17,7 @@ import (
 	"github.com/spiffe/spire/pkg/common/catalog"
 	"github.com/spiffe/spire/pkg/common/plugin/k8s"
 	"github.com/spiffe/spire/pkg/common/plugin/k8s/apiserver"
+	"github.com/spiffe/spire/pkg/common/telemetry"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -22,6 +27,14 @@ import (
 
 const (
 	pluginName = "k8s_psat"
+
+	// clustersDirRefreshInterval is how often the clusters in the clusters
+	// directory are reloaded.
+	clustersDirRefreshInterval = 10 * time.Second
+
+	// clusterFileExt is the extension of the cluster configuration files in
+	// the clusters directory.
+	clusterFileExt = ".conf"
 )
 
 var (
@@ -42,6 +55,11 @@ func builtin(p *AttestorPlugin) catalog.BuiltIn {
 // AttestorConfig contains a map of clusters that uses cluster name as key
 type AttestorConfig struct {
 	Clusters map[string]*ClusterConfig `hcl:"clusters"`
+
+	// Directory with additional cluster configuration files, one per cluster,
+	// named after the cluster with a .conf extension. The directory is
+	// periodically reloaded so clusters can be added without restarting.
+	ClustersDir string `hcl:"clusters_dir"`
 }
 
 // ClusterConfig holds a single cluster configuration
@@ -59,6 +77,18 @@ type ClusterConfig struct {
 	// Used to create a k8s client to query the API server. If string is empty, in-cluster configuration is used
 	KubeConfigFile string `hcl:"kube_config_file"`
 
+	// URL of the API server
+	// Used to create a k8s client without a kubeconfig file. Cannot be used with KubeConfigFile
+	APIServerURL string `hcl:"api_server_url"`
+
+	// Path to the CA certificates used to authenticate the API server at APIServerURL
+	// If string is empty, the system roots are used
+	APIServerCAFile string `hcl:"api_server_ca_file"`
+
+	// Path to a bearer token used to authenticate to the API server at APIServerURL
+	// The file is read again as it changes so an external process can refresh the token
+	APIServerTokenFile string `hcl:"api_server_token_file"`
+
 	// Node labels that are allowed to use as selectors
 	AllowedNodeLabelKeys []string `hcl:"allowed_node_label_keys"`
 
@@ -69,6 +99,16 @@ type ClusterConfig struct {
 type attestorConfig struct {
 	trustDomain string
 	clusters    map[string]*clusterConfig
+	clustersDir *clustersDir
+}
+
+// clustersDir holds the clusters loaded from the clusters directory
+type clustersDir struct {
+	path string
+
+	mu       sync.Mutex
+	clusters map[string]*clusterConfig
+	loadedAt time.Time
 }
 
 type clusterConfig struct {
@@ -87,11 +127,19 @@ type AttestorPlugin struct {
 	mu     sync.RWMutex
 	config *attestorConfig
 	log    hclog.Logger
+
+	hooks struct {
+		newClient func(cluster *ClusterConfig) apiserver.Client
+		now       func() time.Time
+	}
 }
 
 // New creates a new PSAT node attestor plugin
 func New() *AttestorPlugin {
-	return &AttestorPlugin{}
+	p := &AttestorPlugin{}
+	p.hooks.newClient = newAPIServerClient
+	p.hooks.now = time.Now
+	return p
 }
 
 var _ nodeattestorv1.NodeAttestorServer = (*AttestorPlugin)(nil)
@@ -130,7 +178,7 @@ func (p *AttestorPlugin) Attest(stream nodeattestorv1.NodeAttestor_AttestServer)
 		return status.Error(codes.InvalidArgument, "missing token in attestation data")
 	}
 
-	cluster := config.clusters[attestationData.Cluster]
+	cluster := p.getCluster(config, attestationData.Cluster)
 	if cluster == nil {
 		return status.Errorf(codes.InvalidArgument, "not configured for cluster %q", attestationData.Cluster)
 	}
@@ -225,7 +273,7 @@ func (p *AttestorPlugin) Configure(ctx context.Context, req *configv1.ConfigureR
 		return nil, status.Error(codes.InvalidArgument, "core configuration missing trust domain")
 	}
 
-	if len(hclConfig.Clusters) == 0 {
+	if len(hclConfig.Clusters) == 0 && hclConfig.ClustersDir == "" {
 		return nil, status.Error(codes.InvalidArgument, "configuration must have at least one cluster")
 	}
 
@@ -235,43 +283,138 @@ func (p *AttestorPlugin) Configure(ctx context.Context, req *configv1.ConfigureR
 	}
 
 	for name, cluster := range hclConfig.Clusters {
-		if len(cluster.ServiceAccountAllowList) == 0 {
-			return nil, status.Errorf(codes.InvalidArgument, "cluster %q configuration must have at least one service account allowed", name)
+		clusterConfig, err := p.buildClusterConfig(name, cluster)
+		if err != nil {
+			return nil, err
 		}
+		config.clusters[name] = clusterConfig
+	}
 
-		serviceAccounts := make(map[string]bool)
-		for _, serviceAccount := range cluster.ServiceAccountAllowList {
-			serviceAccounts[serviceAccount] = true
+	if hclConfig.ClustersDir != "" {
+		clusters, err := p.loadClustersDir(hclConfig.ClustersDir, config.clusters)
+		if err != nil {
+			return nil, err
+		}
+		config.clustersDir = &clustersDir{
+			path:     hclConfig.ClustersDir,
+			clusters: clusters,
+			loadedAt: p.hooks.now(),
 		}
+	}
+
+	p.setConfig(config)
+	return &configv1.ConfigureResponse{}, nil
+}
+
+// getCluster returns the configuration for the given cluster, reloading the
+// clusters directory if it is due for a refresh.
+func (p *AttestorPlugin) getCluster(config *attestorConfig, name string) *clusterConfig {
+	if cluster := config.clusters[name]; cluster != nil {
+		return cluster
+	}
+
+	dir := config.clustersDir
+	if dir == nil {
+		return nil
+	}
 
-		var audience []string
-		if cluster.Audience == nil {
-			audience = defaultAudience
+	dir.mu.Lock()
+	defer dir.mu.Unlock()
+
+	now := p.hooks.now()
+	if now.Sub(dir.loadedAt) >= clustersDirRefreshInterval {
+		clusters, err := p.loadClustersDir(dir.path, config.clusters)
+		if err != nil {
+			// Keep using the clusters that were last loaded successfully
+			p.log.Warn("Unable to reload clusters directory", telemetry.Path, dir.path, telemetry.Error, err)
 		} else {
-			audience = *cluster.Audience
+			dir.clusters = clusters
 		}
+		dir.loadedAt = now
+	}
+
+	return dir.clusters[name]
+}
+
+// loadClustersDir loads the cluster configuration files in the given
+// directory. Clusters cannot be configured both in the directory and in the
+// main configuration.
+func (p *AttestorPlugin) loadClustersDir(path string, configured map[string]*clusterConfig) (map[string]*clusterConfig, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unable to read clusters directory: %v", err)
+	}
 
-		allowedNodeLabelKeys := make(map[string]bool)
-		for _, label := range cluster.AllowedNodeLabelKeys {
-			allowedNodeLabelKeys[label] = true
+	clusters := make(map[string]*clusterConfig)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != clusterFileExt {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), clusterFileExt)
+		if _, ok := configured[name]; ok {
+			return nil, status.Errorf(codes.InvalidArgument, "cluster %q is configured both in the clusters directory and in the main configuration", name)
 		}
 
-		allowedPodLabelKeys := make(map[string]bool)
-		for _, label := range cluster.AllowedPodLabelKeys {
-			allowedPodLabelKeys[label] = true
+		data, err := os.ReadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unable to read cluster %q configuration: %v", name, err)
+		}
+		cluster := new(ClusterConfig)
+		if err := hcl.Decode(cluster, string(data)); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unable to decode cluster %q configuration: %v", name, err)
 		}
 
-		config.clusters[name] = &clusterConfig{
-			serviceAccounts:      serviceAccounts,
-			audience:             audience,
-			client:               apiserver.New(cluster.KubeConfigFile),
-			allowedNodeLabelKeys: allowedNodeLabelKeys,
-			allowedPodLabelKeys:  allowedPodLabelKeys,
+		clusterConfig, err := p.buildClusterConfig(name, cluster)
+		if err != nil {
+			return nil, err
 		}
+		clusters[name] = clusterConfig
 	}
 
-	p.setConfig(config)
-	return &configv1.ConfigureResponse{}, nil
+	return clusters, nil
+}
+
+func (p *AttestorPlugin) buildClusterConfig(name string, cluster *ClusterConfig) (*clusterConfig, error) {
+	if len(cluster.ServiceAccountAllowList) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "cluster %q configuration must have at least one service account allowed", name)
+	}
+
+	switch {
+	case cluster.APIServerURL != "" && cluster.KubeConfigFile != "":
+		return nil, status.Errorf(codes.InvalidArgument, "cluster %q configuration cannot have both kube_config_file and api_server_url", name)
+	case cluster.APIServerURL == "" && (cluster.APIServerCAFile != "" || cluster.APIServerTokenFile != ""):
+		return nil, status.Errorf(codes.InvalidArgument, "cluster %q configuration must have api_server_url to use api_server_ca_file or api_server_token_file", name)
+	}
+
+	serviceAccounts := make(map[string]bool)
+	for _, serviceAccount := range cluster.ServiceAccountAllowList {
+		serviceAccounts[serviceAccount] = true
+	}
+
+	var audience []string
+	if cluster.Audience == nil {
+		audience = defaultAudience
+	} else {
+		audience = *cluster.Audience
+	}
+
+	allowedNodeLabelKeys := make(map[string]bool)
+	for _, label := range cluster.AllowedNodeLabelKeys {
+		allowedNodeLabelKeys[label] = true
+	}
+
+	allowedPodLabelKeys := make(map[string]bool)
+	for _, label := range cluster.AllowedPodLabelKeys {
+		allowedPodLabelKeys[label] = true
+	}
+
+	return &clusterConfig{
+		serviceAccounts:      serviceAccounts,
+		audience:             audience,
+		client:               p.hooks.newClient(cluster),
+		allowedNodeLabelKeys: allowedNodeLabelKeys,
+		allowedPodLabelKeys:  allowedPodLabelKeys,
+	}, nil
 }
 
 func (p *AttestorPlugin) getConfig() (*attestorConfig, error) {
@@ -288,3 +431,14 @@ func (p *AttestorPlugin) setConfig(config *attestorConfig) {
 	defer p.mu.Unlock()
 	p.config = config
 }
+
+func newAPIServerClient(cluster *ClusterConfig) apiserver.Client {
+	if cluster.APIServerURL != "" {
+		return apiserver.NewWithAPIServer(apiserver.APIServerConfig{
+			URL:       cluster.APIServerURL,
+			CAFile:    cluster.APIServerCAFile,
+			TokenFile: cluster.APIServerTokenFile,
+		})
+	}
+	return apiserver.New(cluster.KubeConfigFile)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/spiffe/spire/pkg/common/catalog"
 	"github.com/spiffe/spire/pkg/common/pemutil"
 	sat_common "github.com/spiffe/spire/pkg/common/plugin/k8s"
+	"github.com/spiffe/spire/pkg/common/plugin/k8s/apiserver"
 	"github.com/spiffe/spire/pkg/server/plugin/nodeattestor"
 	"github.com/spiffe/spire/proto/spire/common"
 	"github.com/spiffe/spire/test/plugintest"
@@ -383,6 +384,106 @@ func (s *AttestorSuite) TestConfigure() {
 			"FOO" = {}
 		}`)
 	s.RequireGRPCStatus(err, codes.InvalidArgument, `cluster "FOO" configuration must have at least one service account allowed`)
+
+	// cluster with both a kubeconfig file and an API server URL
+	err = doConfig(coreConfig, `clusters = {
+			"FOO" = {
+				service_account_allow_list = ["NS1:SA1"]
+				kube_config_file = "path/to/kubeconfig"
+				api_server_url = "https://api-server.example.org"
+			}
+		}`)
+	s.RequireGRPCStatus(err, codes.InvalidArgument, `cluster "FOO" configuration cannot have both kube_config_file and api_server_url`)
+
+	// cluster with API server CA file but no API server URL
+	err = doConfig(coreConfig, `clusters = {
+			"FOO" = {
+				service_account_allow_list = ["NS1:SA1"]
+				api_server_ca_file = "path/to/ca.crt"
+			}
+		}`)
+	s.RequireGRPCStatus(err, codes.InvalidArgument, `cluster "FOO" configuration must have api_server_url to use api_server_ca_file or api_server_token_file`)
+
+	// clusters directory does not exist
+	err = doConfig(coreConfig, fmt.Sprintf(`clusters_dir = %q`, filepath.Join(s.dir, "does-not-exist")))
+	s.RequireGRPCStatusContains(err, codes.InvalidArgument, "unable to read clusters directory")
+
+	// cluster configured in both the clusters directory and the main configuration
+	clustersDir := s.TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(clustersDir, "FOO.conf"), []byte(`service_account_allow_list = ["NS1:SA1"]`), 0600))
+	err = doConfig(coreConfig, fmt.Sprintf(`
+		clusters_dir = %q
+		clusters = {
+			"FOO" = {
+				service_account_allow_list = ["NS1:SA1"]
+			}
+		}`, clustersDir))
+	s.RequireGRPCStatus(err, codes.InvalidArgument, `cluster "FOO" is configured both in the clusters directory and in the main configuration`)
+
+	// malformed cluster configuration in the clusters directory
+	s.Require().NoError(os.WriteFile(filepath.Join(clustersDir, "FOO.conf"), []byte("blah"), 0600))
+	err = doConfig(coreConfig, fmt.Sprintf(`clusters_dir = %q`, clustersDir))
+	s.RequireGRPCStatusContains(err, codes.InvalidArgument, `unable to decode cluster "FOO" configuration`)
+}
+
+func (s *AttestorSuite) TestAttestWithClustersDir() {
+	clustersDir := s.TempDir()
+	now := time.Now()
+
+	attestor := New()
+	attestor.hooks.now = func() time.Time { return now }
+	var newClientCalls []*ClusterConfig
+	attestor.hooks.newClient = func(cluster *ClusterConfig) apiserver.Client {
+		newClientCalls = append(newClientCalls, cluster)
+		return s.apiServerClient
+	}
+	v1 := new(nodeattestor.V1)
+	plugintest.Load(s.T(), builtin(attestor), v1, plugintest.Configure(fmt.Sprintf(`clusters_dir = %q`, clustersDir)),
+		plugintest.CoreConfig(catalog.CoreConfig{
+			TrustDomain: spiffeid.RequireTrustDomainFromString("example.org"),
+		}))
+
+	tokenData := &TokenData{
+		namespace:          "NS3",
+		serviceAccountName: "SA3",
+		podName:            "PODNAME-3",
+		podUID:             "PODUID-3",
+	}
+	token := s.signToken(s.bazSigner, tokenData)
+	s.apiServerClient.SetTokenStatus(token, createTokenStatus(tokenData, true, defaultAudience))
+	s.apiServerClient.SetPod(createPod("NS3", "PODNAME-3", "NODENAME-3", "172.16.10.3"))
+	s.apiServerClient.SetNode(createNode("NODENAME-3", "NODEUID-3"))
+
+	// The cluster is not known yet
+	_, err := v1.Attest(context.Background(), makePayload("BAZ", token), expectNoChallenge)
+	s.RequireGRPCStatus(err, codes.InvalidArgument, `nodeattestor(k8s_psat): not configured for cluster "BAZ"`)
+
+	// The cluster is added, but the directory is not reloaded until the
+	// refresh interval elapses
+	s.Require().NoError(os.WriteFile(filepath.Join(clustersDir, "BAZ.conf"), []byte(`
+		service_account_allow_list = ["NS3:SA3"]
+		api_server_url = "https://baz.example.org"
+		api_server_ca_file = "/path/to/ca.crt"
+		api_server_token_file = "/path/to/token"
+	`), 0600))
+	_, err = v1.Attest(context.Background(), makePayload("BAZ", token), expectNoChallenge)
+	s.RequireGRPCStatus(err, codes.InvalidArgument, `nodeattestor(k8s_psat): not configured for cluster "BAZ"`)
+
+	now = now.Add(clustersDirRefreshInterval)
+	result, err := v1.Attest(context.Background(), makePayload("BAZ", token), expectNoChallenge)
+	s.Require().NoError(err)
+	s.Require().Equal("spiffe://example.org/spire/agent/k8s_psat/BAZ/NODEUID-3", result.AgentID)
+
+	s.Require().Len(newClientCalls, 1)
+	s.Require().Equal("https://baz.example.org", newClientCalls[0].APIServerURL)
+	s.Require().Equal("/path/to/ca.crt", newClientCalls[0].APIServerCAFile)
+	s.Require().Equal("/path/to/token", newClientCalls[0].APIServerTokenFile)
+
+	// Clusters that were loaded keep working if the directory becomes invalid
+	s.Require().NoError(os.WriteFile(filepath.Join(clustersDir, "BAD.conf"), []byte("blah"), 0600))
+	now = now.Add(clustersDirRefreshInterval)
+	_, err = v1.Attest(context.Background(), makePayload("BAZ", token), expectNoChallenge)
+	s.Require().NoError(err)
 }
 
 func (s *AttestorSuite) signToken(signer jose.Signer, tokenData *TokenData) string {
@@ -573,6 +674,20 @@ func (c *fakeAPIServerClient) GetPod(ctx context.Context, namespace, podName str
 	return pod, nil
 }
 
+func (c *fakeAPIServerClient) ListNodePods(ctx context.Context, nodeName string) (*corev1.PodList, error) {
+	podList := new(corev1.PodList)
+	for _, pod := range c.pods {
+		if pod.Spec.NodeName == nodeName {
+			podList.Items = append(podList.Items, *pod)
+		}
+	}
+	return podList, nil
+}
+
+func (c *fakeAPIServerClient) GetNamespace(ctx context.Context, namespace string) (*corev1.Namespace, error) {
+	return nil, fmt.Errorf("namespace %s not found", namespace)
+}
+
 func (c *fakeAPIServerClient) ValidateToken(ctx context.Context, token string, audiences []string) (*authv1.TokenReviewStatus, error) {
 	status, ok := c.status[token]
 	if !ok {
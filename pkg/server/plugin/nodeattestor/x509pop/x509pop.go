@@ -34,14 +34,16 @@ func builtin(p *Plugin) catalog.BuiltIn {
 }
 
 type configuration struct {
-	trustDomain  spiffeid.TrustDomain
-	trustBundle  *x509.CertPool
-	pathTemplate *agentpathtemplate.Template
+	trustDomain   spiffeid.TrustDomain
+	trustBundle   *x509.CertPool
+	intermediates []*x509.Certificate
+	pathTemplate  *agentpathtemplate.Template
 }
 
 type Config struct {
 	CABundlePath      string   `hcl:"ca_bundle_path"`
 	CABundlePaths     []string `hcl:"ca_bundle_paths"`
+	IntermediatesPath string   `hcl:"intermediates_path"`
 	AgentPathTemplate string   `hcl:"agent_path_template"`
 }
 
@@ -86,7 +88,8 @@ func (p *Plugin) Attest(stream nodeattestorv1.NodeAttestor_AttestServer) error {
 	if err != nil {
 		return status.Errorf(codes.InvalidArgument, "unable to parse leaf certificate: %v", err)
 	}
-	intermediates := x509.NewCertPool()
+	// intermediates sent by the agent complete the configured ones
+	intermediates := util.NewCertPool(config.intermediates...)
 	for i, intermediateBytes := range attestationData.Certificates[1:] {
 		intermediate, err := x509.ParseCertificate(intermediateBytes)
 		if err != nil {
@@ -180,6 +183,14 @@ func (p *Plugin) Configure(ctx context.Context, req *configv1.ConfigureRequest)
 		return nil, err
 	}
 
+	var intermediates []*x509.Certificate
+	if hclConfig.IntermediatesPath != "" {
+		intermediates, err = util.LoadCertificates(hclConfig.IntermediatesPath)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unable to load intermediate certificates %q: %v", hclConfig.IntermediatesPath, err)
+		}
+	}
+
 	pathTemplate := x509pop.DefaultAgentPathTemplate
 	if len(hclConfig.AgentPathTemplate) > 0 {
 		tmpl, err := agentpathtemplate.Parse(hclConfig.AgentPathTemplate)
@@ -190,9 +201,10 @@ func (p *Plugin) Configure(ctx context.Context, req *configv1.ConfigureRequest)
 	}
 
 	p.setConfiguration(&configuration{
-		trustDomain:  trustDomain,
-		trustBundle:  util.NewCertPool(bundles...),
-		pathTemplate: pathTemplate,
+		trustDomain:   trustDomain,
+		trustBundle:   util.NewCertPool(bundles...),
+		intermediates: intermediates,
+		pathTemplate:  pathTemplate,
 	})
 
 	return &configv1.ConfigureResponse{}, nil
@@ -31,6 +31,7 @@ type Suite struct {
 	spiretest.Suite
 
 	rootCertPath     string
+	intermediatePath string
 	leafBundle       [][]byte
 	leafKey          crypto.PrivateKey
 	leafCert         *x509.Certificate
@@ -45,6 +46,7 @@ func (s *Suite) SetupTest() {
 	require := s.Require()
 
 	s.rootCertPath = fixture.Join("nodeattestor", "x509pop", "root-crt.pem")
+	s.intermediatePath = fixture.Join("nodeattestor", "x509pop", "intermediate.pem")
 	leafCertPath := fixture.Join("nodeattestor", "x509pop", "leaf-crt-bundle.pem")
 	leafKeyPath := fixture.Join("nodeattestor", "x509pop", "leaf-key.pem")
 
@@ -69,6 +71,7 @@ func (s *Suite) TestAttestSuccess() {
 	tests := []struct {
 		desc          string
 		giveConfig    string
+		giveLeafOnly  bool
 		expectAgentID string
 	}{
 		{
@@ -91,6 +94,12 @@ func (s *Suite) TestAttestSuccess() {
 			expectAgentID: "spiffe://example.org/spire/agent/cn/COMMONNAME",
 			giveConfig:    s.createConfiguration("ca_bundle_paths", `agent_path_template = "/cn/{{ .Subject.CommonName }}"`),
 		},
+		{
+			desc:          "success with configured intermediates",
+			expectAgentID: "spiffe://example.org/spire/agent/x509pop/" + x509pop.Fingerprint(s.leafCert),
+			giveConfig:    s.createConfiguration("ca_bundle_path", fmt.Sprintf("intermediates_path = %q", s.intermediatePath)),
+			giveLeafOnly:  true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -101,6 +110,9 @@ func (s *Suite) TestAttestSuccess() {
 			attestationData := &x509pop.AttestationData{
 				Certificates: s.leafBundle,
 			}
+			if tt.giveLeafOnly {
+				attestationData.Certificates = s.leafBundle[:1]
+			}
 			payload := marshal(t, attestationData)
 
 			challengeFn := func(ctx context.Context, challenge []byte) ([]byte, error) {
@@ -265,6 +277,11 @@ func (s *Suite) TestConfigure() {
 
 		spiretest.RequireGRPCStatusContains(t, err, codes.InvalidArgument, "unable to load trust bundle")
 	})
+
+	s.T().Run("bad intermediates_path", func(t *testing.T) {
+		err := doConfig(t, coreConfig, s.createConfiguration("ca_bundle_path", `intermediates_path = "blah"`))
+		spiretest.RequireGRPCStatusContains(t, err, codes.InvalidArgument, "unable to load intermediate certificates")
+	})
 }
 
 func (s *Suite) loadPlugin(t *testing.T, config string) nodeattestor.NodeAttestor {
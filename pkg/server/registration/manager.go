@@ -13,6 +13,19 @@ import (
 
 const (
 	_pruningCandence = 5 * time.Minute
+
+	// _attestationFailureRetention is how long node attestation failures
+	// are kept for troubleshooting before being pruned
+	_attestationFailureRetention = 24 * time.Hour
+
+	// DefaultAttestationEventRetention is how long successful node
+	// attestations are kept before being pruned, by default
+	DefaultAttestationEventRetention = 30 * 24 * time.Hour
+
+	// DefaultIssuanceRecordRetention is how long the records of the X509
+	// certificates signed by the server CA are kept after the certificates
+	// expire, by default
+	DefaultIssuanceRecordRetention = 30 * 24 * time.Hour
 )
 
 // ManagerConfig is the config for the registration manager
@@ -23,6 +36,16 @@ type ManagerConfig struct {
 	Metrics telemetry.Metrics
 
 	Clock clock.Clock
+
+	// AttestationEventRetention is how long successful node attestations
+	// are kept before being pruned. Failed attestations are kept for a day
+	// at most. Defaults to DefaultAttestationEventRetention.
+	AttestationEventRetention time.Duration
+
+	// IssuanceRecordRetention is how long the records of the X509
+	// certificates signed by the server CA are kept after the certificates
+	// expire. Defaults to DefaultIssuanceRecordRetention.
+	IssuanceRecordRetention time.Duration
 }
 
 // Manager is the manager of registrations
@@ -37,6 +60,12 @@ func NewManager(c ManagerConfig) *Manager {
 	if c.Clock == nil {
 		c.Clock = clock.New()
 	}
+	if c.AttestationEventRetention <= 0 {
+		c.AttestationEventRetention = DefaultAttestationEventRetention
+	}
+	if c.IssuanceRecordRetention <= 0 {
+		c.IssuanceRecordRetention = DefaultIssuanceRecordRetention
+	}
 
 	return &Manager{
 		c:       c,
@@ -58,19 +87,47 @@ func (m *Manager) pruneEvery(ctx context.Context) error {
 		select {
 		case <-ticker.C:
 			// Log an error on failure unless we're shutting down
-			if err := m.prune(ctx); err != nil && ctx.Err() == nil {
+			if err := m.pruneEntries(ctx); err != nil && ctx.Err() == nil {
 				m.log.WithError(err).Error("Failed pruning registration entries")
 			}
+			if err := m.pruneAttestationEvents(ctx); err != nil && ctx.Err() == nil {
+				m.log.WithError(err).Error("Failed pruning attestation events")
+			}
+			if err := m.pruneIssuanceRecords(ctx); err != nil && ctx.Err() == nil {
+				m.log.WithError(err).Error("Failed pruning issuance records")
+			}
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
-func (m *Manager) prune(ctx context.Context) (err error) {
+func (m *Manager) pruneEntries(ctx context.Context) (err error) {
 	counter := telemetry_server.StartRegistrationManagerPruneEntryCall(m.c.Metrics)
 	defer counter.Done(&err)
 
 	err = m.c.DataStore.PruneRegistrationEntries(ctx, m.c.Clock.Now())
 	return err
 }
+
+func (m *Manager) pruneAttestationEvents(ctx context.Context) (err error) {
+	counter := telemetry_server.StartRegistrationManagerPruneAttestationEventCall(m.c.Metrics)
+	defer counter.Done(&err)
+
+	now := m.c.Clock.Now()
+	succeededBefore := now.Add(-m.c.AttestationEventRetention)
+	failedBefore := now.Add(-_attestationFailureRetention)
+	if failedBefore.Before(succeededBefore) {
+		failedBefore = succeededBefore
+	}
+	err = m.c.DataStore.PruneAttestationEvents(ctx, succeededBefore, failedBefore)
+	return err
+}
+
+func (m *Manager) pruneIssuanceRecords(ctx context.Context) (err error) {
+	counter := telemetry_server.StartRegistrationManagerPruneIssuanceRecordCall(m.c.Metrics)
+	defer counter.Done(&err)
+
+	err = m.c.DataStore.PruneIssuanceRecords(ctx, m.c.Clock.Now().Add(-m.c.IssuanceRecordRetention))
+	return err
+}
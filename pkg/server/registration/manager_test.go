@@ -7,6 +7,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/spiffe/spire/pkg/common/telemetry"
 	"github.com/spiffe/spire/pkg/server/datastore"
 	"github.com/spiffe/spire/proto/spire/common"
 	"github.com/spiffe/spire/test/clock"
@@ -99,33 +100,116 @@ func (s *ManagerSuite) TestPruning() {
 	s.NoError(err)
 
 	// no pruning yet
-	s.NoError(s.m.prune(context.Background()))
+	s.NoError(s.m.pruneEntries(context.Background()))
 	listResp, err := s.ds.ListRegistrationEntries(context.Background(), &datastore.ListRegistrationEntriesRequest{})
 	s.NoError(err)
 	s.Equal([]*common.RegistrationEntry{registrationEntry1, registrationEntry2, registrationEntry3}, listResp.Entries)
 
 	// prune first entry
 	s.clock.Add(_pruningCandence + time.Second)
-	s.NoError(s.m.prune(context.Background()))
+	s.NoError(s.m.pruneEntries(context.Background()))
 	listResp, err = s.ds.ListRegistrationEntries(context.Background(), &datastore.ListRegistrationEntriesRequest{})
 	s.NoError(err)
 	s.Equal([]*common.RegistrationEntry{registrationEntry2, registrationEntry3}, listResp.Entries)
 
 	// prune second entry
 	s.clock.Add(time.Minute)
-	s.NoError(s.m.prune(context.Background()))
+	s.NoError(s.m.pruneEntries(context.Background()))
 	listResp, err = s.ds.ListRegistrationEntries(context.Background(), &datastore.ListRegistrationEntriesRequest{})
 	s.NoError(err)
 	s.Equal([]*common.RegistrationEntry{registrationEntry3}, listResp.Entries)
 
 	// prune third entry
 	s.clock.Add(time.Minute)
-	s.NoError(s.m.prune(context.Background()))
+	s.NoError(s.m.pruneEntries(context.Background()))
 	listResp, err = s.ds.ListRegistrationEntries(context.Background(), &datastore.ListRegistrationEntriesRequest{})
 	s.NoError(err)
 	s.Empty(listResp.Entries)
 }
 
+func (s *ManagerSuite) TestPruningAttestationFailures() {
+	done := s.setupAndRunManager()
+	defer done()
+
+	failure := &datastore.AttestationEvent{
+		AttestationType: "join_token",
+		Error:           "failed to attest: join token does not exist or has already been used",
+		AttestedAt:      s.clock.Now(),
+	}
+	s.Require().NoError(s.ds.CreateAttestationEvent(context.Background(), failure))
+
+	// not old enough to be pruned
+	s.clock.Add(_attestationFailureRetention)
+	s.NoError(s.m.pruneAttestationEvents(context.Background()))
+	listResp, err := s.ds.ListAttestationEvents(context.Background(), &datastore.ListAttestationEventsRequest{})
+	s.NoError(err)
+	s.Len(listResp.Events, 1)
+
+	s.clock.Add(time.Second)
+	s.NoError(s.m.pruneAttestationEvents(context.Background()))
+	listResp, err = s.ds.ListAttestationEvents(context.Background(), &datastore.ListAttestationEventsRequest{})
+	s.NoError(err)
+	s.Empty(listResp.Events)
+}
+
+func (s *ManagerSuite) TestPruningAttestationEvents() {
+	done := s.setupAndRunManager()
+	defer done()
+
+	success := &datastore.AttestationEvent{
+		SpiffeID:        "spiffe://test.test/spire/agent/join_token/token",
+		AttestationType: "join_token",
+		SerialNumber:    "1",
+		AttestedAt:      s.clock.Now(),
+	}
+	s.Require().NoError(s.ds.CreateAttestationEvent(context.Background(), success))
+
+	// not old enough to be pruned
+	s.clock.Add(DefaultAttestationEventRetention)
+	s.NoError(s.m.pruneAttestationEvents(context.Background()))
+	listResp, err := s.ds.ListAttestationEvents(context.Background(), &datastore.ListAttestationEventsRequest{})
+	s.NoError(err)
+	s.Len(listResp.Events, 1)
+
+	s.clock.Add(time.Second)
+	s.NoError(s.m.pruneAttestationEvents(context.Background()))
+	listResp, err = s.ds.ListAttestationEvents(context.Background(), &datastore.ListAttestationEventsRequest{})
+	s.NoError(err)
+	s.Empty(listResp.Events)
+
+	// pruning is measured apart from registration entry pruning
+	var keys [][]string
+	for _, metric := range s.metrics.AllMetrics() {
+		keys = append(keys, metric.Key)
+	}
+	s.Contains(keys, []string{telemetry.AttestationEvent, telemetry.Manager, telemetry.Prune})
+}
+
+func (s *ManagerSuite) TestPruningIssuanceRecords() {
+	done := s.setupAndRunManager()
+	defer done()
+
+	record := &datastore.IssuanceRecord{
+		SerialNumber: "1",
+		SpiffeID:     "spiffe://test.test/workload",
+		ExpiresAt:    s.clock.Now(),
+	}
+	s.Require().NoError(s.ds.CreateIssuanceRecord(context.Background(), record))
+
+	// not expired for long enough to be pruned
+	s.clock.Add(DefaultIssuanceRecordRetention)
+	s.NoError(s.m.pruneIssuanceRecords(context.Background()))
+	listResp, err := s.ds.ListIssuanceRecords(context.Background(), &datastore.ListIssuanceRecordsRequest{})
+	s.NoError(err)
+	s.Len(listResp.Records, 1)
+
+	s.clock.Add(time.Second)
+	s.NoError(s.m.pruneIssuanceRecords(context.Background()))
+	listResp, err = s.ds.ListIssuanceRecords(context.Background(), &datastore.ListIssuanceRecordsRequest{})
+	s.NoError(err)
+	s.Empty(listResp.Records)
+}
+
 func (s *ManagerSuite) setupAndRunManager() func() {
 	s.m = NewManager(ManagerConfig{
 		Clock:     s.clock,
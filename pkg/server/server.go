@@ -48,6 +48,29 @@ const (
 
 type Server struct {
 	config Config
+
+	drainOnce sync.Once
+	drain     chan struct{}
+}
+
+// Drain puts the server in drain mode for rolling restarts: it reports
+// itself as not ready, stops accepting new connections on the server APIs
+// so agents move to other servers, waits for in-flight RPCs to finish, and
+// then shuts down.
+func (s *Server) Drain() {
+	s.drainOnce.Do(func() {
+		s.config.Log.Info("Draining server")
+		close(s.drain)
+	})
+}
+
+func (s *Server) isDraining() bool {
+	select {
+	case <-s.drain:
+		return true
+	default:
+		return false
+	}
 }
 
 // Run the server
@@ -116,7 +139,7 @@ func (s *Server) run(ctx context.Context) (err error) {
 		return err
 	}
 
-	serverCA := s.newCA(metrics, healthChecker)
+	serverCA := s.newCA(metrics, healthChecker, cat.GetDataStore())
 
 	// CA manager needs to be initialized before the rotator, otherwise the
 	// server CA plugin won't be able to sign CSRs
@@ -179,6 +202,19 @@ func (s *Server) run(ctx context.Context) (err error) {
 		registrationManager.Run,
 		util.SerialRun(s.waitForTestDial, healthChecker.ListenAndServe),
 		scanForBadEntries(s.config.Log, metrics, cat.GetDataStore()),
+		s.newBundleMetricsReporter(cat, metrics, bundleManager).Run,
+	}
+
+	if s.config.CABackup.Dir != "" {
+		tasks = append(tasks, s.newCABackup(cat).Run)
+	}
+
+	if s.config.AgentEviction.MaxAgents > 0 {
+		tasks = append(tasks, s.newAgentEvictor(cat, metrics).Run)
+	}
+
+	if !s.config.EntryMirror.PeerTrustDomain.IsZero() {
+		tasks = append(tasks, s.newEntryMirror(cat, metrics).Run)
 	}
 
 	if s.config.LogReopener != nil {
@@ -186,7 +222,11 @@ func (s *Server) run(ctx context.Context) (err error) {
 	}
 
 	err = util.RunTasks(ctx, tasks...)
-	if errors.Is(err, context.Canceled) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		err = nil
+	case errors.Is(err, endpoints.ErrDrained):
+		s.config.Log.Info("Server drained")
 		err = nil
 	}
 	return err
@@ -262,15 +302,24 @@ func (s *Server) loadCatalog(ctx context.Context, metrics telemetry.Metrics, ide
 	})
 }
 
-func (s *Server) newCA(metrics telemetry.Metrics, healthChecker health.Checker) *ca.CA {
+func (s *Server) newCA(metrics telemetry.Metrics, healthChecker health.Checker, ds datastore.DataStore) *ca.CA {
+	var issuanceLog ca.IssuanceLog
+	if s.config.RecordX509Issuances {
+		issuanceLog = ds
+	}
+
 	return ca.NewCA(ca.Config{
-		Metrics:         metrics,
-		X509SVIDTTL:     s.config.SVIDTTL,
-		JWTIssuer:       s.config.JWTIssuer,
-		TrustDomain:     s.config.TrustDomain,
-		CASubject:       s.config.CASubject,
-		HealthChecker:   healthChecker,
-		OmitX509SVIDUID: s.config.OmitX509SVIDUID,
+		Metrics:            metrics,
+		X509SVIDTTL:        s.config.SVIDTTL,
+		JWTIssuer:          s.config.JWTIssuer,
+		TrustDomain:        s.config.TrustDomain,
+		CASubject:          s.config.CASubject,
+		HealthChecker:      healthChecker,
+		OmitX509SVIDUID:    s.config.OmitX509SVIDUID,
+		MaxDownstreamDepth: s.config.MaxDownstreamDepth,
+		SerialNumberPolicy: s.config.CASerialNumberPolicy,
+		IssuanceLog:        issuanceLog,
+		StagedRollout:      s.config.CAStagedRollout,
 	})
 }
 
@@ -299,6 +348,9 @@ func (s *Server) newRegistrationManager(cat catalog.Catalog, metrics telemetry.M
 		DataStore: cat.GetDataStore(),
 		Log:       s.config.Log.WithField(telemetry.SubsystemName, telemetry.RegistrationManager),
 		Metrics:   metrics,
+
+		AttestationEventRetention: s.config.AttestationEventRetention,
+		IssuanceRecordRetention:   s.config.IssuanceRecordRetention,
 	})
 	return registrationManager
 }
@@ -317,7 +369,7 @@ func (s *Server) newSVIDRotator(ctx context.Context, serverCA ca.ServerCA, metri
 	return svidRotator, nil
 }
 
-func (s *Server) newEndpointsServer(ctx context.Context, catalog catalog.Catalog, svidObserver svid.Observer, serverCA ca.ServerCA, metrics telemetry.Metrics, caManager *ca.Manager, authPolicyEngine *authpolicy.Engine, bundleManager *bundle_client.Manager) (endpoints.Server, error) {
+func (s *Server) newEndpointsServer(ctx context.Context, catalog catalog.Catalog, svidObserver svid.Observer, serverCA *ca.CA, metrics telemetry.Metrics, caManager *ca.Manager, authPolicyEngine *authpolicy.Engine, bundleManager *bundle_client.Manager) (endpoints.Server, error) {
 	config := endpoints.Config{
 		TCPAddr:             s.config.BindAddress,
 		LocalAddr:           s.config.BindLocalAddress,
@@ -337,11 +389,29 @@ func (s *Server) newEndpointsServer(ctx context.Context, catalog catalog.Catalog
 		AuthPolicyEngine:    authPolicyEngine,
 		BundleManager:       bundleManager,
 		AdminIDs:            s.config.AdminIDs,
+		RESTGatewayAddr:     s.config.RESTGatewayAddr,
+		MaxAgentClockSkew:   s.config.MaxAgentClockSkew,
+
+		AgentSelectorWarningThreshold: s.config.AgentSelectorWarningThreshold,
+		AgentPathTemplates:            s.config.AgentPathTemplates,
+		ApprovalRequiredAttestorTypes: s.config.ApprovalRequiredAttestorTypes,
+		CSRKeyPolicy:                  s.config.CSRKeyPolicy,
+		EntryAdmissionWebhook:         s.config.EntryAdmissionWebhook,
+		Drain:                         s.drain,
+		DrainDelay:                    s.config.DrainDelay,
+		DrainTimeout:                  s.config.DrainTimeout,
 	}
 	if s.config.Federation.BundleEndpoint != nil {
 		config.BundleEndpoint.Address = s.config.Federation.BundleEndpoint.Address
 		config.BundleEndpoint.ACME = s.config.Federation.BundleEndpoint.ACME
 	}
+	if s.config.OCSPResponder != nil {
+		config.OCSPResponder = s.config.OCSPResponder
+		config.X509CASource = serverCA
+	}
+	if s.config.EventBuffer != nil {
+		config.Events = s.config.EventBuffer
+	}
 	return endpoints.New(ctx, config)
 }
 
@@ -358,6 +428,29 @@ func (s *Server) newBundleManager(cat catalog.Catalog, metrics telemetry.Metrics
 	})
 }
 
+func (s *Server) newBundleMetricsReporter(cat catalog.Catalog, metrics telemetry.Metrics, bundleManager *bundle_client.Manager) *bundleMetricsReporter {
+	log := s.config.Log.WithField(telemetry.SubsystemName, "bundle_metrics")
+	return newBundleMetricsReporter(log, metrics, cat.GetDataStore(), bundleManager, clock.New())
+}
+
+func (s *Server) newCABackup(cat catalog.Catalog) *caBackup {
+	log := s.config.Log.WithField(telemetry.SubsystemName, "ca_backup")
+	return newCABackup(s.config.CABackup, log, cat.GetDataStore(), s.config.TrustDomain, ca.JournalPath(s.config.DataDir), clock.New())
+}
+
+func (s *Server) newAgentEvictor(cat catalog.Catalog, metrics telemetry.Metrics) *agentEvictor {
+	log := s.config.Log.WithField(telemetry.SubsystemName, telemetry.AgentEvictor)
+	return newAgentEvictor(s.config.AgentEviction, log, metrics, cat.GetDataStore(), clock.New())
+}
+
+func (s *Server) newEntryMirror(cat catalog.Catalog, metrics telemetry.Metrics) *entryMirror {
+	log := s.config.Log.WithFields(logrus.Fields{
+		telemetry.SubsystemName: telemetry.EntryMirror,
+		telemetry.TrustDomainID: s.config.EntryMirror.PeerTrustDomain.IDString(),
+	})
+	return newEntryMirror(s.config.EntryMirror, log, metrics, cat.GetDataStore(), s.config.TrustDomain, clock.New())
+}
+
 func (s *Server) validateTrustDomain(ctx context.Context, ds datastore.DataStore) error {
 	trustDomain := s.config.TrustDomain.String()
 
@@ -419,16 +512,20 @@ func (s *Server) waitForTestDial(ctx context.Context) error {
 // CheckHealth is used as a top-level health check for the Server.
 func (s *Server) CheckHealth() health.State {
 	err := s.tryGetBundle()
+	draining := s.isDraining()
 
 	// The API is served only after the server CA has been
 	// signed by upstream. Hence, both live and ready checks
 	// are determined by whether the bundles are received or not.
+	// A draining server is live but no longer ready, so that
+	// load balancers stop routing agents to it.
 	// TODO: Better live check for server.
 	return health.State{
-		Ready: err == nil,
+		Ready: err == nil && !draining,
 		Live:  err == nil,
 		ReadyDetails: serverHealthDetails{
 			GetBundleErr: errString(err),
+			Draining:     draining,
 		},
 		LiveDetails: serverHealthDetails{
 			GetBundleErr: errString(err),
@@ -457,6 +554,7 @@ func (s *Server) tryGetBundle() error {
 
 type serverHealthDetails struct {
 	GetBundleErr string `json:"get_bundle_err,omitempty"`
+	Draining     bool   `json:"draining,omitempty"`
 }
 
 func errString(err error) string {
@@ -234,6 +234,13 @@ func (s *DataStore) UpdateRegistrationEntry(ctx context.Context, entry *common.R
 	return s.ds.UpdateRegistrationEntry(ctx, entry, mask)
 }
 
+func (s *DataStore) CompareAndUpdateRegistrationEntry(ctx context.Context, entry *common.RegistrationEntry, mask *common.RegistrationEntryMask, expectedRevision int64) (*common.RegistrationEntry, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.CompareAndUpdateRegistrationEntry(ctx, entry, mask, expectedRevision)
+}
+
 func (s *DataStore) DeleteRegistrationEntry(ctx context.Context, entryID string) (*common.RegistrationEntry, error) {
 	if err := s.getNextError(); err != nil {
 		return nil, err
@@ -311,6 +318,160 @@ func (s *DataStore) UpdateFederationRelationship(ctx context.Context, fr *datast
 	return s.ds.UpdateFederationRelationship(ctx, fr, mask)
 }
 
+func (s *DataStore) DeleteACMECacheEntry(ctx context.Context, key string) error {
+	if err := s.getNextError(); err != nil {
+		return err
+	}
+	return s.ds.DeleteACMECacheEntry(ctx, key)
+}
+
+func (s *DataStore) FetchACMECacheEntry(ctx context.Context, key string) ([]byte, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.FetchACMECacheEntry(ctx, key)
+}
+
+func (s *DataStore) SetACMECacheEntry(ctx context.Context, key string, data []byte) error {
+	if err := s.getNextError(); err != nil {
+		return err
+	}
+	return s.ds.SetACMECacheEntry(ctx, key, data)
+}
+
+func (s *DataStore) CreateAttestationEvent(ctx context.Context, event *datastore.AttestationEvent) error {
+	if err := s.getNextError(); err != nil {
+		return err
+	}
+	return s.ds.CreateAttestationEvent(ctx, event)
+}
+
+func (s *DataStore) ListAttestationEvents(ctx context.Context, req *datastore.ListAttestationEventsRequest) (*datastore.ListAttestationEventsResponse, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.ListAttestationEvents(ctx, req)
+}
+
+func (s *DataStore) PruneAttestationEvents(ctx context.Context, succeededBefore, failedBefore time.Time) error {
+	if err := s.getNextError(); err != nil {
+		return err
+	}
+	return s.ds.PruneAttestationEvents(ctx, succeededBefore, failedBefore)
+}
+
+func (s *DataStore) DeleteMirroredEntry(ctx context.Context, entryID string) error {
+	if err := s.getNextError(); err != nil {
+		return err
+	}
+	return s.ds.DeleteMirroredEntry(ctx, entryID)
+}
+
+func (s *DataStore) ListMirroredEntries(ctx context.Context) ([]*datastore.MirroredEntry, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.ListMirroredEntries(ctx)
+}
+
+func (s *DataStore) SetMirroredEntry(ctx context.Context, entry *datastore.MirroredEntry) error {
+	if err := s.getNextError(); err != nil {
+		return err
+	}
+	return s.ds.SetMirroredEntry(ctx, entry)
+}
+
+func (s *DataStore) ApprovePendingAgent(ctx context.Context, spiffeID string) (*datastore.PendingAgent, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.ApprovePendingAgent(ctx, spiffeID)
+}
+
+func (s *DataStore) DeletePendingAgent(ctx context.Context, spiffeID string) error {
+	if err := s.getNextError(); err != nil {
+		return err
+	}
+	return s.ds.DeletePendingAgent(ctx, spiffeID)
+}
+
+func (s *DataStore) FetchPendingAgent(ctx context.Context, spiffeID string) (*datastore.PendingAgent, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.FetchPendingAgent(ctx, spiffeID)
+}
+
+func (s *DataStore) ListPendingAgents(ctx context.Context, req *datastore.ListPendingAgentsRequest) (*datastore.ListPendingAgentsResponse, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.ListPendingAgents(ctx, req)
+}
+
+func (s *DataStore) SetPendingAgent(ctx context.Context, agent *datastore.PendingAgent) (*datastore.PendingAgent, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.SetPendingAgent(ctx, agent)
+}
+
+func (s *DataStore) CreateIssuanceRecord(ctx context.Context, record *datastore.IssuanceRecord) error {
+	if err := s.getNextError(); err != nil {
+		return err
+	}
+	return s.ds.CreateIssuanceRecord(ctx, record)
+}
+
+func (s *DataStore) ListIssuanceRecords(ctx context.Context, req *datastore.ListIssuanceRecordsRequest) (*datastore.ListIssuanceRecordsResponse, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.ListIssuanceRecords(ctx, req)
+}
+
+func (s *DataStore) PruneIssuanceRecords(ctx context.Context, expiredBefore time.Time) error {
+	if err := s.getNextError(); err != nil {
+		return err
+	}
+	return s.ds.PruneIssuanceRecords(ctx, expiredBefore)
+}
+
+func (s *DataStore) CreateSelectorSet(ctx context.Context, set *datastore.SelectorSet) (*datastore.SelectorSet, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.CreateSelectorSet(ctx, set)
+}
+
+func (s *DataStore) DeleteSelectorSet(ctx context.Context, name string) error {
+	if err := s.getNextError(); err != nil {
+		return err
+	}
+	return s.ds.DeleteSelectorSet(ctx, name)
+}
+
+func (s *DataStore) FetchSelectorSet(ctx context.Context, name string) (*datastore.SelectorSet, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.FetchSelectorSet(ctx, name)
+}
+
+func (s *DataStore) ListSelectorSets(ctx context.Context) ([]*datastore.SelectorSet, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.ListSelectorSets(ctx)
+}
+
+func (s *DataStore) UpdateSelectorSet(ctx context.Context, set *datastore.SelectorSet) (*datastore.SelectorSet, error) {
+	if err := s.getNextError(); err != nil {
+		return nil, err
+	}
+	return s.ds.UpdateSelectorSet(ctx, set)
+}
+
 func (s *DataStore) SetNextError(err error) {
 	s.errs = []error{err}
 }
@@ -9,6 +9,8 @@ import (
 	"github.com/spiffe/spire/pkg/agent/plugin/workloadattestor"
 	"github.com/spiffe/spire/pkg/common/catalog"
 	"github.com/spiffe/spire/test/plugintest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func New(t *testing.T, name string, pids map[int32][]string) workloadattestor.WorkloadAttestor {
@@ -20,13 +22,32 @@ func New(t *testing.T, name string, pids map[int32][]string) workloadattestor.Wo
 	return wa
 }
 
+// NewDenier returns a workload attestor that denies the PIDs in the given map
+// with the mapped reason and attests any other PID with no selectors.
+func NewDenier(t *testing.T, name string, reasons map[int32]string) workloadattestor.WorkloadAttestor {
+	server := workloadattestorv1.WorkloadAttestorPluginServer(&workloadAttestor{
+		reasons: reasons,
+	})
+	wa := new(workloadattestor.V1)
+	plugintest.Load(t, catalog.MakeBuiltIn(name, server), wa)
+	return wa
+}
+
 type workloadAttestor struct {
 	workloadattestorv1.UnimplementedWorkloadAttestorServer
 
-	pids map[int32][]string
+	pids    map[int32][]string
+	reasons map[int32]string
 }
 
 func (p *workloadAttestor) Attest(ctx context.Context, req *workloadattestorv1.AttestRequest) (*workloadattestorv1.AttestResponse, error) {
+	if p.reasons != nil {
+		if reason, ok := p.reasons[req.Pid]; ok {
+			return nil, status.Error(codes.PermissionDenied, reason)
+		}
+		return &workloadattestorv1.AttestResponse{}, nil
+	}
+
 	s, ok := p.pids[req.Pid]
 	if !ok {
 		return nil, fmt.Errorf("cannot attest pid %d", req.Pid)